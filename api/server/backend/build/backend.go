@@ -10,6 +10,7 @@ import (
 	"github.com/docker/docker/builder"
 	buildkit "github.com/docker/docker/builder/builder-next"
 	"github.com/docker/docker/builder/fscache"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/pkg/errors"
@@ -20,6 +21,7 @@ import (
 type ImageComponent interface {
 	SquashImage(from string, to string) (string, error)
 	TagImageWithReference(image.ID, reference.Named) error
+	ImagePoliciesEnforced() bool
 }
 
 // Builder defines interface for running a build
@@ -52,6 +54,11 @@ func (b *Backend) Build(ctx context.Context, config backend.BuildConfig) (string
 
 	var build *builder.Result
 	if useBuildKit {
+		// BuildKit resolves the images builds are FROM itself, so these
+		// images cannot be checked against the image policies.
+		if b.imageComponent.ImagePoliciesEnforced() {
+			return "", errdefs.Forbidden(errors.New("BuildKit builds are not supported while an image policy is enforced"))
+		}
 		build, err = b.buildkit.Build(ctx, config)
 		if err != nil {
 			return "", err
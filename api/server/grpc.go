@@ -0,0 +1,173 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+// http2Preface is the preface the HTTP/2 clients start their connections
+// with. The gRPC clients connect to the sockets without TLS with HTTP/2 with
+// prior knowledge.
+const http2Preface = http2.ClientPreface
+
+// detectTimeout is the time the clients have to complete the TLS handshake,
+// or to send the preface of their connection, for their protocol to be
+// detected.
+const detectTimeout = 30 * time.Second
+
+// grpcHandler returns a handler serving the gRPC calls with g, and the other
+// requests with h.
+func grpcHandler(g, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			g.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// http2Listener is a listener accepting the HTTP/1 connections of the wrapped
+// listener, its HTTP/2 connections being served by serve. The HTTP/2
+// connections are the connections starting with the HTTP/2 preface, or,
+// with TLS, negotiating the h2 protocol.
+type http2Listener struct {
+	net.Listener
+	serve func(net.Conn)
+
+	conns     chan net.Conn
+	errs      chan error
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newHTTP2Listener(l net.Listener, serve func(net.Conn)) *http2Listener {
+	hl := &http2Listener{
+		Listener: l,
+		serve:    serve,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error),
+		closed:   make(chan struct{}),
+	}
+	go hl.acceptConns()
+	return hl
+}
+
+func (l *http2Listener) acceptConns() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			select {
+			case l.errs <- err:
+			case <-l.closed:
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		go l.detect(conn)
+	}
+}
+
+// detect serves the HTTP/2 connections, and passes the HTTP/1 connections to
+// Accept.
+func (l *http2Listener) detect(conn net.Conn) {
+	if tc, ok := conn.(*tls.Conn); ok {
+		tc.SetDeadline(time.Now().Add(detectTimeout))
+		err := tc.Handshake()
+		tc.SetDeadline(time.Time{})
+		if err != nil {
+			logrus.Debugf("TLS handshake error from %s: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			return
+		}
+		if tc.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+			l.serve(conn)
+			return
+		}
+		l.push(conn)
+		return
+	}
+
+	br := bufio.NewReader(conn)
+	bc := &bufferedConn{Conn: conn, r: br}
+	conn.SetReadDeadline(time.Now().Add(detectTimeout))
+	for i := 1; i <= len(http2Preface); i++ {
+		b, err := br.Peek(i)
+		if err != nil || b[i-1] != http2Preface[i-1] {
+			conn.SetReadDeadline(time.Time{})
+			l.push(bc)
+			return
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+	l.serve(bc)
+}
+
+func (l *http2Listener) push(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+// Accept waits for and returns the next HTTP/1 connection to the listener.
+func (l *http2Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: l.Addr().Network(), Addr: l.Addr(), Err: errClosing}
+	}
+}
+
+// Close closes the listener.
+func (l *http2Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return l.Listener.Close()
+}
+
+type closingError struct{}
+
+func (closingError) Error() string {
+	return "use of closed network connection"
+}
+
+var errClosing = closingError{}
+
+// bufferedConn is a connection whose first bytes were read to detect its
+// protocol.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// CloseWrite closes the writing side of the connection, or the connection
+// when it does not support it.
+func (c *bufferedConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface {
+		CloseWrite() error
+	}); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,64 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/server/router"
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types"
+	"google.golang.org/grpc"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type testRouter struct {
+	routes []router.Route
+}
+
+func (r testRouter) Routes() []router.Route {
+	return r.routes
+}
+
+func TestServeGRPC(t *testing.T) {
+	srv := New(&Config{GRPC: true})
+	var userAgents []string
+	srv.InitRouter(testRouter{routes: []router.Route{
+		router.NewGetRoute("/containers/json", func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			userAgents = append(userAgents, r.Header.Get("User-Agent"))
+			return httputils.WriteJSON(w, http.StatusOK, []types.Container{{ID: "c1"}})
+		}),
+	}})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	srv.Accept("", l)
+	go srv.serveAPI()
+	defer srv.Close()
+
+	// the REST API and the gRPC API are served on the same socket
+	req, err := http.NewRequest("GET", "http://"+l.Addr().String()+"/containers/json", nil)
+	assert.NilError(t, err)
+	req.Header.Set("User-Agent", "rest")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	var containers []types.Container
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&containers))
+	assert.Check(t, is.Len(containers, 1))
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure(), grpc.WithUserAgent("grpc"))
+	assert.NilError(t, err)
+	defer conn.Close()
+	list, err := engine.NewEngineClient(conn).ListContainers(context.Background(), &engine.ListContainersRequest{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(list.Containers, 1))
+	assert.Check(t, is.Equal(list.Containers[0].ID, "c1"))
+
+	assert.Assert(t, is.Len(userAgents, 2))
+	assert.Check(t, is.Equal(userAgents[0], "rest"))
+	assert.Check(t, is.Contains(userAgents[1], "grpc"))
+}
@@ -0,0 +1,243 @@
+package httputils // import "github.com/docker/docker/api/server/httputils"
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+// fieldSet is a set of the paths of the fields of a value, each field mapping
+// to the set of its fields to select, or to nil when the whole field is
+// selected.
+type fieldSet map[string]fieldSet
+
+// parseFields parses a comma-separated list of dot-separated paths of fields,
+// such as "State.Health,NetworkSettings.Ports".
+func parseFields(s string) (fieldSet, error) {
+	set := fieldSet{}
+	for _, path := range strings.Split(s, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		names := strings.Split(path, ".")
+		cur := set
+		for i, name := range names {
+			if name == "" {
+				return nil, errors.Errorf("invalid field path: %q", path)
+			}
+			next, ok := cur[name]
+			if ok && next == nil {
+				// the whole field is already selected
+				break
+			}
+			if i == len(names)-1 {
+				cur[name] = nil
+				break
+			}
+			if !ok {
+				next = fieldSet{}
+				cur[name] = next
+			}
+			cur = next
+		}
+	}
+	return set, nil
+}
+
+// SelectFields returns the value v restricted to the fields of the "fields"
+// query parameter of the request, a comma-separated list of the dot-separated
+// paths of the fields in the json encoding of v, or v if the parameter is not
+// set. The paths apply to the elements of the slices, so that they select the
+// fields of each item of the lists.
+func SelectFields(r *http.Request, v interface{}) (interface{}, error) {
+	fields := r.FormValue("fields")
+	if fields == "" {
+		return v, nil
+	}
+	set, err := parseFields(fields)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+	if len(set) == 0 {
+		return v, nil
+	}
+	selected, err := selectFields(reflect.ValueOf(v), set, "")
+	if err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+	return selected, nil
+}
+
+func selectFields(v reflect.Value, set fieldSet, path string) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := jsonFields(v.Type())
+		out := make(map[string]interface{}, len(set))
+		for name, sub := range set {
+			f, ok := fields[name]
+			if !ok {
+				return nil, errors.Errorf("unknown field: %s", path+name)
+			}
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			if sub == nil {
+				out[name] = fv.Interface()
+				continue
+			}
+			selected, err := selectFields(fv, sub, path+name+".")
+			if err != nil {
+				return nil, err
+			}
+			out[name] = selected
+		}
+		return out, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, len(set))
+		for name, sub := range set {
+			// the keys of the maps are not known in advance, the missing ones
+			// are omitted
+			mv := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !mv.IsValid() {
+				continue
+			}
+			if sub == nil {
+				out[name] = mv.Interface()
+				continue
+			}
+			selected, err := selectFields(mv, sub, path+name+".")
+			if err != nil {
+				return nil, err
+			}
+			out[name] = selected
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			selected, err := selectFields(v.Index(i), set, path)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = selected
+		}
+		return out, nil
+	}
+
+	for name := range set {
+		return nil, errors.Errorf("unknown field: %s", path+name)
+	}
+	return nil, nil
+}
+
+type jsonField struct {
+	index     []int
+	omitEmpty bool
+}
+
+// jsonFields returns the fields of the json encoding of the values of a
+// struct type by name, the fields of the embedded structs being promoted as
+// they are by encoding/json.
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	var embedded [][]int
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, sf.Index)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = jsonField{
+			index:     sf.Index,
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		}
+	}
+	// the fields of the struct take precedence over the fields of its
+	// embedded structs
+	for _, index := range embedded {
+		ft := t.FieldByIndex(index).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		for name, f := range jsonFields(ft) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = jsonField{index: append(append([]int{}, index...), f.index...), omitEmpty: f.omitEmpty}
+			}
+		}
+	}
+	return fields
+}
+
+// fieldByIndex returns the field of a struct value by index, and false if it
+// is a field of a nil embedded struct.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue returns whether a value is empty, as defined by the omitempty
+// option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package httputils // import "github.com/docker/docker/api/server/httputils"
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+)
+
+func selectFieldsJSON(t *testing.T, fields string, v interface{}) (string, error) {
+	r, _ := http.NewRequest("GET", "/?fields="+url.QueryEscape(fields), nil)
+	selected, err := SelectFields(r, v)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), nil
+}
+
+func TestSelectFields(t *testing.T) {
+	c := &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: "c1",
+			State: &types.ContainerState{
+				Status: "running",
+				Health: &types.Health{Status: "healthy"},
+			},
+		},
+		Config: &container.Config{Image: "busybox"},
+		NetworkSettings: &types.NetworkSettings{
+			NetworkSettingsBase: types.NetworkSettingsBase{
+				Ports: nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8080"}}},
+			},
+		},
+	}
+
+	cases := []struct {
+		fields   string
+		expected string
+	}{
+		{
+			fields:   "Id,State.Health.Status,NetworkSettings.Ports",
+			expected: `{"Id":"c1","NetworkSettings":{"Ports":{"80/tcp":[{"HostIp":"0.0.0.0","HostPort":"8080"}]}},"State":{"Health":{"Status":"healthy"}}}`,
+		},
+		{
+			fields:   "State.Status, State",
+			expected: `{"State":{"Status":"running","Running":false,"Paused":false,"Restarting":false,"OOMKilled":false,"Dead":false,"Pid":0,"ExitCode":0,"Error":"","StartedAt":"","FinishedAt":"","Health":{"Status":"healthy","FailingStreak":0,"Log":null}}}`,
+		},
+		{
+			// the empty fields are omitted as they are without selection
+			fields:   "Config.Image,Config.Hostname,Config.ExposedPorts,Mounts",
+			expected: `{"Config":{"Hostname":"","Image":"busybox"},"Mounts":null}`,
+		},
+		{
+			fields:   "NetworkSettings.Ports.443/tcp",
+			expected: `{"NetworkSettings":{"Ports":{}}}`,
+		},
+	}
+	for _, tc := range cases {
+		actual, err := selectFieldsJSON(t, tc.fields, c)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.fields, err)
+		}
+		if actual != tc.expected {
+			t.Fatalf("%s: expected %s, got %s", tc.fields, tc.expected, actual)
+		}
+	}
+}
+
+func TestSelectFieldsList(t *testing.T) {
+	containers := []*types.Container{
+		{ID: "c1", Names: []string{"/web"}, Labels: map[string]string{"app": "web"}},
+		{ID: "c2", Names: []string{"/db"}},
+	}
+	actual, err := selectFieldsJSON(t, "Id,Labels.app", containers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"Id":"c1","Labels":{"app":"web"}},{"Id":"c2","Labels":null}]`
+	if actual != expected {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	v, err := SelectFields(r, containers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.([]*types.Container); !ok {
+		t.Fatalf("expected the value to be returned as is without fields, got %T", v)
+	}
+}
+
+func TestSelectFieldsInvalid(t *testing.T) {
+	for _, fields := range []string{"Nope", "State..Status", "Id.Length", "State.Nope"} {
+		_, err := selectFieldsJSON(t, fields, &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "c1", State: &types.ContainerState{}},
+		})
+		if !errdefs.IsInvalidParameter(err) {
+			t.Fatalf("%s: expected an invalid parameter error, got %v", fields, err)
+		}
+	}
+}
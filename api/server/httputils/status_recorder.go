@@ -0,0 +1,78 @@
+package httputils // import "github.com/docker/docker/api/server/httputils"
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusRecorder records the status code of a response. It supports the
+// flushing, hijacking and close notifications of the response writer it
+// wraps, which the handlers use for the streams and attached connections.
+type StatusRecorder struct {
+	http.ResponseWriter
+	status int
+	// upgrade is whether the request upgrades the connection, for the
+	// status of the hijacked connections.
+	upgrade bool
+}
+
+// NewStatusRecorder returns a StatusRecorder recording the status code of
+// the response to a request.
+func NewStatusRecorder(w http.ResponseWriter, r *http.Request) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, upgrade: r.Header.Get("Upgrade") != ""}
+}
+
+// StatusCode returns the status code of the response, 200 if it was not
+// written.
+func (w *StatusRecorder) StatusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// WriteHeader records the status code, and writes it to the wrapped writer.
+func (w *StatusRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *StatusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the wrapped writer, if it supports it.
+func (w *StatusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the wrapped writer.
+func (w *StatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking the connection")
+	}
+	// the handlers hijacking the connection write the status themselves
+	if w.status == 0 && w.upgrade {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// CloseNotify returns the close notifications of the wrapped writer.
+func (w *StatusRecorder) CloseNotify() <-chan bool {
+	if n, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return n.CloseNotify()
+	}
+	// never notified
+	return nil
+}
@@ -0,0 +1,39 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/go-metrics"
+)
+
+var (
+	requestDuration  metrics.LabeledTimer
+	requestsInFlight metrics.LabeledGauge
+)
+
+func init() {
+	ns := metrics.NewNamespace("engine", "api", nil)
+	requestDuration = ns.NewLabeledTimer("request_duration", "The number of seconds it takes to serve the API requests", "route", "method", "status")
+	requestsInFlight = ns.NewLabeledGauge("in_flight", "The number of API requests being served", metrics.Unit("requests"), "route", "method")
+	metrics.Register(ns)
+}
+
+// instrumentHandler returns a handler recording the duration and status of
+// the requests of the route served by handler, and the number of requests
+// being served. The route is the path template of the route, for the
+// requests of the route not to be told apart by the IDs in their paths.
+func instrumentHandler(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inFlight := requestsInFlight.WithValues(route, r.Method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		rec := httputils.NewStatusRecorder(w, r)
+		handler(rec, r)
+		requestDuration.WithValues(route, r.Method, strconv.Itoa(rec.StatusCode())).UpdateSince(start)
+	}
+}
@@ -0,0 +1,68 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestInstrumentHandler(t *testing.T) {
+	inFlight := make(chan float64, 1)
+	handler := instrumentHandler("/test/{name:.*}/json", func(w http.ResponseWriter, r *http.Request) {
+		inFlight <- gatherValue(t, "engine_api_in_flight_requests", map[string]string{"route": "/test/{name:.*}/json", "method": "GET"})
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expected the response writer to be a flusher")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/test/abc/json", nil)
+		resp := httptest.NewRecorder()
+		handler(resp, req)
+		if resp.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", resp.Code)
+		}
+		if v := <-inFlight; v != 1 {
+			t.Fatalf("expected 1 request in flight, got %v", v)
+		}
+	}
+
+	labels := map[string]string{"route": "/test/{name:.*}/json", "method": "GET", "status": "404"}
+	if count := gatherValue(t, "engine_api_request_duration_seconds", labels); count != 2 {
+		t.Fatalf("expected 2 requests recorded, got %v", count)
+	}
+	if v := gatherValue(t, "engine_api_in_flight_requests", map[string]string{"route": "/test/{name:.*}/json", "method": "GET"}); v != 0 {
+		t.Fatalf("expected no request in flight, got %v", v)
+	}
+}
+
+// gatherValue returns the value of the gauge name with the labels, or the
+// count of the histogram.
+func gatherValue(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.Metric {
+			for _, l := range m.Label {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			if m.Histogram != nil {
+				return float64(m.Histogram.GetSampleCount())
+			}
+			return m.Gauge.GetValue()
+		}
+	}
+	t.Fatalf("no metric %s with labels %v", name, labels)
+	return 0
+}
@@ -0,0 +1,66 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/pkg/auditlog"
+	"github.com/docker/docker/pkg/authorization"
+)
+
+// AuditMiddleware records the API requests changing the state of the daemon
+// in an audit log: the requests with methods other than GET, HEAD and
+// OPTIONS. The requests are recorded with their results, including the
+// requests denied by the other middlewares.
+type AuditMiddleware struct {
+	log *auditlog.Log
+}
+
+// NewAuditMiddleware creates a new AuditMiddleware recording the requests in
+// log.
+func NewAuditMiddleware(log *auditlog.Log) AuditMiddleware {
+	return AuditMiddleware{log: log}
+}
+
+// WrapHandler returns a new handler function wrapping the previous one in the request chain.
+func (m AuditMiddleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return handler(ctx, w, r, vars)
+		}
+
+		start := time.Now()
+		ctx, user := authorization.WithUserRecord(ctx)
+		rec := httputils.NewStatusRecorder(w, r)
+		err := handler(ctx, rec, r, vars)
+
+		record := &auditlog.Record{
+			Time: start.UTC(),
+			Request: auditlog.Request{
+				Method: r.Method,
+				Path:   r.URL.Path,
+			},
+			Result: auditlog.Result{
+				Status:   rec.StatusCode(),
+				Duration: time.Since(start),
+			},
+		}
+		record.Actor.User, record.Actor.AuthNMethod = user.User()
+		record.Actor.RemoteAddr = r.RemoteAddr
+		record.Actor.UserAgent = r.UserAgent()
+		if q := r.URL.Query(); len(q) > 0 {
+			record.Request.Query = q
+		}
+		if err != nil {
+			// the errors are written to the responses by the server, once
+			// returned by the middlewares
+			record.Result.Status = httputils.GetHTTPErrorStatusCode(err)
+			record.Result.Error = err.Error()
+		}
+		m.log.Add(record)
+		return err
+	}
+}
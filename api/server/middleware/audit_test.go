@@ -0,0 +1,77 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/auditlog"
+	"github.com/docker/docker/pkg/authorization"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/fs"
+)
+
+type memorySink struct {
+	records []*auditlog.Record
+}
+
+func (s *memorySink) Write(r *auditlog.Record) error {
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	dir := fs.NewDir(t, "audit")
+	defer dir.Remove()
+	sink := &memorySink{}
+	log, err := auditlog.New(sink, dir.Join("state.json"))
+	assert.NilError(t, err)
+
+	// the users are set by the middlewares wrapped by the audit middleware
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		ctx = authorization.WithUser(ctx, "alice", "TLS")
+		switch r.URL.Path {
+		case "/containers/c1/exec":
+			return errdefs.Forbidden(errors.New("denied by policy"))
+		case "/containers/c1/start":
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return nil
+	}
+	m := NewAuditMiddleware(log)
+	for _, target := range []string{"/containers/json", "/containers/c1/start", "/containers/c1/exec", "/containers/c1?force=1"} {
+		method := "POST"
+		switch target {
+		case "/containers/json":
+			method = "GET"
+		case "/containers/c1?force=1":
+			method = "DELETE"
+		}
+		r := httptest.NewRequest(method, target, nil)
+		r.Header.Set("User-Agent", "Docker-Client/19.03")
+		_ = m.WrapHandler(handler)(context.Background(), httptest.NewRecorder(), r, nil)
+	}
+	assert.NilError(t, log.Close())
+
+	assert.Assert(t, is.Len(sink.records, 3))
+	start, exec, del := sink.records[0], sink.records[1], sink.records[2]
+	assert.Check(t, is.Equal(start.Request.Path, "/containers/c1/start"))
+	assert.Check(t, is.Equal(start.Result.Status, http.StatusNoContent))
+	assert.Check(t, is.Equal(start.Actor.User, "alice"))
+	assert.Check(t, is.Equal(start.Actor.AuthNMethod, "TLS"))
+	assert.Check(t, is.Equal(start.Actor.UserAgent, "Docker-Client/19.03"))
+	assert.Check(t, is.Equal(exec.Result.Status, http.StatusForbidden))
+	assert.Check(t, is.Equal(exec.Result.Error, "denied by policy"))
+	assert.Check(t, is.Equal(del.Request.Method, "DELETE"))
+	assert.Check(t, is.Equal(del.Request.Query.Get("force"), "1"))
+	assert.Check(t, is.Equal(del.Result.Status, http.StatusOK))
+	assert.Check(t, is.Equal(del.Seq, uint64(3)))
+}
@@ -0,0 +1,152 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/pkg/authorization"
+	"github.com/docker/go-metrics"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSweepInterval is the time between two removals of the limiters of
+// the identities that stopped making requests.
+const rateLimitSweepInterval = time.Minute
+
+var (
+	rateLimitedRequests metrics.Counter
+	rateLimitIdentities metrics.Gauge
+)
+
+func init() {
+	ns := metrics.NewNamespace("engine", "api", nil)
+	rateLimitedRequests = ns.NewCounter("rate_limited_requests", "The number of API requests rejected by the rate limits")
+	rateLimitIdentities = ns.NewGauge("rate_limit", "The number of identities whose API requests are rate limited", metrics.Unit("identities"))
+	metrics.Register(ns)
+}
+
+// RateLimitMiddleware limits the rate of the API requests of each identity,
+// the requests above the limits being rejected with a 429 status. The
+// identities are the users that authenticated the requests, with TLS or an
+// authorization plugin, and the hosts of the clients of the requests that
+// were not authenticated.
+type RateLimitMiddleware struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	limiters  map[string]*identityLimiter
+	lastSweep time.Time
+}
+
+type identityLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimitMiddleware creates a new RateLimitMiddleware allowing each
+// identity limit requests per second, and burst requests at once. The
+// requests are not limited when limit is 0.
+func NewRateLimitMiddleware(limit float64, burst int) *RateLimitMiddleware {
+	m := &RateLimitMiddleware{}
+	m.SetLimits(limit, burst)
+	return m
+}
+
+// SetLimits sets the limits of the requests of each identity. The burst
+// defaults to the number of requests allowed per second when it is 0.
+func (m *RateLimitMiddleware) SetLimits(limit float64, burst int) {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(limit)))
+	}
+	m.mu.Lock()
+	m.limit, m.burst = rate.Limit(limit), burst
+	m.limiters = make(map[string]*identityLimiter)
+	rateLimitIdentities.Set(0)
+	m.mu.Unlock()
+}
+
+// WrapHandler returns a new handler function wrapping the previous one in the request chain.
+func (m *RateLimitMiddleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		identity := requestIdentity(ctx, r)
+		if delay, ok := m.allow(identity, time.Now()); !ok {
+			rateLimitedRequests.Inc()
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			msg := fmt.Sprintf("too many requests from %s: retry after %ds", identity, retryAfter)
+			if v := vars["version"]; v != "" && versions.LessThan(v, "1.24") {
+				http.Error(w, msg, http.StatusTooManyRequests)
+			} else {
+				httputils.WriteJSON(w, http.StatusTooManyRequests, &types.ErrorResponse{Message: msg})
+			}
+			return nil
+		}
+		return handler(ctx, w, r, vars)
+	}
+}
+
+// allow returns whether a request of the identity is allowed at now, or how
+// long the identity has to wait for its next request to be allowed.
+func (m *RateLimitMiddleware) allow(identity string, now time.Time) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.limit == 0 {
+		return 0, true
+	}
+
+	if now.Sub(m.lastSweep) >= rateLimitSweepInterval {
+		m.sweep(now)
+	}
+	l, ok := m.limiters[identity]
+	if !ok {
+		l = &identityLimiter{Limiter: rate.NewLimiter(m.limit, m.burst)}
+		m.limiters[identity] = l
+		rateLimitIdentities.Inc()
+	}
+	l.lastSeen = now
+
+	res := l.ReserveN(now, 1)
+	if delay := res.DelayFrom(now); delay > 0 {
+		// the rejected requests do not count towards the limits
+		res.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+// sweep removes the limiters of the identities that did not make requests
+// for long enough for their limiters to be full, the new limiters of the
+// identities being the same.
+func (m *RateLimitMiddleware) sweep(now time.Time) {
+	full := time.Duration(float64(m.burst) / float64(m.limit) * float64(time.Second))
+	for identity, l := range m.limiters {
+		if now.Sub(l.lastSeen) > full {
+			delete(m.limiters, identity)
+			rateLimitIdentities.Dec()
+		}
+	}
+	m.lastSweep = now
+}
+
+// requestIdentity returns the identity the rate limits of a request apply to:
+// the user that authenticated the request, or the host of its client. The
+// requests of the clients of the unix sockets and named pipes share an
+// identity.
+func requestIdentity(ctx context.Context, r *http.Request) string {
+	if user, _ := authorization.UserFromContext(ctx); user != "" {
+		return "user " + user
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return "host " + host
+	}
+	return "local clients"
+}
@@ -0,0 +1,74 @@
+package middleware // import "github.com/docker/docker/api/server/middleware"
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/authorization"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	m := NewRateLimitMiddleware(1, 2)
+	h := m.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	request := func(ctx context.Context, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/containers/json", nil)
+		req.RemoteAddr = remoteAddr
+		resp := httptest.NewRecorder()
+		assert.NilError(t, h(ctx, resp, req, map[string]string{}))
+		return resp
+	}
+
+	ctx := context.Background()
+	// the burst is allowed, the next request is not
+	for i := 0; i < 2; i++ {
+		assert.Check(t, is.Equal(request(ctx, "10.0.0.1:1234").Code, http.StatusOK))
+	}
+	resp := request(ctx, "10.0.0.1:5678")
+	assert.Check(t, is.Equal(resp.Code, http.StatusTooManyRequests))
+	assert.Check(t, is.Equal(resp.Header().Get("Retry-After"), "1"))
+	assert.Check(t, is.Contains(resp.Body.String(), "too many requests from host 10.0.0.1"))
+
+	// the other clients have their own limits
+	assert.Check(t, is.Equal(request(ctx, "10.0.0.2:1234").Code, http.StatusOK))
+
+	// the requests of a user share its limits across the clients
+	ctx = authorization.WithUser(ctx, "alice", "TLS")
+	for i := 0; i < 2; i++ {
+		assert.Check(t, is.Equal(request(ctx, "10.0.0.1:1234").Code, http.StatusOK))
+	}
+	assert.Check(t, is.Equal(request(ctx, "10.0.0.3:1234").Code, http.StatusTooManyRequests))
+}
+
+func TestRateLimitMiddlewareDisabled(t *testing.T) {
+	m := NewRateLimitMiddleware(0, 0)
+	for i := 0; i < 100; i++ {
+		_, ok := m.allow("local clients", time.Now())
+		assert.Assert(t, ok)
+	}
+}
+
+func TestRateLimitMiddlewareSweep(t *testing.T) {
+	m := NewRateLimitMiddleware(10, 0)
+	now := time.Now()
+	_, ok := m.allow("host 10.0.0.1", now)
+	assert.Assert(t, ok)
+	// the limiters are full a second after the last requests
+	_, ok = m.allow("host 10.0.0.2", now.Add(rateLimitSweepInterval-500*time.Millisecond))
+	assert.Assert(t, ok)
+
+	// the limiters of the identities without recent requests are removed
+	_, ok = m.allow("host 10.0.0.3", now.Add(rateLimitSweepInterval))
+	assert.Assert(t, ok)
+	assert.Check(t, is.Len(m.limiters, 2))
+	_, ok = m.limiters["host 10.0.0.1"]
+	assert.Check(t, !ok)
+}
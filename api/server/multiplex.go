@@ -0,0 +1,57 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/pkg/multiplex"
+	"github.com/sirupsen/logrus"
+)
+
+// multiplexPath is the path of the requests upgrading their connection to
+// multiplexed sessions.
+var multiplexPath = regexp.MustCompile(`^(/v[0-9.]+)?/system/multiplex$`)
+
+// multiplexHandler returns a handler upgrading the connection of the
+// multiplexing requests to multiplexed sessions whose streams are served
+// with h, as connections of their own, and serving the other requests with
+// h. The multiplexed sessions let the clients connecting through a single
+// connection, such as `docker system dial-stdio` over SSH, run parallel
+// requests.
+//
+// The connections with TLS are not upgraded, for the requests of their
+// streams to be authenticated with the certificates of their clients.
+func multiplexHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !multiplexPath.MatchString(r.URL.Path) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if r.TLS != nil {
+			http.Error(w, "multiplexing is not supported over TLS", http.StatusBadRequest)
+			return
+		}
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "multiplex") {
+			http.Error(w, "the connection must be upgraded to the multiplex protocol", http.StatusBadRequest)
+			return
+		}
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "the connection cannot be upgraded", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			logrus.WithError(err).Error("Error hijacking the multiplexed connection")
+			return
+		}
+		fmt.Fprint(conn, "HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: multiplex\r\n\r\n")
+
+		session := multiplex.Server(&bufferedConn{Conn: conn, r: rw.Reader})
+		srv := &http.Server{Handler: h}
+		// Serve returns once the session is closed by the client
+		srv.Serve(session)
+	})
+}
@@ -0,0 +1,64 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/server/router"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// countingListener counts the connections it accepts.
+type countingListener struct {
+	net.Listener
+	conns int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&l.conns, 1)
+	}
+	return conn, err
+}
+
+func TestServeMultiplexed(t *testing.T) {
+	srv := New(&Config{})
+	srv.InitRouter(testRouter{routes: []router.Route{
+		router.NewGetRoute("/containers/json", func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			return httputils.WriteJSON(w, http.StatusOK, []types.Container{{ID: "c1"}})
+		}),
+	}})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	cl := &countingListener{Listener: l}
+	srv.Accept("", cl)
+	go srv.serveAPI()
+	defer srv.Close()
+
+	c, err := client.NewClientWithOpts(client.WithHost("tcp://"+l.Addr().String()), client.WithVersion("1.40"), client.WithMultiplexing())
+	assert.NilError(t, err)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			containers, err := c.ContainerList(context.Background(), types.ContainerListOptions{})
+			assert.Check(t, err)
+			assert.Check(t, is.Len(containers, 1))
+		}()
+	}
+	wg.Wait()
+	// the parallel requests are multiplexed over one connection
+	assert.Check(t, is.Equal(atomic.LoadInt32(&cl.conns), int32(1)))
+}
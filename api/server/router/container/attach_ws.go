@@ -0,0 +1,275 @@
+package container // import "github.com/docker/docker/api/server/router/container"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// wsAttachProtocol is the websocket subprotocol of the attach sessions with
+// binary framing. The messages of the sessions are binary frames starting
+// with the type of the message, followed by its payload:
+//
+//	stdin  (0): the data of stdin, sent by the client. An empty payload
+//	            closes stdin.
+//	stdout (1): the data of stdout, sent by the daemon.
+//	stderr (2): the data of stderr, sent by the daemon. The output of the
+//	            containers with a TTY is only sent on stdout.
+//	resize (3): the height and width of the TTY, as big-endian uint16,
+//	            sent by the client.
+//	window (4): the number of bytes, as a big-endian uint32, the sender of
+//	            the message allows the other side to send in addition to
+//	            the current window.
+//	error  (5): the error ending the session, sent by the daemon before
+//	            closing the connection.
+//
+// Each side can send wsAttachWindow bytes of data on the streams, stdout and
+// stderr sharing a window, before its window is increased by the window
+// messages of the other side.
+const wsAttachProtocol = "v2.attach.moby"
+
+// The types of the messages of the attach sessions with binary framing.
+const (
+	wsMsgStdin byte = iota
+	wsMsgStdout
+	wsMsgStderr
+	wsMsgResize
+	wsMsgWindow
+	wsMsgError
+)
+
+const (
+	// wsAttachWindow is the initial window of each side of the sessions.
+	wsAttachWindow = 256 * 1024
+	// wsAttachMaxFrame is the maximum size of the data of the frames sent
+	// by the daemon.
+	wsAttachMaxFrame = 32 * 1024
+)
+
+var errWSAttachClosed = errors.New("websocket attach session closed")
+
+// wsAttachHandshake selects the websocket subprotocol of the attach sessions
+// with binary framing when the client offers it, the other protocols being
+// handled as before.
+func wsAttachHandshake(config *websocket.Config, r *http.Request) error {
+	for _, p := range config.Protocol {
+		if p == wsAttachProtocol {
+			config.Protocol = []string{wsAttachProtocol}
+			break
+		}
+	}
+	return nil
+}
+
+// isWSAttachSession returns whether the websocket connection uses the
+// subprotocol of the attach sessions with binary framing.
+func isWSAttachSession(conn *websocket.Conn) bool {
+	p := conn.Config().Protocol
+	return len(p) == 1 && p[0] == wsAttachProtocol
+}
+
+// wsAttachSession is an attach session with binary framing and flow control
+// on a websocket connection.
+type wsAttachSession struct {
+	conn   *websocket.Conn
+	resize func(height, width int) error
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// sendWindow is the number of bytes of stdout and stderr the client
+	// allows the daemon to send, and recvWindow the number of bytes of stdin
+	// the daemon allows the client to send
+	sendWindow int
+	recvWindow int
+	// stdin holds the data of stdin not read yet, and consumed the number of
+	// bytes read since the last window message
+	stdin    []byte
+	stdinEOF bool
+	consumed int
+	err      error
+}
+
+func newWSAttachSession(conn *websocket.Conn, resize func(height, width int) error) *wsAttachSession {
+	s := &wsAttachSession{
+		conn:       conn,
+		resize:     resize,
+		sendWindow: wsAttachWindow,
+		recvWindow: wsAttachWindow,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.readFrames()
+	return s
+}
+
+// readFrames reads the messages of the client until the connection is
+// closed.
+func (s *wsAttachSession) readFrames() {
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(s.conn, &msg); err != nil {
+			s.close(err)
+			return
+		}
+		if err := s.handleFrame(msg); err != nil {
+			s.sendError(err)
+			s.close(err)
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *wsAttachSession) handleFrame(msg []byte) error {
+	if len(msg) == 0 {
+		return errors.New("empty websocket attach message")
+	}
+	payload := msg[1:]
+	switch msg[0] {
+	case wsMsgStdin:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(payload) > s.recvWindow {
+			return errors.New("websocket attach client exceeded the stdin window")
+		}
+		if len(payload) == 0 {
+			s.stdinEOF = true
+		}
+		s.recvWindow -= len(payload)
+		s.stdin = append(s.stdin, payload...)
+		s.cond.Broadcast()
+	case wsMsgResize:
+		if len(payload) != 4 {
+			return fmt.Errorf("invalid websocket attach resize message of %d bytes", len(payload))
+		}
+		height, width := binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])
+		if err := s.resize(int(height), int(width)); err != nil {
+			logrus.WithError(err).Debug("Error resizing the TTY of a websocket attach session")
+		}
+	case wsMsgWindow:
+		if len(payload) != 4 {
+			return fmt.Errorf("invalid websocket attach window message of %d bytes", len(payload))
+		}
+		s.mu.Lock()
+		s.sendWindow += int(binary.BigEndian.Uint32(payload))
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	default:
+		return fmt.Errorf("invalid websocket attach message type %d", msg[0])
+	}
+	return nil
+}
+
+// close ends the session, the reads and writes of the streams returning err,
+// or io.EOF for the reads of stdin when err is io.EOF.
+func (s *wsAttachSession) close(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// sendError sends the error ending the session to the client.
+func (s *wsAttachSession) sendError(err error) {
+	websocket.Message.Send(s.conn, append([]byte{wsMsgError}, err.Error()...))
+}
+
+// Read reads the data of stdin, increasing the window of the client once
+// enough of it is read.
+func (s *wsAttachSession) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	for len(s.stdin) == 0 && !s.stdinEOF && s.err == nil {
+		s.cond.Wait()
+	}
+	if len(s.stdin) == 0 {
+		err := s.err
+		s.mu.Unlock()
+		if s.stdinEOF || err == nil {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	n := copy(p, s.stdin)
+	s.stdin = s.stdin[n:]
+	s.consumed += n
+	var inc int
+	if s.consumed >= wsAttachWindow/4 {
+		inc, s.consumed = s.consumed, 0
+		s.recvWindow += inc
+	}
+	s.mu.Unlock()
+
+	if inc > 0 {
+		msg := make([]byte, 5)
+		msg[0] = wsMsgWindow
+		binary.BigEndian.PutUint32(msg[1:], uint32(inc))
+		if err := websocket.Message.Send(s.conn, msg); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Close closes the connection of the session.
+func (s *wsAttachSession) Close() error {
+	s.close(errWSAttachClosed)
+	return s.conn.Close()
+}
+
+// reserve waits for the client to allow the daemon to send data, returning
+// how much of n bytes can be sent.
+func (s *wsAttachSession) reserve(n int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.sendWindow == 0 && s.err == nil {
+		s.cond.Wait()
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	if n > s.sendWindow {
+		n = s.sendWindow
+	}
+	if n > wsAttachMaxFrame {
+		n = wsAttachMaxFrame
+	}
+	s.sendWindow -= n
+	return n, nil
+}
+
+// stream returns a writer sending the data written to it as messages of the
+// type of a stream.
+func (s *wsAttachSession) stream(t byte) io.Writer {
+	return &wsAttachStream{session: s, t: t}
+}
+
+type wsAttachStream struct {
+	session *wsAttachSession
+	t       byte
+}
+
+func (w *wsAttachStream) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n, err := w.session.reserve(len(p))
+		if err != nil {
+			return written, err
+		}
+		msg := make([]byte, n+1)
+		msg[0] = w.t
+		copy(msg[1:], p[:n])
+		if err := websocket.Message.Send(w.session.conn, msg); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
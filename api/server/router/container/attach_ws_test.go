@@ -0,0 +1,249 @@
+package container // import "github.com/docker/docker/api/server/router/container"
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/backend"
+	"golang.org/x/net/websocket"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// pipeListener is a listener accepting the in-memory connections of dial.
+type pipeListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, errors.New("listener closed")
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "unix"}
+}
+
+// dial connects a websocket client offering the protocols to the handler,
+// over an in-memory connection.
+func dialWS(t *testing.T, handler http.Handler, protocols ...string) (*websocket.Conn, func()) {
+	t.Helper()
+	l := newPipeListener()
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(l)
+
+	client, server := net.Pipe()
+	l.conns <- server
+	config, err := websocket.NewConfig("ws://pipe/containers/c/attach/ws", "http://pipe")
+	assert.NilError(t, err)
+	config.Protocol = protocols
+	conn, err := websocket.NewClient(config, client)
+	assert.NilError(t, err)
+	return conn, func() {
+		conn.Close()
+		srv.Close()
+	}
+}
+
+// newTestWSAttachSession returns an attach session with binary framing, and
+// the client connection of the session.
+func newTestWSAttachSession(t *testing.T, resize func(height, width int) error) (*wsAttachSession, *websocket.Conn, func()) {
+	t.Helper()
+	sessions := make(chan *wsAttachSession)
+	done := make(chan struct{})
+	h := websocket.Server{Handshake: wsAttachHandshake, Handler: func(conn *websocket.Conn) {
+		assert.Check(t, isWSAttachSession(conn))
+		sessions <- newWSAttachSession(conn, resize)
+		<-done
+	}}
+	conn, cleanup := dialWS(t, h, wsAttachProtocol)
+	return <-sessions, conn, func() {
+		close(done)
+		cleanup()
+	}
+}
+
+func receiveWS(t *testing.T, conn *websocket.Conn) []byte {
+	t.Helper()
+	var msg []byte
+	assert.NilError(t, websocket.Message.Receive(conn, &msg))
+	assert.Assert(t, len(msg) > 0)
+	return msg
+}
+
+func windowMessage(n uint32) []byte {
+	msg := make([]byte, 5)
+	msg[0] = wsMsgWindow
+	binary.BigEndian.PutUint32(msg[1:], n)
+	return msg
+}
+
+func TestWSAttachStdinWindow(t *testing.T) {
+	s, conn, cleanup := newTestWSAttachSession(t, nil)
+	defer cleanup()
+
+	// the client can send its whole window
+	data := bytes.Repeat([]byte("i"), wsAttachWindow)
+	assert.NilError(t, websocket.Message.Send(conn, append([]byte{wsMsgStdin}, data...)))
+
+	// the window of the client is increased once a quarter of it is read
+	read := make(chan error)
+	go func() {
+		buf := make([]byte, wsAttachWindow/4)
+		_, err := io.ReadFull(s, buf[:len(buf)-1])
+		if err == nil {
+			_, err = s.Read(buf[:1])
+		}
+		read <- err
+	}()
+	assert.Check(t, is.DeepEqual(receiveWS(t, conn), windowMessage(wsAttachWindow/4)))
+	assert.NilError(t, <-read)
+
+	// sending more than the window ends the session with an error
+	assert.NilError(t, websocket.Message.Send(conn, append([]byte{wsMsgStdin}, make([]byte, wsAttachWindow/4+1)...)))
+	msg := receiveWS(t, conn)
+	assert.Check(t, is.Equal(msg[0], wsMsgError))
+	assert.Check(t, is.Equal(string(msg[1:]), "websocket attach client exceeded the stdin window"))
+	var end []byte
+	assert.Check(t, is.Equal(websocket.Message.Receive(conn, &end), io.EOF))
+}
+
+func TestWSAttachStdinEOF(t *testing.T) {
+	s, conn, cleanup := newTestWSAttachSession(t, nil)
+	defer cleanup()
+
+	assert.NilError(t, websocket.Message.Send(conn, []byte{wsMsgStdin, 'a', 'b'}))
+	assert.NilError(t, websocket.Message.Send(conn, []byte{wsMsgStdin}))
+	data, err := ioutil.ReadAll(s)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(data), "ab"))
+}
+
+func TestWSAttachSendWindow(t *testing.T) {
+	s, conn, cleanup := newTestWSAttachSession(t, nil)
+	defer cleanup()
+
+	// the daemon sends its whole window, in frames of at most
+	// wsAttachMaxFrame bytes, stdout and stderr sharing the window
+	written := make(chan error)
+	go func() {
+		_, err := s.stream(wsMsgStdout).Write(make([]byte, wsAttachWindow-1))
+		if err == nil {
+			_, err = s.stream(wsMsgStderr).Write([]byte{'e'})
+		}
+		written <- err
+	}()
+	var received int
+	for received < wsAttachWindow {
+		msg := receiveWS(t, conn)
+		assert.Assert(t, len(msg)-1 <= wsAttachMaxFrame)
+		if received < wsAttachWindow-1 {
+			assert.Assert(t, is.Equal(msg[0], wsMsgStdout))
+		} else {
+			assert.Assert(t, is.DeepEqual(msg, []byte{wsMsgStderr, 'e'}))
+		}
+		received += len(msg) - 1
+	}
+	assert.NilError(t, <-written)
+
+	// the writers block until the client increases the window
+	go func() {
+		_, err := s.stream(wsMsgStdout).Write([]byte("abc"))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		t.Fatalf("expected the write to block at a zero window, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.NilError(t, websocket.Message.Send(conn, windowMessage(2)))
+	assert.Check(t, is.DeepEqual(receiveWS(t, conn), []byte{wsMsgStdout, 'a', 'b'}))
+	assert.NilError(t, websocket.Message.Send(conn, windowMessage(1)))
+	assert.Check(t, is.DeepEqual(receiveWS(t, conn), []byte{wsMsgStdout, 'c'}))
+	assert.NilError(t, <-written)
+
+	// the writers return once the session is closed
+	go func() {
+		_, err := s.stream(wsMsgStdout).Write([]byte("d"))
+		written <- err
+	}()
+	closed := make(chan error)
+	go func() {
+		closed <- s.Close()
+	}()
+	assert.Check(t, is.Equal(<-written, errWSAttachClosed))
+	var end []byte
+	assert.Check(t, is.Equal(websocket.Message.Receive(conn, &end), io.EOF))
+	assert.NilError(t, <-closed)
+}
+
+func TestWSAttachResize(t *testing.T) {
+	sizes := make(chan [2]int, 1)
+	_, conn, cleanup := newTestWSAttachSession(t, func(height, width int) error {
+		sizes <- [2]int{height, width}
+		return nil
+	})
+	defer cleanup()
+
+	assert.NilError(t, websocket.Message.Send(conn, []byte{wsMsgResize, 0, 24, 1, 4}))
+	assert.Check(t, is.Equal(<-sizes, [2]int{24, 260}))
+
+	assert.NilError(t, websocket.Message.Send(conn, []byte{wsMsgResize, 0, 24}))
+	msg := receiveWS(t, conn)
+	assert.Check(t, is.Equal(msg[0], wsMsgError))
+	assert.Check(t, is.Equal(string(msg[1:]), "invalid websocket attach resize message of 2 bytes"))
+}
+
+// wsAttachBackend is a backend failing the attachments once their streams are
+// set up.
+type wsAttachBackend struct {
+	Backend
+	err error
+}
+
+func (b *wsAttachBackend) ContainerAttach(name string, c *backend.ContainerAttachConfig) error {
+	if _, _, _, err := c.GetStreams(); err != nil {
+		return err
+	}
+	return b.err
+}
+
+func TestWSAttachError(t *testing.T) {
+	s := &containerRouter{backend: &wsAttachBackend{err: errors.New("container c is not running")}}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), httputils.APIVersionKey{}, "1.40")
+		assert.Check(t, s.wsContainersAttach(ctx, w, r, map[string]string{"name": "c"}))
+	})
+	conn, cleanup := dialWS(t, h, wsAttachProtocol)
+	defer cleanup()
+
+	assert.Check(t, is.DeepEqual(conn.Config().Protocol, []string{wsAttachProtocol}))
+	msg := receiveWS(t, conn)
+	assert.Check(t, is.Equal(msg[0], wsMsgError))
+	assert.Check(t, is.Equal(string(msg[1:]), "container c is not running"))
+}
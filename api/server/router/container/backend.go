@@ -18,6 +18,7 @@ type execBackend interface {
 	ContainerExecInspect(id string) (*backend.ExecInspect, error)
 	ContainerExecResize(name string, height, width int) error
 	ContainerExecStart(ctx context.Context, name string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+	ContainerExecAudit(filters filters.Args) ([]types.ExecAuditEntry, error)
 	ExecExists(name string) (bool, error)
 }
 
@@ -30,16 +31,21 @@ type copyBackend interface {
 	ContainerStatPath(name string, path string) (stat *types.ContainerPathStat, err error)
 }
 
+// cloneBackend includes functions to implement to provide container clone functionality.
+type cloneBackend interface {
+	ContainerClone(name string, config *types.ContainerCloneConfig) (container.ContainerCreateCreatedBody, error)
+}
+
 // stateBackend includes functions to implement to provide container state lifecycle functionality.
 type stateBackend interface {
-	ContainerCreate(config types.ContainerCreateConfig) (container.ContainerCreateCreatedBody, error)
+	ContainerCreate(ctx context.Context, config types.ContainerCreateConfig) (container.ContainerCreateCreatedBody, error)
 	ContainerKill(name string, sig uint64) error
 	ContainerPause(name string) error
 	ContainerRename(oldName, newName string) error
 	ContainerResize(name string, height, width int) error
 	ContainerRestart(name string, seconds *int) error
 	ContainerRm(name string, config *types.ContainerRmConfig) error
-	ContainerStart(name string, hostConfig *container.HostConfig, checkpoint string, checkpointDir string) error
+	ContainerStart(ctx context.Context, name string, hostConfig *container.HostConfig, checkpoint string, checkpointDir string) error
 	ContainerStop(name string, seconds *int) error
 	ContainerUnpause(name string) error
 	ContainerUpdate(name string, hostConfig *container.HostConfig) (container.ContainerUpdateOKBody, error)
@@ -51,10 +57,12 @@ type monitorBackend interface {
 	ContainerChanges(name string) ([]archive.Change, error)
 	ContainerInspect(name string, size bool, version string) (interface{}, error)
 	ContainerLogs(ctx context.Context, name string, config *types.ContainerLogsOptions) (msgs <-chan *backend.LogMessage, tty bool, err error)
+	ContainerLogHealth(name string) (*types.ContainerLogHealth, error)
 	ContainerStats(ctx context.Context, name string, config *backend.ContainerStatsConfig) error
 	ContainerTop(name string, psArgs string) (*container.ContainerTopOKBody, error)
 
 	Containers(config *types.ContainerListOptions) ([]*types.Container, error)
+	ContainerSchedules() ([]types.ContainerSchedule, error)
 }
 
 // attachBackend includes function to implement to provide container attaching functionality.
@@ -74,6 +82,7 @@ type commitBackend interface {
 // Backend is all the methods that need to be implemented to provide container specific functionality.
 type Backend interface {
 	commitBackend
+	cloneBackend
 	execBackend
 	copyBackend
 	stateBackend
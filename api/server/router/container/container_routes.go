@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types"
@@ -15,7 +16,6 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/versions"
-	containerpkg "github.com/docker/docker/container"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/signal"
@@ -77,6 +77,7 @@ func (s *containerRouter) getContainersJSON(ctx context.Context, w http.Response
 		Size:    httputils.BoolValue(r, "size"),
 		Since:   r.Form.Get("since"),
 		Before:  r.Form.Get("before"),
+		Cursor:  r.Form.Get("cursor"),
 		Filters: filter,
 	}
 
@@ -92,8 +93,20 @@ func (s *containerRouter) getContainersJSON(ctx context.Context, w http.Response
 	if err != nil {
 		return err
 	}
+	selected, err := httputils.SelectFields(r, containers)
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, selected)
+}
 
-	return httputils.WriteJSON(w, http.StatusOK, containers)
+func (s *containerRouter) getContainersSchedules(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	schedules, err := s.backend.ContainerSchedules()
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, schedules)
 }
 
 func (s *containerRouter) getContainersStats(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -115,6 +128,14 @@ func (s *containerRouter) getContainersStats(ctx context.Context, w http.Respons
 	return s.backend.ContainerStats(ctx, vars["name"], config)
 }
 
+func (s *containerRouter) getContainersLogHealth(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	health, err := s.backend.ContainerLogHealth(vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, health)
+}
+
 func (s *containerRouter) getContainersLogs(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -140,6 +161,7 @@ func (s *containerRouter) getContainersLogs(ctx context.Context, w http.Response
 		ShowStdout: stdout,
 		ShowStderr: stderr,
 		Details:    httputils.BoolValue(r, "details"),
+		Grep:       r.Form.Get("grep"),
 	}
 
 	msgs, tty, err := s.backend.ContainerLogs(ctx, containerName, logsConfig)
@@ -200,7 +222,7 @@ func (s *containerRouter) postContainersStart(ctx context.Context, w http.Respon
 
 	checkpoint := r.Form.Get("checkpoint")
 	checkpointDir := r.Form.Get("checkpoint-dir")
-	if err := s.backend.ContainerStart(vars["name"], hostConfig, checkpoint, checkpointDir); err != nil {
+	if err := s.backend.ContainerStart(ctx, vars["name"], hostConfig, checkpoint, checkpointDir); err != nil {
 		return err
 	}
 
@@ -324,24 +346,26 @@ func (s *containerRouter) postContainersWait(ctx context.Context, w http.Respons
 	legacyRemovalWaitPre134 := false
 
 	// The wait condition defaults to "not-running".
-	waitCondition := containerpkg.WaitConditionNotRunning
+	conditions := []container.WaitCondition{container.WaitConditionNotRunning}
+	var timeout time.Duration
 	if !legacyBehaviorPre130 {
 		if err := httputils.ParseForm(r); err != nil {
 			return err
 		}
-		switch container.WaitCondition(r.Form.Get("condition")) {
-		case container.WaitConditionNextExit:
-			waitCondition = containerpkg.WaitConditionNextExit
-		case container.WaitConditionRemoved:
-			waitCondition = containerpkg.WaitConditionRemoved
-			legacyRemovalWaitPre134 = versions.LessThan(version, "1.34")
+		var err error
+		if conditions, err = waitConditions(r, version); err != nil {
+			return err
+		}
+		if timeout, err = waitTimeout(r); err != nil {
+			return err
 		}
+		legacyRemovalWaitPre134 = len(conditions) == 1 && conditions[0] == container.WaitConditionRemoved && versions.LessThan(version, "1.34")
 	}
 
 	// Note: the context should get canceled if the client closes the
 	// connection since this handler has been wrapped by the
 	// router.WithCancel() wrapper.
-	waitC, err := s.backend.ContainerWait(ctx, vars["name"], waitCondition)
+	cw, err := s.startWait(ctx, vars["name"], conditions, timeout)
 	if err != nil {
 		return err
 	}
@@ -357,25 +381,17 @@ func (s *containerRouter) postContainersWait(ctx context.Context, w http.Respons
 	}
 
 	// Block on the result of the wait operation.
-	status := <-waitC
+	result := cw.result()
 
 	// With API < 1.34, wait on WaitConditionRemoved did not return
 	// in case container removal failed. The only way to report an
 	// error back to the client is to not write anything (i.e. send
 	// an empty response which will be treated as an error).
-	if legacyRemovalWaitPre134 && status.Err() != nil {
+	if legacyRemovalWaitPre134 && result.Error != nil {
 		return nil
 	}
 
-	var waitError *container.ContainerWaitOKBodyError
-	if status.Err() != nil {
-		waitError = &container.ContainerWaitOKBodyError{Message: status.Err().Error()}
-	}
-
-	return json.NewEncoder(w).Encode(&container.ContainerWaitOKBody{
-		StatusCode: int64(status.ExitCode()),
-		Error:      waitError,
-	})
+	return json.NewEncoder(w).Encode(&result)
 }
 
 func (s *containerRouter) getContainersChanges(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -443,6 +459,31 @@ func (s *containerRouter) postContainerUpdate(ctx context.Context, w http.Respon
 	return httputils.WriteJSON(w, http.StatusOK, resp)
 }
 
+func (s *containerRouter) postContainerClone(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	pause := true
+	if r.FormValue("pause") != "" {
+		pause = httputils.BoolValue(r, "pause")
+	}
+
+	cloneConfig := &types.ContainerCloneConfig{
+		Name:    r.Form.Get("name"),
+		Layer:   httputils.BoolValue(r, "layer"),
+		Volumes: httputils.BoolValue(r, "volumes"),
+		Pause:   pause,
+	}
+
+	resp, err := s.backend.ContainerClone(vars["name"], cloneConfig)
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusCreated, resp)
+}
+
 func (s *containerRouter) postContainersCreate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -465,7 +506,23 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 		hostConfig.AutoRemove = false
 	}
 
-	ccr, err := s.backend.ContainerCreate(types.ContainerCreateConfig{
+	if hostConfig != nil && versions.LessThan(version, "1.40") {
+		// Annotations are not supported on API < 1.40.
+		hostConfig.Annotations = nil
+		// Device profiles are not supported on API < 1.40.
+		hostConfig.DeviceProfiles = nil
+		// Adjusting the masked and read-only paths is not supported on API < 1.40.
+		hostConfig.MaskedPathsAdd = nil
+		hostConfig.MaskedPathsRemove = nil
+		hostConfig.ReadonlyPathsAdd = nil
+		hostConfig.ReadonlyPathsRemove = nil
+		// The relabeling of the mounts is not supported on API < 1.40.
+		for i := range hostConfig.Mounts {
+			hostConfig.Mounts[i].Relabel = ""
+		}
+	}
+
+	ccr, err := s.backend.ContainerCreate(ctx, types.ContainerCreateConfig{
 		Name:             name,
 		Config:           config,
 		HostConfig:       hostConfig,
@@ -595,6 +652,10 @@ func (s *containerRouter) wsContainersAttach(ctx context.Context, w http.Respons
 
 	version := httputils.VersionFromContext(ctx)
 
+	// the clients offering the subprotocol of the attach sessions with
+	// binary framing get separate stdout and stderr streams, flow control
+	// and resize messages
+	var session *wsAttachSession
 	setupStreams := func() (io.ReadCloser, io.Writer, io.Writer, error) {
 		wsChan := make(chan *websocket.Conn)
 		h := func(conn *websocket.Conn) {
@@ -602,13 +663,19 @@ func (s *containerRouter) wsContainersAttach(ctx context.Context, w http.Respons
 			<-done
 		}
 
-		srv := websocket.Server{Handler: h, Handshake: nil}
+		srv := websocket.Server{Handler: h, Handshake: wsAttachHandshake}
 		go func() {
 			close(started)
 			srv.ServeHTTP(w, r)
 		}()
 
 		conn := <-wsChan
+		if isWSAttachSession(conn) {
+			session = newWSAttachSession(conn, func(height, width int) error {
+				return s.backend.ContainerResize(containerName, height, width)
+			})
+			return session, session.stream(wsMsgStdout), session.stream(wsMsgStderr), nil
+		}
 		// In case version 1.28 and above, a binary frame will be sent.
 		// See 28176 for details.
 		if versions.GreaterThanOrEqualTo(version, "1.28") {
@@ -629,6 +696,9 @@ func (s *containerRouter) wsContainersAttach(ctx context.Context, w http.Respons
 	}
 
 	err = s.backend.ContainerAttach(containerName, attachConfig)
+	if session != nil && err != nil {
+		session.sendError(err)
+	}
 	close(done)
 	select {
 	case <-started:
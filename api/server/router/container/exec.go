@@ -10,8 +10,10 @@ import (
 
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/sirupsen/logrus"
 )
@@ -25,6 +27,23 @@ func (s *containerRouter) getExecByID(ctx context.Context, w http.ResponseWriter
 	return httputils.WriteJSON(w, http.StatusOK, eConfig)
 }
 
+func (s *containerRouter) getExecAudit(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	auditFilters, err := filters.FromJSON(r.Form.Get("filters"))
+	if err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+
+	entries, err := s.backend.ContainerExecAudit(auditFilters)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, entries)
+}
+
 type execCommandError struct{}
 
 func (execCommandError) Error() string {
@@ -122,7 +141,12 @@ func (s *containerRouter) postContainerExecStart(ctx context.Context, w http.Res
 
 	// Now run the user process in container.
 	// Maybe we should we pass ctx here if we're not detaching?
-	if err := s.backend.ContainerExecStart(context.Background(), execName, stdin, stdout, stderr); err != nil {
+	execCtx := context.Background()
+	if user, authNMethod := authorization.UserFromContext(ctx); user != "" {
+		// keep the identity of the client for the exec audit log
+		execCtx = authorization.WithUser(execCtx, user, authNMethod)
+	}
+	if err := s.backend.ContainerExecStart(execCtx, execName, stdin, stdout, stderr); err != nil {
 		if execStartCheck.Detach {
 			return err
 		}
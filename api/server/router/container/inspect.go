@@ -16,6 +16,10 @@ func (s *containerRouter) getContainersByName(ctx context.Context, w http.Respon
 	if err != nil {
 		return err
 	}
+	selected, err := httputils.SelectFields(r, json)
+	if err != nil {
+		return err
+	}
 
-	return httputils.WriteJSON(w, http.StatusOK, json)
+	return httputils.WriteJSON(w, http.StatusOK, selected)
 }
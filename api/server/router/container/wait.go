@@ -0,0 +1,187 @@
+package container // import "github.com/docker/docker/api/server/router/container"
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/versions"
+	containerpkg "github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+// waitConditions parses the conditions of a wait request, the values of the
+// condition parameters being comma-separated lists of conditions. The
+// unknown conditions are ignored before API 1.40, and the wait condition
+// defaults to "not-running".
+func waitConditions(r *http.Request, version string) ([]container.WaitCondition, error) {
+	var conditions []container.WaitCondition
+	seen := make(map[container.WaitCondition]bool)
+	for _, v := range r.Form["condition"] {
+		for _, c := range strings.Split(v, ",") {
+			condition := container.WaitCondition(strings.TrimSpace(c))
+			switch condition {
+			case container.WaitConditionNotRunning, container.WaitConditionNextExit, container.WaitConditionRemoved:
+			case "":
+				continue
+			default:
+				if versions.LessThan(version, "1.40") {
+					continue
+				}
+				return nil, errdefs.InvalidParameter(errors.Errorf("invalid wait condition: %s", condition))
+			}
+			if !seen[condition] {
+				seen[condition] = true
+				conditions = append(conditions, condition)
+			}
+		}
+	}
+	if len(conditions) == 0 {
+		conditions = []container.WaitCondition{container.WaitConditionNotRunning}
+	}
+	return conditions, nil
+}
+
+// waitTimeout parses the timeout of a wait request, in seconds. The waits do
+// not time out when it is 0.
+func waitTimeout(r *http.Request) (time.Duration, error) {
+	timeout, err := httputils.Int64ValueOrDefault(r, "timeout", 0)
+	if err != nil || timeout < 0 {
+		return 0, errdefs.InvalidParameter(errors.Errorf("invalid timeout: %s", r.Form.Get("timeout")))
+	}
+	return time.Duration(timeout) * time.Second, nil
+}
+
+// containerWait is a wait for the first of the conditions a container
+// reaches.
+type containerWait struct {
+	conditions []container.WaitCondition
+	cancel     context.CancelFunc
+	// fired receives the indexes of the conditions as their waits return,
+	// with their statuses
+	fired    chan int
+	statuses []containerpkg.StateStatus
+}
+
+// startWait starts waiting for a container to reach any of the conditions,
+// until the timeout expires when it is not 0.
+func (s *containerRouter) startWait(ctx context.Context, name string, conditions []container.WaitCondition, timeout time.Duration) (*containerWait, error) {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	cw := &containerWait{
+		conditions: conditions,
+		cancel:     cancel,
+		fired:      make(chan int, len(conditions)),
+		statuses:   make([]containerpkg.StateStatus, len(conditions)),
+	}
+	for i, condition := range conditions {
+		waitC, err := s.backend.ContainerWait(ctx, name, waitConditionPkg(condition))
+		if err != nil {
+			// the waits already started return once canceled
+			cancel()
+			return nil, err
+		}
+		go func(i int) {
+			cw.statuses[i] = <-waitC
+			cw.fired <- i
+		}(i)
+	}
+	return cw, nil
+}
+
+// result blocks until the container reaches any of the conditions, or the
+// timeout expires.
+func (cw *containerWait) result() container.ContainerWaitOKBody {
+	i := <-cw.fired
+	cw.cancel()
+
+	status := cw.statuses[i]
+	body := container.ContainerWaitOKBody{StatusCode: int64(status.ExitCode())}
+	switch {
+	case status.Err() == context.DeadlineExceeded:
+		body.TimedOut = true
+	case status.Err() != nil:
+		body.Condition = cw.conditions[i]
+		body.Error = &container.ContainerWaitOKBodyError{Message: status.Err().Error()}
+	default:
+		body.Condition = cw.conditions[i]
+	}
+	return body
+}
+
+func waitConditionPkg(condition container.WaitCondition) containerpkg.WaitCondition {
+	switch condition {
+	case container.WaitConditionNextExit:
+		return containerpkg.WaitConditionNextExit
+	case container.WaitConditionRemoved:
+		return containerpkg.WaitConditionRemoved
+	}
+	return containerpkg.WaitConditionNotRunning
+}
+
+// postContainersWaitBatch waits for multiple containers to reach any of the
+// conditions, streaming the result of each container once it is known.
+func (s *containerRouter) postContainersWaitBatch(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+	conditions, err := waitConditions(r, httputils.VersionFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	timeout, err := waitTimeout(r)
+	if err != nil {
+		return err
+	}
+	var req container.WaitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+	if len(req.Containers) == 0 {
+		return errdefs.InvalidParameter(errors.New("no containers to wait for"))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	waits := make([]*containerWait, len(req.Containers))
+	for i, name := range req.Containers {
+		if waits[i], err = s.startWait(ctx, name, conditions, timeout); err != nil {
+			return err
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	results := make(chan *container.WaitResult, len(waits))
+	for i, cw := range waits {
+		go func(name string, cw *containerWait) {
+			results <- &container.WaitResult{Container: name, ContainerWaitOKBody: cw.result()}
+		}(req.Containers[i], cw)
+	}
+	enc := json.NewEncoder(w)
+	for range waits {
+		if err := enc.Encode(<-results); err != nil {
+			return nil
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
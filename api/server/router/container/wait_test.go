@@ -0,0 +1,181 @@
+package container // import "github.com/docker/docker/api/server/router/container"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/container"
+	containerpkg "github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// waitBackend is a backend waiting on the states of fake containers. The
+// waits started are sent to started, when it is set.
+type waitBackend struct {
+	Backend
+	states  map[string]*containerpkg.State
+	started chan string
+}
+
+func (b *waitBackend) ContainerWait(ctx context.Context, name string, condition containerpkg.WaitCondition) (<-chan containerpkg.StateStatus, error) {
+	s, ok := b.states[name]
+	if !ok {
+		return nil, errdefs.NotFound(containerpkg.NoSuchContainerError{})
+	}
+	waitC := s.Wait(ctx, condition)
+	if b.started != nil {
+		b.started <- name
+	}
+	return waitC, nil
+}
+
+// flushRecorder is a response recorder sending to flushed when the response
+// is flushed.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan struct{}
+}
+
+func (w *flushRecorder) Flush() {
+	w.ResponseRecorder.Flush()
+	w.flushed <- struct{}{}
+}
+
+func runningState() *containerpkg.State {
+	s := containerpkg.NewState()
+	s.SetRunning(1234, true)
+	return s
+}
+
+func waitRequest(t *testing.T, version, target string, body io.Reader) (context.Context, *http.Request) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, target, body)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	assert.NilError(t, r.ParseForm())
+	return context.WithValue(context.Background(), httputils.APIVersionKey{}, version), r
+}
+
+func TestWaitConditions(t *testing.T) {
+	for _, tc := range []struct {
+		query      string
+		version    string
+		conditions []container.WaitCondition
+		err        string
+	}{
+		{query: "", version: "1.40", conditions: []container.WaitCondition{container.WaitConditionNotRunning}},
+		{query: "condition=next-exit", version: "1.40", conditions: []container.WaitCondition{container.WaitConditionNextExit}},
+		{query: "condition=next-exit,removed", version: "1.40", conditions: []container.WaitCondition{container.WaitConditionNextExit, container.WaitConditionRemoved}},
+		{query: "condition=removed&condition=not-running,%20removed", version: "1.40", conditions: []container.WaitCondition{container.WaitConditionRemoved, container.WaitConditionNotRunning}},
+		{query: "condition=,", version: "1.40", conditions: []container.WaitCondition{container.WaitConditionNotRunning}},
+		{query: "condition=unknown,removed", version: "1.39", conditions: []container.WaitCondition{container.WaitConditionRemoved}},
+		{query: "condition=unknown", version: "1.39", conditions: []container.WaitCondition{container.WaitConditionNotRunning}},
+		{query: "condition=removed,unknown", version: "1.40", err: "invalid wait condition: unknown"},
+	} {
+		_, r := waitRequest(t, tc.version, "/containers/c/wait?"+tc.query, nil)
+		conditions, err := waitConditions(r, tc.version)
+		if tc.err != "" {
+			assert.Check(t, errdefs.IsInvalidParameter(err), "%s", tc.query)
+			assert.Check(t, is.ErrorContains(err, tc.err), "%s", tc.query)
+			continue
+		}
+		assert.Check(t, err, "%s", tc.query)
+		assert.Check(t, is.DeepEqual(conditions, tc.conditions), "%s", tc.query)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	_, r := waitRequest(t, "1.40", "/containers/c/wait?timeout=-1", nil)
+	_, err := waitTimeout(r)
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+
+	b := &waitBackend{states: map[string]*containerpkg.State{"c": runningState()}}
+	s := &containerRouter{backend: b}
+	ctx, r := waitRequest(t, "1.40", "/containers/c/wait?timeout=1", nil)
+	w := httptest.NewRecorder()
+	assert.NilError(t, s.postContainersWait(ctx, w, r, map[string]string{"name": "c"}))
+
+	var body container.ContainerWaitOKBody
+	assert.NilError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Check(t, body.TimedOut)
+	assert.Check(t, is.Equal(body.StatusCode, int64(-1)))
+	assert.Check(t, is.Equal(body.Condition, container.WaitCondition("")))
+	assert.Check(t, body.Error == nil)
+}
+
+func TestWaitFirstCondition(t *testing.T) {
+	state := runningState()
+	b := &waitBackend{states: map[string]*containerpkg.State{"c": state}, started: make(chan string, 2)}
+	s := &containerRouter{backend: b}
+	ctx, r := waitRequest(t, "1.40", "/containers/c/wait?condition=removed,next-exit", nil)
+	w := httptest.NewRecorder()
+
+	done := make(chan error)
+	go func() {
+		done <- s.postContainersWait(ctx, w, r, map[string]string{"name": "c"})
+	}()
+	// the container exits without being removed, so only the next-exit
+	// condition is reached
+	<-b.started
+	<-b.started
+	state.SetStopped(&containerpkg.ExitStatus{ExitCode: 3})
+	assert.NilError(t, <-done)
+
+	var body container.ContainerWaitOKBody
+	assert.NilError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Check(t, is.DeepEqual(body, container.ContainerWaitOKBody{StatusCode: 3, Condition: container.WaitConditionNextExit}))
+}
+
+func TestWaitBatch(t *testing.T) {
+	running := runningState()
+	exited := containerpkg.NewState()
+	exited.SetExitCode(1)
+	b := &waitBackend{states: map[string]*containerpkg.State{"running": running, "exited": exited}}
+	s := &containerRouter{backend: b}
+
+	ctx, r := waitRequest(t, "1.40", "/containers/wait?condition=not-running", bytes.NewBufferString(`{"Containers":["missing"]}`))
+	err := s.postContainersWaitBatch(ctx, httptest.NewRecorder(), r, nil)
+	assert.Check(t, errdefs.IsNotFound(err))
+	ctx, r = waitRequest(t, "1.40", "/containers/wait", bytes.NewBufferString(`{"Containers":[]}`))
+	err = s.postContainersWaitBatch(ctx, httptest.NewRecorder(), r, nil)
+	assert.Check(t, is.ErrorContains(err, "no containers to wait for"))
+
+	ctx, r = waitRequest(t, "1.40", "/containers/wait?condition=not-running", bytes.NewBufferString(`{"Containers":["running","exited"]}`))
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		done <- s.postContainersWaitBatch(ctx, w, r, nil)
+	}()
+	// the result of each container is streamed once it is known, and the
+	// response ends after the last one
+	<-w.flushed // the headers
+	<-w.flushed // the result of the exited container
+	running.SetStopped(&containerpkg.ExitStatus{ExitCode: 2})
+	<-w.flushed
+	assert.NilError(t, <-done)
+
+	dec := json.NewDecoder(w.Body)
+	var results []container.WaitResult
+	for {
+		var res container.WaitResult
+		if err := dec.Decode(&res); err == io.EOF {
+			break
+		} else {
+			assert.NilError(t, err)
+		}
+		results = append(results, res)
+	}
+	assert.Check(t, is.DeepEqual(results, []container.WaitResult{
+		{Container: "exited", ContainerWaitOKBody: container.ContainerWaitOKBody{StatusCode: 1, Condition: container.WaitConditionNotRunning}},
+		{Container: "running", ContainerWaitOKBody: container.ContainerWaitOKBody{StatusCode: 2, Condition: container.WaitConditionNotRunning}},
+	}))
+}
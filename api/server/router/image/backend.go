@@ -8,6 +8,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/daemon/operations"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -17,6 +18,7 @@ type Backend interface {
 	imageBackend
 	importExportBackend
 	registryBackend
+	policyBackend
 }
 
 type imageBackend interface {
@@ -25,6 +27,7 @@ type imageBackend interface {
 	Images(imageFilters filters.Args, all bool, withExtraAttrs bool) ([]*types.ImageSummary, error)
 	LookupImage(name string) (*types.ImageInspect, error)
 	TagImage(imageName, repository, tag string) (string, error)
+	TagImages(ops []image.TagOperation) (*image.TagResponse, error)
 	ImagesPrune(ctx context.Context, pruneFilters filters.Args) (*types.ImagesPruneReport, error)
 }
 
@@ -36,6 +39,14 @@ type importExportBackend interface {
 
 type registryBackend interface {
 	PullImage(ctx context.Context, image, tag string, platform *specs.Platform, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error
+	PullImageInBackground(image, tag string, platform *specs.Platform, metaHeaders map[string][]string, authConfig *types.AuthConfig) (*operations.Operation, bool, error)
 	PushImage(ctx context.Context, image, tag string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error
 	SearchRegistryForImages(ctx context.Context, filtersArgs string, term string, limit int, authConfig *types.AuthConfig, metaHeaders map[string][]string) (*registry.SearchResults, error)
 }
+
+type policyBackend interface {
+	CreateImagePolicy(p types.ImagePolicy) (types.ImagePolicy, error)
+	ImagePolicies() []types.ImagePolicy
+	ImagePolicy(name string) (types.ImagePolicy, error)
+	DeleteImagePolicy(name string) error
+}
@@ -32,13 +32,18 @@ func (r *imageRouter) initRoutes() {
 		router.NewGetRoute("/images/{name:.*}/get", r.getImagesGet),
 		router.NewGetRoute("/images/{name:.*}/history", r.getImagesHistory),
 		router.NewGetRoute("/images/{name:.*}/json", r.getImagesByName),
+		router.NewGetRoute("/image-policies", r.getImagePoliciesList),
+		router.NewGetRoute("/image-policies/{name:.+}", r.getImagePolicy),
 		// POST
 		router.NewPostRoute("/images/load", r.postImagesLoad),
 		router.NewPostRoute("/images/create", r.postImagesCreate, router.WithCancel),
 		router.NewPostRoute("/images/{name:.*}/push", r.postImagesPush, router.WithCancel),
+		router.NewPostRoute("/images/tag", r.postImagesTagBulk),
 		router.NewPostRoute("/images/{name:.*}/tag", r.postImagesTag),
 		router.NewPostRoute("/images/prune", r.postImagesPrune, router.WithCancel),
+		router.NewPostRoute("/image-policies/create", r.postImagePolicyCreate),
 		// DELETE
 		router.NewDeleteRoute("/images/{name:.*}", r.deleteImages),
+		router.NewDeleteRoute("/image-policies/{name:.*}", r.deleteImagePolicy),
 	}
 }
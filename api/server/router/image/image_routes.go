@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,7 +13,9 @@ import (
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	imagetypes "github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/daemon/operations"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/streamformatter"
@@ -76,7 +79,19 @@ func (s *imageRouter) postImagesCreate(ctx context.Context, w http.ResponseWrite
 					authConfig = &types.AuthConfig{}
 				}
 			}
-			err = s.backend.PullImage(ctx, image, tag, platform, metaHeaders, authConfig, output)
+			if httputils.BoolValue(r, "detach") && versions.GreaterThanOrEqualTo(version, "1.40") {
+				// the pull continues in the background when the client
+				// disconnects, the clients pulling the image again being
+				// attached to it
+				var op *operations.Operation
+				op, _, err = s.backend.PullImageInBackground(image, tag, platform, metaHeaders, authConfig)
+				if err == nil {
+					w.Header().Set("Operation-Id", op.ID())
+					err = op.Attach(ctx, output)
+				}
+			} else {
+				err = s.backend.PullImage(ctx, image, tag, platform, metaHeaders, authConfig, output)
+			}
 		} else { //import
 			src := r.Form.Get("fromSrc")
 			// 'err' MUST NOT be defined within this block, we need any error
@@ -219,8 +234,12 @@ func (s *imageRouter) getImagesByName(ctx context.Context, w http.ResponseWriter
 	if err != nil {
 		return err
 	}
+	selected, err := httputils.SelectFields(r, imageInspect)
+	if err != nil {
+		return err
+	}
 
-	return httputils.WriteJSON(w, http.StatusOK, imageInspect)
+	return httputils.WriteJSON(w, http.StatusOK, selected)
 }
 
 func (s *imageRouter) getImagesJSON(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -239,12 +258,49 @@ func (s *imageRouter) getImagesJSON(ctx context.Context, w http.ResponseWriter,
 		imageFilters.Add("reference", filterParam)
 	}
 
+	limit, err := httputils.Int64ValueOrDefault(r, "limit", 0)
+	if err != nil || limit < 0 {
+		return errdefs.InvalidParameter(errors.Errorf("invalid limit: %s", r.Form.Get("limit")))
+	}
+
 	images, err := s.backend.Images(imageFilters, httputils.BoolValue(r, "all"), false)
 	if err != nil {
 		return err
 	}
 
-	return httputils.WriteJSON(w, http.StatusOK, images)
+	if cursor := r.Form.Get("cursor"); cursor != "" {
+		if images, err = imagesAfter(images, cursor); err != nil {
+			return err
+		}
+	}
+	if limit > 0 && int64(len(images)) > limit {
+		images = images[:limit]
+	}
+	selected, err := httputils.SelectFields(r, images)
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, selected)
+}
+
+// imagesAfter returns the images listed after the image of the cursor, made
+// of its creation time and its ID as they are listed, the images being listed
+// from the most recently created, and by ID when they are created at the same
+// time. The image of the cursor may have been removed since.
+func imagesAfter(images []*types.ImageSummary, cursor string) ([]*types.ImageSummary, error) {
+	parts := strings.SplitN(cursor, "_", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errdefs.InvalidParameter(errors.Errorf("invalid cursor %q: the cursor is the creation time and the ID of an image, as <created>_<id>", cursor))
+	}
+	created, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(errors.Errorf("invalid cursor %q: invalid creation time", cursor))
+	}
+	i := sort.Search(len(images), func(i int) bool {
+		return images[i].Created < created || (images[i].Created == created && images[i].ID > parts[1])
+	})
+	return images[i:], nil
 }
 
 func (s *imageRouter) getImagesHistory(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -268,6 +324,23 @@ func (s *imageRouter) postImagesTag(ctx context.Context, w http.ResponseWriter,
 	return nil
 }
 
+// postImagesTagBulk applies multiple tag additions and removals at once,
+// responding with the result of each operation.
+func (s *imageRouter) postImagesTagBulk(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+	var req imagetypes.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+	resp, err := s.backend.TagImages(req.Operations)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, resp)
+}
+
 func (s *imageRouter) getImagesSearch(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -322,3 +395,48 @@ func (s *imageRouter) postImagesPrune(ctx context.Context, w http.ResponseWriter
 	}
 	return httputils.WriteJSON(w, http.StatusOK, pruneReport)
 }
+
+func (s *imageRouter) getImagePoliciesList(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return httputils.WriteJSON(w, http.StatusOK, s.backend.ImagePolicies())
+}
+
+func (s *imageRouter) getImagePolicy(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	policy, err := s.backend.ImagePolicy(vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, policy)
+}
+
+func (s *imageRouter) postImagePolicyCreate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var create types.ImagePolicy
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		return err
+	}
+
+	policy, err := s.backend.CreateImagePolicy(create)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, policy)
+}
+
+func (s *imageRouter) deleteImagePolicy(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := s.backend.DeleteImagePolicy(vars["name"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
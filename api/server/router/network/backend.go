@@ -6,7 +6,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
-	"github.com/docker/libnetwork"
+	"github.com/docker/docker/libnetwork"
 )
 
 // Backend is all the methods that need to be implemented
@@ -17,8 +17,18 @@ type Backend interface {
 	CreateNetwork(nc types.NetworkCreateRequest) (*types.NetworkCreateResponse, error)
 	ConnectContainerToNetwork(containerName, networkName string, endpointConfig *network.EndpointSettings) error
 	DisconnectContainerFromNetwork(containerName string, networkName string, force bool) error
+	SetContainerGwPriority(containerName, networkName string, priority int) error
 	DeleteNetwork(networkID string) error
 	NetworksPrune(ctx context.Context, pruneFilters filters.Args) (*types.NetworksPruneReport, error)
+	CreateNetworkGroup(req types.NetworkGroupCreateRequest) (*types.NetworkCreateResponse, error)
+	NetworkGroups() []types.NetworkGroup
+	NetworkGroup(nameOrID string) (types.NetworkGroup, error)
+	DeleteNetworkGroup(nameOrID string) error
+	CreateNetworkPolicy(p types.NetworkPolicy) (types.NetworkPolicy, error)
+	NetworkPolicies() []types.NetworkPolicy
+	NetworkPolicy(name string) (types.NetworkPolicy, error)
+	DeleteNetworkPolicy(name string) error
+	AddressPools() []types.AddressPool
 }
 
 // ClusterBackend is all the methods that need to be implemented
@@ -32,12 +32,22 @@ func (r *networkRouter) initRoutes() {
 		router.NewGetRoute("/networks", r.getNetworksList),
 		router.NewGetRoute("/networks/", r.getNetworksList),
 		router.NewGetRoute("/networks/{id:.+}", r.getNetwork),
+		router.NewGetRoute("/network-groups", r.getNetworkGroupsList),
+		router.NewGetRoute("/network-groups/{name:.+}", r.getNetworkGroup),
+		router.NewGetRoute("/network-policies", r.getNetworkPoliciesList),
+		router.NewGetRoute("/network-policies/{name:.+}", r.getNetworkPolicy),
+		router.NewGetRoute("/network-address-pools", r.getAddressPools),
 		// POST
 		router.NewPostRoute("/networks/create", r.postNetworkCreate),
 		router.NewPostRoute("/networks/{id:.*}/connect", r.postNetworkConnect),
 		router.NewPostRoute("/networks/{id:.*}/disconnect", r.postNetworkDisconnect),
+		router.NewPostRoute("/networks/{id:.*}/gw-priority", r.postNetworkGwPriority),
 		router.NewPostRoute("/networks/prune", r.postNetworksPrune, router.WithCancel),
+		router.NewPostRoute("/network-groups/create", r.postNetworkGroupCreate),
+		router.NewPostRoute("/network-policies/create", r.postNetworkPolicyCreate),
 		// DELETE
 		router.NewDeleteRoute("/networks/{id:.*}", r.deleteNetwork),
+		router.NewDeleteRoute("/network-groups/{name:.*}", r.deleteNetworkGroup),
+		router.NewDeleteRoute("/network-policies/{name:.*}", r.deleteNetworkPolicy),
 	}
 }
@@ -13,8 +13,8 @@ import (
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/errdefs"
-	"github.com/docker/libnetwork"
-	netconst "github.com/docker/libnetwork/datastore"
+	"github.com/docker/docker/libnetwork"
+	netconst "github.com/docker/docker/libnetwork/datastore"
 	"github.com/pkg/errors"
 )
 
@@ -271,6 +271,23 @@ func (n *networkRouter) postNetworkConnect(ctx context.Context, w http.ResponseW
 	return n.backend.ConnectContainerToNetwork(connect.Container, vars["id"], connect.EndpointConfig)
 }
 
+func (n *networkRouter) postNetworkGwPriority(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var req types.NetworkGwPriority
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	return n.backend.SetContainerGwPriority(req.Container, vars["id"], req.GwPriority)
+}
+
 func (n *networkRouter) postNetworkDisconnect(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	var disconnect types.NetworkDisconnect
 	if err := httputils.ParseForm(r); err != nil {
@@ -327,6 +344,100 @@ func (n *networkRouter) postNetworksPrune(ctx context.Context, w http.ResponseWr
 	return httputils.WriteJSON(w, http.StatusOK, pruneReport)
 }
 
+func (n *networkRouter) getNetworkGroupsList(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return httputils.WriteJSON(w, http.StatusOK, n.backend.NetworkGroups())
+}
+
+func (n *networkRouter) getNetworkGroup(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	group, err := n.backend.NetworkGroup(vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, group)
+}
+
+func (n *networkRouter) postNetworkGroupCreate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var create types.NetworkGroupCreateRequest
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		return err
+	}
+
+	group, err := n.backend.CreateNetworkGroup(create)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, group)
+}
+
+func (n *networkRouter) deleteNetworkGroup(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := n.backend.DeleteNetworkGroup(vars["name"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (n *networkRouter) getNetworkPoliciesList(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return httputils.WriteJSON(w, http.StatusOK, n.backend.NetworkPolicies())
+}
+
+func (n *networkRouter) getNetworkPolicy(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	policy, err := n.backend.NetworkPolicy(vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, policy)
+}
+
+func (n *networkRouter) postNetworkPolicyCreate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var create types.NetworkPolicy
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		return err
+	}
+
+	policy, err := n.backend.CreateNetworkPolicy(create)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, policy)
+}
+
+func (n *networkRouter) deleteNetworkPolicy(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := n.backend.DeleteNetworkPolicy(vars["name"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (n *networkRouter) getAddressPools(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return httputils.WriteJSON(w, http.StatusOK, n.backend.AddressPools())
+}
+
 // findUniqueNetwork will search network across different scopes (both local and swarm).
 // NOTE: This findUniqueNetwork is different from FindNetwork in the daemon.
 // In case multiple networks have duplicate names, return error.
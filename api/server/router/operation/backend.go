@@ -0,0 +1,10 @@
+package operation // import "github.com/docker/docker/api/server/router/operation"
+
+import "github.com/docker/docker/api/types"
+
+// Backend is all the methods that need to be implemented to provide the
+// background operations.
+type Backend interface {
+	Operations() []types.Operation
+	CancelOperation(id string) error
+}
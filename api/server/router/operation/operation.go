@@ -0,0 +1,30 @@
+package operation // import "github.com/docker/docker/api/server/router/operation"
+
+import "github.com/docker/docker/api/server/router"
+
+// operationRouter is a router to talk with the background operations
+type operationRouter struct {
+	backend Backend
+	routes  []router.Route
+}
+
+// NewRouter initializes a new operation router
+func NewRouter(b Backend) router.Router {
+	r := &operationRouter{
+		backend: b,
+	}
+	r.initRoutes()
+	return r
+}
+
+// Routes returns the available routes to the background operations
+func (r *operationRouter) Routes() []router.Route {
+	return r.routes
+}
+
+func (r *operationRouter) initRoutes() {
+	r.routes = []router.Route{
+		router.NewGetRoute("/operations", r.getOperations),
+		router.NewDeleteRoute("/operations/{id}", r.deleteOperation),
+	}
+}
@@ -0,0 +1,20 @@
+package operation // import "github.com/docker/docker/api/server/router/operation"
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/docker/docker/api/server/httputils"
+)
+
+func (s *operationRouter) getOperations(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return httputils.WriteJSON(w, http.StatusOK, s.backend.Operations())
+}
+
+func (s *operationRouter) deleteOperation(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := s.backend.CancelOperation(vars["id"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -16,6 +16,7 @@ type Backend interface {
 	Disable(name string, config *enginetypes.PluginDisableConfig) error
 	Enable(name string, config *enginetypes.PluginEnableConfig) error
 	List(filters.Args) ([]enginetypes.Plugin, error)
+	Capabilities(filters.Args) ([]enginetypes.PluginCapabilities, error)
 	Inspect(name string) (*enginetypes.Plugin, error)
 	Remove(name string, config *enginetypes.PluginRmConfig) error
 	Set(name string, args []string) error
@@ -27,6 +27,7 @@ func (r *pluginRouter) initRoutes() {
 		router.NewGetRoute("/plugins", r.listPlugins),
 		router.NewGetRoute("/plugins/{name:.*}/json", r.inspectPlugin),
 		router.NewGetRoute("/plugins/privileges", r.getPrivileges),
+		router.NewGetRoute("/plugins/capabilities", r.getCapabilities),
 		router.NewDeleteRoute("/plugins/{name:.*}", r.removePlugin),
 		router.NewPostRoute("/plugins/{name:.*}/enable", r.enablePlugin), // PATCH?
 		router.NewPostRoute("/plugins/{name:.*}/disable", r.disablePlugin),
@@ -301,6 +301,22 @@ func (pr *pluginRouter) listPlugins(ctx context.Context, w http.ResponseWriter,
 	return httputils.WriteJSON(w, http.StatusOK, l)
 }
 
+func (pr *pluginRouter) getCapabilities(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	pluginFilters, err := filters.FromJSON(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+	capabilities, err := pr.backend.Capabilities(pluginFilters)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, capabilities)
+}
+
 func (pr *pluginRouter) inspectPlugin(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	result, err := pr.backend.Inspect(vars["name"])
 	if err != nil {
@@ -2,6 +2,7 @@ package swarm // import "github.com/docker/docker/api/server/router/swarm"
 
 import (
 	"context"
+	"io"
 
 	basictypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/backend"
@@ -17,6 +18,8 @@ type Backend interface {
 	Update(uint64, types.Spec, types.UpdateFlags) error
 	GetUnlockKey() (string, error)
 	UnlockSwarm(req types.UnlockRequest) error
+	Backup() (io.ReadCloser, error)
+	Restore(req types.RestoreRequest, backup io.Reader) error
 
 	GetServices(basictypes.ServiceListOptions) ([]types.Service, error)
 	GetService(idOrName string, insertDefaults bool) (types.Service, error)
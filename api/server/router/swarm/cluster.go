@@ -31,6 +31,8 @@ func (sr *swarmRouter) initRoutes() {
 		router.NewGetRoute("/swarm/unlockkey", sr.getUnlockKey),
 		router.NewPostRoute("/swarm/update", sr.updateCluster),
 		router.NewPostRoute("/swarm/unlock", sr.unlockCluster),
+		router.NewPostRoute("/swarm/backup", sr.backupCluster),
+		router.NewPostRoute("/swarm/restore", sr.restoreCluster),
 
 		router.NewGetRoute("/services", sr.getServices),
 		router.NewGetRoute("/services/{id}", sr.getService),
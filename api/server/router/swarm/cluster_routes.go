@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -122,6 +123,36 @@ func (sr *swarmRouter) unlockCluster(ctx context.Context, w http.ResponseWriter,
 	return nil
 }
 
+func (sr *swarmRouter) backupCluster(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	backup, err := sr.backend.Backup()
+	if err != nil {
+		logrus.WithError(err).Error("Error backing up swarm")
+		return err
+	}
+	defer backup.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	_, err = io.Copy(w, backup)
+	return err
+}
+
+func (sr *swarmRouter) restoreCluster(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	req := types.RestoreRequest{
+		ListenAddr:    r.Form.Get("listenAddr"),
+		AdvertiseAddr: r.Form.Get("advertiseAddr"),
+		DataPathAddr:  r.Form.Get("dataPathAddr"),
+		UnlockKey:     r.Header.Get("X-Swarm-Unlock-Key"),
+	}
+	if err := sr.backend.Restore(req, r.Body); err != nil {
+		logrus.WithError(err).Error("Error restoring swarm")
+		return err
+	}
+	return nil
+}
+
 func (sr *swarmRouter) getUnlockKey(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	unlockKey, err := sr.backend.GetUnlockKey()
 	if err != nil {
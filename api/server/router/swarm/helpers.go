@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/docker/docker/api/server/httputils"
 	basictypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/errdefs"
 )
 
 // swarmLogs takes an http response, request, and selector, and writes the logs
@@ -34,6 +36,17 @@ func (sr *swarmRouter) swarmLogs(ctx context.Context, w io.Writer, r *http.Reque
 		ShowStdout: stdout,
 		ShowStderr: stderr,
 		Details:    httputils.BoolValue(r, "details"),
+		Reorder:    r.Form.Get("reorder"),
+	}
+	for _, c := range r.Form["cursor"] {
+		kv := strings.SplitN(c, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return errdefs.InvalidParameter(fmt.Errorf("invalid cursor %q: the format is <task ID>=<timestamp>", c))
+		}
+		if logsConfig.Cursors == nil {
+			logsConfig.Cursors = make(map[string]string)
+		}
+		logsConfig.Cursors[kv[0]] = kv[1]
 	}
 
 	tty := false
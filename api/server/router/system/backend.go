@@ -16,7 +16,9 @@ type Backend interface {
 	SystemInfo() (*types.Info, error)
 	SystemVersion() types.Version
 	SystemDiskUsage(ctx context.Context) (*types.DiskUsage, error)
+	MigrateStorage(ctx context.Context, options types.StorageMigrateOptions) (*types.StorageMigrateReport, error)
 	SubscribeToEvents(since, until time.Time, ef filters.Args) ([]events.Message, chan interface{})
+	SubscribeToEventsAfter(seq uint64, until time.Time, ef filters.Args) ([]events.Message, chan interface{})
 	UnsubscribeFromEvents(chan interface{})
 	AuthenticateToRegistry(ctx context.Context, authConfig *types.AuthConfig) (string, string, error)
 }
@@ -0,0 +1,64 @@
+package system // import "github.com/docker/docker/api/server/router/system"
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// eventStreamKeepAlive is the interval of the comments sent to the clients of
+// the server-sent events streams, for the proxies not to close the idle
+// streams.
+const eventStreamKeepAlive = 15 * time.Second
+
+// acceptsEventStream returns whether the client of a request accepts the
+// events as server-sent events.
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+// eventEncoder encodes the events sent to the clients.
+type eventEncoder interface {
+	Encode(v interface{}) error
+}
+
+// sseEncoder encodes the events as server-sent events, identified by their
+// sequence numbers and named by their types. The clients resume the stream
+// after the last event they received with the Last-Event-ID header.
+type sseEncoder struct {
+	w io.Writer
+}
+
+func (e sseEncoder) Encode(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if ev, ok := v.(events.Message); ok {
+		if ev.Seq > 0 {
+			buf.WriteString("id: " + strconv.FormatUint(ev.Seq, 10) + "\n")
+		}
+		if ev.Type != "" {
+			buf.WriteString("event: " + ev.Type + "\n")
+		}
+	}
+	buf.WriteString("data: ")
+	buf.Write(b)
+	buf.WriteString("\n\n")
+	// the event is written at once, the writer flushing each write
+	_, err = e.w.Write(buf.Bytes())
+	return err
+}
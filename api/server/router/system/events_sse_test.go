@@ -0,0 +1,108 @@
+package system // import "github.com/docker/docker/api/server/router/system"
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// eventsBackend is a backend replaying an event, recording the event after
+// which the events are replayed.
+type eventsBackend struct {
+	Backend
+	after uint64
+}
+
+func (b *eventsBackend) SubscribeToEvents(since, until time.Time, ef filters.Args) ([]events.Message, chan interface{}) {
+	return []events.Message{{Type: events.ContainerEventType, Action: "start", Seq: 1}}, make(chan interface{})
+}
+
+func (b *eventsBackend) SubscribeToEventsAfter(seq uint64, until time.Time, ef filters.Args) ([]events.Message, chan interface{}) {
+	b.after = seq
+	return []events.Message{{Type: events.ContainerEventType, Action: "start", Seq: seq + 1}}, make(chan interface{})
+}
+
+func (b *eventsBackend) UnsubscribeFromEvents(chan interface{}) {}
+
+func TestSSEEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := sseEncoder{w: &buf}
+
+	assert.NilError(t, enc.Encode(events.Message{Type: events.ContainerEventType, Action: "start", Seq: 42}))
+	assert.Check(t, is.Equal(buf.String(), "id: 42\nevent: container\ndata: "+`{"Type":"container","Action":"start","Actor":{"ID":"","Attributes":null},"seq":42}`+"\n\n"))
+
+	// the events without a sequence number or type have no id or name
+	buf.Reset()
+	assert.NilError(t, enc.Encode(events.Message{Action: "start"}))
+	assert.Check(t, is.Equal(buf.String(), "data: "+`{"Type":"","Action":"start","Actor":{"ID":"","Attributes":null}}`+"\n\n"))
+
+	buf.Reset()
+	assert.NilError(t, enc.Encode(map[string]string{"status": "ok"}))
+	assert.Check(t, is.Equal(buf.String(), "data: {\"status\":\"ok\"}\n\n"))
+}
+
+func TestAcceptsEventStream(t *testing.T) {
+	for _, tc := range []struct {
+		accept string
+		sse    bool
+	}{
+		{accept: "", sse: false},
+		{accept: "application/json", sse: false},
+		{accept: "text/event-stream", sse: true},
+		{accept: "Text/Event-Stream", sse: true},
+		{accept: "application/json, text/event-stream;q=0.9", sse: true},
+		{accept: "text/event-stream-v2", sse: false},
+		{accept: "text/*", sse: false},
+		{accept: "text/event-stream;=", sse: false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/events", nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		assert.Check(t, is.Equal(acceptsEventStream(r), tc.sse), "%q", tc.accept)
+	}
+}
+
+func TestEventsLastEventID(t *testing.T) {
+	until := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	getEvents := func(target, accept, lastEventID string) (*eventsBackend, *httptest.ResponseRecorder, error) {
+		b := &eventsBackend{}
+		s := &systemRouter{backend: b}
+		r := httptest.NewRequest(http.MethodGet, target+"&until="+until, nil)
+		r.Header.Set("Accept", accept)
+		r.Header.Set("Last-Event-ID", lastEventID)
+		w := httptest.NewRecorder()
+		return b, w, s.getEvents(context.Background(), w, r, nil)
+	}
+
+	// the clients of the server-sent events resume the stream after the last
+	// event they received
+	b, w, err := getEvents("/events?", "text/event-stream", "41")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(b.after, uint64(41)))
+	assert.Check(t, is.Equal(w.Header().Get("Content-Type"), "text/event-stream"))
+	assert.Check(t, is.Contains(w.Body.String(), "id: 42\nevent: container\n"))
+
+	// the cursor of the request takes precedence
+	b, _, err = getEvents("/events?cursor=7", "text/event-stream", "41")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(b.after, uint64(7)))
+
+	// the header is ignored by the other clients
+	b, w, err = getEvents("/events?", "application/json", "41")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(b.after, uint64(0)))
+	assert.Check(t, is.Equal(w.Header().Get("Content-Type"), "application/json"))
+
+	_, _, err = getEvents("/events?", "text/event-stream", "last")
+	assert.Check(t, is.Error(err, "invalid cursor: last"))
+}
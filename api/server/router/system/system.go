@@ -35,6 +35,7 @@ func NewRouter(b Backend, c ClusterBackend, fscache *fscache.FSCache, builder *b
 		router.NewGetRoute("/version", r.getVersion),
 		router.NewGetRoute("/system/df", r.getDiskUsage, router.WithCancel),
 		router.NewPostRoute("/auth", r.postAuth),
+		router.NewPostRoute("/system/storage/migrate", r.postStorageMigrate, router.WithCancel),
 	}
 
 	return r
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/server/httputils"
@@ -150,6 +151,28 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 		return err
 	}
 
+	limit, err := httputils.Int64ValueOrDefault(r, "limit", 0)
+	if err != nil || limit < 0 {
+		return invalidRequestError{fmt.Errorf("invalid limit: %s", r.Form.Get("limit"))}
+	}
+	// the cursor is the sequence number of the last event of the previous
+	// page, the events after it being replayed
+	var cursor uint64
+	sse := acceptsEventStream(r)
+	v := r.Form.Get("cursor")
+	if v == "" && sse {
+		// the clients of the server-sent events resume the stream after
+		// the last event they received when they reconnect
+		v = r.Header.Get("Last-Event-ID")
+	}
+	if v != "" {
+		if cursor, err = strconv.ParseUint(v, 10, 64); err != nil || cursor == 0 {
+			return invalidRequestError{fmt.Errorf("invalid cursor: %s", v)}
+		}
+	} else if limit > 0 && since.IsZero() {
+		since = time.Unix(0, 0)
+	}
+
 	var (
 		timeout        <-chan time.Time
 		onlyPastEvents bool
@@ -168,21 +191,55 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 			timeout = time.After(dur)
 		}
 	}
+	if limit > 0 {
+		// a page of the past events is returned, the events logged after
+		// it being returned with the next pages
+		onlyPastEvents = true
+	}
 
 	ef, err := filters.FromJSON(r.Form.Get("filters"))
 	if err != nil {
 		return err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	var (
+		enc       eventEncoder
+		keepAlive <-chan time.Time
+	)
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// the proxies buffering the responses would delay the events
+		w.Header().Set("X-Accel-Buffering", "no")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	output := ioutils.NewWriteFlusher(w)
 	defer output.Close()
 	output.Flush()
 
-	enc := json.NewEncoder(output)
+	if sse {
+		enc = sseEncoder{w: output}
+		ticker := time.NewTicker(eventStreamKeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	} else {
+		enc = json.NewEncoder(output)
+	}
 
-	buffered, l := s.backend.SubscribeToEvents(since, until, ef)
+	var (
+		buffered []events.Message
+		l        chan interface{}
+	)
+	if cursor > 0 {
+		buffered, l = s.backend.SubscribeToEventsAfter(cursor, until, ef)
+	} else {
+		buffered, l = s.backend.SubscribeToEvents(since, until, ef)
+	}
 	defer s.backend.UnsubscribeFromEvents(l)
+	if limit > 0 && int64(len(buffered)) > limit {
+		buffered = buffered[:limit]
+	}
 
 	for _, ev := range buffered {
 		if err := enc.Encode(ev); err != nil {
@@ -205,6 +262,11 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 			if err := enc.Encode(jev); err != nil {
 				return err
 			}
+		case <-keepAlive:
+			// the clients ignore the comments
+			if _, err := output.Write([]byte(": keep-alive\n\n")); err != nil {
+				return err
+			}
 		case <-timeout:
 			return nil
 		case <-ctx.Done():
@@ -231,6 +293,17 @@ func (s *systemRouter) postAuth(ctx context.Context, w http.ResponseWriter, r *h
 	})
 }
 
+func (s *systemRouter) postStorageMigrate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	report, err := s.backend.MigrateStorage(ctx, types.StorageMigrateOptions{Snapshotter: r.Form.Get("snapshotter")})
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, report)
+}
+
 func eventTime(formTime string) (time.Time, error) {
 	t, tNano, err := timetypes.ParseTimestamps(formTime, -1)
 	if err != nil {
@@ -2,6 +2,7 @@ package volume // import "github.com/docker/docker/api/server/router/volume"
 
 import (
 	"context"
+	"io"
 
 	"github.com/docker/docker/volume/service/opts"
 	// TODO return types need to be refactored into pkg
@@ -17,4 +18,10 @@ type Backend interface {
 	Create(ctx context.Context, name, driverName string, opts ...opts.CreateOption) (*types.Volume, error)
 	Remove(ctx context.Context, name string, opts ...opts.RemoveOption) error
 	Prune(ctx context.Context, pruneFilters filters.Args) (*types.VolumesPruneReport, error)
+	Snapshot(ctx context.Context, name, snapshot string) (*types.VolumeSnapshot, error)
+	Snapshots(ctx context.Context, name string) ([]types.VolumeSnapshot, error)
+	RestoreSnapshot(ctx context.Context, name, snapshot string) error
+	RemoveSnapshot(ctx context.Context, name, snapshot string) error
+	Export(ctx context.Context, name string, compress bool, out io.Writer) error
+	Import(ctx context.Context, name, driverName string, in io.Reader, opts ...opts.CreateOption) (*types.Volume, error)
 }
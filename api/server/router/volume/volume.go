@@ -26,11 +26,17 @@ func (r *volumeRouter) initRoutes() {
 	r.routes = []router.Route{
 		// GET
 		router.NewGetRoute("/volumes", r.getVolumesList),
+		router.NewGetRoute("/volumes/{name:.*}/snapshots", r.getVolumeSnapshots),
+		router.NewGetRoute("/volumes/{name:.*}/export", r.getVolumeExport),
 		router.NewGetRoute("/volumes/{name:.*}", r.getVolumeByName),
 		// POST
 		router.NewPostRoute("/volumes/create", r.postVolumesCreate),
 		router.NewPostRoute("/volumes/prune", r.postVolumesPrune, router.WithCancel),
+		router.NewPostRoute("/volumes/import", r.postVolumesImport),
+		router.NewPostRoute("/volumes/{name:.*}/snapshot", r.postVolumeSnapshot),
+		router.NewPostRoute("/volumes/{name:.*}/snapshots/{snapshot:.*}/restore", r.postVolumeSnapshotRestore),
 		// DELETE
+		router.NewDeleteRoute("/volumes/{name:.*}/snapshots/{snapshot:.*}", r.deleteVolumeSnapshot),
 		router.NewDeleteRoute("/volumes/{name:.*}", r.deleteVolumes),
 	}
 }
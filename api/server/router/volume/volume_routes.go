@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"sort"
 
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types/filters"
@@ -23,10 +24,25 @@ func (v *volumeRouter) getVolumesList(ctx context.Context, w http.ResponseWriter
 	if err != nil {
 		return errdefs.InvalidParameter(errors.Wrap(err, "error reading volume filters"))
 	}
+	limit, err := httputils.Int64ValueOrDefault(r, "limit", 0)
+	if err != nil || limit < 0 {
+		return errdefs.InvalidParameter(errors.Errorf("invalid limit: %s", r.Form.Get("limit")))
+	}
 	volumes, warnings, err := v.backend.List(ctx, filters)
 	if err != nil {
 		return err
 	}
+	if cursor := r.Form.Get("cursor"); cursor != "" || limit > 0 {
+		// the pages of volumes are listed by name
+		sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+		if cursor != "" {
+			i := sort.Search(len(volumes), func(i int) bool { return volumes[i].Name > cursor })
+			volumes = volumes[i:]
+		}
+		if limit > 0 && int64(len(volumes)) > limit {
+			volumes = volumes[:limit]
+		}
+	}
 	return httputils.WriteJSON(w, http.StatusOK, &volumetypes.VolumeListOKBody{Volumes: volumes, Warnings: warnings})
 }
 
@@ -71,7 +87,14 @@ func (v *volumeRouter) deleteVolumes(ctx context.Context, w http.ResponseWriter,
 		return err
 	}
 	force := httputils.BoolValue(r, "force")
-	if err := v.backend.Remove(ctx, vars["name"], opts.WithPurgeOnError(force)); err != nil {
+	rmOpts := []opts.RemoveOption{opts.WithPurgeOnError(force)}
+	kill := httputils.BoolValue(r, "kill")
+	if r.Form.Get("force") == "detach" {
+		rmOpts = append(rmOpts, opts.WithDetach(kill))
+	} else if kill {
+		return errdefs.InvalidParameter(errors.New("killing the processes holding a volume requires force=detach"))
+	}
+	if err := v.backend.Remove(ctx, vars["name"], rmOpts...); err != nil {
 		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -94,3 +117,84 @@ func (v *volumeRouter) postVolumesPrune(ctx context.Context, w http.ResponseWrit
 	}
 	return httputils.WriteJSON(w, http.StatusOK, pruneReport)
 }
+
+func (v *volumeRouter) postVolumeSnapshot(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	snapshot, err := v.backend.Snapshot(ctx, vars["name"], r.Form.Get("snapshot"))
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, snapshot)
+}
+
+func (v *volumeRouter) getVolumeSnapshots(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	snapshots, err := v.backend.Snapshots(ctx, vars["name"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, snapshots)
+}
+
+func (v *volumeRouter) postVolumeSnapshotRestore(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := v.backend.RestoreSnapshot(ctx, vars["name"], vars["snapshot"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (v *volumeRouter) deleteVolumeSnapshot(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := v.backend.RemoveSnapshot(ctx, vars["name"], vars["snapshot"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (v *volumeRouter) getVolumeExport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	return v.backend.Export(ctx, vars["name"], httputils.BoolValue(r, "compress"), w)
+}
+
+func (v *volumeRouter) postVolumesImport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	var driverOpts, labels map[string]string
+	if s := r.Form.Get("driverOpts"); s != "" {
+		if err := json.Unmarshal([]byte(s), &driverOpts); err != nil {
+			return errdefs.InvalidParameter(errors.Wrap(err, "invalid driverOpts"))
+		}
+	}
+	if s := r.Form.Get("labels"); s != "" {
+		if err := json.Unmarshal([]byte(s), &labels); err != nil {
+			return errdefs.InvalidParameter(errors.Wrap(err, "invalid labels"))
+		}
+	}
+
+	volume, err := v.backend.Import(ctx, r.Form.Get("name"), r.Form.Get("driver"), r.Body, opts.WithCreateOptions(driverOpts), opts.WithCreateLabels(labels))
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, volume)
+}
@@ -0,0 +1,181 @@
+package rpc // import "github.com/docker/docker/api/server/rpc"
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *server) ListContainers(ctx context.Context, req *engine.ListContainersRequest) (*engine.ListContainersResponse, error) {
+	query := url.Values{}
+	boolParam(query, "all", req.All)
+	if err := filtersParam(query, req.Filters); err != nil {
+		return nil, err
+	}
+	var containers []types.Container
+	if err := s.callJSON(ctx, "GET", "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+	resp := &engine.ListContainersResponse{}
+	for _, c := range containers {
+		resp.Containers = append(resp.Containers, &engine.Container{
+			ID:      c.ID,
+			Names:   c.Names,
+			Image:   c.Image,
+			ImageID: c.ImageID,
+			Command: c.Command,
+			Created: c.Created,
+			State:   c.State,
+			Status:  c.Status,
+			Labels:  c.Labels,
+		})
+	}
+	return resp, nil
+}
+
+func (s *server) StartContainer(ctx context.Context, req *engine.StartContainerRequest) (*engine.StartContainerResponse, error) {
+	if err := s.callJSON(ctx, "POST", "/containers/"+url.PathEscape(req.ID)+"/start", nil, nil, nil); err != nil {
+		return nil, err
+	}
+	return &engine.StartContainerResponse{}, nil
+}
+
+func (s *server) StopContainer(ctx context.Context, req *engine.StopContainerRequest) (*engine.StopContainerResponse, error) {
+	query := url.Values{}
+	if req.Timeout > 0 {
+		query.Set("t", strconv.FormatInt(req.Timeout, 10))
+	}
+	if err := s.callJSON(ctx, "POST", "/containers/"+url.PathEscape(req.ID)+"/stop", query, nil, nil); err != nil {
+		return nil, err
+	}
+	return &engine.StopContainerResponse{}, nil
+}
+
+func (s *server) RemoveContainer(ctx context.Context, req *engine.RemoveContainerRequest) (*engine.RemoveContainerResponse, error) {
+	query := url.Values{}
+	boolParam(query, "force", req.Force)
+	boolParam(query, "v", req.RemoveVolumes)
+	if err := s.callJSON(ctx, "DELETE", "/containers/"+url.PathEscape(req.ID), query, nil, nil); err != nil {
+		return nil, err
+	}
+	return &engine.RemoveContainerResponse{}, nil
+}
+
+func (s *server) ContainerLogs(req *engine.ContainerLogsRequest, stream engine.Engine_ContainerLogsServer) error {
+	ctx := stream.Context()
+	// the logs of the containers with a TTY are not multiplexed
+	var c types.ContainerJSON
+	if err := s.callJSON(ctx, "GET", "/containers/"+url.PathEscape(req.ID)+"/json", nil, nil, &c); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	boolParam(query, "follow", req.Follow)
+	boolParam(query, "stdout", req.Stdout)
+	boolParam(query, "stderr", req.Stderr)
+	boolParam(query, "timestamps", req.Timestamps)
+	for k, v := range map[string]string{"since": req.Since, "until": req.Until, "tail": req.Tail} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	resp, err := s.call(ctx, "GET", "/containers/"+url.PathEscape(req.ID)+"/logs", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.body.Close()
+
+	if c.Config != nil && c.Config.Tty {
+		_, err = io.Copy(&logWriter{stream: stream}, resp.body)
+	} else {
+		_, err = stdcopy.StdCopy(&logWriter{stream: stream, name: "stdout"}, &logWriter{stream: stream, name: "stderr"}, resp.body)
+	}
+	return streamError(err)
+}
+
+// logWriter sends the logs written to it as log entries of a stream
+type logWriter struct {
+	stream engine.Engine_ContainerLogsServer
+	name   string
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+	if err := w.stream.Send(&engine.LogEntry{Stream: w.name, Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *server) ContainerStats(req *engine.ContainerStatsRequest, stream engine.Engine_ContainerStatsServer) error {
+	query := url.Values{}
+	query.Set("stream", strconv.FormatBool(req.Stream))
+	resp, err := s.call(stream.Context(), "GET", "/containers/"+url.PathEscape(req.ID)+"/stats", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.body.Close()
+
+	dec := json.NewDecoder(resp.body)
+	for {
+		var stats types.StatsJSON
+		if err := dec.Decode(&stats); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return streamError(err)
+		}
+		if err := stream.Send(containerStats(&stats)); err != nil {
+			return err
+		}
+	}
+}
+
+func containerStats(stats *types.StatsJSON) *engine.ContainerStats {
+	s := &engine.ContainerStats{
+		ID:             stats.ID,
+		CPUTotalUsage:  stats.CPUStats.CPUUsage.TotalUsage,
+		SystemCPUUsage: stats.CPUStats.SystemUsage,
+		OnlineCPUs:     stats.CPUStats.OnlineCPUs,
+		MemoryUsage:    stats.MemoryStats.Usage,
+		MemoryLimit:    stats.MemoryStats.Limit,
+		Pids:           stats.PidsStats.Current,
+	}
+	if !stats.Read.IsZero() {
+		s.ReadTimeNano = stats.Read.UnixNano()
+	}
+	for _, n := range stats.Networks {
+		s.NetworkRxBytes += n.RxBytes
+		s.NetworkTxBytes += n.TxBytes
+	}
+	for _, e := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch e.Op {
+		case "Read", "read":
+			s.BlockReadBytes += e.Value
+		case "Write", "write":
+			s.BlockWriteBytes += e.Value
+		}
+	}
+	return s
+}
+
+// streamError returns the gRPC error of an error streaming the response of a
+// route, the errors of the sends to the clients being returned as is.
+func streamError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
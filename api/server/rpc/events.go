@@ -0,0 +1,51 @@
+package rpc // import "github.com/docker/docker/api/server/rpc"
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types/events"
+)
+
+func (s *server) Events(req *engine.EventsRequest, stream engine.Engine_EventsServer) error {
+	query := url.Values{}
+	if req.Since != "" {
+		query.Set("since", req.Since)
+	}
+	if req.Until != "" {
+		query.Set("until", req.Until)
+	}
+	if err := filtersParam(query, req.Filters); err != nil {
+		return err
+	}
+	resp, err := s.call(stream.Context(), "GET", "/events", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.body.Close()
+
+	dec := json.NewDecoder(resp.body)
+	for {
+		var m events.Message
+		if err := dec.Decode(&m); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return streamError(err)
+		}
+		err := stream.Send(&engine.Event{
+			Type:            m.Type,
+			Action:          m.Action,
+			ActorID:         m.Actor.ID,
+			ActorAttributes: m.Actor.Attributes,
+			Scope:           m.Scope,
+			TimeNano:        m.TimeNano,
+			Seq:             m.Seq,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
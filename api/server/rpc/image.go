@@ -0,0 +1,54 @@
+package rpc // import "github.com/docker/docker/api/server/rpc"
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types"
+)
+
+func (s *server) ListImages(ctx context.Context, req *engine.ListImagesRequest) (*engine.ListImagesResponse, error) {
+	query := url.Values{}
+	boolParam(query, "all", req.All)
+	if err := filtersParam(query, req.Filters); err != nil {
+		return nil, err
+	}
+	var images []types.ImageSummary
+	if err := s.callJSON(ctx, "GET", "/images/json", query, nil, &images); err != nil {
+		return nil, err
+	}
+	resp := &engine.ListImagesResponse{}
+	for _, img := range images {
+		resp.Images = append(resp.Images, &engine.Image{
+			ID:          img.ID,
+			ParentID:    img.ParentID,
+			RepoTags:    img.RepoTags,
+			RepoDigests: img.RepoDigests,
+			Created:     img.Created,
+			Size_:       img.Size,
+			Labels:      img.Labels,
+		})
+	}
+	return resp, nil
+}
+
+func (s *server) RemoveImage(ctx context.Context, req *engine.RemoveImageRequest) (*engine.RemoveImageResponse, error) {
+	query := url.Values{}
+	boolParam(query, "force", req.Force)
+	boolParam(query, "noprune", req.NoPrune)
+	var items []types.ImageDeleteResponseItem
+	if err := s.callJSON(ctx, "DELETE", "/images/"+url.PathEscape(req.Image), query, nil, &items); err != nil {
+		return nil, err
+	}
+	resp := &engine.RemoveImageResponse{}
+	for _, item := range items {
+		if item.Untagged != "" {
+			resp.Untagged = append(resp.Untagged, item.Untagged)
+		}
+		if item.Deleted != "" {
+			resp.Deleted = append(resp.Deleted, item.Deleted)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,232 @@
+// Package rpc serves the Engine gRPC service with the routes of the REST
+// API, for the calls to be authorized, rate limited, instrumented and traced
+// by the middlewares of the REST API like its requests.
+package rpc // import "github.com/docker/docker/api/server/rpc"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer creates a gRPC server serving the Engine service with the routes
+// of the REST API served by handler.
+func NewServer(handler http.Handler) *grpc.Server {
+	s := grpc.NewServer()
+	engine.RegisterEngineServer(s, &server{handler: handler})
+	return s
+}
+
+type server struct {
+	handler http.Handler
+}
+
+// call calls a route of the REST API, returning the response once the status
+// of the route is written, or the error of the route. The body of the
+// response is streamed until the route returns, the route being canceled
+// when ctx is.
+func (s *server) call(ctx context.Context, method, path string, query url.Values, body interface{}) (*response, error) {
+	var b io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		b = bytes.NewReader(buf)
+	}
+	u := "/v" + api.DefaultVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	r, err := http.NewRequest(method, u, b)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	r = r.WithContext(ctx)
+	r.RequestURI = r.URL.RequestURI()
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			r.Header.Set("User-Agent", ua[0])
+		}
+	}
+	// the routes identify the clients of the calls as the clients of the
+	// requests
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			r.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &info.State
+		}
+	}
+
+	pr, pw := io.Pipe()
+	w := &response{
+		header:  make(http.Header),
+		started: make(chan struct{}),
+		body:    pr,
+		pw:      pw,
+		ctx:     ctx,
+	}
+	go func() {
+		s.handler.ServeHTTP(w, r)
+		w.WriteHeader(http.StatusOK)
+		pw.Close()
+	}()
+
+	select {
+	case <-w.started:
+	case <-ctx.Done():
+		pr.CloseWithError(ctx.Err())
+		return nil, status.Error(codes.Canceled, ctx.Err().Error())
+	}
+	if w.status >= http.StatusBadRequest {
+		defer pr.Close()
+		return nil, errorFromResponse(w)
+	}
+	return w, nil
+}
+
+// callJSON calls a route of the REST API, decoding its JSON response into v
+// when it is not nil.
+func (s *server) callJSON(ctx context.Context, method, path string, query url.Values, body, v interface{}) error {
+	resp, err := s.call(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.body.Close()
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.body).Decode(v); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// errorFromResponse returns the gRPC error of the error response of a route
+func errorFromResponse(w *response) error {
+	msg, err := ioutil.ReadAll(w.body)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	var errResp types.ErrorResponse
+	if json.Unmarshal(msg, &errResp) == nil && errResp.Message != "" {
+		msg = []byte(errResp.Message)
+	}
+	return status.Error(codeFromHTTPStatus(w.status), strings.TrimSpace(string(msg)))
+}
+
+// codeFromHTTPStatus returns the gRPC code of the status of an error response
+// of the REST API.
+func codeFromHTTPStatus(s int) codes.Code {
+	switch s {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// filtersParam returns the filters parameter of the REST API of the filters
+// of a call, in the key=value format of the --filter flags of the CLI.
+func filtersParam(query url.Values, flags []string) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	args := filters.NewArgs()
+	for _, f := range flags {
+		var err error
+		if args, err = filters.ParseFlag(f, args); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	p, err := filters.ToJSON(args)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	query.Set("filters", p)
+	return nil
+}
+
+func boolParam(query url.Values, key string, v bool) {
+	if v {
+		query.Set(key, "1")
+	}
+}
+
+// response is the http.ResponseWriter of the calls to the routes of the REST
+// API, streaming the body written by the routes.
+type response struct {
+	header  http.Header
+	status  int
+	once    sync.Once
+	started chan struct{}
+	body    *io.PipeReader
+	pw      *io.PipeWriter
+	ctx     context.Context
+}
+
+func (w *response) Header() http.Header {
+	return w.header
+}
+
+func (w *response) WriteHeader(s int) {
+	w.once.Do(func() {
+		w.status = s
+		close(w.started)
+	})
+}
+
+func (w *response) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pw.Write(b)
+}
+
+// Flush implements http.Flusher, the body being streamed as it is written.
+func (w *response) Flush() {}
+
+// CloseNotify implements http.CloseNotifier, notifying the routes when the
+// call is canceled.
+func (w *response) CloseNotify() <-chan bool {
+	c := make(chan bool, 1)
+	go func() {
+		<-w.ctx.Done()
+		c <- true
+	}()
+	return c
+}
@@ -0,0 +1,167 @@
+package rpc // import "github.com/docker/docker/api/server/rpc"
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) (engine.EngineClient, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	s := NewServer(handler)
+	go s.Serve(l)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	assert.NilError(t, err)
+	return engine.NewEngineClient(conn), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestListContainers(t *testing.T) {
+	m := mux.NewRouter()
+	m.Path("/v{version}/containers/json").Methods("GET").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		args, err := filters.FromJSON(r.FormValue("filters"))
+		assert.Check(t, err)
+		assert.Check(t, is.Equal(r.FormValue("all"), "1"))
+		assert.Check(t, args.ExactMatch("label", "app=web"))
+		writeJSON(w, http.StatusOK, []types.Container{
+			{ID: "c1", Names: []string{"/web"}, State: "running", Labels: map[string]string{"app": "web"}},
+		})
+	})
+	c, closeClient := newTestClient(t, m)
+	defer closeClient()
+
+	resp, err := c.ListContainers(context.Background(), &engine.ListContainersRequest{All: true, Filters: []string{"label=app=web"}})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(resp.Containers, 1))
+	assert.Check(t, is.Equal(resp.Containers[0].ID, "c1"))
+	assert.Check(t, is.DeepEqual(resp.Containers[0].Names, []string{"/web"}))
+	assert.Check(t, is.Equal(resp.Containers[0].Labels["app"], "web"))
+
+	_, err = c.ListContainers(context.Background(), &engine.ListContainersRequest{Filters: []string{"label"}})
+	assert.Check(t, is.Equal(status.Code(err), codes.InvalidArgument))
+}
+
+func TestErrors(t *testing.T) {
+	m := mux.NewRouter()
+	m.Path("/v{version}/containers/{name}/start").Methods("POST").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch mux.Vars(r)["name"] {
+		case "running":
+			w.WriteHeader(http.StatusNotModified)
+		case "denied":
+			writeJSON(w, http.StatusForbidden, &types.ErrorResponse{Message: "authorization denied by plugin authz: not allowed"})
+		default:
+			writeJSON(w, http.StatusNotFound, &types.ErrorResponse{Message: "No such container: " + mux.Vars(r)["name"]})
+		}
+	})
+	c, closeClient := newTestClient(t, m)
+	defer closeClient()
+
+	// the containers already started are not errors
+	_, err := c.StartContainer(context.Background(), &engine.StartContainerRequest{ID: "running"})
+	assert.Check(t, err)
+
+	_, err = c.StartContainer(context.Background(), &engine.StartContainerRequest{ID: "denied"})
+	assert.Check(t, is.Equal(status.Code(err), codes.PermissionDenied))
+	assert.Check(t, is.Equal(status.Convert(err).Message(), "authorization denied by plugin authz: not allowed"))
+
+	_, err = c.StartContainer(context.Background(), &engine.StartContainerRequest{ID: "missing"})
+	assert.Check(t, is.Equal(status.Code(err), codes.NotFound))
+	assert.Check(t, is.Equal(status.Convert(err).Message(), "No such container: missing"))
+}
+
+func TestContainerLogs(t *testing.T) {
+	m := mux.NewRouter()
+	m.Path("/v{version}/containers/{name}/json").Methods("GET").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, &types.ContainerJSON{Config: &container.Config{Tty: mux.Vars(r)["name"] == "tty"}})
+	})
+	m.Path("/v{version}/containers/{name}/logs").Methods("GET").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Check(t, is.Equal(r.FormValue("tail"), "10"))
+		w.WriteHeader(http.StatusOK)
+		if mux.Vars(r)["name"] == "tty" {
+			w.Write([]byte("raw"))
+			return
+		}
+		stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte("out"))
+		stdcopy.NewStdWriter(w, stdcopy.Stderr).Write([]byte("err"))
+	})
+	c, closeClient := newTestClient(t, m)
+	defer closeClient()
+
+	recv := func(id string) []*engine.LogEntry {
+		stream, err := c.ContainerLogs(context.Background(), &engine.ContainerLogsRequest{ID: id, Stdout: true, Stderr: true, Tail: "10"})
+		assert.NilError(t, err)
+		var entries []*engine.LogEntry
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF {
+				return entries
+			}
+			assert.NilError(t, err)
+			entries = append(entries, e)
+		}
+	}
+
+	entries := recv("c1")
+	assert.Assert(t, is.Len(entries, 2))
+	assert.Check(t, is.Equal(entries[0].Stream, "stdout"))
+	assert.Check(t, is.Equal(string(entries[0].Data), "out"))
+	assert.Check(t, is.Equal(entries[1].Stream, "stderr"))
+	assert.Check(t, is.Equal(string(entries[1].Data), "err"))
+
+	entries = recv("tty")
+	assert.Assert(t, is.Len(entries, 1))
+	assert.Check(t, is.Equal(entries[0].Stream, ""))
+	assert.Check(t, is.Equal(string(entries[0].Data), "raw"))
+}
+
+func TestEvents(t *testing.T) {
+	m := mux.NewRouter()
+	m.Path("/v{version}/events").Methods("GET").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Check(t, is.Equal(r.FormValue("since"), "10"))
+		w.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(w)
+		for i := uint64(1); i <= 2; i++ {
+			enc.Encode(&events.Message{Type: "container", Action: "start", Actor: events.Actor{ID: "c1"}, Seq: i})
+		}
+	})
+	c, closeClient := newTestClient(t, m)
+	defer closeClient()
+
+	stream, err := c.Events(context.Background(), &engine.EventsRequest{Since: "10"})
+	assert.NilError(t, err)
+	for i := uint64(1); i <= 2; i++ {
+		e, err := stream.Recv()
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(e.Seq, i))
+		assert.Check(t, is.Equal(e.Action, "start"))
+		assert.Check(t, is.Equal(e.ActorID, "c1"))
+	}
+	_, err = stream.Recv()
+	assert.Check(t, is.Equal(err, io.EOF))
+}
@@ -0,0 +1,61 @@
+package rpc // import "github.com/docker/docker/api/server/rpc"
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/docker/docker/api/services/engine/v1"
+	"github.com/docker/docker/api/types"
+	volumetypes "github.com/docker/docker/api/types/volume"
+)
+
+func (s *server) ListVolumes(ctx context.Context, req *engine.ListVolumesRequest) (*engine.ListVolumesResponse, error) {
+	query := url.Values{}
+	if err := filtersParam(query, req.Filters); err != nil {
+		return nil, err
+	}
+	var list volumetypes.VolumeListOKBody
+	if err := s.callJSON(ctx, "GET", "/volumes", query, nil, &list); err != nil {
+		return nil, err
+	}
+	resp := &engine.ListVolumesResponse{Warnings: list.Warnings}
+	for _, v := range list.Volumes {
+		resp.Volumes = append(resp.Volumes, volume(v))
+	}
+	return resp, nil
+}
+
+func (s *server) CreateVolume(ctx context.Context, req *engine.CreateVolumeRequest) (*engine.CreateVolumeResponse, error) {
+	body := volumetypes.VolumeCreateBody{
+		Name:       req.Name,
+		Driver:     req.Driver,
+		DriverOpts: req.DriverOpts,
+		Labels:     req.Labels,
+	}
+	var v types.Volume
+	if err := s.callJSON(ctx, "POST", "/volumes/create", nil, body, &v); err != nil {
+		return nil, err
+	}
+	return &engine.CreateVolumeResponse{Volume: volume(&v)}, nil
+}
+
+func (s *server) RemoveVolume(ctx context.Context, req *engine.RemoveVolumeRequest) (*engine.RemoveVolumeResponse, error) {
+	query := url.Values{}
+	boolParam(query, "force", req.Force)
+	if err := s.callJSON(ctx, "DELETE", "/volumes/"+url.PathEscape(req.Name), query, nil, nil); err != nil {
+		return nil, err
+	}
+	return &engine.RemoveVolumeResponse{}, nil
+}
+
+func volume(v *types.Volume) *engine.Volume {
+	return &engine.Volume{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		Scope:      v.Scope,
+		Labels:     v.Labels,
+		Options:    v.Options,
+		CreatedAt:  v.CreatedAt,
+	}
+}
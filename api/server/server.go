@@ -11,9 +11,12 @@ import (
 	"github.com/docker/docker/api/server/middleware"
 	"github.com/docker/docker/api/server/router"
 	"github.com/docker/docker/api/server/router/debug"
+	"github.com/docker/docker/api/server/rpc"
 	"github.com/docker/docker/dockerversion"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+	"golang.org/x/net/http2"
 )
 
 // versionMatcher defines a variable matcher to be parsed by the router
@@ -27,6 +30,10 @@ type Config struct {
 	Version     string
 	SocketGroup string
 	TLSConfig   *tls.Config
+	// GRPC enables the gRPC API on the sockets of the REST API. The
+	// TLSConfig must offer the h2 protocol for the clients connecting
+	// with TLS.
+	GRPC bool
 }
 
 // Server contains instance details for the server
@@ -78,8 +85,19 @@ func (s *Server) Close() {
 // with Serve method for each. It sets createMux() as Handler also.
 func (s *Server) serveAPI() error {
 	var chErrors = make(chan error, len(s.servers))
+	var handler http.Handler = multiplexHandler(s.routerSwapper)
+	if s.cfg.GRPC {
+		handler = grpcHandler(rpc.NewServer(s.routerSwapper), handler)
+	}
 	for _, srv := range s.servers {
-		srv.srv.Handler = s.routerSwapper
+		srv.srv.Handler = handler
+		if s.cfg.GRPC {
+			h2 := &http2.Server{}
+			base := srv.srv
+			srv.l = newHTTP2Listener(srv.l, func(conn net.Conn) {
+				h2.ServeConn(conn, &http2.ServeConnOpts{BaseConfig: base, Handler: handler})
+			})
+		}
 		go func(srv *HTTPServer) {
 			var err error
 			logrus.Infof("API listen on %s", srv.l.Addr())
@@ -130,6 +148,12 @@ func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 		// use intermediate variable to prevent "should not use basic type
 		// string as key in context.WithValue" golint errors
 		ctx := context.WithValue(context.Background(), dockerversion.UAStringKey{}, r.Header.Get("User-Agent"))
+		// pass the span of the request on, for the operations of the
+		// backends to be traced as its children
+		span := trace.FromContext(r.Context())
+		if span != nil {
+			ctx = trace.NewContext(ctx, span)
+		}
 		handlerFunc := s.handlerWithGlobalMiddlewares(handler)
 
 		vars := mux.Vars(r)
@@ -141,6 +165,9 @@ func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 			statusCode := httputils.GetHTTPErrorStatusCode(err)
 			if statusCode >= 500 {
 				logrus.Errorf("Handler for %s %s returned error: %v", r.Method, r.URL.Path, err)
+				if span != nil {
+					span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+				}
 			}
 			httputils.MakeErrorHandler(err)(w, r)
 		}
@@ -173,7 +200,7 @@ func (s *Server) createMux() *mux.Router {
 	logrus.Debug("Registering routers")
 	for _, apiRouter := range s.routers {
 		for _, r := range apiRouter.Routes() {
-			f := s.makeHTTPHandler(r.Handler())
+			f := instrumentHandler(r.Path(), traceHandler(r.Path(), s.makeHTTPHandler(r.Handler())))
 
 			logrus.Debugf("Registering %s, %s", r.Method(), r.Path())
 			m.Path(versionMatcher + r.Path()).Methods(r.Method()).Handler(f)
@@ -184,7 +211,7 @@ func (s *Server) createMux() *mux.Router {
 	debugRouter := debug.NewRouter()
 	s.routers = append(s.routers, debugRouter)
 	for _, r := range debugRouter.Routes() {
-		f := s.makeHTTPHandler(r.Handler())
+		f := instrumentHandler("/debug"+r.Path(), s.makeHTTPHandler(r.Handler()))
 		m.Path("/debug" + r.Path()).Handler(f)
 	}
 
@@ -0,0 +1,44 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/pkg/tracing"
+	"go.opencensus.io/trace"
+)
+
+// traceFormat is the format of the trace context propagated with the API
+// requests.
+var traceFormat = &tracing.HTTPFormat{}
+
+// traceHandler returns a handler tracing the requests of the route served by
+// handler. The spans of the requests are the children of the spans of the
+// trace context propagated with them, if any, and are passed to the handler
+// in the context of the requests.
+func traceHandler(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx  context.Context
+			span *trace.Span
+			name = r.Method + " " + route
+		)
+		if sc, ok := traceFormat.SpanContextFromRequest(r); ok {
+			ctx, span = trace.StartSpanWithRemoteParent(r.Context(), name, sc, trace.WithSpanKind(trace.SpanKindServer))
+		} else {
+			ctx, span = trace.StartSpan(r.Context(), name, trace.WithSpanKind(trace.SpanKindServer))
+		}
+		defer span.End()
+		span.AddAttributes(
+			trace.StringAttribute("http.method", r.Method),
+			trace.StringAttribute("http.route", route),
+			trace.StringAttribute("http.target", r.URL.Path),
+			trace.StringAttribute("http.user_agent", r.UserAgent()),
+		)
+
+		rec := httputils.NewStatusRecorder(w, r)
+		handler(rec, r.WithContext(ctx))
+		span.AddAttributes(trace.Int64Attribute("http.status_code", int64(rec.StatusCode())))
+	}
+}
@@ -0,0 +1,51 @@
+package server // import "github.com/docker/docker/api/server"
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+type testExporter []*trace.SpanData
+
+func (e *testExporter) ExportSpan(s *trace.SpanData) {
+	*e = append(*e, s)
+}
+
+func TestTraceHandler(t *testing.T) {
+	var exporter testExporter
+	trace.RegisterExporter(&exporter)
+	defer trace.UnregisterExporter(&exporter)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+
+	var traceID trace.TraceID
+	handler := traceHandler("/containers/{name:.*}/start", func(w http.ResponseWriter, r *http.Request) {
+		span := trace.FromContext(r.Context())
+		if span == nil {
+			t.Fatal("expected the request to be traced")
+		}
+		traceID = span.SpanContext().TraceID
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("POST", "/containers/abc/start", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler(httptest.NewRecorder(), req)
+
+	if traceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected the trace of the request to be continued, got trace %s", traceID)
+	}
+	if len(exporter) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(exporter))
+	}
+	s := exporter[0]
+	if s.Name != "POST /containers/{name:.*}/start" || s.ParentSpanID.String() != "00f067aa0ba902b7" || !s.HasRemoteParent {
+		t.Fatalf("unexpected span: %+v", s)
+	}
+	if code := s.Attributes["http.status_code"]; code != int64(http.StatusNoContent) {
+		t.Fatalf("expected the status code to be recorded, got %v", code)
+	}
+}
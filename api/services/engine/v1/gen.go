@@ -0,0 +1,3 @@
+//go:generate protoc -I . -I ../../../../vendor -I ../../../../vendor/github.com/gogo/protobuf/protobuf --gogofast_out=plugins=grpc,import_path=github.com/docker/docker/api/services/engine/v1:. engine.proto
+
+package engine // import "github.com/docker/docker/api/services/engine/v1"
@@ -48,6 +48,14 @@ type ContainerCommitOptions struct {
 	Config    *container.Config
 }
 
+// ContainerCloneOptions holds parameters to clone a container.
+type ContainerCloneOptions struct {
+	Name    string
+	Layer   bool
+	Volumes bool
+	Pause   bool
+}
+
 // ContainerExecInspect holds information returned by exec inspect.
 type ContainerExecInspect struct {
 	ExecID      string
@@ -66,6 +74,7 @@ type ContainerListOptions struct {
 	Since   string
 	Before  string
 	Limit   int
+	Cursor  string
 	Filters filters.Args
 }
 
@@ -79,6 +88,16 @@ type ContainerLogsOptions struct {
 	Follow     bool
 	Tail       string
 	Details    bool
+	// Grep is a regular expression the logs lines are filtered with by the
+	// daemon, a plain string matching as a substring.
+	Grep string
+	// Cursors are the backfill cursors of the logs of services and tasks,
+	// the timestamps of the last log lines received by task ID, the logs
+	// of the tasks starting after their cursors.
+	Cursors map[string]string
+	// Reorder is the time the log lines of services and tasks are held,
+	// as a duration, for them to be merged in timestamp order.
+	Reorder string
 }
 
 // ContainerRemoveOptions holds parameters to remove containers.
@@ -105,6 +124,8 @@ type CopyToContainerOptions struct {
 type EventsOptions struct {
 	Since   string
 	Until   string
+	Limit   int
+	Cursor  string
 	Filters filters.Args
 }
 
@@ -230,6 +251,8 @@ type ImageImportOptions struct {
 // ImageListOptions holds parameters to filter the list of images with.
 type ImageListOptions struct {
 	All     bool
+	Limit   int
+	Cursor  string
 	Filters filters.Args
 }
 
@@ -404,3 +427,24 @@ type SwarmUnlockKeyResponse struct {
 type PluginCreateOptions struct {
 	RepoName string
 }
+
+// StorageMigrateOptions holds parameters to migrate the images and the
+// containers to a containerd snapshotter.
+type StorageMigrateOptions struct {
+	Snapshotter string // Snapshotter is the snapshotter to migrate to, the default snapshotter of containerd if empty
+}
+
+// VolumeImportOptions holds parameters to import a volume from a tarball.
+type VolumeImportOptions struct {
+	Name       string            // Name is the name of the volume, generated by the daemon if empty
+	Driver     string            // Driver is the volume driver, the default driver if empty
+	DriverOpts map[string]string // DriverOpts are the options of the volume driver
+	Labels     map[string]string // Labels are the labels of the volume
+}
+
+// VolumeRemoveOptions holds parameters to remove a volume.
+type VolumeRemoveOptions struct {
+	Force  bool // Force forgets the volume even if its driver fails to remove it
+	Detach bool // Detach lazily unmounts the volume if it is still mounted, implying Force
+	Kill   bool // Kill kills the processes holding the volume busy, with Detach
+}
@@ -18,6 +18,19 @@ type ContainerCreateConfig struct {
 	AdjustCPUShares  bool
 }
 
+// ContainerCloneConfig holds arguments for the container clone
+// operation.
+type ContainerCloneConfig struct {
+	// Name is the name of the new container
+	Name string
+	// Layer copies the content of the writable layer to the new container
+	Layer bool
+	// Volumes copies the content of the anonymous volumes to the new container
+	Volumes bool
+	// Pause pauses the container while its filesystem is copied
+	Pause bool
+}
+
 // ContainerRmConfig holds arguments for the container remove
 // operation. This struct is used to tell the backend what operations
 // to perform.
@@ -19,6 +19,9 @@ type ContainerWaitOKBodyError struct {
 // swagger:model ContainerWaitOKBody
 type ContainerWaitOKBody struct {
 
+	// The condition the container reached, one of the conditions of the request
+	Condition WaitCondition `json:"Condition,omitempty"`
+
 	// error
 	// Required: true
 	Error *ContainerWaitOKBodyError `json:"Error"`
@@ -26,4 +29,7 @@ type ContainerWaitOKBody struct {
 	// Exit code of the container
 	// Required: true
 	StatusCode int64 `json:"StatusCode"`
+
+	// Whether the timeout of the request expired before the container reached any of the conditions
+	TimedOut bool `json:"TimedOut,omitempty"`
 }
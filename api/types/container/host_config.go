@@ -104,7 +104,7 @@ func (n NetworkMode) IsDefault() bool {
 
 // IsPrivate indicates whether container uses its private network stack.
 func (n NetworkMode) IsPrivate() bool {
-	return !(n.IsHost() || n.IsContainer())
+	return !(n.IsHost() || n.IsContainer() || n.IsGroup())
 }
 
 // IsContainer indicates whether container uses a container network stack.
@@ -122,6 +122,20 @@ func (n NetworkMode) ConnectedContainer() string {
 	return ""
 }
 
+// IsGroup indicates whether container uses the network stack of a network group.
+func (n NetworkMode) IsGroup() bool {
+	parts := strings.SplitN(string(n), ":", 2)
+	return len(parts) > 1 && parts[0] == "group"
+}
+
+// ConnectedGroup is the name of the network group which network stack this container uses.
+func (n NetworkMode) ConnectedGroup() string {
+	if n.IsGroup() {
+		return strings.SplitN(string(n), ":", 2)[1]
+	}
+	return ""
+}
+
 //UserDefined indicates user-created network
 func (n NetworkMode) UserDefined() string {
 	if n.IsUserDefined() {
@@ -390,6 +404,8 @@ type HostConfig struct {
 	ShmSize         int64             // Total shm memory usage
 	Sysctls         map[string]string `json:",omitempty"` // List of Namespaced sysctls used for the container
 	Runtime         string            `json:",omitempty"` // Runtime to use with this container
+	Annotations     map[string]string `json:",omitempty"` // Arbitrary non-identifying metadata attached to container and provided to the runtime
+	DeviceProfiles  []string          `json:",omitempty"` // List of the device profiles of the daemon, adding their devices, device cgroup rules and groups to the container
 
 	// Applicable to Windows
 	ConsoleSize [2]uint   // Initial console size (height,width)
@@ -407,6 +423,14 @@ type HostConfig struct {
 	// ReadonlyPaths is the list of paths to be set as read-only inside the container (this overrides the default set of paths)
 	ReadonlyPaths []string
 
+	// MaskedPathsAdd and MaskedPathsRemove are the paths added to and removed from the masked paths of the container
+	MaskedPathsAdd    []string `json:",omitempty"`
+	MaskedPathsRemove []string `json:",omitempty"`
+
+	// ReadonlyPathsAdd and ReadonlyPathsRemove are the paths added to and removed from the read-only paths of the container
+	ReadonlyPathsAdd    []string `json:",omitempty"`
+	ReadonlyPathsRemove []string `json:",omitempty"`
+
 	// Run a custom init inside the container, if null, use the daemon's configured settings
 	Init *bool `json:",omitempty"`
 }
@@ -0,0 +1,16 @@
+package container // import "github.com/docker/docker/api/types/container"
+
+// WaitRequest holds the containers to wait for, in a request of the Engine
+// API: POST "/containers/wait"
+type WaitRequest struct {
+	// Containers are the IDs or names of the containers
+	Containers []string
+}
+
+// WaitResult is the result of the wait for a container, streamed in the
+// response of the Engine API: POST "/containers/wait"
+type WaitResult struct {
+	// Container is the ID or name of the container, as in the request
+	Container string
+	ContainerWaitOKBody
+}
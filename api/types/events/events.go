@@ -49,4 +49,8 @@ type Message struct {
 
 	Time     int64 `json:"time,omitempty"`
 	TimeNano int64 `json:"timeNano,omitempty"`
+
+	// Seq is the sequence number of the event, increasing with each event
+	// of the daemon.
+	Seq uint64 `json:"seq,omitempty"`
 }
@@ -0,0 +1,40 @@
+package image // import "github.com/docker/docker/api/types/image"
+
+// TagOperation is an operation of a bulk tag request, adding a tag to an
+// image or removing a reference when Remove is set.
+type TagOperation struct {
+	// Ref is the tag added, or the reference removed.
+	Ref string
+	// Image is the name or ID of the image tagged. It is not set for the
+	// removals.
+	Image  string `json:",omitempty"`
+	Remove bool   `json:",omitempty"`
+}
+
+// TagRequest is the request of a bulk tag operation, the operations being
+// applied in order, all at once or none at all.
+type TagRequest struct {
+	Operations []TagOperation
+}
+
+// TagResult is the result of an operation of a bulk tag request.
+type TagResult struct {
+	// Ref is the reference of the operation, normalized.
+	Ref string
+	// ImageID is the ID of the image the reference was added to, or removed
+	// from.
+	ImageID string `json:",omitempty"`
+	Removed bool   `json:",omitempty"`
+	// Error is the error of the operation, which prevented the operations
+	// from being applied.
+	Error string `json:",omitempty"`
+}
+
+// TagResponse is the response of a bulk tag request, with the results of
+// its operations in order.
+type TagResponse struct {
+	// Applied is whether the operations were applied, which they are not
+	// when any of them fails.
+	Applied bool
+	Results []TagResult
+}
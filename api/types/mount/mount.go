@@ -29,12 +29,30 @@ type Mount struct {
 	Target      string      `json:",omitempty"`
 	ReadOnly    bool        `json:",omitempty"`
 	Consistency Consistency `json:",omitempty"`
+	// Relabel is the SELinux relabeling of the source of the mount, the
+	// sources of the local volumes being shared by default, and the
+	// sources of the binds not being relabeled.
+	Relabel Relabel `json:",omitempty"`
 
 	BindOptions   *BindOptions   `json:",omitempty"`
 	VolumeOptions *VolumeOptions `json:",omitempty"`
 	TmpfsOptions  *TmpfsOptions  `json:",omitempty"`
 }
 
+// Relabel represents the SELinux relabeling of the source of a mount.
+type Relabel string
+
+const (
+	// RelabelShared relabels the source for it to be shared among the
+	// containers, as the "z" mode does.
+	RelabelShared Relabel = "shared"
+	// RelabelPrivate relabels the source for it to be private to the
+	// container, as the "Z" mode does.
+	RelabelPrivate Relabel = "private"
+	// RelabelNone does not relabel the source.
+	RelabelNone Relabel = "none"
+)
+
 // Propagation represents the propagation of a mount.
 type Propagation string
 
@@ -80,6 +98,9 @@ const (
 // BindOptions defines options specific to mounts of type "bind".
 type BindOptions struct {
 	Propagation Propagation `json:",omitempty"`
+	// ReadOnlyRecursive makes the mounts below the source read-only too,
+	// with ReadOnly. It requires Linux 5.12, and a private propagation.
+	ReadOnlyRecursive bool `json:",omitempty"`
 }
 
 // VolumeOptions represents the options for a mount of type volume.
@@ -87,6 +108,10 @@ type VolumeOptions struct {
 	NoCopy       bool              `json:",omitempty"`
 	Labels       map[string]string `json:",omitempty"`
 	DriverConfig *Driver           `json:",omitempty"`
+	// Subpath is the path, relative to the root of the volume, of the
+	// directory or file of the volume to mount instead of the whole volume.
+	// It must exist and not escape the volume.
+	Subpath string `json:",omitempty"`
 }
 
 // Driver represents a volume driver.
@@ -108,6 +133,16 @@ type TmpfsOptions struct {
 	SizeBytes int64 `json:",omitempty"`
 	// Mode of the tmpfs upon creation
 	Mode os.FileMode `json:",omitempty"`
+	// UID is the owner of the root of the tmpfs upon creation
+	UID int `json:",omitempty"`
+	// GID is the group of the root of the tmpfs upon creation
+	GID int `json:",omitempty"`
+	// NoSwap prevents the content of the tmpfs from being swapped out. It
+	// requires Linux 6.4.
+	NoSwap bool `json:",omitempty"`
+	// HugePages is the policy of the tmpfs to use huge pages: "never",
+	// "always", "within_size" or "advise".
+	HugePages string `json:",omitempty"`
 
 	// TODO(stevvooe): There are several more tmpfs flags, specified in the
 	// daemon, that are accepted. Only the most basic are added for now.
@@ -118,11 +153,12 @@ type TmpfsOptions struct {
 	// 	"":          true,
 	// 	"size":      true, X
 	// 	"mode":      true, X
-	// 	"uid":       true,
-	// 	"gid":       true,
+	// 	"uid":       true, X
+	// 	"gid":       true, X
 	// 	"nr_inodes": true,
 	// 	"nr_blocks": true,
 	// 	"mpol":      true,
+	// 	"huge":      true, X
 	// }
 	//
 	// Some of these may be straightforward to add, but others, such as
@@ -25,6 +25,14 @@ type IPAMConfig struct {
 	AuxAddress map[string]string `json:"AuxiliaryAddresses,omitempty"`
 }
 
+// DNSConfig represents the DNS configuration of the containers attached to
+// a network
+type DNSConfig struct {
+	Nameservers []string `json:",omitempty"`
+	Search      []string `json:",omitempty"`
+	Options     []string `json:",omitempty"`
+}
+
 // EndpointIPAMConfig represents IPAM configurations for the endpoint
 type EndpointIPAMConfig struct {
 	IPv4Address  string   `json:",omitempty"`
@@ -63,6 +71,10 @@ type EndpointSettings struct {
 	GlobalIPv6PrefixLen int
 	MacAddress          string
 	DriverOpts          map[string]string
+	// GwPriority determines which endpoint provides the default gateway of
+	// the container: the endpoint with the highest priority is used, the
+	// endpoints with the same priority being sorted by network name.
+	GwPriority int `json:",omitempty"`
 }
 
 // Task carries the information about one backend task
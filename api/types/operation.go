@@ -0,0 +1,19 @@
+package types // import "github.com/docker/docker/api/types"
+
+import "time"
+
+// Operation is a background operation of the daemon, such as a pull which
+// continues after its client disconnected.
+type Operation struct {
+	ID string
+	// Type is the type of the operation: "pull".
+	Type string
+	// Target is the object of the operation, such as the reference of the
+	// image pulled.
+	Target  string
+	Created time.Time
+	// Status is the last status reported by the operation.
+	Status string `json:",omitempty"`
+	// Attached is the number of clients attached to the operation.
+	Attached int
+}
@@ -77,6 +77,11 @@ type PluginConfig struct {
 	// Required: true
 	Network PluginConfigNetwork `json:"Network"`
 
+	// The driver options accepted by the plugin, as the `--opt` of
+	// the volumes or networks it creates.
+	//
+	Options []PluginOption `json:"Options,omitempty"`
+
 	// pid host
 	// Required: true
 	PidHost bool `json:"PidHost"`
@@ -0,0 +1,27 @@
+package types
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+// PluginOption plugin option
+// swagger:model PluginOption
+type PluginOption struct {
+
+	// description
+	// Required: true
+	Description string `json:"Description"`
+
+	// name
+	// Required: true
+	Name string `json:"Name"`
+
+	// required
+	Required bool `json:"Required,omitempty"`
+
+	// The type of the values of the option.
+	// Required: true
+	Type string `json:"Type"`
+
+	// The values allowed for an option of type `string`, any if empty.
+	Values []string `json:"Values"`
+}
@@ -9,6 +9,36 @@ import (
 // PluginsListResponse contains the response for the Engine API
 type PluginsListResponse []*Plugin
 
+// PluginCapabilities contains the response for Engine API:
+// GET "/plugins/capabilities"
+type PluginCapabilities struct {
+	ID   string
+	Name string
+	// Types are the interfaces implemented by the plugin
+	Types   []string
+	Enabled bool
+	// Healthy is whether the plugin responded when its capabilities were
+	// requested, which they are only for the enabled plugins
+	Healthy bool
+	// Error is the error the plugin failed to respond with
+	Error   string                     `json:",omitempty"`
+	Volume  *PluginVolumeCapabilities  `json:",omitempty"`
+	Network *PluginNetworkCapabilities `json:",omitempty"`
+	// Options are the driver options declared by the plugin
+	Options []PluginOption `json:",omitempty"`
+}
+
+// PluginVolumeCapabilities are the capabilities of a volume plugin.
+type PluginVolumeCapabilities struct {
+	Scope string
+}
+
+// PluginNetworkCapabilities are the capabilities of a network plugin.
+type PluginNetworkCapabilities struct {
+	Scope             string
+	ConnectivityScope string
+}
+
 // UnmarshalJSON implements json.Unmarshaler for PluginInterfaceType
 func (t *PluginInterfaceType) UnmarshalJSON(p []byte) error {
 	versionIndex := len(p)
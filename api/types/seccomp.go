@@ -2,7 +2,12 @@ package types // import "github.com/docker/docker/api/types"
 
 // Seccomp represents the config for a seccomp profile for syscall restriction.
 type Seccomp struct {
-	DefaultAction Action `json:"defaultAction"`
+	// Include are the profiles the profile is composed from, "default"
+	// for the default profile or the paths of profile files, relative to
+	// the including profile. The rules of a profile for a syscall replace
+	// the rules of its includes for the syscall.
+	Include       []string `json:"include,omitempty"`
+	DefaultAction Action   `json:"defaultAction"`
 	// Architectures is kept to maintain backward compatibility with the old
 	// seccomp profile.
 	Architectures []Arch         `json:"architectures,omitempty"`
@@ -15,6 +20,8 @@ type Seccomp struct {
 type Architecture struct {
 	Arch      Arch   `json:"architecture"`
 	SubArches []Arch `json:"subArchitectures"`
+	// Syscalls are the syscall rules applied on the architecture only.
+	Syscalls []*Syscall `json:"syscalls,omitempty"`
 }
 
 // Arch used for architectures
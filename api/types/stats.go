@@ -134,10 +134,12 @@ type NetworkStats struct {
 	TxErrors uint64 `json:"tx_errors"`
 	// Outgoing packets dropped. Windows and Linux.
 	TxDropped uint64 `json:"tx_dropped"`
-	// Endpoint ID. Not used on Linux.
+	// Endpoint ID. Windows and Linux.
 	EndpointID string `json:"endpoint_id,omitempty"`
 	// Instance ID. Not used on Linux.
 	InstanceID string `json:"instance_id,omitempty"`
+	// Network ID of the endpoint. Not used on Windows.
+	NetworkID string `json:"network_id,omitempty"`
 }
 
 // PidsStats contains the stats of a container's pids
@@ -149,6 +151,36 @@ type PidsStats struct {
 	Limit uint64 `json:"limit,omitempty"`
 }
 
+// TmpfsStats contains the stats of a tmpfs mount of a container.
+// Not used on Windows.
+type TmpfsStats struct {
+	// Usage is the memory used by the content of the mount, in bytes
+	Usage uint64 `json:"usage"`
+	// Limit is the size of the mount, in bytes
+	Limit uint64 `json:"limit"`
+	// Inodes is the number of inodes used in the mount
+	Inodes uint64 `json:"inodes"`
+}
+
+// LogStats contains the numbers of log lines of a container which were not
+// logged, because of the rate-limit and sample-rate log options, or dropped
+// by the buffer of the non-blocking mode, and the stats of the buffer.
+type LogStats struct {
+	// RateLimitedLines is the number of lines dropped by the rate limit
+	RateLimitedLines uint64 `json:"rate_limited_lines"`
+	// SampledOutLines is the number of lines dropped by the sampling
+	SampledOutLines uint64 `json:"sampled_out_lines"`
+	// DroppedMessages is the number of messages dropped by the buffer of
+	// the non-blocking mode
+	DroppedMessages uint64 `json:"dropped_messages"`
+	// BufferHighWatermark is the maximum size in bytes the buffer of the
+	// non-blocking mode reached
+	BufferHighWatermark int64 `json:"buffer_high_watermark"`
+	// BufferSize is the size in bytes of the buffer of the non-blocking
+	// mode, which changes with the adaptive-max-buffer-size log option
+	BufferSize int64 `json:"buffer_size"`
+}
+
 // Stats is Ultimate struct aggregating all types of stats of one container
 type Stats struct {
 	// Common stats
@@ -158,6 +190,8 @@ type Stats struct {
 	// Linux specific stats, not populated on Windows.
 	PidsStats  PidsStats  `json:"pids_stats,omitempty"`
 	BlkioStats BlkioStats `json:"blkio_stats,omitempty"`
+	// TmpfsStats are the stats of the tmpfs mounts, by destination
+	TmpfsStats map[string]TmpfsStats `json:"tmpfs_stats,omitempty"`
 
 	// Windows specific stats, not populated on Linux.
 	NumProcs     uint32       `json:"num_procs"`
@@ -167,6 +201,9 @@ type Stats struct {
 	CPUStats    CPUStats    `json:"cpu_stats,omitempty"`
 	PreCPUStats CPUStats    `json:"precpu_stats,omitempty"` // "Pre"="Previous"
 	MemoryStats MemoryStats `json:"memory_stats,omitempty"`
+	// LogStats are the stats of the log lines dropped since the container
+	// started, when its log options limit them or set the non-blocking mode.
+	LogStats *LogStats `json:"log_stats,omitempty"`
 }
 
 // StatsJSON is newly used Networks
@@ -177,6 +177,19 @@ type UnlockRequest struct {
 	UnlockKey string
 }
 
+// RestoreRequest is the request used to initialize a new swarm from the
+// backup of a manager.
+type RestoreRequest struct {
+	// ListenAddr, AdvertiseAddr and DataPathAddr replace the addresses of
+	// the manager of the backup when they are set.
+	ListenAddr    string
+	AdvertiseAddr string
+	DataPathAddr  string
+	// UnlockKey is the unlock key of the swarm, in ASCII-armored format,
+	// if it is locked.
+	UnlockKey string
+}
+
 // LocalNodeState represents the state of the local node.
 type LocalNodeState string
 
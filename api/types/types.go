@@ -53,6 +53,19 @@ type ImageMetadata struct {
 	LastTagTime time.Time `json:",omitempty"`
 }
 
+// ImagePolicy is a named rule admitting or rejecting the images pulled, the
+// images containers are created from, and the images builds are FROM. Only
+// the registry rules apply at pull time, before the image is known.
+type ImagePolicy struct {
+	Name              string
+	Mode              string            `json:",omitempty"` // "enforce", the default, or "audit" to only log the violations
+	AllowedRegistries []string          `json:",omitempty"` // e.g. "docker.io" or "registry.example.com:5000"
+	DeniedRegistries  []string          `json:",omitempty"`
+	RequiredLabels    map[string]string `json:",omitempty"` // an empty value requires the label with any value
+	ForbiddenLabels   []string          `json:",omitempty"`
+	MaxAge            string            `json:",omitempty"` // the maximum age of the images, e.g. "720h"
+}
+
 // Container contains response of Engine API:
 // GET "/containers/json"
 type Container struct {
@@ -206,7 +219,52 @@ type Info struct {
 	InitCommit         Commit
 	SecurityOptions    []string
 	ProductLicense     string `json:",omitempty"`
-	Warnings           []string
+	Capabilities       Capabilities
+	// ConfigReload is the report of the last reload of the configuration
+	// of the daemon, if any.
+	ConfigReload *ConfigReload `json:",omitempty"`
+	Warnings     []string
+}
+
+// ConfigReload is the report of a reload of the configuration of the daemon.
+type ConfigReload struct {
+	// Time is the time of the reload, in RFC 3339 format with nano-seconds.
+	Time string
+	// Error is the error of the reload when it failed, the configuration
+	// being partially reloaded or unchanged.
+	Error string `json:",omitempty"`
+	// Reloaded are the options of the configuration file which were
+	// reloaded.
+	Reloaded []string `json:",omitempty"`
+	// RestartRequired are the options of the configuration file which
+	// changed but cannot be reloaded, the daemon having to be restarted for
+	// them to take effect.
+	RestartRequired []string `json:",omitempty"`
+}
+
+// Capabilities are the optional features of the daemon and the drivers it
+// enables, returned in the Capabilities section of the Engine API:
+// GET "/info", for the clients to detect the features they can use.
+type Capabilities struct {
+	Features []FeatureCapability
+	Drivers  []DriverCapability
+}
+
+// FeatureCapability is an optional feature of the daemon.
+type FeatureCapability struct {
+	Name    string
+	Enabled bool
+	Version string            `json:",omitempty"`
+	Details map[string]string `json:",omitempty"`
+}
+
+// DriverCapability is a driver enabled by the daemon.
+type DriverCapability struct {
+	// Type is the type of the driver: "storage", "logging", "volume",
+	// "network" or "runtime"
+	Type    string
+	Name    string
+	Version string `json:",omitempty"`
 }
 
 // KeyValue holds a key/value pair
@@ -273,6 +331,56 @@ type ExecStartCheck struct {
 	Tty bool
 }
 
+// ExecAuditEntry is a record of an exec session in the exec audit log
+type ExecAuditEntry struct {
+	ID            string    // ID of the exec instance
+	ContainerID   string    // ID of the container the exec ran in
+	ContainerName string    // Name of the container the exec ran in
+	Identity      string    // Identity of the API client that started the exec
+	AuthNMethod   string    // Method used to authenticate the API client
+	Cmd           []string  // Command that was run
+	User          string    // User the command ran as inside the container
+	Privileged    bool      // Whether the command ran with extended privileges
+	Tty           bool      // Whether a TTY was allocated for the command
+	StartedAt     time.Time // Time the exec was started
+	FinishedAt    time.Time // Time the exec exited
+	ExitCode      int       // Exit code of the command
+}
+
+// ContainerSchedule describes the start and stop schedules of a container,
+// as set by the "com.docker.schedule.start" and "com.docker.schedule.stop"
+// labels of the container.
+type ContainerSchedule struct {
+	ID         string    // ID of the container
+	Name       string    // Name of the container
+	Start      string    `json:",omitempty"` // Schedule on which the container is started
+	Stop       string    `json:",omitempty"` // Schedule on which the container is stopped
+	NextStart  time.Time // Next time the container will be started
+	NextStop   time.Time // Next time the container will be stopped
+	LastAction string    `json:",omitempty"` // Last scheduled action run on the container, "start" or "stop"
+	LastRun    time.Time // Time the last scheduled action was run
+	LastError  string    `json:",omitempty"` // Error of the last scheduled action, if it failed
+}
+
+// ContainerLogHealth describes the state of the logging pipeline of a
+// container, from the copy of its output to its log driver.
+type ContainerLogHealth struct {
+	Driver           string    // Log driver of the container
+	Mode             string    // Delivery mode of the logs, "blocking" or "non-blocking"
+	Running          bool      // Whether the output of the container is being logged
+	BufferedBytes    int64     // Size in bytes of the messages in the buffer of the non-blocking mode
+	BufferSize       int64     // Size in bytes of the buffer of the non-blocking mode
+	DroppedMessages  uint64    // Number of messages dropped by the buffer of the non-blocking mode
+	RateLimitedLines uint64    // Number of lines dropped by the rate-limit log option
+	SampledOutLines  uint64    // Number of lines dropped by the sample-rate log option
+	WrittenMessages  uint64    // Number of messages written to the log driver
+	WriteErrors      uint64    // Number of messages the log driver failed to write
+	LastWrite        time.Time // Time the last message was written to the log driver
+	LastError        string    `json:",omitempty"` // Error of the last message the log driver failed to write
+	LastErrorTime    time.Time // Time the log driver last failed to write a message
+	PluginRestarts   uint64    // Number of times the logging of a logging plugin was restarted after failures
+}
+
 // HealthcheckResult stores information about a single run of a healthcheck probe
 type HealthcheckResult struct {
 	Start    time.Time // Start is the time this check started
@@ -325,6 +433,13 @@ type ContainerNode struct {
 	Labels    map[string]string
 }
 
+// ContainerLogCache contains the disk usage of the local cache of the logs
+// of a container, used when its log driver cannot read the logs back.
+type ContainerLogCache struct {
+	Size    int64
+	MaxSize int64
+}
+
 // ContainerJSONBase contains response of Engine API:
 // GET "/containers/{name:.*}/json"
 type ContainerJSONBase struct {
@@ -338,7 +453,8 @@ type ContainerJSONBase struct {
 	HostnamePath    string
 	HostsPath       string
 	LogPath         string
-	Node            *ContainerNode `json:",omitempty"`
+	LogCache        *ContainerLogCache `json:",omitempty"`
+	Node            *ContainerNode     `json:",omitempty"`
 	Name            string
 	RestartCount    int
 	Driver          string
@@ -431,6 +547,7 @@ type NetworkResource struct {
 	Containers map[string]EndpointResource    // Containers contains endpoints belonging to the network
 	Options    map[string]string              // Options holds the network specific options to use for when creating the network
 	Labels     map[string]string              // Labels holds metadata specific to the network being created
+	DNS        *network.DNSConfig             `json:",omitempty"` // DNS holds the DNS configuration of the containers attached to the network
 	Peers      []network.PeerInfo             `json:",omitempty"` // List of peer nodes for an overlay network
 	Services   map[string]network.ServiceInfo `json:",omitempty"`
 }
@@ -442,6 +559,22 @@ type EndpointResource struct {
 	MacAddress  string
 	IPv4Address string
 	IPv6Address string
+	IngressRate uint64 `json:",omitempty"`
+	EgressRate  uint64 `json:",omitempty"`
+	// Statistics are the traffic counters of the interface of the endpoint,
+	// returned by the verbose network inspect.
+	Statistics *EndpointStatistics `json:",omitempty"`
+}
+
+// EndpointStatistics contains the traffic counters of the interface of an
+// endpoint in its container
+type EndpointStatistics struct {
+	RxBytes   uint64
+	RxPackets uint64
+	RxDropped uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxDropped uint64
 }
 
 // NetworkCreate is the expected body of the "create network" http request message
@@ -465,6 +598,7 @@ type NetworkCreate struct {
 	ConfigFrom     *network.ConfigReference
 	Options        map[string]string
 	Labels         map[string]string
+	DNS            *network.DNSConfig `json:",omitempty"`
 }
 
 // NetworkCreateRequest is the request message sent to the server for network create call.
@@ -479,12 +613,63 @@ type NetworkCreateResponse struct {
 	Warning string
 }
 
+// AddressPool is the utilization of a default address pool of the daemon, which
+// the subnets of the networks created without subnet are allocated from
+type AddressPool struct {
+	Base      string
+	Size      int
+	Driver    string   `json:",omitempty"` // driver of the networks the pool is reserved to
+	Scope     string   // "local", or "swarm" for the pools of the swarm scoped networks
+	Subnets   int      // number of subnets the pool is split into
+	Allocated int      // number of subnets of the pool allocated to networks
+	Networks  []string // names of the networks allocated from the pool
+}
+
+// NetworkGroup represents a network namespace group, shared by the containers
+// started with the "group:<name>" network mode
+type NetworkGroup struct {
+	Name     string
+	ID       string `json:"Id"`
+	Created  time.Time
+	Networks []string
+	// SandboxKey is the path of the network namespace of the group, while
+	// containers are attached to it
+	SandboxKey string `json:",omitempty"`
+	// Containers are the IDs of the running containers attached to the group
+	Containers []string
+}
+
+// NetworkGroupCreateRequest is the request message sent to the server for
+// network group create call
+type NetworkGroupCreateRequest struct {
+	Name     string
+	Networks []string
+}
+
+// NetworkPolicy is a named rule allowing or denying the traffic from the
+// containers of a bridge network to the containers of another bridge network
+type NetworkPolicy struct {
+	Name        string
+	Source      string
+	Destination string
+	Action      string   // "allow" or "deny"
+	Protocol    string   `json:",omitempty"` // "tcp", "udp", "sctp", or empty for all protocols
+	Ports       []string `json:",omitempty"` // destination ports or port ranges, e.g. "8000-8100"
+}
+
 // NetworkConnect represents the data to be used to connect a container to the network
 type NetworkConnect struct {
 	Container      string
 	EndpointConfig *network.EndpointSettings `json:",omitempty"`
 }
 
+// NetworkGwPriority represents the data to be used to change the gateway
+// priority of the endpoint of a container on the network
+type NetworkGwPriority struct {
+	Container  string
+	GwPriority int
+}
+
 // NetworkDisconnect represents the data to be used to disconnect a container from the network
 type NetworkDisconnect struct {
 	Container string
@@ -517,6 +702,24 @@ type DiskUsage struct {
 	Volumes     []*Volume
 	BuildCache  []*BuildCache
 	BuilderSize int64 // deprecated
+	// UpdatedAt is the time the oldest of the sizes of the writable layers
+	// of the containers and of the volumes was calculated at, the sizes
+	// being cached by the daemon.
+	UpdatedAt time.Time `json:",omitempty"`
+}
+
+// StorageMigrateReport contains the response for Engine API:
+// POST "/system/storage/migrate"
+type StorageMigrateReport struct {
+	// Snapshotter is the containerd snapshotter the storage was migrated to
+	Snapshotter string
+	// Images are the IDs of the migrated images
+	Images []string
+	// Containers are the IDs of the containers whose writable layer was
+	// migrated
+	Containers []string
+	// Skipped are the reasons the other containers were not migrated, by ID
+	Skipped map[string]string `json:",omitempty"`
 }
 
 // ContainersPruneReport contains the response for Engine API:
@@ -533,6 +736,17 @@ type VolumesPruneReport struct {
 	SpaceReclaimed uint64
 }
 
+// VolumeSnapshot is a snapshot of the data of a volume, returned by Engine API:
+// POST "/volumes/{name}/snapshot" and GET "/volumes/{name}/snapshots"
+type VolumeSnapshot struct {
+	Name      string
+	Volume    string
+	CreatedAt time.Time
+	// Method is the mechanism used by the volume driver to take the
+	// snapshot, "btrfs", "lvm" or "copy" for the local driver.
+	Method string
+}
+
 // ImagesPruneReport contains the response for Engine API:
 // POST "/images/prune"
 type ImagesPruneReport struct {
@@ -84,6 +84,8 @@ type Client struct {
 	customHTTPHeaders map[string]string
 	// manualOverride is set to true when the version was set by users.
 	manualOverride bool
+	// multiplexer multiplexes the connections of the client, if set.
+	multiplexer *multiplexer
 }
 
 // CheckRedirect specifies the policy for dealing with redirect responses:
@@ -307,6 +309,9 @@ func (cli *Client) Close() error {
 	if t, ok := cli.client.Transport.(*http.Transport); ok {
 		t.CloseIdleConnections()
 	}
+	if cli.multiplexer != nil {
+		return cli.multiplexer.Close()
+	}
 	return nil
 }
 
@@ -0,0 +1,42 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerClone creates a new container from the configuration of an existing container.
+func (cli *Client) ContainerClone(ctx context.Context, containerID string, options types.ContainerCloneOptions) (container.ContainerCreateCreatedBody, error) {
+	var response container.ContainerCreateCreatedBody
+
+	if err := cli.NewVersionError("1.40", "container clone"); err != nil {
+		return response, err
+	}
+
+	query := url.Values{}
+	if options.Name != "" {
+		query.Set("name", options.Name)
+	}
+	if options.Layer {
+		query.Set("layer", "1")
+	}
+	if options.Volumes {
+		query.Set("volumes", "1")
+	}
+	if !options.Pause {
+		query.Set("pause", "0")
+	}
+
+	resp, err := cli.post(ctx, "/containers/"+containerID+"/clone", query, nil, nil)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&response)
+	ensureReaderClosed(resp)
+	return response, err
+}
@@ -0,0 +1,63 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestContainerCloneError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, err := client.ContainerClone(context.Background(), "nothing", types.ContainerCloneOptions{})
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestContainerClone(t *testing.T) {
+	expectedURL := "/containers/container_id/clone"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			query := req.URL.Query()
+			for key, expected := range map[string]string{"name": "debug", "layer": "1", "volumes": "", "pause": "0"} {
+				if actual := query.Get(key); actual != expected {
+					return nil, fmt.Errorf("%s not set in URL query properly. Expected '%s', got %s", key, expected, actual)
+				}
+			}
+			b, err := json.Marshal(container.ContainerCreateCreatedBody{
+				ID: "clone_id",
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	r, err := client.ContainerClone(context.Background(), "container_id", types.ContainerCloneOptions{
+		Name:  "debug",
+		Layer: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != "clone_id" {
+		t.Fatalf("expected `clone_id`, got %s", r.ID)
+	}
+}
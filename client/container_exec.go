@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 )
 
 // ContainerExecCreate creates a new exec configuration to run an exec process.
@@ -52,3 +53,26 @@ func (cli *Client) ContainerExecInspect(ctx context.Context, execID string) (typ
 	ensureReaderClosed(resp)
 	return response, err
 }
+
+// ContainerExecAudit returns the entries of the exec audit log of the docker host.
+func (cli *Client) ContainerExecAudit(ctx context.Context, auditFilters filters.Args) ([]types.ExecAuditEntry, error) {
+	var entries []types.ExecAuditEntry
+
+	if err := cli.NewVersionError("1.40", "exec audit"); err != nil {
+		return entries, err
+	}
+
+	query, err := getFiltersQuery(auditFilters)
+	if err != nil {
+		return entries, err
+	}
+
+	resp, err := cli.get(ctx, "/exec/audit", query, nil)
+	if err != nil {
+		return entries, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&entries)
+	ensureReaderClosed(resp)
+	return entries, err
+}
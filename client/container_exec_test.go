@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 )
 
 func TestContainerExecCreateError(t *testing.T) {
@@ -154,3 +155,37 @@ func TestContainerExecInspect(t *testing.T) {
 		t.Fatalf("expected ContainerID `container_id`, got %s", inspect.ContainerID)
 	}
 }
+
+func TestContainerExecAudit(t *testing.T) {
+	expectedURL := "/exec/audit"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if f := req.URL.Query().Get("filters"); f != `{"identity":{"alice":true}}` {
+				return nil, fmt.Errorf("unexpected filters %q", f)
+			}
+			b, err := json.Marshal([]types.ExecAuditEntry{{
+				ID:          "exec_id",
+				ContainerID: "container_id",
+				Identity:    "alice",
+			}})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	entries, err := client.ContainerExecAudit(context.Background(), filters.NewArgs(filters.Arg("identity", "alice")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != "exec_id" {
+		t.Fatalf("expected a single entry for exec_id, got %v", entries)
+	}
+}
@@ -30,6 +30,10 @@ func (cli *Client) ContainerList(ctx context.Context, options types.ContainerLis
 		query.Set("before", options.Before)
 	}
 
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	}
+
 	if options.Size {
 		query.Set("size", "1")
 	}
@@ -49,6 +49,10 @@ func TestContainerList(t *testing.T) {
 			if before != "" {
 				return nil, fmt.Errorf("before should have not be present in query, go %s", before)
 			}
+			cursor := query.Get("cursor")
+			if cursor != "1546300800_container_id0" {
+				return nil, fmt.Errorf("cursor not set in URL query properly. Expected '1546300800_container_id0', got %s", cursor)
+			}
 			size := query.Get("size")
 			if size != "1" {
 				return nil, fmt.Errorf("size not set in URL query properly. Expected '1', got %s", size)
@@ -85,6 +89,7 @@ func TestContainerList(t *testing.T) {
 		Size:    true,
 		All:     true,
 		Since:   "container",
+		Cursor:  "1546300800_container_id0",
 		Filters: filters,
 	})
 	if err != nil {
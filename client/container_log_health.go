@@ -0,0 +1,26 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerLogHealth returns the state of the logging pipeline of a container.
+func (cli *Client) ContainerLogHealth(ctx context.Context, container string) (types.ContainerLogHealth, error) {
+	var health types.ContainerLogHealth
+
+	if err := cli.NewVersionError("1.40", "container log health"); err != nil {
+		return health, err
+	}
+
+	resp, err := cli.get(ctx, "/containers/"+container+"/logs/health", nil, nil)
+	if err != nil {
+		return health, wrapResponseError(err, resp, "container", container)
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&health)
+	ensureReaderClosed(resp)
+	return health, err
+}
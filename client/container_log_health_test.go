@@ -0,0 +1,56 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestContainerLogHealthError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, err := client.ContainerLogHealth(context.Background(), "container_id")
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestContainerLogHealth(t *testing.T) {
+	expectedURL := "/containers/container_id/logs/health"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != expectedURL {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			b, err := json.Marshal(types.ContainerLogHealth{
+				Driver:      "fluentd",
+				Mode:        "non-blocking",
+				Running:     true,
+				WriteErrors: 2,
+				LastError:   "fluent#send: can't send logs, client is reconnecting",
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	health, err := client.ContainerLogHealth(context.Background(), "container_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Driver != "fluentd" || !health.Running || health.WriteErrors != 2 {
+		t.Fatalf("unexpected log health %+v", health)
+	}
+}
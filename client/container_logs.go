@@ -70,6 +70,13 @@ func (cli *Client) ContainerLogs(ctx context.Context, container string, options
 	if options.Follow {
 		query.Set("follow", "1")
 	}
+
+	if options.Grep != "" {
+		if err := cli.NewVersionError("1.40", "logs grep"); err != nil {
+			return nil, err
+		}
+		query.Set("grep", options.Grep)
+	}
 	query.Set("tail", options.Tail)
 
 	resp, err := cli.get(ctx, "/containers/"+container+"/logs", query, nil)
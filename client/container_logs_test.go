@@ -101,6 +101,15 @@ func TestContainerLogs(t *testing.T) {
 				"until": "1136073600.000000001",
 			},
 		},
+		{
+			options: types.ContainerLogsOptions{
+				Grep: "error|warn",
+			},
+			expectedQueryParams: map[string]string{
+				"tail": "",
+				"grep": "error|warn",
+			},
+		},
 		{
 			options: types.ContainerLogsOptions{
 				// An complete invalid date will not be passed
@@ -0,0 +1,26 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerSchedules returns the start and stop schedules of the containers.
+func (cli *Client) ContainerSchedules(ctx context.Context) ([]types.ContainerSchedule, error) {
+	var schedules []types.ContainerSchedule
+
+	if err := cli.NewVersionError("1.40", "container schedules"); err != nil {
+		return schedules, err
+	}
+
+	resp, err := cli.get(ctx, "/containers/schedules", nil, nil)
+	if err != nil {
+		return schedules, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&schedules)
+	ensureReaderClosed(resp)
+	return schedules, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -89,6 +90,14 @@ func buildEventsQueryParams(cliVersion string, options types.EventsOptions) (url
 		query.Set("until", ts)
 	}
 
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	}
+
 	if options.Filters.Len() > 0 {
 		filterJSON, err := filters.ToParamWithVersion(cliVersion, options.Filters)
 		if err != nil {
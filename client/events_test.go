@@ -111,6 +111,18 @@ func TestEvents(t *testing.T) {
 				"3": true,
 			},
 		},
+		{
+			options: types.EventsOptions{
+				Limit:  2,
+				Cursor: "1546300800000000000",
+			},
+			expectedQueryParams: map[string]string{
+				"limit":  "2",
+				"cursor": "1546300800000000000",
+			},
+			events:         []events.Message{},
+			expectedEvents: make(map[string]bool),
+		},
 	}
 
 	for _, eventsCase := range eventsCases {
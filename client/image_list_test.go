@@ -68,6 +68,16 @@ func TestImageList(t *testing.T) {
 				"filters": `{"dangling":{"false":true}}`,
 			},
 		},
+		{
+			options: types.ImageListOptions{
+				Limit:  10,
+				Cursor: "1546300800_sha256:d74508fb6632",
+			},
+			expectedQueryParams: map[string]string{
+				"limit":  "10",
+				"cursor": "1546300800_sha256:d74508fb6632",
+			},
+		},
 	}
 	for _, listCase := range listCases {
 		client := &Client{
@@ -0,0 +1,76 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ImagePolicyCreate creates a policy admitting or rejecting the images by
+// their registry, their labels, and their age.
+func (cli *Client) ImagePolicyCreate(ctx context.Context, policy types.ImagePolicy) (types.ImagePolicy, error) {
+	var response types.ImagePolicy
+
+	if err := cli.NewVersionError("1.40", "image policy create"); err != nil {
+		return response, err
+	}
+
+	resp, err := cli.post(ctx, "/image-policies/create", nil, policy, nil)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&response)
+	ensureReaderClosed(resp)
+	return response, err
+}
+
+// ImagePolicyList returns the network policies of the docker host.
+func (cli *Client) ImagePolicyList(ctx context.Context) ([]types.ImagePolicy, error) {
+	if err := cli.NewVersionError("1.40", "image policy list"); err != nil {
+		return nil, err
+	}
+
+	var policies []types.ImagePolicy
+	resp, err := cli.get(ctx, "/image-policies", nil, nil)
+	if err != nil {
+		return policies, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&policies)
+	ensureReaderClosed(resp)
+	return policies, err
+}
+
+// ImagePolicyInspect returns a image policy.
+func (cli *Client) ImagePolicyInspect(ctx context.Context, name string) (types.ImagePolicy, error) {
+	var policy types.ImagePolicy
+
+	if err := cli.NewVersionError("1.40", "image policy inspect"); err != nil {
+		return policy, err
+	}
+	if name == "" {
+		return policy, objectNotFoundError{object: "image policy", id: name}
+	}
+
+	resp, err := cli.get(ctx, "/image-policies/"+name, nil, nil)
+	if err != nil {
+		return policy, wrapResponseError(err, resp, "image policy", name)
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&policy)
+	ensureReaderClosed(resp)
+	return policy, err
+}
+
+// ImagePolicyRemove removes a image policy.
+func (cli *Client) ImagePolicyRemove(ctx context.Context, name string) error {
+	if err := cli.NewVersionError("1.40", "image policy remove"); err != nil {
+		return err
+	}
+
+	resp, err := cli.delete(ctx, "/image-policies/"+name, nil, nil)
+	ensureReaderClosed(resp)
+	return wrapResponseError(err, resp, "image policy", name)
+}
@@ -0,0 +1,80 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestImagePolicyCreate(t *testing.T) {
+	policy := types.ImagePolicy{
+		Name:              "internal-only",
+		Mode:              "audit",
+		AllowedRegistries: []string{"registry.example.com"},
+		RequiredLabels:    map[string]string{"com.example.team": ""},
+		MaxAge:            "720h",
+	}
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/image-policies/create" {
+				return nil, fmt.Errorf("Expected URL '/image-policies/create', got '%s'", req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			var create types.ImagePolicy
+			if err := json.NewDecoder(req.Body).Decode(&create); err != nil {
+				return nil, err
+			}
+			b, err := json.Marshal(create)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	r, err := client.ImagePolicyCreate(context.Background(), policy)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(r, policy))
+}
+
+func TestImagePolicyInspectNotFound(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusNotFound, "Server error")),
+	}
+
+	_, err := client.ImagePolicyInspect(context.Background(), "unknown")
+	assert.Check(t, IsErrNotFound(err))
+}
+
+func TestImagePolicyRemove(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/image-policies/internal-only" {
+				return nil, fmt.Errorf("Expected URL '/image-policies/internal-only', got '%s'", req.URL)
+			}
+			if req.Method != "DELETE" {
+				return nil, fmt.Errorf("expected DELETE method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.ImagePolicyRemove(context.Background(), "internal-only")
+	assert.NilError(t, err)
+}
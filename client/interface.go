@@ -46,12 +46,14 @@ type CommonAPIClient interface {
 // ContainerAPIClient defines API client methods for the containers
 type ContainerAPIClient interface {
 	ContainerAttach(ctx context.Context, container string, options types.ContainerAttachOptions) (types.HijackedResponse, error)
+	ContainerClone(ctx context.Context, container string, options types.ContainerCloneOptions) (containertypes.ContainerCreateCreatedBody, error)
 	ContainerCommit(ctx context.Context, container string, options types.ContainerCommitOptions) (types.IDResponse, error)
 	ContainerCreate(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error)
 	ContainerDiff(ctx context.Context, container string) ([]containertypes.ContainerChangeResponseItem, error)
 	ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error)
 	ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error)
 	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+	ContainerExecAudit(ctx context.Context, filters filters.Args) ([]types.ExecAuditEntry, error)
 	ContainerExecResize(ctx context.Context, execID string, options types.ResizeOptions) error
 	ContainerExecStart(ctx context.Context, execID string, config types.ExecStartCheck) error
 	ContainerExport(ctx context.Context, container string) (io.ReadCloser, error)
@@ -60,11 +62,13 @@ type ContainerAPIClient interface {
 	ContainerKill(ctx context.Context, container, signal string) error
 	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
 	ContainerLogs(ctx context.Context, container string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	ContainerLogHealth(ctx context.Context, container string) (types.ContainerLogHealth, error)
 	ContainerPause(ctx context.Context, container string) error
 	ContainerRemove(ctx context.Context, container string, options types.ContainerRemoveOptions) error
 	ContainerRename(ctx context.Context, container, newContainerName string) error
 	ContainerResize(ctx context.Context, container string, options types.ResizeOptions) error
 	ContainerRestart(ctx context.Context, container string, timeout *time.Duration) error
+	ContainerSchedules(ctx context.Context) ([]types.ContainerSchedule, error)
 	ContainerStatPath(ctx context.Context, container, path string) (types.ContainerPathStat, error)
 	ContainerStats(ctx context.Context, container string, stream bool) (types.ContainerStats, error)
 	ContainerStart(ctx context.Context, container string, options types.ContainerStartOptions) error
@@ -94,6 +98,10 @@ type ImageAPIClient interface {
 	ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error)
 	ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error)
 	ImageLoad(ctx context.Context, input io.Reader, quiet bool) (types.ImageLoadResponse, error)
+	ImagePolicyCreate(ctx context.Context, policy types.ImagePolicy) (types.ImagePolicy, error)
+	ImagePolicyInspect(ctx context.Context, name string) (types.ImagePolicy, error)
+	ImagePolicyList(ctx context.Context) ([]types.ImagePolicy, error)
+	ImagePolicyRemove(ctx context.Context, name string) error
 	ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error)
 	ImagePush(ctx context.Context, ref string, options types.ImagePushOptions) (io.ReadCloser, error)
 	ImageRemove(ctx context.Context, image string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
@@ -105,12 +113,22 @@ type ImageAPIClient interface {
 
 // NetworkAPIClient defines API client methods for the networks
 type NetworkAPIClient interface {
+	NetworkAddressPools(ctx context.Context) ([]types.AddressPool, error)
 	NetworkConnect(ctx context.Context, network, container string, config *networktypes.EndpointSettings) error
 	NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error)
 	NetworkDisconnect(ctx context.Context, network, container string, force bool) error
+	NetworkGwPriority(ctx context.Context, network, container string, priority int) error
+	NetworkGroupCreate(ctx context.Context, name string, networks []string) (types.NetworkCreateResponse, error)
+	NetworkGroupInspect(ctx context.Context, nameOrID string) (types.NetworkGroup, error)
+	NetworkGroupList(ctx context.Context) ([]types.NetworkGroup, error)
+	NetworkGroupRemove(ctx context.Context, nameOrID string) error
 	NetworkInspect(ctx context.Context, network string, options types.NetworkInspectOptions) (types.NetworkResource, error)
 	NetworkInspectWithRaw(ctx context.Context, network string, options types.NetworkInspectOptions) (types.NetworkResource, []byte, error)
 	NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error)
+	NetworkPolicyCreate(ctx context.Context, policy types.NetworkPolicy) (types.NetworkPolicy, error)
+	NetworkPolicyInspect(ctx context.Context, name string) (types.NetworkPolicy, error)
+	NetworkPolicyList(ctx context.Context) ([]types.NetworkPolicy, error)
+	NetworkPolicyRemove(ctx context.Context, name string) error
 	NetworkRemove(ctx context.Context, network string) error
 	NetworksPrune(ctx context.Context, pruneFilter filters.Args) (types.NetworksPruneReport, error)
 }
@@ -126,6 +144,7 @@ type NodeAPIClient interface {
 // PluginAPIClient defines API client methods for the plugins
 type PluginAPIClient interface {
 	PluginList(ctx context.Context, filter filters.Args) (types.PluginsListResponse, error)
+	PluginCapabilities(ctx context.Context, filter filters.Args) ([]types.PluginCapabilities, error)
 	PluginRemove(ctx context.Context, name string, options types.PluginRemoveOptions) error
 	PluginEnable(ctx context.Context, name string, options types.PluginEnableOptions) error
 	PluginDisable(ctx context.Context, name string, options types.PluginDisableOptions) error
@@ -156,6 +175,8 @@ type SwarmAPIClient interface {
 	SwarmJoin(ctx context.Context, req swarm.JoinRequest) error
 	SwarmGetUnlockKey(ctx context.Context) (types.SwarmUnlockKeyResponse, error)
 	SwarmUnlock(ctx context.Context, req swarm.UnlockRequest) error
+	SwarmBackup(ctx context.Context) (io.ReadCloser, error)
+	SwarmRestore(ctx context.Context, req swarm.RestoreRequest, backup io.Reader) error
 	SwarmLeave(ctx context.Context, force bool) error
 	SwarmInspect(ctx context.Context) (swarm.Swarm, error)
 	SwarmUpdate(ctx context.Context, version swarm.Version, swarm swarm.Spec, flags swarm.UpdateFlags) error
@@ -167,6 +188,7 @@ type SystemAPIClient interface {
 	Info(ctx context.Context) (types.Info, error)
 	RegistryLogin(ctx context.Context, auth types.AuthConfig) (registry.AuthenticateOKBody, error)
 	DiskUsage(ctx context.Context) (types.DiskUsage, error)
+	StorageMigrate(ctx context.Context, options types.StorageMigrateOptions) (types.StorageMigrateReport, error)
 	Ping(ctx context.Context) (types.Ping, error)
 }
 
@@ -177,7 +199,14 @@ type VolumeAPIClient interface {
 	VolumeInspectWithRaw(ctx context.Context, volumeID string) (types.Volume, []byte, error)
 	VolumeList(ctx context.Context, filter filters.Args) (volumetypes.VolumeListOKBody, error)
 	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	VolumeRemoveWithOptions(ctx context.Context, volumeID string, options types.VolumeRemoveOptions) error
 	VolumesPrune(ctx context.Context, pruneFilter filters.Args) (types.VolumesPruneReport, error)
+	VolumeSnapshot(ctx context.Context, volumeID, snapshot string) (types.VolumeSnapshot, error)
+	VolumeSnapshotList(ctx context.Context, volumeID string) ([]types.VolumeSnapshot, error)
+	VolumeSnapshotRestore(ctx context.Context, volumeID, snapshot string) error
+	VolumeSnapshotRemove(ctx context.Context, volumeID, snapshot string) error
+	VolumeExport(ctx context.Context, volumeID string, compress bool) (io.ReadCloser, error)
+	VolumeImport(ctx context.Context, source io.Reader, options types.VolumeImportOptions) (types.Volume, error)
 }
 
 // SecretAPIClient defines API client methods for secrets
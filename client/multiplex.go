@@ -0,0 +1,138 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"sync"
+
+	"github.com/docker/docker/pkg/multiplex"
+	"github.com/pkg/errors"
+)
+
+// WithMultiplexing multiplexes the connections of the client to the daemon
+// over a single connection, for the parallel requests of the client not to
+// open connections of their own, such as the connections through
+// `docker system dial-stdio` over SSH. It must be applied after the dialer
+// of the client, if any.
+//
+// The connections are not multiplexed with TLS, or when the daemon does not
+// support multiplexing.
+func WithMultiplexing() func(*Client) error {
+	return func(c *Client) error {
+		transport, ok := c.client.Transport.(*http.Transport)
+		if !ok {
+			return errors.Errorf("cannot apply multiplexing to transport: %T", c.client.Transport)
+		}
+		c.multiplexer = &multiplexer{
+			cli:       c,
+			transport: transport,
+			dial:      transport.DialContext,
+		}
+		transport.DialContext = c.multiplexer.DialContext
+		return nil
+	}
+}
+
+// multiplexer dials the streams of a multiplexed session, upgrading a
+// connection to a new session when the previous session is closed.
+type multiplexer struct {
+	cli       *Client
+	transport *http.Transport
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	mu          sync.Mutex
+	session     *multiplex.Session
+	unsupported bool
+}
+
+// DialContext opens a stream of the multiplexed session, or dials a
+// connection as the transport would when multiplexing is not supported.
+func (m *multiplexer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if m.transport.TLSClientConfig != nil {
+		return m.dialConn(ctx, network, addr)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unsupported {
+		return m.dialConn(ctx, network, addr)
+	}
+	if m.session != nil {
+		if st, err := m.session.Open(); err == nil {
+			return st, nil
+		}
+		m.session = nil
+	}
+	session, err := m.upgrade(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		m.unsupported = true
+		return m.dialConn(ctx, network, addr)
+	}
+	m.session = session
+	return session.Open()
+}
+
+func (m *multiplexer) dialConn(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch {
+	case m.dial != nil:
+		return m.dial(ctx, network, addr)
+	case m.transport.Dial != nil:
+		return m.transport.Dial(network, addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, network, addr)
+}
+
+// upgrade dials a connection and upgrades it to a multiplexed session. It
+// returns no session when the daemon does not support multiplexing.
+func (m *multiplexer) upgrade(ctx context.Context, network, addr string) (*multiplex.Session, error) {
+	conn, err := m.dialConn(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", path.Join("/", m.cli.basePath, "/system/multiplex"), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	req.Host = m.cli.addr
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "multiplex")
+
+	clientconn := httputil.NewClientConn(conn, nil)
+	resp, err := clientconn.Do(req.WithContext(ctx))
+	if err != nil && err != httputil.ErrPersistEOF {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		conn.Close()
+		switch resp.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusBadRequest:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to upgrade to multiplex, received %d", resp.StatusCode)
+	}
+	c, br := clientconn.Hijack()
+	return multiplex.Client(&hijackedConn{c, br}), nil
+}
+
+// Close closes the multiplexed session, if any.
+func (m *multiplexer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.session == nil {
+		return nil
+	}
+	err := m.session.Close()
+	m.session = nil
+	return err
+}
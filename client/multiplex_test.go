@@ -0,0 +1,37 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestMultiplexingUnsupported(t *testing.T) {
+	var upgrades int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/system/multiplex") {
+			upgrades++
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("API-Version", "1.40")
+		w.Write([]byte("OK"))
+	}))
+	defer ts.Close()
+
+	c, err := NewClientWithOpts(WithHost("tcp://"+ts.Listener.Addr().String()), WithMultiplexing())
+	assert.NilError(t, err)
+	defer c.Close()
+
+	// the requests fall back to connections of their own
+	for i := 0; i < 3; i++ {
+		_, err := c.Ping(context.Background())
+		assert.Check(t, err)
+	}
+	assert.Check(t, is.Equal(upgrades, 1))
+}
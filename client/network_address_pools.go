@@ -0,0 +1,26 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// NetworkAddressPools returns the utilization of the default address pools
+// of the docker host.
+func (cli *Client) NetworkAddressPools(ctx context.Context) ([]types.AddressPool, error) {
+	if err := cli.NewVersionError("1.40", "network address pools"); err != nil {
+		return nil, err
+	}
+
+	var pools []types.AddressPool
+	resp, err := cli.get(ctx, "/network-address-pools", nil, nil)
+	if err != nil {
+		return pools, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&pools)
+	ensureReaderClosed(resp)
+	return pools, err
+}
@@ -0,0 +1,81 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// NetworkGroupCreate creates a network group, a network namespace shared by
+// the containers started with the "group:<name>" network mode.
+func (cli *Client) NetworkGroupCreate(ctx context.Context, name string, networks []string) (types.NetworkCreateResponse, error) {
+	var response types.NetworkCreateResponse
+
+	if err := cli.NewVersionError("1.40", "network group create"); err != nil {
+		return response, err
+	}
+
+	req := types.NetworkGroupCreateRequest{
+		Name:     name,
+		Networks: networks,
+	}
+	resp, err := cli.post(ctx, "/network-groups/create", nil, req, nil)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&response)
+	ensureReaderClosed(resp)
+	return response, err
+}
+
+// NetworkGroupList returns the network groups of the docker host.
+func (cli *Client) NetworkGroupList(ctx context.Context) ([]types.NetworkGroup, error) {
+	if err := cli.NewVersionError("1.40", "network group list"); err != nil {
+		return nil, err
+	}
+
+	var groups []types.NetworkGroup
+	resp, err := cli.get(ctx, "/network-groups", nil, nil)
+	if err != nil {
+		return groups, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&groups)
+	ensureReaderClosed(resp)
+	return groups, err
+}
+
+// NetworkGroupInspect returns the information of a network group.
+func (cli *Client) NetworkGroupInspect(ctx context.Context, nameOrID string) (types.NetworkGroup, error) {
+	var group types.NetworkGroup
+
+	if err := cli.NewVersionError("1.40", "network group inspect"); err != nil {
+		return group, err
+	}
+	if nameOrID == "" {
+		return group, objectNotFoundError{object: "network group", id: nameOrID}
+	}
+
+	resp, err := cli.get(ctx, "/network-groups/"+nameOrID, nil, nil)
+	if err != nil {
+		return group, wrapResponseError(err, resp, "network group", nameOrID)
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&group)
+	ensureReaderClosed(resp)
+	return group, err
+}
+
+// NetworkGroupRemove removes a network group, which must not have running
+// containers.
+func (cli *Client) NetworkGroupRemove(ctx context.Context, nameOrID string) error {
+	if err := cli.NewVersionError("1.40", "network group remove"); err != nil {
+		return err
+	}
+
+	resp, err := cli.delete(ctx, "/network-groups/"+nameOrID, nil, nil)
+	ensureReaderClosed(resp)
+	return wrapResponseError(err, resp, "network group", nameOrID)
+}
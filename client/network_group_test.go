@@ -0,0 +1,99 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestNetworkGroupCreate(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/network-groups/create" {
+				return nil, fmt.Errorf("Expected URL '/network-groups/create', got '%s'", req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			var create types.NetworkGroupCreateRequest
+			if err := json.NewDecoder(req.Body).Decode(&create); err != nil {
+				return nil, err
+			}
+			if create.Name != "web" || len(create.Networks) != 1 || create.Networks[0] != "front" {
+				return nil, fmt.Errorf("unexpected create request %+v", create)
+			}
+			b, err := json.Marshal(types.NetworkCreateResponse{ID: "group_id"})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	r, err := client.NetworkGroupCreate(context.Background(), "web", []string{"front"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(r.ID, "group_id"))
+}
+
+func TestNetworkGroupInspect(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/network-groups/web" {
+				return nil, fmt.Errorf("Expected URL '/network-groups/web', got '%s'", req.URL)
+			}
+			b, err := json.Marshal(types.NetworkGroup{Name: "web", Containers: []string{"container_id"}})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	g, err := client.NetworkGroupInspect(context.Background(), "web")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(g.Name, "web"))
+	assert.Check(t, is.DeepEqual(g.Containers, []string{"container_id"}))
+}
+
+func TestNetworkGroupInspectNotFound(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusNotFound, "Server error")),
+	}
+
+	_, err := client.NetworkGroupInspect(context.Background(), "unknown")
+	assert.Check(t, IsErrNotFound(err))
+}
+
+func TestNetworkGroupRemove(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/network-groups/web" {
+				return nil, fmt.Errorf("Expected URL '/network-groups/web', got '%s'", req.URL)
+			}
+			if req.Method != "DELETE" {
+				return nil, fmt.Errorf("expected DELETE method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.NetworkGroupRemove(context.Background(), "web")
+	assert.NilError(t, err)
+}
@@ -0,0 +1,19 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+)
+
+// NetworkGwPriority changes the gateway priority of the endpoint of a
+// container on a network.
+func (cli *Client) NetworkGwPriority(ctx context.Context, networkID, containerID string, priority int) error {
+	req := types.NetworkGwPriority{
+		Container:  containerID,
+		GwPriority: priority,
+	}
+	resp, err := cli.post(ctx, "/networks/"+networkID+"/gw-priority", nil, req, nil)
+	ensureReaderClosed(resp)
+	return err
+}
@@ -0,0 +1,64 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestNetworkGwPriorityError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	err := client.NetworkGwPriority(context.Background(), "network_id", "container_id", 10)
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestNetworkGwPriority(t *testing.T) {
+	expectedURL := "/networks/network_id/gw-priority"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+
+			var gwPriority types.NetworkGwPriority
+			if err := json.NewDecoder(req.Body).Decode(&gwPriority); err != nil {
+				return nil, err
+			}
+
+			if gwPriority.Container != "container_id" {
+				return nil, fmt.Errorf("expected 'container_id', got %s", gwPriority.Container)
+			}
+
+			if gwPriority.GwPriority != 10 {
+				return nil, fmt.Errorf("expected GwPriority to be 10, got %d", gwPriority.GwPriority)
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+
+	err := client.NetworkGwPriority(context.Background(), "network_id", "container_id", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+)
+
+// NetworkPolicyCreate creates a policy allowing or denying the traffic between
+// two bridge networks.
+func (cli *Client) NetworkPolicyCreate(ctx context.Context, policy types.NetworkPolicy) (types.NetworkPolicy, error) {
+	var response types.NetworkPolicy
+
+	if err := cli.NewVersionError("1.40", "network policy create"); err != nil {
+		return response, err
+	}
+
+	resp, err := cli.post(ctx, "/network-policies/create", nil, policy, nil)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&response)
+	ensureReaderClosed(resp)
+	return response, err
+}
+
+// NetworkPolicyList returns the network policies of the docker host.
+func (cli *Client) NetworkPolicyList(ctx context.Context) ([]types.NetworkPolicy, error) {
+	if err := cli.NewVersionError("1.40", "network policy list"); err != nil {
+		return nil, err
+	}
+
+	var policies []types.NetworkPolicy
+	resp, err := cli.get(ctx, "/network-policies", nil, nil)
+	if err != nil {
+		return policies, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&policies)
+	ensureReaderClosed(resp)
+	return policies, err
+}
+
+// NetworkPolicyInspect returns a network policy.
+func (cli *Client) NetworkPolicyInspect(ctx context.Context, name string) (types.NetworkPolicy, error) {
+	var policy types.NetworkPolicy
+
+	if err := cli.NewVersionError("1.40", "network policy inspect"); err != nil {
+		return policy, err
+	}
+	if name == "" {
+		return policy, objectNotFoundError{object: "network policy", id: name}
+	}
+
+	resp, err := cli.get(ctx, "/network-policies/"+name, nil, nil)
+	if err != nil {
+		return policy, wrapResponseError(err, resp, "network policy", name)
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&policy)
+	ensureReaderClosed(resp)
+	return policy, err
+}
+
+// NetworkPolicyRemove removes a network policy.
+func (cli *Client) NetworkPolicyRemove(ctx context.Context, name string) error {
+	if err := cli.NewVersionError("1.40", "network policy remove"); err != nil {
+		return err
+	}
+
+	resp, err := cli.delete(ctx, "/network-policies/"+name, nil, nil)
+	ensureReaderClosed(resp)
+	return wrapResponseError(err, resp, "network policy", name)
+}
@@ -0,0 +1,81 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestNetworkPolicyCreate(t *testing.T) {
+	policy := types.NetworkPolicy{
+		Name:        "frontend-to-db",
+		Source:      "frontend",
+		Destination: "backend",
+		Action:      "allow",
+		Protocol:    "tcp",
+		Ports:       []string{"5432"},
+	}
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/network-policies/create" {
+				return nil, fmt.Errorf("Expected URL '/network-policies/create', got '%s'", req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			var create types.NetworkPolicy
+			if err := json.NewDecoder(req.Body).Decode(&create); err != nil {
+				return nil, err
+			}
+			b, err := json.Marshal(create)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	r, err := client.NetworkPolicyCreate(context.Background(), policy)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(r, policy))
+}
+
+func TestNetworkPolicyInspectNotFound(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusNotFound, "Server error")),
+	}
+
+	_, err := client.NetworkPolicyInspect(context.Background(), "unknown")
+	assert.Check(t, IsErrNotFound(err))
+}
+
+func TestNetworkPolicyRemove(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/network-policies/frontend-to-db" {
+				return nil, fmt.Errorf("Expected URL '/network-policies/frontend-to-db', got '%s'", req.URL)
+			}
+			if req.Method != "DELETE" {
+				return nil, fmt.Errorf("expected DELETE method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.NetworkPolicyRemove(context.Background(), "frontend-to-db")
+	assert.NilError(t, err)
+}
@@ -0,0 +1,36 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// PluginCapabilities returns the capabilities, options and health of the
+// installed volume and network plugins
+func (cli *Client) PluginCapabilities(ctx context.Context, filter filters.Args) ([]types.PluginCapabilities, error) {
+	var capabilities []types.PluginCapabilities
+
+	if err := cli.NewVersionError("1.40", "plugin capabilities"); err != nil {
+		return capabilities, err
+	}
+	query := url.Values{}
+	if filter.Len() > 0 {
+		filterJSON, err := filters.ToJSON(filter)
+		if err != nil {
+			return capabilities, err
+		}
+		query.Set("filters", filterJSON)
+	}
+	resp, err := cli.get(ctx, "/plugins/capabilities", query, nil)
+	defer ensureReaderClosed(resp)
+	if err != nil {
+		return capabilities, wrapResponseError(err, resp, "plugin", "")
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&capabilities)
+	return capabilities, err
+}
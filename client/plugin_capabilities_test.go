@@ -0,0 +1,61 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func TestPluginCapabilitiesError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, err := client.PluginCapabilities(context.Background(), filters.NewArgs())
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestPluginCapabilities(t *testing.T) {
+	expectedURL := "/plugins/capabilities"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if f := req.URL.Query().Get("filters"); f != `{"capability":{"volumedriver":true}}` {
+				return nil, fmt.Errorf("filters not set in URL query properly, got %s", f)
+			}
+
+			b, err := json.Marshal([]types.PluginCapabilities{{
+				Name:    "sample-volume-plugin:latest",
+				Enabled: true,
+				Healthy: true,
+				Volume:  &types.PluginVolumeCapabilities{Scope: "local"},
+				Options: []types.PluginOption{{Name: "size", Type: "size"}},
+			}})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+	capabilities, err := client.PluginCapabilities(context.Background(), filters.NewArgs(filters.Arg("capability", "volumedriver")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(capabilities) != 1 || capabilities[0].Volume == nil || len(capabilities[0].Options) != 1 {
+		t.Fatalf("unexpected capabilities %+v", capabilities)
+	}
+}
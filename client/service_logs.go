@@ -44,6 +44,18 @@ func (cli *Client) ServiceLogs(ctx context.Context, serviceID string, options ty
 	}
 	query.Set("tail", options.Tail)
 
+	for task, cursor := range options.Cursors {
+		ts, err := timetypes.GetTimestamp(cursor, time.Now())
+		if err != nil {
+			return nil, errors.Wrapf(err, `invalid cursor for task %s`, task)
+		}
+		query.Add("cursor", task+"="+ts)
+	}
+
+	if options.Reorder != "" {
+		query.Set("reorder", options.Reorder)
+	}
+
 	resp, err := cli.get(ctx, "/services/"+serviceID+"/logs", query, nil)
 	if err != nil {
 		return nil, err
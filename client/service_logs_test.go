@@ -84,6 +84,23 @@ func TestServiceLogs(t *testing.T) {
 			},
 			expectedError: `invalid value for "since": failed to parse value as time or duration: "invalid value"`,
 		},
+		{
+			options: types.ContainerLogsOptions{
+				Cursors: map[string]string{"task_id": "1136073600.000000001"},
+				Reorder: "500ms",
+			},
+			expectedQueryParams: map[string]string{
+				"tail":    "",
+				"cursor":  "task_id=1136073600.000000001",
+				"reorder": "500ms",
+			},
+		},
+		{
+			options: types.ContainerLogsOptions{
+				Cursors: map[string]string{"task_id": "invalid value"},
+			},
+			expectedError: `invalid cursor for task task_id: failed to parse value as time or duration: "invalid value"`,
+		},
 	}
 	for _, logCase := range cases {
 		client := &Client{
@@ -0,0 +1,32 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+)
+
+// StorageMigrate requests the daemon to migrate its images and containers to
+// a containerd snapshotter.
+func (cli *Client) StorageMigrate(ctx context.Context, options types.StorageMigrateOptions) (types.StorageMigrateReport, error) {
+	var report types.StorageMigrateReport
+
+	if err := cli.NewVersionError("1.40", "storage migrate"); err != nil {
+		return report, err
+	}
+
+	query := url.Values{}
+	if options.Snapshotter != "" {
+		query.Set("snapshotter", options.Snapshotter)
+	}
+	serverResp, err := cli.post(ctx, "/system/storage/migrate", query, nil, nil)
+	defer ensureReaderClosed(serverResp)
+	if err != nil {
+		return report, err
+	}
+
+	err = json.NewDecoder(serverResp.body).Decode(&report)
+	return report, err
+}
@@ -0,0 +1,57 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestStorageMigrateError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+	_, err := client.StorageMigrate(context.Background(), types.StorageMigrateOptions{})
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestStorageMigrate(t *testing.T) {
+	expectedURL := "/system/storage/migrate"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			if snapshotter := req.URL.Query().Get("snapshotter"); snapshotter != "native" {
+				return nil, fmt.Errorf("snapshotter not set in URL query properly. Expected 'native', got %s", snapshotter)
+			}
+
+			b, err := json.Marshal(types.StorageMigrateReport{Snapshotter: "native", Images: []string{"sha256:abc"}})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+	report, err := client.StorageMigrate(context.Background(), types.StorageMigrateOptions{Snapshotter: "native"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Images) != 1 || report.Snapshotter != "native" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,17 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"io"
+)
+
+// SwarmBackup returns a tar archive of the swarm state of the manager.
+// It's up to the caller to close the io.ReadCloser returned by this
+// function.
+func (cli *Client) SwarmBackup(ctx context.Context) (io.ReadCloser, error) {
+	resp, err := cli.post(ctx, "/swarm/backup", nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
@@ -0,0 +1,54 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSwarmBackupError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.SwarmBackup(context.Background())
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestSwarmBackup(t *testing.T) {
+	expectedURL := "/swarm/backup"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("archive"))),
+			}, nil
+		}),
+	}
+
+	backup, err := client.SwarmBackup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+	b, err := ioutil.ReadAll(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "archive" {
+		t.Fatalf("expected the archive, got %q", b)
+	}
+}
@@ -0,0 +1,30 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// SwarmRestore initializes a new swarm from the backup of a manager.
+func (cli *Client) SwarmRestore(ctx context.Context, req swarm.RestoreRequest, backup io.Reader) error {
+	query := url.Values{}
+	if req.ListenAddr != "" {
+		query.Set("listenAddr", req.ListenAddr)
+	}
+	if req.AdvertiseAddr != "" {
+		query.Set("advertiseAddr", req.AdvertiseAddr)
+	}
+	if req.DataPathAddr != "" {
+		query.Set("dataPathAddr", req.DataPathAddr)
+	}
+	headers := map[string][]string{"Content-Type": {"application/x-tar"}}
+	if req.UnlockKey != "" {
+		headers["X-Swarm-Unlock-Key"] = []string{req.UnlockKey}
+	}
+	resp, err := cli.postRaw(ctx, "/swarm/restore", query, backup, headers)
+	ensureReaderClosed(resp)
+	return err
+}
@@ -0,0 +1,64 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func TestSwarmRestoreError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	err := client.SwarmRestore(context.Background(), swarm.RestoreRequest{}, strings.NewReader("archive"))
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+}
+
+func TestSwarmRestore(t *testing.T) {
+	expectedURL := "/swarm/restore"
+
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			if listenAddr := req.URL.Query().Get("listenAddr"); listenAddr != "0.0.0.0:2377" {
+				return nil, fmt.Errorf("listenAddr not set in URL query properly. Expected '0.0.0.0:2377', got %s", listenAddr)
+			}
+			if key := req.Header.Get("X-Swarm-Unlock-Key"); key != "SWMKEY-1-y6guTZNTwpQeTL5RhUfOsdBdXoQjiB2GADHSRJvbXeU" {
+				return nil, fmt.Errorf("unlock key not set in the headers properly, got %s", key)
+			}
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if string(b) != "archive" {
+				return nil, fmt.Errorf("expected the archive, got %q", b)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+
+	err := client.SwarmRestore(context.Background(), swarm.RestoreRequest{
+		ListenAddr: "0.0.0.0:2377",
+		UnlockKey:  "SWMKEY-1-y6guTZNTwpQeTL5RhUfOsdBdXoQjiB2GADHSRJvbXeU",
+	}, strings.NewReader("archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -43,6 +43,18 @@ func (cli *Client) TaskLogs(ctx context.Context, taskID string, options types.Co
 	}
 	query.Set("tail", options.Tail)
 
+	for task, cursor := range options.Cursors {
+		ts, err := timetypes.GetTimestamp(cursor, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		query.Add("cursor", task+"="+ts)
+	}
+
+	if options.Reorder != "" {
+		query.Set("reorder", options.Reorder)
+	}
+
 	resp, err := cli.get(ctx, "/tasks/"+taskID+"/logs", query, nil)
 	if err != nil {
 		return nil, err
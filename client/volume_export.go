@@ -0,0 +1,70 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+)
+
+// VolumeExport retrieves a tarball of the data of a volume, gzip compressed
+// if compress is set, and returns it as an io.ReadCloser. It's up to the
+// caller to close the stream.
+func (cli *Client) VolumeExport(ctx context.Context, volumeID string, compress bool) (io.ReadCloser, error) {
+	if err := cli.NewVersionError("1.40", "volume export"); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if compress {
+		query.Set("compress", "1")
+	}
+	resp, err := cli.get(ctx, "/volumes/"+volumeID+"/export", query, nil)
+	if err != nil {
+		return nil, wrapResponseError(err, resp, "volume", volumeID)
+	}
+	return resp.body, nil
+}
+
+// VolumeImport creates a volume from a tarball of its data, which may be
+// compressed.
+func (cli *Client) VolumeImport(ctx context.Context, source io.Reader, options types.VolumeImportOptions) (types.Volume, error) {
+	var volume types.Volume
+
+	if err := cli.NewVersionError("1.40", "volume import"); err != nil {
+		return volume, err
+	}
+
+	query := url.Values{}
+	if options.Name != "" {
+		query.Set("name", options.Name)
+	}
+	if options.Driver != "" {
+		query.Set("driver", options.Driver)
+	}
+	if len(options.DriverOpts) > 0 {
+		driverOpts, err := json.Marshal(options.DriverOpts)
+		if err != nil {
+			return volume, err
+		}
+		query.Set("driverOpts", string(driverOpts))
+	}
+	if len(options.Labels) > 0 {
+		labels, err := json.Marshal(options.Labels)
+		if err != nil {
+			return volume, err
+		}
+		query.Set("labels", string(labels))
+	}
+
+	headers := map[string][]string{"Content-Type": {"application/x-tar"}}
+	resp, err := cli.postRaw(ctx, "/volumes/import", query, source, headers)
+	if err != nil {
+		return volume, err
+	}
+	err = json.NewDecoder(resp.body).Decode(&volume)
+	ensureReaderClosed(resp)
+	return volume, err
+}
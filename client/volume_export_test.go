@@ -0,0 +1,104 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestVolumeExportError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeExport(context.Background(), "volume_id", false)
+	assert.Check(t, is.Error(err, "Error response from daemon: Server error"))
+}
+
+func TestVolumeExport(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/volumes/volume_id/export" {
+				return nil, fmt.Errorf("Expected URL '/volumes/volume_id/export', got '%s'", req.URL)
+			}
+			if compress := req.URL.Query().Get("compress"); compress != "1" {
+				return nil, fmt.Errorf("compress not set in URL query properly. Expected '1', got %s", compress)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("response"))),
+			}, nil
+		}),
+	}
+
+	body, err := client.VolumeExport(context.Background(), "volume_id", true)
+	assert.NilError(t, err)
+	defer body.Close()
+	content, err := ioutil.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "response"))
+}
+
+func TestVolumeImportError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeImport(context.Background(), strings.NewReader("source"), types.VolumeImportOptions{})
+	assert.Check(t, is.Error(err, "Error response from daemon: Server error"))
+}
+
+func TestVolumeImport(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/volumes/import" {
+				return nil, fmt.Errorf("Expected URL '/volumes/import', got '%s'", req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			if contentType := req.Header.Get("Content-Type"); contentType != "application/x-tar" {
+				return nil, fmt.Errorf("Content-type header not set properly. Expected 'application/x-tar', got %s", contentType)
+			}
+			query := req.URL.Query()
+			if name := query.Get("name"); name != "volume_id" {
+				return nil, fmt.Errorf("name not set in URL query properly. Expected 'volume_id', got %s", name)
+			}
+			if labels := query.Get("labels"); labels != `{"foo":"bar"}` {
+				return nil, fmt.Errorf("labels not set in URL query properly. Expected '{\"foo\":\"bar\"}', got %s", labels)
+			}
+			content, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if string(content) != "source" {
+				return nil, fmt.Errorf("expected the source as body, got %s", content)
+			}
+			b, err := json.Marshal(types.Volume{Name: "volume_id", Driver: "local"})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	volume, err := client.VolumeImport(context.Background(), strings.NewReader("source"), types.VolumeImportOptions{
+		Name:   "volume_id",
+		Labels: map[string]string{"foo": "bar"},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(volume.Name, "volume_id"))
+	assert.Check(t, is.Equal(volume.Driver, "local"))
+}
@@ -4,14 +4,29 @@ import (
 	"context"
 	"net/url"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/versions"
 )
 
 // VolumeRemove removes a volume from the docker host.
 func (cli *Client) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	return cli.VolumeRemoveWithOptions(ctx, volumeID, types.VolumeRemoveOptions{Force: force})
+}
+
+// VolumeRemoveWithOptions removes a volume from the docker host, detaching
+// it if it is still mounted with options.Detach.
+func (cli *Client) VolumeRemoveWithOptions(ctx context.Context, volumeID string, options types.VolumeRemoveOptions) error {
 	query := url.Values{}
-	if versions.GreaterThanOrEqualTo(cli.version, "1.25") {
-		if force {
+	if options.Detach {
+		if err := cli.NewVersionError("1.40", "volume detach"); err != nil {
+			return err
+		}
+		query.Set("force", "detach")
+		if options.Kill {
+			query.Set("kill", "1")
+		}
+	} else if versions.GreaterThanOrEqualTo(cli.version, "1.25") {
+		if options.Force {
 			query.Set("force", "1")
 		}
 	}
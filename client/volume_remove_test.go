@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/docker/docker/api/types"
 )
 
 func TestVolumeRemoveError(t *testing.T) {
@@ -44,3 +46,26 @@ func TestVolumeRemove(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestVolumeRemoveDetach(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			if force := query.Get("force"); force != "detach" {
+				return nil, fmt.Errorf("force not set to detach in URL query properly: %q", force)
+			}
+			if kill := query.Get("kill"); kill != "1" {
+				return nil, fmt.Errorf("kill not set in URL query properly: %q", kill)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.VolumeRemoveWithOptions(context.Background(), "volume_id", types.VolumeRemoveOptions{Detach: true, Kill: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
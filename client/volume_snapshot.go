@@ -0,0 +1,72 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+)
+
+// VolumeSnapshot takes a snapshot of the data of a volume. The name of the
+// snapshot is generated by the daemon if it is empty.
+func (cli *Client) VolumeSnapshot(ctx context.Context, volumeID, snapshot string) (types.VolumeSnapshot, error) {
+	var response types.VolumeSnapshot
+
+	if err := cli.NewVersionError("1.40", "volume snapshot"); err != nil {
+		return response, err
+	}
+
+	query := url.Values{}
+	if snapshot != "" {
+		query.Set("snapshot", snapshot)
+	}
+	resp, err := cli.post(ctx, "/volumes/"+volumeID+"/snapshot", query, nil, nil)
+	if err != nil {
+		return response, wrapResponseError(err, resp, "volume", volumeID)
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&response)
+	ensureReaderClosed(resp)
+	return response, err
+}
+
+// VolumeSnapshotList returns the snapshots of a volume.
+func (cli *Client) VolumeSnapshotList(ctx context.Context, volumeID string) ([]types.VolumeSnapshot, error) {
+	if err := cli.NewVersionError("1.40", "volume snapshot list"); err != nil {
+		return nil, err
+	}
+
+	var snapshots []types.VolumeSnapshot
+	resp, err := cli.get(ctx, "/volumes/"+volumeID+"/snapshots", nil, nil)
+	if err != nil {
+		return snapshots, wrapResponseError(err, resp, "volume", volumeID)
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&snapshots)
+	ensureReaderClosed(resp)
+	return snapshots, err
+}
+
+// VolumeSnapshotRestore replaces the data of a volume with the data of one of
+// its snapshots. The volume must not be in use.
+func (cli *Client) VolumeSnapshotRestore(ctx context.Context, volumeID, snapshot string) error {
+	if err := cli.NewVersionError("1.40", "volume snapshot restore"); err != nil {
+		return err
+	}
+
+	resp, err := cli.post(ctx, "/volumes/"+volumeID+"/snapshots/"+snapshot+"/restore", nil, nil, nil)
+	ensureReaderClosed(resp)
+	return wrapResponseError(err, resp, "volume snapshot", snapshot)
+}
+
+// VolumeSnapshotRemove removes a snapshot of a volume.
+func (cli *Client) VolumeSnapshotRemove(ctx context.Context, volumeID, snapshot string) error {
+	if err := cli.NewVersionError("1.40", "volume snapshot remove"); err != nil {
+		return err
+	}
+
+	resp, err := cli.delete(ctx, "/volumes/"+volumeID+"/snapshots/"+snapshot, nil, nil)
+	ensureReaderClosed(resp)
+	return wrapResponseError(err, resp, "volume snapshot", snapshot)
+}
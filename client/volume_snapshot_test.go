@@ -0,0 +1,130 @@
+package client // import "github.com/docker/docker/client"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestVolumeSnapshotError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusInternalServerError, "Server error")),
+	}
+
+	_, err := client.VolumeSnapshot(context.Background(), "volume_id", "")
+	assert.Check(t, is.Error(err, "Error response from daemon: Server error"))
+}
+
+func TestVolumeSnapshot(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/volumes/volume_id/snapshot" {
+				return nil, fmt.Errorf("Expected URL '/volumes/volume_id/snapshot', got '%s'", req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			snapshot := req.URL.Query().Get("snapshot")
+			if snapshot != "before-upgrade" {
+				return nil, fmt.Errorf("snapshot name not set in URL query properly. Expected 'before-upgrade', got %s", snapshot)
+			}
+			b, err := json.Marshal(types.VolumeSnapshot{Name: snapshot, Volume: "volume_id", Method: "copy"})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	snapshot, err := client.VolumeSnapshot(context.Background(), "volume_id", "before-upgrade")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(snapshot.Name, "before-upgrade"))
+	assert.Check(t, is.Equal(snapshot.Volume, "volume_id"))
+	assert.Check(t, is.Equal(snapshot.Method, "copy"))
+}
+
+func TestVolumeSnapshotList(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/volumes/volume_id/snapshots" {
+				return nil, fmt.Errorf("Expected URL '/volumes/volume_id/snapshots', got '%s'", req.URL)
+			}
+			if req.Method != "GET" {
+				return nil, fmt.Errorf("expected GET method, got %s", req.Method)
+			}
+			b, err := json.Marshal([]types.VolumeSnapshot{{Name: "one"}, {Name: "two"}})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	snapshots, err := client.VolumeSnapshotList(context.Background(), "volume_id")
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(snapshots, 2))
+	assert.Check(t, is.Equal(snapshots[1].Name, "two"))
+}
+
+func TestVolumeSnapshotRestoreError(t *testing.T) {
+	client := &Client{
+		client: newMockClient(errorMock(http.StatusConflict, "volume is in use")),
+	}
+
+	err := client.VolumeSnapshotRestore(context.Background(), "volume_id", "snapshot_id")
+	assert.Check(t, is.Error(err, "Error response from daemon: volume is in use"))
+}
+
+func TestVolumeSnapshotRestore(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/volumes/volume_id/snapshots/snapshot_id/restore" {
+				return nil, fmt.Errorf("Expected URL '/volumes/volume_id/snapshots/snapshot_id/restore', got '%s'", req.URL)
+			}
+			if req.Method != "POST" {
+				return nil, fmt.Errorf("expected POST method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.VolumeSnapshotRestore(context.Background(), "volume_id", "snapshot_id")
+	assert.NilError(t, err)
+}
+
+func TestVolumeSnapshotRemove(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/volumes/volume_id/snapshots/snapshot_id" {
+				return nil, fmt.Errorf("Expected URL '/volumes/volume_id/snapshots/snapshot_id', got '%s'", req.URL)
+			}
+			if req.Method != "DELETE" {
+				return nil, fmt.Errorf("expected DELETE method, got %s", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}
+
+	err := client.VolumeSnapshotRemove(context.Background(), "volume_id", "snapshot_id")
+	assert.NilError(t, err)
+}
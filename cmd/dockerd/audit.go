@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/docker/docker/daemon/config"
+	"github.com/docker/docker/pkg/auditlog"
+	"github.com/sirupsen/logrus"
+)
+
+// newAuditLog creates the audit log of the state-changing API requests, or
+// returns nil if it is disabled. The state of the log is kept in the audit
+// directory of the data root, for the log to continue across the restarts.
+func newAuditLog(conf config.AuditLogConfig, root string) (*auditlog.Log, error) {
+	var (
+		sink auditlog.Sink
+		err  error
+	)
+	switch conf.Sink {
+	case "":
+		return nil, nil
+	case config.AuditLogSinkFile:
+		path := conf.Path
+		if path == "" {
+			path = filepath.Join(root, "audit", "audit.log")
+		}
+		sink, err = auditlog.NewFileSink(path)
+	case config.AuditLogSinkSyslog:
+		sink, err = auditlog.NewSyslogSink(conf.Address)
+	case config.AuditLogSinkWebhook:
+		sink = auditlog.NewWebhookSink(conf.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l, err := auditlog.New(sink, filepath.Join(root, "audit", "state.json"))
+	if err != nil {
+		sink.Close()
+		return nil, err
+	}
+	logrus.WithField("sink", conf.Sink).Info("recording API requests in the audit log")
+	return l, nil
+}
@@ -50,6 +50,9 @@ func installCommonConfigFlags(conf *config.Config, flags *pflag.FlagSet) {
 	flags.Var(opts.NewListOptsRef(&conf.DNS, opts.ValidateIPAddress), "dns", "DNS server to use")
 	flags.Var(opts.NewNamedListOptsRef("dns-opts", &conf.DNSOptions, nil), "dns-opt", "DNS options to use")
 	flags.Var(opts.NewListOptsRef(&conf.DNSSearch, opts.ValidateDNSSearch), "dns-search", "DNS search domains to use")
+	flags.Var(opts.NewNamedListOptsRef("dns-upstreams", &conf.DNSUpstreams, opts.ValidateDNSUpstream), "dns-upstream", "DNS over TLS (tls://) or DNS over HTTPS (https://) server the embedded DNS server forwards queries to")
+	flags.StringVar(&conf.DNSUpstreamCA, "dns-upstream-ca", "", "Trust only certificates signed by this CA for the DNS upstreams")
+	flags.StringVar(&conf.DNSResponseMode, "dns-response-mode", "all", "Return all the addresses of a name shared by several containers (all), or one in turn (round-robin)")
 	flags.Var(opts.NewNamedListOptsRef("labels", &conf.Labels, opts.ValidateLabel), "label", "Set key=value labels to the daemon")
 	flags.StringVar(&conf.LogConfig.Type, "log-driver", "json-file", "Default driver for container logs")
 	flags.Var(opts.NewNamedMapOpts("log-opts", conf.LogConfig.Config, nil), "log-opt", "Default log driver options for containers")
@@ -57,17 +60,29 @@ func installCommonConfigFlags(conf *config.Config, flags *pflag.FlagSet) {
 	flags.StringVar(&conf.ClusterStore, "cluster-store", "", "URL of the distributed storage backend")
 	flags.Var(opts.NewNamedMapOpts("cluster-store-opts", conf.ClusterOpts, nil), "cluster-store-opt", "Set cluster store options")
 	flags.StringVar(&conf.CorsHeaders, "api-cors-header", "", "Set CORS headers in the Engine API")
+	flags.Float64Var(&conf.APIRateLimit, "api-rate-limit", 0, "Limit the API requests of each client to this many per second")
+	flags.IntVar(&conf.APIRateBurst, "api-rate-burst", 0, "Allow each client this many API requests at once above the rate limit")
+	flags.BoolVar(&conf.GRPCAPI, "grpc-api", false, "Serve the gRPC API on the sockets of the REST API")
 	flags.IntVar(&maxConcurrentDownloads, "max-concurrent-downloads", config.DefaultMaxConcurrentDownloads, "Set the max concurrent downloads for each pull")
 	flags.IntVar(&maxConcurrentUploads, "max-concurrent-uploads", config.DefaultMaxConcurrentUploads, "Set the max concurrent uploads for each push")
 	flags.IntVar(&conf.ShutdownTimeout, "shutdown-timeout", defaultShutdownTimeout, "Set the default shutdown timeout")
+	flags.BoolVar(&conf.ExecAudit, "exec-audit", false, "Record exec sessions in the exec audit log")
+	flags.BoolVar(&conf.ExecAuditEvents, "exec-audit-events", false, "Emit an event for every exec audit log entry")
+	flags.BoolVar(&conf.ContainerScheduler, "container-scheduler", false, "Start and stop containers on the schedules set in their labels")
+	flags.Var(&conf.EventsLogSize, "events-log-size", "Persist the events to an event log of this size, to replay them across restarts")
+	flags.StringVar(&conf.EventsLogRetention, "events-log-retention", "", "Replay the events of the event log for this duration")
 	flags.IntVar(&conf.NetworkDiagnosticPort, "network-diagnostic-port", 0, "TCP port number of the network diagnostic server")
 	flags.MarkHidden("network-diagnostic-port")
 
 	flags.StringVar(&conf.SwarmDefaultAdvertiseAddr, "swarm-default-advertise-addr", "", "Set default address or interface for swarm advertised address")
 	flags.BoolVar(&conf.Experimental, "experimental", false, "Enable experimental features")
 	flags.StringVar(&conf.MetricsAddress, "metrics-addr", "", "Set default address and port to serve the metrics api on")
+	flags.BoolVar(&conf.ContainerMetrics, "container-metrics", false, "Export the resource usage of every running container on the metrics api")
 
 	flags.Var(opts.NewNamedListOptsRef("node-generic-resources", &conf.NodeGenericResources, opts.ValidateSingleGenericResource), "node-generic-resource", "Advertise user-defined resource")
+	flags.BoolVar(&conf.NodeGPUDiscovery, "node-gpu-discovery", false, "Advertise the GPUs of the host as generic resources")
+	flags.Var(opts.NewNamedListOptsRef("csi-plugins", &conf.CSIPlugins, nil), "csi-plugin", "Use a CSI node plugin as a volume driver (name=endpoint)")
+	flags.StringVar(&conf.VolumeEncryptionKeyFile, "volume-encryption-key-file", "", "Key file of the encrypted local volumes, outside of the filesystem of the volumes")
 
 	flags.IntVar(&conf.NetworkControlPlaneMTU, "network-control-plane-mtu", config.DefaultNetworkMtu, "Network Control plane MTU")
 
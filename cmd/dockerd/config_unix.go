@@ -27,12 +27,17 @@ func installConfigFlags(conf *config.Config, flags *pflag.FlagSet) {
 	flags.BoolVar(&conf.EnableSelinuxSupport, "selinux-enabled", false, "Enable selinux support")
 	flags.Var(opts.NewNamedUlimitOpt("default-ulimits", &conf.Ulimits), "default-ulimit", "Default ulimits for containers")
 	flags.BoolVar(&conf.BridgeConfig.EnableIPTables, "iptables", true, "Enable addition of iptables rules")
+	flags.BoolVar(&conf.BridgeConfig.EnableIP6Tables, "ip6tables", false, "Enable addition of ip6tables rules")
+	flags.StringVar(&conf.BridgeConfig.FirewallBackend, "firewall-backend", "iptables", "Backend programming the firewall rules (iptables, nftables)")
 	flags.BoolVar(&conf.BridgeConfig.EnableIPForward, "ip-forward", true, "Enable net.ipv4.ip_forward")
 	flags.BoolVar(&conf.BridgeConfig.EnableIPMasq, "ip-masq", true, "Enable IP masquerading")
 	flags.BoolVar(&conf.BridgeConfig.EnableIPv6, "ipv6", false, "Enable IPv6 networking")
 	flags.StringVar(&conf.BridgeConfig.FixedCIDRv6, "fixed-cidr-v6", "", "IPv6 subnet for fixed IPs")
 	flags.BoolVar(&conf.BridgeConfig.EnableUserlandProxy, "userland-proxy", true, "Use userland proxy for loopback traffic")
 	flags.StringVar(&conf.BridgeConfig.UserlandProxyPath, "userland-proxy-path", "", "Path to the userland proxy binary")
+	flags.StringVar(&conf.BridgeConfig.UserlandProxyMode, "userland-proxy-mode", "process", "Run the userland proxy in a process per published port (process), or in the daemon (daemon)")
+	flags.StringVar(&conf.BridgeConfig.PortMapper, "port-mapper", "iptables", "Map the published ports with iptables NAT rules (iptables), or with an eBPF socket lookup program (ebpf)")
+	flags.StringVar(&conf.BridgeConfig.DynamicPortRange, "dynamic-port-range", "", "Range of the host ports allocated to published container ports (e.g. 49153-60999)")
 	flags.StringVar(&conf.CgroupParent, "cgroup-parent", "", "Set parent cgroup for all containers")
 	flags.StringVar(&conf.RemappedRoot, "userns-remap", "", "User/Group setting for user namespaces")
 	flags.BoolVar(&conf.LiveRestoreEnabled, "live-restore", false, "Enable live restore of docker when containers are still running")
@@ -42,9 +47,13 @@ func installConfigFlags(conf *config.Config, flags *pflag.FlagSet) {
 	flags.Int64Var(&conf.CPURealtimePeriod, "cpu-rt-period", 0, "Limit the CPU real-time period in microseconds")
 	flags.Int64Var(&conf.CPURealtimeRuntime, "cpu-rt-runtime", 0, "Limit the CPU real-time runtime in microseconds")
 	flags.StringVar(&conf.SeccompProfile, "seccomp-profile", "", "Path to seccomp profile")
+	flags.Var(opts.NewNamedListOptsRef("selinux-label-opts", &conf.SELinuxLabelOpts, nil), "selinux-label-opt", "Default SELinux label options of the containers")
+	flags.Var(opts.NewNamedListOptsRef("masked-paths", &conf.MaskedPaths, nil), "masked-path", "Default paths masked in the containers, instead of the built-in defaults")
+	flags.Var(opts.NewNamedListOptsRef("readonly-paths", &conf.ReadonlyPaths, nil), "readonly-path", "Default paths set read-only in the containers, instead of the built-in defaults")
+	flags.StringVar(&conf.AppArmorTemplate, "apparmor-template", "", "Path to the template of the AppArmor profiles of the containers")
 	flags.Var(&conf.ShmSize, "default-shm-size", "Default shm size for containers")
 	flags.BoolVar(&conf.NoNewPrivileges, "no-new-privileges", false, "Set no-new-privileges by default for new containers")
 	flags.StringVar(&conf.IpcMode, "default-ipc-mode", config.DefaultIpcMode, `Default mode for containers ipc ("shareable" | "private")`)
-	flags.Var(&conf.NetworkConfig.DefaultAddressPools, "default-address-pool", "Default address pools for node specific local networks")
+	flags.Var(&conf.NetworkConfig.DefaultAddressPools, "default-address-pool", "Default address pools for node specific local networks, optionally reserved to the networks of a driver")
 
 }
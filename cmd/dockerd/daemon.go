@@ -23,6 +23,7 @@ import (
 	distributionrouter "github.com/docker/docker/api/server/router/distribution"
 	"github.com/docker/docker/api/server/router/image"
 	"github.com/docker/docker/api/server/router/network"
+	operationrouter "github.com/docker/docker/api/server/router/operation"
 	pluginrouter "github.com/docker/docker/api/server/router/plugin"
 	sessionrouter "github.com/docker/docker/api/server/router/session"
 	swarmrouter "github.com/docker/docker/api/server/router/swarm"
@@ -39,6 +40,7 @@ import (
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/libcontainerd/supervisor"
 	dopts "github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/auditlog"
 	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/pidfile"
@@ -61,9 +63,14 @@ type DaemonCli struct {
 	configFile *string
 	flags      *pflag.FlagSet
 
-	api             *apiserver.Server
-	d               *daemon.Daemon
-	authzMiddleware *authorization.Middleware // authzMiddleware enables to dynamically reload the authorization plugins
+	api                 *apiserver.Server
+	d                   *daemon.Daemon
+	authzMiddleware     *authorization.Middleware       // authzMiddleware enables to dynamically reload the authorization plugins
+	rateLimitMiddleware *middleware.RateLimitMiddleware // rateLimitMiddleware enables to dynamically reload the API rate limits
+	// tlsIdentityMiddleware enables to dynamically reload the TLS identities
+	tlsIdentityMiddleware *authorization.TLSIdentityMiddleware
+	auditLog              *auditlog.Log // auditLog is the audit log of the API requests, if enabled
+	metrics               metricsServer // metrics serves the metrics API on the reloadable metrics address
 }
 
 // NewDaemonCli returns a daemon CLI
@@ -128,6 +135,12 @@ func (cli *DaemonCli) start(opts *daemonOptions) (err error) {
 		}()
 	}
 
+	stopTracing, err := startTracing(cli.Config.Tracing)
+	if err != nil {
+		return errors.Wrap(err, "failed to start tracing")
+	}
+	defer stopTracing()
+
 	serverConfig, err := newAPIServerConfig(cli)
 	if err != nil {
 		return errors.Wrap(err, "failed to create API server")
@@ -176,6 +189,13 @@ func (cli *DaemonCli) start(opts *daemonOptions) (err error) {
 	if err := cli.initMiddlewares(cli.api, serverConfig, pluginStore); err != nil {
 		logrus.Fatalf("Error creating middlewares: %v", err)
 	}
+	if cli.auditLog != nil {
+		defer func() {
+			if err := cli.auditLog.Close(); err != nil {
+				logrus.WithError(err).Error("Error closing audit log")
+			}
+		}()
+	}
 
 	d, err := daemon.NewDaemon(ctx, cli.Config, pluginStore)
 	if err != nil {
@@ -189,12 +209,11 @@ func (cli *DaemonCli) start(opts *daemonOptions) (err error) {
 		return errors.Wrap(err, "failed to validate authorization plugin")
 	}
 
-	// TODO: move into startMetricsServer()
 	if cli.Config.MetricsAddress != "" {
 		if !d.HasExperimental() {
 			return errors.Wrap(err, "metrics-addr is only supported when experimental is enabled")
 		}
-		if err := startMetricsServer(cli.Config.MetricsAddress); err != nil {
+		if err := cli.metrics.setAddress(cli.Config.MetricsAddress); err != nil {
 			return err
 		}
 	}
@@ -326,6 +345,26 @@ func (cli *DaemonCli) reloadConfig() {
 		}
 		cli.authzMiddleware.SetPlugins(c.AuthorizationPlugins)
 
+		if c.IsValueSet("api-rate-limit") || c.IsValueSet("api-rate-burst") {
+			cli.rateLimitMiddleware.SetLimits(c.APIRateLimit, c.APIRateBurst)
+		}
+
+		if c.IsValueSet("tls-identities") {
+			cli.tlsIdentityMiddleware.SetIdentities(c.TLSIdentities.Identities, c.TLSIdentities.DefaultPolicy)
+		}
+
+		if c.IsValueSet("metrics-addr") {
+			err := errors.New("metrics-addr is only supported when experimental is enabled")
+			if c.MetricsAddress == "" || cli.d.HasExperimental() {
+				err = cli.metrics.setAddress(c.MetricsAddress)
+			}
+			if err != nil {
+				logrus.Errorf("Error reloading the metrics address: %v", err)
+				cli.d.RecordReloadError(err)
+				return
+			}
+		}
+
 		// The namespaces com.docker.*, io.docker.*, org.dockerproject.* have been documented
 		// to be reserved for Docker's internal use, but this was never enforced.  Allowing
 		// configured labels to use these namespaces are deprecated for 18.05.
@@ -357,6 +396,7 @@ func (cli *DaemonCli) reloadConfig() {
 
 	if err := config.Reload(*cli.configFile, cli.flags, reload); err != nil {
 		logrus.Error(err)
+		cli.d.RecordReloadError(err)
 	}
 }
 
@@ -492,6 +532,7 @@ func initRouter(opts routerOptions) {
 		swarmrouter.NewRouter(opts.cluster),
 		pluginrouter.NewRouter(opts.daemon.PluginManager()),
 		distributionrouter.NewRouter(opts.daemon.ImageService()),
+		operationrouter.NewRouter(opts.daemon),
 	}
 
 	if opts.daemon.NetworkControllerEnabled() {
@@ -526,9 +567,31 @@ func (cli *DaemonCli) initMiddlewares(s *apiserver.Server, cfg *apiserver.Config
 		s.UseMiddleware(c)
 	}
 
+	// the rate limits apply to the users set by the authorization
+	// middleware, which wraps the rate limit middleware
+	cli.rateLimitMiddleware = middleware.NewRateLimitMiddleware(cli.Config.APIRateLimit, cli.Config.APIRateBurst)
+	s.UseMiddleware(cli.rateLimitMiddleware)
+
 	cli.authzMiddleware = authorization.NewMiddleware(cli.Config.AuthorizationPlugins, pluginStore)
 	cli.Config.AuthzMiddleware = cli.authzMiddleware
 	s.UseMiddleware(cli.authzMiddleware)
+
+	// the policies of the TLS identities are enforced before the
+	// authorization plugins, the authorization middleware using the
+	// identities as the users of the requests
+	cli.tlsIdentityMiddleware = authorization.NewTLSIdentityMiddleware(cli.Config.TLSIdentities.Identities, cli.Config.TLSIdentities.DefaultPolicy)
+	s.UseMiddleware(cli.tlsIdentityMiddleware)
+
+	// the audit middleware wraps all the other middlewares, for the
+	// requests they deny to be recorded
+	auditLog, err := newAuditLog(cli.Config.AuditLog, cli.Config.Root)
+	if err != nil {
+		return errors.Wrap(err, "failed to create audit log")
+	}
+	if auditLog != nil {
+		cli.auditLog = auditLog
+		s.UseMiddleware(middleware.NewAuditMiddleware(auditLog))
+	}
 	return nil
 }
 
@@ -557,6 +620,7 @@ func newAPIServerConfig(cli *DaemonCli) (*apiserver.Config, error) {
 		SocketGroup: cli.Config.SocketGroup,
 		Version:     dockerversion.Version,
 		CorsHeaders: cli.Config.CorsHeaders,
+		GRPC:        cli.Config.GRPCAPI,
 	}
 
 	if cli.Config.TLS {
@@ -575,6 +639,10 @@ func newAPIServerConfig(cli *DaemonCli) (*apiserver.Config, error) {
 		if err != nil {
 			return nil, err
 		}
+		if cli.Config.GRPCAPI {
+			// the gRPC clients connecting with TLS negotiate HTTP/2
+			tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		}
 		serverConfig.TLSConfig = tlsConfig
 	}
 
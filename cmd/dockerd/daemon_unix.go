@@ -15,7 +15,7 @@ import (
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/libcontainerd/supervisor"
-	"github.com/docker/libnetwork/portallocator"
+	"github.com/docker/docker/libnetwork/portallocator"
 	"golang.org/x/sys/unix"
 )
 
@@ -71,30 +71,52 @@ func (cli *DaemonCli) getSwarmRunRoot() string {
 // allocateDaemonPort ensures that there are no containers
 // that try to use any port allocated for the docker server.
 func allocateDaemonPort(addr string) error {
-	host, port, err := net.SplitHostPort(addr)
+	hostIPs, port, err := daemonPortIPs(addr)
 	if err != nil {
 		return err
 	}
 
+	pa := portallocator.Get()
+	for _, hostIP := range hostIPs {
+		if _, err := pa.RequestPort(hostIP, "tcp", port); err != nil {
+			return fmt.Errorf("failed to allocate daemon listening port %d (err: %v)", port, err)
+		}
+	}
+	return nil
+}
+
+// releaseDaemonPort releases the port allocated by allocateDaemonPort.
+func releaseDaemonPort(addr string) {
+	hostIPs, port, err := daemonPortIPs(addr)
+	if err != nil {
+		return
+	}
+	pa := portallocator.Get()
+	for _, hostIP := range hostIPs {
+		pa.ReleasePort(hostIP, "tcp", port)
+	}
+}
+
+// daemonPortIPs returns the IP addresses and the port of a listening address
+// of the daemon.
+func daemonPortIPs(addr string) ([]net.IP, int, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	intPort, err := strconv.Atoi(port)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	var hostIPs []net.IP
 	if parsedIP := net.ParseIP(host); parsedIP != nil {
 		hostIPs = append(hostIPs, parsedIP)
 	} else if hostIPs, err = net.LookupIP(host); err != nil {
-		return fmt.Errorf("failed to lookup %s address in host specification", host)
+		return nil, 0, fmt.Errorf("failed to lookup %s address in host specification", host)
 	}
-
-	pa := portallocator.Get()
-	for _, hostIP := range hostIPs {
-		if _, err := pa.RequestPort(hostIP, "tcp", intPort); err != nil {
-			return fmt.Errorf("failed to allocate daemon listening port %d (err: %v)", intPort, err)
-		}
-	}
-	return nil
+	return hostIPs, intPort, nil
 }
 
 func wrapListeners(proto string, ls []net.Listener) []net.Listener {
@@ -3,25 +3,58 @@ package main
 import (
 	"net"
 	"net/http"
+	"sync"
 
 	"github.com/docker/go-metrics"
 	"github.com/sirupsen/logrus"
 )
 
-func startMetricsServer(addr string) error {
-	if err := allocateDaemonPort(addr); err != nil {
-		return err
+// metricsServer serves the metrics API on an address which is reloaded with
+// the configuration.
+type metricsServer struct {
+	mu   sync.Mutex
+	addr string
+	srv  *http.Server
+}
+
+// setAddress serves the metrics API on addr, or stops serving it if addr is
+// empty. The metrics API keeps being served on its previous address when it
+// cannot be served on addr.
+func (s *metricsServer) setAddress(addr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if addr == s.addr {
+		return nil
+	}
+
+	var l net.Listener
+	if addr != "" {
+		if err := allocateDaemonPort(addr); err != nil {
+			return err
+		}
+		var err error
+		if l, err = net.Listen("tcp", addr); err != nil {
+			releaseDaemonPort(addr)
+			return err
+		}
 	}
-	l, err := net.Listen("tcp", addr)
-	if err != nil {
-		return err
+	if s.srv != nil {
+		s.srv.Close()
+		releaseDaemonPort(s.addr)
+		s.srv = nil
 	}
+	s.addr = addr
+	if l == nil {
+		return nil
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", metrics.Handler())
-	go func() {
-		if err := http.Serve(l, mux); err != nil {
+	s.srv = &http.Server{Handler: mux}
+	go func(srv *http.Server) {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
 			logrus.Errorf("serve metrics api: %s", err)
 		}
-	}()
+	}(s.srv)
 	return nil
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/docker/docker/daemon/config"
+	"github.com/docker/docker/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+// startTracing starts exporting the spans of the daemon to the OTLP endpoint
+// of the configuration, and returns a function sending the spans left to be
+// sent when the daemon stops. The spans are not sampled when the endpoint is
+// not set.
+func startTracing(conf config.TracingConfig) (func(), error) {
+	if conf.Endpoint == "" {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return func() {}, nil
+	}
+
+	serviceName := conf.ServiceName
+	if serviceName == "" {
+		serviceName = "dockerd"
+	}
+	exporter, err := tracing.NewExporter(conf.Endpoint, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	trace.RegisterExporter(exporter)
+
+	sampler := trace.AlwaysSample()
+	if conf.SamplingRatio != nil {
+		sampler = trace.ProbabilitySampler(*conf.SamplingRatio)
+	}
+	trace.ApplyConfig(trace.Config{DefaultSampler: sampler})
+	logrus.WithField("endpoint", conf.Endpoint).Info("exporting trace spans")
+
+	return func() {
+		trace.UnregisterExporter(exporter)
+		exporter.Close()
+	}, nil
+}
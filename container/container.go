@@ -23,6 +23,7 @@ import (
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/daemon/logger/local"
+	"github.com/docker/docker/daemon/logger/loggerutils/cache"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
@@ -89,10 +90,13 @@ type Container struct {
 	SecretReferences       []*swarmtypes.SecretReference
 	ConfigReferences       []*swarmtypes.ConfigReference
 	// logDriver for closing
-	LogDriver      logger.Logger  `json:"-"`
-	LogCopier      *logger.Copier `json:"-"`
-	restartManager restartmanager.RestartManager
-	attachContext  *attachContext
+	LogDriver logger.Logger  `json:"-"`
+	LogCopier *logger.Copier `json:"-"`
+	// LogDropsHandler is called when the buffer of the logs in the
+	// non-blocking mode keeps dropping messages
+	LogDropsHandler func(dropped uint64) `json:"-"`
+	restartManager  restartmanager.RestartManager
+	attachContext   *attachContext
 
 	// Fields here are specific to Unix platforms
 	AppArmorProfile string
@@ -358,10 +362,6 @@ func (container *Container) CheckpointDir() string {
 // StartLogger starts a new logger driver for the container.
 func (container *Container) StartLogger() (logger.Logger, error) {
 	cfg := container.HostConfig.LogConfig
-	initDriver, err := logger.GetLogDriver(cfg.Type)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get logging factory")
-	}
 	info := logger.Info{
 		Config:              cfg.Config,
 		ContainerID:         container.ID,
@@ -376,9 +376,84 @@ func (container *Container) StartLogger() (logger.Logger, error) {
 		DaemonName:          "docker",
 	}
 
+	l, err := container.newLogDriver(cfg.Type, &info)
+	if err != nil {
+		return nil, err
+	}
+	driver := l
+
+	// the logs are also sent to the tee'd drivers, with their own options
+	if drivers := logger.TeeDrivers(cfg.Config); len(drivers) > 0 && cfg.Type != "none" {
+		loggers := []logger.Logger{l}
+		for _, driver := range drivers {
+			teeInfo := info
+			teeInfo.Config = logger.TeeConfig(cfg.Config, driver)
+			teeInfo.LogPath = ""
+			tl, err := container.newLogDriver(driver, &teeInfo)
+			if err != nil {
+				for _, l := range loggers {
+					l.Close()
+				}
+				return nil, errors.Wrapf(err, "failed to start the tee'd log driver %s", driver)
+			}
+			loggers = append(loggers, tl)
+		}
+		l = logger.NewTeeLogger(loggers...)
+	}
+
+	// the logs of the drivers which cannot read them back are cached
+	// locally, for them to be read
+	if _, ok := l.(logger.LogReader); !ok && cfg.Type != "none" && cache.ShouldUseCache(cfg.Config) {
+		if info.LogPath, err = container.LogCachePath(); err != nil {
+			l.Close()
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(info.LogPath), 0700); err != nil {
+			l.Close()
+			return nil, errdefs.System(errors.Wrap(err, "error creating local logs dir"))
+		}
+		cl, err := cache.WithLocalCache(l, info)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l = cl
+	}
+
+	// the writes of the messages are recorded for the health of the logging
+	// pipeline
+	l = logger.WithHealth(l, driver)
+
+	if containertypes.LogMode(cfg.Config["mode"]) == containertypes.LogModeNonBlock {
+		ringCfg := logger.RingConfig{MaxSize: -1, OnSustainedDrops: container.LogDropsHandler}
+		if s, exists := cfg.Config["max-buffer-size"]; exists {
+			ringCfg.MaxSize, err = units.RAMInBytes(s)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if s, exists := cfg.Config["adaptive-max-buffer-size"]; exists {
+			ringCfg.AdaptiveMaxSize, err = units.RAMInBytes(s)
+			if err != nil {
+				return nil, err
+			}
+		}
+		l = logger.NewRingLoggerWithConfig(l, info, ringCfg)
+	}
+	return l, nil
+}
+
+// newLogDriver starts the log driver with info, setting the path of the log
+// file of the drivers writing the logs locally.
+func (container *Container) newLogDriver(driver string, info *logger.Info) (logger.Logger, error) {
+	initDriver, err := logger.GetLogDriver(driver)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get logging factory")
+	}
+
 	// Set logging file for "json-logger"
 	// TODO(@cpuguy83): Setup here based on log driver is a little weird.
-	switch cfg.Type {
+	switch driver {
 	case jsonfilelog.Name:
 		info.LogPath, err = container.GetRootResourcePath(fmt.Sprintf("%s-json.log", container.ID))
 		if err != nil {
@@ -400,22 +475,39 @@ func (container *Container) StartLogger() (logger.Logger, error) {
 		info.LogPath = filepath.Join(logDir, "container.log")
 	}
 
-	l, err := initDriver(info)
+	return initDriver(*info)
+}
+
+// LogCachePath returns the path of the local cache of the logs of the
+// container, used when its log driver cannot read them back.
+func (container *Container) LogCachePath() (string, error) {
+	return container.GetRootResourcePath(filepath.Join("local-logs", "container-cached.log"))
+}
+
+// OpenLogCache returns a logger reading the local cache of the logs of the
+// container, without starting its log driver, or nil when the logs are not
+// cached.
+func (container *Container) OpenLogCache() (logger.Logger, error) {
+	cfg := container.HostConfig.LogConfig
+	if cfg.Type == "none" || !cache.ShouldUseCache(cfg.Config) {
+		return nil, nil
+	}
+	logPath, err := container.LogCachePath()
 	if err != nil {
 		return nil, err
 	}
-
-	if containertypes.LogMode(cfg.Config["mode"]) == containertypes.LogModeNonBlock {
-		bufferSize := int64(-1)
-		if s, exists := cfg.Config["max-buffer-size"]; exists {
-			bufferSize, err = units.RAMInBytes(s)
-			if err != nil {
-				return nil, err
-			}
+	if _, err := os.Stat(logPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
-		l = logger.NewRingLogger(l, info, bufferSize)
+		return nil, errdefs.System(err)
 	}
-	return l, nil
+	return cache.NewReader(logger.Info{
+		Config:        cfg.Config,
+		ContainerID:   container.ID,
+		ContainerName: container.Name,
+		LogPath:       logPath,
+	})
 }
 
 // GetProcessLabel returns the process label for the container.
@@ -616,7 +708,21 @@ func (container *Container) startLogging() error {
 		return fmt.Errorf("failed to initialize logging driver: %v", err)
 	}
 
+	limiter, err := logger.NewLimiter(container.HostConfig.LogConfig.Config)
+	if err != nil {
+		l.Close()
+		return errdefs.InvalidParameter(err)
+	}
+
+	multiline, err := logger.NewMultiline(container.HostConfig.LogConfig.Config)
+	if err != nil {
+		l.Close()
+		return errdefs.InvalidParameter(err)
+	}
+
 	copier := logger.NewCopier(map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
+	copier.SetLimiter(limiter)
+	copier.SetMultiline(multiline)
 	container.LogCopier = copier
 	copier.Run()
 	container.LogDriver = l
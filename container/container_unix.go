@@ -9,6 +9,7 @@ import (
 
 	"github.com/containerd/continuity/fs"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/blkiodev"
 	containertypes "github.com/docker/docker/api/types/container"
 	mounttypes "github.com/docker/docker/api/types/mount"
 	swarmtypes "github.com/docker/docker/api/types/swarm"
@@ -302,6 +303,18 @@ func (container *Container) UpdateContainer(hostConfig *containertypes.HostConfi
 	if resources.BlkioWeight != 0 {
 		cResources.BlkioWeight = resources.BlkioWeight
 	}
+	if len(resources.BlkioDeviceReadBps) != 0 {
+		cResources.BlkioDeviceReadBps = mergeThrottleDevices(cResources.BlkioDeviceReadBps, resources.BlkioDeviceReadBps)
+	}
+	if len(resources.BlkioDeviceWriteBps) != 0 {
+		cResources.BlkioDeviceWriteBps = mergeThrottleDevices(cResources.BlkioDeviceWriteBps, resources.BlkioDeviceWriteBps)
+	}
+	if len(resources.BlkioDeviceReadIOps) != 0 {
+		cResources.BlkioDeviceReadIOps = mergeThrottleDevices(cResources.BlkioDeviceReadIOps, resources.BlkioDeviceReadIOps)
+	}
+	if len(resources.BlkioDeviceWriteIOps) != 0 {
+		cResources.BlkioDeviceWriteIOps = mergeThrottleDevices(cResources.BlkioDeviceWriteIOps, resources.BlkioDeviceWriteIOps)
+	}
 	if resources.CPUShares != 0 {
 		cResources.CPUShares = resources.CPUShares
 	}
@@ -355,6 +368,37 @@ func (container *Container) UpdateContainer(hostConfig *containertypes.HostConfi
 	return nil
 }
 
+// mergeThrottleDevices returns the throttle devices in current, updated with
+// the rates of the devices in update. A rate of 0 removes the limit set on
+// the device.
+func mergeThrottleDevices(current, update []*blkiodev.ThrottleDevice) []*blkiodev.ThrottleDevice {
+	merged := make([]*blkiodev.ThrottleDevice, 0, len(current)+len(update))
+	for _, d := range current {
+		merged = append(merged, &blkiodev.ThrottleDevice{Path: d.Path, Rate: d.Rate})
+	}
+	for _, u := range update {
+		found := false
+		for i, d := range merged {
+			if d.Path == u.Path {
+				merged[i].Rate = u.Rate
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, &blkiodev.ThrottleDevice{Path: u.Path, Rate: u.Rate})
+		}
+	}
+
+	result := merged[:0]
+	for _, d := range merged {
+		if d.Rate != 0 {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
 // DetachAndUnmount uses a detached mount on all mount destinations, then
 // unmounts each volume normally.
 // This is used from daemon/archive for `docker cp`
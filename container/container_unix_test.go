@@ -0,0 +1,31 @@
+// +build !windows
+
+package container // import "github.com/docker/docker/container"
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/blkiodev"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestMergeThrottleDevices(t *testing.T) {
+	current := []*blkiodev.ThrottleDevice{
+		{Path: "/dev/sda", Rate: 1024},
+		{Path: "/dev/sdb", Rate: 2048},
+	}
+	update := []*blkiodev.ThrottleDevice{
+		{Path: "/dev/sda", Rate: 4096},
+		{Path: "/dev/sdb", Rate: 0},
+		{Path: "/dev/sdc", Rate: 512},
+	}
+
+	merged := mergeThrottleDevices(current, update)
+	assert.Check(t, is.DeepEqual(merged, []*blkiodev.ThrottleDevice{
+		{Path: "/dev/sda", Rate: 4096},
+		{Path: "/dev/sdc", Rate: 512},
+	}))
+	// The current devices are left untouched
+	assert.Check(t, is.Equal(current[0].Rate, uint64(1024)))
+}
@@ -288,6 +288,11 @@ if ! is_set EXT4_FS || ! is_set EXT4_FS_POSIX_ACL || ! is_set EXT4_FS_SECURITY;
 fi
 
 echo '- Network Drivers:'
+echo '  - "'$(wrap_color 'erofs' blue)'":'
+check_flags EROFS_FS EROFS_FS_XATTR OVERLAY_FS | sed 's/^/    /'
+[ "$EXITCODE" = 0 ] && STORAGE=0
+EXITCODE=0
+
 echo '  - "'$(wrap_color 'overlay' blue)'":'
 check_flags VXLAN | sed 's/^/    /'
 echo '      Optional (for encrypted networks):'
@@ -0,0 +1,104 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"math"
+	"net"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/libnetwork"
+	"github.com/docker/docker/libnetwork/datastore"
+	"github.com/docker/docker/libnetwork/ipamutils"
+	"github.com/sirupsen/logrus"
+)
+
+// AddressPools returns the utilization of the default address pools, which
+// the subnets of the local and swarm scoped networks created without subnet
+// are allocated from.
+func (daemon *Daemon) AddressPools() []types.AddressPool {
+	local := make(map[string][]*net.IPNet)
+	swarm := make(map[string][]*net.IPNet)
+	if daemon.netController != nil {
+		for _, n := range daemon.netController.Networks() {
+			if n.Info().ConfigOnly() {
+				continue
+			}
+			if n.Info().Scope() == datastore.SwarmScope {
+				swarm[n.Name()] = networkSubnets(n)
+			} else {
+				local[n.Name()] = networkSubnets(n)
+			}
+		}
+	}
+	if daemon.cluster != nil && daemon.cluster.IsManager() {
+		// the manager knows all the swarm scoped networks, not only the ones
+		// attached to this node
+		nws, err := daemon.cluster.GetNetworks(filters.NewArgs())
+		if err != nil {
+			logrus.WithError(err).Warn("failed to get the swarm networks")
+		}
+		for _, nw := range nws {
+			var subnets []*net.IPNet
+			for _, c := range nw.IPAM.Config {
+				if _, subnet, err := net.ParseCIDR(c.Subnet); err == nil {
+					subnets = append(subnets, subnet)
+				}
+			}
+			swarm[nw.Name] = subnets
+		}
+	}
+
+	pools := addressPoolUsage(ipamutils.GetLocalScopeDefaultPools(), "local", local)
+	return append(pools, addressPoolUsage(ipamutils.GetGlobalScopeDefaultPools(), "swarm", swarm)...)
+}
+
+func networkSubnets(n libnetwork.Network) []*net.IPNet {
+	var subnets []*net.IPNet
+	v4, v6 := n.Info().IpamInfo()
+	for _, info := range append(v4, v6...) {
+		if info.Pool != nil {
+			subnets = append(subnets, info.Pool)
+		}
+	}
+	return subnets
+}
+
+// addressPoolUsage returns the utilization of the pools by the subnets of the
+// networks, by network name.
+func addressPoolUsage(pools []*ipamutils.NetworkToSplit, scope string, networks map[string][]*net.IPNet) []types.AddressPool {
+	var usage []types.AddressPool
+	for _, p := range pools {
+		_, base, err := net.ParseCIDR(p.Base)
+		if err != nil {
+			continue
+		}
+		ones, _ := base.Mask.Size()
+		u := types.AddressPool{
+			Base:     p.Base,
+			Size:     p.Size,
+			Driver:   p.Driver,
+			Scope:    scope,
+			Subnets:  math.MaxInt32,
+			Networks: []string{},
+		}
+		if p.Size-ones < 31 {
+			u.Subnets = 1 << uint(p.Size-ones)
+		}
+		for name, subnets := range networks {
+			inPool := false
+			for _, subnet := range subnets {
+				if subnetOnes, _ := subnet.Mask.Size(); subnetOnes >= ones && base.Contains(subnet.IP) {
+					u.Allocated++
+					inPool = true
+				}
+			}
+			if inPool {
+				u.Networks = append(u.Networks, name)
+			}
+		}
+		sort.Strings(u.Networks)
+		usage = append(usage, u)
+	}
+	return usage
+}
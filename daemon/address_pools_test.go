@@ -0,0 +1,35 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"net"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/libnetwork/ipamutils"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestAddressPoolUsage(t *testing.T) {
+	subnet := func(s string) *net.IPNet {
+		_, n, err := net.ParseCIDR(s)
+		assert.NilError(t, err)
+		return n
+	}
+	pools := []*ipamutils.NetworkToSplit{
+		{Base: "172.80.0.0/16", Size: 24, Driver: "bridge"},
+		{Base: "10.10.0.0/16", Size: 20},
+	}
+	networks := map[string][]*net.IPNet{
+		"front":  {subnet("172.80.1.0/24")},
+		"back":   {subnet("172.80.2.0/24"), subnet("fd00::/64")},
+		"custom": {subnet("192.168.5.0/24")},
+		"wide":   {subnet("172.0.0.0/8")},
+	}
+
+	usage := addressPoolUsage(pools, "local", networks)
+	assert.Check(t, is.DeepEqual(usage, []types.AddressPool{
+		{Base: "172.80.0.0/16", Size: 24, Driver: "bridge", Scope: "local", Subnets: 256, Allocated: 2, Networks: []string{"back", "front"}},
+		{Base: "10.10.0.0/16", Size: 20, Scope: "local", Subnets: 16, Networks: []string{}},
+	}))
+}
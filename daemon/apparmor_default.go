@@ -4,9 +4,14 @@ package daemon // import "github.com/docker/docker/daemon"
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 
+	"github.com/docker/docker/container"
 	aaprofile "github.com/docker/docker/profiles/apparmor"
 	"github.com/opencontainers/runc/libcontainer/apparmor"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 )
 
 // Define constants for native driver
@@ -34,3 +39,59 @@ func ensureDefaultAppArmorProfile() error {
 
 	return nil
 }
+
+// setupAppArmorTemplate parses the template the AppArmor profiles of the
+// containers are rendered from, if any.
+func (daemon *Daemon) setupAppArmorTemplate() error {
+	path := daemon.configStore.AppArmorTemplate
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("opening AppArmor template (%s) failed: %v", path, err)
+	}
+	tmpl, err := aaprofile.ParseTemplate(filepath.Base(path), string(b))
+	if err != nil {
+		return fmt.Errorf("parsing AppArmor template (%s) failed: %v", path, err)
+	}
+	daemon.apparmorTemplate = tmpl
+	return nil
+}
+
+// installContainerAppArmorProfile renders the AppArmor profile of a container
+// from the template of the daemon, with the mounts and the capabilities of its
+// spec, and loads it.
+func (daemon *Daemon) installContainerAppArmorProfile(c *container.Container, s *specs.Spec) error {
+	p := aaprofile.ContainerProfile{
+		Name:        c.AppArmorProfile,
+		NetworkMode: string(c.HostConfig.NetworkMode),
+	}
+	if s.Process.Capabilities != nil {
+		p.Capabilities = s.Process.Capabilities.Bounding
+	}
+	for _, m := range s.Mounts {
+		mount := aaprofile.Mount{Source: m.Source, Destination: m.Destination, Type: m.Type}
+		for _, o := range m.Options {
+			if o == "ro" {
+				mount.ReadOnly = true
+			}
+		}
+		p.Mounts = append(p.Mounts, mount)
+	}
+	if err := aaprofile.InstallContainer(daemon.apparmorTemplate, p); err != nil {
+		return fmt.Errorf("AppArmor enabled on system but the %s profile could not be loaded: %s", p.Name, err)
+	}
+	return nil
+}
+
+// removeContainerAppArmorProfile unloads the AppArmor profile rendered for a
+// container, if any.
+func (daemon *Daemon) removeContainerAppArmorProfile(c *container.Container) {
+	if daemon.apparmorTemplate == nil || c.AppArmorProfile != containerAppArmorProfile(c) || !apparmor.IsEnabled() {
+		return
+	}
+	if err := aaprofile.Remove(c.AppArmorProfile); err != nil {
+		logrus.WithError(err).WithField("container", c.ID).Warn("Error unloading the AppArmor profile of the container")
+	}
+}
@@ -2,6 +2,15 @@
 
 package daemon // import "github.com/docker/docker/daemon"
 
+import "github.com/docker/docker/container"
+
 func ensureDefaultAppArmorProfile() error {
 	return nil
 }
+
+func (daemon *Daemon) setupAppArmorTemplate() error {
+	return nil
+}
+
+func (daemon *Daemon) removeContainerAppArmorProfile(c *container.Container) {
+}
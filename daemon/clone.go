@@ -0,0 +1,239 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	mounttypes "github.com/docker/docker/api/types/mount"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/stringid"
+	volumemounts "github.com/docker/docker/volume/mounts"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ContainerClone creates a new container from the configuration of an
+// existing container. If requested, the content of the writable layer and
+// of the anonymous volumes of the container is copied to the new container.
+func (daemon *Daemon) ContainerClone(name string, config *types.ContainerCloneConfig) (containertypes.ContainerCreateCreatedBody, error) {
+	start := time.Now()
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return containertypes.ContainerCreateCreatedBody{}, err
+	}
+
+	if ctr.IsDead() {
+		err := fmt.Errorf("You cannot clone container %s which is Dead", ctr.ID)
+		return containertypes.ContainerCreateCreatedBody{}, errdefs.Conflict(err)
+	}
+
+	if ctr.IsRemovalInProgress() {
+		err := fmt.Errorf("You cannot clone container %s which is being removed", ctr.ID)
+		return containertypes.ContainerCreateCreatedBody{}, errdefs.Conflict(err)
+	}
+
+	if (config.Layer || config.Volumes) && runtime.GOOS == "windows" {
+		return containertypes.ContainerCreateCreatedBody{}, errdefs.NotImplemented(errors.Errorf("%s does not support cloning the filesystem of a container", runtime.GOOS))
+	}
+
+	ctr.Lock()
+	params, err := cloneContainerConfig(ctr)
+	ctr.Unlock()
+	if err != nil {
+		return containertypes.ContainerCreateCreatedBody{}, errdefs.System(err)
+	}
+	params.Name = config.Name
+
+	created, err := daemon.containerCreate(context.Background(), params, false)
+	if err != nil {
+		return created, err
+	}
+
+	clone, err := daemon.GetContainer(created.ID)
+	if err != nil {
+		return created, err
+	}
+	if err := daemon.cloneContainerState(ctr, clone, config); err != nil {
+		if err := daemon.cleanupContainer(clone, true, true); err != nil {
+			logrus.WithError(err).WithField("container", clone.ID).Error("failed to cleanup container on clone error")
+		}
+		return containertypes.ContainerCreateCreatedBody{}, err
+	}
+
+	daemon.LogContainerEventWithAttributes(ctr, "clone", map[string]string{
+		"cloneID": clone.ID,
+	})
+	containerActions.WithValues("clone").UpdateSince(start)
+	return created, nil
+}
+
+// cloneContainerState copies the networks, and optionally the writable layer
+// and anonymous volumes, of ctr to the newly created clone.
+func (daemon *Daemon) cloneContainerState(ctr, clone *container.Container, config *types.ContainerCloneConfig) error {
+	if !clone.Config.NetworkDisabled && !clone.HostConfig.NetworkMode.IsContainer() && !clone.HostConfig.NetworkMode.IsGroup() {
+		// Predefined networks can only be set through the network mode,
+		// which is part of the configuration of the clone.
+		endpoints := make(map[string]*networktypes.EndpointSettings)
+		ctr.Lock()
+		if ctr.NetworkSettings != nil {
+			for name, ep := range ctr.NetworkSettings.Networks {
+				if containertypes.NetworkMode(name).IsUserDefined() {
+					endpoints[name] = cloneEndpointSettings(ep.EndpointSettings, stringid.TruncateID(ctr.ID))
+				}
+			}
+		}
+		ctr.Unlock()
+		for name, ep := range endpoints {
+			if err := daemon.ConnectToNetwork(clone, name, ep); err != nil {
+				return errors.Wrapf(err, "failed to connect clone to network %s", name)
+			}
+		}
+	}
+
+	if !config.Layer && !config.Volumes {
+		return nil
+	}
+
+	if config.Pause && ctr.IsRunning() && !ctr.IsPaused() {
+		if err := daemon.containerPause(ctr); err != nil {
+			return errors.Wrap(err, "failed to pause the container")
+		}
+		defer func() {
+			if err := daemon.containerUnpause(ctr); err != nil {
+				logrus.WithError(err).WithField("container", ctr.ID).Error("failed to unpause container after clone")
+			}
+		}()
+	}
+
+	if config.Layer {
+		if err := daemon.cloneRWLayer(ctr, clone); err != nil {
+			return errdefs.System(errors.Wrap(err, "failed to copy the writable layer"))
+		}
+	}
+	if config.Volumes {
+		if err := cloneAnonymousVolumes(ctr, clone); err != nil {
+			return errdefs.System(errors.Wrap(err, "failed to copy anonymous volumes"))
+		}
+	}
+	return nil
+}
+
+// cloneRWLayer applies the changes of the writable layer of ctr on top of the
+// filesystem of clone. Both containers must be based on the same image.
+func (daemon *Daemon) cloneRWLayer(ctr, clone *container.Container) error {
+	changes, err := ctr.RWLayer.TarStream()
+	if err != nil {
+		return err
+	}
+	defer changes.Close()
+
+	if err := daemon.Mount(clone); err != nil {
+		return err
+	}
+	defer daemon.Unmount(clone)
+
+	_, err = chrootarchive.ApplyUncompressedLayer(clone.BaseFS.Path(), changes, &archive.TarOptions{
+		UIDMaps: daemon.idMapping.UIDs(),
+		GIDMaps: daemon.idMapping.GIDs(),
+	})
+	return err
+}
+
+// cloneAnonymousVolumes copies the content of the anonymous volumes of ctr
+// to the volumes created for the same destinations in clone.
+func cloneAnonymousVolumes(ctr, clone *container.Container) error {
+	for dest, m := range ctr.MountPoints {
+		if m.Type != mounttypes.TypeVolume || m.Volume == nil || m.Spec.Source != "" {
+			continue
+		}
+		cm, ok := clone.MountPoints[dest]
+		if !ok || cm.Volume == nil || cm.Volume.Name() == m.Volume.Name() {
+			continue
+		}
+		if err := copyVolume(m, cm); err != nil {
+			return errors.Wrapf(err, "failed to copy volume %s to %s", m.Volume.Name(), cm.Volume.Name())
+		}
+	}
+	return nil
+}
+
+func copyVolume(src, dst *volumemounts.MountPoint) error {
+	id := stringid.GenerateNonCryptoID()
+	srcPath, err := src.Volume.Mount(id)
+	if err != nil {
+		return err
+	}
+	defer src.Volume.Unmount(id)
+
+	dstPath, err := dst.Volume.Mount(id)
+	if err != nil {
+		return err
+	}
+	defer dst.Volume.Unmount(id)
+
+	return fs.CopyDir(dstPath, srcPath)
+}
+
+// cloneContainerConfig returns the parameters to create a new container with
+// the same configuration as ctr. The clone uses the exact image of ctr, and
+// settings which must be unique to a container are reset. The caller must
+// hold the lock on ctr.
+func cloneContainerConfig(ctr *container.Container) (types.ContainerCreateConfig, error) {
+	var params types.ContainerCreateConfig
+	if err := deepCopy(&params.Config, ctr.Config); err != nil {
+		return params, err
+	}
+	if err := deepCopy(&params.HostConfig, ctr.HostConfig); err != nil {
+		return params, err
+	}
+
+	params.Config.Image = ctr.ImageID.String()
+	if params.Config.Hostname == stringid.TruncateID(ctr.ID) {
+		params.Config.Hostname = ""
+	}
+	params.Config.MacAddress = ""
+	return params, nil
+}
+
+// cloneEndpointSettings returns the user-provided settings of an endpoint,
+// excluding the static addresses which cannot be shared with the clone.
+func cloneEndpointSettings(ep *networktypes.EndpointSettings, shortID string) *networktypes.EndpointSettings {
+	settings := &networktypes.EndpointSettings{}
+	if ep == nil {
+		return settings
+	}
+	for _, alias := range ep.Aliases {
+		// The short ID of the container is added as an alias by the daemon
+		if alias != shortID {
+			settings.Aliases = append(settings.Aliases, alias)
+		}
+	}
+	if len(ep.Links) > 0 {
+		settings.Links = append([]string(nil), ep.Links...)
+	}
+	if len(ep.DriverOpts) > 0 {
+		settings.DriverOpts = make(map[string]string, len(ep.DriverOpts))
+		for k, v := range ep.DriverOpts {
+			settings.DriverOpts[k] = v
+		}
+	}
+	return settings
+}
+
+func deepCopy(dst, src interface{}) error {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
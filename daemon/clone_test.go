@@ -0,0 +1,73 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/image"
+	"github.com/docker/go-connections/nat"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestCloneContainerConfig(t *testing.T) {
+	ctr := &container.Container{
+		ID:      "0123456789abcdef0123456789abcdef",
+		ImageID: image.ID("sha256:abcdef"),
+		Config: &containertypes.Config{
+			Hostname:     "0123456789ab",
+			Image:        "busybox:latest",
+			MacAddress:   "02:42:ac:11:00:02",
+			Env:          []string{"FOO=bar"},
+			ExposedPorts: nat.PortSet{"80/tcp": struct{}{}},
+		},
+		HostConfig: &containertypes.HostConfig{
+			Binds:       []string{"data:/data"},
+			NetworkMode: "mynet",
+		},
+	}
+
+	params, err := cloneContainerConfig(ctr)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(params.Config.Image, "sha256:abcdef"))
+	assert.Check(t, is.Equal(params.Config.Hostname, ""))
+	assert.Check(t, is.Equal(params.Config.MacAddress, ""))
+	assert.Check(t, is.DeepEqual(params.Config.Env, []string{"FOO=bar"}))
+	assert.Check(t, is.Len(params.Config.ExposedPorts, 1))
+	assert.Check(t, is.DeepEqual(params.HostConfig.Binds, []string{"data:/data"}))
+	assert.Check(t, is.Equal(params.HostConfig.NetworkMode, containertypes.NetworkMode("mynet")))
+
+	// The configuration of the clone must not share state with the container
+	params.Config.Env[0] = "FOO=baz"
+	params.HostConfig.Binds[0] = "other:/data"
+	assert.Check(t, is.Equal(ctr.Config.Env[0], "FOO=bar"))
+	assert.Check(t, is.Equal(ctr.HostConfig.Binds[0], "data:/data"))
+	assert.Check(t, is.Equal(ctr.Config.Image, "busybox:latest"))
+
+	// Custom hostnames are kept
+	ctr.Config.Hostname = "web"
+	params, err = cloneContainerConfig(ctr)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(params.Config.Hostname, "web"))
+}
+
+func TestCloneEndpointSettings(t *testing.T) {
+	ep := &networktypes.EndpointSettings{
+		IPAMConfig: &networktypes.EndpointIPAMConfig{IPv4Address: "172.20.0.10"},
+		Aliases:    []string{"web", "0123456789ab"},
+		Links:      []string{"db:db"},
+		DriverOpts: map[string]string{"opt": "value"},
+		IPAddress:  "172.20.0.10",
+		EndpointID: "endpoint",
+	}
+
+	settings := cloneEndpointSettings(ep, "0123456789ab")
+	assert.Check(t, is.DeepEqual(settings, &networktypes.EndpointSettings{
+		Aliases:    []string{"web"},
+		Links:      []string{"db:db"},
+		DriverOpts: map[string]string{"opt": "value"},
+	}))
+	assert.Check(t, is.DeepEqual(cloneEndpointSettings(nil, "0123456789ab"), &networktypes.EndpointSettings{}))
+}
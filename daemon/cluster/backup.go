@@ -0,0 +1,219 @@
+package cluster // import "github.com/docker/docker/daemon/cluster"
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	types "github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+	swarmapi "github.com/docker/swarmkit/api"
+	"github.com/docker/swarmkit/manager/encryption"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// raftDirName is the directory of the raft store in the state of a manager.
+const raftDirName = "raft"
+
+// Backup returns an archive of the swarm state of the manager, consistent
+// as the manager is stopped while its state is archived, and restarted
+// afterwards.
+func (c *Cluster) Backup() (io.ReadCloser, error) {
+	c.controlMutex.Lock()
+	defer c.controlMutex.Unlock()
+
+	c.mu.RLock()
+	state := c.currentNodeState()
+	if !state.IsActiveManager() {
+		c.mu.RUnlock()
+		return nil, c.errNoManager(state)
+	}
+	nr := c.nr
+	// the manager is restarted with the unlock key of the swarm, not to be
+	// locked after the backup when the swarm is autolocked
+	ctx, cancel := c.getRequestContext()
+	resp, err := swarmapi.NewCAClient(state.grpcConn).GetUnlockKey(ctx, &swarmapi.GetUnlockKeyRequest{})
+	cancel()
+	c.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	config := nr.config
+	if len(resp.UnlockKey) > 0 {
+		config.lockKey = resp.UnlockKey
+	}
+
+	active, reachable, unreachable, err := managerStats(state.controlClient, state.NodeID())
+	if err == nil && active && !isLastManager(reachable, unreachable) && removingManagerCausesLossOfQuorum(reachable, unreachable) {
+		return nil, errors.WithStack(notAvailableError(fmt.Sprintf("Stopping this manager for the backup leaves %v managers out of %v. Without a Raft quorum your swarm will be inaccessible. Please run the backup once the other managers are reachable.", reachable-1, reachable+unreachable)))
+	}
+
+	if err := nr.Stop(); err != nil {
+		return nil, err
+	}
+	backup, archiveErr := archiveState(c.root)
+
+	nr, err = c.newNodeRunner(config)
+	if err == nil {
+		c.mu.Lock()
+		c.nr = nr
+		c.mu.Unlock()
+		err = <-nr.Ready()
+	}
+	if err != nil {
+		if backup != nil {
+			backup.Close()
+		}
+		return nil, errors.Errorf("swarm component could not be restarted after the backup: %v", err)
+	}
+	if archiveErr != nil {
+		return nil, errors.Wrap(archiveErr, "error archiving the swarm state")
+	}
+	return backup, nil
+}
+
+// Restore initializes a new swarm from the backup of a manager, the node
+// taking over the identity of the manager and forcing a new cluster with
+// its state.
+func (c *Cluster) Restore(req types.RestoreRequest, backup io.Reader) error {
+	c.controlMutex.Lock()
+	defer c.controlMutex.Unlock()
+
+	c.mu.RLock()
+	nr := c.nr
+	c.mu.RUnlock()
+	if nr != nil {
+		return errSwarmExists
+	}
+
+	var lockKey []byte
+	if req.UnlockKey != "" {
+		key, err := encryption.ParseHumanReadableKey(req.UnlockKey)
+		if err != nil {
+			return errdefs.InvalidParameter(err)
+		}
+		lockKey = key
+	}
+
+	if err := clearPersistentState(c.root); err != nil {
+		return err
+	}
+	config, err := c.restoreState(req, backup)
+	if err != nil {
+		if err := clearPersistentState(c.root); err != nil {
+			logrus.WithError(err).Error("Error removing the restored swarm state")
+		}
+		return err
+	}
+	config.forceNewCluster = true
+	config.lockKey = lockKey
+
+	nr, err = c.newNodeRunner(*config)
+	if err != nil {
+		clearPersistentState(c.root)
+		return err
+	}
+	c.mu.Lock()
+	c.nr = nr
+	c.mu.Unlock()
+
+	if err := <-nr.Ready(); err != nil {
+		if err := nr.Stop(); err != nil {
+			logrus.WithError(err).Error("Error stopping the restored swarm node")
+		}
+		c.mu.Lock()
+		c.nr = nil
+		c.mu.Unlock()
+		clearPersistentState(c.root)
+		if errors.Cause(err) == errSwarmLocked {
+			return invalidUnlockKey{}
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreState extracts the backup of a manager in the swarm state
+// directory, returning its node configuration with the addresses of the
+// request.
+func (c *Cluster) restoreState(req types.RestoreRequest, backup io.Reader) (*nodeStartConfig, error) {
+	if err := archive.Untar(backup, c.root, nil); err != nil {
+		return nil, errdefs.InvalidParameter(errors.Wrap(err, "error extracting the swarm backup"))
+	}
+	config, err := loadPersistentState(c.root)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(errors.Wrap(err, "invalid swarm backup"))
+	}
+	if _, err := os.Stat(filepath.Join(c.root, raftDirName)); err != nil {
+		return nil, errdefs.InvalidParameter(errors.New("invalid swarm backup: the backup is not the backup of a manager"))
+	}
+	config.JoinInProgress = false
+
+	if req.ListenAddr != "" || req.AdvertiseAddr != "" {
+		listenAddr := config.ListenAddr
+		if req.ListenAddr != "" {
+			if listenAddr, err = validateAddr(req.ListenAddr); err != nil {
+				return nil, errdefs.InvalidParameter(fmt.Errorf("invalid ListenAddr %q: %v", req.ListenAddr, err))
+			}
+		}
+		listenHost, listenPort, err := resolveListenAddr(listenAddr)
+		if err != nil {
+			return nil, err
+		}
+		advertiseHost, advertisePort, err := c.resolveAdvertiseAddr(req.AdvertiseAddr, listenPort)
+		if err != nil {
+			return nil, err
+		}
+		localAddr, err := c.resolveLocalAddr(listenHost, advertiseHost)
+		if err != nil {
+			return nil, err
+		}
+		config.LocalAddr = localAddr
+		config.ListenAddr = net.JoinHostPort(listenHost, listenPort)
+		config.AdvertiseAddr = net.JoinHostPort(advertiseHost, advertisePort)
+	}
+	if req.DataPathAddr != "" {
+		dataPathAddr, err := resolveDataPathAddr(req.DataPathAddr)
+		if err != nil {
+			return nil, err
+		}
+		config.DataPathAddr = dataPathAddr
+	}
+	return config, nil
+}
+
+// archiveState archives the swarm state directory in a temporary file,
+// removed once the archive is closed.
+func archiveState(root string) (io.ReadCloser, error) {
+	rdr, err := archive.TarWithOptions(root, &archive.TarOptions{
+		ExcludePatterns: []string{controlSocket},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+
+	f, err := ioutil.TempFile("", "swarm-backup")
+	if err != nil {
+		return nil, err
+	}
+	remove := func() error {
+		f.Close()
+		return os.Remove(f.Name())
+	}
+	if _, err := io.Copy(f, rdr); err != nil {
+		remove()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		remove()
+		return nil, err
+	}
+	return ioutils.NewReadCloserWrapper(f, remove), nil
+}
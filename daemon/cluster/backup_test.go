@@ -0,0 +1,69 @@
+package cluster // import "github.com/docker/docker/daemon/cluster"
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	types "github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestBackupRestoreState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swarm-backup")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "manager")
+	for _, d := range []string{"certificates", "raft/wal-v3-encrypted", "worker"} {
+		assert.NilError(t, os.MkdirAll(filepath.Join(root, d), 0700))
+	}
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "certificates/swarm-node.crt"), []byte("cert"), 0600))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "raft/wal-v3-encrypted/0.wal"), []byte("wal"), 0600))
+	assert.NilError(t, savePersistentState(root, nodeStartConfig{
+		LocalAddr:      "10.0.0.1",
+		ListenAddr:     "0.0.0.0:2377",
+		AdvertiseAddr:  "10.0.0.1:2377",
+		JoinInProgress: true,
+	}))
+	// the control socket of the node is not archived
+	l, err := net.Listen("unix", filepath.Join(root, controlSocket))
+	assert.NilError(t, err)
+	defer l.Close()
+
+	backup, err := archiveState(root)
+	assert.NilError(t, err)
+	b, err := ioutil.ReadAll(backup)
+	assert.NilError(t, err)
+	assert.NilError(t, backup.Close())
+
+	c := &Cluster{root: filepath.Join(dir, "restored")}
+	assert.NilError(t, os.MkdirAll(c.root, 0700))
+	config, err := c.restoreState(types.RestoreRequest{}, bytes.NewReader(b))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(config.LocalAddr, "10.0.0.1"))
+	assert.Check(t, is.Equal(config.ListenAddr, "0.0.0.0:2377"))
+	assert.Check(t, is.Equal(config.AdvertiseAddr, "10.0.0.1:2377"))
+	assert.Check(t, !config.JoinInProgress)
+	wal, err := ioutil.ReadFile(filepath.Join(c.root, "raft/wal-v3-encrypted/0.wal"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(wal), "wal"))
+	_, err = os.Stat(filepath.Join(c.root, controlSocket))
+	assert.Check(t, os.IsNotExist(err))
+
+	// the backups of workers can not be restored
+	assert.NilError(t, os.RemoveAll(filepath.Join(root, "raft")))
+	assert.NilError(t, l.Close())
+	backup, err = archiveState(root)
+	assert.NilError(t, err)
+	defer backup.Close()
+	assert.NilError(t, clearPersistentState(c.root))
+	_, err = c.restoreState(types.RestoreRequest{}, backup)
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+	assert.Check(t, is.ErrorContains(err, "not the backup of a manager"))
+}
@@ -1,10 +1,13 @@
 package container // import "github.com/docker/docker/daemon/cluster/executor/container"
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/gpu"
 	swarmapi "github.com/docker/swarmkit/api"
+	"github.com/docker/swarmkit/api/genericresource"
 	"gotest.tools/assert"
 )
 
@@ -80,3 +83,39 @@ func TestContainerLabels(t *testing.T) {
 	labels := c.labels()
 	assert.DeepEqual(t, expected, labels)
 }
+
+func TestContainerGPUs(t *testing.T) {
+	defer func(discover func() ([]gpu.Device, error)) { discoverGPUs = discover }(discoverGPUs)
+	discoverGPUs = func() ([]gpu.Device, error) {
+		return []gpu.Device{
+			{ID: "GPU-0", Vendor: gpu.VendorNVIDIA, Paths: []string{"/dev/nvidia0", "/dev/nvidiactl"}},
+			{ID: "GPU-1", Vendor: gpu.VendorNVIDIA, Paths: []string{"/dev/nvidia1", "/dev/nvidiactl"}},
+			{ID: "0000:03:00.0", Vendor: gpu.VendorAMD, Paths: []string{"/dev/dri/renderD128"}},
+		}, nil
+	}
+	c := &containerConfig{
+		task: &swarmapi.Task{
+			Spec: swarmapi.TaskSpec{
+				Runtime: &swarmapi.TaskSpec_Container{
+					Container: &swarmapi.ContainerSpec{Image: "alpine:latest", Env: []string{"FOO=bar"}},
+				},
+			},
+			AssignedGenericResources: []*swarmapi.GenericResource{
+				genericresource.NewString(gpu.Kind, "GPU-1"),
+				genericresource.NewString(gpu.Kind, "0000:03:00.0"),
+				genericresource.NewDiscrete("ssd", 1),
+			},
+		},
+	}
+
+	// the environment variables of the generic resources are in no
+	// particular order
+	env := c.config().Env
+	sort.Strings(env)
+	assert.DeepEqual(t, []string{"DOCKER_RESOURCE_GPU=GPU-1,0000:03:00.0", "DOCKER_RESOURCE_SSD=1", "FOO=bar", "NVIDIA_VISIBLE_DEVICES=GPU-1"}, env)
+	assert.DeepEqual(t, []container.DeviceMapping{
+		{PathOnHost: "/dev/dri/renderD128", PathInContainer: "/dev/dri/renderD128", CgroupPermissions: "rwm"},
+		{PathOnHost: "/dev/nvidia1", PathInContainer: "/dev/nvidia1", CgroupPermissions: "rwm"},
+		{PathOnHost: "/dev/nvidiactl", PathInContainer: "/dev/nvidiactl", CgroupPermissions: "rwm"},
+	}, c.hostConfig().Devices)
+}
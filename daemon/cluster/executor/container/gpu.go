@@ -0,0 +1,60 @@
+package container // import "github.com/docker/docker/daemon/cluster/executor/container"
+
+import (
+	enginecontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/gpu"
+	"github.com/sirupsen/logrus"
+)
+
+// nvidiaVisibleDevices is the environment variable selecting the GPUs the
+// NVIDIA container runtime exposes to a container, for it to also mount the
+// user-space driver of the host.
+const nvidiaVisibleDevices = "NVIDIA_VISIBLE_DEVICES"
+
+// discoverGPUs is the GPU discovery of the host, replaced in the tests.
+var discoverGPUs = gpu.Discover
+
+// gpus returns the GPUs of the host assigned to the task by the scheduler.
+func (c *containerConfig) gpus() []gpu.Device {
+	var ids []string
+	for _, r := range c.task.AssignedGenericResources {
+		if spec := r.GetNamedResourceSpec(); spec != nil && spec.Kind == gpu.Kind {
+			ids = append(ids, spec.Value)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	devices, err := discoverGPUs()
+	if err != nil {
+		logrus.WithError(err).WithField("task.id", c.task.ID).Error("Error discovering the GPUs of the host")
+		return nil
+	}
+	assigned := gpu.Lookup(devices, ids)
+	if len(assigned) != len(ids) {
+		logrus.WithField("task.id", c.task.ID).Warnf("%d of the %d GPUs assigned to the task were not found on the host", len(ids)-len(assigned), len(ids))
+	}
+	return assigned
+}
+
+// gpuEnv returns the environment of the container selecting the NVIDIA GPUs
+// assigned to the task.
+func gpuEnv(gpus []gpu.Device) []string {
+	if ids := gpu.IDs(gpus, gpu.VendorNVIDIA); ids != "" {
+		return []string{nvidiaVisibleDevices + "=" + ids}
+	}
+	return nil
+}
+
+// gpuDevices returns the device nodes of the GPUs assigned to the task.
+func gpuDevices(gpus []gpu.Device) []enginecontainer.DeviceMapping {
+	var devices []enginecontainer.DeviceMapping
+	for _, p := range gpu.Paths(gpus) {
+		devices = append(devices, enginecontainer.DeviceMapping{
+			PathOnHost:        p,
+			PathInContainer:   p,
+			CgroupPermissions: "rwm",
+		})
+	}
+	return devices
+}
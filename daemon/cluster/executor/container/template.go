@@ -0,0 +1,321 @@
+package container // import "github.com/docker/docker/daemon/cluster/executor/container"
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/docker/swarmkit/agent/configs"
+	"github.com/docker/swarmkit/agent/exec"
+	"github.com/docker/swarmkit/agent/secrets"
+	"github.com/docker/swarmkit/api"
+	swarmtemplate "github.com/docker/swarmkit/template"
+	"github.com/pkg/errors"
+)
+
+// golangTemplating is the name of the templating driver of the configs and
+// secrets whose payloads are Go templates.
+const golangTemplating = "golang"
+
+// maxIncludeDepth is the depth of the includes of the configs above which
+// the expansion of a payload fails, the configs including themselves.
+const maxIncludeDepth = 10
+
+// templateFuncs are the helper functions of the payload templates, in
+// addition to the functions getting the secrets, configs and environment
+// variables of the tasks. The functions taking a string operate on their
+// last argument, for the templates to pipe the values into them.
+var templateFuncs = template.FuncMap{
+	// join is the join function of the swarmkit templates: its first
+	// argument is the separator of the other arguments.
+	"join": func(s ...string) string {
+		return strings.Join(s[1:], s[0])
+	},
+	"default": func(d interface{}, given ...interface{}) interface{} {
+		if len(given) == 0 || isEmpty(given[0]) {
+			return d
+		}
+		return given[0]
+	},
+	"empty": isEmpty,
+	"coalesce": func(values ...interface{}) interface{} {
+		for _, v := range values {
+			if !isEmpty(v) {
+				return v
+			}
+		}
+		return nil
+	},
+	"ternary": func(vt, vf interface{}, cond bool) interface{} {
+		if cond {
+			return vt
+		}
+		return vf
+	},
+	"required": func(msg string, v interface{}) (interface{}, error) {
+		if isEmpty(v) {
+			return nil, errors.New(msg)
+		}
+		return v, nil
+	},
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      strings.Title,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"quote":      func(s string) string { return fmt.Sprintf("%q", s) },
+	"squote":     func(s string) string { return "'" + s + "'" },
+	"indent":     indent,
+	"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+	"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return string(b), err
+	},
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// isEmpty returns whether a value is empty: nil, false, 0, or of length 0.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+// payloadContext is the context the payloads of the templated configs and
+// secrets of a task are expanded with.
+type payloadContext struct {
+	swarmtemplate.Context
+
+	t       *api.Task
+	secrets exec.SecretGetter
+	configs exec.ConfigGetter
+	// sensitive is whether the payload was expanded with the data of a
+	// secret
+	sensitive bool
+	// depth is the depth of the includes of the payload
+	depth int
+}
+
+func newPayloadContext(node *api.NodeDescription, t *api.Task, dependencies exec.DependencyGetter) *payloadContext {
+	return &payloadContext{
+		Context: swarmtemplate.NewContext(node, t),
+		t:       t,
+		secrets: secrets.Restrict(dependencies.Secrets(), t),
+		configs: configs.Restrict(dependencies.Configs(), t),
+	}
+}
+
+func (ctx *payloadContext) expand(payload []byte) ([]byte, error) {
+	funcs := template.FuncMap{
+		"secret":  ctx.secret,
+		"config":  ctx.config,
+		"include": ctx.include,
+		"env":     ctx.env,
+	}
+	tmpl, err := template.New("expansion").Option("missingkey=error").Funcs(templateFuncs).Funcs(funcs).Parse(string(payload))
+	if err != nil {
+		return payload, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx.Context); err != nil {
+		return payload, err
+	}
+	return buf.Bytes(), nil
+}
+
+// secret returns the data of the secret of the task with the target file.
+func (ctx *payloadContext) secret(target string) (string, error) {
+	if ctx.secrets == nil {
+		return "", errors.New("secrets unavailable")
+	}
+	container := ctx.t.Spec.GetContainer()
+	if container == nil {
+		return "", errors.New("task is not a container")
+	}
+	for _, ref := range container.Secrets {
+		if file := ref.GetFile(); file != nil && file.Name == target {
+			secret, err := ctx.secrets.Get(ref.SecretID)
+			if err != nil {
+				return "", err
+			}
+			ctx.sensitive = true
+			return string(secret.Spec.Data), nil
+		}
+	}
+	return "", errors.Errorf("secret target %s not found", target)
+}
+
+// taskConfig returns the config of the task with the target file.
+func (ctx *payloadContext) taskConfig(target string) (*api.Config, error) {
+	if ctx.configs == nil {
+		return nil, errors.New("configs unavailable")
+	}
+	container := ctx.t.Spec.GetContainer()
+	if container == nil {
+		return nil, errors.New("task is not a container")
+	}
+	for _, ref := range container.Configs {
+		if file := ref.GetFile(); file != nil && file.Name == target {
+			return ctx.configs.Get(ref.ConfigID)
+		}
+	}
+	return nil, errors.Errorf("config target %s not found", target)
+}
+
+// config returns the data of the config of the task with the target file, as
+// is.
+func (ctx *payloadContext) config(target string) (string, error) {
+	config, err := ctx.taskConfig(target)
+	if err != nil {
+		return "", err
+	}
+	return string(config.Spec.Data), nil
+}
+
+// include returns the data of the config of the task with the target file,
+// expanded with the context of the payload when the config is templated.
+func (ctx *payloadContext) include(target string) (string, error) {
+	config, err := ctx.taskConfig(target)
+	if err != nil {
+		return "", err
+	}
+	if config.Spec.Templating == nil {
+		return string(config.Spec.Data), nil
+	}
+	if config.Spec.Templating.Name != golangTemplating {
+		return "", errors.Errorf("cannot include config target %s: unrecognized template type", target)
+	}
+	if ctx.depth >= maxIncludeDepth {
+		return "", errors.Errorf("cannot include config target %s: too many nested includes", target)
+	}
+	included := *ctx
+	included.depth++
+	data, err := included.expand(config.Spec.Data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to expand included config target %s", target)
+	}
+	ctx.sensitive = ctx.sensitive || included.sensitive
+	return string(data), nil
+}
+
+// env returns the value of an environment variable of the task, or an empty
+// string if it is not set.
+func (ctx *payloadContext) env(variable string) (string, error) {
+	container := ctx.t.Spec.GetContainer()
+	if container == nil {
+		return "", errors.New("task is not a container")
+	}
+	for _, env := range container.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) > 1 && parts[0] == variable {
+			return parts[1], nil
+		}
+	}
+	return "", nil
+}
+
+// templatedDependencyGetter is a dependency getter expanding the payloads of
+// the templated configs and secrets of a task. It extends the templates of
+// swarmkit with the include function, expanding the configs the templates
+// include, and with the helper functions of templateFuncs.
+type templatedDependencyGetter struct {
+	dependencies exec.DependencyGetter
+	t            *api.Task
+	node         *api.NodeDescription
+}
+
+func newTemplatedDependencyGetter(dependencies exec.DependencyGetter, t *api.Task, node *api.NodeDescription) exec.DependencyGetter {
+	return &templatedDependencyGetter{dependencies: dependencies, t: t, node: node}
+}
+
+func (g *templatedDependencyGetter) Secrets() exec.SecretGetter {
+	return templatedSecretGetter{g}
+}
+
+func (g *templatedDependencyGetter) Configs() exec.ConfigGetter {
+	return templatedConfigGetter{g}
+}
+
+type templatedSecretGetter struct {
+	*templatedDependencyGetter
+}
+
+func (g templatedSecretGetter) Get(secretID string) (*api.Secret, error) {
+	if g.dependencies == nil || g.dependencies.Secrets() == nil {
+		return nil, errors.New("no secret provider available")
+	}
+	secret, err := g.dependencies.Secrets().Get(secretID)
+	if err != nil || secret.Spec.Templating == nil {
+		return secret, err
+	}
+	if secret.Spec.Templating.Name != golangTemplating {
+		return secret, errors.Errorf("failed to expand templated secret %s: unrecognized template type", secretID)
+	}
+	spec := secret.Spec.Copy()
+	if spec.Data, err = newPayloadContext(g.node, g.t, g.dependencies).expand(spec.Data); err != nil {
+		return secret, errors.Wrapf(err, "failed to expand templated secret %s", secretID)
+	}
+	secretCopy := *secret
+	secretCopy.Spec = *spec
+	return &secretCopy, nil
+}
+
+type templatedConfigGetter struct {
+	*templatedDependencyGetter
+}
+
+func (g templatedConfigGetter) Get(configID string) (*api.Config, error) {
+	config, _, err := g.GetAndFlagSecretData(configID)
+	return config, err
+}
+
+// GetAndFlagSecretData returns the expanded config, and whether it was
+// expanded with the data of a secret, in which case it must not be written
+// to disk.
+func (g templatedConfigGetter) GetAndFlagSecretData(configID string) (*api.Config, bool, error) {
+	if g.dependencies == nil || g.dependencies.Configs() == nil {
+		return nil, false, errors.New("no config provider available")
+	}
+	config, err := g.dependencies.Configs().Get(configID)
+	if err != nil || config.Spec.Templating == nil {
+		return config, false, err
+	}
+	if config.Spec.Templating.Name != golangTemplating {
+		return config, false, errors.Errorf("failed to expand templated config %s: unrecognized template type", configID)
+	}
+	ctx := newPayloadContext(g.node, g.t, g.dependencies)
+	spec := config.Spec.Copy()
+	if spec.Data, err = ctx.expand(spec.Data); err != nil {
+		return config, false, errors.Wrapf(err, "failed to expand templated config %s", configID)
+	}
+	configCopy := *config
+	configCopy.Spec = *spec
+	return &configCopy, ctx.sensitive, nil
+}
@@ -0,0 +1,81 @@
+package container // import "github.com/docker/docker/daemon/cluster/executor/container"
+
+import (
+	"testing"
+
+	"github.com/docker/swarmkit/agent"
+	swarmapi "github.com/docker/swarmkit/api"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func templatedConfig(id, data string) swarmapi.Config {
+	return swarmapi.Config{
+		ID: id,
+		Spec: swarmapi.ConfigSpec{
+			Data:       []byte(data),
+			Templating: &swarmapi.Driver{Name: "golang"},
+		},
+	}
+}
+
+func TestTemplatedDependencies(t *testing.T) {
+	dependencies := agent.NewDependencyManager()
+	dependencies.Configs().Add(
+		swarmapi.Config{ID: "base", Spec: swarmapi.ConfigSpec{Data: []byte("log={{ not expanded }}")}},
+		templatedConfig("partial", `port={{ env "PORT" | default "8080" }} host={{ env "HOST" | default "localhost" }}`),
+		templatedConfig("main", "{{ include \"partial.conf\" }}\n{{ include \"base.conf\" }}\nname={{ .Service.Name | upper | quote }}"),
+		templatedConfig("password", `{{ secret "password" | b64enc }}`),
+		templatedConfig("loop", `{{ include "loop.conf" }}`),
+		templatedConfig("missing", `{{ include "nope.conf" }}`),
+		templatedConfig("required", `{{ env "DB" | required "DB must be set" }}`),
+	)
+	dependencies.Secrets().Add(swarmapi.Secret{ID: "password", Spec: swarmapi.SecretSpec{Data: []byte("s3cr3t")}})
+
+	var configRefs []*swarmapi.ConfigReference
+	for _, id := range []string{"base", "partial", "main", "password", "loop", "missing", "required"} {
+		configRefs = append(configRefs, &swarmapi.ConfigReference{
+			ConfigID: id,
+			Target:   &swarmapi.ConfigReference_File{File: &swarmapi.FileTarget{Name: id + ".conf"}},
+		})
+	}
+	task := &swarmapi.Task{
+		ID:                 "t1",
+		ServiceAnnotations: swarmapi.Annotations{Name: "web"},
+		Spec: swarmapi.TaskSpec{
+			Runtime: &swarmapi.TaskSpec_Container{
+				Container: &swarmapi.ContainerSpec{
+					Env:     []string{"PORT=80"},
+					Configs: configRefs,
+					Secrets: []*swarmapi.SecretReference{{
+						SecretID: "password",
+						Target:   &swarmapi.SecretReference_File{File: &swarmapi.FileTarget{Name: "password"}},
+					}},
+				},
+			},
+		},
+	}
+	getter := newTemplatedDependencyGetter(agent.Restrict(dependencies, task), task, nil)
+	configs := getter.Configs().(templatedConfigGetter)
+
+	config, sensitive, err := configs.GetAndFlagSecretData("main")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(config.Spec.Data), "port=80 host=localhost\nlog={{ not expanded }}\nname=\"WEB\""))
+	assert.Check(t, !sensitive)
+
+	config, sensitive, err = configs.GetAndFlagSecretData("password")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(config.Spec.Data), "czNjcjN0"))
+	assert.Check(t, sensitive)
+
+	_, err = configs.Get("loop")
+	assert.Check(t, is.ErrorContains(err, "too many nested includes"))
+	_, err = configs.Get("missing")
+	assert.Check(t, is.ErrorContains(err, "config target nope.conf not found"))
+	_, err = configs.Get("required")
+	assert.Check(t, is.ErrorContains(err, "DB must be set"))
+
+	secret, err := getter.Secrets().Get("password")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(secret.Spec.Data), "s3cr3t"))
+}
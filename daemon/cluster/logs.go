@@ -0,0 +1,296 @@
+package cluster // import "github.com/docker/docker/daemon/cluster"
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	apitypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/backend"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/docker/docker/errdefs"
+	swarmapi "github.com/docker/swarmkit/api"
+	gogotypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxLogReorder is the maximum time the log lines are held to be
+	// reordered.
+	maxLogReorder = 10 * time.Second
+	// maxPendingLogs is the maximum number of log lines held to be
+	// reordered, above which the oldest lines are sent without waiting.
+	maxPendingLogs = 1024
+	// maxLogResubscriptions is the number of consecutive times a followed
+	// log stream is subscribed again after it failed.
+	maxLogResubscriptions = 5
+)
+
+// pendingLog is a log line held to be reordered.
+type pendingLog struct {
+	msg      *backend.LogMessage
+	received time.Time
+}
+
+// serviceLogStream merges the log streams of the tasks of services, the log
+// lines being numbered by task, and the log lines before the cursors of
+// their tasks being skipped. The lines are reordered by timestamp, within a
+// bounded window, and the followed streams are subscribed again, from the
+// cursors of the tasks, when they fail.
+type serviceLogStream struct {
+	c       *Cluster
+	request swarmapi.SubscribeLogsRequest
+	reorder time.Duration
+	// cursors are the timestamps of the last log lines of the tasks
+	cursors map[string]time.Time
+	seqs    map[string]uint64
+	pending []pendingLog
+}
+
+func newServiceLogStream(c *Cluster, request swarmapi.SubscribeLogsRequest, config *apitypes.ContainerLogsOptions) (*serviceLogStream, error) {
+	s := &serviceLogStream{
+		c:       c,
+		request: request,
+		cursors: make(map[string]time.Time),
+		seqs:    make(map[string]uint64),
+	}
+	for task, cursor := range config.Cursors {
+		sec, nsec, err := timetypes.ParseTimestamps(cursor, 0)
+		if err != nil {
+			return nil, errdefs.InvalidParameter(errors.Wrapf(err, "invalid cursor for task %s", task))
+		}
+		s.cursors[task] = time.Unix(sec, nsec)
+	}
+	if config.Reorder != "" {
+		reorder, err := time.ParseDuration(config.Reorder)
+		if err != nil || reorder < 0 || reorder > maxLogReorder {
+			return nil, errdefs.InvalidParameter(errors.Errorf("invalid reorder window %q: it must be a duration up to %s", config.Reorder, maxLogReorder))
+		}
+		s.reorder = reorder
+	}
+	return s, nil
+}
+
+type subscribeLogsResult struct {
+	msg *swarmapi.SubscribeLogsMessage
+	err error
+}
+
+// receive receives the messages of a log stream until it fails.
+func receive(ctx context.Context, stream swarmapi.Logs_SubscribeLogsClient) <-chan subscribeLogsResult {
+	results := make(chan subscribeLogsResult)
+	go func() {
+		defer close(results)
+		for {
+			msg, err := stream.Recv()
+			select {
+			case <-ctx.Done():
+				return
+			case results <- subscribeLogsResult{msg: msg, err: err}:
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// run sends the log lines of the stream to messages, until the stream ends
+// or the context is canceled.
+func (s *serviceLogStream) run(ctx context.Context, stream swarmapi.Logs_SubscribeLogsClient, messages chan<- *backend.LogMessage) {
+	defer close(messages)
+
+	results := receive(ctx, stream)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case r := <-results:
+			if r.err == io.EOF {
+				s.flush(ctx, messages, true)
+				return
+			}
+			if r.err != nil {
+				if s.request.Options.Follow && status.Code(r.err) == codes.Unavailable && failures < maxLogResubscriptions {
+					failures++
+					logrus.WithError(r.err).Warnf("Service log stream failed, subscribing again (attempt %d)", failures)
+					stream, err := s.resubscribe(ctx, failures)
+					if err == nil {
+						results = receive(ctx, stream)
+						continue
+					}
+					if err != context.Canceled {
+						r.err = err
+					}
+				}
+				s.flush(ctx, messages, true)
+				select {
+				case <-ctx.Done():
+				case messages <- &backend.LogMessage{Err: r.err}:
+				}
+				return
+			}
+			failures = 0
+			for _, msg := range r.msg.Messages {
+				s.add(logMessage(msg))
+			}
+		}
+		if !s.flush(ctx, messages, false) {
+			return
+		}
+		if len(s.pending) > 0 {
+			timer.Reset(time.Until(s.oldestReceived().Add(s.reorder)))
+		}
+	}
+}
+
+// resubscribe subscribes to the logs again, after the failure of the
+// subscription, from the earliest cursor of the tasks.
+func (s *serviceLogStream) resubscribe(ctx context.Context, attempt int) (swarmapi.Logs_SubscribeLogsClient, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Duration(attempt) * time.Second):
+	}
+
+	request := s.request
+	if len(s.cursors) > 0 {
+		var since time.Time
+		for _, cursor := range s.cursors {
+			if since.IsZero() || cursor.Before(since) {
+				since = cursor
+			}
+		}
+		sinceProto, err := gogotypes.TimestampProto(since)
+		if err != nil {
+			return nil, err
+		}
+		options := *request.Options
+		options.Since = sinceProto
+		options.Tail = 0
+		request.Options = &options
+	}
+
+	s.c.mu.RLock()
+	state := s.c.currentNodeState()
+	if !state.IsActiveManager() {
+		s.c.mu.RUnlock()
+		return nil, s.c.errNoManager(state)
+	}
+	logsClient := state.logsClient
+	s.c.mu.RUnlock()
+	return logsClient.SubscribeLogs(ctx, &request)
+}
+
+// add adds a log line to the pending lines, skipping it when it is not after
+// the cursor of its task.
+func (s *serviceLogStream) add(m *backend.LogMessage) {
+	task := logAttr(m, contextPrefix+".task.id")
+	if m.Err == nil {
+		if cursor, ok := s.cursors[task]; ok && !m.Timestamp.After(cursor) {
+			return
+		}
+		s.cursors[task] = m.Timestamp
+	}
+	s.seqs[task]++
+	m.Attrs = append(m.Attrs, backend.LogAttr{Key: contextPrefix + ".task.seq", Value: strconv.FormatUint(s.seqs[task], 10)})
+
+	p := pendingLog{msg: m, received: time.Now()}
+	i := sort.Search(len(s.pending), func(i int) bool {
+		return s.pending[i].msg.Timestamp.After(m.Timestamp)
+	})
+	s.pending = append(s.pending, pendingLog{})
+	copy(s.pending[i+1:], s.pending[i:])
+	s.pending[i] = p
+}
+
+// flush sends the pending log lines in timestamp order while the oldest
+// received line has been held for the reorder window, or all of them. It
+// returns false if the context is canceled.
+func (s *serviceLogStream) flush(ctx context.Context, messages chan<- *backend.LogMessage, all bool) bool {
+	for len(s.pending) > 0 {
+		if !all && len(s.pending) <= maxPendingLogs && time.Since(s.oldestReceived()) < s.reorder {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case messages <- s.pending[0].msg:
+		}
+		s.pending = s.pending[1:]
+	}
+	return true
+}
+
+func (s *serviceLogStream) oldestReceived() time.Time {
+	oldest := s.pending[0].received
+	for _, p := range s.pending[1:] {
+		if p.received.Before(oldest) {
+			oldest = p.received
+		}
+	}
+	return oldest
+}
+
+// logMessage converts a log message of swarmkit to a log message of the
+// backend.
+func logMessage(msg swarmapi.LogMessage) *backend.LogMessage {
+	m := new(backend.LogMessage)
+	m.Attrs = make([]backend.LogAttr, 0, len(msg.Attrs)+4)
+	// add the timestamp, adding the error if it fails
+	var err error
+	m.Timestamp, err = gogotypes.TimestampFromProto(msg.Timestamp)
+	if err != nil {
+		m.Err = err
+	}
+
+	nodeKey := contextPrefix + ".node.id"
+	serviceKey := contextPrefix + ".service.id"
+	taskKey := contextPrefix + ".task.id"
+	seqKey := contextPrefix + ".task.seq"
+
+	// copy over all of the details
+	for _, d := range msg.Attrs {
+		switch d.Key {
+		case nodeKey, serviceKey, taskKey, seqKey:
+			// we have the final say over context details (in case there
+			// is a conflict (if the user added a detail with a context's
+			// key for some reason))
+		default:
+			m.Attrs = append(m.Attrs, backend.LogAttr{Key: d.Key, Value: d.Value})
+		}
+	}
+	m.Attrs = append(m.Attrs,
+		backend.LogAttr{Key: nodeKey, Value: msg.Context.NodeID},
+		backend.LogAttr{Key: serviceKey, Value: msg.Context.ServiceID},
+		backend.LogAttr{Key: taskKey, Value: msg.Context.TaskID},
+	)
+
+	switch msg.Stream {
+	case swarmapi.LogStreamStdout:
+		m.Source = "stdout"
+	case swarmapi.LogStreamStderr:
+		m.Source = "stderr"
+	}
+	m.Line = msg.Data
+	return m
+}
+
+func logAttr(m *backend.LogMessage, key string) string {
+	for _, a := range m.Attrs {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
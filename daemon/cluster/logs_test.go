@@ -0,0 +1,83 @@
+package cluster // import "github.com/docker/docker/daemon/cluster"
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	apitypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/errdefs"
+	swarmapi "github.com/docker/swarmkit/api"
+	gogotypes "github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type fakeLogsStream struct {
+	grpc.ClientStream
+	messages []*swarmapi.SubscribeLogsMessage
+}
+
+func (s *fakeLogsStream) Recv() (*swarmapi.SubscribeLogsMessage, error) {
+	if len(s.messages) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func fakeLogMessage(task string, sec int64, line string) swarmapi.LogMessage {
+	return swarmapi.LogMessage{
+		Context:   swarmapi.LogContext{NodeID: "node", ServiceID: "service", TaskID: task},
+		Timestamp: &gogotypes.Timestamp{Seconds: sec},
+		Stream:    swarmapi.LogStreamStdout,
+		Data:      []byte(line),
+	}
+}
+
+func readServiceLogs(t *testing.T, config *apitypes.ContainerLogsOptions, messages ...*swarmapi.SubscribeLogsMessage) []string {
+	s, err := newServiceLogStream(nil, swarmapi.SubscribeLogsRequest{Options: &swarmapi.LogSubscriptionOptions{}}, config)
+	assert.NilError(t, err)
+	out := make(chan *backend.LogMessage)
+	go s.run(context.Background(), &fakeLogsStream{messages: messages}, out)
+
+	var lines []string
+	for m := range out {
+		assert.NilError(t, m.Err)
+		lines = append(lines, string(m.Line)+" "+logAttr(m, "com.docker.swarm.task.seq"))
+	}
+	return lines
+}
+
+func TestServiceLogStream(t *testing.T) {
+	messages := []*swarmapi.SubscribeLogsMessage{
+		{Messages: []swarmapi.LogMessage{fakeLogMessage("a", 1, "a1"), fakeLogMessage("a", 3, "a3")}},
+		{Messages: []swarmapi.LogMessage{fakeLogMessage("b", 2, "b2")}},
+	}
+
+	// the log lines are numbered by task
+	lines := readServiceLogs(t, &apitypes.ContainerLogsOptions{}, messages...)
+	assert.Check(t, is.DeepEqual(lines, []string{"a1 1", "a3 2", "b2 1"}))
+
+	// the log lines are merged in timestamp order
+	lines = readServiceLogs(t, &apitypes.ContainerLogsOptions{Reorder: "100ms"}, messages...)
+	assert.Check(t, is.DeepEqual(lines, []string{"a1 1", "b2 1", "a3 2"}))
+
+	// the log lines before the cursors of their tasks are skipped
+	lines = readServiceLogs(t, &apitypes.ContainerLogsOptions{Cursors: map[string]string{"a": "1.000000000"}}, messages...)
+	assert.Check(t, is.DeepEqual(lines, []string{"a3 1", "b2 1"}))
+}
+
+func TestServiceLogStreamInvalidOptions(t *testing.T) {
+	for _, config := range []*apitypes.ContainerLogsOptions{
+		{Reorder: "1h"},
+		{Reorder: "soon"},
+		{Cursors: map[string]string{"a": "yesterday"}},
+	} {
+		_, err := newServiceLogStream(nil, swarmapi.SubscribeLogsRequest{}, config)
+		assert.Check(t, errdefs.IsInvalidParameter(err), "%+v", config)
+	}
+}
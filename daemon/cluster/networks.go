@@ -272,6 +272,10 @@ func (c *Cluster) CreateNetwork(s apitypes.NetworkCreateRequest) (string, error)
 		return "", errors.WithStack(err)
 	}
 
+	if s.DNS != nil {
+		return "", errors.WithStack(configError("DNS configuration is not supported for swarm scoped networks"))
+	}
+
 	var resp *swarmapi.CreateNetworkResponse
 	if err := c.lockedManagerAction(func(ctx context.Context, state nodeState) error {
 		networkSpec := convert.BasicNetworkCreateToGRPC(s)
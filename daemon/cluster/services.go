@@ -5,7 +5,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -430,7 +429,7 @@ func (c *Cluster) ServiceLogs(ctx context.Context, selector *backend.LogSelector
 		}
 	}
 
-	stream, err := state.logsClient.SubscribeLogs(ctx, &swarmapi.SubscribeLogsRequest{
+	request := swarmapi.SubscribeLogsRequest{
 		Selector: swarmSelector,
 		Options: &swarmapi.LogSubscriptionOptions{
 			Follow:  config.Follow,
@@ -438,85 +437,19 @@ func (c *Cluster) ServiceLogs(ctx context.Context, selector *backend.LogSelector
 			Tail:    tail,
 			Since:   sinceProto,
 		},
-	})
+	}
+	logStream, err := newServiceLogStream(c, request, config)
 	if err != nil {
 		return nil, err
 	}
 
-	messageChan := make(chan *backend.LogMessage, 1)
-	go func() {
-		defer close(messageChan)
-		for {
-			// Check the context before doing anything.
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-			subscribeMsg, err := stream.Recv()
-			if err == io.EOF {
-				return
-			}
-			// if we're not io.EOF, push the message in and return
-			if err != nil {
-				select {
-				case <-ctx.Done():
-				case messageChan <- &backend.LogMessage{Err: err}:
-				}
-				return
-			}
-
-			for _, msg := range subscribeMsg.Messages {
-				// make a new message
-				m := new(backend.LogMessage)
-				m.Attrs = make([]backend.LogAttr, 0, len(msg.Attrs)+3)
-				// add the timestamp, adding the error if it fails
-				m.Timestamp, err = gogotypes.TimestampFromProto(msg.Timestamp)
-				if err != nil {
-					m.Err = err
-				}
+	stream, err := state.logsClient.SubscribeLogs(ctx, &request)
+	if err != nil {
+		return nil, err
+	}
 
-				nodeKey := contextPrefix + ".node.id"
-				serviceKey := contextPrefix + ".service.id"
-				taskKey := contextPrefix + ".task.id"
-
-				// copy over all of the details
-				for _, d := range msg.Attrs {
-					switch d.Key {
-					case nodeKey, serviceKey, taskKey:
-						// we have the final say over context details (in case there
-						// is a conflict (if the user added a detail with a context's
-						// key for some reason))
-					default:
-						m.Attrs = append(m.Attrs, backend.LogAttr{Key: d.Key, Value: d.Value})
-					}
-				}
-				m.Attrs = append(m.Attrs,
-					backend.LogAttr{Key: nodeKey, Value: msg.Context.NodeID},
-					backend.LogAttr{Key: serviceKey, Value: msg.Context.ServiceID},
-					backend.LogAttr{Key: taskKey, Value: msg.Context.TaskID},
-				)
-
-				switch msg.Stream {
-				case swarmapi.LogStreamStdout:
-					m.Source = "stdout"
-				case swarmapi.LogStreamStderr:
-					m.Source = "stderr"
-				}
-				m.Line = msg.Data
-
-				// there could be a case where the reader stops accepting
-				// messages and the context is canceled. we need to check that
-				// here, or otherwise we risk blocking forever on the message
-				// send.
-				select {
-				case <-ctx.Done():
-					return
-				case messageChan <- m:
-				}
-			}
-		}
-	}()
+	messageChan := make(chan *backend.LogMessage, 1)
+	go logStream.run(ctx, stream, messageChan)
 	return messageChan, nil
 }
 
@@ -63,33 +63,9 @@ func (c *Cluster) Init(req types.InitRequest) (string, error) {
 		return "", err
 	}
 
-	localAddr := listenHost
-
-	// If the local address is undetermined, the advertise address
-	// will be used as local address, if it belongs to this system.
-	// If the advertise address is not local, then we try to find
-	// a system address to use as local address. If this fails,
-	// we give up and ask the user to pass the listen address.
-	if net.ParseIP(localAddr).IsUnspecified() {
-		advertiseIP := net.ParseIP(advertiseHost)
-
-		found := false
-		for _, systemIP := range listSystemIPs() {
-			if systemIP.Equal(advertiseIP) {
-				localAddr = advertiseIP.String()
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			ip, err := c.resolveSystemAddr()
-			if err != nil {
-				logrus.Warnf("Could not find a local address: %v", err)
-				return "", errMustSpecifyListenAddr
-			}
-			localAddr = ip.String()
-		}
+	localAddr, err := c.resolveLocalAddr(listenHost, advertiseHost)
+	if err != nil {
+		return "", err
 	}
 
 	//Validate Default Address Pool input
@@ -498,6 +474,40 @@ func validateAndSanitizeJoinRequest(req *types.JoinRequest) error {
 	return nil
 }
 
+// resolveLocalAddr returns the local address of the node listening on
+// listenHost and advertising advertiseHost.
+func (c *Cluster) resolveLocalAddr(listenHost, advertiseHost string) (string, error) {
+	localAddr := listenHost
+
+	// If the local address is undetermined, the advertise address
+	// will be used as local address, if it belongs to this system.
+	// If the advertise address is not local, then we try to find
+	// a system address to use as local address. If this fails,
+	// we give up and ask the user to pass the listen address.
+	if net.ParseIP(localAddr).IsUnspecified() {
+		advertiseIP := net.ParseIP(advertiseHost)
+
+		found := false
+		for _, systemIP := range listSystemIPs() {
+			if systemIP.Equal(advertiseIP) {
+				localAddr = advertiseIP.String()
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ip, err := c.resolveSystemAddr()
+			if err != nil {
+				logrus.Warnf("Could not find a local address: %v", err)
+				return "", errMustSpecifyListenAddr
+			}
+			localAddr = ip.String()
+		}
+	}
+	return localAddr, nil
+}
+
 func validateAddr(addr string) (string, error) {
 	if addr == "" {
 		return addr, errors.New("invalid empty address")
@@ -0,0 +1,51 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+)
+
+// The sinks the audit records can be written to.
+const (
+	AuditLogSinkFile    = "file"
+	AuditLogSinkSyslog  = "syslog"
+	AuditLogSinkWebhook = "webhook"
+)
+
+// AuditLogConfig contains the configuration of the audit log, recording the
+// API requests changing the state of the daemon.
+type AuditLogConfig struct {
+	// Sink is where the audit records are written: "file", "syslog" or
+	// "webhook". The audit log is disabled when it is not set.
+	Sink string `json:",omitempty"`
+	// Path is the path of the file of the file sink, <data-root>/audit/audit.log
+	// by default.
+	Path string `json:",omitempty"`
+	// Address is the address of the syslog server of the syslog sink, such as
+	// udp://host:514, the local syslog server by default.
+	Address string `json:",omitempty"`
+	// URL is the URL the webhook sink posts the audit records to.
+	URL string `json:",omitempty"`
+}
+
+// ValidateAuditLog validates the configuration of the audit log.
+func ValidateAuditLog(conf AuditLogConfig) error {
+	switch conf.Sink {
+	case "":
+		return nil
+	case AuditLogSinkFile:
+		if conf.Path != "" && !filepath.IsAbs(conf.Path) {
+			return fmt.Errorf("invalid audit log path %s: the path must be absolute", conf.Path)
+		}
+	case AuditLogSinkSyslog:
+	case AuditLogSinkWebhook:
+		u, err := url.Parse(conf.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid audit log webhook URL %q: an http or https URL is required", conf.URL)
+		}
+	default:
+		return fmt.Errorf("invalid audit log sink %q: must be %s, %s or %s", conf.Sink, AuditLogSinkFile, AuditLogSinkSyslog, AuditLogSinkWebhook)
+	}
+	return nil
+}
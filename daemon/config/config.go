@@ -12,11 +12,14 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	daemondiscovery "github.com/docker/docker/daemon/discovery"
+	nwconfig "github.com/docker/docker/libnetwork/config"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/discovery"
+	"github.com/docker/docker/pkg/gpu"
 	"github.com/docker/docker/registry"
 	"github.com/imdario/mergo"
 	"github.com/sirupsen/logrus"
@@ -56,14 +59,28 @@ var flatOptions = map[string]bool{
 	"default-ulimits":    true,
 	"features":           true,
 	"builder":            true,
+	"container-gc":       true,
+	"tracing":            true,
+	"tls-identities":     true,
+	"audit-log":          true,
+	"webhooks":           true,
+	"hooks":              true,
+	"device-profiles":    true,
 }
 
 // skipValidateOptions contains configuration keys
 // that will be skipped from findConfigurationConflicts
 // for unknown flag validation.
 var skipValidateOptions = map[string]bool{
-	"features": true,
-	"builder":  true,
+	"features":        true,
+	"builder":         true,
+	"container-gc":    true,
+	"tracing":         true,
+	"tls-identities":  true,
+	"audit-log":       true,
+	"webhooks":        true,
+	"hooks":           true,
+	"device-profiles": true,
 }
 
 // skipDuplicates contains configuration keys that
@@ -121,6 +138,9 @@ type CommonConfig struct {
 	DNS                   []string                  `json:"dns,omitempty"`
 	DNSOptions            []string                  `json:"dns-opts,omitempty"`
 	DNSSearch             []string                  `json:"dns-search,omitempty"`
+	DNSUpstreams          []string                  `json:"dns-upstreams,omitempty"`
+	DNSUpstreamCA         string                    `json:"dns-upstream-ca,omitempty"`
+	DNSResponseMode       string                    `json:"dns-response-mode,omitempty"`
 	ExecOptions           []string                  `json:"exec-opts,omitempty"`
 	GraphDriver           string                    `json:"storage-driver,omitempty"`
 	GraphOptions          []string                  `json:"storage-opts,omitempty"`
@@ -135,6 +155,19 @@ type CommonConfig struct {
 	SocketGroup           string                    `json:"group,omitempty"`
 	CorsHeaders           string                    `json:"api-cors-header,omitempty"`
 
+	// APIRateLimit is the number of API requests per second allowed for
+	// each identity, the identities being the users that authenticated the
+	// requests, with TLS or an authorization plugin, and the hosts of the
+	// other clients. The requests are not limited when it is 0.
+	APIRateLimit float64 `json:"api-rate-limit,omitempty"`
+
+	// APIRateBurst is the number of API requests each identity is allowed
+	// to make at once. It defaults to APIRateLimit.
+	APIRateBurst int `json:"api-rate-burst,omitempty"`
+
+	// GRPCAPI enables the gRPC API, served on the sockets of the REST API.
+	GRPCAPI bool `json:"grpc-api,omitempty"`
+
 	// TrustKeyPath is used to generate the daemon ID and for signing schema 1 manifests
 	// when pushing to a registry which does not support schema 2. This field is marked as
 	// deprecated because schema 1 manifests are deprecated in favor of schema 2 and the
@@ -171,6 +204,28 @@ type CommonConfig struct {
 	// to stop when daemon is being shutdown
 	ShutdownTimeout int `json:"shutdown-timeout,omitempty"`
 
+	// ExecAudit enables recording the exec sessions run in containers
+	// in the exec audit log.
+	ExecAudit bool `json:"exec-audit,omitempty"`
+
+	// ExecAuditEvents enables emitting an event for every entry added
+	// to the exec audit log.
+	ExecAuditEvents bool `json:"exec-audit-events,omitempty"`
+
+	// ContainerScheduler enables starting and stopping containers on the
+	// schedules set in their labels.
+	ContainerScheduler bool `json:"container-scheduler,omitempty"`
+
+	// EventsLogSize is the maximum size of the event log the events are
+	// persisted to, for them to be replayed across the restarts of the
+	// daemon. The events are not persisted when it is 0.
+	EventsLogSize opts.MemBytes `json:"events-log-size,omitempty"`
+
+	// EventsLogRetention is how long the events of the event log are
+	// replayed for, as a duration. They are replayed as long as they are in
+	// the event log when it is not set.
+	EventsLogRetention string `json:"events-log-retention,omitempty"`
+
 	Debug     bool     `json:"debug,omitempty"`
 	Hosts     []string `json:"hosts,omitempty"`
 	LogLevel  string   `json:"log-level,omitempty"`
@@ -199,6 +254,11 @@ type CommonConfig struct {
 
 	MetricsAddress string `json:"metrics-addr"`
 
+	// ContainerMetrics enables exporting the resource usage of every running
+	// container on the metrics address. This adds several series per
+	// container, so it is disabled by default.
+	ContainerMetrics bool `json:"container-metrics,omitempty"`
+
 	LogConfig
 	BridgeConfig // bridgeConfig holds bridge network specific configuration.
 	NetworkConfig
@@ -215,6 +275,19 @@ type CommonConfig struct {
 	// e.g: ["orange=red", "orange=green", "orange=blue", "apple=3"]
 	NodeGenericResources []string `json:"node-generic-resources,omitempty"`
 
+	// NodeGPUDiscovery advertises the GPUs discovered on the host as
+	// generic resources of kind "gpu"
+	NodeGPUDiscovery bool `json:"node-gpu-discovery,omitempty"`
+
+	// CSIPlugins are the CSI node plugins used as volume drivers, as
+	// name=endpoint pairs, e.g: ["ebs=unix:///run/csi/ebs.sock"]
+	CSIPlugins []string `json:"csi-plugins,omitempty"`
+
+	// VolumeEncryptionKeyFile is the key file protecting the keys of the
+	// encrypted local volumes which do not use a key management plugin. It
+	// must not be on the filesystem of the volumes.
+	VolumeEncryptionKeyFile string `json:"volume-encryption-key-file,omitempty"`
+
 	// ContainerAddr is the address used to connect to containerd if we're
 	// not starting it ourselves
 	ContainerdAddr string `json:"containerd,omitempty"`
@@ -229,6 +302,25 @@ type CommonConfig struct {
 	Features map[string]bool `json:"features,omitempty"`
 
 	Builder BuilderConfig `json:"builder,omitempty"`
+
+	// ContainerGC contains the policies used to automatically remove
+	// exited containers.
+	ContainerGC ContainerGCConfig `json:"container-gc,omitempty"`
+
+	// Tracing contains the configuration for the tracing of the daemon
+	// operations.
+	Tracing TracingConfig `json:"tracing,omitempty"`
+
+	// TLSIdentities contains the identities the certificates of the TLS
+	// clients are mapped to, and the policies of their API requests.
+	TLSIdentities TLSIdentitiesConfig `json:"tls-identities,omitempty"`
+
+	// AuditLog contains the configuration of the audit log of the API
+	// requests changing the state of the daemon.
+	AuditLog AuditLogConfig `json:"audit-log,omitempty"`
+
+	// Webhooks are the webhooks the events of the daemon are posted to.
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
 }
 
 // IsValueSet returns true if a configuration value
@@ -545,6 +637,19 @@ func Validate(config *Config) error {
 		}
 	}
 
+	// validate DNSUpstreams
+	for _, upstream := range config.DNSUpstreams {
+		if _, err := opts.ValidateDNSUpstream(upstream); err != nil {
+			return err
+		}
+	}
+
+	switch config.DNSResponseMode {
+	case "", nwconfig.DNSResponseAll, nwconfig.DNSResponseRoundRobin:
+	default:
+		return fmt.Errorf("invalid DNS response mode %q: must be %q or %q", config.DNSResponseMode, nwconfig.DNSResponseAll, nwconfig.DNSResponseRoundRobin)
+	}
+
 	// validate Labels
 	for _, label := range config.Labels {
 		if _, err := opts.ValidateLabel(label); err != nil {
@@ -567,7 +672,53 @@ func Validate(config *Config) error {
 		}
 	}
 
-	if _, err := ParseGenericResources(config.NodeGenericResources); err != nil {
+	genericResources, err := ParseGenericResources(config.NodeGenericResources)
+	if err != nil {
+		return err
+	}
+	if config.NodeGPUDiscovery {
+		for _, r := range genericResources {
+			if (r.NamedResourceSpec != nil && r.NamedResourceSpec.Kind == gpu.Kind) || (r.DiscreteResourceSpec != nil && r.DiscreteResourceSpec.Kind == gpu.Kind) {
+				return fmt.Errorf("node-gpu-discovery conflicts with the %q node generic resources", gpu.Kind)
+			}
+		}
+	}
+
+	if _, err := ParseCSIPlugins(config.CSIPlugins); err != nil {
+		return err
+	}
+
+	if err := ValidateContainerGC(config.ContainerGC); err != nil {
+		return err
+	}
+
+	if err := ValidateTracing(config.Tracing); err != nil {
+		return err
+	}
+
+	if err := ValidateTLSIdentities(config.TLSIdentities); err != nil {
+		return err
+	}
+
+	if err := ValidateAuditLog(config.AuditLog); err != nil {
+		return err
+	}
+
+	if err := ValidateWebhooks(config.Webhooks); err != nil {
+		return err
+	}
+
+	if config.APIRateLimit < 0 {
+		return fmt.Errorf("invalid API rate limit: %v", config.APIRateLimit)
+	}
+	if config.APIRateBurst < 0 {
+		return fmt.Errorf("invalid API rate burst: %d", config.APIRateBurst)
+	}
+
+	if config.EventsLogSize < 0 {
+		return fmt.Errorf("invalid events log size: %d", config.EventsLogSize)
+	}
+	if _, err := ParseEventsLogRetention(config.EventsLogRetention); err != nil {
 		return err
 	}
 
@@ -582,6 +733,19 @@ func Validate(config *Config) error {
 	return config.ValidatePlatformConfig()
 }
 
+// ParseEventsLogRetention parses the retention of the event log, 0 being
+// returned when it is not set.
+func ParseEventsLogRetention(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	retention, err := time.ParseDuration(v)
+	if err != nil || retention <= 0 {
+		return 0, fmt.Errorf("invalid events log retention %q: must be a positive duration", v)
+	}
+	return retention, nil
+}
+
 // ModifiedDiscoverySettings returns whether the discovery configuration has been modified or not.
 func ModifiedDiscoverySettings(config *Config, backendType, advertise string, clusterOpts map[string]string) bool {
 	if config.ClusterStore != backendType || config.ClusterAdvertise != advertise {
@@ -6,8 +6,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/daemon/discovery"
 	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/authorization"
 	"github.com/spf13/pflag"
 	"gotest.tools/assert"
 	is "gotest.tools/assert/cmp"
@@ -305,6 +307,35 @@ func TestValidateConfigurationErrors(t *testing.T) {
 				},
 			},
 		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					NodeGenericResources: []string{"gpu=2"},
+					NodeGPUDiscovery:     true,
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					DNSResponseMode: "random",
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					EventsLogSize: -1,
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					EventsLogRetention: "7 days",
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		err := Validate(tc.config)
@@ -376,6 +407,21 @@ func TestValidateConfiguration(t *testing.T) {
 				},
 			},
 		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					NodeGenericResources: []string{"foo=1"},
+					NodeGPUDiscovery:     true,
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					DNSResponseMode: "round-robin",
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		err := Validate(tc.config)
@@ -516,3 +562,344 @@ func TestReloadWithDuplicateLabels(t *testing.T) {
 	err := Reload(configFile, flags, func(c *Config) {})
 	assert.Check(t, err)
 }
+
+func TestContainerGCConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"container-gc": {
+		"Enabled": true,
+		"Interval": "10m",
+		"Policy": [
+			{"Filter": {"label": {"env=ci": true}, "exited": {"0": true}}, "MinAge": "24h"},
+			{"KeepLast": 3}
+		]
+	}
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Check(t, cc.ContainerGC.Enabled)
+	assert.Check(t, is.Equal(cc.ContainerGC.Interval, "10m"))
+	assert.Assert(t, is.Len(cc.ContainerGC.Policy, 2))
+	assert.Check(t, is.DeepEqual(cc.ContainerGC.Policy[0].Filter.Get("label"), []string{"env=ci"}))
+	assert.Check(t, is.DeepEqual(cc.ContainerGC.Policy[0].Filter.Get("exited"), []string{"0"}))
+	assert.Check(t, is.Equal(cc.ContainerGC.Policy[0].MinAge, "24h"))
+	assert.Check(t, is.Equal(cc.ContainerGC.Policy[1].KeepLast, 3))
+}
+
+func TestValidateContainerGC(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		config      ContainerGCConfig
+		expectedErr string
+	}{
+		{
+			doc:    "empty",
+			config: ContainerGCConfig{},
+		},
+		{
+			doc:         "invalid interval",
+			config:      ContainerGCConfig{Interval: "soon"},
+			expectedErr: `invalid container GC interval "soon"`,
+		},
+		{
+			doc:         "negative interval",
+			config:      ContainerGCConfig{Interval: "-1m"},
+			expectedErr: "must be positive",
+		},
+		{
+			doc: "invalid filter",
+			config: ContainerGCConfig{Policy: []ContainerGCRule{
+				{Filter: filters.NewArgs(filters.Arg("name", "foo"))},
+			}},
+			expectedErr: "Invalid filter 'name'",
+		},
+		{
+			doc:         "invalid min age",
+			config:      ContainerGCConfig{Policy: []ContainerGCRule{{MinAge: "1y"}}},
+			expectedErr: `invalid min age "1y"`,
+		},
+		{
+			doc:         "negative keep last",
+			config:      ContainerGCConfig{Policy: []ContainerGCRule{{KeepLast: -1}}},
+			expectedErr: "keep last must not be negative",
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateContainerGC(tc.config)
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
+
+func TestTracingConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"tracing": {
+		"Endpoint": "http://localhost:4318",
+		"SamplingRatio": 0.25
+	}
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(cc.Tracing.Endpoint, "http://localhost:4318"))
+	assert.Assert(t, cc.Tracing.SamplingRatio != nil)
+	assert.Check(t, is.Equal(*cc.Tracing.SamplingRatio, 0.25))
+}
+
+func TestValidateTracing(t *testing.T) {
+	ratio := func(r float64) *float64 { return &r }
+	testCases := []struct {
+		doc         string
+		config      TracingConfig
+		expectedErr string
+	}{
+		{
+			doc:    "empty",
+			config: TracingConfig{},
+		},
+		{
+			doc:    "valid",
+			config: TracingConfig{Endpoint: "https://collector:4318", SamplingRatio: ratio(0)},
+		},
+		{
+			doc:         "invalid endpoint",
+			config:      TracingConfig{Endpoint: "collector:4317"},
+			expectedErr: `invalid OTLP endpoint "collector:4317"`,
+		},
+		{
+			doc:         "invalid sampling ratio",
+			config:      TracingConfig{SamplingRatio: ratio(1.5)},
+			expectedErr: "invalid tracing sampling ratio 1.5",
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateTracing(tc.config)
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
+
+func TestTLSIdentitiesConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"tls-identities": {
+		"Identities": [
+			{"Name": "monitoring", "Subjects": ["prometheus"], "Policy": "read-only"},
+			{"Name": "ci", "SANs": ["ci.example.com"], "Policy": "no-exec"}
+		],
+		"DefaultPolicy": "read-only"
+	}
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cc.TLSIdentities, TLSIdentitiesConfig{
+		Identities: []authorization.TLSIdentity{
+			{Name: "monitoring", Subjects: []string{"prometheus"}, Policy: authorization.PolicyReadOnly},
+			{Name: "ci", SANs: []string{"ci.example.com"}, Policy: authorization.PolicyNoExec},
+		},
+		DefaultPolicy: authorization.PolicyReadOnly,
+	}))
+	assert.Check(t, cc.IsValueSet("tls-identities"))
+}
+
+func TestValidateTLSIdentities(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		config      TLSIdentitiesConfig
+		expectedErr string
+	}{
+		{
+			doc:    "empty",
+			config: TLSIdentitiesConfig{},
+		},
+		{
+			doc: "valid",
+			config: TLSIdentitiesConfig{
+				Identities:    []authorization.TLSIdentity{{Name: "ci", SANs: []string{"10.0.0.5"}, Policy: authorization.PolicyFull}},
+				DefaultPolicy: authorization.PolicyNoExec,
+			},
+		},
+		{
+			doc:         "no subjects",
+			config:      TLSIdentitiesConfig{Identities: []authorization.TLSIdentity{{Name: "ci", Policy: authorization.PolicyFull}}},
+			expectedErr: "invalid TLS identity ci: at least one subject or SAN is required",
+		},
+		{
+			doc: "duplicate name",
+			config: TLSIdentitiesConfig{Identities: []authorization.TLSIdentity{
+				{Name: "ci", Subjects: []string{"a"}, Policy: authorization.PolicyFull},
+				{Name: "ci", Subjects: []string{"b"}, Policy: authorization.PolicyFull},
+			}},
+			expectedErr: "invalid TLS identity ci: duplicate name",
+		},
+		{
+			doc:         "invalid policy",
+			config:      TLSIdentitiesConfig{Identities: []authorization.TLSIdentity{{Name: "ci", Subjects: []string{"a"}, Policy: "admin"}}},
+			expectedErr: `invalid TLS identity ci: invalid policy "admin"`,
+		},
+		{
+			doc:         "invalid default policy",
+			config:      TLSIdentitiesConfig{DefaultPolicy: "none"},
+			expectedErr: `invalid TLS identities default policy: invalid policy "none"`,
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateTLSIdentities(tc.config)
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
+
+func TestAuditLogConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"audit-log": {
+		"Sink": "webhook",
+		"URL": "https://audit.example.com/records"
+	}
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cc.AuditLog, AuditLogConfig{Sink: AuditLogSinkWebhook, URL: "https://audit.example.com/records"}))
+}
+
+func TestValidateAuditLog(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		config      AuditLogConfig
+		expectedErr string
+	}{
+		{
+			doc:    "disabled",
+			config: AuditLogConfig{},
+		},
+		{
+			doc:    "file",
+			config: AuditLogConfig{Sink: AuditLogSinkFile, Path: "/var/log/docker-audit.log"},
+		},
+		{
+			doc:         "relative path",
+			config:      AuditLogConfig{Sink: AuditLogSinkFile, Path: "audit.log"},
+			expectedErr: "invalid audit log path audit.log: the path must be absolute",
+		},
+		{
+			doc:    "syslog",
+			config: AuditLogConfig{Sink: AuditLogSinkSyslog, Address: "udp://localhost:514"},
+		},
+		{
+			doc:         "webhook without URL",
+			config:      AuditLogConfig{Sink: AuditLogSinkWebhook},
+			expectedErr: `invalid audit log webhook URL "": an http or https URL is required`,
+		},
+		{
+			doc:         "invalid sink",
+			config:      AuditLogConfig{Sink: "kafka"},
+			expectedErr: `invalid audit log sink "kafka"`,
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateAuditLog(tc.config)
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
+
+func TestWebhooksConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"webhooks": [
+		{
+			"URL": "https://alerts.example.com/docker",
+			"Filter": {"event": {"health_status": true, "oom": true}},
+			"Secret": "s3cr3t",
+			"MaxRetries": 5,
+			"RetryInterval": "2s"
+		},
+		{"URL": "http://localhost:8080/events"}
+	]
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(cc.Webhooks, 2))
+	w := cc.Webhooks[0]
+	assert.Check(t, is.Equal(w.URL, "https://alerts.example.com/docker"))
+	assert.Check(t, w.Filter.ExactMatch("event", "oom"))
+	assert.Check(t, !w.Filter.ExactMatch("event", "die"))
+	assert.Check(t, is.Equal(w.Secret, "s3cr3t"))
+	assert.Check(t, is.Equal(w.MaxRetries, 5))
+	assert.Check(t, is.Equal(w.RetryInterval, "2s"))
+	assert.Check(t, is.Equal(cc.Webhooks[1].URL, "http://localhost:8080/events"))
+}
+
+func TestValidateWebhooks(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		config      WebhookConfig
+		expectedErr string
+	}{
+		{
+			doc:    "valid",
+			config: WebhookConfig{URL: "https://alerts.example.com", Filter: filters.NewArgs(filters.Arg("event", "oom")), MaxRetries: 3, RetryInterval: "500ms"},
+		},
+		{
+			doc:         "invalid URL",
+			config:      WebhookConfig{URL: "alerts.example.com"},
+			expectedErr: `invalid webhook URL "alerts.example.com": an http or https URL is required`,
+		},
+		{
+			doc:         "invalid filter",
+			config:      WebhookConfig{URL: "https://alerts.example.com", Filter: filters.NewArgs(filters.Arg("exited", "0"))},
+			expectedErr: "invalid filter of webhook https://alerts.example.com",
+		},
+		{
+			doc:         "negative max retries",
+			config:      WebhookConfig{URL: "https://alerts.example.com", MaxRetries: -1},
+			expectedErr: "invalid max retries of webhook https://alerts.example.com: -1",
+		},
+		{
+			doc:         "invalid retry interval",
+			config:      WebhookConfig{URL: "https://alerts.example.com", RetryInterval: "soon"},
+			expectedErr: "invalid retry interval of webhook https://alerts.example.com",
+		},
+		{
+			doc:         "negative retry interval",
+			config:      WebhookConfig{URL: "https://alerts.example.com", RetryInterval: "-1s"},
+			expectedErr: "invalid retry interval of webhook https://alerts.example.com: must be positive",
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateWebhooks([]WebhookConfig{tc.config})
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
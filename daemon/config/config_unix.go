@@ -4,6 +4,8 @@ package config // import "github.com/docker/docker/daemon/config"
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/opts"
@@ -34,11 +36,26 @@ type Config struct {
 	Init                 bool                     `json:"init,omitempty"`
 	InitPath             string                   `json:"init-path,omitempty"`
 	SeccompProfile       string                   `json:"seccomp-profile,omitempty"`
-	ShmSize              opts.MemBytes            `json:"default-shm-size,omitempty"`
-	NoNewPrivileges      bool                     `json:"no-new-privileges,omitempty"`
-	IpcMode              string                   `json:"default-ipc-mode,omitempty"`
+	// SELinuxLabelOpts are the default SELinux label options of the
+	// containers, for the options they do not set.
+	SELinuxLabelOpts []string      `json:"selinux-label-opts,omitempty"`
+	AppArmorTemplate string        `json:"apparmor-template,omitempty"`
+	ShmSize          opts.MemBytes `json:"default-shm-size,omitempty"`
+	NoNewPrivileges  bool          `json:"no-new-privileges,omitempty"`
+	IpcMode          string        `json:"default-ipc-mode,omitempty"`
 	// ResolvConf is the path to the configuration of the host resolver
 	ResolvConf string `json:"resolv-conf,omitempty"`
+	// Hooks are the OCI hooks of the containers, by name. The containers
+	// select the hooks they run with the com.docker.hooks label.
+	Hooks map[string]HookConfig `json:"hooks,omitempty"`
+	// DeviceProfiles are the device profiles the containers reference, by
+	// name.
+	DeviceProfiles map[string]DeviceProfile `json:"device-profiles,omitempty"`
+	// MaskedPaths and ReadonlyPaths are the paths masked and set read-only
+	// in the containers which do not set their own, instead of the defaults
+	// of the OCI spec.
+	MaskedPaths   []string `json:"masked-paths,omitempty"`
+	ReadonlyPaths []string `json:"readonly-paths,omitempty"`
 }
 
 // BridgeConfig stores all the bridge driver specific
@@ -52,11 +69,16 @@ type BridgeConfig struct {
 	// Fields below here are platform specific.
 	EnableIPv6          bool   `json:"ipv6,omitempty"`
 	EnableIPTables      bool   `json:"iptables,omitempty"`
+	EnableIP6Tables     bool   `json:"ip6tables,omitempty"`
+	FirewallBackend     string `json:"firewall-backend,omitempty"`
 	EnableIPForward     bool   `json:"ip-forward,omitempty"`
 	EnableIPMasq        bool   `json:"ip-masq,omitempty"`
 	EnableUserlandProxy bool   `json:"userland-proxy,omitempty"`
 	UserlandProxyPath   string `json:"userland-proxy-path,omitempty"`
+	UserlandProxyMode   string `json:"userland-proxy-mode,omitempty"`
 	FixedCIDRv6         string `json:"fixed-cidr-v6,omitempty"`
+	DynamicPortRange    string `json:"dynamic-port-range,omitempty"`
+	PortMapper          string `json:"port-mapper,omitempty"`
 }
 
 // IsSwarmCompatible defines if swarm mode can be enabled in this config
@@ -85,5 +107,53 @@ func verifyDefaultIpcMode(mode string) error {
 
 // ValidatePlatformConfig checks if any platform-specific configuration settings are invalid.
 func (conf *Config) ValidatePlatformConfig() error {
-	return verifyDefaultIpcMode(conf.IpcMode)
+	if err := verifyDefaultIpcMode(conf.IpcMode); err != nil {
+		return err
+	}
+	for _, opt := range conf.SELinuxLabelOpts {
+		if opt == "disable" {
+			return fmt.Errorf("invalid SELinux label option %q: the labeling of the containers is disabled with --selinux-enabled=false", opt)
+		}
+		if err := ValidateSELinuxLabelOpt(opt); err != nil {
+			return err
+		}
+	}
+	if err := ValidateHooks(conf.Hooks); err != nil {
+		return err
+	}
+	if err := ValidateDeviceProfiles(conf.DeviceProfiles); err != nil {
+		return err
+	}
+	if err := validateSystemPaths("masked path", conf.MaskedPaths); err != nil {
+		return err
+	}
+	return validateSystemPaths("read-only path", conf.ReadonlyPaths)
+}
+
+// selinuxLevelRegex matches the SELinux levels, a sensitivity or a range of
+// sensitivities and the MCS categories, "s0:c1,c2" or "s0-s0:c0.c1023" for
+// instance.
+var selinuxLevelRegex = regexp.MustCompile(`^s[0-9]+(-s[0-9]+)?(:c[0-9]+(\.c[0-9]+)?(,c[0-9]+(\.c[0-9]+)?)*)?$`)
+
+// ValidateSELinuxLabelOpt validates an SELinux label option of the
+// containers: "disable", or "user", "role", "type", "level" or "filetype"
+// (the type of the mount label) followed by ":" and a value.
+func ValidateSELinuxLabelOpt(opt string) error {
+	if opt == "disable" {
+		return nil
+	}
+	con := strings.SplitN(opt, ":", 2)
+	if len(con) != 2 || con[1] == "" {
+		return fmt.Errorf("invalid SELinux label option %q: valid options are 'disable', or 'user', 'role', 'type', 'level' or 'filetype' followed by ':' and a value", opt)
+	}
+	switch con[0] {
+	case "user", "role", "type", "filetype":
+	case "level":
+		if !selinuxLevelRegex.MatchString(con[1]) {
+			return fmt.Errorf("invalid SELinux level %q: a level is a sensitivity and optional categories, such as s0:c1,c2", con[1])
+		}
+	default:
+		return fmt.Errorf("invalid SELinux label option %q: valid options are 'disable', or 'user', 'role', 'type', 'level' or 'filetype' followed by ':' and a value", opt)
+	}
+	return nil
 }
@@ -5,6 +5,7 @@ package config // import "github.com/docker/docker/daemon/config"
 import (
 	"testing"
 
+	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/opts"
 	"github.com/docker/go-units"
 	"github.com/spf13/pflag"
@@ -132,3 +133,188 @@ func TestDaemonConfigurationMergeShmSize(t *testing.T) {
 	expectedValue := 1 * 1024 * 1024 * 1024
 	assert.Check(t, is.Equal(int64(expectedValue), cc.ShmSize.Value()))
 }
+
+func TestHooksConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"hooks": {
+		"gpu": {"stage": "prestart", "path": "/usr/bin/gpu-hook", "args": ["gpu-hook", "prestart"], "timeout": 10},
+		"observer": {"stage": "poststop", "path": "/usr/bin/observer", "always": true}
+	}
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cc.Hooks, map[string]HookConfig{
+		"gpu":      {Stage: HookStagePrestart, Path: "/usr/bin/gpu-hook", Args: []string{"gpu-hook", "prestart"}, Timeout: 10},
+		"observer": {Stage: HookStagePoststop, Path: "/usr/bin/observer", Always: true},
+	}))
+}
+
+func TestValidateHooks(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		config      HookConfig
+		expectedErr string
+	}{
+		{
+			doc:    "valid",
+			config: HookConfig{Stage: HookStagePoststart, Path: "/usr/bin/hook", Timeout: 5},
+		},
+		{
+			doc:         "unsupported stage",
+			config:      HookConfig{Stage: "createRuntime", Path: "/usr/bin/hook"},
+			expectedErr: `invalid stage of hook test: "createRuntime"`,
+		},
+		{
+			doc:         "relative path",
+			config:      HookConfig{Stage: HookStagePrestart, Path: "hook"},
+			expectedErr: `invalid path of hook test: "hook" is not an absolute path`,
+		},
+		{
+			doc:         "negative timeout",
+			config:      HookConfig{Stage: HookStagePrestart, Path: "/usr/bin/hook", Timeout: -1},
+			expectedErr: "invalid timeout of hook test: -1",
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateHooks(map[string]HookConfig{"test": tc.config})
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
+
+func TestValidateSELinuxLabelOpts(t *testing.T) {
+	testCases := []struct {
+		opt         string
+		expectedErr string
+	}{
+		{opt: "type:svirt_apache_t"},
+		{opt: "filetype:svirt_sandbox_file_t"},
+		{opt: "level:s0:c100,c200"},
+		{opt: "level:s0-s0:c0.c1023"},
+		{opt: "level:s0:c1.c3,c5"},
+		{opt: "level:s0"},
+		{opt: "level:c100", expectedErr: `invalid SELinux level "c100"`},
+		{opt: "level:s0:c1,", expectedErr: `invalid SELinux level "s0:c1,"`},
+		{opt: "range:s0", expectedErr: `invalid SELinux label option "range:s0"`},
+		{opt: "type:", expectedErr: `invalid SELinux label option "type:"`},
+		{opt: "disable", expectedErr: "the labeling of the containers is disabled with --selinux-enabled=false"},
+	}
+	for _, tc := range testCases {
+		conf := &Config{SELinuxLabelOpts: []string{tc.opt}}
+		err := conf.ValidatePlatformConfig()
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.opt)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.opt)
+		}
+	}
+}
+
+func TestDeviceProfilesConfiguration(t *testing.T) {
+	tempFile := fs.NewFile(t, "config", fs.WithContent(`{
+	"device-profiles": {
+		"gpu-inference": {
+			"devices": [{"PathOnHost": "/dev/nvidia0", "PathInContainer": "/dev/nvidia0", "CgroupPermissions": "rwm"}],
+			"device-cgroup-rules": ["c 195:* rwm"],
+			"group-add": ["video"]
+		}
+	}
+}`))
+	defer tempFile.Remove()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config-file", tempFile.Path(), "")
+	cc, err := MergeDaemonConfigurations(&Config{}, flags, tempFile.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cc.DeviceProfiles, map[string]DeviceProfile{
+		"gpu-inference": {
+			Devices:           []containertypes.DeviceMapping{{PathOnHost: "/dev/nvidia0", PathInContainer: "/dev/nvidia0", CgroupPermissions: "rwm"}},
+			DeviceCgroupRules: []string{"c 195:* rwm"},
+			GroupAdd:          []string{"video"},
+		},
+	}))
+}
+
+func TestValidateDeviceProfiles(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		profile     DeviceProfile
+		expectedErr string
+	}{
+		{
+			doc:     "valid",
+			profile: DeviceProfile{Devices: []containertypes.DeviceMapping{{PathOnHost: "/dev/fuse"}}, DeviceCgroupRules: []string{"c 10:229 rwm"}},
+		},
+		{
+			doc:         "relative device path",
+			profile:     DeviceProfile{Devices: []containertypes.DeviceMapping{{PathOnHost: "fuse"}}},
+			expectedErr: `invalid device of device profile test: "fuse" is not an absolute path`,
+		},
+		{
+			doc:         "invalid cgroup permissions",
+			profile:     DeviceProfile{Devices: []containertypes.DeviceMapping{{PathOnHost: "/dev/fuse", CgroupPermissions: "rwx"}}},
+			expectedErr: `invalid device of device profile test: invalid cgroup permissions "rwx"`,
+		},
+		{
+			doc:         "invalid cgroup rule",
+			profile:     DeviceProfile{DeviceCgroupRules: []string{"c 10:229"}},
+			expectedErr: `invalid device cgroup rule of device profile test: "c 10:229"`,
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateDeviceProfiles(map[string]DeviceProfile{"test": tc.profile})
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
+
+func TestValidateSystemPaths(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		config      Config
+		expectedErr string
+	}{
+		{
+			doc:    "valid",
+			config: Config{MaskedPaths: []string{"/proc/kcore", "/sys/kernel/debug"}, ReadonlyPaths: []string{"/proc/sys"}},
+		},
+		{
+			doc:         "relative path",
+			config:      Config{MaskedPaths: []string{"proc/kcore"}},
+			expectedErr: `invalid masked path: invalid path "proc/kcore"`,
+		},
+		{
+			doc:         "unclean path",
+			config:      Config{ReadonlyPaths: []string{"/proc/sys/"}},
+			expectedErr: `invalid read-only path: invalid path "/proc/sys/"`,
+		},
+		{
+			doc:         "root",
+			config:      Config{ReadonlyPaths: []string{"/"}},
+			expectedErr: `invalid read-only path: invalid path "/"`,
+		},
+		{
+			doc:         "duplicate path",
+			config:      Config{MaskedPaths: []string{"/proc/kcore", "/proc/kcore"}},
+			expectedErr: `invalid masked path: duplicate path "/proc/kcore"`,
+		},
+	}
+	for _, tc := range testCases {
+		err := tc.config.ValidatePlatformConfig()
+		if tc.expectedErr == "" {
+			assert.Check(t, err, tc.doc)
+		} else {
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.doc)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// containerGCAcceptedFilters lists the filters a container GC rule may use
+var containerGCAcceptedFilters = map[string]bool{
+	"label":  true,
+	"label!": true,
+	"exited": true,
+}
+
+// ContainerGCRule represents a rule selecting exited containers for removal.
+// A container is removed if it matches all the criteria of the rule.
+type ContainerGCRule struct {
+	// Filter restricts the rule to containers matching the given
+	// "label", "label!", and "exited" (exit code) filters.
+	Filter filters.Args `json:",omitempty"`
+	// MinAge is the minimum time (as a Go duration) since the container
+	// exited before it can be removed.
+	MinAge string `json:",omitempty"`
+	// KeepLast is the number of most recently exited containers matching
+	// the rule to keep for each image.
+	KeepLast int `json:",omitempty"`
+}
+
+// UnmarshalJSON decodes the rule, the filters being decoded into an
+// initialized filters.Args, which filters.Args.UnmarshalJSON requires.
+func (r *ContainerGCRule) UnmarshalJSON(data []byte) error {
+	type rule ContainerGCRule
+	v := rule{Filter: filters.NewArgs()}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = ContainerGCRule(v)
+	return nil
+}
+
+// ContainerGCConfig contains the configuration for the automatic removal
+// of exited containers by the daemon.
+type ContainerGCConfig struct {
+	Enabled bool `json:",omitempty"`
+	// Interval is the time (as a Go duration) between two GC runs.
+	Interval string            `json:",omitempty"`
+	Policy   []ContainerGCRule `json:",omitempty"`
+}
+
+// ValidateContainerGC validates the container GC configuration.
+func ValidateContainerGC(conf ContainerGCConfig) error {
+	if conf.Interval != "" {
+		d, err := time.ParseDuration(conf.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid container GC interval %q: %v", conf.Interval, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid container GC interval %q: must be positive", conf.Interval)
+		}
+	}
+	for i, rule := range conf.Policy {
+		if err := rule.Filter.Validate(containerGCAcceptedFilters); err != nil {
+			return fmt.Errorf("invalid container GC rule %d: %v", i, err)
+		}
+		err := rule.Filter.WalkValues("exited", func(value string) error {
+			_, err := strconv.Atoi(value)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("invalid container GC rule %d: invalid exited filter: %v", i, err)
+		}
+		if rule.MinAge != "" {
+			if _, err := time.ParseDuration(rule.MinAge); err != nil {
+				return fmt.Errorf("invalid container GC rule %d: invalid min age %q: %v", i, rule.MinAge, err)
+			}
+		}
+		if rule.KeepLast < 0 {
+			return fmt.Errorf("invalid container GC rule %d: keep last must not be negative", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	containertypes "github.com/docker/docker/api/types/container"
+)
+
+// deviceCgroupRuleRegex matches the device cgroup rules, as the rules of
+// the containers.
+var deviceCgroupRuleRegex = regexp.MustCompile(`^([acb]) ([0-9]+|\*):([0-9]+|\*) ([rwm]{1,3})$`)
+
+// DeviceProfile is a named set of devices, device cgroup rules and groups
+// the containers referencing it are given, as if they were set in their
+// host configs.
+type DeviceProfile struct {
+	Devices           []containertypes.DeviceMapping `json:"devices,omitempty"`
+	DeviceCgroupRules []string                       `json:"device-cgroup-rules,omitempty"`
+	// GroupAdd are the additional groups of the processes of the
+	// containers, owning the devices.
+	GroupAdd []string `json:"group-add,omitempty"`
+}
+
+// ValidateDeviceProfiles validates the device profiles, by name.
+func ValidateDeviceProfiles(profiles map[string]DeviceProfile) error {
+	for name, p := range profiles {
+		if name == "" {
+			return fmt.Errorf("invalid device profile: the name of the profile is empty")
+		}
+		for _, d := range p.Devices {
+			if !filepath.IsAbs(d.PathOnHost) {
+				return fmt.Errorf("invalid device of device profile %s: %q is not an absolute path", name, d.PathOnHost)
+			}
+			if d.PathInContainer != "" && !filepath.IsAbs(d.PathInContainer) {
+				return fmt.Errorf("invalid device of device profile %s: %q is not an absolute path", name, d.PathInContainer)
+			}
+			if strings.Trim(d.CgroupPermissions, "rwm") != "" {
+				return fmt.Errorf("invalid device of device profile %s: invalid cgroup permissions %q", name, d.CgroupPermissions)
+			}
+		}
+		for _, rule := range p.DeviceCgroupRules {
+			if !deviceCgroupRuleRegex.MatchString(rule) {
+				return fmt.Errorf("invalid device cgroup rule of device profile %s: %q", name, rule)
+			}
+		}
+		for _, g := range p.GroupAdd {
+			if g == "" {
+				return fmt.Errorf("invalid group of device profile %s: the group is empty", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// The stages of the lifecycles of the containers the hooks run at.
+const (
+	HookStagePrestart  = "prestart"
+	HookStagePoststart = "poststart"
+	HookStagePoststop  = "poststop"
+)
+
+// HookConfig is the configuration of an OCI hook of the containers, run by
+// the runtime at a stage of their lifecycles.
+type HookConfig struct {
+	// Stage is the stage the hook runs at, "prestart", "poststart" or
+	// "poststop".
+	Stage string `json:"stage"`
+	// Path is the absolute path of the binary of the hook, and Args its
+	// arguments, including the name of the binary.
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+	Env  []string `json:"env,omitempty"`
+	// Timeout is the number of seconds after which the hook is aborted,
+	// the hook not timing out when it is 0.
+	Timeout int `json:"timeout,omitempty"`
+	// Always runs the hook for all the containers, the other hooks only
+	// running for the containers selecting them.
+	Always bool `json:"always,omitempty"`
+}
+
+// ValidateHooks validates the configuration of the hooks, by name.
+func ValidateHooks(hooks map[string]HookConfig) error {
+	for name, h := range hooks {
+		if name == "" {
+			return fmt.Errorf("invalid hook: the name of the hook is empty")
+		}
+		switch h.Stage {
+		case HookStagePrestart, HookStagePoststart, HookStagePoststop:
+		default:
+			return fmt.Errorf("invalid stage of hook %s: %q, must be %q, %q or %q", name, h.Stage, HookStagePrestart, HookStagePoststart, HookStagePoststop)
+		}
+		if !filepath.IsAbs(h.Path) {
+			return fmt.Errorf("invalid path of hook %s: %q is not an absolute path", name, h.Path)
+		}
+		if h.Timeout < 0 {
+			return fmt.Errorf("invalid timeout of hook %s: %d", name, h.Timeout)
+		}
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package config // import "github.com/docker/docker/daemon/config"
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/daemon/cluster/convert"
+	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/csi"
 	"github.com/docker/swarmkit/api/genericresource"
 )
 
@@ -20,3 +25,31 @@ func ParseGenericResources(value []string) ([]swarm.GenericResource, error) {
 	obj := convert.GenericResourcesFromGRPC(resources)
 	return obj, nil
 }
+
+// ParseCSIPlugins parses and validates the specified list of CSI plugins, as
+// name=endpoint pairs, returning the endpoints of the plugins by name.
+func ParseCSIPlugins(value []string) (map[string]string, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	plugins := make(map[string]string, len(value))
+	for _, p := range value {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid CSI plugin %q: the format is name=endpoint", p)
+		}
+		name, endpoint := kv[0], kv[1]
+		if name == volume.DefaultDriverName {
+			return nil, fmt.Errorf("invalid CSI plugin %q: the volume driver name %q is reserved", p, name)
+		}
+		if _, exists := plugins[name]; exists {
+			return nil, fmt.Errorf("invalid CSI plugins: %q is specified more than once", name)
+		}
+		if _, err := csi.ParseEndpoint(endpoint); err != nil {
+			return nil, err
+		}
+		plugins[name] = endpoint
+	}
+	return plugins, nil
+}
@@ -0,0 +1,31 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ValidateSystemPath validates a path masked or set read-only in the
+// containers: a clean absolute path, other than the root of the container.
+func ValidateSystemPath(path string) error {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path || path == "/" {
+		return fmt.Errorf("invalid path %q: the masked and read-only paths are clean absolute paths other than /", path)
+	}
+	return nil
+}
+
+// validateSystemPaths validates the default masked or read-only paths of the
+// containers.
+func validateSystemPaths(option string, paths []string) error {
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if err := ValidateSystemPath(p); err != nil {
+			return fmt.Errorf("invalid %s: %v", option, err)
+		}
+		if seen[p] {
+			return fmt.Errorf("invalid %s: duplicate path %q", option, p)
+		}
+		seen[p] = true
+	}
+	return nil
+}
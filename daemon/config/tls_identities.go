@@ -0,0 +1,46 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/pkg/authorization"
+)
+
+// TLSIdentitiesConfig contains the identities the certificates of the TLS
+// clients are mapped to, the API requests of the identities being restricted
+// by their policies before they are authorized by the authorization plugins.
+type TLSIdentitiesConfig struct {
+	// Identities are the identities the certificates are mapped to, each
+	// certificate being mapped to the first identity it matches.
+	Identities []authorization.TLSIdentity `json:",omitempty"`
+	// DefaultPolicy is the policy of the certificates matching no identity.
+	// Their requests are not restricted when it is not set.
+	DefaultPolicy authorization.Policy `json:",omitempty"`
+}
+
+// ValidateTLSIdentities validates the configuration of the TLS identities.
+func ValidateTLSIdentities(conf TLSIdentitiesConfig) error {
+	names := make(map[string]bool)
+	for _, i := range conf.Identities {
+		if i.Name == "" {
+			return fmt.Errorf("invalid TLS identity: the name is required")
+		}
+		if names[i.Name] {
+			return fmt.Errorf("invalid TLS identity %s: duplicate name", i.Name)
+		}
+		names[i.Name] = true
+		if len(i.Subjects) == 0 && len(i.SANs) == 0 {
+			return fmt.Errorf("invalid TLS identity %s: at least one subject or SAN is required", i.Name)
+		}
+		if i.Policy == "" {
+			return fmt.Errorf("invalid TLS identity %s: the policy is required", i.Name)
+		}
+		if err := authorization.ValidatePolicy(i.Policy); err != nil {
+			return fmt.Errorf("invalid TLS identity %s: %v", i.Name, err)
+		}
+	}
+	if err := authorization.ValidatePolicy(conf.DefaultPolicy); err != nil {
+		return fmt.Errorf("invalid TLS identities default policy: %v", err)
+	}
+	return nil
+}
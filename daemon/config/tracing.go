@@ -0,0 +1,37 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/pkg/tracing"
+)
+
+// TracingConfig contains the configuration for the tracing of the API
+// requests, the container lifecycle and the image pulls, the spans being
+// exported to an OpenTelemetry collector.
+type TracingConfig struct {
+	// Endpoint is the URL of the OTLP/HTTP endpoint of the collector, e.g.
+	// http://localhost:4318. The operations are not traced when it is not set.
+	Endpoint string `json:",omitempty"`
+	// SamplingRatio is the ratio of the traces started by the daemon that
+	// are sampled, from 0 to 1. All the traces are sampled when it is not
+	// set. The traces propagated with the API requests follow the sampling
+	// decision of their callers.
+	SamplingRatio *float64 `json:",omitempty"`
+	// ServiceName is the name the spans are reported with, dockerd by
+	// default.
+	ServiceName string `json:",omitempty"`
+}
+
+// ValidateTracing validates the tracing configuration.
+func ValidateTracing(conf TracingConfig) error {
+	if conf.Endpoint != "" {
+		if _, err := tracing.EndpointURL(conf.Endpoint); err != nil {
+			return err
+		}
+	}
+	if r := conf.SamplingRatio; r != nil && (*r < 0 || *r > 1) {
+		return fmt.Errorf("invalid tracing sampling ratio %v: must be between 0 and 1", *r)
+	}
+	return nil
+}
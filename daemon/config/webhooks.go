@@ -0,0 +1,90 @@
+package config // import "github.com/docker/docker/daemon/config"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// webhookAcceptedFilters lists the filters of the events a webhook may use,
+// as the events API does.
+var webhookAcceptedFilters = map[string]bool{
+	"config":    true,
+	"container": true,
+	"daemon":    true,
+	"event":     true,
+	"image":     true,
+	"label":     true,
+	"network":   true,
+	"node":      true,
+	"plugin":    true,
+	"scope":     true,
+	"secret":    true,
+	"service":   true,
+	"type":      true,
+	"volume":    true,
+}
+
+// WebhookConfig is the configuration of a webhook the daemon posts its
+// events to, such as the changes of the health status of the containers
+// ("health_status") and their OOM kills ("oom").
+type WebhookConfig struct {
+	// URL is the http or https URL the events are posted to.
+	URL string
+	// Filter restricts the events posted to the webhook to the events
+	// matching the filters, as the events API does. All the events are
+	// posted when it is empty.
+	Filter filters.Args `json:",omitempty"`
+	// Secret is the key the events are signed with, with HMAC-SHA256, the
+	// signature being sent in the X-Docker-Signature header as
+	// sha256=<hex>. The events are not signed when it is empty.
+	Secret string `json:",omitempty"`
+	// MaxRetries is the number of times the failed posts of an event are
+	// retried, the events not being retried when it is 0.
+	MaxRetries int `json:",omitempty"`
+	// RetryInterval is the time (as a Go duration) before the first retry
+	// of a post, doubled for each following retry, 1s by default.
+	RetryInterval string `json:",omitempty"`
+}
+
+// UnmarshalJSON decodes the configuration of the webhook, the filters being
+// decoded into an initialized filters.Args, which filters.Args.UnmarshalJSON
+// requires.
+func (w *WebhookConfig) UnmarshalJSON(data []byte) error {
+	type webhook WebhookConfig
+	v := webhook{Filter: filters.NewArgs()}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*w = WebhookConfig(v)
+	return nil
+}
+
+// ValidateWebhooks validates the configuration of the webhooks.
+func ValidateWebhooks(webhooks []WebhookConfig) error {
+	for _, w := range webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q: an http or https URL is required", w.URL)
+		}
+		if err := w.Filter.Validate(webhookAcceptedFilters); err != nil {
+			return fmt.Errorf("invalid filter of webhook %s: %v", w.URL, err)
+		}
+		if w.MaxRetries < 0 {
+			return fmt.Errorf("invalid max retries of webhook %s: %d", w.URL, w.MaxRetries)
+		}
+		if w.RetryInterval != "" {
+			d, err := time.ParseDuration(w.RetryInterval)
+			if err != nil {
+				return fmt.Errorf("invalid retry interval of webhook %s: %v", w.URL, err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("invalid retry interval of webhook %s: must be positive", w.URL)
+			}
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -120,6 +121,10 @@ func (daemon *Daemon) Register(c *container.Container) error {
 	c.Lock()
 	defer c.Unlock()
 
+	c.LogDropsHandler = func(dropped uint64) {
+		daemon.LogContainerEventWithAttributes(c, "log_drops", map[string]string{"dropped": strconv.FormatUint(dropped, 10)})
+	}
+
 	daemon.containers.Add(c.ID, c)
 	daemon.idIndex.Add(c.ID)
 	return c.CheckpointTo(daemon.containersReplica)
@@ -285,12 +290,22 @@ func (daemon *Daemon) verifyContainerSettings(platform string, hostConfig *conta
 				return nil, errors.Errorf("StartPeriod in Healthcheck cannot be less than %s", containertypes.MinimumDuration)
 			}
 		}
+
+		if _, err := parseScheduleLabels(config.Labels); err != nil {
+			return nil, err
+		}
 	}
 
 	if hostConfig == nil {
 		return nil, nil
 	}
 
+	for k := range hostConfig.Annotations {
+		if k == "" {
+			return nil, errors.Errorf("invalid Annotations: the empty string is not permitted as an annotation key")
+		}
+	}
+
 	if hostConfig.AutoRemove && !hostConfig.RestartPolicy.IsNone() {
 		return nil, errors.Errorf("can't create 'AutoRemove' container with restart policy")
 	}
@@ -0,0 +1,191 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultContainerGCInterval is the time between two container GC runs
+	// when no interval is configured.
+	defaultContainerGCInterval = time.Hour
+
+	// swarmTaskIDLabel is set on containers managed by the swarm executor,
+	// which takes care of removing them itself.
+	swarmTaskIDLabel = "com.docker.swarm.task.id"
+)
+
+// containerGCRule is the parsed form of a config.ContainerGCRule.
+type containerGCRule struct {
+	filter    filters.Args
+	exitCodes map[int]bool
+	minAge    time.Duration
+	keepLast  int
+}
+
+// gcCandidate holds the state of an exited container that is relevant to
+// the container GC policy.
+type gcCandidate struct {
+	id         string
+	imageID    string
+	labels     map[string]string
+	exitCode   int
+	finishedAt time.Time
+}
+
+func parseContainerGCRules(policy []config.ContainerGCRule) ([]containerGCRule, error) {
+	rules := make([]containerGCRule, 0, len(policy))
+	for _, p := range policy {
+		rule := containerGCRule{
+			filter:    p.Filter,
+			exitCodes: make(map[int]bool),
+			keepLast:  p.KeepLast,
+		}
+		err := p.Filter.WalkValues("exited", func(value string) error {
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid exited filter %q", value)
+			}
+			rule.exitCodes[code] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if p.MinAge != "" {
+			if rule.minAge, err = time.ParseDuration(p.MinAge); err != nil {
+				return nil, err
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (rule containerGCRule) matches(c gcCandidate) bool {
+	if len(rule.exitCodes) > 0 && !rule.exitCodes[c.exitCode] {
+		return false
+	}
+	return matchLabels(rule.filter, c.labels)
+}
+
+// selectContainersForGC returns the IDs of the candidates selected for
+// removal by at least one of the rules.
+func selectContainersForGC(candidates []gcCandidate, rules []containerGCRule, now time.Time) []string {
+	selected := make(map[string]bool)
+	for _, rule := range rules {
+		byImage := make(map[string][]gcCandidate)
+		for _, c := range candidates {
+			if rule.matches(c) {
+				byImage[c.imageID] = append(byImage[c.imageID], c)
+			}
+		}
+		for _, cs := range byImage {
+			// most recently exited first, so that the first keepLast
+			// containers of each image are kept.
+			sort.Slice(cs, func(i, j int) bool {
+				return cs[i].finishedAt.After(cs[j].finishedAt)
+			})
+			for i, c := range cs {
+				if i < rule.keepLast || now.Sub(c.finishedAt) < rule.minAge {
+					continue
+				}
+				selected[c.id] = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(selected))
+	for id := range selected {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// newGCCandidate returns the GC candidate for the container, and false if
+// the container is not eligible for garbage collection.
+func newGCCandidate(c *container.Container) (gcCandidate, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.Running || c.Paused || c.Restarting || c.RemovalInProgress || c.Dead || c.FinishedAt.IsZero() {
+		return gcCandidate{}, false
+	}
+	if _, ok := c.Config.Labels[swarmTaskIDLabel]; ok {
+		return gcCandidate{}, false
+	}
+	return gcCandidate{
+		id:         c.ID,
+		imageID:    c.ImageID.String(),
+		labels:     c.Config.Labels,
+		exitCode:   c.ExitCode(),
+		finishedAt: c.FinishedAt,
+	}, true
+}
+
+func (daemon *Daemon) containerGCConfig() config.ContainerGCConfig {
+	daemon.configStore.Lock()
+	defer daemon.configStore.Unlock()
+	return daemon.configStore.ContainerGC
+}
+
+// containerGC runs a loop removing exited containers according to
+// the container GC policy of the daemon.
+func (daemon *Daemon) containerGC() {
+	for {
+		interval := defaultContainerGCInterval
+		if conf := daemon.containerGCConfig(); conf.Interval != "" {
+			// the interval has been validated with the configuration
+			interval, _ = time.ParseDuration(conf.Interval)
+		}
+		time.Sleep(interval)
+
+		if daemon.IsShuttingDown() {
+			return
+		}
+		conf := daemon.containerGCConfig()
+		if !conf.Enabled || len(conf.Policy) == 0 {
+			continue
+		}
+		if err := daemon.runContainerGC(conf.Policy); err != nil {
+			logrus.WithError(err).Error("container GC failed")
+		}
+	}
+}
+
+// runContainerGC removes the exited containers selected by the policy.
+func (daemon *Daemon) runContainerGC(policy []config.ContainerGCRule) error {
+	rules, err := parseContainerGCRules(policy)
+	if err != nil {
+		return err
+	}
+
+	var candidates []gcCandidate
+	for _, c := range daemon.List() {
+		if candidate, ok := newGCCandidate(c); ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	var removed int
+	for _, id := range selectContainersForGC(candidates, rules, time.Now()) {
+		if err := daemon.ContainerRm(id, &types.ContainerRmConfig{}); err != nil {
+			logrus.Warnf("container GC failed to remove container %s: %v", id, err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		logrus.Infof("container GC removed %d exited containers", removed)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/daemon/config"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestSelectContainersForGC(t *testing.T) {
+	now := time.Now()
+	candidates := []gcCandidate{
+		{id: "a1", imageID: "a", exitCode: 0, finishedAt: now.Add(-time.Hour)},
+		{id: "a2", imageID: "a", exitCode: 1, finishedAt: now.Add(-2 * time.Hour)},
+		{id: "a3", imageID: "a", exitCode: 0, finishedAt: now.Add(-3 * time.Hour)},
+		{id: "b1", imageID: "b", exitCode: 0, finishedAt: now.Add(-time.Minute), labels: map[string]string{"env": "ci"}},
+		{id: "b2", imageID: "b", exitCode: 137, finishedAt: now.Add(-4 * time.Hour), labels: map[string]string{"env": "ci"}},
+	}
+
+	testCases := []struct {
+		doc      string
+		policy   []config.ContainerGCRule
+		expected []string
+	}{
+		{
+			doc:      "match all",
+			policy:   []config.ContainerGCRule{{}},
+			expected: []string{"a1", "a2", "a3", "b1", "b2"},
+		},
+		{
+			doc:      "min age",
+			policy:   []config.ContainerGCRule{{MinAge: "90m"}},
+			expected: []string{"a2", "a3", "b2"},
+		},
+		{
+			doc:      "exit code",
+			policy:   []config.ContainerGCRule{{Filter: filters.NewArgs(filters.Arg("exited", "0"))}},
+			expected: []string{"a1", "a3", "b1"},
+		},
+		{
+			doc:      "label",
+			policy:   []config.ContainerGCRule{{Filter: filters.NewArgs(filters.Arg("label", "env=ci"))}},
+			expected: []string{"b1", "b2"},
+		},
+		{
+			doc:      "negated label",
+			policy:   []config.ContainerGCRule{{Filter: filters.NewArgs(filters.Arg("label!", "env"))}},
+			expected: []string{"a1", "a2", "a3"},
+		},
+		{
+			doc:      "keep last per image",
+			policy:   []config.ContainerGCRule{{KeepLast: 1}},
+			expected: []string{"a2", "a3", "b2"},
+		},
+		{
+			doc:      "keep last with filter",
+			policy:   []config.ContainerGCRule{{KeepLast: 1, Filter: filters.NewArgs(filters.Arg("exited", "0"))}},
+			expected: []string{"a3"},
+		},
+		{
+			doc: "union of rules",
+			policy: []config.ContainerGCRule{
+				{Filter: filters.NewArgs(filters.Arg("exited", "137"))},
+				{MinAge: "150m", Filter: filters.NewArgs(filters.Arg("label!", "env"))},
+			},
+			expected: []string{"a3", "b2"},
+		},
+	}
+	for _, tc := range testCases {
+		rules, err := parseContainerGCRules(tc.policy)
+		assert.NilError(t, err, tc.doc)
+		assert.Check(t, is.DeepEqual(selectContainersForGC(candidates, rules, now), tc.expected), tc.doc)
+	}
+}
+
+func TestParseContainerGCRulesInvalidExitCode(t *testing.T) {
+	_, err := parseContainerGCRules([]config.ContainerGCRule{{Filter: filters.NewArgs(filters.Arg("exited", "zero"))}})
+	assert.Check(t, is.ErrorContains(err, `invalid exited filter "zero"`))
+}
@@ -19,6 +19,9 @@ func (daemon *Daemon) saveApparmorConfig(container *container.Container) error {
 	}
 
 	if !container.HostConfig.Privileged {
+		if container.AppArmorProfile == "" && daemon.apparmorTemplate != nil {
+			container.AppArmorProfile = containerAppArmorProfile(container)
+		}
 		if container.AppArmorProfile == "" {
 			container.AppArmorProfile = defaultApparmorProfile
 		}
@@ -28,3 +31,9 @@ func (daemon *Daemon) saveApparmorConfig(container *container.Container) error {
 	}
 	return nil
 }
+
+// containerAppArmorProfile returns the name of the AppArmor profile rendered
+// for a container from the template of the daemon.
+func containerAppArmorProfile(container *container.Container) string {
+	return "docker-" + container.ID
+}
@@ -15,15 +15,15 @@ import (
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/libnetwork"
+	netconst "github.com/docker/docker/libnetwork/datastore"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/options"
+	"github.com/docker/docker/libnetwork/types"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/go-connections/nat"
-	"github.com/docker/libnetwork"
-	netconst "github.com/docker/libnetwork/datastore"
-	"github.com/docker/libnetwork/netlabel"
-	"github.com/docker/libnetwork/options"
-	"github.com/docker/libnetwork/types"
 	"github.com/sirupsen/logrus"
 )
 
@@ -46,6 +46,20 @@ func (daemon *Daemon) getDNSSearchSettings(container *container.Container) []str
 	return nil
 }
 
+// networkDNSConfig returns the DNS configuration of the user-defined network
+// the container is started on, if any.
+func (daemon *Daemon) networkDNSConfig(container *container.Container) ([]string, []string, []string) {
+	mode := container.HostConfig.NetworkMode
+	if !mode.IsUserDefined() {
+		return nil, nil, nil
+	}
+	n, err := daemon.FindNetwork(mode.NetworkName())
+	if err != nil {
+		return nil, nil, nil
+	}
+	return n.Info().DNSConfig()
+}
+
 func (daemon *Daemon) buildSandboxOptions(container *container.Container) ([]libnetwork.SandboxOption, error) {
 	var (
 		sboxOptions []libnetwork.SandboxOption
@@ -73,8 +87,15 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container) ([]lib
 		return nil, err
 	}
 
+	// The DNS configuration of the container takes precedence over the one of
+	// the network the container is started on, which takes precedence over
+	// the one of the daemon.
+	netDNS, netDNSSearch, netDNSOptions := daemon.networkDNSConfig(container)
+
 	if len(container.HostConfig.DNS) > 0 {
 		dns = container.HostConfig.DNS
+	} else if len(netDNS) > 0 {
+		dns = netDNS
 	} else if len(daemon.configStore.DNS) > 0 {
 		dns = daemon.configStore.DNS
 	}
@@ -84,6 +105,9 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container) ([]lib
 	}
 
 	dnsSearch := daemon.getDNSSearchSettings(container)
+	if len(container.HostConfig.DNSSearch) == 0 && len(netDNSSearch) > 0 {
+		dnsSearch = netDNSSearch
+	}
 
 	for _, ds := range dnsSearch {
 		sboxOptions = append(sboxOptions, libnetwork.OptionDNSSearch(ds))
@@ -91,6 +115,8 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container) ([]lib
 
 	if len(container.HostConfig.DNSOptions) > 0 {
 		dnsOptions = container.HostConfig.DNSOptions
+	} else if len(netDNSOptions) > 0 {
+		dnsOptions = netDNSOptions
 	} else if len(daemon.configStore.DNSOptions) > 0 {
 		dnsOptions = daemon.configStore.DNSOptions
 	}
@@ -431,7 +457,7 @@ func (daemon *Daemon) updateContainerNetworkSettings(container *container.Contai
 	var n libnetwork.Network
 
 	mode := container.HostConfig.NetworkMode
-	if container.Config.NetworkDisabled || mode.IsContainer() {
+	if container.Config.NetworkDisabled || mode.IsContainer() || mode.IsGroup() {
 		return
 	}
 
@@ -511,10 +537,16 @@ func (daemon *Daemon) allocateNetwork(container *container.Container) error {
 		logrus.Errorf("failed to cleanup up stale network sandbox for container %s", container.ID)
 	}
 
-	if container.Config.NetworkDisabled || container.HostConfig.NetworkMode.IsContainer() {
+	if container.Config.NetworkDisabled || container.HostConfig.NetworkMode.IsContainer() || container.HostConfig.NetworkMode.IsGroup() {
 		return nil
 	}
 
+	if !container.HostConfig.NetworkMode.IsHost() {
+		if err := daemon.checkPortConflicts(container); err != nil {
+			return err
+		}
+	}
+
 	updateSettings := false
 
 	if len(container.NetworkSettings.Networks) == 0 {
@@ -581,6 +613,55 @@ func (daemon *Daemon) allocateNetwork(container *container.Container) error {
 	return nil
 }
 
+// checkPortConflicts returns a conflict error identifying the running
+// container which already publishes one of the host ports explicitly
+// requested by the port bindings of the container.
+func (daemon *Daemon) checkPortConflicts(container *container.Container) error {
+	if len(container.HostConfig.PortBindings) == 0 {
+		return nil
+	}
+	others, err := daemon.containersReplica.Snapshot().All()
+	if err != nil {
+		return err
+	}
+	for port, bindings := range container.HostConfig.PortBindings {
+		for _, b := range bindings {
+			hostPort, err := nat.ParsePort(b.HostPort)
+			if err != nil || hostPort == 0 {
+				// dynamically allocated, or a range of host ports
+				continue
+			}
+			for _, other := range others {
+				if other.ID == container.ID || !other.Running {
+					continue
+				}
+				for _, p := range other.Ports {
+					if p.Type != port.Proto() || int(p.PublicPort) != hostPort || !hostIPsOverlap(p.IP, b.HostIP) {
+						continue
+					}
+					hostIP := b.HostIP
+					if hostIP == "" {
+						hostIP = "0.0.0.0"
+					}
+					return errdefs.Conflict(fmt.Errorf("Bind for %s failed: port is already allocated by container %s (%s)",
+						net.JoinHostPort(hostIP, b.HostPort), strings.TrimPrefix(other.Name, "/"), stringid.TruncateID(other.ID)))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hostIPsOverlap returns whether ports published on the host addresses a and
+// b conflict, that is if they are equal or one of them is unspecified.
+func hostIPsOverlap(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil || ipA.IsUnspecified() || ipB.IsUnspecified() {
+		return true
+	}
+	return ipA.Equal(ipB)
+}
+
 func (daemon *Daemon) getNetworkSandbox(container *container.Container) libnetwork.Sandbox {
 	var sb libnetwork.Sandbox
 	daemon.netController.WalkSandboxes(func(s libnetwork.Sandbox) bool {
@@ -689,7 +770,7 @@ func (daemon *Daemon) updateNetworkConfig(container *container.Container, n libn
 
 func (daemon *Daemon) connectToNetwork(container *container.Container, idOrName string, endpointConfig *networktypes.EndpointSettings, updateSettings bool) (err error) {
 	start := time.Now()
-	if container.HostConfig.NetworkMode.IsContainer() {
+	if container.HostConfig.NetworkMode.IsContainer() || container.HostConfig.NetworkMode.IsGroup() {
 		return runconfig.ErrConflictSharedNetwork
 	}
 	if containertypes.NetworkMode(idOrName).IsBridge() &&
@@ -796,7 +877,7 @@ func (daemon *Daemon) connectToNetwork(container *container.Container, idOrName
 
 	container.NetworkSettings.Ports = getPortMapInfo(sb)
 
-	daemon.LogNetworkEventWithAttributes(n, "connect", map[string]string{"container": container.ID})
+	daemon.LogNetworkEventWithAttributes(n, "connect", endpointEventAttributes(container, n.Name()))
 	networkActions.WithValues("connect").UpdateSince(start)
 	return nil
 }
@@ -891,14 +972,15 @@ func (daemon *Daemon) disconnectFromNetwork(container *container.Container, n li
 		return fmt.Errorf("endpoint delete failed for container %s on network %s: %v", container.ID, n.Name(), err)
 	}
 
+	attributes := endpointEventAttributes(container, n.Name())
 	delete(container.NetworkSettings.Networks, n.Name())
 
-	daemon.tryDetachContainerFromClusterNetwork(n, container)
+	daemon.tryDetachContainerFromClusterNetwork(n, container, attributes)
 
 	return nil
 }
 
-func (daemon *Daemon) tryDetachContainerFromClusterNetwork(network libnetwork.Network, container *container.Container) {
+func (daemon *Daemon) tryDetachContainerFromClusterNetwork(network libnetwork.Network, container *container.Container, attributes map[string]string) {
 	if daemon.clusterProvider != nil && network.Info().Dynamic() && !container.Managed {
 		if err := daemon.clusterProvider.DetachNetwork(network.Name(), container.ID); err != nil {
 			logrus.Warnf("error detaching from network %s: %v", network.Name(), err)
@@ -907,12 +989,42 @@ func (daemon *Daemon) tryDetachContainerFromClusterNetwork(network libnetwork.Ne
 			}
 		}
 	}
-	attributes := map[string]string{
-		"container": container.ID,
-	}
 	daemon.LogNetworkEventWithAttributes(network, "disconnect", attributes)
 }
 
+// endpointEventAttributes returns the attributes of the network events of the
+// container on the given network: the addresses of its endpoint on the
+// network, if any, and the key of its sandbox.
+func endpointEventAttributes(container *container.Container, networkName string) map[string]string {
+	attributes := map[string]string{"container": container.ID}
+	if container.NetworkSettings == nil {
+		return attributes
+	}
+	if key := container.NetworkSettings.SandboxKey; key != "" {
+		attributes["sandboxKey"] = key
+	}
+	epSettings, ok := container.NetworkSettings.Networks[networkName]
+	if !ok || epSettings.EndpointSettings == nil {
+		return attributes
+	}
+	ep := epSettings.EndpointSettings
+	for name, value := range map[string]string{
+		"endpoint":   ep.EndpointID,
+		"macAddress": ep.MacAddress,
+	} {
+		if value != "" {
+			attributes[name] = value
+		}
+	}
+	if ep.IPAddress != "" {
+		attributes["ipv4Address"] = fmt.Sprintf("%s/%d", ep.IPAddress, ep.IPPrefixLen)
+	}
+	if ep.GlobalIPv6Address != "" {
+		attributes["ipv6Address"] = fmt.Sprintf("%s/%d", ep.GlobalIPv6Address, ep.GlobalIPv6PrefixLen)
+	}
+	return attributes
+}
+
 func (daemon *Daemon) initializeNetworking(container *container.Container) error {
 	var err error
 
@@ -933,6 +1045,16 @@ func (daemon *Daemon) initializeNetworking(container *container.Container) error
 		return nil
 	}
 
+	if container.HostConfig.NetworkMode.IsGroup() {
+		if container.Config.NetworkDisabled {
+			return nil
+		}
+		if err := daemon.joinNetworkGroup(container); err != nil {
+			return err
+		}
+		return container.BuildHostnameFile()
+	}
+
 	if container.HostConfig.NetworkMode.IsHost() {
 		if container.Config.Hostname == "" {
 			container.Config.Hostname, err = os.Hostname()
@@ -975,6 +1097,10 @@ func (daemon *Daemon) releaseNetwork(container *container.Container) {
 	if container.HostConfig.NetworkMode.IsContainer() || container.Config.NetworkDisabled {
 		return
 	}
+	if container.HostConfig.NetworkMode.IsGroup() {
+		daemon.leaveNetworkGroup(container)
+		return
+	}
 
 	sid := container.NetworkSettings.SandboxID
 	settings := container.NetworkSettings.Networks
@@ -984,10 +1110,14 @@ func (daemon *Daemon) releaseNetwork(container *container.Container) {
 		return
 	}
 
-	var networks []libnetwork.Network
+	var (
+		networks   []libnetwork.Network
+		attributes []map[string]string
+	)
 	for n, epSettings := range settings {
 		if nw, err := daemon.FindNetwork(getNetworkID(n, epSettings.EndpointSettings)); err == nil {
 			networks = append(networks, nw)
+			attributes = append(attributes, endpointEventAttributes(container, nw.Name()))
 		}
 
 		if epSettings.EndpointSettings == nil {
@@ -1007,8 +1137,8 @@ func (daemon *Daemon) releaseNetwork(container *container.Container) {
 		logrus.Errorf("Error deleting sandbox id %s for container %s: %v", sid, container.ID, err)
 	}
 
-	for _, nw := range networks {
-		daemon.tryDetachContainerFromClusterNetwork(nw, container)
+	for i, nw := range networks {
+		daemon.tryDetachContainerFromClusterNetwork(nw, container, attributes[i])
 	}
 	networkActions.WithValues("release").UpdateSince(start)
 }
@@ -1057,6 +1187,11 @@ func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, netw
 	container.Lock()
 	defer container.Unlock()
 
+	var attributes map[string]string
+	if n != nil {
+		attributes = endpointEventAttributes(container, n.Name())
+	}
+
 	if !container.Running || (err != nil && force) {
 		if container.RemovalInProgress || container.Dead {
 			return errRemovalContainer(container.ID)
@@ -1089,9 +1224,7 @@ func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, netw
 	}
 
 	if n != nil {
-		daemon.LogNetworkEventWithAttributes(n, "disconnect", map[string]string{
-			"container": container.ID,
-		})
+		daemon.LogNetworkEventWithAttributes(n, "disconnect", attributes)
 	}
 
 	return nil
@@ -0,0 +1,97 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestCheckPortConflicts(t *testing.T) {
+	store, err := container.NewViewDB()
+	assert.NilError(t, err)
+	daemon := &Daemon{containersReplica: store}
+
+	web := &container.Container{
+		ID:              "0123456789abcdef0123456789abcdef",
+		Name:            "/web",
+		State:           &container.State{Running: true},
+		Config:          &containertypes.Config{},
+		HostConfig:      &containertypes.HostConfig{},
+		NetworkSettings: &network.Settings{},
+	}
+	web.NetworkSettings.Ports = nat.PortMap{
+		"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}},
+		"53/udp": {{HostIP: "127.0.0.1", HostPort: "5353"}},
+	}
+	assert.NilError(t, store.Save(web))
+
+	newContainer := func(bindings nat.PortMap) *container.Container {
+		return &container.Container{
+			ID:         "fedcba9876543210fedcba9876543210",
+			Config:     &containertypes.Config{},
+			HostConfig: &containertypes.HostConfig{PortBindings: bindings},
+		}
+	}
+
+	err = daemon.checkPortConflicts(newContainer(nat.PortMap{"8000/tcp": {{HostPort: "8080"}}}))
+	assert.Check(t, errdefs.IsConflict(err))
+	assert.Check(t, is.Error(err, "Bind for 0.0.0.0:8080 failed: port is already allocated by container web (0123456789ab)"))
+
+	err = daemon.checkPortConflicts(newContainer(nat.PortMap{"53/udp": {{HostIP: "127.0.0.1", HostPort: "5353"}}}))
+	assert.Check(t, errdefs.IsConflict(err))
+
+	// other protocol, other address, or dynamic host port
+	assert.Check(t, daemon.checkPortConflicts(newContainer(nat.PortMap{"80/udp": {{HostPort: "8080"}}})))
+	assert.Check(t, daemon.checkPortConflicts(newContainer(nat.PortMap{"53/udp": {{HostIP: "127.0.0.2", HostPort: "5353"}}})))
+	assert.Check(t, daemon.checkPortConflicts(newContainer(nat.PortMap{"80/tcp": {{HostPort: ""}}})))
+
+	// the published ports of stopped containers are not allocated
+	web.State.Running = false
+	assert.NilError(t, store.Save(web))
+	assert.Check(t, daemon.checkPortConflicts(newContainer(nat.PortMap{"8000/tcp": {{HostPort: "8080"}}})))
+}
+
+func TestEndpointEventAttributes(t *testing.T) {
+	c := &container.Container{
+		ID: "0123456789abcdef0123456789abcdef",
+		NetworkSettings: &network.Settings{
+			Networks: map[string]*network.EndpointSettings{
+				"web": {EndpointSettings: &networktypes.EndpointSettings{
+					EndpointID:          "fedcba9876543210",
+					MacAddress:          "02:42:ac:12:00:02",
+					IPAddress:           "172.18.0.2",
+					IPPrefixLen:         16,
+					GlobalIPv6Address:   "2001:db8::2",
+					GlobalIPv6PrefixLen: 64,
+				}},
+				"stopped": {EndpointSettings: &networktypes.EndpointSettings{}},
+			},
+		},
+	}
+	c.NetworkSettings.SandboxKey = "/var/run/docker/netns/0123456789ab"
+
+	assert.Check(t, is.DeepEqual(endpointEventAttributes(c, "web"), map[string]string{
+		"container":   c.ID,
+		"endpoint":    "fedcba9876543210",
+		"macAddress":  "02:42:ac:12:00:02",
+		"ipv4Address": "172.18.0.2/16",
+		"ipv6Address": "2001:db8::2/64",
+		"sandboxKey":  "/var/run/docker/netns/0123456789ab",
+	}))
+	assert.Check(t, is.DeepEqual(endpointEventAttributes(c, "stopped"), map[string]string{
+		"container":  c.ID,
+		"sandboxKey": "/var/run/docker/netns/0123456789ab",
+	}))
+
+	c.NetworkSettings.SandboxKey = ""
+	assert.Check(t, is.DeepEqual(endpointEventAttributes(c, "other"), map[string]string{
+		"container": c.ID,
+	}))
+}
@@ -6,8 +6,8 @@ import (
 	"os"
 
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/libnetwork"
 	"github.com/docker/docker/pkg/system"
-	"github.com/docker/libnetwork"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
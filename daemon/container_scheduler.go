@@ -0,0 +1,198 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/scheduler"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// scheduleStartLabel is the label holding the schedule on which a
+	// container is started.
+	scheduleStartLabel = "com.docker.schedule.start"
+
+	// scheduleStopLabel is the label holding the schedule on which a
+	// container is stopped.
+	scheduleStopLabel = "com.docker.schedule.stop"
+)
+
+// containerSchedules holds the parsed schedules of a container.
+type containerSchedules struct {
+	start, stop *scheduler.Schedule
+}
+
+// parseScheduleLabels parses the schedules set in the labels of a container.
+// It returns nil if no schedule is set.
+func parseScheduleLabels(labels map[string]string) (*containerSchedules, error) {
+	var (
+		s   containerSchedules
+		err error
+	)
+	if spec, ok := labels[scheduleStartLabel]; ok {
+		if s.start, err = scheduler.Parse(spec); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s label", scheduleStartLabel)
+		}
+	}
+	if spec, ok := labels[scheduleStopLabel]; ok {
+		if s.stop, err = scheduler.Parse(spec); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s label", scheduleStopLabel)
+		}
+	}
+	if s.start == nil && s.stop == nil {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// dueAction returns the scheduled action ("start" or "stop") due in the
+// interval (last, now], or an empty string if none is. If both actions are
+// due, the most recent one is returned.
+func (s *containerSchedules) dueAction(last, now time.Time) string {
+	var (
+		action string
+		at     time.Time
+	)
+	if s.start != nil {
+		if next := s.start.Next(last); !next.IsZero() && !next.After(now) {
+			action, at = "start", next
+		}
+	}
+	if s.stop != nil {
+		if next := s.stop.Next(last); !next.IsZero() && !next.After(now) && !next.Before(at) {
+			action = "stop"
+		}
+	}
+	return action
+}
+
+// containerScheduler runs a loop starting and stopping containers on the
+// schedules set in their labels, checking the schedules every minute.
+func (daemon *Daemon) containerScheduler() {
+	if daemon.scheduleHistory == nil {
+		return
+	}
+	last := time.Now()
+	for {
+		// wake up at the start of the next minute
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		if daemon.IsShuttingDown() {
+			return
+		}
+		now = time.Now()
+		daemon.runScheduledActions(last, now)
+		last = now
+	}
+}
+
+// runScheduledActions starts and stops the containers whose schedules are
+// due in the interval (last, now].
+func (daemon *Daemon) runScheduledActions(last, now time.Time) {
+	containers := daemon.List()
+	live := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		live[c.ID] = true
+
+		c.Lock()
+		skip := c.RemovalInProgress || c.Dead
+		labels := c.Config.Labels
+		c.Unlock()
+		if skip {
+			continue
+		}
+
+		schedules, err := parseScheduleLabels(labels)
+		if err != nil {
+			logrus.WithError(err).WithField("container", c.ID).Debug("ignoring container with invalid schedule")
+			continue
+		}
+		if schedules == nil {
+			continue
+		}
+		if action := schedules.dueAction(last, now); action != "" {
+			daemon.runScheduledAction(c, action)
+		}
+	}
+
+	if err := daemon.scheduleHistory.Prune(func(id string) bool { return live[id] }); err != nil {
+		logrus.WithError(err).Warn("failed to prune container schedule history")
+	}
+}
+
+func (daemon *Daemon) runScheduledAction(c *container.Container, action string) {
+	var err error
+	switch action {
+	case "start":
+		if c.IsRunning() {
+			return
+		}
+		err = daemon.ContainerStart(context.Background(), c.ID, nil, "", "")
+	case "stop":
+		if !c.IsRunning() {
+			return
+		}
+		err = daemon.ContainerStop(c.ID, nil)
+	}
+
+	record := scheduler.Record{Action: action, Time: time.Now().UTC()}
+	if err != nil {
+		logrus.WithError(err).WithField("container", c.ID).Warnf("scheduled %s failed", action)
+		record.Error = err.Error()
+	} else {
+		logrus.WithField("container", c.ID).Infof("scheduled %s of container", action)
+	}
+	if err := daemon.scheduleHistory.Set(c.ID, record); err != nil {
+		logrus.WithError(err).Warn("failed to record scheduled action")
+	}
+}
+
+// ContainerSchedules returns the schedules of the containers which have a
+// start or stop schedule set in their labels.
+func (daemon *Daemon) ContainerSchedules() ([]types.ContainerSchedule, error) {
+	if daemon.scheduleHistory == nil {
+		return nil, errdefs.NotImplemented(errors.New("the container scheduler is not enabled on this daemon"))
+	}
+
+	now := time.Now()
+	list := []types.ContainerSchedule{}
+	for _, c := range daemon.List() {
+		c.Lock()
+		labels := c.Config.Labels
+		name := strings.TrimPrefix(c.Name, "/")
+		c.Unlock()
+
+		schedules, err := parseScheduleLabels(labels)
+		if err != nil || schedules == nil {
+			continue
+		}
+		s := types.ContainerSchedule{
+			ID:    c.ID,
+			Name:  name,
+			Start: labels[scheduleStartLabel],
+			Stop:  labels[scheduleStopLabel],
+		}
+		if schedules.start != nil {
+			s.NextStart = schedules.start.Next(now)
+		}
+		if schedules.stop != nil {
+			s.NextStop = schedules.stop.Next(now)
+		}
+		if r, ok := daemon.scheduleHistory.Get(c.ID); ok {
+			s.LastAction = r.Action
+			s.LastRun = r.Time
+			s.LastError = r.Error
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
@@ -0,0 +1,49 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestParseScheduleLabels(t *testing.T) {
+	s, err := parseScheduleLabels(map[string]string{"foo": "bar"})
+	assert.NilError(t, err)
+	assert.Check(t, s == nil)
+
+	s, err = parseScheduleLabels(map[string]string{scheduleStartLabel: "0 22 * * *"})
+	assert.NilError(t, err)
+	assert.Check(t, s.start != nil)
+	assert.Check(t, s.stop == nil)
+
+	_, err = parseScheduleLabels(map[string]string{scheduleStopLabel: "every night"})
+	assert.Check(t, is.ErrorContains(err, "invalid com.docker.schedule.stop label"))
+}
+
+func TestScheduleDueAction(t *testing.T) {
+	s, err := parseScheduleLabels(map[string]string{
+		scheduleStartLabel: "0 22 * * *",
+		scheduleStopLabel:  "0 6 * * *",
+	})
+	assert.NilError(t, err)
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2019, time.January, day, hour, min, 0, 0, time.UTC)
+	}
+	testCases := []struct {
+		doc       string
+		last, now time.Time
+		expected  string
+	}{
+		{doc: "nothing due", last: at(16, 12, 0), now: at(16, 12, 1), expected: ""},
+		{doc: "start due", last: at(16, 21, 59), now: at(16, 22, 0), expected: "start"},
+		{doc: "stop due", last: at(17, 5, 59), now: at(17, 6, 0), expected: "stop"},
+		{doc: "most recent action", last: at(16, 21, 0), now: at(17, 7, 0), expected: "stop"},
+		{doc: "last is excluded", last: at(16, 22, 0), now: at(16, 22, 0), expected: ""},
+	}
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(s.dueAction(tc.last, tc.now), tc.expected), tc.doc)
+	}
+}
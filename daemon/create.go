@@ -1,6 +1,7 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"runtime"
@@ -15,24 +16,30 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/pkg/tracing"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/go-connections/nat"
 	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 )
 
 // CreateManagedContainer creates a container that is managed by a Service
-func (daemon *Daemon) CreateManagedContainer(params types.ContainerCreateConfig) (containertypes.ContainerCreateCreatedBody, error) {
-	return daemon.containerCreate(params, true)
+func (daemon *Daemon) CreateManagedContainer(ctx context.Context, params types.ContainerCreateConfig) (containertypes.ContainerCreateCreatedBody, error) {
+	return daemon.containerCreate(ctx, params, true)
 }
 
 // ContainerCreate creates a regular container
-func (daemon *Daemon) ContainerCreate(params types.ContainerCreateConfig) (containertypes.ContainerCreateCreatedBody, error) {
-	return daemon.containerCreate(params, false)
+func (daemon *Daemon) ContainerCreate(ctx context.Context, params types.ContainerCreateConfig) (containertypes.ContainerCreateCreatedBody, error) {
+	return daemon.containerCreate(ctx, params, false)
 }
 
-func (daemon *Daemon) containerCreate(params types.ContainerCreateConfig, managed bool) (containertypes.ContainerCreateCreatedBody, error) {
+func (daemon *Daemon) containerCreate(ctx context.Context, params types.ContainerCreateConfig, managed bool) (_ containertypes.ContainerCreateCreatedBody, retErr error) {
 	start := time.Now()
+	ctx, span := trace.StartSpan(ctx, "daemon.ContainerCreate")
+	defer func() { tracing.EndSpan(span, retErr) }()
+
 	if params.Config == nil {
 		return containertypes.ContainerCreateCreatedBody{}, errdefs.InvalidParameter(errors.New("Config cannot be empty in order to create a container"))
 	}
@@ -64,22 +71,24 @@ func (daemon *Daemon) containerCreate(params types.ContainerCreateConfig, manage
 	if params.HostConfig == nil {
 		params.HostConfig = &containertypes.HostConfig{}
 	}
+	expandPortProtocols(params.Config, params.HostConfig)
 	err = daemon.adaptContainerSettings(params.HostConfig, params.AdjustCPUShares)
 	if err != nil {
 		return containertypes.ContainerCreateCreatedBody{Warnings: warnings}, errdefs.InvalidParameter(err)
 	}
 
-	container, err := daemon.create(params, managed)
+	container, err := daemon.create(ctx, params, managed)
 	if err != nil {
 		return containertypes.ContainerCreateCreatedBody{Warnings: warnings}, err
 	}
+	span.AddAttributes(trace.StringAttribute("container.id", container.ID))
 	containerActions.WithValues("create").UpdateSince(start)
 
 	return containertypes.ContainerCreateCreatedBody{ID: container.ID, Warnings: warnings}, nil
 }
 
 // Create creates a new container from the given configuration with a given name.
-func (daemon *Daemon) create(params types.ContainerCreateConfig, managed bool) (retC *container.Container, retErr error) {
+func (daemon *Daemon) create(ctx context.Context, params types.ContainerCreateConfig, managed bool) (retC *container.Container, retErr error) {
 	var (
 		container *container.Container
 		img       *image.Image
@@ -93,6 +102,9 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig, managed bool) (
 		if err != nil {
 			return nil, err
 		}
+		if err := daemon.imageService.CheckImagePolicies(params.Config.Image, img); err != nil {
+			return nil, err
+		}
 		if img.OS != "" {
 			os = img.OS
 		} else {
@@ -155,7 +167,9 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig, managed bool) (
 	}
 
 	// Set RWLayer for container after mount labels have been set
+	_, span := trace.StartSpan(ctx, "images.CreateLayer")
 	rwLayer, err := daemon.imageService.CreateLayer(container, setupInitLayer(daemon.idMapping))
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, errdefs.System(err)
 	}
@@ -203,13 +217,18 @@ func toHostConfigSelinuxLabels(labels []string) []string {
 }
 
 func (daemon *Daemon) generateSecurityOpt(hostConfig *containertypes.HostConfig) ([]string, error) {
+	var labelOpts []string
 	for _, opt := range hostConfig.SecurityOpt {
-		con := strings.Split(opt, "=")
-		if con[0] == "label" {
-			// Caller overrode SecurityOpts
-			return nil, nil
+		con := strings.SplitN(opt, "=", 2)
+		if con[0] == "label" && len(con) == 2 {
+			labelOpts = append(labelOpts, con[1])
 		}
 	}
+	if len(labelOpts) > 0 {
+		// Caller overrode SecurityOpts, the defaults of the daemon only
+		// completing the label options it did not set
+		return daemon.defaultLabelOpts(labelOpts), nil
+	}
 	ipcMode := hostConfig.IpcMode
 	pidMode := hostConfig.PidMode
 	privileged := hostConfig.Privileged
@@ -251,7 +270,7 @@ func (daemon *Daemon) generateSecurityOpt(hostConfig *containertypes.HostConfig)
 		}
 		return toHostConfigSelinuxLabels(pidLabel), nil
 	}
-	return nil, nil
+	return daemon.defaultLabelOpts(nil), nil
 }
 
 func (daemon *Daemon) mergeAndVerifyConfig(config *containertypes.Config, img *image.Image) error {
@@ -270,6 +289,44 @@ func (daemon *Daemon) mergeAndVerifyConfig(config *containertypes.Config, img *i
 	return nil
 }
 
+// expandPortProtocols replaces the ports exposed or published for both TCP
+// and UDP (for example "53/both" or "53/tcp+udp") with a TCP port and a UDP
+// port, so that the stored configuration only holds actual protocols.
+func expandPortProtocols(config *containertypes.Config, hostConfig *containertypes.HostConfig) {
+	for port := range config.ExposedPorts {
+		protos := expandProto(port.Proto())
+		if len(protos) == 1 {
+			continue
+		}
+		delete(config.ExposedPorts, port)
+		for _, proto := range protos {
+			config.ExposedPorts[nat.Port(port.Port()+"/"+proto)] = struct{}{}
+		}
+	}
+	for port, bindings := range hostConfig.PortBindings {
+		protos := expandProto(port.Proto())
+		if len(protos) == 1 {
+			continue
+		}
+		delete(hostConfig.PortBindings, port)
+		for _, proto := range protos {
+			p := nat.Port(port.Port() + "/" + proto)
+			hostConfig.PortBindings[p] = append(hostConfig.PortBindings[p], bindings...)
+		}
+	}
+}
+
+// expandProto returns the protocols designated by proto. The "both" and
+// "tcp+udp" pseudo-protocols designate both "tcp" and "udp".
+func expandProto(proto string) []string {
+	switch strings.ToLower(proto) {
+	case "both", "tcp+udp":
+		return []string{"tcp", "udp"}
+	default:
+		return []string{proto}
+	}
+}
+
 // Checks if the client set configurations for more than one network while creating a container
 // Also checks if the IPAMConfig is valid
 func verifyNetworkingConfig(nwConfig *networktypes.NetworkingConfig) error {
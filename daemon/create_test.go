@@ -3,9 +3,12 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"testing"
 
+	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
 	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
 )
 
 // Test case for 35752
@@ -19,3 +22,33 @@ func TestVerifyNetworkingConfig(t *testing.T) {
 	err := verifyNetworkingConfig(nwConfig)
 	assert.Check(t, errdefs.IsInvalidParameter(err))
 }
+
+func TestExpandPortProtocols(t *testing.T) {
+	config := &containertypes.Config{
+		ExposedPorts: nat.PortSet{
+			"53/both":    {},
+			"80/tcp":     {},
+			"67/tcp+udp": {},
+		},
+	}
+	hostConfig := &containertypes.HostConfig{
+		PortBindings: nat.PortMap{
+			"53/both": {{HostIP: "127.0.0.1", HostPort: "5353"}},
+			"80/tcp":  {{HostPort: "8080"}},
+		},
+	}
+	expandPortProtocols(config, hostConfig)
+
+	assert.Check(t, is.DeepEqual(nat.PortSet{
+		"53/tcp": {},
+		"53/udp": {},
+		"80/tcp": {},
+		"67/tcp": {},
+		"67/udp": {},
+	}, config.ExposedPorts))
+	assert.Check(t, is.DeepEqual(nat.PortMap{
+		"53/tcp": {{HostIP: "127.0.0.1", HostPort: "5353"}},
+		"53/udp": {{HostIP: "127.0.0.1", HostPort: "5353"}},
+		"80/tcp": {{HostPort: "8080"}},
+	}, hostConfig.PortBindings))
+}
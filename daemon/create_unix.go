@@ -32,11 +32,17 @@ func (daemon *Daemon) createContainerOSSpecificSettings(container *container.Con
 
 	// Set the default masked and readonly paths with regard to the host config options if they are not set.
 	if hostConfig.MaskedPaths == nil && !hostConfig.Privileged {
-		hostConfig.MaskedPaths = oci.DefaultSpec().Linux.MaskedPaths // Set it to the default if nil
+		hostConfig.MaskedPaths = daemon.defaultMaskedPaths() // Set it to the default if nil
 		container.HostConfig.MaskedPaths = hostConfig.MaskedPaths
 	}
 	if hostConfig.ReadonlyPaths == nil && !hostConfig.Privileged {
-		hostConfig.ReadonlyPaths = oci.DefaultSpec().Linux.ReadonlyPaths // Set it to the default if nil
+		hostConfig.ReadonlyPaths = daemon.defaultReadonlyPaths() // Set it to the default if nil
+		container.HostConfig.ReadonlyPaths = hostConfig.ReadonlyPaths
+	}
+	if !hostConfig.Privileged {
+		hostConfig.MaskedPaths = adjustPaths(hostConfig.MaskedPaths, hostConfig.MaskedPathsAdd, hostConfig.MaskedPathsRemove)
+		container.HostConfig.MaskedPaths = hostConfig.MaskedPaths
+		hostConfig.ReadonlyPaths = adjustPaths(hostConfig.ReadonlyPaths, hostConfig.ReadonlyPathsAdd, hostConfig.ReadonlyPathsRemove)
 		container.HostConfig.ReadonlyPaths = hostConfig.ReadonlyPaths
 	}
 
@@ -73,6 +79,47 @@ func (daemon *Daemon) createContainerOSSpecificSettings(container *container.Con
 	return daemon.populateVolumes(container)
 }
 
+// defaultMaskedPaths returns the paths masked in the containers which do not
+// set their own, the default paths of the daemon, or of the OCI spec.
+func (daemon *Daemon) defaultMaskedPaths() []string {
+	if len(daemon.configStore.MaskedPaths) != 0 {
+		return append([]string{}, daemon.configStore.MaskedPaths...)
+	}
+	return oci.DefaultSpec().Linux.MaskedPaths
+}
+
+// defaultReadonlyPaths returns the paths set read-only in the containers
+// which do not set their own, the default paths of the daemon, or of the OCI
+// spec.
+func (daemon *Daemon) defaultReadonlyPaths() []string {
+	if len(daemon.configStore.ReadonlyPaths) != 0 {
+		return append([]string{}, daemon.configStore.ReadonlyPaths...)
+	}
+	return oci.DefaultSpec().Linux.ReadonlyPaths
+}
+
+// adjustPaths returns the paths without the removed paths, and with the
+// added paths they do not contain yet. The removed paths not in paths are
+// ignored, so that the containers keep being created when the defaults of
+// the daemon change.
+func adjustPaths(paths, add, remove []string) []string {
+	if len(add) == 0 && len(remove) == 0 {
+		return paths
+	}
+	adjusted := []string{}
+	seen := make(map[string]bool)
+	for _, p := range remove {
+		seen[p] = true
+	}
+	for _, p := range append(append([]string{}, paths...), add...) {
+		if !seen[p] {
+			seen[p] = true
+			adjusted = append(adjusted, p)
+		}
+	}
+	return adjusted
+}
+
 // populateVolumes copies data from the container's rootfs into the volume for non-binds.
 // this is only called when the container is created.
 func (daemon *Daemon) populateVolumes(c *container.Container) error {
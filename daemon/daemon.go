@@ -17,6 +17,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"google.golang.org/grpc"
@@ -38,6 +39,8 @@ import (
 	"github.com/docker/docker/daemon/images"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/network"
+	"github.com/docker/docker/daemon/operations"
+	"github.com/docker/docker/daemon/scheduler"
 	"github.com/docker/docker/errdefs"
 	"github.com/moby/buildkit/util/resolver"
 	"github.com/moby/buildkit/util/tracing"
@@ -50,7 +53,11 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
 	"github.com/docker/docker/libcontainerd"
+	"github.com/docker/docker/libnetwork"
+	"github.com/docker/docker/libnetwork/cluster"
+	nwconfig "github.com/docker/docker/libnetwork/config"
 	"github.com/docker/docker/migrate/v1"
+	"github.com/docker/docker/pkg/gpu"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/locker"
 	"github.com/docker/docker/pkg/plugingetter"
@@ -62,16 +69,19 @@ import (
 	refstore "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/volume/keymanager"
 	volumesservice "github.com/docker/docker/volume/service"
-	"github.com/docker/libnetwork"
-	"github.com/docker/libnetwork/cluster"
-	nwconfig "github.com/docker/libnetwork/config"
+	"github.com/docker/go-metrics"
 	"github.com/pkg/errors"
 )
 
 // ContainersNamespace is the name of the namespace used for users containers
 const ContainersNamespace = "moby"
 
+// imagePoliciesFile is the file, in the root of the daemon, in which the
+// image policies are persisted.
+const imagePoliciesFile = "image-policies.json"
+
 var (
 	errSystemNotSupported = errors.New("the Docker daemon is not supported on this platform")
 )
@@ -83,14 +93,23 @@ type Daemon struct {
 	containers        container.Store
 	containersReplica container.ViewDB
 	execCommands      *exec.Store
+	execAudit         *exec.AuditLog
+	scheduleHistory   *scheduler.History
 	imageService      *images.ImageService
+	operations        *operations.Manager
+	webhooks          []*webhook
+	configReloadMu    sync.Mutex
+	configReload      *types.ConfigReload
 	idIndex           *truncindex.TruncIndex
 	configStore       *config.Config
 	statsCollector    *stats.Collector
+	containerMetrics  *containerMetrics
 	defaultLogConfig  containertypes.LogConfig
 	RegistryService   registry.Service
 	EventsService     *events.Events
 	netController     libnetwork.NetworkController
+	networkGroups     *networkGroupStore
+	networkPolicies   *networkPolicyStore
 	volumes           *volumesservice.VolumesService
 	discoveryWatcher  discovery.Reloader
 	root              string
@@ -98,6 +117,11 @@ type Daemon struct {
 	apparmorEnabled   bool
 	shutdown          bool
 	idMapping         *idtools.IdentityMapping
+	// the user namespaces the idmapped mounts of the containers are
+	// created with, on first use
+	usernsLock   sync.Mutex
+	volumeUserns *os.File
+	bindUserns   *os.File
 	// TODO: move graphDrivers field to an InfoService
 	graphDrivers map[string]string // By operating system
 
@@ -110,6 +134,7 @@ type Daemon struct {
 	clusterProvider       cluster.Provider
 	cluster               Cluster
 	genericResources      []swarm.GenericResource
+	gpus                  []gpu.Device
 	metricsPluginListener net.Listener
 
 	machineMemory uint64
@@ -117,10 +142,15 @@ type Daemon struct {
 	seccompProfile     []byte
 	seccompProfilePath string
 
-	diskUsageRunning int32
-	pruneRunning     int32
-	hosts            map[string]bool // hosts stores the addresses the daemon is listening on
-	startupDone      chan struct{}
+	// apparmorTemplate is the template the AppArmor profiles of the
+	// containers are rendered from, if any
+	apparmorTemplate *template.Template
+
+	diskUsage             diskUsageCache
+	pruneRunning          int32
+	storageMigrateRunning int32
+	hosts                 map[string]bool // hosts stores the addresses the daemon is listening on
+	startupDone           chan struct{}
 
 	attachmentStore       network.AttachmentStore
 	attachableNetworkLock *locker.Locker
@@ -366,7 +396,7 @@ func (daemon *Daemon) restore() error {
 				}
 
 				c.ResetRestartManager(false)
-				if !c.HostConfig.NetworkMode.IsContainer() && c.IsRunning() {
+				if !c.HostConfig.NetworkMode.IsContainer() && !c.HostConfig.NetworkMode.IsGroup() && c.IsRunning() {
 					options, err := daemon.buildSandboxOptions(c)
 					if err != nil {
 						logrus.Warnf("Failed build sandbox option to restore container %s: %v", c.ID, err)
@@ -420,6 +450,9 @@ func (daemon *Daemon) restore() error {
 	if err != nil {
 		return fmt.Errorf("Error initializing network controller: %v", err)
 	}
+	if err := daemon.applyNetworkPolicies(); err != nil {
+		logrus.WithError(err).Error("failed to apply the network policies")
+	}
 
 	// Now that all the containers are registered, register the links
 	for _, c := range containers {
@@ -452,7 +485,7 @@ func (daemon *Daemon) restore() error {
 
 			// Make sure networks are available before starting
 			daemon.waitForNetworks(c)
-			if err := daemon.containerStart(c, "", "", true); err != nil {
+			if err := daemon.containerStart(context.Background(), c, "", "", true); err != nil {
 				logrus.Errorf("Failed to start container %s: %s", c.ID, err)
 			}
 			close(chNotify)
@@ -519,7 +552,7 @@ func (daemon *Daemon) RestartSwarmContainers() {
 				group.Add(1)
 				go func(c *container.Container) {
 					defer group.Done()
-					if err := daemon.containerStart(c, "", "", true); err != nil {
+					if err := daemon.containerStart(context.Background(), c, "", "", true); err != nil {
 						logrus.Error(err)
 					}
 				}(c)
@@ -726,6 +759,10 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		return nil, err
 	}
 
+	if err := d.setupAppArmorTemplate(); err != nil {
+		return nil, err
+	}
+
 	// Set the default isolation mode (only applicable on Windows)
 	if err := d.setDefaultIsolation(); err != nil {
 		return nil, fmt.Errorf("error setting default isolation mode: %v", err)
@@ -785,6 +822,7 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 
 	d.RegistryService = registryService
 	logger.RegisterPluginGetter(d.PluginStore)
+	keymanager.RegisterPluginGetter(d.PluginStore)
 
 	metricsSockPath, err := d.listenMetricsSock()
 	if err != nil {
@@ -884,7 +922,11 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		return nil, err
 	}
 
-	d.volumes, err = volumesservice.NewVolumeService(config.Root, d.PluginStore, rootIDs, d)
+	csiPlugins, err := parseCSIPlugins(config)
+	if err != nil {
+		return nil, err
+	}
+	d.volumes, err = volumesservice.NewVolumeService(config.Root, d.PluginStore, idMapping, csiPlugins, config.VolumeEncryptionKeyFile, d)
 	if err != nil {
 		return nil, err
 	}
@@ -949,11 +991,34 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	if d.containersReplica, err = container.NewViewDB(); err != nil {
 		return nil, err
 	}
+	if d.networkGroups, err = newNetworkGroupStore(config.Root); err != nil {
+		return nil, err
+	}
+	if d.networkPolicies, err = newNetworkPolicyStore(filepath.Join(config.Root, networkPoliciesFile)); err != nil {
+		return nil, err
+	}
 	d.execCommands = exec.NewStore()
+	if config.ExecAudit {
+		d.execAudit = exec.NewAuditLog(filepath.Join(config.Root, "exec-audit.log"))
+	}
+	if config.ContainerScheduler {
+		if d.scheduleHistory, err = scheduler.NewHistory(filepath.Join(config.Root, "container-schedules.json")); err != nil {
+			return nil, err
+		}
+	}
 	d.idIndex = truncindex.NewTruncIndex([]string{})
 	d.statsCollector = d.newStatsCollector(1 * time.Second)
+	if config.ContainerMetrics {
+		ns := metrics.NewNamespace("engine", "container", nil)
+		d.containerMetrics = newContainerMetrics(ns)
+		ns.Add(d.containerMetrics)
+		metrics.Register(ns)
+	}
 
-	d.EventsService = events.New()
+	if d.EventsService, err = newEventsService(config); err != nil {
+		return nil, err
+	}
+	d.startWebhooks(config.Webhooks)
 	d.root = config.Root
 	d.idMapping = idMapping
 	d.seccompEnabled = sysInfo.Seccomp
@@ -964,6 +1029,7 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	// TODO: imageStore, distributionMetadataStore, and ReferenceStore are only
 	// used above to run migration. They could be initialized in ImageService
 	// if migration is called from daemon/images. layerStore might move as well.
+	d.operations = operations.NewManager()
 	d.imageService = images.NewImageService(images.ImageServiceConfig{
 		ContainerStore:            d.containers,
 		DistributionMetadataStore: distributionMetadataStore,
@@ -972,12 +1038,20 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 		LayerStores:               layerStores,
 		MaxConcurrentDownloads:    *config.MaxConcurrentDownloads,
 		MaxConcurrentUploads:      *config.MaxConcurrentUploads,
+		Operations:                d.operations,
 		ReferenceStore:            rs,
 		RegistryService:           registryService,
 		TrustKey:                  trustKey,
 	})
+	if err := d.imageService.LoadImagePolicies(filepath.Join(config.Root, imagePoliciesFile)); err != nil {
+		return nil, err
+	}
 
 	go d.execCommandGC()
+	go d.containerGC()
+	go d.containerScheduler()
+	go d.collectContainerMetrics()
+	go d.diskUsageRefresher()
 
 	d.containerd, err = libcontainerd.NewClient(ctx, d.containerdCli, filepath.Join(config.ExecRoot, "containerd"), ContainersNamespace, d)
 	if err != nil {
@@ -1116,6 +1190,11 @@ func (daemon *Daemon) Shutdown() error {
 		}
 	}
 
+	// the background operations use the image service
+	if daemon.operations != nil {
+		daemon.operations.CancelAll()
+	}
+
 	if daemon.imageService != nil {
 		daemon.imageService.Cleanup()
 	}
@@ -1136,6 +1215,9 @@ func (daemon *Daemon) Shutdown() error {
 		daemon.netController.Stop()
 	}
 
+	// the webhooks post the events of the shutdown
+	daemon.stopWebhooks()
+
 	if daemon.containerdCli != nil {
 		daemon.containerdCli.Close()
 	}
@@ -1238,17 +1320,58 @@ func (daemon *Daemon) setGenericResources(conf *config.Config) error {
 		return err
 	}
 
+	if conf.NodeGPUDiscovery {
+		gpus, err := gpu.Discover()
+		if err != nil {
+			logrus.WithError(err).Warn("Error discovering the GPUs of the host")
+		}
+		for _, d := range gpus {
+			genericResources = append(genericResources, swarm.GenericResource{
+				NamedResourceSpec: &swarm.NamedGenericResource{Kind: gpu.Kind, Value: d.ID},
+			})
+			logrus.WithField("id", d.ID).WithField("model", d.Model).Info("Advertising GPU")
+		}
+		daemon.gpus = gpus
+	}
+
 	daemon.genericResources = genericResources
 
 	return nil
 }
 
+// engineLabels returns the labels of the engine, the labels of its
+// configuration and the labels describing its GPUs.
+func (daemon *Daemon) engineLabels() []string {
+	labels := daemon.configStore.Labels
+	if model := gpu.Model(daemon.gpus); model != "" {
+		labels = append(labels[:len(labels):len(labels)], gpu.ModelLabel+"="+model)
+	}
+	return labels
+}
+
+func parseCSIPlugins(conf *config.Config) (map[string]string, error) {
+	return config.ParseCSIPlugins(conf.CSIPlugins)
+}
+
+// newEventsService returns the events service, persisting the events to the
+// event log when its size is set.
+func newEventsService(conf *config.Config) (*events.Events, error) {
+	if conf.EventsLogSize <= 0 {
+		return events.New(), nil
+	}
+	retention, err := config.ParseEventsLogRetention(conf.EventsLogRetention)
+	if err != nil {
+		return nil, err
+	}
+	return events.NewWithLog(filepath.Join(conf.Root, "events.log"), conf.EventsLogSize.Value(), retention)
+}
+
 func setDefaultMtu(conf *config.Config) {
 	// do nothing if the config does not have the default 0 value.
 	if conf.Mtu != 0 {
 		return
 	}
-	conf.Mtu = config.DefaultNetworkMtu
+	conf.Mtu = defaultNetworkMtu()
 }
 
 // IsShuttingDown tells whether the daemon is shutting down or not
@@ -1332,6 +1455,13 @@ func (daemon *Daemon) networkOptions(dconfig *config.Config, pg plugingetter.Plu
 
 	options = append(options, nwconfig.OptionNetworkControlPlaneMTU(dconfig.NetworkControlPlaneMTU))
 
+	if len(dconfig.DNSUpstreams) > 0 {
+		options = append(options, nwconfig.OptionDNSUpstreams(dconfig.DNSUpstreams, dconfig.DNSUpstreamCA))
+	}
+	if dconfig.DNSResponseMode != "" {
+		options = append(options, nwconfig.OptionDNSResponseMode(dconfig.DNSResponseMode))
+	}
+
 	return options, nil
 }
 
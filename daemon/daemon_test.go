@@ -8,14 +8,15 @@ import (
 	"testing"
 
 	containertypes "github.com/docker/docker/api/types/container"
+	networktypes "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/libnetwork"
 	_ "github.com/docker/docker/pkg/discovery/memory"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/truncindex"
 	volumesservice "github.com/docker/docker/volume/service"
 	"github.com/docker/go-connections/nat"
-	"github.com/docker/libnetwork"
 	"github.com/pkg/errors"
 	"gotest.tools/assert"
 	is "gotest.tools/assert/cmp"
@@ -118,7 +119,7 @@ func initDaemonWithVolumeStore(tmp string) (*Daemon, error) {
 		repository: tmp,
 		root:       tmp,
 	}
-	daemon.volumes, err = volumesservice.NewVolumeService(tmp, nil, idtools.Identity{UID: 0, GID: 0}, daemon)
+	daemon.volumes, err = volumesservice.NewVolumeService(tmp, nil, &idtools.IdentityMapping{}, nil, "", daemon)
 	if err != nil {
 		return nil, err
 	}
@@ -309,6 +310,13 @@ func TestValidateContainerIsolation(t *testing.T) {
 	assert.Check(t, is.Error(err, "invalid isolation 'invalid' on "+runtime.GOOS))
 }
 
+func TestValidateContainerAnnotations(t *testing.T) {
+	d := Daemon{}
+
+	_, err := d.verifyContainerSettings(runtime.GOOS, &containertypes.HostConfig{Annotations: map[string]string{"": "foo"}}, nil, false)
+	assert.Check(t, is.Error(err, "invalid Annotations: the empty string is not permitted as an annotation key"))
+}
+
 func TestFindNetworkErrorType(t *testing.T) {
 	d := Daemon{}
 	_, err := d.FindNetwork("fakeNet")
@@ -317,3 +325,53 @@ func TestFindNetworkErrorType(t *testing.T) {
 		t.Error("The FindNetwork method MUST always return an error that implements the NotFound interface and is ErrNoSuchNetwork")
 	}
 }
+
+func TestValidateNetworkMTU(t *testing.T) {
+	tests := []struct {
+		mtu        string
+		enableIPv6 bool
+		err        string
+	}{
+		{mtu: "1500"},
+		{mtu: "68"},
+		{mtu: "65535"},
+		{mtu: "1280", enableIPv6: true},
+		{mtu: "abc", err: `invalid MTU "abc": must be an integer`},
+		{mtu: "67", err: "invalid MTU 67: must be between 68 and 65535"},
+		{mtu: "65536", err: "invalid MTU 65536: must be between 68 and 65535"},
+		{mtu: "1279", enableIPv6: true, err: "invalid MTU 1279: must be between 1280 and 65535"},
+	}
+	for _, tc := range tests {
+		err := validateNetworkMTU(map[string]string{"com.docker.network.driver.mtu": tc.mtu}, tc.enableIPv6)
+		if tc.err == "" {
+			assert.Check(t, err, tc.mtu)
+		} else {
+			assert.Check(t, is.Error(err, tc.err), tc.mtu)
+		}
+	}
+	assert.Check(t, validateNetworkMTU(nil, false))
+}
+
+func TestValidateNetworkDNS(t *testing.T) {
+	dns, err := validateNetworkDNS(nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Nil(dns))
+
+	dns, err = validateNetworkDNS(&networktypes.DNSConfig{
+		Nameservers: []string{" 10.0.0.53", "2001:db8::53"},
+		Search:      []string{"example.com", "."},
+		Options:     []string{"ndots:2"},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(dns, &networktypes.DNSConfig{
+		Nameservers: []string{"10.0.0.53", "2001:db8::53"},
+		Search:      []string{"example.com", "."},
+		Options:     []string{"ndots:2"},
+	}))
+
+	_, err = validateNetworkDNS(&networktypes.DNSConfig{Nameservers: []string{"ns.example.com"}})
+	assert.Check(t, is.Error(err, "invalid nameserver: ns.example.com is not an ip address"))
+
+	_, err = validateNetworkDNS(&networktypes.DNSConfig{Search: []string{"-example"}})
+	assert.Check(t, is.ErrorContains(err, "invalid DNS search domain"))
+}
@@ -24,6 +24,14 @@ import (
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/initlayer"
+	"github.com/docker/docker/libnetwork"
+	nwconfig "github.com/docker/docker/libnetwork/config"
+	"github.com/docker/docker/libnetwork/drivers/bridge"
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/netutils"
+	"github.com/docker/docker/libnetwork/options"
+	lntypes "github.com/docker/docker/libnetwork/types"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/containerfs"
 	"github.com/docker/docker/pkg/idtools"
@@ -34,16 +42,10 @@ import (
 	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/runconfig"
 	volumemounts "github.com/docker/docker/volume/mounts"
-	"github.com/docker/libnetwork"
-	nwconfig "github.com/docker/libnetwork/config"
-	"github.com/docker/libnetwork/drivers/bridge"
-	"github.com/docker/libnetwork/netlabel"
-	"github.com/docker/libnetwork/netutils"
-	"github.com/docker/libnetwork/options"
-	lntypes "github.com/docker/libnetwork/types"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	rsystem "github.com/opencontainers/runc/libcontainer/system"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/selinux/go-selinux"
 	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -187,13 +189,14 @@ func (daemon *Daemon) parseSecurityOpt(container *container.Container, hostConfi
 	return parseSecurityOpt(container, hostConfig)
 }
 
-func parseSecurityOpt(container *container.Container, config *containertypes.HostConfig) error {
+func parseSecurityOpt(container *container.Container, hostConfig *containertypes.HostConfig) error {
 	var (
 		labelOpts []string
+		fileType  string
 		err       error
 	)
 
-	for _, opt := range config.SecurityOpt {
+	for _, opt := range hostConfig.SecurityOpt {
 		if opt == "no-new-privileges" {
 			container.NoNewPrivileges = true
 			continue
@@ -216,6 +219,13 @@ func parseSecurityOpt(container *container.Container, config *containertypes.Hos
 
 		switch con[0] {
 		case "label":
+			if err := config.ValidateSELinuxLabelOpt(con[1]); err != nil {
+				return err
+			}
+			if strings.HasPrefix(con[1], "filetype:") {
+				fileType = strings.TrimPrefix(con[1], "filetype:")
+				continue
+			}
 			labelOpts = append(labelOpts, con[1])
 		case "apparmor":
 			container.AppArmorProfile = con[1]
@@ -233,7 +243,37 @@ func parseSecurityOpt(container *container.Container, config *containertypes.Hos
 	}
 
 	container.ProcessLabel, container.MountLabel, err = label.InitLabels(labelOpts)
-	return err
+	if err != nil {
+		return err
+	}
+	if fileType != "" && container.MountLabel != "" {
+		mountLabel := selinux.NewContext(container.MountLabel)
+		mountLabel["type"] = fileType
+		container.MountLabel = mountLabel.Get()
+	}
+	return nil
+}
+
+// defaultLabelOpts returns the default SELinux label options of the daemon,
+// as security options, for the label options a container does not set.
+func (daemon *Daemon) defaultLabelOpts(labelOpts []string) []string {
+	if daemon.configStore == nil {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, opt := range labelOpts {
+		if opt == "disable" {
+			return nil
+		}
+		set[strings.SplitN(opt, ":", 2)[0]] = true
+	}
+	var opts []string
+	for _, opt := range daemon.configStore.SELinuxLabelOpts {
+		if !set[strings.SplitN(opt, ":", 2)[0]] {
+			opts = append(opts, "label="+opt)
+		}
+	}
+	return opts
 }
 
 func getBlkioThrottleDevices(devs []*blkiodev.ThrottleDevice) ([]specs.LinuxThrottleDevice, error) {
@@ -312,6 +352,9 @@ func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConf
 		return err
 	}
 	hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, opts...)
+	if err := daemon.applyDeviceProfiles(hostConfig); err != nil {
+		return err
+	}
 	if hostConfig.OomKillDisable == nil {
 		defaultOomKillDisable := false
 		hostConfig.OomKillDisable = &defaultOomKillDisable
@@ -320,6 +363,29 @@ func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConf
 	return nil
 }
 
+// applyDeviceProfiles adds the devices, the device cgroup rules and the
+// groups of the device profiles of a container to its host config. The
+// changes of the profiles do not apply to the containers already created.
+func (daemon *Daemon) applyDeviceProfiles(hostConfig *containertypes.HostConfig) error {
+	if len(hostConfig.DeviceProfiles) == 0 {
+		return nil
+	}
+	var profiles map[string]config.DeviceProfile
+	if daemon.configStore != nil {
+		profiles = daemon.configStore.DeviceProfiles
+	}
+	for _, name := range hostConfig.DeviceProfiles {
+		p, ok := profiles[name]
+		if !ok {
+			return fmt.Errorf("unknown device profile: %s", name)
+		}
+		hostConfig.Devices = append(hostConfig.Devices, p.Devices...)
+		hostConfig.DeviceCgroupRules = append(hostConfig.DeviceCgroupRules, p.DeviceCgroupRules...)
+		hostConfig.GroupAdd = append(hostConfig.GroupAdd, p.GroupAdd...)
+	}
+	return nil
+}
+
 // adaptSharedNamespaceContainer replaces container name with its ID in hostConfig.
 // To be more precisely, it modifies `container:name` to `container:ID` of PidMode, IpcMode
 // and NetworkMode.
@@ -574,6 +640,41 @@ func UsingSystemd(config *config.Config) bool {
 
 // verifyPlatformContainerSettings performs platform-specific validation of the
 // hostconfig and config structures.
+// verifyAdjustedPaths validates the paths added to and removed from the
+// masked and read-only paths of a container.
+func verifyAdjustedPaths(hostConfig *containertypes.HostConfig) error {
+	for _, paths := range []struct {
+		kind        string
+		add, remove []string
+	}{
+		{"masked", hostConfig.MaskedPathsAdd, hostConfig.MaskedPathsRemove},
+		{"read-only", hostConfig.ReadonlyPathsAdd, hostConfig.ReadonlyPathsRemove},
+	} {
+		if len(paths.add) == 0 && len(paths.remove) == 0 {
+			continue
+		}
+		if hostConfig.Privileged {
+			return fmt.Errorf("conflicting options: the %s paths of privileged containers cannot be changed", paths.kind)
+		}
+		removed := make(map[string]bool)
+		for _, p := range paths.remove {
+			if err := config.ValidateSystemPath(p); err != nil {
+				return err
+			}
+			removed[p] = true
+		}
+		for _, p := range paths.add {
+			if err := config.ValidateSystemPath(p); err != nil {
+				return err
+			}
+			if removed[p] {
+				return fmt.Errorf("conflicting options: %s is both added to and removed from the %s paths", p, paths.kind)
+			}
+		}
+	}
+	return nil
+}
+
 func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) ([]string, error) {
 	var warnings []string
 	sysInfo := sysinfo.New(true)
@@ -595,6 +696,16 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 		return warnings, fmt.Errorf("Invalid value %d, range for oom score adj is [-1000, 1000]", hostConfig.OomScoreAdj)
 	}
 
+	if config != nil {
+		if _, err := selectHooks(daemon.configStore.Hooks, config.Labels); err != nil {
+			return warnings, err
+		}
+	}
+
+	if err := verifyAdjustedPaths(hostConfig); err != nil {
+		return warnings, err
+	}
+
 	// ip-forwarding does not affect container with '--net=host' (or '--net=none')
 	if sysInfo.IPv4ForwardingDisabled && !(hostConfig.NetworkMode.IsHost() || hostConfig.NetworkMode.IsNone()) {
 		warnings = append(warnings, "IPv4 forwarding is disabled. Networking will not work.")
@@ -627,10 +738,15 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 	}
 
 	parser := volumemounts.NewParser(runtime.GOOS)
-	for dest := range hostConfig.Tmpfs {
+	for dest, data := range hostConfig.Tmpfs {
 		if err := parser.ValidateTmpfsMountDestination(dest); err != nil {
 			return warnings, err
 		}
+		if data != "" {
+			if _, err := mount.MergeTmpfsOptions(strings.Split(data, ",")); err != nil {
+				return warnings, err
+			}
+		}
 	}
 
 	return warnings, nil
@@ -693,6 +809,12 @@ func verifyDaemonSettings(conf *config.Config) error {
 	if !conf.BridgeConfig.EnableIPTables && !conf.BridgeConfig.InterContainerCommunication {
 		return fmt.Errorf("You specified --iptables=false with --icc=false. ICC=false uses iptables to function. Please set --icc or --iptables to true")
 	}
+	if !conf.BridgeConfig.EnableIPTables && conf.BridgeConfig.EnableIP6Tables {
+		return fmt.Errorf("You specified --iptables=false with --ip6tables=true. Please set --iptables to true")
+	}
+	if err := iptables.ValidateFirewallBackend(iptables.FirewallBackend(conf.BridgeConfig.FirewallBackend)); err != nil {
+		return err
+	}
 	if !conf.BridgeConfig.EnableIPTables && conf.BridgeConfig.EnableIPMasq {
 		conf.BridgeConfig.EnableIPMasq = false
 	}
@@ -726,6 +848,15 @@ func checkSystem() error {
 
 // configureMaxThreads sets the Go runtime max threads threshold
 // which is 90% of the kernel setting from /proc/sys/kernel/threads-max
+// defaultNetworkMtu returns the MTU of the interface of the default route,
+// or the default network MTU if it cannot be determined.
+func defaultNetworkMtu() int {
+	if mtu, err := netutils.DefaultRouteMTU(); err == nil && mtu > 0 {
+		return mtu
+	}
+	return config.DefaultNetworkMtu
+}
+
 func configureMaxThreads(config *config.Config) error {
 	mt, err := ioutil.ReadFile("/proc/sys/kernel/threads-max")
 	if err != nil {
@@ -799,6 +930,12 @@ func configureKernelSecuritySupport(config *config.Config, driverName string) er
 }
 
 func (daemon *Daemon) initNetworkController(config *config.Config, activeSandboxes map[string]interface{}) (libnetwork.NetworkController, error) {
+	// The firewall backend is selected before the network controller
+	// programs any rule.
+	if err := iptables.SetFirewallBackend(iptables.FirewallBackend(config.BridgeConfig.FirewallBackend)); err != nil {
+		return nil, err
+	}
+
 	netOptions, err := daemon.networkOptions(config, daemon.PluginStore, activeSandboxes)
 	if err != nil {
 		return nil, err
@@ -854,8 +991,12 @@ func driverOptions(config *config.Config) []nwconfig.Option {
 	bridgeConfig := options.Generic{
 		"EnableIPForwarding":  config.BridgeConfig.EnableIPForward,
 		"EnableIPTables":      config.BridgeConfig.EnableIPTables,
+		"EnableIP6Tables":     config.BridgeConfig.EnableIP6Tables,
 		"EnableUserlandProxy": config.BridgeConfig.EnableUserlandProxy,
-		"UserlandProxyPath":   config.BridgeConfig.UserlandProxyPath}
+		"UserlandProxyPath":   config.BridgeConfig.UserlandProxyPath,
+		"UserlandProxyMode":   config.BridgeConfig.UserlandProxyMode,
+		"DynamicPortRange":    config.BridgeConfig.DynamicPortRange,
+		"PortMapper":          config.BridgeConfig.PortMapper}
 	bridgeOption := options.Generic{netlabel.GenericData: bridgeConfig}
 
 	dOptions := []nwconfig.Option{}
@@ -1396,6 +1537,8 @@ func (daemon *Daemon) stats(c *container.Container) (*types.StatsJSON, error) {
 		}
 	}
 
+	s.TmpfsStats = getTmpfsStats(c)
+
 	return s, nil
 }
 
@@ -1517,6 +1660,9 @@ func (daemon *Daemon) setupSeccompProfile() error {
 		if err != nil {
 			return fmt.Errorf("opening seccomp profile (%s) failed: %v", daemon.configStore.SeccompProfile, err)
 		}
+		if b, err = resolveSeccompProfile(daemon.configStore.SeccompProfile, b); err != nil {
+			return fmt.Errorf("loading seccomp profile (%s) failed: %v", daemon.configStore.SeccompProfile, err)
+		}
 		daemon.seccompProfile = b
 	}
 	return nil
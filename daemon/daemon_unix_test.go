@@ -6,11 +6,13 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/config"
+	"github.com/opencontainers/selinux/go-selinux/label"
 )
 
 type fakeContainerGetter struct {
@@ -266,3 +268,127 @@ func TestNetworkOptions(t *testing.T) {
 		t.Fatal("Expected networkOptions error, got nil")
 	}
 }
+
+func TestParseSecurityOptInvalidLabel(t *testing.T) {
+	container := &container.Container{}
+	config := &containertypes.HostConfig{SecurityOpt: []string{"label=level:s0:c1,c2", "label=filetype:svirt_sandbox_file_t"}}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+
+	config.SecurityOpt = []string{"label=level:c1,c2"}
+	if err := parseSecurityOpt(container, config); err == nil || !strings.Contains(err.Error(), `invalid SELinux level "c1,c2"`) {
+		t.Fatalf("Expected an invalid SELinux level error, got %v", err)
+	}
+}
+
+func TestGenerateSecurityOptDefaultLabels(t *testing.T) {
+	daemon := &Daemon{
+		configStore: &config.Config{SELinuxLabelOpts: []string{"type:svirt_apache_t", "level:s0:c100,c200"}},
+	}
+	testCases := []struct {
+		securityOpt []string
+		privileged  bool
+		expected    []string
+	}{
+		{
+			expected: []string{"label=type:svirt_apache_t", "label=level:s0:c100,c200"},
+		},
+		{
+			securityOpt: []string{"label=level:s0:c1,c2", "no-new-privileges"},
+			expected:    []string{"label=type:svirt_apache_t"},
+		},
+		{
+			securityOpt: []string{"label=disable"},
+		},
+		{
+			privileged: true,
+			expected:   toHostConfigSelinuxLabels(label.DisableSecOpt()),
+		},
+	}
+	for _, tc := range testCases {
+		opts, err := daemon.generateSecurityOpt(&containertypes.HostConfig{SecurityOpt: tc.securityOpt, Privileged: tc.privileged})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(opts, " ") != strings.Join(tc.expected, " ") {
+			t.Fatalf("Expected security options %v for %v, got %v", tc.expected, tc.securityOpt, opts)
+		}
+	}
+}
+
+func TestApplyDeviceProfiles(t *testing.T) {
+	daemon := &Daemon{
+		configStore: &config.Config{DeviceProfiles: map[string]config.DeviceProfile{
+			"gpu-inference": {
+				Devices:           []containertypes.DeviceMapping{{PathOnHost: "/dev/nvidia0", PathInContainer: "/dev/nvidia0", CgroupPermissions: "rwm"}},
+				DeviceCgroupRules: []string{"c 195:* rwm"},
+				GroupAdd:          []string{"video"},
+			},
+		}},
+	}
+	hostConfig := &containertypes.HostConfig{
+		DeviceProfiles: []string{"gpu-inference"},
+		GroupAdd:       []string{"audio"},
+	}
+	if err := daemon.applyDeviceProfiles(hostConfig); err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.Devices) != 1 || hostConfig.Devices[0].PathOnHost != "/dev/nvidia0" {
+		t.Fatalf("Expected the devices of the profile, got %v", hostConfig.Devices)
+	}
+	if strings.Join(hostConfig.DeviceCgroupRules, ",") != "c 195:* rwm" {
+		t.Fatalf("Expected the device cgroup rules of the profile, got %v", hostConfig.DeviceCgroupRules)
+	}
+	if strings.Join(hostConfig.GroupAdd, ",") != "audio,video" {
+		t.Fatalf("Expected the groups of the container and the profile, got %v", hostConfig.GroupAdd)
+	}
+
+	err := daemon.applyDeviceProfiles(&containertypes.HostConfig{DeviceProfiles: []string{"fpga"}})
+	if err == nil || err.Error() != "unknown device profile: fpga" {
+		t.Fatalf("Expected an unknown device profile error, got %v", err)
+	}
+}
+
+func TestAdjustPaths(t *testing.T) {
+	paths := adjustPaths([]string{"/proc/kcore", "/proc/keys"}, []string{"/sys/kernel/debug", "/proc/kcore"}, []string{"/proc/keys", "/proc/acpi"})
+	if strings.Join(paths, ",") != "/proc/kcore,/sys/kernel/debug" {
+		t.Fatalf("Expected the adjusted paths, got %v", paths)
+	}
+	if paths := adjustPaths([]string{"/proc/kcore"}, nil, []string{"/proc/kcore"}); paths == nil || len(paths) != 0 {
+		t.Fatalf("Expected no path, got %v", paths)
+	}
+}
+
+func TestVerifyAdjustedPaths(t *testing.T) {
+	testCases := []struct {
+		hostConfig  containertypes.HostConfig
+		expectedErr string
+	}{
+		{
+			hostConfig: containertypes.HostConfig{MaskedPathsAdd: []string{"/sys/kernel/debug"}, ReadonlyPathsRemove: []string{"/proc/sys"}},
+		},
+		{
+			hostConfig:  containertypes.HostConfig{MaskedPathsAdd: []string{"sys/kernel/debug"}},
+			expectedErr: `invalid path "sys/kernel/debug"`,
+		},
+		{
+			hostConfig:  containertypes.HostConfig{ReadonlyPathsAdd: []string{"/proc/sys"}, ReadonlyPathsRemove: []string{"/proc/sys"}},
+			expectedErr: "conflicting options: /proc/sys is both added to and removed from the read-only paths",
+		},
+		{
+			hostConfig:  containertypes.HostConfig{Privileged: true, MaskedPathsRemove: []string{"/proc/kcore"}},
+			expectedErr: "conflicting options: the masked paths of privileged containers cannot be changed",
+		},
+	}
+	for _, tc := range testCases {
+		err := verifyAdjustedPaths(&tc.hostConfig)
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Expected no error for %+v, got %v", tc.hostConfig, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+			t.Fatalf("Expected error %q for %+v, got %v", tc.expectedErr, tc.hostConfig, err)
+		}
+	}
+}
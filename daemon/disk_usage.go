@@ -3,27 +3,64 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"context"
 	"fmt"
-	"sync/atomic"
+	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/sirupsen/logrus"
 )
 
-// SystemDiskUsage returns information about the daemon data disk usage
+const (
+	// diskUsageRefreshInterval is the interval the cached sizes which may
+	// have changed are calculated again at, once the disk usage has been
+	// requested.
+	diskUsageRefreshInterval = 5 * time.Minute
+	// diskUsageMaxAge is the age after which the cached sizes which are not
+	// expected to have changed, such as those of the stopped containers and
+	// of the unused volumes, are calculated again, for the changes made
+	// outside of the daemon to be accounted for.
+	diskUsageMaxAge = time.Hour
+)
+
+// diskUsageCache holds the sizes of the writable layers of the containers,
+// and of the local volumes, which are slow to calculate.
+type diskUsageCache struct {
+	sync.Mutex
+	requested  bool
+	containers map[string]cachedSize
+	volumes    map[string]cachedSize
+}
+
+// cachedSize is a size, and the time it was calculated at.
+type cachedSize struct {
+	size       int64
+	rootFsSize int64
+	at         time.Time
+}
+
+func (s cachedSize) expired(now time.Time) bool {
+	return now.Sub(s.at) >= diskUsageMaxAge
+}
+
+// SystemDiskUsage returns information about the daemon data disk usage. The
+// sizes of the writable layers of the containers, and of the volumes, are
+// served from the cache, only the sizes which are not cached yet being
+// calculated.
 func (daemon *Daemon) SystemDiskUsage(ctx context.Context) (*types.DiskUsage, error) {
-	if !atomic.CompareAndSwapInt32(&daemon.diskUsageRunning, 0, 1) {
-		return nil, fmt.Errorf("a disk usage operation is already running")
-	}
-	defer atomic.StoreInt32(&daemon.diskUsageRunning, 0)
+	daemon.diskUsage.Lock()
+	daemon.diskUsage.requested = true
+	daemon.diskUsage.Unlock()
 
 	// Retrieve container list
 	allContainers, err := daemon.Containers(&types.ContainerListOptions{
-		Size: true,
-		All:  true,
+		All: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve container list: %v", err)
 	}
+	updatedAt := daemon.containersSize(ctx, allContainers, false)
 
 	// Get all top images with extra attributes
 	allImages, err := daemon.imageService.Images(filters.NewArgs(), false, true)
@@ -31,10 +68,13 @@ func (daemon *Daemon) SystemDiskUsage(ctx context.Context) (*types.DiskUsage, er
 		return nil, fmt.Errorf("failed to retrieve image list: %v", err)
 	}
 
-	localVolumes, err := daemon.volumes.LocalVolumesSize(ctx)
+	localVolumes, volumesUpdatedAt, err := daemon.localVolumesSize(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	if volumesUpdatedAt.Before(updatedAt) {
+		updatedAt = volumesUpdatedAt
+	}
 
 	allLayersSize, err := daemon.imageService.LayerDiskUsage(ctx)
 	if err != nil {
@@ -46,5 +86,139 @@ func (daemon *Daemon) SystemDiskUsage(ctx context.Context) (*types.DiskUsage, er
 		Containers: allContainers,
 		Volumes:    localVolumes,
 		Images:     allImages,
+		UpdatedAt:  updatedAt,
 	}, nil
 }
+
+// containersSize sets the sizes of the containers from the cache, calculating
+// those which are not cached. With refresh, the sizes which may have changed
+// are calculated again: those of the running containers, of the containers
+// which ran since their size was calculated, and the expired ones. It returns
+// the time the oldest of the sizes was calculated at.
+func (daemon *Daemon) containersSize(ctx context.Context, containers []*types.Container, refresh bool) time.Time {
+	now := time.Now()
+	updatedAt := now
+	sizes := make(map[string]cachedSize, len(containers))
+	for _, c := range containers {
+		if ctx.Err() != nil {
+			return updatedAt
+		}
+		daemon.diskUsage.Lock()
+		s, ok := daemon.diskUsage.containers[c.ID]
+		daemon.diskUsage.Unlock()
+		if !ok || refresh && (s.expired(now) || daemon.ranSince(c.ID, s.at)) {
+			s.size, s.rootFsSize = daemon.imageService.GetContainerLayerSize(c.ID)
+			s.at = now
+		}
+		c.SizeRw, c.SizeRootFs = s.size, s.rootFsSize
+		sizes[c.ID] = s
+		if s.at.Before(updatedAt) {
+			updatedAt = s.at
+		}
+	}
+	// the removed containers are not in the list any more
+	daemon.diskUsage.Lock()
+	daemon.diskUsage.containers = sizes
+	daemon.diskUsage.Unlock()
+	return updatedAt
+}
+
+// ranSince returns whether the container is running, or stopped after t.
+func (daemon *Daemon) ranSince(id string, t time.Time) bool {
+	c := daemon.containers.Get(id)
+	if c == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	return c.Running || c.FinishedAt.After(t)
+}
+
+// localVolumesSize returns the local volumes with their sizes from the
+// cache, calculating those which are not cached. The sizes of the volumes of
+// inUse, and the expired ones, are calculated again. It returns the time the
+// oldest of the sizes was calculated at.
+func (daemon *Daemon) localVolumesSize(ctx context.Context, inUse map[string]bool) ([]*types.Volume, time.Time, error) {
+	now := time.Now()
+	daemon.diskUsage.Lock()
+	cached := daemon.diskUsage.volumes
+	daemon.diskUsage.Unlock()
+
+	sizes := make(map[string]cachedSize)
+	volumes, err := daemon.volumes.LocalVolumesCachedSize(ctx, func(name string) (int64, bool) {
+		s, ok := cached[name]
+		if !ok || inUse[name] || s.expired(now) {
+			return 0, false
+		}
+		sizes[name] = s
+		return s.size, true
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	updatedAt := now
+	for _, v := range volumes {
+		if s, ok := sizes[v.Name]; ok {
+			if s.at.Before(updatedAt) {
+				updatedAt = s.at
+			}
+			continue
+		}
+		// the sizes which failed to be calculated are not cached
+		if v.UsageData != nil && v.UsageData.Size >= 0 {
+			sizes[v.Name] = cachedSize{size: v.UsageData.Size, at: now}
+		}
+	}
+	daemon.diskUsage.Lock()
+	daemon.diskUsage.volumes = sizes
+	daemon.diskUsage.Unlock()
+	return volumes, updatedAt, nil
+}
+
+// refreshDiskUsage calculates again the cached sizes which may have changed.
+func (daemon *Daemon) refreshDiskUsage(ctx context.Context) error {
+	allContainers, err := daemon.Containers(&types.ContainerListOptions{
+		All: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve container list: %v", err)
+	}
+	daemon.containersSize(ctx, allContainers, true)
+
+	// the volumes of the running containers may be written to
+	inUse := make(map[string]bool)
+	for _, c := range allContainers {
+		if c.State != "running" && c.State != "paused" && c.State != "restarting" {
+			continue
+		}
+		for _, m := range c.Mounts {
+			if m.Type == mount.TypeVolume && m.Name != "" {
+				inUse[m.Name] = true
+			}
+		}
+	}
+	_, _, err = daemon.localVolumesSize(ctx, inUse)
+	return err
+}
+
+// diskUsageRefresher runs a loop refreshing the cached sizes of the disk
+// usage, once it has been requested.
+func (daemon *Daemon) diskUsageRefresher() {
+	for {
+		time.Sleep(diskUsageRefreshInterval)
+
+		if daemon.IsShuttingDown() {
+			return
+		}
+		daemon.diskUsage.Lock()
+		requested := daemon.diskUsage.requested
+		daemon.diskUsage.Unlock()
+		if !requested {
+			continue
+		}
+		if err := daemon.refreshDiskUsage(context.Background()); err != nil {
+			logrus.WithError(err).Warn("failed to refresh the disk usage")
+		}
+	}
+}
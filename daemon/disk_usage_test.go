@@ -0,0 +1,58 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/volume"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestLocalVolumesSizeCache(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-disk-usage-test-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tmp)
+	d, err := initDaemonWithVolumeStore(tmp)
+	assert.NilError(t, err)
+	d.EventsService = events.New()
+
+	ctx := context.Background()
+	v, err := d.volumes.Create(ctx, "test", volume.DefaultDriverName)
+	assert.NilError(t, err)
+	err = ioutil.WriteFile(filepath.Join(v.Mountpoint, "data"), make([]byte, 1024), 0644)
+	assert.NilError(t, err)
+
+	volumeSize := func(inUse map[string]bool) (int64, time.Time) {
+		ls, updatedAt, err := d.localVolumesSize(ctx, inUse)
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(ls, 1))
+		return ls[0].UsageData.Size, updatedAt
+	}
+
+	size, updatedAt := volumeSize(nil)
+	assert.Check(t, is.Equal(size, int64(1024)))
+
+	err = ioutil.WriteFile(filepath.Join(v.Mountpoint, "data"), make([]byte, 2048), 0644)
+	assert.NilError(t, err)
+	size, cachedAt := volumeSize(nil)
+	assert.Check(t, is.Equal(size, int64(1024)), "expected the size to be served from the cache")
+	assert.Check(t, cachedAt.Equal(updatedAt))
+
+	size, _ = volumeSize(map[string]bool{"test": true})
+	assert.Check(t, is.Equal(size, int64(2048)), "expected the size of a volume in use to be calculated again")
+
+	d.diskUsage.volumes["test"] = cachedSize{size: 1, at: time.Now().Add(-diskUsageMaxAge)}
+	size, _ = volumeSize(nil)
+	assert.Check(t, is.Equal(size, int64(2048)), "expected an expired size to be calculated again")
+
+	assert.NilError(t, d.volumes.Remove(ctx, "test"))
+	_, _, err = d.localVolumesSize(ctx, nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(d.diskUsage.volumes, 0))
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/container"
 	daemonevents "github.com/docker/docker/daemon/events"
-	"github.com/docker/libnetwork"
+	"github.com/docker/docker/libnetwork"
 	swarmapi "github.com/docker/swarmkit/api"
 	gogotypes "github.com/gogo/protobuf/types"
 	"github.com/sirupsen/logrus"
@@ -104,6 +104,13 @@ func (daemon *Daemon) SubscribeToEvents(since, until time.Time, filter filters.A
 	return daemon.EventsService.SubscribeTopic(since, until, ef)
 }
 
+// SubscribeToEventsAfter is like SubscribeToEvents, the events returned being
+// the events after the one with the sequence number seq, until until.
+func (daemon *Daemon) SubscribeToEventsAfter(seq uint64, until time.Time, filter filters.Args) ([]events.Message, chan interface{}) {
+	ef := daemonevents.NewFilter(filter)
+	return daemon.EventsService.SubscribeTopicAfter(seq, until, ef)
+}
+
 // UnsubscribeFromEvents stops the event subscription for a client by closing the
 // channel where the daemon sends events to.
 func (daemon *Daemon) UnsubscribeFromEvents(listener chan interface{}) {
@@ -6,6 +6,7 @@ import (
 
 	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/pkg/pubsub"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -18,6 +19,15 @@ type Events struct {
 	mu     sync.Mutex
 	events []eventtypes.Message
 	pub    *pubsub.Publisher
+	// seq is the sequence number of the last event.
+	seq uint64
+
+	// log is the event log the events are persisted to, nil when they are
+	// not persisted.
+	log *eventLog
+	// logFailing is whether the last event could not be persisted, for the
+	// errors to be logged once for the events failing in a row.
+	logFailing bool
 }
 
 // New returns new *Events instance
@@ -28,6 +38,22 @@ func New() *Events {
 	}
 }
 
+// NewWithLog returns new *Events instance persisting the events to the event
+// log at path, of up to size bytes, for them to be replayed across the
+// restarts of the daemon. The events older than retention are not replayed,
+// unless it is 0. The sequence numbers of the events follow the ones of the
+// events of the log.
+func NewWithLog(path string, size int64, retention time.Duration) (*Events, error) {
+	l, seq, err := openEventLog(path, size, retention)
+	if err != nil {
+		return nil, err
+	}
+	e := New()
+	e.log = l
+	e.seq = seq
+	return e, nil
+}
+
 // Subscribe adds new listener to events, returns slice of 256 stored
 // last events, a channel in which you can expect new events (in form
 // of interface{}, so you need type assertion), and a function to call
@@ -50,6 +76,16 @@ func (e *Events) Subscribe() ([]eventtypes.Message, chan interface{}, func()) {
 // last events, a channel in which you can expect new events (in form
 // of interface{}, so you need type assertion).
 func (e *Events) SubscribeTopic(since, until time.Time, ef *Filter) ([]eventtypes.Message, chan interface{}) {
+	return e.subscribeTopic(since, until, 0, ef)
+}
+
+// SubscribeTopicAfter is like SubscribeTopic, the events returned being the
+// events after the one with the sequence number seq, until until.
+func (e *Events) SubscribeTopicAfter(seq uint64, until time.Time, ef *Filter) ([]eventtypes.Message, chan interface{}) {
+	return e.subscribeTopic(time.Time{}, until, seq, ef)
+}
+
+func (e *Events) subscribeTopic(since, until time.Time, afterSeq uint64, ef *Filter) ([]eventtypes.Message, chan interface{}) {
 	eventSubscribers.Inc()
 	e.mu.Lock()
 
@@ -58,7 +94,7 @@ func (e *Events) SubscribeTopic(since, until time.Time, ef *Filter) ([]eventtype
 		topic = func(m interface{}) bool { return ef.Include(m.(eventtypes.Message)) }
 	}
 
-	buffered := e.loadBufferedEvents(since, until, topic)
+	buffered := e.loadBufferedEvents(since, until, afterSeq, topic)
 
 	var ch chan interface{}
 	if topic != nil {
@@ -110,6 +146,8 @@ func (e *Events) PublishMessage(jm eventtypes.Message) {
 	eventsCounter.Inc()
 
 	e.mu.Lock()
+	e.seq++
+	jm.Seq = e.seq
 	if len(e.events) == cap(e.events) {
 		// discard oldest event
 		copy(e.events, e.events[1:])
@@ -117,6 +155,16 @@ func (e *Events) PublishMessage(jm eventtypes.Message) {
 	} else {
 		e.events = append(e.events, jm)
 	}
+	if e.log != nil {
+		if err := e.log.append(jm); err != nil {
+			if !e.logFailing {
+				logrus.WithError(err).Error("error persisting the events to the event log")
+				e.logFailing = true
+			}
+		} else {
+			e.logFailing = false
+		}
+	}
 	e.mu.Unlock()
 	e.pub.Publish(jm)
 }
@@ -127,12 +175,14 @@ func (e *Events) SubscribersCount() int {
 }
 
 // loadBufferedEvents iterates over the cached events in the buffer
-// and returns those that were emitted between two specific dates.
+// and returns those that were emitted between two specific dates,
+// after the event with the sequence number afterSeq if it is not 0.
 // It uses `time.Unix(seconds, nanoseconds)` to generate valid dates with those arguments.
 // It filters those buffered messages with a topic function if it's not nil, otherwise it adds all messages.
-func (e *Events) loadBufferedEvents(since, until time.Time, topic func(interface{}) bool) []eventtypes.Message {
+// The events are loaded from the event log when the events are persisted.
+func (e *Events) loadBufferedEvents(since, until time.Time, afterSeq uint64, topic func(interface{}) bool) []eventtypes.Message {
 	var buffered []eventtypes.Message
-	if since.IsZero() && until.IsZero() {
+	if since.IsZero() && until.IsZero() && afterSeq == 0 {
 		return buffered
 	}
 
@@ -146,10 +196,29 @@ func (e *Events) loadBufferedEvents(since, until time.Time, topic func(interface
 		untilNanoUnix = until.UnixNano()
 	}
 
+	if e.log != nil {
+		err := e.log.walk(func(ev eventtypes.Message) {
+			if ev.TimeNano < sinceNanoUnix || (afterSeq > 0 && ev.Seq <= afterSeq) {
+				return
+			}
+			if untilNanoUnix > 0 && ev.TimeNano > untilNanoUnix {
+				return
+			}
+			if topic == nil || topic(ev) {
+				buffered = append(buffered, ev)
+			}
+		}, true)
+		if err == nil {
+			return buffered
+		}
+		logrus.WithError(err).Error("error loading the events of the event log, loading the buffered events")
+		buffered = nil
+	}
+
 	for i := len(e.events) - 1; i >= 0; i-- {
 		ev := e.events[i]
 
-		if ev.TimeNano < sinceNanoUnix {
+		if ev.TimeNano < sinceNanoUnix || (afterSeq > 0 && ev.Seq <= afterSeq) {
 			break
 		}
 
@@ -197,7 +197,7 @@ func TestLoadBufferedEvents(t *testing.T) {
 	since := time.Unix(s, sNano)
 	until := time.Time{}
 
-	out := events.loadBufferedEvents(since, until, nil)
+	out := events.loadBufferedEvents(since, until, 0, nil)
 	if len(out) != 1 {
 		t.Fatalf("expected 1 message, got %d: %v", len(out), out)
 	}
@@ -243,7 +243,7 @@ func TestLoadBufferedEventsOnlyFromPast(t *testing.T) {
 	since := time.Unix(s, sNano)
 	until := time.Unix(u, uNano)
 
-	out := events.loadBufferedEvents(since, until, nil)
+	out := events.loadBufferedEvents(since, until, 0, nil)
 	if len(out) != 1 {
 		t.Fatalf("expected 1 message, got %d: %v", len(out), out)
 	}
@@ -275,7 +275,7 @@ func TestIgnoreBufferedWhenNoTimes(t *testing.T) {
 	since := time.Time{}
 	until := time.Time{}
 
-	out := events.loadBufferedEvents(since, until, nil)
+	out := events.loadBufferedEvents(since, until, 0, nil)
 	if len(out) != 0 {
 		t.Fatalf("expected 0 buffered events, got %q", out)
 	}
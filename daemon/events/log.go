@@ -0,0 +1,127 @@
+package events // import "github.com/docker/docker/daemon/events"
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// eventLog is a bounded log of the events on disk, for the events to be
+// replayed across the restarts of the daemon. The events are appended to a
+// file, one JSON-encoded event per line, which is rotated once it reaches
+// half of the size of the log, the events of the previous file being
+// dropped.
+type eventLog struct {
+	path string
+	// maxSize is the maximum size of each of the two files.
+	maxSize int64
+	// retention is how long the events are replayed for, or 0 for as long
+	// as they are in the log.
+	retention time.Duration
+	// size is the size of the current file.
+	size int64
+}
+
+// openEventLog opens the event log stored at path, and returns the sequence
+// number of its last event.
+func openEventLog(path string, size int64, retention time.Duration) (*eventLog, uint64, error) {
+	l := &eventLog{path: path, maxSize: size / 2, retention: retention}
+	fi, err := os.Stat(path)
+	switch {
+	case err == nil:
+		l.size = fi.Size()
+	case !os.IsNotExist(err):
+		return nil, 0, errors.Wrap(err, "error opening event log")
+	}
+
+	var seq uint64
+	err = l.walk(func(ev eventtypes.Message) {
+		if ev.Seq > seq {
+			seq = ev.Seq
+		}
+	}, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	return l, seq, nil
+}
+
+// append appends an event to the log, rotating the file first if the event
+// does not fit in it.
+func (l *eventLog) append(ev eventtypes.Message) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	if l.size > 0 && l.size+int64(len(b)) > l.maxSize {
+		if err := os.Rename(l.path, l.path+".1"); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "error rotating event log")
+		}
+		l.size = 0
+	}
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error opening event log")
+	}
+	defer f.Close()
+
+	n, err := f.Write(b)
+	l.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "error writing event log")
+	}
+	return nil
+}
+
+// walk calls fn with the events of the log, from the oldest, skipping the
+// events older than the retention when retained is set.
+func (l *eventLog) walk(fn func(eventtypes.Message), retained bool) error {
+	var oldest int64
+	if retained && l.retention > 0 {
+		oldest = time.Now().Add(-l.retention).UnixNano()
+	}
+	for _, path := range []string{l.path + ".1", l.path} {
+		if err := walkEventLogFile(path, oldest, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkEventLogFile(path string, oldest int64, fn func(eventtypes.Message)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "error opening event log")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var ev eventtypes.Message
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			// the last event may have been partially written when the
+			// daemon stopped
+			logrus.WithError(err).WithField("path", path).Warn("skipping invalid event in event log")
+			continue
+		}
+		if ev.TimeNano >= oldest {
+			fn(ev)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "error reading event log")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package events // import "github.com/docker/docker/daemon/events"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+func TestEventLogReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-log-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.log")
+
+	e, err := NewWithLog(path, 1024*1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		e.Log("create", eventtypes.ContainerEventType, eventtypes.Actor{ID: id})
+	}
+
+	// the events are replayed by the events of the restarted daemon, whose
+	// sequence numbers follow them
+	e, err = NewWithLog(path, 1024*1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Log("create", eventtypes.ContainerEventType, eventtypes.Actor{ID: "4"})
+
+	buffered, l := e.SubscribeTopic(time.Unix(0, 0), time.Time{}, nil)
+	defer e.Evict(l)
+	if len(buffered) != 4 {
+		t.Fatalf("expected 4 events, got %d: %v", len(buffered), buffered)
+	}
+	for i, ev := range buffered {
+		if ev.Seq != uint64(i+1) {
+			t.Fatalf("expected the sequence number of event %d to be %d, got %d", i, i+1, ev.Seq)
+		}
+	}
+
+	after, l2 := e.SubscribeTopicAfter(2, time.Time{}, nil)
+	defer e.Evict(l2)
+	if len(after) != 2 || after[0].Actor.ID != "3" || after[1].Actor.ID != "4" {
+		t.Fatalf("expected the events 3 and 4, got %v", after)
+	}
+}
+
+func TestEventLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-log-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.log")
+
+	// each file holds a few events
+	e, err := NewWithLog(path, 1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		e.Log("create", eventtypes.ContainerEventType, eventtypes.Actor{ID: "container"})
+	}
+	for _, p := range []string{path, path + ".1"} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > 512 {
+			t.Fatalf("expected %s to be at most 512 bytes, got %d", p, fi.Size())
+		}
+	}
+
+	buffered := e.loadBufferedEvents(time.Unix(0, 0), time.Time{}, 0, nil)
+	if len(buffered) == 0 || len(buffered) >= 50 {
+		t.Fatalf("expected the oldest events to be dropped, got %d events", len(buffered))
+	}
+	if last := buffered[len(buffered)-1]; last.Seq != 50 {
+		t.Fatalf("expected the last event to be replayed, got %v", last)
+	}
+}
+
+func TestEventLogRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-log-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.log")
+
+	e, err := NewWithLog(path, 1024*1024, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	e.PublishMessage(eventtypes.Message{Type: eventtypes.ContainerEventType, Action: "create", Time: old.Unix(), TimeNano: old.UnixNano()})
+	e.Log("start", eventtypes.ContainerEventType, eventtypes.Actor{ID: "container"})
+
+	buffered := e.loadBufferedEvents(time.Unix(0, 0), time.Time{}, 0, nil)
+	if len(buffered) != 1 || buffered[0].Action != "start" {
+		t.Fatalf("expected the events older than the retention not to be replayed, got %v", buffered)
+	}
+}
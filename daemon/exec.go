@@ -13,6 +13,7 @@ import (
 	"github.com/docker/docker/container/stream"
 	"github.com/docker/docker/daemon/exec"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/pools"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/term"
@@ -172,6 +173,8 @@ func (d *Daemon) ContainerExecStart(ctx context.Context, name string, stdin io.R
 		return errdefs.Conflict(fmt.Errorf("Error: Exec command %s is already running", ec.ID))
 	}
 	ec.Running = true
+	ec.StartedAt = time.Now().UTC()
+	ec.Identity, ec.AuthNMethod = authorization.UserFromContext(ctx)
 	ec.Unlock()
 
 	c := d.containers.Get(ec.ContainerID)
@@ -190,6 +193,7 @@ func (d *Daemon) ContainerExecStart(ctx context.Context, name string, stdin io.R
 			if err := ec.CloseStreams(); err != nil {
 				logrus.Errorf("failed to cleanup exec %s streams: %s", c.ID, err)
 			}
+			d.auditExec(c, ec)
 			ec.Unlock()
 			c.ExecCommands.Delete(ec.ID, ec.Pid)
 		}
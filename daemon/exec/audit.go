@@ -0,0 +1,78 @@
+package exec // import "github.com/docker/docker/daemon/exec"
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// AuditLog is an append-only log of the exec sessions run by the daemon.
+// Entries are stored in a file, one JSON-encoded entry per line.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewAuditLog returns an audit log storing its entries at the given path.
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Add appends an entry to the audit log.
+func (l *AuditLog) Add(entry types.ExecAuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error opening exec audit log")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "error writing exec audit log")
+	}
+	return f.Sync()
+}
+
+// List returns the entries of the audit log for which the filter
+// returns true, in the order they were added.
+func (l *AuditLog) List(filter func(types.ExecAuditEntry) bool) ([]types.ExecAuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []types.ExecAuditEntry{}
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, errors.Wrap(err, "error opening exec audit log")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry types.ExecAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrap(err, "error reading exec audit log")
+		}
+		if filter == nil || filter(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading exec audit log")
+	}
+	return entries, nil
+}
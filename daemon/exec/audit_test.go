@@ -0,0 +1,41 @@
+package exec // import "github.com/docker/docker/daemon/exec"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec-audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	l := NewAuditLog(filepath.Join(dir, "exec-audit.log"))
+
+	entries, err := l.List(nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 0))
+
+	assert.NilError(t, l.Add(types.ExecAuditEntry{ID: "1", Identity: "alice", Cmd: []string{"sh"}}))
+	assert.NilError(t, l.Add(types.ExecAuditEntry{ID: "2", Identity: "bob", ExitCode: 1}))
+
+	entries, err = l.List(nil)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(entries, 2))
+	assert.Check(t, is.Equal(entries[0].ID, "1"))
+	assert.Check(t, is.DeepEqual(entries[0].Cmd, []string{"sh"}))
+	assert.Check(t, is.Equal(entries[1].ExitCode, 1))
+
+	entries, err = l.List(func(e types.ExecAuditEntry) bool {
+		return e.Identity == "bob"
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(entries, 1))
+	assert.Check(t, is.Equal(entries[0].ID, "2"))
+}
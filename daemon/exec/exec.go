@@ -3,6 +3,7 @@ package exec // import "github.com/docker/docker/daemon/exec"
 import (
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/containerd/containerd/cio"
 	"github.com/docker/docker/container/stream"
@@ -34,6 +35,12 @@ type Config struct {
 	WorkingDir   string
 	Env          []string
 	Pid          int
+	StartedAt    time.Time
+
+	// Identity and AuthNMethod identify the API client that started the
+	// exec, and are recorded in the exec audit log.
+	Identity    string
+	AuthNMethod string
 }
 
 // NewConfig initializes the a new exec configuration
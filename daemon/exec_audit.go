@@ -0,0 +1,123 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/exec"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var execAuditAcceptedFilters = map[string]bool{
+	"container": true,
+	"identity":  true,
+	"since":     true,
+	"until":     true,
+}
+
+// auditExec records the exec session in the exec audit log, and emits an
+// exec_audit event if configured. The caller must hold the lock on ec.
+func (d *Daemon) auditExec(c *container.Container, ec *exec.Config) {
+	if d.execAudit == nil {
+		return
+	}
+
+	entry := types.ExecAuditEntry{
+		ID:            ec.ID,
+		ContainerID:   c.ID,
+		ContainerName: strings.TrimPrefix(c.Name, "/"),
+		Identity:      ec.Identity,
+		AuthNMethod:   ec.AuthNMethod,
+		Cmd:           append([]string{ec.Entrypoint}, ec.Args...),
+		User:          ec.User,
+		Privileged:    ec.Privileged,
+		Tty:           ec.Tty,
+		StartedAt:     ec.StartedAt,
+		FinishedAt:    time.Now().UTC(),
+	}
+	if ec.ExitCode != nil {
+		entry.ExitCode = *ec.ExitCode
+	}
+	if err := d.execAudit.Add(entry); err != nil {
+		logrus.WithError(err).WithField("exec-id", ec.ID).Error("failed to record exec in audit log")
+	}
+
+	if d.configStore.ExecAuditEvents {
+		attributes := map[string]string{
+			"execID":      entry.ID,
+			"identity":    entry.Identity,
+			"authNMethod": entry.AuthNMethod,
+			"cmd":         strings.Join(entry.Cmd, " "),
+			"user":        entry.User,
+			"privileged":  strconv.FormatBool(entry.Privileged),
+			"tty":         strconv.FormatBool(entry.Tty),
+			"startedAt":   entry.StartedAt.Format(time.RFC3339Nano),
+			"finishedAt":  entry.FinishedAt.Format(time.RFC3339Nano),
+			"exitCode":    strconv.Itoa(entry.ExitCode),
+		}
+		d.LogContainerEventWithAttributes(c, "exec_audit", attributes)
+	}
+}
+
+// ContainerExecAudit returns the entries of the exec audit log matching
+// the filters.
+func (d *Daemon) ContainerExecAudit(auditFilters filters.Args) ([]types.ExecAuditEntry, error) {
+	if d.execAudit == nil {
+		return nil, errdefs.NotImplemented(errors.New("exec audit is not enabled on this daemon"))
+	}
+	if err := auditFilters.Validate(execAuditAcceptedFilters); err != nil {
+		return nil, err
+	}
+	since, err := getTimeFromFilters(auditFilters, "since")
+	if err != nil {
+		return nil, err
+	}
+	until, err := getTimeFromFilters(auditFilters, "until")
+	if err != nil {
+		return nil, err
+	}
+
+	return d.execAudit.List(func(entry types.ExecAuditEntry) bool {
+		if auditFilters.Contains("container") && !auditFilters.ExactMatch("container", entry.ContainerID) && !auditFilters.ExactMatch("container", entry.ContainerName) {
+			return false
+		}
+		if auditFilters.Contains("identity") && !auditFilters.ExactMatch("identity", entry.Identity) {
+			return false
+		}
+		if !since.IsZero() && entry.StartedAt.Before(since) {
+			return false
+		}
+		if !until.IsZero() && entry.StartedAt.After(until) {
+			return false
+		}
+		return true
+	})
+}
+
+// getTimeFromFilters parses the timestamp of the given filter key. It
+// returns the zero time if the filter is not set.
+func getTimeFromFilters(f filters.Args, key string) (time.Time, error) {
+	values := f.Get(key)
+	if len(values) == 0 {
+		return time.Time{}, nil
+	}
+	if len(values) > 1 {
+		return time.Time{}, errdefs.InvalidParameter(errors.Errorf("more than one %s filter specified", key))
+	}
+	ts, err := timetypes.GetTimestamp(values[0], time.Now())
+	if err != nil {
+		return time.Time{}, errdefs.InvalidParameter(err)
+	}
+	seconds, nanoseconds, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, errdefs.InvalidParameter(err)
+	}
+	return time.Unix(seconds, nanoseconds), nil
+}
@@ -15,7 +15,9 @@ static void set_name_btrfs_ioctl_vol_args_v2(struct btrfs_ioctl_vol_args_v2* btr
 import "C"
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"os"
@@ -27,6 +29,7 @@ import (
 	"unsafe"
 
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/containerfs"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/parsers"
@@ -87,6 +90,7 @@ func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (grap
 		gidMaps: gidMaps,
 		options: opt,
 	}
+	driver.naiveDiff = graphdriver.NewNaiveDiffDriver(driver, uidMaps, gidMaps)
 
 	if userDiskQuota {
 		if err := driver.subvolEnableQuota(); err != nil {
@@ -94,7 +98,7 @@ func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (grap
 		}
 	}
 
-	return graphdriver.NewNaiveDiffDriver(driver, uidMaps, gidMaps), nil
+	return driver, nil
 }
 
 func parseOptions(opt []string) (btrfsOptions, bool, error) {
@@ -130,6 +134,7 @@ type Driver struct {
 	options      btrfsOptions
 	quotaEnabled bool
 	once         sync.Once
+	naiveDiff    graphdriver.DiffDriver
 }
 
 // String prints the name of the driver (btrfs).
@@ -148,6 +153,8 @@ func (d *Driver) Status() [][2]string {
 	if lv := btrfsLibVersion(); lv != -1 {
 		status = append(status, [2]string{"Library Version", fmt.Sprintf("%d", lv)})
 	}
+	d.updateQuotaStatus()
+	status = append(status, [2]string{"Quota Groups", fmt.Sprintf("%v", d.quotaEnabled)})
 	return status
 }
 
@@ -450,6 +457,52 @@ func subvolQgroupStatus(path string) error {
 	return nil
 }
 
+// subvolQgroupUsage returns the number of bytes of the subvolume which are not
+// shared with any other subvolume, as accounted by its level 0 qgroup. For the
+// snapshot of a container layer, they are the bytes written by the container.
+// The accounting is updated when the transactions are committed.
+func subvolQgroupUsage(path string) (uint64, error) {
+	qgroupid, err := subvolLookupQgroup(path)
+	if err != nil {
+		return 0, err
+	}
+
+	dir, err := openDir(path)
+	if err != nil {
+		return 0, err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_search_args
+	args.key.tree_id = C.BTRFS_QUOTA_TREE_OBJECTID
+	args.key.min_type = C.BTRFS_QGROUP_INFO_KEY
+	args.key.max_type = C.BTRFS_QGROUP_INFO_KEY
+	args.key.min_offset = C.__u64(qgroupid)
+	args.key.max_offset = C.__u64(qgroupid)
+	args.key.max_transid = C.__u64(math.MaxUint64)
+	args.key.nr_items = 1
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_TREE_SEARCH,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return 0, fmt.Errorf("Failed to search qgroup for %s: %v", path, errno.Error())
+	}
+	if args.key.nr_items == 0 {
+		return 0, fmt.Errorf("No qgroup found for %s", path)
+	}
+	sh := (*C.struct_btrfs_ioctl_search_header)(unsafe.Pointer(&args.buf))
+	if sh._type != C.BTRFS_QGROUP_INFO_KEY || uint64(sh.offset) != qgroupid {
+		return 0, fmt.Errorf("Invalid qgroup search header for %s: type %v, qgroup %v", path, sh._type, sh.offset)
+	}
+	// The item is a struct btrfs_qgroup_info_item, whose fields are little
+	// endian: generation, rfer, rfer_cmpr, excl and excl_cmpr.
+	item := C.GoBytes(unsafe.Pointer(&args.buf[C.sizeof_struct_btrfs_ioctl_search_header]), C.int(sh.len))
+	if len(item) < 32 {
+		return 0, fmt.Errorf("Invalid qgroup info item for %s: %d bytes", path, len(item))
+	}
+	return binary.LittleEndian.Uint64(item[24:32]), nil
+}
+
 func subvolLookupQgroup(path string) (uint64, error) {
 	dir, err := openDir(path)
 	if err != nil {
@@ -498,6 +551,17 @@ func (d *Driver) CreateReadWrite(id, parent string, opts *graphdriver.CreateOpts
 func (d *Driver) Create(id, parent string, opts *graphdriver.CreateOpts) error {
 	quotas := path.Join(d.home, "quotas")
 	subvolumes := path.Join(d.home, "subvolumes")
+
+	var storageOpt map[string]string
+	if opts != nil {
+		storageOpt = opts.StorageOpt
+	}
+	// Reject the unknown options before creating the subvolume
+	driver := &Driver{}
+	if err := d.parseStorageOpt(storageOpt, driver); err != nil {
+		return err
+	}
+
 	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
 	if err != nil {
 		return err
@@ -523,17 +587,7 @@ func (d *Driver) Create(id, parent string, opts *graphdriver.CreateOpts) error {
 		}
 	}
 
-	var storageOpt map[string]string
-	if opts != nil {
-		storageOpt = opts.StorageOpt
-	}
-
 	if _, ok := storageOpt["size"]; ok {
-		driver := &Driver{}
-		if err := d.parseStorageOpt(storageOpt, driver); err != nil {
-			return err
-		}
-
 		if err := d.setStorageSize(path.Join(subvolumes, id), driver); err != nil {
 			return err
 		}
@@ -661,3 +715,39 @@ func (d *Driver) Exists(id string) bool {
 	_, err := os.Stat(dir)
 	return err == nil
 }
+
+// ApplyDiff extracts the changeset from the given diff into the
+// layer with the specified id and parent, returning the size of the
+// new layer in bytes.
+func (d *Driver) ApplyDiff(id, parent string, diff io.Reader) (size int64, err error) {
+	return d.naiveDiff.ApplyDiff(id, parent, diff)
+}
+
+// DiffSize calculates the changes between the specified id
+// and its parent and returns the size in bytes of the changes
+// relative to its base filesystem directory. When the quota groups
+// are enabled, it is the space used exclusively by the subvolume,
+// instead of the sum of the sizes of the changed files.
+func (d *Driver) DiffSize(id, parent string) (size int64, err error) {
+	d.updateQuotaStatus()
+	if d.quotaEnabled {
+		usage, err := subvolQgroupUsage(d.subvolumesDirID(id))
+		if err == nil {
+			return int64(usage), nil
+		}
+		logrus.WithField("storage-driver", "btrfs").Debugf("Failed to get the qgroup usage of %s, falling back to the naive diff: %v", id, err)
+	}
+	return d.naiveDiff.DiffSize(id, parent)
+}
+
+// Diff produces an archive of the changes between the specified
+// layer and its parent layer which may be "".
+func (d *Driver) Diff(id, parent string) (io.ReadCloser, error) {
+	return d.naiveDiff.Diff(id, parent)
+}
+
+// Changes produces a list of changes between the specified layer
+// and its parent layer. If parent is "", then all changes will be ADD changes.
+func (d *Driver) Changes(id, parent string) ([]archive.Change, error) {
+	return d.naiveDiff.Changes(id, parent)
+}
@@ -3,11 +3,14 @@
 package btrfs // import "github.com/docker/docker/daemon/graphdriver/btrfs"
 
 import (
+	"io/ioutil"
 	"os"
 	"path"
 	"testing"
 
+	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/daemon/graphdriver/graphtest"
+	"golang.org/x/sys/unix"
 )
 
 // This avoids creating a new driver for each test if all tests are run
@@ -60,6 +63,50 @@ func TestBtrfsSubvolDelete(t *testing.T) {
 	}
 }
 
+func TestBtrfsStorageSize(t *testing.T) {
+	d := graphtest.GetDriver(t, "btrfs")
+	opts := &graphdriver.CreateOpts{StorageOpt: map[string]string{"size": "16M"}}
+	if err := d.CreateReadWrite("quota", "", opts); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Remove("quota")
+
+	dirFS, err := d.Get("quota", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Put("quota")
+	dir := dirFS.Path()
+
+	if err := ioutil.WriteFile(path.Join(dir, "data"), make([]byte, 1<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// commit the transaction to update the accounting of the qgroups
+	unix.Sync()
+	size, err := d.DiffSize("quota", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size < 1<<20 {
+		t.Fatalf("expected the size of the layer to be at least 1MiB, got %d", size)
+	}
+
+	err = ioutil.WriteFile(path.Join(dir, "too-large"), make([]byte, 32<<20), 0644)
+	if err == nil {
+		// the writes may only fail when the data is flushed
+		unix.Sync()
+		err = ioutil.WriteFile(path.Join(dir, "too-large"), make([]byte, 32<<20), 0644)
+	}
+	if err == nil {
+		t.Fatal("expected writing more than the size of the layer to fail")
+	}
+
+	if err := d.CreateReadWrite("invalid", "", &graphdriver.CreateOpts{StorageOpt: map[string]string{"invalid": "1"}}); err == nil {
+		d.Remove("invalid")
+		t.Fatal("expected an unknown storage option to be rejected")
+	}
+}
+
 func TestBtrfsTeardown(t *testing.T) {
 	graphtest.PutDriver(t)
 }
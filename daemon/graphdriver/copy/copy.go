@@ -14,6 +14,7 @@ import (
 	"container/list"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -88,6 +89,40 @@ func doCopyWithFileRange(srcFile, dstFile *os.File, fileinfo os.FileInfo) error
 	return nil
 }
 
+// SupportsReflink checks whether the filesystem of dir supports cloning
+// files, the clones sharing the extents of the original file until either of
+// them is written. The files are cloned by the regular file copies of
+// DirCopy, and copied up by the kernel from the lower layers of an overlay
+// filesystem, when it is supported.
+func SupportsReflink(dir string) (bool, error) {
+	src, err := ioutil.TempFile(dir, "reflink-check")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(src.Name())
+	defer src.Close()
+	if _, err := src.Write([]byte{0}); err != nil {
+		return false, err
+	}
+
+	dst, err := ioutil.TempFile(dir, "reflink-check")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, dst.Fd(), C.FICLONE, src.Fd())
+	switch errno {
+	case 0:
+		return true, nil
+	case unix.EOPNOTSUPP, unix.ENOTTY, unix.EINVAL, unix.EXDEV, unix.ENOSYS:
+		return false, nil
+	default:
+		return false, errno
+	}
+}
+
 func legacyCopy(srcFile io.Reader, dstFile io.Writer) error {
 	_, err := pools.Copy(dstFile, srcFile)
 
@@ -157,3 +157,16 @@ func TestCopyHardlink(t *testing.T) {
 	assert.NilError(t, unix.Stat(dstFile2, &dstFile2FileInfo))
 	assert.Check(t, is.Equal(dstFile1FileInfo.Ino, dstFile2FileInfo.Ino))
 }
+
+func TestSupportsReflink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reflink")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = SupportsReflink(dir)
+	assert.NilError(t, err)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(files, 0))
+}
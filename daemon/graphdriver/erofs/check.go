@@ -0,0 +1,79 @@
+// +build linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// supportsDataOnlyLowers checks whether the kernel follows the redirects of
+// the metacopy files of the images to the object store, mounted as a data-only
+// lower directory of the overlay. They are supported on kernel 6.5 and up.
+func supportsDataOnlyLowers(d string) error {
+	td, err := ioutil.TempDir(d, "data-only-lower-check")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(td); err != nil {
+			logrus.WithField("storage-driver", driverName).Warnf("Failed to remove check directory %v: %v", td, err)
+		}
+	}()
+
+	for _, dir := range []string{"layer", "objects", "image", "merged"} {
+		if err := os.Mkdir(filepath.Join(td, dir), 0755); err != nil {
+			return err
+		}
+	}
+	data := []byte("data-only lower")
+	if err := ioutil.WriteFile(filepath.Join(td, "layer", "file"), data, 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(td, imageFile))
+	if err != nil {
+		return err
+	}
+	err = writeImage(filepath.Join(td, "layer"), f, func(path string, fi os.FileInfo) (string, error) {
+		return "/object", os.Rename(path, filepath.Join(td, "objects", "object"))
+	})
+	f.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write image")
+	}
+
+	if err := mountImage(filepath.Join(td, imageFile), filepath.Join(td, "image")); err != nil {
+		return err
+	}
+	defer func() {
+		if err := unix.Unmount(filepath.Join(td, "image"), 0); err != nil {
+			logrus.WithField("storage-driver", driverName).Warnf("Failed to unmount check directory %v: %v", filepath.Join(td, "image"), err)
+		}
+	}()
+
+	opts := fmt.Sprintf("lowerdir=%s::%s,metacopy=on", filepath.Join(td, "image"), filepath.Join(td, "objects"))
+	if err := unix.Mount("overlay", filepath.Join(td, "merged"), "overlay", unix.MS_RDONLY, opts); err != nil {
+		return errors.Wrap(err, "failed to mount overlay")
+	}
+	defer func() {
+		if err := unix.Unmount(filepath.Join(td, "merged"), 0); err != nil {
+			logrus.WithField("storage-driver", driverName).Warnf("Failed to unmount check directory %v: %v", filepath.Join(td, "merged"), err)
+		}
+	}()
+
+	b, err := ioutil.ReadFile(filepath.Join(td, "merged", "file"))
+	if err != nil {
+		return errors.Wrap(err, "failed to read the data of a metacopy file")
+	}
+	if string(b) != string(data) {
+		return errors.New("the redirect of a metacopy file was not followed")
+	}
+	return nil
+}
@@ -0,0 +1,842 @@
+// +build linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/daemon/graphdriver/overlayutils"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/containerfs"
+	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/pkg/fsutils"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/locker"
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/system"
+	digest "github.com/opencontainers/go-digest"
+	rsystem "github.com/opencontainers/runc/libcontainer/system"
+	"github.com/opencontainers/selinux/go-selinux/label"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// The erofs driver stores the read-only layers as EROFS images, holding the
+// metadata of their files, and the data of their regular files in an object
+// store shared by all the layers, addressed by the digest of the data. The
+// layers are mounted as an overlay of their images, with the object store
+// as a data-only lower directory, to which the regular files of the images
+// are redirected.
+//
+// The files with the same data, in any layer, are the same file of the object
+// store, whose page cache is shared by all the containers using them. The
+// digests of the images are verified when they are mounted, to detect the
+// images corrupted on disk. The digests are stored next to the images, so
+// they do not protect the layers from a user able to write to the home of
+// the driver.
+//
+// The layers used by the containers are directories, like the layers of the
+// overlay2 driver, as are the layers created in the layer store for their
+// initialization.
+//
+// Each layer has a directory in the home of the driver:
+//
+//	<id>/link         the short id of the layer in the link directory
+//	<id>/lower        the ids of the parent layers, parent first
+//	<id>/image.erofs  the image of a read-only layer, and its digest in image.digest
+//	<id>/refs         the links to the objects of a read-only layer
+//	<id>/diff         the upper directory of a writable layer, and its work directory in work
+//	<id>/merged       the mount point of the layer
+//
+// The images are mounted on the link directory of their layer, in l/<link>,
+// which is a symbolic link to the upper directory of the writable layers, so
+// that the mount data of the overlays references the lower directories by
+// their short paths.
+const (
+	driverName = "erofs"
+	linkDir    = "l"
+	objectsDir = "objects"
+	lowerFile  = "lower"
+	imageFile  = "image.erofs"
+	digestFile = "image.digest"
+	refsDir    = "refs"
+	stagingDir = "staging"
+	maxDepth   = 128
+
+	// idLength represents the number of characters of the short ids of the
+	// layers in the link directory.
+	idLength = 26
+)
+
+var (
+	backingFs = "<unknown>"
+
+	// untar defines the untar method
+	untar = chrootarchive.UntarUncompressed
+)
+
+// Driver contains information about the home directory, and the images and
+// the overlays mounted by the driver.
+type Driver struct {
+	home      string
+	uidMaps   []idtools.IDMap
+	gidMaps   []idtools.IDMap
+	ctr       *graphdriver.RefCounter
+	naiveDiff graphdriver.DiffDriver
+	locker    *locker.Locker
+
+	// imagesMu protects the counts of the users of the mounted images
+	imagesMu sync.Mutex
+	images   map[string]int
+	// objectsMu serializes the changes to the links of the objects
+	objectsMu sync.Mutex
+}
+
+func init() {
+	graphdriver.Register(driverName, Init)
+}
+
+// Init returns the erofs driver. If EROFS, or the overlays following the
+// redirects of metacopy files to data-only lower directories, are not
+// supported on the host, the error graphdriver.ErrNotSupported is returned.
+// If the filesystem of home does not support overlays, the error
+// graphdriver.ErrIncompatibleFS is returned.
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
+	for _, option := range options {
+		return nil, fmt.Errorf("erofs: unknown option %s", option)
+	}
+
+	logger := logrus.WithField("storage-driver", driverName)
+	for _, fsType := range []string{"erofs", "overlay"} {
+		if err := supportsFilesystem(fsType); err != nil {
+			logger.Debug(err)
+			return nil, graphdriver.ErrNotSupported
+		}
+	}
+
+	// Perform feature detection on /var/lib/docker/erofs if it's an existing
+	// directory, or else on /var/lib/docker.
+	testdir := home
+	if _, err := os.Stat(testdir); os.IsNotExist(err) {
+		testdir = filepath.Dir(testdir)
+	}
+
+	fsMagic, err := graphdriver.GetFSMagic(testdir)
+	if err != nil {
+		return nil, err
+	}
+	if fsName, ok := graphdriver.FsNames[fsMagic]; ok {
+		backingFs = fsName
+	}
+	switch fsMagic {
+	case graphdriver.FsMagicAufs, graphdriver.FsMagicEcryptfs, graphdriver.FsMagicNfsFs, graphdriver.FsMagicOverlay, graphdriver.FsMagicZfs:
+		logger.Errorf("'erofs' is not supported over %s", backingFs)
+		return nil, graphdriver.ErrIncompatibleFS
+	}
+
+	supportsDType, err := fsutils.SupportsDType(testdir)
+	if err != nil {
+		return nil, err
+	}
+	if !supportsDType {
+		return nil, overlayutils.ErrDTypeNotSupported(driverName, backingFs)
+	}
+
+	if err := supportsDataOnlyLowers(testdir); err != nil {
+		logger.Debugf("Data-only lower directories not supported: %v", err)
+		return nil, graphdriver.ErrNotSupported
+	}
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	root := idtools.Identity{UID: rootUID, GID: rootGID}
+	for _, dir := range []string{linkDir, objectsDir} {
+		if err := idtools.MkdirAllAndChown(path.Join(home, dir), 0700, root); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Driver{
+		home:    home,
+		uidMaps: uidMaps,
+		gidMaps: gidMaps,
+		ctr:     graphdriver.NewRefCounter(graphdriver.NewFsChecker(graphdriver.FsMagicOverlay)),
+		locker:  locker.New(),
+		images:  make(map[string]int),
+	}
+	d.naiveDiff = graphdriver.NewNaiveDiffDriver(d, uidMaps, gidMaps)
+
+	logger.Debugf("backingFs=%s", backingFs)
+	return d, nil
+}
+
+func (d *Driver) String() string {
+	return driverName
+}
+
+// Status returns current driver information in a two dimensional string array.
+func (d *Driver) Status() [][2]string {
+	return [][2]string{
+		{"Backing Filesystem", backingFs},
+		{"Layer Image Format", "erofs"},
+	}
+}
+
+// GetMetadata returns metadata about the layer, such as its image and the
+// directories of its overlay.
+func (d *Driver) GetMetadata(id string) (map[string]string, error) {
+	dir := d.dir(id)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{
+		"MergedDir": path.Join(dir, "merged"),
+	}
+	if d.isImage(id) {
+		metadata["Image"] = path.Join(dir, imageFile)
+		dgst, err := ioutil.ReadFile(path.Join(dir, digestFile))
+		if err != nil {
+			return nil, err
+		}
+		metadata["ImageDigest"] = string(dgst)
+	} else {
+		metadata["UpperDir"] = path.Join(dir, "diff")
+		metadata["WorkDir"] = path.Join(dir, "work")
+	}
+
+	lowers, err := d.getLowers(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(lowers) > 0 {
+		lowerDirs := make([]string, 0, len(lowers))
+		for _, l := range lowers {
+			lowerDir, err := d.linkPath(l)
+			if err != nil {
+				return nil, err
+			}
+			lowerDirs = append(lowerDirs, lowerDir)
+		}
+		metadata["LowerDir"] = strings.Join(lowerDirs, ":")
+	}
+	return metadata, nil
+}
+
+// Cleanup unmounts the overlays and the images mounted by the driver.
+func (d *Driver) Cleanup() error {
+	return mount.RecursiveUnmount(d.home)
+}
+
+// CreateReadWrite creates a layer that is writable for use as a container
+// file system.
+func (d *Driver) CreateReadWrite(id, parent string, opts *graphdriver.CreateOpts) error {
+	if opts != nil && len(opts.StorageOpt) != 0 {
+		return fmt.Errorf("--storage-opt is not supported by the erofs driver")
+	}
+	return d.create(id, parent, true)
+}
+
+// Create creates a read-only layer, with an empty image replaced by the image
+// of the diff applied to the layer.
+func (d *Driver) Create(id, parent string, opts *graphdriver.CreateOpts) error {
+	if opts != nil && len(opts.StorageOpt) != 0 {
+		return fmt.Errorf("--storage-opt is not supported by the erofs driver")
+	}
+	return d.create(id, parent, false)
+}
+
+func (d *Driver) create(id, parent string, rw bool) (retErr error) {
+	dir := d.dir(id)
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return err
+	}
+	root := idtools.Identity{UID: rootUID, GID: rootGID}
+
+	if err := idtools.MkdirAndChown(dir, 0700, root); err != nil {
+		return err
+	}
+	defer func() {
+		// Clean up on failure
+		if retErr != nil {
+			d.Remove(id)
+		}
+	}()
+
+	var lowers []string
+	if parent != "" {
+		if _, err := os.Lstat(d.dir(parent)); err != nil {
+			return err
+		}
+		parentLowers, err := d.getLowers(parent)
+		if err != nil {
+			return err
+		}
+		lowers = append([]string{parent}, parentLowers...)
+		if len(lowers) > maxDepth {
+			return errors.New("max depth exceeded")
+		}
+	}
+
+	lid := stringid.GenerateRandomID()[:idLength]
+	if rw {
+		if err := idtools.MkdirAndChown(path.Join(dir, "diff"), 0755, root); err != nil {
+			return err
+		}
+		if err := idtools.MkdirAndChown(path.Join(dir, "work"), 0700, root); err != nil {
+			return err
+		}
+		if err := os.Symlink(path.Join("..", id, "diff"), path.Join(d.home, linkDir, lid)); err != nil {
+			return err
+		}
+	} else if err := idtools.MkdirAndChown(path.Join(d.home, linkDir, lid), 0700, root); err != nil {
+		return err
+	}
+
+	// Write link id to link file
+	if err := ioutil.WriteFile(path.Join(dir, "link"), []byte(lid), 0644); err != nil {
+		return err
+	}
+	if len(lowers) > 0 {
+		if err := ioutil.WriteFile(path.Join(dir, lowerFile), []byte(strings.Join(lowers, ":")), 0644); err != nil {
+			return err
+		}
+	}
+
+	if !rw {
+		staging := path.Join(dir, stagingDir)
+		if err := idtools.MkdirAndChown(staging, 0755, root); err != nil {
+			return err
+		}
+		defer os.RemoveAll(staging)
+		return d.writeLayerImage(id, staging)
+	}
+	return nil
+}
+
+func (d *Driver) dir(id string) string {
+	return path.Join(d.home, id)
+}
+
+// isImage returns whether the layer is a read-only layer stored as an image.
+func (d *Driver) isImage(id string) bool {
+	_, err := os.Lstat(path.Join(d.dir(id), "diff"))
+	return os.IsNotExist(err)
+}
+
+// getLowers returns the ids of the parent layers of the layer, parent first.
+func (d *Driver) getLowers(id string) ([]string, error) {
+	lowers, err := ioutil.ReadFile(path.Join(d.dir(id), lowerFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return strings.Split(string(lowers), ":"), nil
+}
+
+// link returns the path of the layer in the link directory, relative to the
+// home of the driver.
+func (d *Driver) link(id string) (string, error) {
+	lid, err := ioutil.ReadFile(path.Join(d.dir(id), "link"))
+	if err != nil {
+		return "", err
+	}
+	return path.Join(linkDir, string(lid)), nil
+}
+
+func (d *Driver) linkPath(id string) (string, error) {
+	link, err := d.link(id)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(d.home, link), nil
+}
+
+// Remove cleans the directories that are created for this id.
+func (d *Driver) Remove(id string) error {
+	if id == "" {
+		return fmt.Errorf("refusing to remove the directories: id is empty")
+	}
+	d.locker.Lock(id)
+	defer d.locker.Unlock(id)
+
+	if linkPath, err := d.linkPath(id); err == nil {
+		d.imagesMu.Lock()
+		delete(d.images, id)
+		d.imagesMu.Unlock()
+		if err := unix.Unmount(linkPath, unix.MNT_DETACH); err != nil && err != unix.EINVAL && err != unix.ENOENT {
+			logrus.WithField("storage-driver", driverName).Debugf("Failed to unmount image of %s: %v", id, err)
+		}
+		if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+			logrus.WithField("storage-driver", driverName).Debugf("Failed to remove link: %v", err)
+		}
+	}
+
+	if err := d.releaseObjects(id); err != nil {
+		return err
+	}
+	if err := system.EnsureRemoveAll(d.dir(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Get mounts the overlay of the layer and returns its mount path. The
+// overlays of the read-only layers are mounted read-only.
+func (d *Driver) Get(id, mountLabel string) (_ containerfs.ContainerFS, retErr error) {
+	d.locker.Lock(id)
+	defer d.locker.Unlock(id)
+	dir := d.dir(id)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	rw := !d.isImage(id)
+	lowers, err := d.getLowers(id)
+	if err != nil {
+		return nil, err
+	}
+	if rw && len(lowers) == 0 {
+		// If no lower, just return diff directory
+		return containerfs.NewLocalContainerFS(path.Join(dir, "diff")), nil
+	}
+	if !rw {
+		lowers = append([]string{id}, lowers...)
+	}
+
+	mergedDir := path.Join(dir, "merged")
+	if count := d.ctr.Increment(mergedDir); count > 1 {
+		return containerfs.NewLocalContainerFS(mergedDir), nil
+	}
+	defer func() {
+		if retErr != nil {
+			if c := d.ctr.Decrement(mergedDir); c <= 0 {
+				if mntErr := unix.Unmount(mergedDir, 0); mntErr != nil && mntErr != unix.EINVAL && mntErr != unix.ENOENT {
+					logrus.WithField("storage-driver", driverName).Errorf("error unmounting %v: %v", mergedDir, mntErr)
+				}
+				if rmErr := unix.Rmdir(mergedDir); rmErr != nil && !os.IsNotExist(rmErr) {
+					logrus.WithField("storage-driver", driverName).Debugf("Failed to remove %s: %v: %v", id, rmErr, err)
+				}
+			}
+		}
+	}()
+
+	links := make([]string, 0, len(lowers))
+	for i, l := range lowers {
+		if d.isImage(l) {
+			if err := d.getImage(l); err != nil {
+				d.putImages(lowers[:i])
+				return nil, err
+			}
+		}
+		link, err := d.link(l)
+		if err != nil {
+			d.putImages(lowers[:i+1])
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	defer func() {
+		if retErr != nil {
+			d.putImages(lowers)
+		}
+	}()
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	if err := idtools.MkdirAndChown(mergedDir, 0700, idtools.Identity{UID: rootUID, GID: rootGID}); err != nil {
+		return nil, err
+	}
+
+	var flags uintptr
+	upper := ""
+	if rw {
+		upper = fmt.Sprintf(",upperdir=%s,workdir=%s", path.Join(id, "diff"), path.Join(id, "work"))
+	} else {
+		flags = unix.MS_RDONLY
+	}
+	// The relative paths are resolved from the home of the driver, by
+	// mounting the overlay from it.
+	opts := fmt.Sprintf("lowerdir=%s::%s%s,metacopy=on", strings.Join(links, ":"), objectsDir, upper)
+	mountData := label.FormatMountLabel(opts, mountLabel)
+	pageSize := unix.Getpagesize()
+	if pageSize > 4096 {
+		pageSize = 4096
+	}
+	if len(mountData) > pageSize {
+		return nil, fmt.Errorf("cannot mount layer, mount label too large %d", len(mountData))
+	}
+	if err := overlayutils.MountFrom(d.home, "overlay", path.Join(id, "merged"), "overlay", flags, mountData); err != nil {
+		return nil, fmt.Errorf("error creating overlay mount to %s: %v", mergedDir, err)
+	}
+
+	if rw {
+		// chown "workdir/work" to the remapped root UID/GID. Overlay fs inside a
+		// user namespace requires this to move a directory from lower to upper.
+		if err := os.Chown(path.Join(dir, "work", "work"), rootUID, rootGID); err != nil {
+			return nil, err
+		}
+	}
+
+	return containerfs.NewLocalContainerFS(mergedDir), nil
+}
+
+// Put unmounts the overlay of the layer, and the images it no longer uses.
+func (d *Driver) Put(id string) error {
+	d.locker.Lock(id)
+	defer d.locker.Unlock(id)
+	dir := d.dir(id)
+	lowers, err := d.getLowers(id)
+	if err != nil {
+		return err
+	}
+	if d.isImage(id) {
+		lowers = append([]string{id}, lowers...)
+	} else if len(lowers) == 0 {
+		// If no lower, no mount happened and just return directly
+		return nil
+	}
+
+	mountpoint := path.Join(dir, "merged")
+	logger := logrus.WithField("storage-driver", driverName)
+	if count := d.ctr.Decrement(mountpoint); count > 0 {
+		return nil
+	}
+	if err := unix.Unmount(mountpoint, unix.MNT_DETACH); err != nil {
+		logger.Debugf("Failed to unmount %s overlay: %s - %v", id, mountpoint, err)
+	}
+	// Remove the mountpoint to unmount the overlay in the other mount
+	// namespaces, see the overlay2 driver.
+	if err := unix.Rmdir(mountpoint); err != nil && !os.IsNotExist(err) {
+		logger.Debugf("Failed to remove %s overlay: %v", id, err)
+	}
+	d.putImages(lowers)
+	return nil
+}
+
+// getImage mounts the image of a layer, unless it is already mounted, after
+// checking it is not corrupted.
+func (d *Driver) getImage(id string) error {
+	d.imagesMu.Lock()
+	defer d.imagesMu.Unlock()
+	if d.images[id] > 0 {
+		d.images[id]++
+		return nil
+	}
+
+	target, err := d.linkPath(id)
+	if err != nil {
+		return err
+	}
+	// The image may be mounted, and in use, since the previous start of the
+	// daemon.
+	if mounted, err := mount.Mounted(target); err != nil {
+		return err
+	} else if !mounted {
+		if err := d.verifyImage(id); err != nil {
+			return err
+		}
+		if err := mountImage(path.Join(d.dir(id), imageFile), target); err != nil {
+			return err
+		}
+	}
+	d.images[id] = 1
+	return nil
+}
+
+// putImages releases the images of the layers, unmounting those which are
+// no longer used.
+func (d *Driver) putImages(ids []string) {
+	d.imagesMu.Lock()
+	defer d.imagesMu.Unlock()
+	for _, id := range ids {
+		if !d.isImage(id) || d.images[id] == 0 {
+			continue
+		}
+		if d.images[id]--; d.images[id] > 0 {
+			continue
+		}
+		delete(d.images, id)
+		target, err := d.linkPath(id)
+		if err == nil {
+			err = unix.Unmount(target, 0)
+		}
+		if err != nil {
+			logrus.WithField("storage-driver", driverName).Debugf("Failed to unmount image of %s: %v", id, err)
+		}
+	}
+}
+
+// verifyImage checks the image of a layer against the digest computed when
+// the image was written, to detect a corruption of the image on disk.
+func (d *Driver) verifyImage(id string) error {
+	b, err := ioutil.ReadFile(path.Join(d.dir(id), digestFile))
+	if err != nil {
+		return err
+	}
+	expected, err := digest.Parse(string(b))
+	if err != nil {
+		return errors.Wrapf(err, "invalid digest of the image of layer %s", id)
+	}
+	f, err := os.Open(path.Join(d.dir(id), imageFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	verifier := expected.Verifier()
+	if _, err := io.Copy(verifier, f); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("the image of layer %s is corrupted: it does not match its digest %s", id, expected)
+	}
+	return nil
+}
+
+// Exists checks to see if the id is already mounted.
+func (d *Driver) Exists(id string) bool {
+	_, err := os.Stat(d.dir(id))
+	return err == nil
+}
+
+// ApplyDiff extracts the diff into the staging directory of the layer, and
+// replaces the image of the layer with the image of the diff, the data of its
+// regular files being moved to the object store.
+func (d *Driver) ApplyDiff(id string, parent string, diff io.Reader) (size int64, err error) {
+	if !d.isImage(id) {
+		return d.naiveDiff.ApplyDiff(id, parent, diff)
+	}
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return 0, err
+	}
+	staging := path.Join(d.dir(id), stagingDir)
+	if err := os.RemoveAll(staging); err != nil {
+		return 0, err
+	}
+	if err := idtools.MkdirAndChown(staging, 0755, idtools.Identity{UID: rootUID, GID: rootGID}); err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(staging)
+
+	logrus.WithField("storage-driver", driverName).Debugf("Applying tar in %s", staging)
+	if err := untar(diff, staging, &archive.TarOptions{
+		UIDMaps:        d.uidMaps,
+		GIDMaps:        d.gidMaps,
+		WhiteoutFormat: archive.OverlayWhiteoutFormat,
+		InUserNS:       rsystem.RunningInUserNS(),
+	}); err != nil {
+		return 0, err
+	}
+	if size, err = directory.Size(context.TODO(), staging); err != nil {
+		return 0, err
+	}
+	return size, d.writeLayerImage(id, staging)
+}
+
+// writeLayerImage writes the image of the directory tree at staging as the
+// image of the layer. The objects of the new image are linked from a new refs
+// directory, the links to the objects of the previous image of the layer only
+// being released once the new image replaced it, for the objects still used
+// by the previous image not to be removed when the new image is not written.
+func (d *Driver) writeLayerImage(id, staging string) error {
+	dir := d.dir(id)
+	refs, err := ioutil.TempDir(dir, refsDir)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	defer func() {
+		if !replaced {
+			if err := d.releaseRefs(refs); err != nil {
+				logrus.WithField("storage-driver", driverName).WithError(err).Warnf("Failed to release the objects of the image of %s", id)
+			}
+		}
+	}()
+
+	f, err := ioutil.TempFile(dir, imageFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	digester := digest.Canonical.Digester()
+	err = writeImage(staging, io.MultiWriter(f, digester.Hash()), func(path string, fi os.FileInfo) (string, error) {
+		return d.storeObject(refs, path)
+	})
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error writing the image of layer %s", id)
+	}
+	if err := os.Chmod(f.Name(), 0600); err != nil {
+		return err
+	}
+	digestF, err := ioutil.TempFile(dir, digestFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(digestF.Name())
+	_, err = digestF.WriteString(digester.Digest().String())
+	if closeErr := digestF.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path.Join(dir, imageFile)); err != nil {
+		return err
+	}
+
+	// The objects of the layer are the objects of the new image from now
+	// on, whether or not its digest is written.
+	replaced = true
+	old := refs + ".old"
+	if err := os.Rename(path.Join(dir, refsDir), old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(refs, path.Join(dir, refsDir)); err != nil {
+		return err
+	}
+	if err := d.releaseRefs(old); err != nil {
+		logrus.WithField("storage-driver", driverName).WithError(err).Warnf("Failed to release the objects of the previous image of %s", id)
+	}
+	return os.Rename(digestF.Name(), path.Join(dir, digestFile))
+}
+
+// storeObject moves the data of the regular file to the object store, and
+// links the object from the refs directory. It returns the path of the object
+// in the object store.
+func (d *Driver) storeObject(refs, p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	object := path.Join(d.home, objectsDir, sum[:2], sum[2:])
+
+	d.objectsMu.Lock()
+	defer d.objectsMu.Unlock()
+	if err := os.MkdirAll(path.Dir(object), 0700); err != nil {
+		return "", err
+	}
+	if err := os.Link(p, object); err == nil {
+		// The objects are only read through the overlays.
+		if err := os.Chmod(object, 0400); err != nil {
+			return "", err
+		}
+	} else if !os.IsExist(err) {
+		return "", err
+	}
+	if err := os.Link(object, path.Join(refs, sum)); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	return "/" + sum[:2] + "/" + sum[2:], nil
+}
+
+// releaseObjects removes the links of the layer to the objects, including
+// those of the images which were not written, and the objects which are no
+// longer linked from any layer.
+func (d *Driver) releaseObjects(id string) error {
+	dirs, err := filepath.Glob(path.Join(d.dir(id), refsDir+"*"))
+	if err != nil {
+		return err
+	}
+	for _, refs := range dirs {
+		if err := d.releaseRefs(refs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseRefs removes the refs directory, and the objects which are no longer
+// linked from any layer.
+func (d *Driver) releaseRefs(dir string) error {
+	refs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	d.objectsMu.Lock()
+	defer d.objectsMu.Unlock()
+	for _, ref := range refs {
+		sum := ref.Name()
+		if err := os.Remove(path.Join(dir, sum)); err != nil {
+			return err
+		}
+		if len(sum) < 3 {
+			continue
+		}
+		object := path.Join(d.home, objectsDir, sum[:2], sum[2:])
+		var st unix.Stat_t
+		if err := unix.Lstat(object, &st); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if st.Nlink == 1 {
+			if err := os.Remove(object); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return os.Remove(dir)
+}
+
+// DiffSize calculates the changes between the specified id
+// and its parent and returns the size in bytes of the changes
+// relative to its base filesystem directory.
+func (d *Driver) DiffSize(id, parent string) (size int64, err error) {
+	return d.naiveDiff.DiffSize(id, parent)
+}
+
+// Diff produces an archive of the changes between the specified
+// layer and its parent layer which may be "".
+func (d *Driver) Diff(id, parent string) (io.ReadCloser, error) {
+	return d.naiveDiff.Diff(id, parent)
+}
+
+// Changes produces a list of changes between the specified layer and its
+// parent layer. If parent is "", then all changes will be ADD changes.
+func (d *Driver) Changes(id, parent string) ([]archive.Change, error) {
+	return d.naiveDiff.Changes(id, parent)
+}
+
+var _ graphdriver.Driver = &Driver{}
@@ -0,0 +1,228 @@
+// +build linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/reexec"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func init() {
+	// Do not use chroot to speed run time and allow archive
+	// errors or hangs to be debugged directly from the test process.
+	untar = archive.UntarUncompressed
+	graphdriver.ApplyUncompressedLayer = archive.ApplyUncompressedLayer
+
+	reexec.Init()
+}
+
+func newTestDriver(t *testing.T) (*Driver, func()) {
+	if os.Getuid() != 0 {
+		t.Skip("the erofs driver requires root")
+	}
+	dir, err := ioutil.TempDir("", "erofs-driver")
+	assert.NilError(t, err)
+	d, err := Init(filepath.Join(dir, driverName), nil, nil, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		if graphdriver.IsDriverNotSupported(err) {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	return d.(*Driver), func() {
+		d.Cleanup()
+		os.RemoveAll(dir)
+	}
+}
+
+// layerTar returns the tar of the directory tree created by populate.
+func layerTar(t *testing.T, populate func(root string)) io.Reader {
+	root, err := ioutil.TempDir("", "erofs-layer")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+	populate(root)
+
+	rc, err := archive.Tar(root, archive.Uncompressed)
+	assert.NilError(t, err)
+	defer rc.Close()
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, rc)
+	assert.NilError(t, err)
+	return &buf
+}
+
+func listObjects(t *testing.T, d *Driver) []string {
+	var objects []string
+	err := filepath.Walk(filepath.Join(d.home, objectsDir), func(path string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			objects = append(objects, path)
+		}
+		return err
+	})
+	assert.NilError(t, err)
+	sort.Strings(objects)
+	return objects
+}
+
+func TestLayers(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+
+	assert.NilError(t, d.Create("base", "", nil))
+	_, err := d.ApplyDiff("base", "", layerTar(t, func(root string) {
+		assert.NilError(t, os.Mkdir(filepath.Join(root, "etc"), 0755))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "etc", "hostname"), []byte("base\n"), 0644))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "etc", "hosts"), []byte("localhost\n"), 0644))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "removed"), []byte("removed\n"), 0644))
+	}))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(listObjects(t, d), 3))
+
+	assert.NilError(t, d.Create("child", "base", nil))
+	_, err = d.ApplyDiff("child", "base", layerTar(t, func(root string) {
+		assert.NilError(t, os.Mkdir(filepath.Join(root, "etc"), 0755))
+		// the same data as a file of the base layer
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "etc", "hostname"), []byte("localhost\n"), 0644))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, ".wh.removed"), nil, 0644))
+	}))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(listObjects(t, d), 3), "the objects must be shared by the layers")
+
+	metadata, err := d.GetMetadata("child")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(metadata["Image"], filepath.Join(d.home, "child", imageFile)))
+
+	assert.NilError(t, d.CreateReadWrite("container", "child", nil))
+	fs, err := d.Get("container", "")
+	assert.NilError(t, err)
+	root := fs.Path()
+	b, err := ioutil.ReadFile(filepath.Join(root, "etc", "hostname"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "localhost\n"))
+	b, err = ioutil.ReadFile(filepath.Join(root, "etc", "hosts"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "localhost\n"))
+	_, err = os.Stat(filepath.Join(root, "removed"))
+	assert.Check(t, os.IsNotExist(err))
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "etc", "hosts"), []byte("changed\n"), 0644))
+	assert.NilError(t, d.Put("container"))
+
+	changes, err := d.Changes("container", "child")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(changes, []archive.Change{
+		{Path: "/etc", Kind: archive.ChangeModify},
+		{Path: "/etc/hosts", Kind: archive.ChangeModify},
+	}))
+	// the objects are not modified by the writes to the containers
+	for _, object := range listObjects(t, d) {
+		b, err := ioutil.ReadFile(object)
+		assert.NilError(t, err)
+		assert.Check(t, string(b) != "changed\n")
+	}
+
+	// the read-only layers are mounted read-only
+	fs, err = d.Get("child", "")
+	assert.NilError(t, err)
+	err = ioutil.WriteFile(filepath.Join(fs.Path(), "new"), nil, 0644)
+	assert.Check(t, err != nil)
+	assert.NilError(t, d.Put("child"))
+
+	assert.NilError(t, d.Remove("container"))
+	assert.NilError(t, d.Remove("child"))
+	assert.Check(t, is.Len(listObjects(t, d), 3))
+	assert.NilError(t, d.Remove("base"))
+	assert.Check(t, is.Len(listObjects(t, d), 0), "the objects must be removed with their last layer")
+}
+
+func TestCorruptedImage(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+
+	assert.NilError(t, d.Create("base", "", nil))
+	_, err := d.ApplyDiff("base", "", layerTar(t, func(root string) {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "file"), []byte("data\n"), 0644))
+	}))
+	assert.NilError(t, err)
+
+	image := filepath.Join(d.home, "base", imageFile)
+	b, err := ioutil.ReadFile(image)
+	assert.NilError(t, err)
+	b[len(b)-1] ^= 0xff
+	assert.NilError(t, ioutil.WriteFile(image, b, 0600))
+
+	_, err = d.Get("base", "")
+	assert.Check(t, is.ErrorContains(err, "is corrupted"))
+}
+
+func TestReplaceImage(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+
+	assert.NilError(t, d.Create("base", "", nil))
+	_, err := d.ApplyDiff("base", "", layerTar(t, func(root string) {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "old"), []byte("old\n"), 0644))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "shared"), []byte("shared\n"), 0644))
+	}))
+	assert.NilError(t, err)
+	objects := listObjects(t, d)
+	assert.Assert(t, is.Len(objects, 2))
+
+	diff := func(root string) {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "new"), []byte("new\n"), 0644))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "shared"), []byte("shared\n"), 0644))
+	}
+	// the object of the new file cannot be stored
+	sum := sha256.Sum256([]byte("new\n"))
+	blocker := filepath.Join(d.home, objectsDir, hex.EncodeToString(sum[:1]))
+	assert.NilError(t, ioutil.WriteFile(blocker, nil, 0600))
+	_, err = d.ApplyDiff("base", "", layerTar(t, diff))
+	assert.Check(t, err != nil)
+
+	// the previous image and its objects are kept
+	kept := append([]string{blocker}, objects...)
+	sort.Strings(kept)
+	assert.Check(t, is.DeepEqual(listObjects(t, d), kept))
+	refs, err := filepath.Glob(filepath.Join(d.home, "base", refsDir+"*"))
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(refs, []string{filepath.Join(d.home, "base", refsDir)}))
+	fs, err := d.Get("base", "")
+	assert.NilError(t, err)
+	b, err := ioutil.ReadFile(filepath.Join(fs.Path(), "old"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "old\n"))
+	assert.NilError(t, d.Put("base"))
+
+	// the objects of the previous image are released once it is replaced
+	assert.NilError(t, os.Remove(blocker))
+	_, err = d.ApplyDiff("base", "", layerTar(t, diff))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(listObjects(t, d), 2))
+	refs, err = filepath.Glob(filepath.Join(d.home, "base", refsDir+"*"))
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(refs, []string{filepath.Join(d.home, "base", refsDir)}))
+	fs, err = d.Get("base", "")
+	assert.NilError(t, err)
+	for name, data := range map[string]string{"new": "new\n", "shared": "shared\n"} {
+		b, err := ioutil.ReadFile(filepath.Join(fs.Path(), name))
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(b), data))
+	}
+	_, err = os.Stat(filepath.Join(fs.Path(), "old"))
+	assert.Check(t, os.IsNotExist(err))
+	assert.NilError(t, d.Put("base"))
+}
@@ -0,0 +1,3 @@
+// +build !linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
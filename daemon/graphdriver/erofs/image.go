@@ -0,0 +1,490 @@
+// +build linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/system"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// The images of the layers are uncompressed EROFS images with 4096 bytes
+// blocks and extended inodes. They hold the metadata of the files of the
+// layer, the regular files being overlay metacopy files, without data, whose
+// redirect is the path of their data in the object store.
+const (
+	blockSize        = 4096
+	blockBits        = 12
+	superblockOffset = 1024
+	superMagic       = 0xe0f5e1e2
+
+	featureIncompatChunkedFile = 0x4
+
+	inodeSlotSize     = 32
+	extendedInodeSize = 64
+	direntSize        = 12
+	xattrHeaderSize   = 12
+	chunkMapEntrySize = 4
+	maxChunkBits      = 31
+	nullBlockAddr     = 0xffffffff
+
+	layoutFlatPlain  = 0
+	layoutFlatInline = 2
+	layoutChunkBased = 4
+)
+
+// file types of the directory entries
+const (
+	fileTypeUnknown = iota
+	fileTypeRegular
+	fileTypeDir
+	fileTypeCharDev
+	fileTypeBlockDev
+	fileTypeFifo
+	fileTypeSocket
+	fileTypeSymlink
+)
+
+const (
+	overlayXattrPrefix = "trusted.overlay."
+	overlayOpaque      = overlayXattrPrefix + "opaque"
+	overlayMetacopy    = overlayXattrPrefix + "metacopy"
+	overlayRedirect    = overlayXattrPrefix + "redirect"
+)
+
+// xattrPrefixes are the prefixes of the names of the extended attributes
+// which are stored by their index.
+var xattrPrefixes = []struct {
+	index  uint8
+	prefix string
+	exact  bool
+}{
+	{index: 2, prefix: "system.posix_acl_access", exact: true},
+	{index: 3, prefix: "system.posix_acl_default", exact: true},
+	{index: 1, prefix: "user."},
+	{index: 4, prefix: "trusted."},
+	{index: 6, prefix: "security."},
+}
+
+type imageXattr struct {
+	index uint8
+	name  string
+	value []byte
+}
+
+type imageDirent struct {
+	name  string
+	inode *imageInode
+}
+
+type imageInode struct {
+	mode      uint32
+	uid       uint32
+	gid       uint32
+	mtime     int64
+	mtimeNsec uint32
+	nlink     uint32
+	size      uint64
+	rdev      uint32
+	xattrs    []imageXattr
+
+	// data is the target of a symbolic link, or the content of a directory
+	// once the inodes are placed
+	data []byte
+	// blocks are the entries of a directory, per block
+	blocks [][]imageDirent
+
+	layout    uint16
+	chunkBits uint16
+	xattrSize int
+	nid       uint64
+	ino       uint32
+	blkaddr   uint32
+}
+
+func (i *imageInode) isDir() bool {
+	return i.mode&unix.S_IFMT == unix.S_IFDIR
+}
+
+// tailSize is the size of the data stored after the inode and its extended
+// attributes.
+func (i *imageInode) tailSize() int {
+	switch i.layout {
+	case layoutFlatInline:
+		return int(i.size)
+	case layoutChunkBased:
+		return chunkMapEntrySize
+	default:
+		return 0
+	}
+}
+
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// objectFunc stores the data of a regular file in the object store, and
+// returns the path of the object in the object store.
+type objectFunc func(path string, fi os.FileInfo) (string, error)
+
+type imageBuilder struct {
+	object objectFunc
+	links  map[fileID]*imageInode
+	inodes []*imageInode
+}
+
+// writeImage writes the EROFS image of the directory tree at root to w. The
+// data of the regular files are stored in the object store with object.
+func writeImage(root string, w io.Writer, object objectFunc) error {
+	b := &imageBuilder{object: object, links: make(map[fileID]*imageInode)}
+	fi, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	rootInode, err := b.add(root, fi, nil)
+	if err != nil {
+		return err
+	}
+	return b.write(w, rootInode)
+}
+
+func (b *imageBuilder) add(path string, fi os.FileInfo, parent *imageInode) (*imageInode, error) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, fmt.Errorf("unable to get raw syscall.Stat_t data for %s", path)
+	}
+	id := fileID{dev: uint64(st.Dev), ino: st.Ino}
+	if !fi.IsDir() && st.Nlink > 1 {
+		if inode, ok := b.links[id]; ok {
+			inode.nlink++
+			return inode, nil
+		}
+	}
+
+	inode := &imageInode{
+		mode:      st.Mode,
+		uid:       st.Uid,
+		gid:       st.Gid,
+		mtime:     int64(st.Mtim.Sec),
+		mtimeNsec: uint32(st.Mtim.Nsec),
+		nlink:     1,
+		ino:       uint32(len(b.inodes) + 1),
+	}
+	b.inodes = append(b.inodes, inode)
+	if !fi.IsDir() && st.Nlink > 1 {
+		b.links[id] = inode
+	}
+
+	xattrs, err := readXattrs(path)
+	if err != nil {
+		return nil, err
+	}
+	inode.xattrs = xattrs
+
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFDIR:
+		if parent == nil {
+			parent = inode
+		}
+		if err := b.addDir(path, inode, parent); err != nil {
+			return nil, err
+		}
+	case unix.S_IFREG:
+		if st.Size > 0 {
+			redirect, err := b.object(path, fi)
+			if err != nil {
+				return nil, err
+			}
+			inode.xattrs = append(inode.xattrs,
+				imageXattr{index: 4, name: strings.TrimPrefix(overlayMetacopy, "trusted.")},
+				imageXattr{index: 4, name: strings.TrimPrefix(overlayRedirect, "trusted."), value: []byte(redirect)},
+			)
+			inode.size = uint64(st.Size)
+			inode.layout = layoutChunkBased
+			for inode.chunkBits = 0; uint64(blockSize)<<inode.chunkBits < inode.size; inode.chunkBits++ {
+				if inode.chunkBits == maxChunkBits {
+					return nil, fmt.Errorf("%s is too large", path)
+				}
+			}
+		}
+	case unix.S_IFLNK:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		inode.data = []byte(target)
+		inode.size = uint64(len(inode.data))
+	case unix.S_IFCHR, unix.S_IFBLK:
+		major, minor := uint32(unix.Major(uint64(st.Rdev))), uint32(unix.Minor(uint64(st.Rdev)))
+		inode.rdev = (minor & 0xff) | (major << 8) | ((minor &^ 0xff) << 12)
+	}
+
+	for _, x := range inode.xattrs {
+		inode.xattrSize += (4 + len(x.name) + len(x.value) + 3) &^ 3
+	}
+	if inode.xattrSize > 0 {
+		inode.xattrSize += xattrHeaderSize
+	}
+	if inode.xattrSize > 0xffff*4 {
+		return nil, fmt.Errorf("the extended attributes of %s are too large", path)
+	}
+	if inode.layout != layoutChunkBased && inode.size > 0 {
+		if extendedInodeSize+inode.xattrSize+int(inode.size) <= blockSize {
+			inode.layout = layoutFlatInline
+		} else {
+			inode.layout = layoutFlatPlain
+		}
+	}
+	return inode, nil
+}
+
+func (b *imageBuilder) addDir(path string, inode, parent *imageInode) error {
+	children, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	entries := []imageDirent{{name: ".", inode: inode}, {name: "..", inode: parent}}
+	inode.nlink = 2
+	for _, fi := range children {
+		child, err := b.add(filepath.Join(path, fi.Name()), fi, inode)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			inode.nlink++
+		}
+		entries = append(entries, imageDirent{name: fi.Name(), inode: child})
+	}
+	// The entries are looked up with a binary search.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	used := 0
+	var block []imageDirent
+	for _, e := range entries {
+		if used+direntSize+len(e.name) > blockSize {
+			inode.blocks = append(inode.blocks, block)
+			block, used = nil, 0
+		}
+		block = append(block, e)
+		used += direntSize + len(e.name)
+	}
+	inode.blocks = append(inode.blocks, block)
+	inode.size = uint64((len(inode.blocks)-1)*blockSize + used)
+	return nil
+}
+
+func readXattrs(path string) ([]imageXattr, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil {
+		if err == unix.ENOTSUP {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list the extended attributes of %s", path)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Llistxattr(path, buf); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the extended attributes of %s", path)
+	}
+
+	var xattrs []imageXattr
+	for _, name := range strings.Split(strings.TrimRight(string(buf[:size]), "\x00"), "\x00") {
+		// The overlay attributes of the layer are set by the driver, only
+		// the opaque directories of the layer are kept.
+		if strings.HasPrefix(name, overlayXattrPrefix) && name != overlayOpaque {
+			continue
+		}
+		var x *imageXattr
+		for _, p := range xattrPrefixes {
+			if (p.exact && name == p.prefix) || (!p.exact && strings.HasPrefix(name, p.prefix)) {
+				x = &imageXattr{index: p.index, name: strings.TrimPrefix(name, p.prefix)}
+				break
+			}
+		}
+		if x == nil {
+			continue
+		}
+		if x.value, err = system.Lgetxattr(path, name); err != nil {
+			return nil, err
+		}
+		if len(x.value) > 0xffff {
+			return nil, fmt.Errorf("the extended attribute %s of %s is too large", name, path)
+		}
+		xattrs = append(xattrs, *x)
+	}
+	sort.Slice(xattrs, func(i, j int) bool {
+		if xattrs[i].index != xattrs[j].index {
+			return xattrs[i].index < xattrs[j].index
+		}
+		return xattrs[i].name < xattrs[j].name
+	})
+	return xattrs, nil
+}
+
+func alignUp(n, align int64) int64 {
+	return (n + align - 1) / align * align
+}
+
+func (b *imageBuilder) write(w io.Writer, root *imageInode) error {
+	// The inodes are placed in the metadata blocks, starting at the second
+	// block, the data blocks following. The inodes with inline data do not
+	// cross a block boundary.
+	off := int64(blockSize)
+	for _, inode := range b.inodes {
+		size := int64(extendedInodeSize + inode.xattrSize + inode.tailSize())
+		if size <= blockSize && off%blockSize+size > blockSize {
+			off = alignUp(off, blockSize)
+		}
+		inode.nid = uint64(off-blockSize) / inodeSlotSize
+		off += alignUp(size, inodeSlotSize)
+	}
+	if root.nid > 0xffff {
+		return errors.New("the root inode is out of range")
+	}
+	blkaddr := uint32(alignUp(off, blockSize) / blockSize)
+	metaBlocks := blkaddr
+	for _, inode := range b.inodes {
+		if inode.layout == layoutFlatPlain && inode.size > 0 {
+			inode.blkaddr = blkaddr
+			blkaddr += uint32(alignUp(int64(inode.size), blockSize) / blockSize)
+		}
+	}
+
+	meta := make([]byte, int64(metaBlocks)*blockSize)
+	chunked := false
+	for _, inode := range b.inodes {
+		if inode.isDir() {
+			inode.data = dirData(inode)
+		}
+		if inode.layout == layoutChunkBased {
+			chunked = true
+		}
+		encodeInode(meta[blockSize+inode.nid*inodeSlotSize:], inode)
+	}
+
+	sb := meta[superblockOffset:]
+	le := binary.LittleEndian
+	le.PutUint32(sb[0:], superMagic)
+	sb[12] = blockBits
+	le.PutUint16(sb[14:], uint16(root.nid))
+	le.PutUint64(sb[16:], uint64(len(b.inodes)))
+	le.PutUint32(sb[36:], blkaddr)
+	le.PutUint32(sb[40:], 1)
+	if chunked {
+		le.PutUint32(sb[80:], featureIncompatChunkedFile)
+	}
+
+	if _, err := w.Write(meta); err != nil {
+		return err
+	}
+	for _, inode := range b.inodes {
+		if inode.layout == layoutFlatPlain && inode.size > 0 {
+			data := make([]byte, alignUp(int64(inode.size), blockSize))
+			copy(data, inode.data)
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func encodeInode(buf []byte, inode *imageInode) {
+	le := binary.LittleEndian
+	le.PutUint16(buf[0:], 1|inode.layout<<1)
+	if inode.xattrSize > 0 {
+		le.PutUint16(buf[2:], uint16((inode.xattrSize-xattrHeaderSize)/4+1))
+	}
+	le.PutUint16(buf[4:], uint16(inode.mode))
+	le.PutUint64(buf[8:], inode.size)
+	switch {
+	case inode.layout == layoutChunkBased:
+		le.PutUint16(buf[16:], inode.chunkBits)
+	case inode.mode&unix.S_IFMT == unix.S_IFCHR || inode.mode&unix.S_IFMT == unix.S_IFBLK:
+		le.PutUint32(buf[16:], inode.rdev)
+	default:
+		le.PutUint32(buf[16:], inode.blkaddr)
+	}
+	le.PutUint32(buf[20:], inode.ino)
+	le.PutUint32(buf[24:], inode.uid)
+	le.PutUint32(buf[28:], inode.gid)
+	le.PutUint64(buf[32:], uint64(inode.mtime))
+	le.PutUint32(buf[40:], inode.mtimeNsec)
+	le.PutUint32(buf[44:], inode.nlink)
+
+	p := buf[extendedInodeSize:]
+	if inode.xattrSize > 0 {
+		// The header holds the name filter and the shared attributes, none
+		// of which are used.
+		p = p[xattrHeaderSize:]
+		for _, x := range inode.xattrs {
+			p[0] = uint8(len(x.name))
+			p[1] = x.index
+			le.PutUint16(p[2:], uint16(len(x.value)))
+			copy(p[4:], x.name)
+			copy(p[4+len(x.name):], x.value)
+			p = p[(4+len(x.name)+len(x.value)+3)&^3:]
+		}
+	}
+	switch inode.layout {
+	case layoutFlatInline:
+		copy(p, inode.data)
+	case layoutChunkBased:
+		le.PutUint32(p, nullBlockAddr)
+	}
+}
+
+func dirData(inode *imageInode) []byte {
+	le := binary.LittleEndian
+	data := make([]byte, 0, inode.size)
+	for i, block := range inode.blocks {
+		buf := make([]byte, blockSize)
+		nameoff := direntSize * len(block)
+		for j, e := range block {
+			d := buf[j*direntSize:]
+			le.PutUint64(d[0:], e.inode.nid)
+			le.PutUint16(d[8:], uint16(nameoff))
+			d[10] = fileType(e.inode.mode)
+			nameoff += copy(buf[nameoff:], e.name)
+		}
+		if i == len(inode.blocks)-1 {
+			buf = buf[:nameoff]
+		}
+		data = append(data, buf...)
+	}
+	return data
+}
+
+func fileType(mode uint32) uint8 {
+	switch mode & unix.S_IFMT {
+	case unix.S_IFREG:
+		return fileTypeRegular
+	case unix.S_IFDIR:
+		return fileTypeDir
+	case unix.S_IFCHR:
+		return fileTypeCharDev
+	case unix.S_IFBLK:
+		return fileTypeBlockDev
+	case unix.S_IFIFO:
+		return fileTypeFifo
+	case unix.S_IFSOCK:
+		return fileTypeSocket
+	case unix.S_IFLNK:
+		return fileTypeSymlink
+	default:
+		return fileTypeUnknown
+	}
+}
@@ -0,0 +1,111 @@
+// +build linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/system"
+	"golang.org/x/sys/unix"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestWriteImage(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("mounting images requires root")
+	}
+	if err := supportsFilesystem("erofs"); err != nil {
+		t.Skip(err)
+	}
+
+	dir, err := ioutil.TempDir("", "erofs-image")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "etc", "empty"), 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "etc", "hostname"), []byte("hello\n"), 0640))
+	assert.NilError(t, os.Link(filepath.Join(root, "etc", "hostname"), filepath.Join(root, "etc", "hostname.link")))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "empty"), nil, 0600))
+	assert.NilError(t, os.Symlink("etc/hostname", filepath.Join(root, "short")))
+	// too long to be inlined
+	long := strings.Repeat("a/", 2040)
+	assert.NilError(t, os.Symlink(long, filepath.Join(root, "long")))
+	assert.NilError(t, unix.Mknod(filepath.Join(root, "whiteout"), unix.S_IFCHR, 0))
+	assert.NilError(t, system.Lsetxattr(filepath.Join(root, "etc"), overlayOpaque, []byte("y"), 0))
+	assert.NilError(t, system.Lsetxattr(filepath.Join(root, "etc"), overlayRedirect, []byte("/ignored"), 0))
+	assert.NilError(t, system.Lsetxattr(filepath.Join(root, "etc", "hostname"), "user.comment", []byte("host name"), 0))
+	// enough entries for the directory to span several blocks
+	assert.NilError(t, os.Mkdir(filepath.Join(root, "many"), 0700))
+	for i := 0; i < 500; i++ {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(root, "many", fmt.Sprintf("file-%03d", i)), nil, 0644))
+	}
+
+	var objects []string
+	image := filepath.Join(dir, "image.erofs")
+	f, err := os.Create(image)
+	assert.NilError(t, err)
+	err = writeImage(root, f, func(path string, fi os.FileInfo) (string, error) {
+		objects = append(objects, path)
+		return "/objects/" + fi.Name(), nil
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, f.Close())
+	assert.Check(t, is.DeepEqual(objects, []string{filepath.Join(root, "etc", "hostname")}))
+
+	mnt := filepath.Join(dir, "mnt")
+	assert.NilError(t, os.Mkdir(mnt, 0755))
+	assert.NilError(t, mountImage(image, mnt))
+	defer unix.Unmount(mnt, unix.MNT_DETACH)
+
+	fi, err := os.Lstat(filepath.Join(mnt, "etc", "hostname"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fi.Size(), int64(6)))
+	assert.Check(t, is.Equal(fi.Mode(), os.FileMode(0640)))
+	assert.Check(t, is.Equal(uint64(fi.Sys().(*syscall.Stat_t).Nlink), uint64(2)))
+	value, err := system.Lgetxattr(filepath.Join(mnt, "etc", "hostname"), overlayRedirect)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(value), "/objects/hostname"))
+	value, err = system.Lgetxattr(filepath.Join(mnt, "etc", "hostname"), "user.comment")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(value), "host name"))
+
+	value, err = system.Lgetxattr(filepath.Join(mnt, "etc"), overlayOpaque)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(value), "y"))
+	value, err = system.Lgetxattr(filepath.Join(mnt, "etc"), overlayRedirect)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(value, 0), "the overlay attributes of the layer must be dropped")
+
+	target, err := os.Readlink(filepath.Join(mnt, "short"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(target, "etc/hostname"))
+	target, err = os.Readlink(filepath.Join(mnt, "long"))
+	assert.NilError(t, err)
+	assert.Check(t, target == long, "unexpected target %q", target)
+
+	fi, err = os.Lstat(filepath.Join(mnt, "whiteout"))
+	assert.NilError(t, err)
+	assert.Check(t, fi.Mode()&os.ModeCharDevice != 0)
+	assert.Check(t, is.Equal(fi.Sys().(*syscall.Stat_t).Rdev, uint64(0)))
+
+	fi, err = os.Lstat(filepath.Join(mnt, "empty"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fi.Size(), int64(0)))
+
+	files, err := ioutil.ReadDir(filepath.Join(mnt, "many"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(files, 500))
+	_, err = os.Lstat(filepath.Join(mnt, "many", "file-499"))
+	assert.Check(t, err)
+	files, err = ioutil.ReadDir(filepath.Join(mnt, "etc", "empty"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(files, 0))
+}
@@ -0,0 +1,53 @@
+// +build linux
+
+package erofs // import "github.com/docker/docker/daemon/graphdriver/erofs"
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/loopback"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// supportsFilesystem checks whether the kernel supports a filesystem.
+func supportsFilesystem(fsType string) error {
+	// We can try to modprobe the filesystem first before looking at
+	// proc/filesystems for when it is supported
+	exec.Command("modprobe", fsType).Run()
+
+	f, err := os.Open("/proc/filesystems")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) > 0 && fields[len(fields)-1] == fsType {
+			return nil
+		}
+	}
+	return errors.Wrapf(graphdriver.ErrNotSupported, "'%s' not found as a supported filesystem on this host", fsType)
+}
+
+// mountImage mounts an image read-only on target, directly when the kernel
+// supports the EROFS filesystems backed by files, or else on a loop device
+// which is released when the image is unmounted.
+func mountImage(image, target string) error {
+	err := unix.Mount(image, target, "erofs", unix.MS_RDONLY, "")
+	if err != unix.ENOTBLK {
+		return errors.Wrapf(err, "error mounting image %s", image)
+	}
+
+	loop, err := loopback.AttachLoopDevice(image)
+	if err != nil {
+		return errors.Wrapf(err, "error attaching a loop device to image %s", image)
+	}
+	defer loop.Close()
+	return errors.Wrapf(unix.Mount(loop.Name(), target, "erofs", unix.MS_RDONLY, ""), "error mounting image %s", image)
+}
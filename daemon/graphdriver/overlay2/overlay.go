@@ -18,6 +18,7 @@ import (
 	"sync"
 
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/daemon/graphdriver/copy"
 	"github.com/docker/docker/daemon/graphdriver/overlayutils"
 	"github.com/docker/docker/daemon/graphdriver/quota"
 	"github.com/docker/docker/pkg/archive"
@@ -94,15 +95,16 @@ type overlayOptions struct {
 // Driver contains information about the home directory and the list of active
 // mounts that are created using this driver.
 type Driver struct {
-	home          string
-	uidMaps       []idtools.IDMap
-	gidMaps       []idtools.IDMap
-	ctr           *graphdriver.RefCounter
-	quotaCtl      *quota.Control
-	options       overlayOptions
-	naiveDiff     graphdriver.DiffDriver
-	supportsDType bool
-	locker        *locker.Locker
+	home            string
+	uidMaps         []idtools.IDMap
+	gidMaps         []idtools.IDMap
+	ctr             *graphdriver.RefCounter
+	quotaCtl        *quota.Control
+	options         overlayOptions
+	naiveDiff       graphdriver.DiffDriver
+	supportsDType   bool
+	supportsReflink bool
+	locker          *locker.Locker
 }
 
 var (
@@ -204,31 +206,40 @@ func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (grap
 		return nil, err
 	}
 
+	// The reflink support of the backing filesystem is only detected, to be
+	// reported in the status of the driver: the files are copied up by the
+	// kernel, and the layers are extracted from tar streams.
+	supportsReflink, err := copy.SupportsReflink(testdir)
+	if err != nil {
+		logger.Warnf("Unable to detect reflink support: %v", err)
+	}
+
 	d := &Driver{
-		home:          home,
-		uidMaps:       uidMaps,
-		gidMaps:       gidMaps,
-		ctr:           graphdriver.NewRefCounter(graphdriver.NewFsChecker(graphdriver.FsMagicOverlay)),
-		supportsDType: supportsDType,
-		locker:        locker.New(),
-		options:       *opts,
+		home:            home,
+		uidMaps:         uidMaps,
+		gidMaps:         gidMaps,
+		ctr:             graphdriver.NewRefCounter(graphdriver.NewFsChecker(graphdriver.FsMagicOverlay)),
+		supportsDType:   supportsDType,
+		supportsReflink: supportsReflink,
+		locker:          locker.New(),
+		options:         *opts,
 	}
 
 	d.naiveDiff = graphdriver.NewNaiveDiffDriver(d, uidMaps, gidMaps)
 
-	if backingFs == "xfs" {
-		// Try to enable project quota support over xfs.
+	if backingFs == "xfs" || backingFs == "extfs" {
+		// Try to enable project quota support over xfs or ext4.
 		if d.quotaCtl, err = quota.NewControl(home); err == nil {
 			projectQuotaSupported = true
 		} else if opts.quota.Size > 0 {
 			return nil, fmt.Errorf("Storage option overlay2.size not supported. Filesystem does not support Project Quota: %v", err)
 		}
 	} else if opts.quota.Size > 0 {
-		// if xfs is not the backing fs then error out if the storage-opt overlay2.size is used.
-		return nil, fmt.Errorf("Storage Option overlay2.size only supported for backingFS XFS. Found %v", backingFs)
+		// if xfs or ext4 is not the backing fs then error out if the storage-opt overlay2.size is used.
+		return nil, fmt.Errorf("Storage Option overlay2.size only supported for backingFS XFS or EXT4. Found %v", backingFs)
 	}
 
-	logger.Debugf("backingFs=%s,  projectQuotaSupported=%v", backingFs, projectQuotaSupported)
+	logger.Debugf("backingFs=%s,  projectQuotaSupported=%v, reflinkSupported=%v", backingFs, projectQuotaSupported, supportsReflink)
 
 	return d, nil
 }
@@ -302,6 +313,7 @@ func (d *Driver) Status() [][2]string {
 		{"Backing Filesystem", backingFs},
 		{"Supports d_type", strconv.FormatBool(d.supportsDType)},
 		{"Native Overlay Diff", strconv.FormatBool(!useNaiveDiff(d.home))},
+		{"Supports reflinks", strconv.FormatBool(d.supportsReflink)},
 	}
 }
 
@@ -341,7 +353,7 @@ func (d *Driver) Cleanup() error {
 // file system.
 func (d *Driver) CreateReadWrite(id, parent string, opts *graphdriver.CreateOpts) error {
 	if opts != nil && len(opts.StorageOpt) != 0 && !projectQuotaSupported {
-		return fmt.Errorf("--storage-opt is supported only for overlay over xfs with 'pquota' mount option")
+		return fmt.Errorf("--storage-opt is supported only for overlay over xfs or ext4 with 'pquota' or 'prjquota' mount option")
 	}
 
 	if opts == nil {
@@ -613,7 +625,7 @@ func (d *Driver) Get(id, mountLabel string) (_ containerfs.ContainerFS, retErr e
 		}
 
 		mount = func(source string, target string, mType string, flags uintptr, label string) error {
-			return mountFrom(d.home, source, target, mType, flags, label)
+			return overlayutils.MountFrom(d.home, source, target, mType, flags, label)
 		}
 		mountTarget = path.Join(id, "merged")
 	}
@@ -3,10 +3,9 @@
 //
 // projectquota.go - implements XFS project quota controls
 // for setting quota limits on a newly created directory.
-// It currently supports the legacy XFS specific ioctls.
-//
-// TODO: use generic quota control ioctl FS_IOC_FS{GET,SET}XATTR
-//       for both xfs/ext4 for kernel version >= v4.5
+// It uses the XFS quotactl interface and the FS_IOC_FS{GET,SET}XATTR
+// ioctls, which are also implemented by ext4 for kernel version >= v4.5
+// (mkfs.ext4 -O quota,project, mounted with the "prjquota" option).
 //
 
 package quota // import "github.com/docker/docker/daemon/graphdriver/quota"
@@ -83,7 +82,7 @@ type Control struct {
 // Returns nil (and error) if project quota is not supported.
 //
 // First get the project id of the home directory.
-// This test will fail if the backing fs is not xfs.
+// This test will fail if the backing fs is not xfs or ext4.
 //
 // xfs_quota tool can be used to assign a project id to the driver home directory, e.g.:
 //    echo 999:/var/lib/docker/overlay2 >> /etc/projects
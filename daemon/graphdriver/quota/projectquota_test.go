@@ -26,22 +26,8 @@ func TestBlockDev(t *testing.T) {
 		t.Skip("mkfs.xfs not found in PATH")
 	}
 
-	// create a sparse image
-	imageFile, err := ioutil.TempFile("", "xfs-image")
-	if err != nil {
-		t.Fatal(err)
-	}
-	imageFileName := imageFile.Name()
+	imageFileName := createSparseImage(t, "xfs-image")
 	defer os.Remove(imageFileName)
-	if _, err = imageFile.Seek(imageSize-1, 0); err != nil {
-		t.Fatal(err)
-	}
-	if _, err = imageFile.Write([]byte{0}); err != nil {
-		t.Fatal(err)
-	}
-	if err = imageFile.Close(); err != nil {
-		t.Fatal(err)
-	}
 
 	// The reason for disabling these options is sometimes people run with a newer userspace
 	// than kernelspace
@@ -60,6 +46,59 @@ func TestBlockDev(t *testing.T) {
 	t.Run("testRetrieveQuota", wrapMountTest(imageFileName, true, wrapQuotaTest(testRetrieveQuota)))
 }
 
+func TestBlockDevExt4(t *testing.T) {
+	mkfs, err := exec.LookPath("mkfs.ext4")
+	if err != nil {
+		t.Skip("mkfs.ext4 not found in PATH")
+	}
+
+	imageFileName := createSparseImage(t, "ext4-image")
+	defer os.Remove(imageFileName)
+
+	// Project quotas on ext4 require both the "quota" and "project" features
+	out, err := exec.Command(mkfs, "-F", "-O", "quota,project", imageFileName).CombinedOutput()
+	if len(out) > 0 {
+		t.Log(string(out))
+	}
+	if err != nil {
+		t.Skipf("mkfs.ext4 does not support project quotas: %v", err)
+	}
+
+	mountPointDir := fs.NewDir(t, "ext4-mountPoint")
+	defer mountPointDir.Remove()
+	if out, err := exec.Command("mount", "-o", "loop,prjquota", imageFileName, mountPointDir.Path()).CombinedOutput(); err != nil {
+		t.Skipf("unable to mount ext4 with project quotas: %v: %s", err, out)
+	}
+	assert.NilError(t, unix.Unmount(mountPointDir.Path(), 0))
+
+	// testBiggerThanQuota is not run, as root (CAP_SYS_RESOURCE) is allowed
+	// to exceed the quota on ext4.
+	t.Run("testBlockDevQuotaEnabled", wrapMountTest(imageFileName, true, testBlockDevQuotaEnabled))
+	t.Run("testSmallerThanQuota", wrapMountTest(imageFileName, true, wrapQuotaTest(testSmallerThanQuota)))
+	t.Run("testRetrieveQuota", wrapMountTest(imageFileName, true, wrapQuotaTest(testRetrieveQuota)))
+}
+
+func createSparseImage(t *testing.T, prefix string) string {
+	imageFile, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imageFileName := imageFile.Name()
+	if _, err = imageFile.Seek(imageSize-1, 0); err != nil {
+		os.Remove(imageFileName)
+		t.Fatal(err)
+	}
+	if _, err = imageFile.Write([]byte{0}); err != nil {
+		os.Remove(imageFileName)
+		t.Fatal(err)
+	}
+	if err = imageFile.Close(); err != nil {
+		os.Remove(imageFileName)
+		t.Fatal(err)
+	}
+	return imageFileName
+}
+
 func wrapMountTest(imageFileName string, enableQuota bool, testFunc func(t *testing.T, mountPoint, backingFsDev string)) func(*testing.T) {
 	return func(t *testing.T) {
 		mountOptions := "loop"
@@ -68,7 +107,7 @@ func wrapMountTest(imageFileName string, enableQuota bool, testFunc func(t *test
 			mountOptions = mountOptions + ",prjquota"
 		}
 
-		mountPointDir := fs.NewDir(t, "xfs-mountPoint")
+		mountPointDir := fs.NewDir(t, "quota-mountPoint")
 		defer mountPointDir.Remove()
 		mountPoint := mountPointDir.Path()
 
@@ -0,0 +1,8 @@
+// +build !exclude_graphdriver_erofs,linux
+
+package register // import "github.com/docker/docker/daemon/graphdriver/register"
+
+import (
+	// register the erofs graphdriver
+	_ "github.com/docker/docker/daemon/graphdriver/erofs"
+)
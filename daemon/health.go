@@ -179,9 +179,28 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 	current := h.Status()
 	if oldStatus != current {
 		d.LogContainerEvent(c, "health_status: "+current)
+		d.setNetworkHealth(c, current)
 	}
 }
 
+// setNetworkHealth leaves the addresses of the container out of the DNS
+// responses of its networks while it is unhealthy. The containers sharing the
+// network namespace of another container, or of a network group, do not
+// change the health of the shared endpoints.
+func (d *Daemon) setNetworkHealth(c *container.Container, status string) {
+	if d.netController == nil || c.NetworkSettings == nil || c.NetworkSettings.SandboxID == "" {
+		return
+	}
+	if c.HostConfig.NetworkMode.IsContainer() || c.HostConfig.NetworkMode.IsGroup() {
+		return
+	}
+	sb, err := d.netController.SandboxByID(c.NetworkSettings.SandboxID)
+	if err != nil {
+		return
+	}
+	sb.SetHealthy(status != types.Unhealthy)
+}
+
 // Run the container's monitoring thread until notified via "stop".
 // There is never more than one monitor thread running per container at a time.
 func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe) {
@@ -0,0 +1,71 @@
+// +build linux freebsd
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/daemon/config"
+	"github.com/docker/docker/errdefs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// hooksLabel is the label of the containers selecting the hooks of the daemon
+// they run, as a comma-separated list of the names of the hooks.
+const hooksLabel = "com.docker.hooks"
+
+// selectHooks returns the names of the hooks a container runs, sorted: the
+// hooks its labels select, and the hooks run for all the containers.
+func selectHooks(hooks map[string]config.HookConfig, labels map[string]string) ([]string, error) {
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(labels[hooksLabel], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := hooks[name]; !ok {
+			return nil, errdefs.InvalidParameter(fmt.Errorf("invalid %s label: unknown hook %s", hooksLabel, name))
+		}
+		selected[name] = true
+	}
+	var names []string
+	for name, h := range hooks {
+		if h.Always || selected[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// setHooks adds the hooks a container runs to its spec, after the hooks of
+// the daemon.
+func setHooks(daemon *Daemon, s *specs.Spec, labels map[string]string) error {
+	hooks := daemon.configStore.Hooks
+	names, err := selectHooks(hooks, labels)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+	if s.Hooks == nil {
+		s.Hooks = &specs.Hooks{}
+	}
+	for _, name := range names {
+		h := hooks[name]
+		hook := specs.Hook{Path: h.Path, Args: h.Args, Env: h.Env}
+		if h.Timeout > 0 {
+			timeout := h.Timeout
+			hook.Timeout = &timeout
+		}
+		switch h.Stage {
+		case config.HookStagePrestart:
+			s.Hooks.Prestart = append(s.Hooks.Prestart, hook)
+		case config.HookStagePoststart:
+			s.Hooks.Poststart = append(s.Hooks.Poststart, hook)
+		case config.HookStagePoststop:
+			s.Hooks.Poststop = append(s.Hooks.Poststop, hook)
+		}
+	}
+	return nil
+}
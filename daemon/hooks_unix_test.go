@@ -0,0 +1,42 @@
+// +build linux freebsd
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/config"
+	"github.com/docker/docker/errdefs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestSetHooks(t *testing.T) {
+	d := &Daemon{configStore: &config.Config{
+		Hooks: map[string]config.HookConfig{
+			"gpu":      {Stage: config.HookStagePrestart, Path: "/usr/bin/gpu-hook", Args: []string{"gpu-hook"}, Timeout: 10},
+			"observer": {Stage: config.HookStagePoststop, Path: "/usr/bin/observer", Always: true},
+			"tracer":   {Stage: config.HookStagePoststart, Path: "/usr/bin/tracer"},
+		},
+	}}
+	setkey := specs.Hook{Path: "/proc/1/exe", Args: []string{"libnetwork-setkey"}}
+	s := &specs.Spec{Hooks: &specs.Hooks{Prestart: []specs.Hook{setkey}}}
+
+	err := setHooks(d, s, map[string]string{hooksLabel: "gpu, "})
+	assert.NilError(t, err)
+	timeout := 10
+	assert.Check(t, is.DeepEqual(s.Hooks, &specs.Hooks{
+		Prestart: []specs.Hook{setkey, {Path: "/usr/bin/gpu-hook", Args: []string{"gpu-hook"}, Timeout: &timeout}},
+		Poststop: []specs.Hook{{Path: "/usr/bin/observer"}},
+	}))
+
+	s = &specs.Spec{}
+	err = setHooks(d, s, nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(s.Hooks, &specs.Hooks{Poststop: []specs.Hook{{Path: "/usr/bin/observer"}}}))
+
+	err = setHooks(d, &specs.Spec{}, map[string]string{hooksLabel: "gpu,unknown"})
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+	assert.Check(t, is.ErrorContains(err, "unknown hook unknown"))
+}
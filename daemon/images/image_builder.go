@@ -158,6 +158,10 @@ func (i *ImageService) pullForBuilder(ctx context.Context, name string, authConf
 		pullRegistryAuth = &resolvedConfig
 	}
 
+	if err := i.checkPullPolicies(ref); err != nil {
+		return nil, err
+	}
+
 	if err := i.pullImageWithReference(ctx, ref, platform, nil, pullRegistryAuth, output); err != nil {
 		return nil, err
 	}
@@ -190,6 +194,9 @@ func (i *ImageService) GetImageAndReleasableLayer(ctx context.Context, refOrID s
 			if !system.IsOSSupported(image.OperatingSystem()) {
 				return nil, nil, system.ErrNotSupportedOperatingSystem
 			}
+			if err := i.CheckImagePolicies(refOrID, image); err != nil {
+				return nil, nil, err
+			}
 			layer, err := newROLayerForImage(image, i.layerStores[image.OperatingSystem()])
 			return image, layer, err
 		}
@@ -202,6 +209,9 @@ func (i *ImageService) GetImageAndReleasableLayer(ctx context.Context, refOrID s
 	if !system.IsOSSupported(image.OperatingSystem()) {
 		return nil, nil, system.ErrNotSupportedOperatingSystem
 	}
+	if err := i.CheckImagePolicies(refOrID, image); err != nil {
+		return nil, nil, err
+	}
 	layer, err := newROLayerForImage(image, i.layerStores[image.OperatingSystem()])
 	return image, layer, err
 }
@@ -8,11 +8,13 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/system"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 type conflictType int
@@ -331,6 +333,9 @@ func (i *ImageService) imageDeleteHelper(imgID image.ID, records *[]types.ImageD
 	if err != nil {
 		return err
 	}
+	if err := metadata.NewImageSourceService(i.distributionMetadataStore).Delete(imgID.Digest()); err != nil {
+		logrus.WithError(err).Warnf("failed to remove the sources of image %s", imgID)
+	}
 
 	i.LogImageEvent(imgID.String(), imgID.String(), "delete")
 	*records = append(*records, types.ImageDeleteResponseItem{Deleted: imgID.String()})
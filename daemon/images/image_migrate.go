@@ -0,0 +1,160 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	containerderrors "github.com/containerd/containerd/errdefs"
+	containerdimages "github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/system"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// MigrateImages copies the images to the content store of containerd, as
+// images of the namespace of ctx, and unpacks them with the snapshotter,
+// without pulling them again. The layers are copied uncompressed from the
+// layer stores, their digests being their DiffIDs.
+//
+// The images are named by their references, and by their ID for the
+// containers using them and the untagged images. The images which are
+// already migrated are only updated. It returns the IDs of the migrated
+// images.
+func (i *ImageService) MigrateImages(ctx context.Context, client *containerd.Client, snapshotter string) ([]string, error) {
+	var migrated []string
+	for id, img := range i.imageStore.Map() {
+		if err := ctx.Err(); err != nil {
+			return migrated, err
+		}
+		if !system.IsOSSupported(img.OperatingSystem()) {
+			continue
+		}
+		desc, err := i.migrateImage(ctx, client.ContentStore(), img)
+		if err != nil {
+			return migrated, errors.Wrapf(err, "error migrating image %s", id)
+		}
+
+		names := []string{id.String()}
+		for _, ref := range i.referenceStore.References(id.Digest()) {
+			names = append(names, ref.String())
+		}
+		for _, name := range names {
+			if err := createOrUpdateImage(ctx, client.ImageService(), containerdimages.Image{Name: name, Target: desc}); err != nil {
+				return migrated, errors.Wrapf(err, "error migrating image %s", id)
+			}
+		}
+
+		platform := platforms.Normalize(ocispec.Platform{OS: img.OperatingSystem(), Architecture: img.BaseImgArch()})
+		cimg := containerd.NewImageWithPlatform(client, containerdimages.Image{Name: id.String(), Target: desc}, platforms.Only(platform))
+		if err := cimg.Unpack(ctx, snapshotter); err != nil {
+			return migrated, errors.Wrapf(err, "error unpacking image %s", id)
+		}
+		logrus.WithField("image", id).WithField("snapshotter", snapshotter).Debug("migrated image")
+		migrated = append(migrated, id.String())
+	}
+	return migrated, nil
+}
+
+// migrateImage writes the layers, the config and a manifest of the image to
+// the content store, returning the descriptor of the manifest.
+func (i *ImageService) migrateImage(ctx context.Context, cs content.Store, img *image.Image) (ocispec.Descriptor, error) {
+	ls, ok := i.layerStores[img.OperatingSystem()]
+	if !ok {
+		return ocispec.Descriptor{}, errors.Errorf("no layer store for %s", img.OperatingSystem())
+	}
+
+	m := schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+		Config: distribution.Descriptor{
+			MediaType: schema2.MediaTypeImageConfig,
+			Digest:    img.ID().Digest(),
+			Size:      int64(len(img.RawJSON())),
+		},
+	}
+	configDesc := ocispec.Descriptor{MediaType: m.Config.MediaType, Digest: m.Config.Digest, Size: m.Config.Size}
+	if err := content.WriteBlob(ctx, cs, "migrate-"+configDesc.Digest.String(), bytes.NewReader(img.RawJSON()), configDesc); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	labels := map[string]string{"containerd.io/gc.ref.content.config": configDesc.Digest.String()}
+
+	for n, diffID := range img.RootFS.DiffIDs {
+		desc, err := migrateLayer(ctx, cs, ls, layer.CreateChainID(img.RootFS.DiffIDs[:n+1]), diffID)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		m.Layers = append(m.Layers, distribution.Descriptor{MediaType: desc.MediaType, Digest: desc.Digest, Size: desc.Size})
+		labels[fmt.Sprintf("containerd.io/gc.ref.content.l.%d", n)] = desc.Digest.String()
+	}
+
+	dm, err := schema2.FromStruct(m)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	_, payload, err := dm.Payload()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: schema2.MediaTypeManifest,
+		Digest:    digest.FromBytes(payload),
+		Size:      int64(len(payload)),
+	}
+	if err := content.WriteBlob(ctx, cs, "migrate-"+desc.Digest.String(), bytes.NewReader(payload), desc, content.WithLabels(labels)); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// migrateLayer writes the uncompressed tar stream of the layer to the
+// content store, unless it is already there.
+func migrateLayer(ctx context.Context, cs content.Store, ls layer.Store, chainID layer.ChainID, diffID layer.DiffID) (ocispec.Descriptor, error) {
+	desc := ocispec.Descriptor{MediaType: schema2.MediaTypeUncompressedLayer, Digest: digest.Digest(diffID)}
+	if info, err := cs.Info(ctx, desc.Digest); err == nil {
+		desc.Size = info.Size
+		return desc, nil
+	}
+
+	l, err := ls.Get(chainID)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer layer.ReleaseAndLog(ls, l)
+	ts, err := l.TarStream()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer ts.Close()
+
+	// the size of the tar stream is not known beforehand, the digest is
+	// verified when its content is committed
+	if err := content.WriteBlob(ctx, cs, "migrate-"+desc.Digest.String(), ts, desc); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	info, err := cs.Info(ctx, desc.Digest)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc.Size = info.Size
+	return desc, nil
+}
+
+func createOrUpdateImage(ctx context.Context, is containerdimages.Store, img containerdimages.Image) error {
+	if _, err := is.Create(ctx, img); err != nil {
+		if !containerderrors.IsAlreadyExists(err) {
+			return err
+		}
+		_, err = is.Update(ctx, img, "target")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/names"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ImagePolicyModeEnforce is the mode of the image policies rejecting the
+	// images violating them.
+	ImagePolicyModeEnforce = "enforce"
+	// ImagePolicyModeAudit is the mode of the image policies only logging
+	// the images violating them, so that a policy can be tried before it is
+	// enforced.
+	ImagePolicyModeAudit = "audit"
+)
+
+// imagePolicyStore holds the image policies of the daemon, by name.
+type imagePolicyStore struct {
+	sync.Mutex
+	path     string
+	policies map[string]types.ImagePolicy
+}
+
+// LoadImagePolicies loads the image policies persisted in the file at path,
+// the policies created afterwards being persisted in the same file.
+func (i *ImageService) LoadImagePolicies(path string) error {
+	s := &imagePolicyStore{
+		path:     path,
+		policies: make(map[string]types.ImagePolicy),
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		var policies []types.ImagePolicy
+		if err := json.Unmarshal(b, &policies); err != nil {
+			return errors.Wrap(err, "failed to load the image policies")
+		}
+		for _, p := range policies {
+			s.policies[p.Name] = p
+		}
+	}
+	i.policies = s
+	return nil
+}
+
+// list returns the policies sorted by name. The store must be locked.
+func (s *imagePolicyStore) list() []types.ImagePolicy {
+	policies := make([]types.ImagePolicy, 0, len(s.policies))
+	for _, p := range s.policies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies
+}
+
+// save persists the policies. The store must be locked.
+func (s *imagePolicyStore) save() error {
+	b, err := json.Marshal(s.list())
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(s.path, b, 0600)
+}
+
+func validateImagePolicy(p *types.ImagePolicy) error {
+	if p.Name == "" || !names.RestrictedNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid image policy name %q, only %s are allowed", p.Name, names.RestrictedNameChars)
+	}
+	switch p.Mode {
+	case "":
+		p.Mode = ImagePolicyModeEnforce
+	case ImagePolicyModeEnforce, ImagePolicyModeAudit:
+	default:
+		return fmt.Errorf("invalid image policy mode %q, the mode is either %q or %q", p.Mode, ImagePolicyModeEnforce, ImagePolicyModeAudit)
+	}
+	for _, r := range append(append([]string{}, p.AllowedRegistries...), p.DeniedRegistries...) {
+		if r == "" || strings.ContainsAny(r, "/ ") {
+			return fmt.Errorf("invalid registry %q, the registries are hostnames with an optional port, e.g. docker.io", r)
+		}
+	}
+	for k := range p.RequiredLabels {
+		if k == "" {
+			return errors.New("invalid required label: empty label name")
+		}
+	}
+	for _, k := range p.ForbiddenLabels {
+		if k == "" {
+			return errors.New("invalid forbidden label: empty label name")
+		}
+		if _, ok := p.RequiredLabels[k]; ok {
+			return fmt.Errorf("label %q cannot be both required and forbidden", k)
+		}
+	}
+	if p.MaxAge != "" {
+		d, err := time.ParseDuration(p.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max age %q: %v", p.MaxAge, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid max age %q: the max age must be positive", p.MaxAge)
+		}
+	}
+	if len(p.AllowedRegistries) == 0 && len(p.DeniedRegistries) == 0 && len(p.RequiredLabels) == 0 && len(p.ForbiddenLabels) == 0 && p.MaxAge == "" {
+		return fmt.Errorf("image policy %s has no rule", p.Name)
+	}
+	return nil
+}
+
+// CreateImagePolicy creates a policy admitting or rejecting the images by
+// their registry, their labels, and their age.
+func (i *ImageService) CreateImagePolicy(p types.ImagePolicy) (types.ImagePolicy, error) {
+	if err := validateImagePolicy(&p); err != nil {
+		return types.ImagePolicy{}, errdefs.InvalidParameter(err)
+	}
+
+	s := i.policies
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.policies[p.Name]; ok {
+		return types.ImagePolicy{}, errdefs.Conflict(fmt.Errorf("image policy %s already exists", p.Name))
+	}
+	s.policies[p.Name] = p
+	if err := s.save(); err != nil {
+		delete(s.policies, p.Name)
+		return types.ImagePolicy{}, err
+	}
+	return p, nil
+}
+
+// ImagePolicies returns the image policies of the daemon.
+func (i *ImageService) ImagePolicies() []types.ImagePolicy {
+	s := i.policies
+	s.Lock()
+	defer s.Unlock()
+	return s.list()
+}
+
+// ImagePolicy returns the image policy with the given name.
+func (i *ImageService) ImagePolicy(name string) (types.ImagePolicy, error) {
+	s := i.policies
+	s.Lock()
+	defer s.Unlock()
+	p, ok := s.policies[name]
+	if !ok {
+		return types.ImagePolicy{}, errdefs.NotFound(fmt.Errorf("image policy %s not found", name))
+	}
+	return p, nil
+}
+
+// DeleteImagePolicy removes the image policy with the given name.
+func (i *ImageService) DeleteImagePolicy(name string) error {
+	s := i.policies
+	s.Lock()
+	defer s.Unlock()
+	p, ok := s.policies[name]
+	if !ok {
+		return errdefs.NotFound(fmt.Errorf("image policy %s not found", name))
+	}
+	delete(s.policies, name)
+	if err := s.save(); err != nil {
+		s.policies[name] = p
+		return err
+	}
+	return nil
+}
+
+// CheckImagePolicies evaluates the image policies for an image referenced by
+// refOrID, the images of the containers created and the images builds are
+// FROM. The registries of the image are the registries it was pulled from,
+// whatever its references, so that tagging or loading an image does not make
+// it come from another registry.
+func (i *ImageService) CheckImagePolicies(refOrID string, img *image.Image) error {
+	return i.checkImagePolicies(refOrID, i.imageRegistries(img), img)
+}
+
+// checkPullPolicies evaluates the registry rules of the image policies for an
+// image about to be pulled, the other rules applying to the image once it is
+// used.
+func (i *ImageService) checkPullPolicies(ref reference.Named) error {
+	return i.checkImagePolicies(reference.FamiliarString(ref), []string{reference.Domain(ref)}, nil)
+}
+
+// imageRegistries returns the registries the image was pulled from, as
+// recorded by the puller. An image which was not pulled, e.g. a loaded or a
+// built image, has no registry.
+func (i *ImageService) imageRegistries(img *image.Image) []string {
+	sources, err := metadata.NewImageSourceService(i.distributionMetadataStore).Get(img.ID().Digest())
+	if err != nil {
+		return nil
+	}
+	var registries []string
+	seen := make(map[string]bool)
+	for _, s := range sources {
+		ref, err := reference.ParseNormalizedNamed(s)
+		if err != nil {
+			continue
+		}
+		if r := reference.Domain(ref); !seen[r] {
+			seen[r] = true
+			registries = append(registries, r)
+		}
+	}
+	return registries
+}
+
+// ImagePoliciesEnforced returns whether an image policy rejects the images
+// violating it.
+func (i *ImageService) ImagePoliciesEnforced() bool {
+	if i.policies == nil {
+		return false
+	}
+	s := i.policies
+	s.Lock()
+	defer s.Unlock()
+	for _, p := range s.policies {
+		if p.Mode == ImagePolicyModeEnforce {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *ImageService) checkImagePolicies(name string, registries []string, img *image.Image) error {
+	if i.policies == nil {
+		return nil
+	}
+	s := i.policies
+	s.Lock()
+	policies := s.list()
+	s.Unlock()
+
+	for _, p := range policies {
+		violations := imagePolicyViolations(p, registries, img, time.Now())
+		if len(violations) == 0 {
+			continue
+		}
+		if p.Mode == ImagePolicyModeAudit {
+			logrus.WithField("policy", p.Name).Warnf("image %s violates the image policy: %s", name, strings.Join(violations, ", "))
+			continue
+		}
+		return errdefs.Forbidden(fmt.Errorf("image %s is rejected by image policy %s: %s", name, p.Name, strings.Join(violations, ", ")))
+	}
+	return nil
+}
+
+// imagePolicyViolations returns the rules of the policy the image violates.
+// Only the registry rules are evaluated when img is nil.
+func imagePolicyViolations(p types.ImagePolicy, registries []string, img *image.Image, now time.Time) []string {
+	var violations []string
+	if len(p.AllowedRegistries) != 0 && len(registries) == 0 {
+		violations = append(violations, "the image is not from an allowed registry")
+	}
+	for _, r := range registries {
+		if len(p.AllowedRegistries) != 0 && !containsString(p.AllowedRegistries, r) {
+			violations = append(violations, fmt.Sprintf("registry %s is not allowed", r))
+		}
+		if containsString(p.DeniedRegistries, r) {
+			violations = append(violations, fmt.Sprintf("registry %s is denied", r))
+		}
+	}
+	if img == nil {
+		return violations
+	}
+
+	var labels map[string]string
+	if img.Config != nil {
+		labels = img.Config.Labels
+	}
+	keys := make([]string, 0, len(p.RequiredLabels))
+	for k := range p.RequiredLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, ok := labels[k]
+		switch {
+		case !ok:
+			violations = append(violations, fmt.Sprintf("required label %s is missing", k))
+		case p.RequiredLabels[k] != "" && v != p.RequiredLabels[k]:
+			violations = append(violations, fmt.Sprintf("label %s is not %q", k, p.RequiredLabels[k]))
+		}
+	}
+	for _, k := range p.ForbiddenLabels {
+		if _, ok := labels[k]; ok {
+			violations = append(violations, fmt.Sprintf("label %s is forbidden", k))
+		}
+	}
+	if p.MaxAge != "" {
+		if d, err := time.ParseDuration(p.MaxAge); err == nil && now.Sub(img.Created) > d {
+			violations = append(violations, fmt.Sprintf("the image is older than %s", p.MaxAge))
+		}
+	}
+	return violations
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
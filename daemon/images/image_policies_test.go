@@ -0,0 +1,145 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/image"
+	dockerreference "github.com/docker/docker/reference"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestImagePolicyViolations(t *testing.T) {
+	now := time.Now()
+	img := &image.Image{}
+	img.Created = now.Add(-48 * time.Hour)
+
+	p := types.ImagePolicy{
+		Name:              "internal-only",
+		AllowedRegistries: []string{"registry.example.com"},
+		RequiredLabels:    map[string]string{"com.example.team": "", "com.example.tier": "prod"},
+		ForbiddenLabels:   []string{"com.example.deprecated"},
+		MaxAge:            "24h",
+	}
+	assert.Check(t, is.DeepEqual(imagePolicyViolations(p, []string{"registry.example.com"}, nil, now), []string(nil)))
+	assert.Check(t, is.DeepEqual(imagePolicyViolations(p, []string{"docker.io"}, nil, now), []string{"registry docker.io is not allowed"}))
+	assert.Check(t, is.DeepEqual(imagePolicyViolations(p, nil, img, now), []string{
+		"the image is not from an allowed registry",
+		"required label com.example.team is missing",
+		"required label com.example.tier is missing",
+		"the image is older than 24h",
+	}))
+
+	img, err := image.NewFromJSON([]byte(`{"created":"` + now.Format(time.RFC3339Nano) + `","rootfs":{"type":"layers"},"config":{"Labels":{"com.example.team":"web","com.example.tier":"dev","com.example.deprecated":"true"}}}`))
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(imagePolicyViolations(p, []string{"registry.example.com"}, img, now), []string{
+		`label com.example.tier is not "prod"`,
+		"label com.example.deprecated is forbidden",
+	}))
+
+	p = types.ImagePolicy{Name: "no-hub", DeniedRegistries: []string{"docker.io"}}
+	assert.Check(t, is.DeepEqual(imagePolicyViolations(p, []string{"docker.io", "registry.example.com"}, img, now), []string{"registry docker.io is denied"}))
+	assert.Check(t, is.DeepEqual(imagePolicyViolations(p, nil, img, now), []string(nil)))
+}
+
+func TestValidateImagePolicy(t *testing.T) {
+	p := types.ImagePolicy{Name: "no-hub", DeniedRegistries: []string{"docker.io"}}
+	assert.NilError(t, validateImagePolicy(&p))
+	assert.Check(t, is.Equal(p.Mode, ImagePolicyModeEnforce))
+
+	for _, tc := range []struct {
+		policy types.ImagePolicy
+		err    string
+	}{
+		{types.ImagePolicy{Name: "", MaxAge: "1h"}, `invalid image policy name ""`},
+		{types.ImagePolicy{Name: "pol", Mode: "dry-run", MaxAge: "1h"}, `invalid image policy mode "dry-run"`},
+		{types.ImagePolicy{Name: "pol", AllowedRegistries: []string{"docker.io/library"}}, `invalid registry "docker.io/library"`},
+		{types.ImagePolicy{Name: "pol", MaxAge: "a month"}, `invalid max age "a month"`},
+		{types.ImagePolicy{Name: "pol", MaxAge: "-1h"}, "the max age must be positive"},
+		{types.ImagePolicy{Name: "pol", RequiredLabels: map[string]string{"a": ""}, ForbiddenLabels: []string{"a"}}, `label "a" cannot be both required and forbidden`},
+		{types.ImagePolicy{Name: "pol"}, "image policy pol has no rule"},
+	} {
+		assert.Check(t, is.ErrorContains(validateImagePolicy(&tc.policy), tc.err))
+	}
+}
+
+func TestCheckImagePolicies(t *testing.T) {
+	root, err := ioutil.TempDir("", "images-policy-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	metadataStore, err := metadata.NewFSMetadataStore(filepath.Join(root, "distribution"))
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(root, "repositories.json"))
+	assert.NilError(t, err)
+	i := &ImageService{distributionMetadataStore: metadataStore, referenceStore: referenceStore}
+	assert.NilError(t, i.LoadImagePolicies(filepath.Join(root, "image-policies.json")))
+	assert.Check(t, !i.ImagePoliciesEnforced())
+
+	fs, err := image.NewFSStoreBackend(filepath.Join(root, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(fs, nil)
+	assert.NilError(t, err)
+	id, err := imageStore.Create([]byte(`{"rootfs":{"type":"layers"},"config":{"Labels":{"com.example.team":"web"}}}`))
+	assert.NilError(t, err)
+	img, err := imageStore.Get(id)
+	assert.NilError(t, err)
+	hubRef, err := reference.ParseNormalizedNamed("web:latest")
+	assert.NilError(t, err)
+
+	_, err = i.CreateImagePolicy(types.ImagePolicy{Name: "no-hub", Mode: ImagePolicyModeAudit, DeniedRegistries: []string{"docker.io"}})
+	assert.NilError(t, err)
+	assert.NilError(t, i.checkPullPolicies(hubRef))
+	assert.Check(t, !i.ImagePoliciesEnforced())
+
+	_, err = i.CreateImagePolicy(types.ImagePolicy{Name: "no-hub", DeniedRegistries: []string{"docker.io"}})
+	assert.Check(t, errdefs.IsConflict(err))
+	assert.NilError(t, i.DeleteImagePolicy("no-hub"))
+	_, err = i.CreateImagePolicy(types.ImagePolicy{Name: "no-hub", DeniedRegistries: []string{"docker.io"}})
+	assert.NilError(t, err)
+	assert.Check(t, i.ImagePoliciesEnforced())
+
+	err = i.checkPullPolicies(hubRef)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.Error(err, "image web:latest is rejected by image policy no-hub: registry docker.io is denied"))
+
+	// The registries are the ones the image was pulled from, whatever its
+	// tags.
+	sources := metadata.NewImageSourceService(metadataStore)
+	assert.NilError(t, sources.Add(img.ID().Digest(), "registry.example.com/web"))
+	assert.NilError(t, referenceStore.AddTag(hubRef, img.ID().Digest(), false))
+	assert.NilError(t, i.CheckImagePolicies("web:latest", img))
+	assert.NilError(t, i.CheckImagePolicies(img.ID().String(), img))
+
+	assert.NilError(t, sources.Add(img.ID().Digest(), "docker.io/library/web"))
+	assert.Check(t, errdefs.IsForbidden(i.CheckImagePolicies("registry.example.com/web", img)))
+
+	// An image which was not pulled comes from no registry.
+	assert.NilError(t, sources.Delete(img.ID().Digest()))
+	assert.NilError(t, i.CheckImagePolicies("web:latest", img))
+	_, err = i.CreateImagePolicy(types.ImagePolicy{Name: "internal-only", AllowedRegistries: []string{"registry.example.com"}})
+	assert.NilError(t, err)
+	ref, err := reference.ParseNormalizedNamed("registry.example.com/web:latest")
+	assert.NilError(t, err)
+	assert.NilError(t, referenceStore.AddTag(ref, img.ID().Digest(), false))
+	err = i.CheckImagePolicies("registry.example.com/web", img)
+	assert.Check(t, is.Error(err, "image registry.example.com/web is rejected by image policy internal-only: the image is not from an allowed registry"))
+	assert.NilError(t, i.DeleteImagePolicy("internal-only"))
+
+	// The policies are persisted.
+	loaded := &ImageService{}
+	assert.NilError(t, loaded.LoadImagePolicies(filepath.Join(root, "image-policies.json")))
+	p, err := loaded.ImagePolicy("no-hub")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(p, types.ImagePolicy{Name: "no-hub", Mode: ImagePolicyModeEnforce, DeniedRegistries: []string{"docker.io"}}))
+	_, err = loaded.ImagePolicy("unknown")
+	assert.Check(t, errdefs.IsNotFound(err))
+}
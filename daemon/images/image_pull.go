@@ -6,22 +6,67 @@ import (
 	"strings"
 	"time"
 
+	"github.com/containerd/containerd/platforms"
 	dist "github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/operations"
 	"github.com/docker/docker/distribution"
 	progressutils "github.com/docker/docker/distribution/utils"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/tracing"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opencensus.io/trace"
 )
 
 // PullImage initiates a pull operation. image is the repository name to pull, and
 // tag may be either empty, or indicate a specific tag to pull.
-func (i *ImageService) PullImage(ctx context.Context, image, tag string, platform *specs.Platform, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+func (i *ImageService) PullImage(ctx context.Context, image, tag string, platform *specs.Platform, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) (retErr error) {
 	start := time.Now()
+	ctx, span := trace.StartSpan(ctx, "images.PullImage")
+	defer func() { tracing.EndSpan(span, retErr) }()
+
+	ref, err := pullReference(image, tag)
+	if err != nil {
+		return err
+	}
+
+	span.AddAttributes(trace.StringAttribute("image.ref", reference.FamiliarString(ref)))
+	if err := i.checkPullPolicies(ref); err != nil {
+		return err
+	}
+	err = i.pullImageWithReference(ctx, ref, platform, metaHeaders, authConfig, outStream)
+	imageActions.WithValues("pull").UpdateSince(start)
+	return err
+}
+
+// PullImageInBackground starts pulling an image in a background operation,
+// which continues after the client disconnects. The clients pulling the same
+// image for the same platform in the background are attached to the same
+// operation, the second return value being false for the operation already
+// running.
+func (i *ImageService) PullImageInBackground(image, tag string, platform *specs.Platform, metaHeaders map[string][]string, authConfig *types.AuthConfig) (*operations.Operation, bool, error) {
+	ref, err := pullReference(image, tag)
+	if err != nil {
+		return nil, false, err
+	}
+	target := reference.FamiliarString(ref)
+	key := "pull " + target
+	if platform != nil {
+		key += " " + platforms.Format(*platform)
+	}
+	op, started := i.operations.Start("pull", target, key, func(ctx context.Context, progress io.Writer) error {
+		return i.PullImage(ctx, image, tag, platform, metaHeaders, authConfig, progress)
+	})
+	return op, started, nil
+}
+
+// pullReference returns the reference of the image pulled, tag being either
+// empty, or a tag or a digest.
+func pullReference(image, tag string) (reference.Named, error) {
 	// Special case: "pull -a" may send an image name with a
 	// trailing :. This is ugly, but let's not break API
 	// compatibility.
@@ -29,7 +74,7 @@ func (i *ImageService) PullImage(ctx context.Context, image, tag string, platfor
 
 	ref, err := reference.ParseNormalizedNamed(image)
 	if err != nil {
-		return errdefs.InvalidParameter(err)
+		return nil, errdefs.InvalidParameter(err)
 	}
 
 	if tag != "" {
@@ -42,13 +87,10 @@ func (i *ImageService) PullImage(ctx context.Context, image, tag string, platfor
 			ref, err = reference.WithTag(ref, tag)
 		}
 		if err != nil {
-			return errdefs.InvalidParameter(err)
+			return nil, errdefs.InvalidParameter(err)
 		}
 	}
-
-	err = i.pullImageWithReference(ctx, ref, platform, metaHeaders, authConfig, outStream)
-	imageActions.WithValues("pull").UpdateSince(start)
-	return err
+	return ref, nil
 }
 
 func (i *ImageService) pullImageWithReference(ctx context.Context, ref reference.Named, platform *specs.Platform, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
@@ -2,7 +2,13 @@ package images // import "github.com/docker/docker/daemon/images"
 
 import (
 	"github.com/docker/distribution/reference"
+	imagetypes "github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
+	dockerreference "github.com/docker/docker/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // TagImage creates the tag specified by newTag, pointing to the image named
@@ -39,3 +45,99 @@ func (i *ImageService) TagImageWithReference(imageID image.ID, newTag reference.
 	i.LogImageEvent(imageID.String(), reference.FamiliarString(newTag), "tag")
 	return nil
 }
+
+// TagImages applies the tag operations in order, all at once or none at all
+// when any of them fails, the errors of the operations being reported by
+// their results.
+func (i *ImageService) TagImages(ops []imagetypes.TagOperation) (*imagetypes.TagResponse, error) {
+	if len(ops) == 0 {
+		return nil, errdefs.InvalidParameter(errors.New("no tag operations"))
+	}
+	resp := &imagetypes.TagResponse{Results: make([]imagetypes.TagResult, len(ops))}
+	changes := make([]dockerreference.Change, len(ops))
+	var failed bool
+	// pending are the references changed by the previous operations, the
+	// removed references being empty, for the operations to be validated
+	// in order
+	pending := make(map[string]digest.Digest)
+	for n, op := range ops {
+		res := &resp.Results[n]
+		res.Ref, res.Removed = op.Ref, op.Remove
+		change, err := i.tagChange(op, pending)
+		if err != nil {
+			res.Error = err.Error()
+			failed = true
+			continue
+		}
+		res.Ref, res.ImageID = reference.FamiliarString(change.Ref), string(change.ID)
+		if op.Remove {
+			pending[res.Ref] = ""
+			change.ID = ""
+		} else {
+			pending[res.Ref] = change.ID
+		}
+		changes[n] = change
+	}
+	if failed {
+		return resp, nil
+	}
+
+	if err := i.referenceStore.Apply(changes); err != nil {
+		changeErr, ok := err.(*dockerreference.ChangeError)
+		if !ok {
+			return nil, err
+		}
+		resp.Results[changeErr.Index].Error = changeErr.Err.Error()
+		return resp, nil
+	}
+	resp.Applied = true
+	for _, res := range resp.Results {
+		if res.Removed {
+			i.LogImageEvent(res.ImageID, res.Ref, "untag")
+			continue
+		}
+		if err := i.imageStore.SetLastUpdated(image.ID(res.ImageID)); err != nil {
+			logrus.WithError(err).WithField("image", res.ImageID).Warn("Error updating the last update time of the image")
+		}
+		i.LogImageEvent(res.ImageID, res.Ref, "tag")
+	}
+	return resp, nil
+}
+
+// tagChange returns the change of the references of a tag operation, with
+// the ID of the image the reference is added to or removed from.
+func (i *ImageService) tagChange(op imagetypes.TagOperation, pending map[string]digest.Digest) (dockerreference.Change, error) {
+	ref, err := reference.ParseNormalizedNamed(op.Ref)
+	if err != nil {
+		return dockerreference.Change{}, errdefs.InvalidParameter(err)
+	}
+
+	if op.Remove {
+		if op.Image != "" {
+			return dockerreference.Change{}, errdefs.InvalidParameter(errors.New("the image of a removal must not be set"))
+		}
+		if _, isCanonical := ref.(reference.Canonical); !isCanonical {
+			ref = reference.TagNameOnly(ref)
+		}
+		id, ok := pending[reference.FamiliarString(ref)]
+		if !ok {
+			id, err = i.referenceStore.Get(ref)
+		}
+		if err != nil || id == "" {
+			return dockerreference.Change{}, errdefs.NotFound(errors.Errorf("reference %s does not exist", reference.FamiliarString(ref)))
+		}
+		return dockerreference.Change{Ref: ref, ID: id}, nil
+	}
+
+	if _, isCanonical := ref.(reference.Canonical); isCanonical {
+		return dockerreference.Change{}, errdefs.InvalidParameter(errors.New("refusing to create a tag with a digest reference"))
+	}
+	if op.Image == "" {
+		return dockerreference.Change{}, errdefs.InvalidParameter(errors.New("the image to tag is required"))
+	}
+	img, err := i.GetImage(op.Image)
+	if err != nil {
+		return dockerreference.Change{}, err
+	}
+	return dockerreference.Change{Ref: reference.TagNameOnly(ref), ID: img.ID().Digest()}, nil
+}
@@ -0,0 +1,96 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	imagetypes "github.com/docker/docker/api/types/image"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/image"
+	dockerreference "github.com/docker/docker/reference"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func newTagTestService(t *testing.T) (*ImageService, []image.ID, func()) {
+	root, err := ioutil.TempDir("", "images-tag-test")
+	assert.NilError(t, err)
+	fs, err := image.NewFSStoreBackend(filepath.Join(root, "images"))
+	assert.NilError(t, err)
+	imageStore, err := image.NewImageStore(fs, nil)
+	assert.NilError(t, err)
+	referenceStore, err := dockerreference.NewReferenceStore(filepath.Join(root, "repositories.json"))
+	assert.NilError(t, err)
+
+	var ids []image.ID
+	for _, cmd := range []string{"one", "two"} {
+		id, err := imageStore.Create([]byte(`{"rootfs":{"type":"layers"},"config":{"Cmd":["` + cmd + `"]}}`))
+		assert.NilError(t, err)
+		ids = append(ids, id)
+	}
+	i := &ImageService{
+		imageStore:     imageStore,
+		referenceStore: referenceStore,
+		eventsService:  daemonevents.New(),
+	}
+	return i, ids, func() { os.RemoveAll(root) }
+}
+
+func TestTagImages(t *testing.T) {
+	i, ids, cleanup := newTagTestService(t)
+	defer cleanup()
+
+	resp, err := i.TagImages([]imagetypes.TagOperation{
+		{Ref: "old.example.com/app:1", Image: ids[0].String()},
+		{Ref: "old.example.com/app:2", Image: ids[1].String()},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, resp.Applied)
+
+	// a registry migration, the removals following the additions
+	resp, err = i.TagImages([]imagetypes.TagOperation{
+		{Ref: "new.example.com/app:1", Image: "old.example.com/app:1"},
+		{Ref: "new.example.com/app:2", Image: "old.example.com/app:2"},
+		{Ref: "old.example.com/app:1", Remove: true},
+		{Ref: "old.example.com/app:2", Remove: true},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, resp.Applied)
+	assert.Check(t, is.DeepEqual(resp.Results, []imagetypes.TagResult{
+		{Ref: "new.example.com/app:1", ImageID: ids[0].String()},
+		{Ref: "new.example.com/app:2", ImageID: ids[1].String()},
+		{Ref: "old.example.com/app:1", ImageID: ids[0].String(), Removed: true},
+		{Ref: "old.example.com/app:2", ImageID: ids[1].String(), Removed: true},
+	}))
+	refs := i.referenceStore.References(ids[0].Digest())
+	assert.Assert(t, is.Len(refs, 1))
+	assert.Check(t, is.Equal(reference.FamiliarString(refs[0]), "new.example.com/app:1"))
+}
+
+func TestTagImagesErrors(t *testing.T) {
+	i, ids, cleanup := newTagTestService(t)
+	defer cleanup()
+
+	_, err := i.TagImages(nil)
+	assert.Check(t, is.ErrorContains(err, "no tag operations"))
+
+	// none of the operations is applied when any of them fails
+	resp, err := i.TagImages([]imagetypes.TagOperation{
+		{Ref: "app:1", Image: ids[0].String()},
+		{Ref: "app:2", Image: "missing"},
+		{Ref: "app:1", Remove: true},
+		{Ref: "app:1", Remove: true},
+		{Ref: "Invalid", Image: ids[1].String()},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, !resp.Applied)
+	assert.Check(t, is.Equal(resp.Results[0].Error, ""))
+	assert.Check(t, is.Contains(resp.Results[1].Error, "No such image: missing"))
+	assert.Check(t, is.Equal(resp.Results[2].Error, ""))
+	assert.Check(t, is.Equal(resp.Results[3].Error, "reference app:1 does not exist"))
+	assert.Check(t, resp.Results[4].Error != "")
+	assert.Check(t, is.Len(i.referenceStore.References(ids[0].Digest()), 0))
+}
@@ -26,12 +26,18 @@ var acceptedImageFilterTags = map[string]bool{
 }
 
 // byCreated is a temporary type used to sort a list of images by creation
-// time.
+// time, and by descending ID for the images created at the same time, for
+// the order of the images to be stable.
 type byCreated []*types.ImageSummary
 
-func (r byCreated) Len() int           { return len(r) }
-func (r byCreated) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
-func (r byCreated) Less(i, j int) bool { return r[i].Created < r[j].Created }
+func (r byCreated) Len() int      { return len(r) }
+func (r byCreated) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byCreated) Less(i, j int) bool {
+	if r[i].Created == r[j].Created {
+		return r[i].ID > r[j].ID
+	}
+	return r[i].Created < r[j].Created
+}
 
 // Map returns a map of all images in the ImageStore
 func (i *ImageService) Map() map[image.ID]*image.Image {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/docker/docker/container"
 	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/daemon/operations"
 	"github.com/docker/docker/distribution"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
@@ -38,6 +39,7 @@ type ImageServiceConfig struct {
 	LayerStores               map[string]layer.Store
 	MaxConcurrentDownloads    int
 	MaxConcurrentUploads      int
+	Operations                *operations.Manager
 	ReferenceStore            dockerreference.Store
 	RegistryService           registry.Service
 	TrustKey                  libtrust.PrivateKey
@@ -54,6 +56,7 @@ func NewImageService(config ImageServiceConfig) *ImageService {
 		eventsService:             config.EventsService,
 		imageStore:                config.ImageStore,
 		layerStores:               config.LayerStores,
+		operations:                config.Operations,
 		referenceStore:            config.ReferenceStore,
 		registryService:           config.RegistryService,
 		trustKey:                  config.TrustKey,
@@ -69,6 +72,8 @@ type ImageService struct {
 	eventsService             *daemonevents.Events
 	imageStore                image.Store
 	layerStores               map[string]layer.Store // By operating system
+	operations                *operations.Manager
+	policies                  *imagePolicyStore
 	pruneRunning              int32
 	referenceStore            dockerreference.Store
 	registryService           registry.Service
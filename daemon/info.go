@@ -5,9 +5,11 @@ import (
 	"net/url"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/containerd/containerd"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/cli/debug"
@@ -57,7 +59,7 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		MemTotal:           memInfo().MemTotal,
 		GenericResources:   daemon.genericResources,
 		DockerRootDir:      daemon.configStore.Root,
-		Labels:             daemon.configStore.Labels,
+		Labels:             daemon.engineLabels(),
 		ExperimentalBuild:  daemon.configStore.Experimental,
 		ServerVersion:      dockerversion.Version,
 		ClusterStore:       daemon.configStore.ClusterStore,
@@ -67,6 +69,7 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		NoProxy:            sockets.GetProxyEnv("no_proxy"),
 		LiveRestoreEnabled: daemon.configStore.LiveRestoreEnabled,
 		Isolation:          daemon.defaultIsolation,
+		ConfigReload:       daemon.configReloadReport(),
 	}
 
 	daemon.fillAPIInfo(v)
@@ -76,6 +79,7 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 	daemon.fillPluginsInfo(v)
 	daemon.fillSecurityOptions(v, sysInfo)
 	daemon.fillLicense(v)
+	daemon.fillCapabilities(v)
 
 	return v, nil
 }
@@ -152,6 +156,50 @@ func (daemon *Daemon) fillPluginsInfo(v *types.Info) {
 	}
 }
 
+// fillCapabilities fills the optional features of the daemon, and the drivers
+// it enables from the drivers, plugins and runtimes of the info.
+func (daemon *Daemon) fillCapabilities(v *types.Info) {
+	features := []types.FeatureCapability{
+		{Name: "buildkit", Enabled: daemon.configStore.Features["buildkit"]},
+		// the images and the containers are stored by the graph drivers, the
+		// containerd snapshotters being available to migrate the storage to
+		{
+			Name:    "snapshotter",
+			Enabled: daemon.containerdCli != nil,
+			Details: map[string]string{"Mode": "migrate", "Default": containerd.DefaultSnapshotter},
+		},
+	}
+	v.Capabilities.Features = append(features, daemon.platformFeatures(v)...)
+
+	var drivers []types.DriverCapability
+	addDrivers := func(typ string, names []string) {
+		names = append([]string(nil), names...)
+		sort.Strings(names)
+		for _, name := range names {
+			drivers = append(drivers, types.DriverCapability{Type: typ, Name: name})
+		}
+	}
+	var storage []string
+	for _, gd := range daemon.graphDrivers {
+		storage = append(storage, gd)
+	}
+	addDrivers("storage", storage)
+	addDrivers("logging", v.Plugins.Log)
+	addDrivers("volume", v.Plugins.Volume)
+	addDrivers("network", v.Plugins.Network)
+	var runtimes []string
+	for name := range v.Runtimes {
+		runtimes = append(runtimes, name)
+	}
+	addDrivers("runtime", runtimes)
+	for i, d := range drivers {
+		if d.Type == "runtime" && d.Name == v.DefaultRuntime && v.RuncCommit.ID != "N/A" {
+			drivers[i].Version = v.RuncCommit.ID
+		}
+	}
+	v.Capabilities.Drivers = drivers
+}
+
 func (daemon *Daemon) fillSecurityOptions(v *types.Info, sysInfo *sysinfo.SysInfo) {
 	var securityOptions []string
 	if sysInfo.AppArmor {
@@ -5,7 +5,9 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/docker/docker/api/types"
@@ -139,6 +141,49 @@ func fillDriverWarnings(v *types.Info) {
 	}
 }
 
+// platformFeatures returns the optional features of the daemon specific to
+// the platform.
+func (daemon *Daemon) platformFeatures(v *types.Info) []types.FeatureCapability {
+	cgroup := types.FeatureCapability{Name: "cgroup", Details: map[string]string{"Driver": v.CgroupDriver}}
+	if _, err := os.Stat("/sys/fs/cgroup"); err == nil {
+		cgroup.Enabled = true
+		cgroup.Version = "1"
+		// the root of the cgroup v2 unified hierarchy lists its controllers
+		if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
+			cgroup.Version = "2"
+		}
+	}
+
+	bridge := daemon.configStore.BridgeConfig
+	ipv6NAT := types.FeatureCapability{
+		Name:    "ipv6-nat",
+		Enabled: bridge.EnableIPv6 && bridge.EnableIPTables && bridge.EnableIP6Tables,
+	}
+
+	// checkpoints are created with CRIU, and are only available with the
+	// experimental features
+	criu := types.FeatureCapability{Name: "criu", Details: map[string]string{"Checkpoint": strconv.FormatBool(daemon.HasExperimental())}}
+	if rv, err := exec.Command("criu", "--version").Output(); err == nil {
+		if criu.Version, err = parseCriuVersion(string(rv)); err != nil {
+			logrus.Warnf("failed to retrieve criu version: %s", err)
+		}
+		criu.Enabled = true
+	}
+
+	return []types.FeatureCapability{cgroup, ipv6NAT, criu}
+}
+
+// parseCriuVersion parses the output of `criu --version`, and extracts the
+// version.
+func parseCriuVersion(v string) (string, error) {
+	for _, line := range strings.Split(v, "\n") {
+		if version := strings.TrimPrefix(line, "Version: "); version != line {
+			return strings.TrimSpace(version), nil
+		}
+	}
+	return "", errors.Errorf("unknown output format: %s", v)
+}
+
 func getBackingFs(v *types.Info) string {
 	if v.DriverStatus == nil {
 		return ""
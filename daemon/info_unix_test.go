@@ -51,3 +51,12 @@ func TestParseInitVersion(t *testing.T) {
 		assert.Check(t, is.DeepEqual(test.result, ver))
 	}
 }
+
+func TestParseCriuVersion(t *testing.T) {
+	version, err := parseCriuVersion("Version: 3.13\nGitID: v3.13-12-gd2fa6a8\n")
+	assert.Check(t, err)
+	assert.Check(t, is.Equal(version, "3.13"))
+
+	_, err = parseCriuVersion("criu 3.13")
+	assert.Check(t, is.ErrorContains(err, "unknown output format"))
+}
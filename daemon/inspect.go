@@ -3,6 +3,7 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -11,9 +12,11 @@ import (
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/api/types/versions/v1p20"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/logger/loggerutils/cache"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/go-connections/nat"
+	"github.com/sirupsen/logrus"
 )
 
 // ContainerInspect returns low-level information about a
@@ -179,6 +182,17 @@ func (daemon *Daemon) getInspectData(container *container.Container) (*types.Con
 		HostConfig:   &hostConfig,
 	}
 
+	if logCachePath, err := container.LogCachePath(); err == nil {
+		if _, err := os.Stat(logCachePath); err == nil {
+			size, maxSize, err := cache.Usage(logCachePath, container.HostConfig.LogConfig.Config)
+			if err != nil {
+				logrus.WithError(err).WithField("container", container.ID).Warn("error getting the usage of the log cache")
+			} else {
+				contJSONBase.LogCache = &types.ContainerLogCache{Size: size, MaxSize: maxSize}
+			}
+		}
+	}
+
 	// Now set any platform-specific fields
 	contJSONBase = setPlatformSpecificContainerFields(container, contJSONBase)
 
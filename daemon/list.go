@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
@@ -21,6 +22,7 @@ var acceptedPsFilterTags = map[string]bool{
 	"ancestor":  true,
 	"before":    true,
 	"exited":    true,
+	"expr":      true,
 	"id":        true,
 	"isolation": true,
 	"label":     true,
@@ -79,6 +81,9 @@ type listContext struct {
 	beforeFilter *container.Snapshot
 	// sinceFilter is a filter to stop the filtering when the iterator arrives to the given container
 	sinceFilter *container.Snapshot
+	// cursor is the position of the last container of the previous page,
+	// the containers listed before it being ignored
+	cursor *container.Snapshot
 
 	// taskFilter tells if we should filter based on whether a container is part of a task
 	taskFilter bool
@@ -90,6 +95,10 @@ type listContext struct {
 	// expose is a list of exposed ports to filter with
 	expose map[nat.Port]bool
 
+	// exprs is a list of expressions to filter with, a container being
+	// included if any of them matches
+	exprs []psExpr
+
 	// ContainerListOptions is the filters set by the user
 	*types.ContainerListOptions
 }
@@ -100,7 +109,43 @@ type byCreatedDescending []container.Snapshot
 func (r byCreatedDescending) Len() int      { return len(r) }
 func (r byCreatedDescending) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
 func (r byCreatedDescending) Less(i, j int) bool {
-	return r[j].CreatedAt.UnixNano() < r[i].CreatedAt.UnixNano()
+	return listedBefore(&r[i], &r[j])
+}
+
+// listedBefore returns whether the container a is listed before b, the
+// containers being listed from the most recently created, and by ID when
+// they are created at the same time, for the pages of the list to be stable.
+func listedBefore(a, b *container.Snapshot) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID < b.ID
+	}
+	return b.CreatedAt.UnixNano() < a.CreatedAt.UnixNano()
+}
+
+// parseListCursor returns the position in the list of the last container of
+// the previous page, from a cursor made of its creation time, in seconds, and
+// of its ID, as they are listed. The container of the cursor may have been
+// removed since, the list then resuming after the containers created in a
+// later second, so that no container is skipped.
+func parseListCursor(view container.View, cursor string) (*container.Snapshot, error) {
+	parts := strings.SplitN(cursor, "_", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errdefs.InvalidParameter(errors.Errorf("invalid cursor %q: the cursor is the creation time and the ID of a container, as <created>_<id>", cursor))
+	}
+	created, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(errors.Errorf("invalid cursor %q: invalid creation time", cursor))
+	}
+
+	last, err := view.Get(parts[1])
+	switch err.(type) {
+	case nil:
+		return last, nil
+	case container.NoSuchContainerError:
+		return &container.Snapshot{CreatedAt: time.Unix(created+1, 0).Add(-time.Nanosecond)}, nil
+	default:
+		return nil, err
+	}
 }
 
 // Containers returns the list of containers to show given the user's filtering.
@@ -344,6 +389,27 @@ func (daemon *Daemon) foldFilter(view container.View, config *types.ContainerLis
 		return nil, err
 	}
 
+	var cursor *container.Snapshot
+	if config.Cursor != "" {
+		if cursor, err = parseListCursor(view, config.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	var exprs []psExpr
+	now := time.Now()
+	err = psFilters.WalkValues("expr", func(value string) error {
+		expr, err := parsePsExpr(value, now)
+		if err != nil {
+			return err
+		}
+		exprs = append(exprs, expr)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &listContext{
 		filters:              psFilters,
 		ancestorFilter:       ancestorFilter,
@@ -351,10 +417,12 @@ func (daemon *Daemon) foldFilter(view container.View, config *types.ContainerLis
 		exitAllowed:          filtExited,
 		beforeFilter:         beforeContFilter,
 		sinceFilter:          sinceContFilter,
+		cursor:               cursor,
 		taskFilter:           taskFilter,
 		isTask:               isTask,
 		publish:              publishFilter,
 		expose:               exposeFilter,
+		exprs:                exprs,
 		ContainerListOptions: config,
 		names:                view.GetAllNames(),
 	}, nil
@@ -417,6 +485,11 @@ func includeContainerInList(container *container.Snapshot, ctx *listContext) ite
 		return excludeContainer
 	}
 
+	// Do not include container if it's in the previous pages
+	if ctx.cursor != nil && !listedBefore(ctx.cursor, container) {
+		return excludeContainer
+	}
+
 	// Stop iteration when the container arrives to the filter container
 	if ctx.sinceFilter != nil {
 		if container.ID == ctx.sinceFilter.ID {
@@ -577,6 +650,19 @@ func includeContainerInList(container *container.Snapshot, ctx *listContext) ite
 		}
 	}
 
+	if len(ctx.exprs) > 0 {
+		shouldSkip := true
+		for _, expr := range ctx.exprs {
+			if expr.match(container) {
+				shouldSkip = false
+				break
+			}
+		}
+		if shouldSkip {
+			return excludeContainer
+		}
+	}
+
 	return includeContainer
 }
 
@@ -0,0 +1,419 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+// maxPsExprDepth is the maximum nesting of the parentheses and NOT operators
+// of an expression.
+const maxPsExprDepth = 32
+
+// psExpr is an expression of the expr filter of the container list, such as
+//
+//	name=~^web- AND (label.tier=frontend OR created>24h) AND NOT status=exited
+//
+// The conditions compare a field of the containers to a value, the value
+// being quoted when it contains spaces or parentheses. The fields are id,
+// name, image, status, health, created and label.<key>, and the operators
+// are =, !=, =~ and !~, the last two matching regular expressions. created
+// and the labels can also be compared with <, <=, > and >=, created to a
+// timestamp or a duration before the time the containers are listed, and the
+// labels numerically when the value is a number. label.<key> without an
+// operator matches the containers with the label. AND takes precedence over
+// OR, and the keywords are case insensitive.
+type psExpr interface {
+	match(s *container.Snapshot) bool
+}
+
+type andExpr []psExpr
+
+func (e andExpr) match(s *container.Snapshot) bool {
+	for _, sub := range e {
+		if !sub.match(s) {
+			return false
+		}
+	}
+	return true
+}
+
+type orExpr []psExpr
+
+func (e orExpr) match(s *container.Snapshot) bool {
+	for _, sub := range e {
+		if sub.match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+type notExpr struct {
+	psExpr
+}
+
+func (e notExpr) match(s *container.Snapshot) bool {
+	return !e.psExpr.match(s)
+}
+
+// labelExistsExpr matches the containers with a label.
+type labelExistsExpr string
+
+func (e labelExistsExpr) match(s *container.Snapshot) bool {
+	_, ok := s.Labels[string(e)]
+	return ok
+}
+
+// condExpr compares a field of the containers to a value.
+type condExpr struct {
+	field string
+	label string // the key of the label, for the label field
+	op    string
+	value string
+
+	re      *regexp.Regexp // for =~ and !~
+	number  float64        // for the labels compared numerically
+	numeric bool
+	created time.Time // for the created field
+}
+
+func (e *condExpr) match(s *container.Snapshot) bool {
+	switch e.field {
+	case "id":
+		return e.matchString(s.ID)
+	case "name":
+		return e.matchString(strings.TrimPrefix(s.Name, "/"))
+	case "image":
+		// the images are matched by their reference or their ID
+		if e.op == "!=" || e.op == "!~" {
+			return e.matchString(s.Image) && e.matchString(s.ImageID)
+		}
+		return e.matchString(s.Image) || e.matchString(s.ImageID)
+	case "status":
+		return e.matchString(s.State)
+	case "health":
+		return e.matchString(s.Health)
+	case "created":
+		return compare(e.op, compareTimes(s.CreatedAt, e.created))
+	default:
+		v, ok := s.Labels[e.label]
+		if !ok {
+			// a container without the label matches the negative
+			// conditions only
+			return e.op == "!=" || e.op == "!~"
+		}
+		if isOrdering(e.op) {
+			if !e.numeric {
+				return compare(e.op, strings.Compare(v, e.value))
+			}
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return false
+			}
+			return compare(e.op, compareNumbers(n, e.number))
+		}
+		return e.matchString(v)
+	}
+}
+
+func (e *condExpr) matchString(v string) bool {
+	switch e.op {
+	case "=":
+		return v == e.value
+	case "!=":
+		return v != e.value
+	case "=~":
+		return e.re.MatchString(v)
+	default: // !~
+		return !e.re.MatchString(v)
+	}
+}
+
+func isOrdering(op string) bool {
+	return op == "<" || op == "<=" || op == ">" || op == ">="
+}
+
+// compare returns whether the result of a comparison, -1, 0 or 1, satisfies
+// the operator.
+func compare(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // >=
+		return cmp >= 0
+	}
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// psExprOperators are the operators of the conditions, the longest first
+// for them to be matched before their prefixes.
+var psExprOperators = []string{"=~", "!~", "!=", "<=", ">=", "=", "<", ">"}
+
+// psExprParser parses the expressions of the expr filter.
+type psExprParser struct {
+	input string
+	pos   int
+	depth int
+	// now is the reference time of the durations compared to created.
+	now time.Time
+}
+
+// parsePsExpr parses an expression of the expr filter, the durations being
+// relative to now.
+func parsePsExpr(input string, now time.Time) (psExpr, error) {
+	p := &psExprParser{input: input, now: now}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return e, nil
+}
+
+func (p *psExprParser) errorf(format string, args ...interface{}) error {
+	return errdefs.InvalidParameter(errors.Errorf("invalid filter 'expr=%s': %s at offset %d", p.input, fmt.Sprintf(format, args...), p.pos))
+}
+
+func (p *psExprParser) skipSpaces() {
+	for p.pos < len(p.input) && isPsExprSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func isPsExprSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// keyword consumes the keyword kw, case insensitively, if it is the next
+// word of the input.
+func (p *psExprParser) keyword(kw string) bool {
+	p.skipSpaces()
+	end := p.pos + len(kw)
+	if end > len(p.input) || !strings.EqualFold(p.input[p.pos:end], kw) {
+		return false
+	}
+	if end < len(p.input) && !isPsExprSpace(p.input[end]) && p.input[end] != '(' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *psExprParser) parseOr() (psExpr, error) {
+	e, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := orExpr{e}
+	for p.keyword("OR") {
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, e)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *psExprParser) parseAnd() (psExpr, error) {
+	e, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	and := andExpr{e}
+	for p.keyword("AND") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, e)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *psExprParser) parseUnary() (psExpr, error) {
+	if p.depth++; p.depth > maxPsExprDepth {
+		return nil, p.errorf("expression nested too deeply")
+	}
+	defer func() { p.depth-- }()
+
+	if p.keyword("NOT") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e}, nil
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpaces(); p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return nil, p.errorf("missing ')'")
+		}
+		p.pos++
+		return e, nil
+	}
+	return p.parseCond()
+}
+
+func (p *psExprParser) parseCond() (psExpr, error) {
+	start := p.pos
+	for p.pos < len(p.input) && !isPsExprSpace(p.input[p.pos]) && !strings.ContainsRune("()=!~<>", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	field := p.input[start:p.pos]
+	if field == "" {
+		return nil, p.errorf("expected a condition")
+	}
+
+	e := &condExpr{field: field}
+	if strings.HasPrefix(field, "label.") {
+		e.field, e.label = "label", strings.TrimPrefix(field, "label.")
+		if e.label == "" {
+			return nil, p.errorf("missing the key of the label")
+		}
+	} else {
+		switch field {
+		case "id", "name", "image", "status", "health", "created":
+		default:
+			p.pos = start
+			return nil, p.errorf("unknown field %q", field)
+		}
+	}
+
+	p.skipSpaces()
+	for _, op := range psExprOperators {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			e.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if e.op == "" {
+		if e.field == "label" {
+			return labelExistsExpr(e.label), nil
+		}
+		return nil, p.errorf("expected an operator after %q", field)
+	}
+
+	p.skipSpaces()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	e.value = value
+
+	switch {
+	case e.op == "=~" || e.op == "!~":
+		if e.field == "created" {
+			return nil, p.errorf("operator %s cannot be used with created", e.op)
+		}
+		if e.re, err = regexp.Compile(value); err != nil {
+			return nil, p.errorf("invalid regular expression %q: %v", value, err)
+		}
+	case e.field == "created":
+		ts, err := timetypes.GetTimestamp(value, p.now)
+		if err != nil {
+			return nil, p.errorf("invalid time %q: %v", value, err)
+		}
+		sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+		if err != nil {
+			return nil, p.errorf("invalid time %q: %v", value, err)
+		}
+		e.created = time.Unix(sec, nsec)
+	case isOrdering(e.op):
+		if e.field != "label" {
+			return nil, p.errorf("operator %s cannot be used with %s", e.op, e.field)
+		}
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			e.number, e.numeric = n, true
+		}
+	}
+	return e, nil
+}
+
+// parseValue parses the value of a condition, which is quoted with double
+// quotes, with the escapes of the Go strings, with single quotes, without
+// escapes, or unquoted up to a space or parenthesis.
+func (p *psExprParser) parseValue() (string, error) {
+	if p.pos >= len(p.input) {
+		return "", p.errorf("missing the value")
+	}
+	switch q := p.input[p.pos]; q {
+	case '"', '\'':
+		end := p.pos + 1
+		for ; end < len(p.input) && p.input[end] != q; end++ {
+			if q == '"' && p.input[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(p.input) {
+			return "", p.errorf("unterminated quoted value")
+		}
+		quoted := p.input[p.pos : end+1]
+		value := quoted[1 : len(quoted)-1]
+		if q == '"' {
+			var err error
+			if value, err = strconv.Unquote(quoted); err != nil {
+				return "", p.errorf("invalid quoted value %s", quoted)
+			}
+		}
+		p.pos = end + 1
+		return value, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) && !isPsExprSpace(p.input[p.pos]) && p.input[p.pos] != '(' && p.input[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("missing the value")
+	}
+	return p.input[start:p.pos], nil
+}
@@ -0,0 +1,140 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestPsExprMatch(t *testing.T) {
+	now := time.Now()
+	s := &container.Snapshot{
+		Container: types.Container{
+			ID:      "4ce4b0c1a2c3",
+			Image:   "nginx:alpine",
+			ImageID: "sha256:d1e8",
+			State:   "running",
+			Labels:  map[string]string{"tier": "frontend", "version": "10", "team": "web platform"},
+		},
+		Name:      "/web-1",
+		CreatedAt: now.Add(-time.Hour),
+		Health:    "healthy",
+	}
+
+	for _, tc := range []struct {
+		expr  string
+		match bool
+	}{
+		{expr: "name=web-1", match: true},
+		{expr: "name=/web-1"},
+		{expr: "name=~^web-", match: true},
+		{expr: `name=~"^(web|api)-"`, match: true},
+		{expr: "name!~^web-"},
+		{expr: "id=~^4ce4", match: true},
+		{expr: "image=nginx:alpine", match: true},
+		{expr: "image=sha256:d1e8", match: true},
+		{expr: "image!=sha256:d1e8"},
+		{expr: "status=running AND health=healthy", match: true},
+		{expr: "status=exited OR health=healthy", match: true},
+		{expr: "status=exited or health=unhealthy"},
+		{expr: "NOT status=exited", match: true},
+		{expr: "not (status=running and name=web-1)"},
+		{expr: "created<30m", match: true},
+		{expr: "created>2h", match: true},
+		{expr: "created>30m"},
+		{expr: "created<2000-01-01"},
+		{expr: "label.tier=frontend", match: true},
+		{expr: "label.tier", match: true},
+		{expr: "label.missing"},
+		{expr: "label.missing=x"},
+		{expr: "label.missing!=x", match: true},
+		{expr: `label.team="web platform"`, match: true},
+		{expr: "label.team='web platform'", match: true},
+		{expr: "label.version>9", match: true},
+		{expr: "label.version>=10 AND label.version<=10", match: true},
+		// compared as strings, "10" < "9"
+		{expr: "label.version>v9"},
+		{expr: "label.tier=backend OR label.tier=frontend AND name=~web", match: true},
+		{expr: "(label.tier=backend OR label.tier=frontend) AND name=~^api"},
+		{expr: "  ( ( name = web-1 ) )  ", match: true},
+	} {
+		e, err := parsePsExpr(tc.expr, now)
+		assert.NilError(t, err, tc.expr)
+		assert.Check(t, is.Equal(e.match(s), tc.match), tc.expr)
+	}
+}
+
+func TestPsExprInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		err  string
+	}{
+		{expr: "", err: "expected a condition at offset 0"},
+		{expr: "names=web", err: `unknown field "names" at offset 0`},
+		{expr: "name", err: `expected an operator after "name"`},
+		{expr: "name=", err: "missing the value"},
+		{expr: "name=web AND", err: "expected a condition"},
+		{expr: "name=web status=running", err: `unexpected "status=running"`},
+		{expr: "(name=web", err: "missing ')'"},
+		{expr: "name=web)", err: `unexpected ")"`},
+		{expr: "name=~(", err: "missing the value"},
+		{expr: `name=~"("`, err: "invalid regular expression"},
+		{expr: `name="web`, err: "unterminated quoted value"},
+		{expr: "name<web", err: "operator < cannot be used with name"},
+		{expr: "created=~1h", err: "operator =~ cannot be used with created"},
+		{expr: "created>yesterday", err: `invalid time "yesterday"`},
+		{expr: "label.=x", err: "missing the key of the label"},
+		{expr: "NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT NOT name=web", err: "nested too deeply"},
+	} {
+		_, err := parsePsExpr(tc.expr, time.Now())
+		assert.Check(t, is.ErrorContains(err, tc.err), tc.expr)
+		assert.Check(t, errdefs.IsInvalidParameter(err), tc.expr)
+	}
+}
+
+func TestExprFilter(t *testing.T) {
+	db, err := container.NewViewDB()
+	assert.Assert(t, err == nil)
+	d := &Daemon{
+		containersReplica: db,
+	}
+
+	var (
+		one = setupContainerWithName(t, "web-1", d)
+		two = setupContainerWithName(t, "api-1", d)
+		_   = setupContainerWithName(t, "db-1", d)
+	)
+
+	containerList, err := d.Containers(&types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("expr", "name=~^web- OR name=api-1")),
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(containerList, 2))
+	assert.Check(t, containerListContainsName(containerList, one.Name))
+	assert.Check(t, containerListContainsName(containerList, two.Name))
+
+	// the containers matching any of the expressions are listed
+	containerList, err = d.Containers(&types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("expr", "name=web-1"), filters.Arg("expr", "name=api-1")),
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(containerList, 2))
+
+	// the expressions are combined with the other filters
+	containerList, err = d.Containers(&types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("expr", "name=~-1$"), filters.Arg("name", "db")),
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(containerList, 1))
+
+	_, err = d.Containers(&types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("expr", "name=~^web- OR")),
+	})
+	assert.Check(t, errdefs.IsInvalidParameter(err))
+}
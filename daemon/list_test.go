@@ -1,15 +1,18 @@
 package daemon
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
 	"github.com/opencontainers/go-digest"
 	"github.com/pborman/uuid"
@@ -143,3 +146,53 @@ func TestNameFilter(t *testing.T) {
 	assert.Assert(t, is.Len(containerListWithPrefix, 1))
 	assert.Assert(t, containerListContainsName(containerListWithPrefix, three.Name))
 }
+
+func TestListCursor(t *testing.T) {
+	db, err := container.NewViewDB()
+	assert.Assert(t, err == nil)
+	d := &Daemon{
+		containersReplica: db,
+	}
+
+	var containers []*container.Container
+	for i, name := range []string{"a1", "a2", "a3", "a4", "a5"} {
+		c := setupContainerWithName(t, name, d)
+		c.Created = time.Unix(1000+int64(i/2), int64(i))
+		assert.NilError(t, c.CheckpointTo(db))
+		containers = append(containers, c)
+	}
+	all, err := d.Containers(&types.ContainerListOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(all, 5))
+
+	// the pages list the containers in the same order as the whole list
+	var (
+		paged  []*types.Container
+		cursor string
+	)
+	for {
+		page, err := d.Containers(&types.ContainerListOptions{Limit: 2, Cursor: cursor})
+		assert.NilError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		assert.Assert(t, len(page) <= 2)
+		paged = append(paged, page...)
+		cursor = fmt.Sprintf("%d_%s", page[len(page)-1].Created, page[len(page)-1].ID)
+	}
+	assert.Check(t, is.DeepEqual(paged, all))
+
+	// the list resumes after the second of the removed container of the
+	// cursor, without skipping the containers created in the same second
+	cursor = fmt.Sprintf("%d_%s", all[1].Created, all[1].ID)
+	assert.NilError(t, db.Delete(containers[3]))
+	page, err := d.Containers(&types.ContainerListOptions{Cursor: cursor})
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(page, 3))
+	assert.Check(t, is.Equal(page[0].ID, all[2].ID))
+
+	for _, c := range []string{"missing", "1000_", "x_" + all[0].ID} {
+		_, err = d.Containers(&types.ContainerListOptions{Cursor: c})
+		assert.Check(t, errdefs.IsInvalidParameter(err), "cursor %q", c)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types/plugins/logdriver"
@@ -13,6 +14,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// pluginRestartInterval is the minimum interval between the restarts of the
+// logging of a plugin failing to write messages.
+const pluginRestartInterval = 10 * time.Second
+
 // pluginAdapter takes a plugin and implements the Logger interface for logger
 // instances
 type pluginAdapter struct {
@@ -30,6 +35,11 @@ type pluginAdapter struct {
 	// buf is shared for each `Log()` call to reduce allocations.
 	// buf must be protected by mutex
 	buf logdriver.LogEntry
+	// lastRestart is when the logging of the plugin was last restarted,
+	// protected by mu
+	lastRestart time.Time
+
+	restartCount uint64 // accessed atomically
 }
 
 func (a *pluginAdapter) Log(msg *Message) error {
@@ -42,6 +52,9 @@ func (a *pluginAdapter) Log(msg *Message) error {
 
 	err := a.enc.Encode(&a.buf)
 	a.buf.Reset()
+	if err != nil {
+		a.restartLocked()
+	}
 
 	a.mu.Unlock()
 
@@ -49,6 +62,45 @@ func (a *pluginAdapter) Log(msg *Message) error {
 	return err
 }
 
+// restartLocked restarts the logging of the plugin after it failed to write a
+// message, for example because the plugin was restarted, reopening the stream
+// of the messages and asking the plugin to log them again. The logging is
+// restarted at most once per pluginRestartInterval.
+func (a *pluginAdapter) restartLocked() {
+	if time.Since(a.lastRestart) < pluginRestartInterval {
+		return
+	}
+	a.lastRestart = time.Now()
+	atomic.AddUint64(&a.restartCount, 1)
+	pluginRestartsCount.WithValues(a.driverName).Inc(1)
+
+	log := logrus.WithField("driver", a.driverName).WithField("container", a.logInfo.ContainerID)
+	log.Warn("logging plugin failed to write a message, restarting the logging")
+
+	streamPath := filepath.Join("/", "run", "docker", "logging", a.id)
+	// the plugin may not be logging anymore
+	a.plugin.StopLogging(streamPath)
+	if err := a.stream.Close(); err != nil {
+		log.WithError(err).Debug("error closing plugin fifo")
+	}
+	stream, err := openPluginStream(a)
+	if err != nil {
+		log.WithError(err).Error("error restarting the logging of the plugin")
+		return
+	}
+	a.stream = stream
+	a.enc = logdriver.NewLogEntryEncoder(a.stream)
+	if err := a.plugin.StartLogging(streamPath, a.logInfo); err != nil {
+		log.WithError(err).Error("error restarting the logging of the plugin")
+	}
+}
+
+// restarts returns the number of times the logging of the plugin was
+// restarted.
+func (a *pluginAdapter) restarts() uint64 {
+	return atomic.LoadUint64(&a.restartCount)
+}
+
 func (a *pluginAdapter) Name() string {
 	return a.driverName
 }
@@ -115,6 +167,9 @@ func (a *pluginAdapterWithRead) ReadLogs(config ReadConfig) *LogWatcher {
 			if !config.Until.IsZero() && msg.Timestamp.After(config.Until) {
 				return
 			}
+			if !config.Match(msg) {
+				continue
+			}
 
 			// send the message unless the consumer is gone
 			select {
@@ -0,0 +1,77 @@
+// +build linux freebsd
+
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/plugins/logdriver"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type failingWriteCloser struct{}
+
+func (failingWriteCloser) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func (failingWriteCloser) Close() error { return nil }
+
+// restartingPlugin counts the calls to start and stop the logging.
+type restartingPlugin struct {
+	mockLoggingPlugin
+	started, stopped int
+}
+
+func (p *restartingPlugin) StartLogging(string, Info) error {
+	p.started++
+	return nil
+}
+
+func (p *restartingPlugin) StopLogging(string) error {
+	p.stopped++
+	return nil
+}
+
+func TestPluginAdapterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	plugin := &restartingPlugin{}
+	a := &pluginAdapter{
+		driverName: "test",
+		id:         "id",
+		plugin:     plugin,
+		fifoPath:   filepath.Join(dir, "id"),
+		stream:     failingWriteCloser{},
+	}
+	a.enc = logdriver.NewLogEntryEncoder(a.stream)
+	defer a.stream.Close()
+
+	msg := NewMessage()
+	msg.Line = append(msg.Line, "message"...)
+	msg.Timestamp = time.Now()
+	assert.Check(t, is.ErrorContains(a.Log(msg), "broken pipe"))
+	assert.Check(t, is.Equal(a.restarts(), uint64(1)))
+	assert.Check(t, is.Equal(plugin.stopped, 1))
+	assert.Check(t, is.Equal(plugin.started, 1))
+
+	// the messages are written to the reopened stream
+	msg = NewMessage()
+	msg.Line = append(msg.Line, "message"...)
+	msg.Timestamp = time.Now()
+	assert.Check(t, a.Log(msg))
+
+	// the logging is not restarted again before the restart interval
+	a.stream = failingWriteCloser{}
+	a.enc = logdriver.NewLogEntryEncoder(a.stream)
+	msg = NewMessage()
+	msg.Line = append(msg.Line, "message"...)
+	assert.Check(t, a.Log(msg) != nil)
+	assert.Check(t, is.Equal(a.restarts(), uint64(1)))
+}
@@ -2,6 +2,7 @@
 package awslogs // import "github.com/docker/docker/daemon/logger/awslogs"
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -64,6 +65,7 @@ type logStream struct {
 	logCreateGroup   bool
 	logNonBlocking   bool
 	multilinePattern *regexp.Regexp
+	extraAttrs       map[string]string
 	client           api
 	messages         chan *logger.Message
 	lock             sync.RWMutex
@@ -113,7 +115,8 @@ type eventBatch struct {
 // New creates an awslogs logger using the configuration passed in on the
 // context.  Supported context configuration variables are awslogs-region,
 // awslogs-endpoint, awslogs-group, awslogs-stream, awslogs-create-group,
-// awslogs-multiline-pattern and awslogs-datetime-format.
+// awslogs-multiline-pattern and awslogs-datetime-format, and the templates of
+// the extra attributes.
 // When available, configuration is also taken from environment variables
 // AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, the shared credentials
 // file (~/.aws/credentials), and the EC2 Instance Metadata Service.
@@ -142,6 +145,14 @@ func New(info logger.Info) (logger.Logger, error) {
 		return nil, err
 	}
 
+	extraAttrs, err := info.ExtraAttributes(nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(extraAttrs) == 0 {
+		extraAttrs = nil
+	}
+
 	client, err := newAWSLogsClient(info)
 	if err != nil {
 		return nil, err
@@ -153,6 +164,7 @@ func New(info logger.Info) (logger.Logger, error) {
 		logCreateGroup:   logCreateGroup,
 		logNonBlocking:   logNonBlocking,
 		multilinePattern: multilinePattern,
+		extraAttrs:       extraAttrs,
 		client:           client,
 		messages:         make(chan *logger.Message, 4096),
 	}
@@ -524,8 +536,12 @@ func (l *logStream) collectBatch(created chan bool) {
 // batch (defined in maximumBytesPerPut).  Log messages are split by the maximum
 // bytes per event (defined in maximumBytesPerEvent).  There is a fixed per-event
 // byte overhead (defined in perEventBytes) which is accounted for in split- and
-// batch-calculations.
+// batch-calculations.  When the container has extra attributes, the messages
+// are sent as JSON objects holding the log and the attributes.
 func (l *logStream) processEvent(batch *eventBatch, events []byte, timestamp int64) {
+	if l.extraAttrs != nil && len(events) > 0 {
+		events = l.withExtraAttrs(events)
+	}
 	for len(events) > 0 {
 		// Split line length so it does not exceed the maximum
 		lineBytes := len(events)
@@ -552,6 +568,25 @@ func (l *logStream) processEvent(batch *eventBatch, events []byte, timestamp int
 	}
 }
 
+// attributedEvent is the JSON message of a log event with the extra
+// attributes of the container.
+type attributedEvent struct {
+	Log   string            `json:"log"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+// withExtraAttrs returns the JSON message of the event with the extra
+// attributes, CloudWatch Logs having no metadata for the events. The messages
+// exceeding the maximum bytes per event are still split, as invalid JSON.
+func (l *logStream) withExtraAttrs(event []byte) []byte {
+	b, err := json.Marshal(&attributedEvent{Log: string(event), Attrs: l.extraAttrs})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal the log event with its attributes")
+		return event
+	}
+	return b
+}
+
 // publishBatch calls PutLogEvents for a given set of InputLogEvents,
 // accounting for sequencing requirements (each request must reference the
 // sequence token returned by the previous request).
@@ -631,7 +666,9 @@ func ValidateLogOpt(cfg map[string]string) error {
 		case multilinePatternKey:
 		case credentialsEndpointKey:
 		default:
-			return fmt.Errorf("unknown log opt '%s' for %s log driver", key, name)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt '%s' for %s log driver", key, name)
+			}
 		}
 	}
 	if cfg[logGroupKey] == "" {
@@ -532,6 +532,45 @@ func TestCollectBatchSimple(t *testing.T) {
 	}
 }
 
+func TestCollectBatchExtraAttrs(t *testing.T) {
+	mockClient := newMockClient()
+	stream := &logStream{
+		client:        mockClient,
+		logGroupName:  groupName,
+		logStreamName: streamName,
+		sequenceToken: aws.String(sequenceToken),
+		extraAttrs:    map[string]string{"project": "shop"},
+		messages:      make(chan *logger.Message),
+	}
+	mockClient.putLogEventsResult <- &putLogEventsResult{
+		successResult: &cloudwatchlogs.PutLogEventsOutput{
+			NextSequenceToken: aws.String(nextSequenceToken),
+		},
+	}
+	ticks := make(chan time.Time)
+	newTicker = func(_ time.Duration) *time.Ticker {
+		return &time.Ticker{
+			C: ticks,
+		}
+	}
+	d := make(chan bool)
+	close(d)
+	go stream.collectBatch(d)
+
+	stream.Log(&logger.Message{
+		Line:      []byte("hello"),
+		Timestamp: time.Time{},
+	})
+
+	ticks <- time.Time{}
+	stream.Close()
+
+	argument := <-mockClient.putLogEventsArgument
+	assert.Assert(t, argument != nil)
+	assert.Assert(t, is.Len(argument.LogEvents, 1))
+	assert.Check(t, is.Equal(*argument.LogEvents[0].Message, `{"log":"hello","attrs":{"project":"shop"}}`))
+}
+
 func TestCollectBatchTicker(t *testing.T) {
 	mockClient := newMockClient()
 	stream := &logStream{
@@ -27,6 +27,8 @@ type Copier struct {
 	// srcs is map of name -> reader pairs, for example "stdout", "stderr"
 	srcs      map[string]io.Reader
 	dst       Logger
+	limiter   *Limiter
+	multiline *Multiline
 	copyJobs  sync.WaitGroup
 	closeOnce sync.Once
 	closed    chan struct{}
@@ -41,6 +43,25 @@ func NewCopier(srcs map[string]io.Reader, dst Logger) *Copier {
 	}
 }
 
+// SetLimiter sets the limiter of the lines copied, which must be set before
+// the copier runs.
+func (c *Copier) SetLimiter(l *Limiter) {
+	c.limiter = l
+}
+
+// Limiter returns the limiter of the lines copied, nil when they are not
+// limited.
+func (c *Copier) Limiter() *Limiter {
+	return c.limiter
+}
+
+// SetMultiline sets the processor joining the lines copied into records,
+// which must be set before the copier runs. The limiter then limits the
+// records rather than the lines.
+func (c *Copier) SetMultiline(m *Multiline) {
+	c.multiline = m
+}
+
 // Run starts logs copying
 func (c *Copier) Run() {
 	for src, w := range c.srcs {
@@ -58,6 +79,12 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	}
 	buf := make([]byte, bufSize)
 
+	var joiner *multilineJoiner
+	if c.multiline != nil {
+		joiner = c.multiline.newJoiner(c.logLine)
+		defer joiner.close()
+	}
+
 	n := 0
 	eof := false
 	var partialid string
@@ -65,6 +92,9 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	var ordinal int
 	firstPartial := true
 	hasMorePartial := false
+	// whether the partial messages of the current line are dropped by the
+	// limiter, which decides on their first one
+	dropPartial := false
 
 	for {
 		select {
@@ -120,9 +150,18 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 						msg.Timestamp = partialTS
 					}
 
-					if logErr := c.dst.Log(msg); logErr != nil {
-						logWritesFailedCount.Inc(1)
-						logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, c.dst.Name(), logErr)
+					drop := dropPartial
+					dropPartial = false
+
+					switch {
+					case msg.PLogMetaData == nil && joiner != nil:
+						joiner.add(msg)
+					case msg.PLogMetaData == nil:
+						c.logLine(msg)
+					case drop:
+						PutMessage(msg)
+					default:
+						c.log(msg)
 					}
 				}
 				p += q + 1
@@ -140,12 +179,18 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 					// Record timestamp for first partial. Use it across partials.
 					// Initialize Ordinal for first partial. Increment it across partials.
 					if firstPartial {
+						// the partial messages are not joined with the
+						// lines before them
+						if joiner != nil {
+							joiner.flush()
+						}
 						msg.Timestamp = time.Now().UTC()
 						partialTS = msg.Timestamp
 						partialid = stringid.GenerateRandomID()
 						ordinal = 1
 						firstPartial = false
 						totalPartialLogs.Inc(1)
+						dropPartial = c.dropLine()
 					} else {
 						msg.Timestamp = partialTS
 					}
@@ -153,9 +198,10 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 					ordinal++
 					hasMorePartial = true
 
-					if logErr := c.dst.Log(msg); logErr != nil {
-						logWritesFailedCount.Inc(1)
-						logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, c.dst.Name(), logErr)
+					if dropPartial {
+						PutMessage(msg)
+					} else {
+						c.log(msg)
 					}
 					p = 0
 					n = 0
@@ -173,6 +219,28 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	}
 }
 
+// dropLine returns whether the limiter drops a line, with all its partial
+// messages.
+func (c *Copier) dropLine() bool {
+	return c.limiter != nil && !c.limiter.Allow()
+}
+
+// logLine logs a line, or a joined record, unless the limiter drops it.
+func (c *Copier) logLine(msg *Message) {
+	if c.dropLine() {
+		PutMessage(msg)
+		return
+	}
+	c.log(msg)
+}
+
+func (c *Copier) log(msg *Message) {
+	if logErr := c.dst.Log(msg); logErr != nil {
+		logWritesFailedCount.Inc(1)
+		logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, c.dst.Name(), logErr)
+	}
+}
+
 // Wait waits until all copying is done
 func (c *Copier) Wait() {
 	c.copyJobs.Wait()
@@ -393,6 +393,170 @@ func TestCopierWithPartial(t *testing.T) {
 	}
 }
 
+func TestCopierWithLimiter(t *testing.T) {
+	var stdout bytes.Buffer
+	for i := 0; i < 100; i++ {
+		stdout.WriteString("a line of a log-bombing container\n")
+	}
+	// a line longer than the buffer, logged as partial messages
+	stdout.WriteString(strings.Repeat("x", 3*defaultBufSize) + "\n")
+
+	limiter, err := NewLimiter(map[string]string{"rate-limit": "0.001", "rate-limit-burst": "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jsonBuf bytes.Buffer
+	c := NewCopier(map[string]io.Reader{"stdout": &stdout}, &TestLoggerJSON{Encoder: json.NewEncoder(&jsonBuf)})
+	c.SetLimiter(limiter)
+	c.Run()
+	c.Wait()
+
+	dec := json.NewDecoder(&jsonBuf)
+	var logged int
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if msg.PLogMetaData != nil {
+			t.Fatalf("expected the partial messages of the long line to be dropped: %+v", msg.PLogMetaData)
+		}
+		logged++
+	}
+	if logged != 10 {
+		t.Fatalf("expected the burst of 10 lines to be logged, got %d", logged)
+	}
+	if rateLimited, sampledOut := c.Limiter().Dropped(); rateLimited != 91 || sampledOut != 0 {
+		t.Fatalf("expected 91 rate limited lines, and none sampled out, got %d and %d", rateLimited, sampledOut)
+	}
+}
+
+func TestCopierWithMultiline(t *testing.T) {
+	var stdout bytes.Buffer
+	for i := 0; i < 3; i++ {
+		stdout.WriteString("2019-01-01 ERROR java.lang.NullPointerException\n")
+		stdout.WriteString("\tat com.example.Foo.bar(Foo.java:42)\n")
+		stdout.WriteString("\tat com.example.Foo.main(Foo.java:7)\n")
+	}
+	stdout.WriteString("2019-01-01 INFO done\n")
+
+	multiline, err := NewMultiline(map[string]string{"multiline-pattern": "^[0-9]{4}-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := NewLimiter(map[string]string{"rate-limit": "0.001", "rate-limit-burst": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jsonBuf bytes.Buffer
+	c := NewCopier(map[string]io.Reader{"stdout": &stdout}, &TestLoggerJSON{Encoder: json.NewEncoder(&jsonBuf)})
+	c.SetMultiline(multiline)
+	c.SetLimiter(limiter)
+	c.Run()
+	c.Wait()
+
+	dec := json.NewDecoder(&jsonBuf)
+	var records []string
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, string(msg.Line))
+	}
+	expected := "2019-01-01 ERROR java.lang.NullPointerException\n\tat com.example.Foo.bar(Foo.java:42)\n\tat com.example.Foo.main(Foo.java:7)"
+	if len(records) != 2 || records[0] != expected || records[1] != expected {
+		t.Fatalf("expected the burst of 2 joined records to be logged, got %q", records)
+	}
+	if rateLimited, _ := c.Limiter().Dropped(); rateLimited != 2 {
+		t.Fatalf("expected 2 rate limited records, got %d", rateLimited)
+	}
+}
+
+func TestMultilineTimeout(t *testing.T) {
+	multiline, err := NewMultiline(map[string]string{"multiline-pattern": "^[^ ]", "multiline-timeout": "10ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitted := make(chan string, 2)
+	j := multiline.newJoiner(func(msg *Message) {
+		emitted <- string(msg.Line)
+	})
+	defer j.close()
+
+	for _, line := range []string{"Traceback:", "  File \"x.py\""} {
+		msg := NewMessage()
+		msg.Line = append(msg.Line, line...)
+		j.add(msg)
+	}
+	select {
+	case record := <-emitted:
+		if record != "Traceback:\n  File \"x.py\"" {
+			t.Fatalf("unexpected record %q", record)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the record to be emitted on timeout")
+	}
+}
+
+func TestNewMultiline(t *testing.T) {
+	for _, cfg := range []map[string]string{
+		{"multiline-pattern": ""},
+		{"multiline-pattern": "("},
+		{"multiline-pattern": "^a", "multiline-timeout": "0s"},
+		{"multiline-pattern": "^a", "multiline-timeout": "soon"},
+		{"multiline-timeout": "1s"},
+	} {
+		if _, err := NewMultiline(cfg); err == nil {
+			t.Fatalf("expected an error for %v", cfg)
+		}
+	}
+	if m, err := NewMultiline(map[string]string{"max-size": "10m"}); err != nil || m != nil {
+		t.Fatalf("expected no multiline processor, got %v, %v", m, err)
+	}
+	m, err := NewMultiline(map[string]string{"multiline-pattern": "^a"})
+	if err != nil || m.timeout != defaultMultilineTimeout {
+		t.Fatalf("expected the default timeout, got %v, %v", m, err)
+	}
+}
+
+func TestNewLimiter(t *testing.T) {
+	for _, cfg := range []map[string]string{
+		{"rate-limit": "0"},
+		{"rate-limit": "fast"},
+		{"rate-limit": "10", "rate-limit-burst": "0"},
+		{"rate-limit-burst": "10"},
+		{"sample-rate": "0"},
+		{"sample-rate": "1.5"},
+	} {
+		if _, err := NewLimiter(cfg); err == nil {
+			t.Fatalf("expected an error for %v", cfg)
+		}
+	}
+
+	if l, err := NewLimiter(map[string]string{"sample-rate": "1", "max-size": "10m"}); err != nil || l != nil {
+		t.Fatalf("expected no limiter, got %v, %v", l, err)
+	}
+
+	l, err := NewLimiter(map[string]string{"sample-rate": "0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var allowed int
+	for i := 0; i < 10000; i++ {
+		if l.Allow() {
+			allowed++
+		}
+	}
+	if _, sampledOut := l.Dropped(); allowed < 4000 || allowed > 6000 || sampledOut != uint64(10000-allowed) {
+		t.Fatalf("expected about half of the lines to be sampled out, got %d allowed and %d sampled out", allowed, sampledOut)
+	}
+}
+
 type BenchmarkLoggerDummy struct {
 }
 
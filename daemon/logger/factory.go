@@ -117,8 +117,32 @@ func GetLogDriver(name string) (Creator, error) {
 }
 
 var builtInLogOpts = map[string]bool{
-	"mode":            true,
-	"max-buffer-size": true,
+	"mode":                     true,
+	"max-buffer-size":          true,
+	"adaptive-max-buffer-size": true,
+	rateLimitKey:               true,
+	rateLimitBurstKey:          true,
+	sampleRateKey:              true,
+	multilinePatternKey:        true,
+	multilineTimeoutKey:        true,
+}
+
+var externalValidators []LogOptValidator
+
+// AddBuiltinLogOpts registers options which are handled by the daemon for
+// all the log drivers, and are not passed to their validators.
+// It must be called from an init function.
+func AddBuiltinLogOpts(opts map[string]bool) {
+	for k, v := range opts {
+		builtInLogOpts[k] = v
+	}
+}
+
+// RegisterExternalValidator registers a validator of the options handled by
+// the daemon for all the log drivers, called with all the options.
+// It must be called from an init function.
+func RegisterExternalValidator(v LogOptValidator) {
+	externalValidators = append(externalValidators, v)
 }
 
 // ValidateLogOpts checks the options for the given log driver. The
@@ -134,22 +158,59 @@ func ValidateLogOpts(name string, cfg map[string]string) error {
 		return fmt.Errorf("logger: logging mode not supported: %s", cfg["mode"])
 	}
 
+	maxBufferSize := int64(defaultRingMaxSize)
 	if s, ok := cfg["max-buffer-size"]; ok {
 		if containertypes.LogMode(cfg["mode"]) != containertypes.LogModeNonBlock {
 			return fmt.Errorf("logger: max-buffer-size option is only supported with 'mode=%s'", containertypes.LogModeNonBlock)
 		}
-		if _, err := units.RAMInBytes(s); err != nil {
+		var err error
+		if maxBufferSize, err = units.RAMInBytes(s); err != nil {
 			return errors.Wrap(err, "error parsing option max-buffer-size")
 		}
 	}
 
+	if s, ok := cfg["adaptive-max-buffer-size"]; ok {
+		if containertypes.LogMode(cfg["mode"]) != containertypes.LogModeNonBlock {
+			return fmt.Errorf("logger: adaptive-max-buffer-size option is only supported with 'mode=%s'", containertypes.LogModeNonBlock)
+		}
+		size, err := units.RAMInBytes(s)
+		if err != nil {
+			return errors.Wrap(err, "error parsing option adaptive-max-buffer-size")
+		}
+		if size < maxBufferSize {
+			return fmt.Errorf("logger: adaptive-max-buffer-size cannot be smaller than max-buffer-size")
+		}
+	}
+
+	if _, err := NewLimiter(cfg); err != nil {
+		return errors.Wrap(err, "logger")
+	}
+
+	if _, err := NewMultiline(cfg); err != nil {
+		return errors.Wrap(err, "logger")
+	}
+
+	if err := validateAttrTemplates(cfg); err != nil {
+		return err
+	}
+
 	if !factory.driverRegistered(name) {
 		return fmt.Errorf("logger: no log driver named '%s' is registered", name)
 	}
 
+	if err := validateTeeOpts(name, cfg); err != nil {
+		return err
+	}
+
+	for _, validator := range externalValidators {
+		if err := validator(cfg); err != nil {
+			return err
+		}
+	}
+
 	filteredOpts := make(map[string]string, len(builtInLogOpts))
 	for k, v := range cfg {
-		if !builtInLogOpts[k] {
+		if !builtInLogOpts[k] && !isTeeOpt(cfg, k) {
 			filteredOpts[k] = v
 		}
 	}
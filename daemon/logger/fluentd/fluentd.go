@@ -198,7 +198,9 @@ func ValidateLogOpt(cfg map[string]string) error {
 		case subSecondPrecisionKey:
 			// Accepted
 		default:
-			return fmt.Errorf("unknown log opt '%s' for fluentd log driver", key)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt '%s' for fluentd log driver", key)
+			}
 		}
 	}
 
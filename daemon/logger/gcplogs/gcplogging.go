@@ -212,7 +212,9 @@ func ValidateLogOpts(cfg map[string]string) error {
 		switch k {
 		case projectOptKey, logLabelsKey, logEnvKey, logEnvRegexKey, logCmdKey, logZoneKey, logNameKey, logIDKey:
 		default:
-			return fmt.Errorf("%q is not a valid option for the gcplogs driver", k)
+			if !logger.IsAttrTemplateOpt(k) {
+				return fmt.Errorf("%q is not a valid option for the gcplogs driver", k)
+			}
 		}
 	}
 	return nil
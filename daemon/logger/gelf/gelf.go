@@ -4,29 +4,41 @@ package gelf // import "github.com/docker/docker/daemon/logger/gelf"
 
 import (
 	"compress/flate"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Graylog2/go-gelf/gelf"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/loggerutils"
 	"github.com/docker/docker/pkg/urlutil"
+	"github.com/docker/go-connections/tlsconfig"
 	"github.com/sirupsen/logrus"
 )
 
-const name = "gelf"
+const (
+	name        = "gelf"
+	secureProto = "tcp+tls"
+)
 
 type gelfLogger struct {
-	writer   gelf.Writer
+	writer   messageWriter
 	info     logger.Info
 	hostname string
 	rawExtra json.RawMessage
 }
 
+// messageWriter sends the GELF messages to the endpoint.
+type messageWriter interface {
+	WriteMessage(*gelf.Message) error
+	Close() error
+}
+
 func init() {
 	if err := logger.RegisterLogDriver(name, New); err != nil {
 		logrus.Fatal(err)
@@ -37,7 +49,8 @@ func init() {
 }
 
 // New creates a gelf logger using the configuration passed in on the
-// context. The supported context configuration variable is gelf-address.
+// context. The supported context configuration variable is gelf-address,
+// the messages being sent over UDP, TCP or TCP with TLS.
 func New(info logger.Info) (logger.Logger, error) {
 	// parse gelf address
 	address, err := parseAddress(info.Config["gelf-address"])
@@ -87,13 +100,13 @@ func New(info logger.Info) (logger.Logger, error) {
 		return nil, err
 	}
 
-	var gelfWriter gelf.Writer
+	var gelfWriter messageWriter
 	if address.Scheme == "udp" {
 		gelfWriter, err = newGELFUDPWriter(address.Host, info)
 		if err != nil {
 			return nil, err
 		}
-	} else if address.Scheme == "tcp" {
+	} else if address.Scheme == "tcp" || address.Scheme == secureProto {
 		gelfWriter, err = newGELFTCPWriter(address.Host, info)
 		if err != nil {
 			return nil, err
@@ -108,32 +121,69 @@ func New(info logger.Info) (logger.Logger, error) {
 	}, nil
 }
 
-// create new TCP gelfWriter
-func newGELFTCPWriter(address string, info logger.Info) (gelf.Writer, error) {
-	gelfWriter, err := gelf.NewTCPWriter(address)
-	if err != nil {
-		return nil, fmt.Errorf("gelf: cannot connect to GELF endpoint: %s %v", address, err)
-	}
-
+// create new TCP gelfWriter, with TLS for the tcp+tls gelf-address
+func newGELFTCPWriter(address string, info logger.Info) (*tcpWriter, error) {
+	maxReconnect := defaultMaxReconnect
 	if v, ok := info.Config["gelf-tcp-max-reconnect"]; ok {
 		i, err := strconv.Atoi(v)
 		if err != nil || i < 0 {
 			return nil, fmt.Errorf("gelf-tcp-max-reconnect must be a positive integer")
 		}
-		gelfWriter.MaxReconnect = i
+		maxReconnect = i
 	}
 
+	reconnectDelay := defaultReconnectDelay
 	if v, ok := info.Config["gelf-tcp-reconnect-delay"]; ok {
 		i, err := strconv.Atoi(v)
 		if err != nil || i < 0 {
 			return nil, fmt.Errorf("gelf-tcp-reconnect-delay must be a positive integer")
 		}
-		gelfWriter.ReconnectDelay = time.Duration(i)
+		reconnectDelay = time.Duration(i) * time.Second
+	}
+
+	bufferSize := defaultTCPBufferSize
+	if v, ok := info.Config["gelf-tcp-buffer-size"]; ok {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("gelf-tcp-buffer-size must be a positive integer")
+		}
+		bufferSize = i
+	}
+
+	var tlsConfig *tls.Config
+	if strings.HasPrefix(info.Config["gelf-address"], secureProto+"://") {
+		var err error
+		if tlsConfig, err = parseTLSConfig(info.Config); err != nil {
+			return nil, err
+		}
 	}
 
+	gelfWriter, err := newTCPWriter(address, tlsConfig, maxReconnect, reconnectDelay, bufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("gelf: cannot connect to GELF endpoint: %s %v", address, err)
+	}
 	return gelfWriter, nil
 }
 
+func parseTLSConfig(cfg map[string]string) (*tls.Config, error) {
+	var skipVerify bool
+	if v, ok := cfg["gelf-tls-skip-verify"]; ok {
+		var err error
+		if skipVerify, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("gelf-tls-skip-verify must be a boolean")
+		}
+	}
+
+	opts := tlsconfig.Options{
+		CAFile:             cfg["gelf-tls-ca-cert"],
+		CertFile:           cfg["gelf-tls-cert"],
+		KeyFile:            cfg["gelf-tls-key"],
+		InsecureSkipVerify: skipVerify,
+	}
+
+	return tlsconfig.Client(opts)
+}
+
 // create new UDP gelfWriter
 func newGELFUDPWriter(address string, info logger.Info) (gelf.Writer, error) {
 	gelfWriter, err := gelf.NewUDPWriter(address)
@@ -226,16 +276,29 @@ func ValidateLogOpt(cfg map[string]string) error {
 			default:
 				return fmt.Errorf("unknown value %q for log opt %q for gelf log driver", val, key)
 			}
-		case "gelf-tcp-max-reconnect", "gelf-tcp-reconnect-delay":
-			if address.Scheme != "tcp" {
+		case "gelf-tcp-max-reconnect", "gelf-tcp-reconnect-delay", "gelf-tcp-buffer-size":
+			if address.Scheme != "tcp" && address.Scheme != secureProto {
 				return fmt.Errorf("%q is only valid for TCP", key)
 			}
 			i, err := strconv.Atoi(val)
 			if err != nil || i < 0 {
 				return fmt.Errorf("%q must be a positive integer", key)
 			}
+		case "gelf-tls-ca-cert", "gelf-tls-cert", "gelf-tls-key":
+			if address.Scheme != secureProto {
+				return fmt.Errorf("%q is only valid for TCP with TLS", key)
+			}
+		case "gelf-tls-skip-verify":
+			if address.Scheme != secureProto {
+				return fmt.Errorf("%q is only valid for TCP with TLS", key)
+			}
+			if _, err := strconv.ParseBool(val); err != nil {
+				return fmt.Errorf("%q must be a boolean", key)
+			}
 		default:
-			return fmt.Errorf("unknown log opt %q for gelf log driver", key)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt %q for gelf log driver", key)
+			}
 		}
 	}
 
@@ -254,9 +317,9 @@ func parseAddress(address string) (*url.URL, error) {
 		return nil, err
 	}
 
-	// we support only udp
-	if url.Scheme != "udp" && url.Scheme != "tcp" {
-		return nil, fmt.Errorf("gelf: endpoint needs to be TCP or UDP")
+	// we support only udp, tcp and tcp with tls
+	if url.Scheme != "udp" && url.Scheme != "tcp" && url.Scheme != secureProto {
+		return nil, fmt.Errorf("gelf: endpoint needs to be TCP, TCP with TLS or UDP")
 	}
 
 	// get host and port
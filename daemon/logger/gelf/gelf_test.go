@@ -92,6 +92,47 @@ func TestTCPValidateLogOpt(t *testing.T) {
 	}
 }
 
+// Validate TCP with TLS options
+func TestTLSValidateLogOpt(t *testing.T) {
+	err := ValidateLogOpt(map[string]string{
+		"gelf-address":             "tcp+tls://127.0.0.1:12201",
+		"gelf-tls-ca-cert":         "/etc/ssl/ca.pem",
+		"gelf-tls-cert":            "/etc/ssl/cert.pem",
+		"gelf-tls-key":             "/etc/ssl/key.pem",
+		"gelf-tls-skip-verify":     "false",
+		"gelf-tcp-max-reconnect":   "5",
+		"gelf-tcp-reconnect-delay": "1",
+		"gelf-tcp-buffer-size":     "100",
+	})
+	if err != nil {
+		t.Fatalf("Expected TCP with TLS to be supported: %v", err)
+	}
+
+	err = ValidateLogOpt(map[string]string{
+		"gelf-address":     "tcp://127.0.0.1:12201",
+		"gelf-tls-ca-cert": "/etc/ssl/ca.pem",
+	})
+	if err == nil {
+		t.Fatal("Expected TLS options to be invalid for TCP")
+	}
+
+	err = ValidateLogOpt(map[string]string{
+		"gelf-address":         "tcp+tls://127.0.0.1:12201",
+		"gelf-tls-skip-verify": "maybe",
+	})
+	if err == nil {
+		t.Fatal("Expected gelf-tls-skip-verify to be required to be a boolean")
+	}
+
+	err = ValidateLogOpt(map[string]string{
+		"gelf-address":         "udp://127.0.0.1:12201",
+		"gelf-tcp-buffer-size": "100",
+	})
+	if err == nil {
+		t.Fatal("Expected the TCP buffer size to be invalid for UDP")
+	}
+}
+
 // Validate UDP options
 func TestUDPValidateLogOpt(t *testing.T) {
 	err := ValidateLogOpt(map[string]string{
@@ -0,0 +1,19 @@
+package gelf // import "github.com/docker/docker/daemon/logger/gelf"
+
+import (
+	"github.com/docker/go-metrics"
+)
+
+var (
+	reconnectsCount      metrics.Counter
+	messagesDroppedCount metrics.Counter
+)
+
+func init() {
+	gelfMetrics := metrics.NewNamespace("logger", "gelf", nil)
+
+	reconnectsCount = gelfMetrics.NewCounter("tcp_reconnects", "Number of reconnections to the GELF endpoints over TCP")
+	messagesDroppedCount = gelfMetrics.NewCounter("tcp_messages_dropped", "Number of GELF messages dropped as they could not be sent over TCP")
+
+	metrics.Register(gelfMetrics)
+}
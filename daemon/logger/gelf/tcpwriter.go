@@ -0,0 +1,224 @@
+package gelf // import "github.com/docker/docker/daemon/logger/gelf"
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Graylog2/go-gelf/gelf"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxReconnect   = gelf.DefaultMaxReconnect
+	defaultReconnectDelay = gelf.DefaultReconnectDelay * time.Second
+	defaultTCPBufferSize  = 1024
+
+	// maxReconnectDelay is the maximum delay between the reconnections,
+	// the delay doubling after each failed one.
+	maxReconnectDelay = time.Minute
+	dialTimeout       = 10 * time.Second
+	// flushTimeout is how long the buffered messages are sent for when the
+	// writer is closed, the messages left being dropped.
+	flushTimeout = 5 * time.Second
+)
+
+var errWriterClosed = errors.New("gelf: the TCP writer is closed")
+
+// tcpWriter sends the GELF messages over TCP, optionally with TLS. The
+// messages are buffered, and sent by a goroutine which reconnects to the
+// endpoint with an exponential backoff when they cannot be sent.
+type tcpWriter struct {
+	address        string
+	tlsConfig      *tls.Config // nil without TLS
+	maxReconnect   int
+	reconnectDelay time.Duration
+
+	messages chan []byte
+	closing  chan struct{} // closed on Close for the buffered messages to be flushed
+	abort    chan struct{} // closed when the flush times out for the messages left to be dropped
+	done     chan struct{} // closed once the sender returns
+
+	mu   sync.Mutex // protects conn, which is only written by the sender
+	conn net.Conn
+
+	closeOnce sync.Once
+	// failing is whether the last message was dropped, for the errors to be
+	// logged once for the messages dropped in a row. It is only accessed by
+	// the sender.
+	failing bool
+}
+
+// newTCPWriter connects to the GELF endpoint, with TLS when tlsConfig is
+// set, and starts the sender of the messages, bufferSize messages being
+// buffered.
+func newTCPWriter(address string, tlsConfig *tls.Config, maxReconnect int, reconnectDelay time.Duration, bufferSize int) (*tcpWriter, error) {
+	w := &tcpWriter{
+		address:        address,
+		tlsConfig:      tlsConfig,
+		maxReconnect:   maxReconnect,
+		reconnectDelay: reconnectDelay,
+		messages:       make(chan []byte, bufferSize),
+		closing:        make(chan struct{}),
+		abort:          make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	conn, err := w.dial()
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	go w.run()
+	return w, nil
+}
+
+func (w *tcpWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if w.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", w.address, w.tlsConfig)
+	}
+	return dialer.Dial("tcp", w.address)
+}
+
+// WriteMessage buffers the message to be sent, blocking while the buffer is
+// full.
+func (w *tcpWriter) WriteMessage(m *gelf.Message) error {
+	buf := new(bytes.Buffer)
+	if err := m.MarshalJSONBuf(buf); err != nil {
+		return err
+	}
+	// the messages are delimited by null bytes over TCP
+	buf.WriteByte(0)
+
+	select {
+	case <-w.closing:
+		return errWriterClosed
+	default:
+	}
+	select {
+	case <-w.closing:
+		return errWriterClosed
+	case w.messages <- buf.Bytes():
+		return nil
+	}
+}
+
+// Close sends the buffered messages, dropping those which cannot be sent
+// before the flush timeout, and closes the connection.
+func (w *tcpWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closing)
+		select {
+		case <-w.done:
+		case <-time.After(flushTimeout):
+			close(w.abort)
+			// interrupt the message being sent
+			w.mu.Lock()
+			if w.conn != nil {
+				w.conn.Close()
+			}
+			w.mu.Unlock()
+			<-w.done
+		}
+	})
+	return nil
+}
+
+func (w *tcpWriter) run() {
+	defer close(w.done)
+	defer func() {
+		w.mu.Lock()
+		if w.conn != nil {
+			w.conn.Close()
+			w.conn = nil
+		}
+		w.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg := <-w.messages:
+			w.send(msg)
+		case <-w.closing:
+			for {
+				select {
+				case msg := <-w.messages:
+					w.send(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send sends a message, reconnecting up to maxReconnect times when it cannot
+// be sent, after which the message is dropped.
+func (w *tcpWriter) send(msg []byte) {
+	delay := w.reconnectDelay
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-w.abort:
+			w.drop(errWriterClosed)
+			return
+		default:
+		}
+
+		err := w.write(msg)
+		if err == nil {
+			if w.failing {
+				logrus.WithField("address", w.address).Info("gelf: sending the messages to the GELF endpoint again")
+				w.failing = false
+			}
+			return
+		}
+		if attempt >= w.maxReconnect {
+			w.drop(err)
+			return
+		}
+
+		select {
+		case <-w.abort:
+			w.drop(errWriterClosed)
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+		reconnectsCount.Inc(1)
+	}
+}
+
+// write writes the message on the connection, connecting first if there is
+// none. The connection is closed when the message cannot be written.
+func (w *tcpWriter) write(msg []byte) error {
+	if w.conn == nil {
+		conn, err := w.dial()
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		w.conn = conn
+		w.mu.Unlock()
+	}
+	if _, err := w.conn.Write(msg); err != nil {
+		w.mu.Lock()
+		w.conn.Close()
+		w.conn = nil
+		w.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (w *tcpWriter) drop(err error) {
+	messagesDroppedCount.Inc(1)
+	if !w.failing {
+		logrus.WithError(err).WithField("address", w.address).Error("gelf: cannot send the messages to the GELF endpoint, dropping them")
+		w.failing = true
+	}
+}
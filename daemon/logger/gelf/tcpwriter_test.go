@@ -0,0 +1,133 @@
+// +build linux
+
+package gelf // import "github.com/docker/docker/daemon/logger/gelf"
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Graylog2/go-gelf/gelf"
+	"github.com/docker/docker/daemon/logger"
+)
+
+// readMessages sends the GELF messages read from the connections accepted by
+// l, delimited by null bytes, to messages.
+func readMessages(l net.Listener, messages chan<- string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				msg, err := r.ReadBytes(0)
+				if err != nil {
+					return
+				}
+				var m gelf.Message
+				if err := json.Unmarshal(msg[:len(msg)-1], &m); err == nil {
+					messages <- m.Short
+				}
+			}
+		}()
+	}
+}
+
+func TestTCPWriterReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	w, err := newTCPWriter(l.Addr().String(), nil, 3, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// the first connection is closed by the endpoint
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	messages := make(chan string, 100)
+	go readMessages(l, messages)
+
+	// the messages written until the connection is known to be closed are
+	// lost, as with any TCP endpoint closing the connection
+	timeout := time.After(10 * time.Second)
+	for {
+		if err := w.WriteMessage(&gelf.Message{Version: "1.1", Short: "retried"}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-messages:
+			if msg != "retried" {
+				t.Fatalf("unexpected message %q", msg)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timeout waiting for the writer to reconnect")
+		}
+	}
+}
+
+func TestNewTLS(t *testing.T) {
+	// borrow the certificate of a TLS test server
+	srv := httptest.NewTLSServer(nil)
+	tlsConfig := srv.TLS
+	srv.Close()
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	messages := make(chan string, 1)
+	go readMessages(l, messages)
+
+	info := logger.Info{
+		Config: map[string]string{
+			"gelf-address":         "tcp+tls://" + l.Addr().String(),
+			"gelf-tls-skip-verify": "true",
+		},
+		ContainerID: "12345678901234567890",
+	}
+	gelfLogger, err := New(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := logger.NewMessage()
+	msg.Line = append(msg.Line, "over TLS"...)
+	msg.Timestamp = time.Now()
+	if err := gelfLogger.Log(msg); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-messages:
+		if msg != "over TLS" {
+			t.Fatalf("unexpected message %q", msg)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the message")
+	}
+
+	if err := gelfLogger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gelfLogger.Log(logger.NewMessage()); err == nil {
+		t.Fatal("expected an error logging once closed")
+	}
+}
@@ -0,0 +1,114 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthStats are the stats of the writes of the messages of a container to
+// its log driver.
+type HealthStats struct {
+	// Written is the number of messages written.
+	Written uint64
+	// Errors is the number of messages which could not be written.
+	Errors uint64
+	// LastWrite is the time of the last message written.
+	LastWrite time.Time
+	// LastError is the error of the last message which could not be
+	// written, and LastErrorTime its time.
+	LastError     string
+	LastErrorTime time.Time
+	// PluginRestarts is the number of times the logging of the logging
+	// plugin was restarted after failing to write messages.
+	PluginRestarts uint64
+}
+
+// healthLogger records the writes of the messages to its logger.
+type healthLogger struct {
+	Logger
+	// driver is the log driver at the end of Logger, whose restarts are
+	// reported for the logging plugins.
+	driver Logger
+
+	mu    sync.Mutex
+	stats HealthStats
+}
+
+// healthWithReader is a healthLogger whose logger can read the logs.
+type healthWithReader struct {
+	*healthLogger
+}
+
+func (l *healthWithReader) ReadLogs(config ReadConfig) *LogWatcher {
+	return l.Logger.(LogReader).ReadLogs(config)
+}
+
+// WithHealth returns a logger recording the writes of the messages to l, for
+// the health of the logging pipeline to be reported by GetHealthStats. driver
+// is the log driver the messages end up in, l itself or the logger wrapped by
+// l, such as a local cache.
+func WithHealth(l Logger, driver Logger) Logger {
+	h := &healthLogger{Logger: l, driver: driver}
+	if _, ok := l.(LogReader); ok {
+		return &healthWithReader{h}
+	}
+	return h
+}
+
+func (l *healthLogger) Log(msg *Message) error {
+	err := l.Logger.Log(msg)
+
+	now := time.Now()
+	l.mu.Lock()
+	if err != nil {
+		l.stats.Errors++
+		l.stats.LastError = err.Error()
+		l.stats.LastErrorTime = now
+	} else {
+		l.stats.Written++
+		l.stats.LastWrite = now
+	}
+	l.mu.Unlock()
+
+	if err != nil {
+		driverWriteErrorsCount.WithValues(l.driver.Name()).Inc(1)
+	}
+	return err
+}
+
+// BufSize returns the size of buffer of the logger, if it controls it.
+func (l *healthLogger) BufSize() int {
+	if sl, ok := l.Logger.(SizedLogger); ok {
+		return sl.BufSize()
+	}
+	return defaultBufSize
+}
+
+func (l *healthLogger) healthStats() HealthStats {
+	l.mu.Lock()
+	stats := l.stats
+	l.mu.Unlock()
+	if r, ok := l.driver.(interface{ restarts() uint64 }); ok {
+		stats.PluginRestarts = r.restarts()
+	}
+	return stats
+}
+
+// GetHealthStats returns the stats of the writes of the messages to the log
+// driver of l, and whether they are recorded, l being a logger returned by
+// WithHealth, or a RingLogger wrapping one.
+func GetHealthStats(l Logger) (HealthStats, bool) {
+	switch r := l.(type) {
+	case *RingLogger:
+		l = r.l
+	case *ringWithReader:
+		l = r.l
+	}
+	switch h := l.(type) {
+	case *healthLogger:
+		return h.healthStats(), true
+	case *healthWithReader:
+		return h.healthStats(), true
+	}
+	return HealthStats{}, false
+}
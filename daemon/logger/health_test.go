@@ -0,0 +1,57 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type restartedLogger struct {
+	resettingLogger
+}
+
+func (l *restartedLogger) restarts() uint64 { return 3 }
+
+func TestHealthLogger(t *testing.T) {
+	driver := &restartedLogger{resettingLogger{name: "plugin"}}
+	l := WithHealth(driver, driver)
+	_, ok := l.(LogReader)
+	assert.Check(t, !ok, "expected the logs not to be readable without a log reader")
+
+	before := time.Now()
+	for i := 0; i < 2; i++ {
+		msg := NewMessage()
+		msg.Line = append(msg.Line, "message"...)
+		assert.NilError(t, l.Log(msg))
+	}
+	driver.err = errors.New("endpoint unreachable")
+	msg := NewMessage()
+	msg.Line = append(msg.Line, "message"...)
+	assert.Check(t, l.Log(msg) != nil)
+
+	stats, ok := GetHealthStats(l)
+	assert.Assert(t, ok)
+	assert.Check(t, is.Equal(stats.Written, uint64(2)))
+	assert.Check(t, is.Equal(stats.Errors, uint64(1)))
+	assert.Check(t, !stats.LastWrite.Before(before))
+	assert.Check(t, is.Equal(stats.LastError, "endpoint unreachable"))
+	assert.Check(t, !stats.LastErrorTime.Before(stats.LastWrite))
+	assert.Check(t, is.Equal(stats.PluginRestarts, uint64(3)))
+
+	// the stats are got through the buffer of the non-blocking mode
+	ring := NewRingLoggerWithConfig(l, Info{}, RingConfig{MaxSize: -1})
+	stats, ok = GetHealthStats(ring)
+	assert.Assert(t, ok)
+	assert.Check(t, is.Equal(stats.Written, uint64(2)))
+	assert.NilError(t, ring.Close())
+
+	_, ok = GetHealthStats(driver)
+	assert.Check(t, !ok)
+
+	reader := &readingLogger{resettingLogger{name: "local"}}
+	_, ok = WithHealth(reader, reader).(LogReader)
+	assert.Check(t, ok, "expected the logs to be readable from a log reader")
+}
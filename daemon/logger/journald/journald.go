@@ -6,6 +6,12 @@ package journald // import "github.com/docker/docker/daemon/logger/journald"
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"unicode"
 
@@ -15,13 +21,45 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const name = "journald"
+const (
+	name = "journald"
+
+	namespaceKey = "journald-namespace"
+	fieldsKey    = "journald-fields"
+
+	maxFieldNameLength = 64
+)
+
+var (
+	namespaceRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	fieldNameRegexp = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_]*$`)
+
+	// reservedFields are the fields set by the driver, which cannot be set
+	// with journald-fields.
+	reservedFields = map[string]bool{
+		"MESSAGE":                   true,
+		"PRIORITY":                  true,
+		"CONTAINER_ID":              true,
+		"CONTAINER_ID_FULL":         true,
+		"CONTAINER_NAME":            true,
+		"CONTAINER_TAG":             true,
+		"CONTAINER_PARTIAL_MESSAGE": true,
+		"SYSLOG_IDENTIFIER":         true,
+	}
+
+	// machineIDPath and journalDirs locate the journal files of the
+	// namespaces, persistent journals first.
+	machineIDPath = "/etc/machine-id"
+	journalDirs   = []string{"/var/log/journal", "/run/log/journal"}
+)
 
 type journald struct {
-	mu      sync.Mutex
-	vars    map[string]string // additional variables and values to send to the journal along with the log message
-	readers map[*logger.LogWatcher]struct{}
-	closed  bool
+	mu        sync.Mutex
+	vars      map[string]string // additional variables and values to send to the journal along with the log message
+	namespace string            // journal namespace the messages are sent to, the default journal when empty
+	conn      *net.UnixConn     // connection to the journal namespace, nil for the default journal
+	readers   map[*logger.LogWatcher]struct{}
+	closed    bool
 }
 
 func init() {
@@ -56,9 +94,14 @@ func sanitizeKeyMod(s string) string {
 // New creates a journald logger using the configuration passed in on
 // the context.
 func New(info logger.Info) (logger.Logger, error) {
-	if !journal.Enabled() {
+	namespace := info.Config[namespaceKey]
+	if namespace == "" && !journal.Enabled() {
 		return nil, fmt.Errorf("journald is not enabled on this host")
 	}
+	fields, err := parseFields(info.Config[fieldsKey])
+	if err != nil {
+		return nil, err
+	}
 
 	// parse log tag
 	tag, err := loggerutils.ParseLogTag(info, loggerutils.DefaultTemplate)
@@ -80,20 +123,76 @@ func New(info logger.Info) (logger.Logger, error) {
 	for k, v := range extraAttrs {
 		vars[k] = v
 	}
-	return &journald{vars: vars, readers: make(map[*logger.LogWatcher]struct{})}, nil
+	for k, v := range fields {
+		vars[k] = v
+	}
+
+	s := &journald{vars: vars, namespace: namespace, readers: make(map[*logger.LogWatcher]struct{})}
+	if namespace != "" {
+		if s.conn, err = dialNamespace(namespace); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// parseFields parses the custom fields of journald-fields, a comma separated
+// list of NAME=value, the names following the rules of the journal fields.
+func parseFields(s string) (map[string]string, error) {
+	fields := make(map[string]string)
+	if s == "" {
+		return fields, nil
+	}
+	for _, f := range strings.Split(s, ",") {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 || len(kv[0]) > maxFieldNameLength || !fieldNameRegexp.MatchString(kv[0]) {
+			return nil, fmt.Errorf("invalid journald field %q: expected NAME=value, NAME being made of up to %d uppercase letters, numbers and underscores, not starting with an underscore", f, maxFieldNameLength)
+		}
+		if reservedFields[kv[0]] {
+			return nil, fmt.Errorf("invalid journald field %q: %s is set by the journald log driver", f, kv[0])
+		}
+		fields[kv[0]] = kv[1]
+	}
+	return fields, nil
 }
 
-// We don't actually accept any options, but we have to supply a callback for
-// the factory to pass the (probably empty) configuration map to.
+// namespaceJournalDir returns the directory of the journal files of the
+// journal namespace.
+func namespaceJournalDir(namespace string) (string, error) {
+	machineID, err := ioutil.ReadFile(machineIDPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading the machine ID of the journal namespace %s: %v", namespace, err)
+	}
+	for _, dir := range journalDirs {
+		dir = filepath.Join(dir, strings.TrimSpace(string(machineID))+"."+namespace)
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no journal found for the journal namespace %s", namespace)
+}
+
+// validateLogOpt looks for journald specific log options journald-namespace
+// and journald-fields.
 func validateLogOpt(cfg map[string]string) error {
-	for key := range cfg {
+	for key, val := range cfg {
 		switch key {
 		case "labels":
 		case "env":
 		case "env-regex":
 		case "tag":
+		case namespaceKey:
+			if !namespaceRegexp.MatchString(val) {
+				return fmt.Errorf("invalid value %q for log opt '%s': the name of a journal namespace is made of letters, numbers, '_' and '-'", val, key)
+			}
+		case fieldsKey:
+			if _, err := parseFields(val); err != nil {
+				return err
+			}
 		default:
-			return fmt.Errorf("unknown log opt '%s' for journald log driver", key)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt '%s' for journald log driver", key)
+			}
 		}
 	}
 	return nil
@@ -113,9 +212,24 @@ func (s *journald) Log(msg *logger.Message) error {
 	logger.PutMessage(msg)
 
 	if source == "stderr" {
-		return journal.Send(line, journal.PriErr, vars)
+		return s.send(line, journal.PriErr, vars)
+	}
+	return s.send(line, journal.PriInfo, vars)
+}
+
+func (s *journald) send(message string, priority journal.Priority, vars map[string]string) error {
+	if s.conn == nil {
+		return journal.Send(message, priority, vars)
+	}
+	return sendTo(s.conn, message, priority, vars)
+}
+
+// closeConn closes the connection to the journal namespace, if any.
+func (s *journald) closeConn() error {
+	if s.conn == nil {
+		return nil
 	}
-	return journal.Send(line, journal.PriInfo, vars)
+	return s.conn.Close()
 }
 
 func (s *journald) Name() string {
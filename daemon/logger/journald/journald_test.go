@@ -3,7 +3,15 @@
 package journald // import "github.com/docker/docker/daemon/logger/journald"
 
 import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/coreos/go-systemd/journal"
 )
 
 func TestSanitizeKeyMod(t *testing.T) {
@@ -21,3 +29,111 @@ func TestSanitizeKeyMod(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields("TEAM=payments,RETENTION=30d,EMPTY=,URL=http://x/?a=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"TEAM": "payments", "RETENTION": "30d", "EMPTY": "", "URL": "http://x/?a=b"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expected fields %v, got %v", expected, fields)
+	}
+
+	for _, s := range []string{"team=payments", "_TEAM=payments", "TEAM", "TE-AM=payments", "CONTAINER_NAME=web", "MESSAGE=hi"} {
+		if _, err := parseFields(s); err == nil {
+			t.Fatalf("expected an error parsing the fields %q", s)
+		}
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	for _, cfg := range []map[string]string{
+		{namespaceKey: "payments", fieldsKey: "TEAM=payments", "tag": "{{.Name}}"},
+		{namespaceKey: "my_ns-1"},
+	} {
+		if err := validateLogOpt(cfg); err != nil {
+			t.Fatalf("unexpected error validating %v: %v", cfg, err)
+		}
+	}
+	for _, cfg := range []map[string]string{
+		{namespaceKey: ""},
+		{namespaceKey: "../payments"},
+		{fieldsKey: "team=payments"},
+		{"journald-unknown": "1"},
+	} {
+		if err := validateLogOpt(cfg); err == nil {
+			t.Fatalf("expected an error validating %v", cfg)
+		}
+	}
+}
+
+func TestNamespaceJournalDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(p string, dirs []string) {
+		machineIDPath, journalDirs = p, dirs
+	}(machineIDPath, journalDirs)
+	machineIDPath = filepath.Join(dir, "machine-id")
+	journalDirs = []string{filepath.Join(dir, "var"), filepath.Join(dir, "run")}
+	if err := ioutil.WriteFile(machineIDPath, []byte("0123456789abcdef\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := namespaceJournalDir("payments"); err == nil {
+		t.Fatal("expected an error without a journal for the namespace")
+	}
+	volatile := filepath.Join(dir, "run", "0123456789abcdef.payments")
+	if err := os.MkdirAll(volatile, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := namespaceJournalDir("payments"); err != nil || d != volatile {
+		t.Fatalf("expected the volatile journal %s, got %s: %v", volatile, d, err)
+	}
+	persistent := filepath.Join(dir, "var", "0123456789abcdef.payments")
+	if err := os.MkdirAll(persistent, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := namespaceJournalDir("payments"); err != nil || d != persistent {
+		t.Fatalf("expected the persistent journal %s, got %s: %v", persistent, d, err)
+	}
+}
+
+func TestSendTo(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	addr := &net.UnixAddr{Name: filepath.Join(dir, "socket"), Net: "unixgram"}
+	l, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := sendTo(conn, "two\nlines", journal.PriErr, map[string]string{"TEAM": "payments"}); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 1024)
+	n, err := l.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len("two\nlines")))
+	expected := "PRIORITY=3\nMESSAGE\n" + string(size) + "two\nlines\nTEAM=payments\n"
+	if string(b[:n]) != expected {
+		t.Fatalf("expected %q, got %q", expected, b[:n])
+	}
+}
@@ -151,6 +151,7 @@ import "C"
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 	"unsafe"
@@ -170,10 +171,10 @@ func (s *journald) Close() error {
 
 	}
 	s.mu.Unlock()
-	return nil
+	return s.closeConn()
 }
 
-func (s *journald) drainJournal(logWatcher *logger.LogWatcher, j *C.sd_journal, oldCursor *C.char, untilUnixMicro uint64) (*C.char, bool) {
+func (s *journald) drainJournal(logWatcher *logger.LogWatcher, j *C.sd_journal, oldCursor *C.char, untilUnixMicro uint64, grep *regexp.Regexp) (*C.char, bool) {
 	var msg, data, cursor *C.char
 	var length C.size_t
 	var stamp C.uint64_t
@@ -208,6 +209,13 @@ drain:
 			// Set up the time and text of the entry.
 			timestamp := time.Unix(int64(stamp)/1000000, (int64(stamp)%1000000)*1000)
 			line := C.GoBytes(unsafe.Pointer(msg), C.int(length))
+			// Skip the entry if it does not match any provided grep.
+			if grep != nil && !grep.Match(line) {
+				if C.sd_journal_next(j) <= 0 {
+					break
+				}
+				continue
+			}
 			if partial == 0 {
 				line = append(line, "\n"...)
 			}
@@ -253,7 +261,19 @@ drain:
 	return cursor, done
 }
 
-func (s *journald) followJournal(logWatcher *logger.LogWatcher, j *C.sd_journal, pfd [2]C.int, cursor *C.char, untilUnixMicro uint64) *C.char {
+// entryMatches returns whether the message of the current entry of the
+// journal matches grep.
+func entryMatches(j *C.sd_journal, grep *regexp.Regexp) bool {
+	var msg *C.char
+	var length C.size_t
+	var partial C.int
+	if i := C.get_message(j, &msg, &length, &partial); i == -C.ENOENT || i == -C.EADDRNOTAVAIL {
+		return false
+	}
+	return grep.Match(C.GoBytes(unsafe.Pointer(msg), C.int(length)))
+}
+
+func (s *journald) followJournal(logWatcher *logger.LogWatcher, j *C.sd_journal, pfd [2]C.int, cursor *C.char, untilUnixMicro uint64, grep *regexp.Regexp) *C.char {
 	s.mu.Lock()
 	s.readers[logWatcher] = struct{}{}
 	if s.closed {
@@ -281,7 +301,7 @@ func (s *journald) followJournal(logWatcher *logger.LogWatcher, j *C.sd_journal,
 			}
 
 			var done bool
-			cursor, done = s.drainJournal(logWatcher, j, cursor, untilUnixMicro)
+			cursor, done = s.drainJournal(logWatcher, j, cursor, untilUnixMicro, grep)
 
 			if status != 1 || done {
 				// We were notified to stop
@@ -318,8 +338,21 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 	var untilUnixMicro uint64
 	var pipes [2]C.int
 
-	// Get a handle to the journal.
-	rc := C.sd_journal_open(&j, C.int(0))
+	// Get a handle to the journal, or to the files of its namespace.
+	var rc C.int
+	if s.namespace != "" {
+		dir, err := namespaceJournalDir(s.namespace)
+		if err != nil {
+			logWatcher.Err <- err
+			close(logWatcher.Msg)
+			return
+		}
+		cdir := C.CString(dir)
+		rc = C.sd_journal_open_directory(&j, cdir, C.int(0))
+		C.free(unsafe.Pointer(cdir))
+	} else {
+		rc = C.sd_journal_open(&j, C.int(0))
+	}
 	if rc != 0 {
 		logWatcher.Err <- fmt.Errorf("error opening journal")
 		close(logWatcher.Msg)
@@ -350,10 +383,11 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 		logWatcher.Err <- fmt.Errorf("error setting journal match")
 		return
 	}
-	// If we have a cutoff time, convert it to Unix time once.
+	// If we have a cutoff time, convert it to Unix time once, rounding it
+	// up for the entries before it not to be read.
 	if !config.Since.IsZero() {
 		nano := config.Since.UnixNano()
-		sinceUnixMicro = uint64(nano / 1000)
+		sinceUnixMicro = uint64((nano + 999) / 1000)
 	}
 	// If we have an until value, convert it too
 	if !config.Until.IsZero() {
@@ -388,7 +422,10 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 					break
 				}
 			}
-			lines--
+			// Only count the entries matching any provided grep.
+			if config.Grep == nil || entryMatches(j, config.Grep) {
+				lines--
+			}
 			// If we're at the start of the journal, or
 			// don't need to back up past any more entries,
 			// stop.
@@ -412,7 +449,7 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 			return
 		}
 	}
-	cursor, _ = s.drainJournal(logWatcher, j, nil, untilUnixMicro)
+	cursor, _ = s.drainJournal(logWatcher, j, nil, untilUnixMicro, config.Grep)
 	if config.Follow {
 		// Allocate a descriptor for following the journal, if we'll
 		// need one.  Do it here so that we can report if it fails.
@@ -424,7 +461,7 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 			if C.pipe(&pipes[0]) == C.int(-1) {
 				logWatcher.Err <- fmt.Errorf("error opening journald close notification pipe")
 			} else {
-				cursor = s.followJournal(logWatcher, j, pipes, cursor, untilUnixMicro)
+				cursor = s.followJournal(logWatcher, j, pipes, cursor, untilUnixMicro, config.Grep)
 				// Let followJournal handle freeing the journal context
 				// object and closing the channel.
 				following = true
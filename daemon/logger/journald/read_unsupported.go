@@ -3,5 +3,5 @@
 package journald // import "github.com/docker/docker/daemon/logger/journald"
 
 func (s *journald) Close() error {
-	return nil
+	return s.closeConn()
 }
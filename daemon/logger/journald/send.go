@@ -0,0 +1,85 @@
+// +build linux
+
+package journald // import "github.com/docker/docker/daemon/logger/journald"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/coreos/go-systemd/journal"
+)
+
+// namespaceSocket returns the path of the socket of the systemd-journald
+// instance of the journal namespace.
+func namespaceSocket(namespace string) string {
+	return "/run/systemd/journal." + namespace + "/socket"
+}
+
+// dialNamespace connects to the socket of the journal namespace.
+func dialNamespace(namespace string) (*net.UnixConn, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: namespaceSocket(namespace), Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journal namespace %s is not available on this host: %v", namespace, err)
+	}
+	return conn, nil
+}
+
+// sendTo sends a message to the journal listening on conn with the native
+// protocol of systemd-journald, as journal.Send does with the default journal.
+// The messages too large for a datagram are passed in a temporary file.
+func sendTo(conn *net.UnixConn, message string, priority journal.Priority, vars map[string]string) error {
+	data := new(bytes.Buffer)
+	appendVariable(data, "PRIORITY", strconv.Itoa(int(priority)))
+	appendVariable(data, "MESSAGE", message)
+	for k, v := range vars {
+		appendVariable(data, k, v)
+	}
+
+	_, err := conn.Write(data.Bytes())
+	if err == nil || !isSocketSpaceError(err) {
+		return err
+	}
+
+	file, err := ioutil.TempFile("/dev/shm", "journal.")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		return err
+	}
+	if _, err := file.Write(data.Bytes()); err != nil {
+		return err
+	}
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), nil)
+	return err
+}
+
+func appendVariable(b *bytes.Buffer, name, value string) {
+	if !strings.ContainsRune(value, '\n') {
+		fmt.Fprintf(b, "%s=%s\n", name, value)
+		return
+	}
+	// the values with newlines are written after the name, prefixed by
+	// their size as a 64 bit little endian integer
+	b.WriteString(name + "\n")
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
+
+func isSocketSpaceError(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	return err == syscall.EMSGSIZE || err == syscall.ENOBUFS
+}
@@ -65,16 +65,12 @@ func New(info logger.Info) (logger.Logger, error) {
 		}
 	}
 
-	var compress bool
-	if compressString, ok := info.Config["compress"]; ok {
-		var err error
-		compress, err = strconv.ParseBool(compressString)
-		if err != nil {
-			return nil, err
-		}
-		if compress && (maxFiles == 1 || capval == -1) {
-			return nil, fmt.Errorf("compress cannot be true when max-file is less than 2 or max-size is not set")
-		}
+	compress, err := loggerutils.ParseCompression(info.Config, loggerutils.Compression{})
+	if err != nil {
+		return nil, err
+	}
+	if compress.Enabled() && (maxFiles == 1 || capval == -1) {
+		return nil, fmt.Errorf("compress cannot be enabled when max-file is less than 2 or max-size is not set")
 	}
 
 	attrs, err := info.ExtraAttributes(nil)
@@ -155,12 +151,15 @@ func ValidateLogOpt(cfg map[string]string) error {
 		case "max-file":
 		case "max-size":
 		case "compress":
+		case "compression-level":
 		case "labels":
 		case "env":
 		case "env-regex":
 		case "tag":
 		default:
-			return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
+			}
 		}
 	}
 	return nil
@@ -152,6 +152,48 @@ func BenchmarkJSONFileLoggerLog(b *testing.B) {
 	}
 }
 
+func TestJSONFileLoggerReadZstdCompressed(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := New(logger.Info{
+		LogPath: filename,
+		Config:  map[string]string{"max-file": "3", "max-size": "1k", "compress": "zstd", "compression-level": "19"},
+	})
+	assert.NilError(t, err)
+	defer l.Close()
+	for i := 0; i < 36; i++ {
+		err := l.Log(&logger.Message{Line: []byte("line" + strconv.Itoa(i)), Source: "src1", Timestamp: time.Now()})
+		assert.NilError(t, err)
+	}
+
+	lw := l.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: -1})
+	defer lw.ConsumerGone()
+	var lines []string
+	for done := false; !done; {
+		select {
+		case msg, ok := <-lw.Msg:
+			if !ok {
+				done = true
+				break
+			}
+			lines = append(lines, string(msg.Line))
+		case err := <-lw.Err:
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout reading the logs")
+		}
+	}
+	_, err = os.Stat(filename + ".2.zst")
+	assert.NilError(t, err)
+	assert.Assert(t, len(lines) > 0)
+	assert.Check(t, is.Equal(lines[len(lines)-1], "line35\n"))
+	for i, line := range lines {
+		assert.Check(t, is.Equal(line, "line"+strconv.Itoa(36-len(lines)+i)+"\n"))
+	}
+}
+
 func TestJSONFileLoggerWithOpts(t *testing.T) {
 	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
 	tmp, err := ioutil.TempDir("", "docker-logger-")
@@ -0,0 +1,98 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	rateLimitKey      = "rate-limit"
+	rateLimitBurstKey = "rate-limit-burst"
+	sampleRateKey     = "sample-rate"
+)
+
+// Limiter limits the rate of the lines logged by a Copier, and samples them.
+// The lines which are not logged are counted.
+type Limiter struct {
+	limiter    *rate.Limiter
+	sampleRate float64
+
+	mu   sync.Mutex // protects rand
+	rand *rand.Rand
+
+	rateLimited uint64 // accessed atomically
+	sampledOut  uint64 // accessed atomically
+}
+
+// NewLimiter returns the limiter of the log options, or nil if they do not
+// limit the lines logged. rate-limit is the number of lines per second
+// logged, rate-limit-burst the number of lines logged above it in bursts,
+// defaulting to rate-limit, and sample-rate the fraction of the lines logged.
+func NewLimiter(cfg map[string]string) (*Limiter, error) {
+	l := &Limiter{sampleRate: 1}
+	if v, ok := cfg[rateLimitKey]; ok {
+		limit, err := strconv.ParseFloat(v, 64)
+		if err != nil || limit <= 0 {
+			return nil, errors.Errorf("invalid value for %s: %s, expected a positive number of lines per second", rateLimitKey, v)
+		}
+		burst := int(limit)
+		if burst < 1 {
+			burst = 1
+		}
+		if v, ok := cfg[rateLimitBurstKey]; ok {
+			if burst, err = strconv.Atoi(v); err != nil || burst < 1 {
+				return nil, errors.Errorf("invalid value for %s: %s, expected a positive number of lines", rateLimitBurstKey, v)
+			}
+		}
+		l.limiter = rate.NewLimiter(rate.Limit(limit), burst)
+	} else if _, ok := cfg[rateLimitBurstKey]; ok {
+		return nil, errors.Errorf("%s cannot be set without %s", rateLimitBurstKey, rateLimitKey)
+	}
+
+	if v, ok := cfg[sampleRateKey]; ok {
+		sampleRate, err := strconv.ParseFloat(v, 64)
+		if err != nil || sampleRate <= 0 || sampleRate > 1 {
+			return nil, errors.Errorf("invalid value for %s: %s, expected a fraction of the lines greater than 0, up to 1", sampleRateKey, v)
+		}
+		l.sampleRate = sampleRate
+		l.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	if l.limiter == nil && l.sampleRate == 1 {
+		return nil, nil
+	}
+	return l, nil
+}
+
+// Allow returns whether a line is logged, counting it otherwise. The lines
+// are sampled before being rate limited.
+func (l *Limiter) Allow() bool {
+	if l.sampleRate < 1 {
+		l.mu.Lock()
+		sampled := l.rand.Float64() < l.sampleRate
+		l.mu.Unlock()
+		if !sampled {
+			atomic.AddUint64(&l.sampledOut, 1)
+			logLinesDroppedCount.WithValues("sampling").Inc(1)
+			return false
+		}
+	}
+	if l.limiter != nil && !l.limiter.Allow() {
+		atomic.AddUint64(&l.rateLimited, 1)
+		logLinesDroppedCount.WithValues("rate_limit").Inc(1)
+		return false
+	}
+	return true
+}
+
+// Dropped returns the numbers of lines which were not logged because of the
+// rate limit, and of the sampling.
+func (l *Limiter) Dropped() (rateLimited, sampledOut uint64) {
+	return atomic.LoadUint64(&l.rateLimited), atomic.LoadUint64(&l.sampledOut)
+}
@@ -1,21 +1,22 @@
 package local
 
 import (
+	"github.com/docker/docker/daemon/logger/loggerutils"
 	"github.com/pkg/errors"
 )
 
 // CreateConfig is used to configure new instances of driver
 type CreateConfig struct {
-	DisableCompression bool
-	MaxFileSize        int64
-	MaxFileCount       int
+	Compression  loggerutils.Compression
+	MaxFileSize  int64
+	MaxFileCount int
 }
 
 func newDefaultConfig() *CreateConfig {
 	return &CreateConfig{
-		MaxFileSize:        defaultMaxFileSize,
-		MaxFileCount:       defaultMaxFileCount,
-		DisableCompression: !defaultCompressLogs,
+		MaxFileSize:  DefaultMaxFileSize,
+		MaxFileCount: DefaultMaxFileCount,
+		Compression:  loggerutils.Compression{Format: defaultCompression},
 	}
 }
 
@@ -27,7 +28,7 @@ func validateConfig(cfg *CreateConfig) error {
 		return errors.New("max file count cannot be less than 0")
 	}
 
-	if !cfg.DisableCompression {
+	if cfg.Compression.Enabled() {
 		if cfg.MaxFileCount <= 1 {
 			return errors.New("compression cannot be enabled when max file count is 1")
 		}
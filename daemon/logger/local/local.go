@@ -25,16 +25,19 @@ const (
 	initialBufSize  = 2048
 	maxDecodeRetry  = 20000
 
-	defaultMaxFileSize  int64 = 20 * 1024 * 1024
-	defaultMaxFileCount       = 5
-	defaultCompressLogs       = true
+	// DefaultMaxFileSize is the default max-size of the log files
+	DefaultMaxFileSize int64 = 20 * 1024 * 1024
+	// DefaultMaxFileCount is the default max-file of the log files
+	DefaultMaxFileCount = 5
+	defaultCompression  = loggerutils.CompressGzip
 )
 
 // LogOptKeys are the keys names used for log opts passed in to initialize the driver.
 var LogOptKeys = map[string]bool{
-	"max-file": true,
-	"max-size": true,
-	"compress": true,
+	"max-file":          true,
+	"max-size":          true,
+	"compress":          true,
+	"compression-level": true,
 }
 
 // ValidateLogOpt looks for log driver specific options.
@@ -87,12 +90,10 @@ func New(info logger.Info) (logger.Logger, error) {
 		}
 	}
 
-	if userCompress, ok := info.Config["compress"]; ok {
-		compressLogs, err := strconv.ParseBool(userCompress)
-		if err != nil {
-			return nil, errdefs.InvalidParameter(errors.Wrap(err, "error reading compress log option"))
-		}
-		cfg.DisableCompression = !compressLogs
+	var err error
+	cfg.Compression, err = loggerutils.ParseCompression(info.Config, cfg.Compression)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(err)
 	}
 	return newDriver(info.LogPath, cfg)
 }
@@ -140,7 +141,7 @@ func newDriver(logPath string, cfg *CreateConfig) (logger.Logger, error) {
 		return nil, errdefs.InvalidParameter(err)
 	}
 
-	lf, err := loggerutils.NewLogFile(logPath, cfg.MaxFileSize, cfg.MaxFileCount, !cfg.DisableCompression, makeMarshaller(), decodeFunc, 0640, getTailReader)
+	lf, err := loggerutils.NewLogFile(logPath, cfg.MaxFileSize, cfg.MaxFileCount, cfg.Compression, makeMarshaller(), decodeFunc, 0640, getTailReader)
 	if err != nil {
 		return nil, err
 	}
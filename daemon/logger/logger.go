@@ -8,6 +8,8 @@
 package logger // import "github.com/docker/docker/daemon/logger"
 
 import (
+	"bytes"
+	"regexp"
 	"sync"
 	"time"
 
@@ -91,6 +93,17 @@ type ReadConfig struct {
 	Until  time.Time
 	Tail   int
 	Follow bool
+	// Grep, when set, filters the messages whose line does not match it
+	// out, Tail counting the matching messages only. It is not passed to
+	// the logging plugins, the daemon filtering the messages they read,
+	// after their Tail.
+	Grep *regexp.Regexp `json:"-"`
+}
+
+// Match returns whether the line of msg, without its trailing newline,
+// matches the Grep of the config.
+func (config *ReadConfig) Match(msg *Message) bool {
+	return config.Grep == nil || config.Grep.Match(bytes.TrimSuffix(msg.Line, []byte("\n")))
 }
 
 // LogReader is the interface for reading log messages for loggers that support reading.
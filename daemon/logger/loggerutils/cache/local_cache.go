@@ -0,0 +1,161 @@
+package cache // import "github.com/docker/docker/daemon/logger/loggerutils/cache"
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/local"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DriverName is the name of the driver used for the local cache
+	DriverName = local.Name
+
+	cachePrefix      = "cache-"
+	cacheDisabledKey = cachePrefix + "disabled"
+)
+
+var builtInCacheLogOpts = map[string]bool{
+	cacheDisabledKey: true,
+}
+
+// WithLocalCache wraps the passed in logger with a logger which also writes
+// the messages to a local cache, the logs being read from the cache. The
+// cache is configured by the options of info prefixed by "cache-".
+func WithLocalCache(l logger.Logger, info logger.Info) (logger.Logger, error) {
+	initLogger, err := logger.GetLogDriver(DriverName)
+	if err != nil {
+		return nil, err
+	}
+
+	cacher, err := initLogger(cacheInfo(info))
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing local log cache driver")
+	}
+
+	return &loggerWithCache{
+		l:     l,
+		cache: cacher,
+	}, nil
+}
+
+// NewReader returns a logger reading the logs cached with the options of
+// info, at info.LogPath, without the log driver they were written by.
+func NewReader(info logger.Info) (logger.Logger, error) {
+	initLogger, err := logger.GetLogDriver(DriverName)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := initLogger(cacheInfo(info))
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing local log cache driver")
+	}
+	return l, nil
+}
+
+// cacheInfo returns the info of the local driver of the cache, with the
+// options of the cache without their prefix.
+func cacheInfo(info logger.Info) logger.Info {
+	cfg := make(map[string]string)
+	for k, v := range info.Config {
+		if k == cacheDisabledKey || !strings.HasPrefix(k, cachePrefix) {
+			continue
+		}
+		cfg[strings.TrimPrefix(k, cachePrefix)] = v
+	}
+	info.Config = cfg
+	return info
+}
+
+type loggerWithCache struct {
+	l     logger.Logger
+	cache logger.Logger
+}
+
+func (l *loggerWithCache) Log(msg *logger.Message) error {
+	// copy the message as the original will be reset once the call to `Log`
+	// is complete
+	dup := logger.NewMessage()
+	dumbCopyMessage(dup, msg)
+
+	if err := l.l.Log(msg); err != nil {
+		return err
+	}
+	return l.cache.Log(dup)
+}
+
+func (l *loggerWithCache) Name() string {
+	return l.l.Name()
+}
+
+func (l *loggerWithCache) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
+	return l.cache.(logger.LogReader).ReadLogs(config)
+}
+
+func (l *loggerWithCache) Close() error {
+	err := l.l.Close()
+	if err := l.cache.Close(); err != nil {
+		logrus.WithError(err).Warn("error while shutting cache logger")
+	}
+	return err
+}
+
+// ShouldUseCache returns whether the logs should be cached locally, when the
+// log driver cannot read them back, according to the log options.
+func ShouldUseCache(cfg map[string]string) bool {
+	disabled, _ := strconv.ParseBool(cfg[cacheDisabledKey])
+	return !disabled
+}
+
+// MergeDefaultLogConfig adds the options of the cache of the default log
+// options of the daemon to dst, unless dst already sets them.
+func MergeDefaultLogConfig(dst, defaults map[string]string) {
+	for k, v := range defaults {
+		if !builtInCacheLogOpts[k] {
+			continue
+		}
+		if _, exists := dst[k]; !exists {
+			dst[k] = v
+		}
+	}
+}
+
+// Usage returns the disk space used by the files of the cache at logPath,
+// including the rotated ones, and the space they may use according to the
+// log options.
+func Usage(logPath string, cfg map[string]string) (size int64, maxSize int64, err error) {
+	files, err := filepath.Glob(logPath + ".*")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, f := range append(files, logPath) {
+		fi, err := os.Stat(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, 0, err
+		}
+		size += fi.Size()
+	}
+
+	maxFileSize, maxFileCount := local.DefaultMaxFileSize, local.DefaultMaxFileCount
+	if v, ok := cfg[cachePrefix+"max-size"]; ok {
+		if maxFileSize, err = units.FromHumanSize(v); err != nil {
+			return 0, 0, err
+		}
+	}
+	if v, ok := cfg[cachePrefix+"max-file"]; ok {
+		if maxFileCount, err = strconv.Atoi(v); err != nil {
+			return 0, 0, err
+		}
+	}
+	return size, maxFileSize * int64(maxFileCount), nil
+}
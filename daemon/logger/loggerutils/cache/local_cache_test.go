@@ -0,0 +1,150 @@
+package cache // import "github.com/docker/docker/daemon/logger/loggerutils/cache"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type fakeLogger struct {
+	messages []string
+	closed   bool
+}
+
+func (l *fakeLogger) Log(msg *logger.Message) error {
+	l.messages = append(l.messages, string(msg.Line))
+	// the message is reset as the message of a caller would be
+	logger.PutMessage(msg)
+	return nil
+}
+
+func (l *fakeLogger) Name() string {
+	return "fake"
+}
+
+func (l *fakeLogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+func TestLoggerWithCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "container-cached.log")
+	fake := &fakeLogger{}
+	l, err := WithLocalCache(fake, logger.Info{
+		LogPath: logPath,
+		Config:  map[string]string{"cache-max-size": "1k", "cache-max-file": "2"},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(l.Name(), "fake"))
+
+	for _, line := range []string{"message 1", "message 2"} {
+		msg := logger.NewMessage()
+		msg.Source = "stdout"
+		msg.Timestamp = time.Now()
+		msg.Line = append(msg.Line, line...)
+		assert.NilError(t, l.Log(msg))
+	}
+	assert.Check(t, is.DeepEqual(fake.messages, []string{"message 1", "message 2"}))
+
+	lw := l.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: -1})
+	defer lw.ConsumerGone()
+	var read []string
+	for len(read) < 2 {
+		select {
+		case msg := <-lw.Msg:
+			read = append(read, string(msg.Line))
+		case err := <-lw.Err:
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout reading the logs from the cache")
+		}
+	}
+	assert.Check(t, is.DeepEqual(read, []string{"message 1\n", "message 2\n"}))
+
+	size, maxSize, err := Usage(logPath, map[string]string{"cache-max-size": "1k", "cache-max-file": "2"})
+	assert.NilError(t, err)
+	assert.Check(t, size > 0)
+	assert.Check(t, is.Equal(maxSize, int64(2000)))
+
+	assert.NilError(t, l.Close())
+	assert.Check(t, fake.closed)
+}
+
+func TestValidateLogCacheOpts(t *testing.T) {
+	for _, tc := range []struct {
+		opts  map[string]string
+		valid bool
+	}{
+		{opts: map[string]string{}, valid: true},
+		{opts: map[string]string{"cache-disabled": "true", "cache-max-size": "10m", "cache-max-file": "3", "cache-compress": "false"}, valid: true},
+		{opts: map[string]string{"cache-disabled": "maybe"}},
+		{opts: map[string]string{"cache-max-size": "big"}},
+		{opts: map[string]string{"cache-max-file": "0"}},
+		{opts: map[string]string{"cache-compress": "yes please"}},
+		{opts: map[string]string{"cache-compress": "zstd", "cache-compression-level": "19"}, valid: true},
+		{opts: map[string]string{"cache-compress": "gzip", "cache-compression-level": "19"}},
+		{opts: map[string]string{"cache-unknown": "1"}},
+	} {
+		err := validateLogCacheOpts(tc.opts)
+		if tc.valid {
+			assert.Check(t, err, "%v", tc.opts)
+		} else {
+			assert.Check(t, err != nil, "expected an error validating %v", tc.opts)
+		}
+	}
+}
+
+func TestMergeDefaultLogConfig(t *testing.T) {
+	cfg := map[string]string{"cache-max-file": "2", "tag": "x"}
+	MergeDefaultLogConfig(cfg, map[string]string{"cache-max-file": "5", "cache-disabled": "true", "max-size": "1m"})
+	assert.Check(t, is.DeepEqual(cfg, map[string]string{"cache-max-file": "2", "cache-disabled": "true", "tag": "x"}))
+}
+
+func TestShouldUseCache(t *testing.T) {
+	assert.Check(t, ShouldUseCache(nil))
+	assert.Check(t, ShouldUseCache(map[string]string{"cache-disabled": "false"}))
+	assert.Check(t, !ShouldUseCache(map[string]string{"cache-disabled": "true"}))
+}
+
+func TestNewReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	info := logger.Info{
+		LogPath: filepath.Join(dir, "container-cached.log"),
+		Config:  map[string]string{"cache-max-file": "2"},
+	}
+	l, err := WithLocalCache(&fakeLogger{}, info)
+	assert.NilError(t, err)
+	msg := logger.NewMessage()
+	msg.Source = "stdout"
+	msg.Timestamp = time.Now()
+	msg.Line = append(msg.Line, "message 1"...)
+	assert.NilError(t, l.Log(msg))
+	assert.NilError(t, l.Close())
+
+	r, err := NewReader(info)
+	assert.NilError(t, err)
+	defer r.Close()
+	lw := r.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: -1})
+	defer lw.ConsumerGone()
+	select {
+	case msg := <-lw.Msg:
+		assert.Check(t, is.Equal(string(msg.Line), "message 1\n"))
+	case err := <-lw.Err:
+		t.Fatal(err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout reading the logs from the cache")
+	}
+}
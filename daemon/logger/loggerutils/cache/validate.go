@@ -0,0 +1,62 @@
+package cache // import "github.com/docker/docker/daemon/logger/loggerutils/cache"
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/local"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	for k, v := range local.LogOptKeys {
+		builtInCacheLogOpts[cachePrefix+k] = v
+	}
+	logger.AddBuiltinLogOpts(builtInCacheLogOpts)
+	logger.RegisterExternalValidator(validateLogCacheOpts)
+}
+
+func validateLogCacheOpts(cfg map[string]string) error {
+	if v := cfg[cacheDisabledKey]; v != "" {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return errors.Errorf("invalid value for option %s: %s", cacheDisabledKey, v)
+		}
+	}
+
+	opts := make(map[string]string)
+	for k, v := range cfg {
+		if k != cacheDisabledKey && strings.HasPrefix(k, cachePrefix) {
+			opts[strings.TrimPrefix(k, cachePrefix)] = v
+		}
+	}
+	if err := local.ValidateLogOpt(opts); err != nil {
+		return errors.Wrap(err, "error validating the options of the local log cache")
+	}
+	if v, ok := opts["max-size"]; ok {
+		if _, err := units.FromHumanSize(v); err != nil {
+			return errors.Wrapf(err, "invalid value for option %smax-size", cachePrefix)
+		}
+	}
+	if v, ok := opts["max-file"]; ok {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			return errors.Errorf("invalid value for option %smax-file: %s", cachePrefix, v)
+		}
+	}
+	if _, err := loggerutils.ParseCompression(opts, loggerutils.Compression{}); err != nil {
+		return errors.Wrap(err, "error validating the options of the local log cache")
+	}
+	return nil
+}
+
+// dumbCopyMessage copies the message, with a copy of its line, to dst.
+func dumbCopyMessage(dst, src *logger.Message) {
+	dst.Source = src.Source
+	dst.Timestamp = src.Timestamp
+	dst.PLogMetaData = src.PLogMetaData
+	dst.Err = src.Err
+	dst.Attrs = src.Attrs
+	dst.Line = append(dst.Line[:0], src.Line...)
+}
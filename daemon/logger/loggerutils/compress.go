@@ -0,0 +1,190 @@
+package loggerutils // import "github.com/docker/docker/daemon/logger/loggerutils"
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CompressGzip compresses the rotated log files with gzip.
+	CompressGzip = "gzip"
+	// CompressZstd compresses the rotated log files with zstd.
+	CompressZstd = "zstd"
+
+	gzipExt = ".gz"
+	zstdExt = ".zst"
+
+	// zstdMetadataMagic is the magic number of the skippable frame holding
+	// the rotateFileMetadata, at the start of the zstd log files, which the
+	// decoders ignore.
+	zstdMetadataMagic = 0x184D2A50
+	zstdMaxLevel      = 22
+)
+
+// compressedExts are the extensions of the compressed log files, which are
+// all read whatever the compression of the log file.
+var compressedExts = []string{gzipExt, zstdExt}
+
+// Compression is the compression of the rotated log files.
+type Compression struct {
+	// Format is the format of the compressed files, either CompressGzip or
+	// CompressZstd. The rotated files are not compressed when empty.
+	Format string
+	// Level is the compression level, the default level of the format being
+	// used when 0.
+	Level int
+}
+
+// Enabled returns whether the rotated log files are compressed.
+func (c Compression) Enabled() bool {
+	return c.Format != ""
+}
+
+func (c Compression) extension() string {
+	switch c.Format {
+	case CompressGzip:
+		return gzipExt
+	case CompressZstd:
+		return zstdExt
+	}
+	return ""
+}
+
+// ParseCompression parses the compression of the rotated log files from the
+// compress and compression-level log options, def being the compression when
+// compress is not set. compress is either a boolean, true meaning gzip, or the
+// name of the compression format.
+func ParseCompression(cfg map[string]string, def Compression) (Compression, error) {
+	c := def
+	if v, ok := cfg["compress"]; ok {
+		c = Compression{}
+		switch strings.ToLower(v) {
+		case CompressGzip, CompressZstd:
+			c.Format = strings.ToLower(v)
+		default:
+			compress, err := strconv.ParseBool(v)
+			if err != nil {
+				return c, errors.Errorf("invalid value for compress: %s, expected a boolean, %s or %s", v, CompressGzip, CompressZstd)
+			}
+			if compress {
+				c.Format = CompressGzip
+			}
+		}
+	}
+
+	if v, ok := cfg["compression-level"]; ok {
+		if !c.Enabled() {
+			return c, errors.New("compression-level cannot be set when compress is not enabled")
+		}
+		level, err := strconv.Atoi(v)
+		if err != nil {
+			return c, errors.Wrapf(err, "invalid value for compression-level: %s", v)
+		}
+		maxLevel := gzip.BestCompression
+		if c.Format == CompressZstd {
+			maxLevel = zstdMaxLevel
+		}
+		if level < 1 || level > maxLevel {
+			return c, errors.Errorf("invalid value for compression-level: %d, the %s levels range from 1 to %d", level, c.Format, maxLevel)
+		}
+		c.Level = level
+	}
+	return c, nil
+}
+
+// newWriter returns a writer compressing to w, storing the metadata in the
+// compressed stream.
+func (c Compression) newWriter(w io.Writer, metadata rotateFileMetadata) (io.WriteCloser, error) {
+	extra, err := json.Marshal(&metadata)
+	if err != nil {
+		// Here log the error only and don't return since this is just an optimization.
+		logrus.Warningf("Failed to marshal the log file metadata as JSON: %v", err)
+	}
+
+	switch c.Format {
+	case CompressGzip:
+		level := gzip.DefaultCompression
+		if c.Level != 0 {
+			level = c.Level
+		}
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		// Add the last log entry timestamp to the gzip header
+		gw.Header.Extra = extra
+		return gw, nil
+	case CompressZstd:
+		if len(extra) > 0 {
+			hdr := make([]byte, 8)
+			binary.LittleEndian.PutUint32(hdr, zstdMetadataMagic)
+			binary.LittleEndian.PutUint32(hdr[4:], uint32(len(extra)))
+			if _, err := w.Write(append(hdr, extra...)); err != nil {
+				return nil, err
+			}
+		}
+		var opts []zstd.EOption
+		if c.Level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	}
+	return nil, errors.Errorf("unknown compression format: %s", c.Format)
+}
+
+// newDecompressReader returns a reader decompressing r, the format being
+// given by the extension of the file, with the metadata of the file.
+func newDecompressReader(r io.Reader, ext string) (io.ReadCloser, *rotateFileMetadata, error) {
+	extra := &rotateFileMetadata{}
+	switch ext {
+	case gzipExt:
+		rc, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error making gzip reader for compressed log file")
+		}
+		// Extract the last log entry timestamp from the gzip header
+		if err := json.Unmarshal(rc.Header.Extra, extra); err != nil {
+			extra = nil
+		}
+		return rc, extra, nil
+	case zstdExt:
+		br := bufio.NewReader(r)
+		if hdr, err := br.Peek(8); err == nil && binary.LittleEndian.Uint32(hdr) == zstdMetadataMagic {
+			data := make([]byte, 8+int(binary.LittleEndian.Uint32(hdr[4:])))
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, nil, errors.Wrap(err, "error reading the metadata of the compressed log file")
+			}
+			if err := json.Unmarshal(data[8:], extra); err != nil {
+				extra = nil
+			}
+		} else {
+			extra = nil
+		}
+		dec, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error making zstd reader for compressed log file")
+		}
+		return zstdReadCloser{dec}, extra, nil
+	}
+	return nil, nil, errors.Errorf("unknown compressed log file extension: %s", ext)
+}
+
+// zstdReadCloser makes the zstd decoder an io.ReadCloser, releasing its
+// resources on Close.
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (r zstdReadCloser) Close() error {
+	r.Decoder.Close()
+	return nil
+}
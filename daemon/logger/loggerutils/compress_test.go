@@ -0,0 +1,103 @@
+package loggerutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestParseCompression(t *testing.T) {
+	gzip := Compression{Format: CompressGzip}
+	for _, tc := range []struct {
+		cfg      map[string]string
+		def      Compression
+		expected Compression
+		err      string
+	}{
+		{cfg: map[string]string{}, expected: Compression{}},
+		{cfg: map[string]string{}, def: gzip, expected: gzip},
+		{cfg: map[string]string{"compress": "false"}, def: gzip, expected: Compression{}},
+		{cfg: map[string]string{"compress": "true"}, expected: gzip},
+		{cfg: map[string]string{"compress": "ZSTD"}, expected: Compression{Format: CompressZstd}},
+		{cfg: map[string]string{"compress": "zstd", "compression-level": "19"}, expected: Compression{Format: CompressZstd, Level: 19}},
+		{cfg: map[string]string{"compression-level": "3"}, def: gzip, expected: Compression{Format: CompressGzip, Level: 3}},
+		{cfg: map[string]string{"compress": "lz4"}, err: "invalid value for compress"},
+		{cfg: map[string]string{"compression-level": "3"}, err: "compress is not enabled"},
+		{cfg: map[string]string{"compress": "gzip", "compression-level": "19"}, err: "levels range from 1 to 9"},
+		{cfg: map[string]string{"compress": "zstd", "compression-level": "0"}, err: "levels range from 1 to 22"},
+	} {
+		c, err := ParseCompression(tc.cfg, tc.def)
+		if tc.err != "" {
+			assert.Check(t, is.ErrorContains(err, tc.err), "%v", tc.cfg)
+			continue
+		}
+		assert.Check(t, err, "%v", tc.cfg)
+		assert.Check(t, is.Equal(c, tc.expected))
+	}
+}
+
+func TestCompressDecompressFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := strings.Repeat("a chatty container logging the same line\n", 1000)
+	lastTimestamp := time.Now().Truncate(time.Second)
+
+	for _, compress := range []Compression{
+		{Format: CompressGzip},
+		{Format: CompressZstd},
+		{Format: CompressZstd, Level: 19},
+	} {
+		fileName := filepath.Join(dir, "container.log.1")
+		assert.NilError(t, ioutil.WriteFile(fileName, []byte(content), 0640))
+
+		compressFile(fileName, compress, lastTimestamp)
+		_, err := os.Stat(fileName)
+		assert.Check(t, os.IsNotExist(err), "expected the log file to be removed once compressed")
+		fi, err := os.Stat(fileName + compress.extension())
+		assert.NilError(t, err)
+		assert.Check(t, fi.Size() < int64(len(content)/10), "%v: expected the file to be compressed", compress)
+
+		tmpFile, err := decompressfile(fileName, fileName+tmpLogfileSuffix, lastTimestamp.Add(-time.Minute))
+		assert.NilError(t, err)
+		decompressed, err := ioutil.ReadFile(tmpFile.Name())
+		tmpFile.Close()
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(decompressed), content), "%v", compress)
+		assert.NilError(t, os.Remove(tmpFile.Name()))
+
+		// the file does not need to be read when its last log is before since
+		tmpFile, err = decompressfile(fileName, fileName+tmpLogfileSuffix, lastTimestamp.Add(time.Minute))
+		assert.NilError(t, err)
+		assert.Check(t, tmpFile == nil, "%v", compress)
+
+		assert.NilError(t, os.Remove(fileName+compress.extension()))
+	}
+}
+
+func TestRotateCompressed(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "container.log")
+	compress := Compression{Format: CompressZstd}
+	for _, f := range []string{name, name + ".1.zst", name + ".2.zst"} {
+		assert.NilError(t, ioutil.WriteFile(f, []byte(f), 0640))
+	}
+	assert.NilError(t, rotate(name, 3, compress))
+
+	b, err := ioutil.ReadFile(name + ".1")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), name))
+	b, err = ioutil.ReadFile(name + ".2.zst")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), name+".1.zst"))
+}
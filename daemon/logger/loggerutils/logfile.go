@@ -1,9 +1,7 @@
 package loggerutils // import "github.com/docker/docker/daemon/logger/loggerutils"
 
 import (
-	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -24,7 +22,7 @@ import (
 
 const tmpLogfileSuffix = ".tmp"
 
-// rotateFileMetadata is a metadata of the gzip header of the compressed log file
+// rotateFileMetadata is a metadata of the header of the compressed log file
 type rotateFileMetadata struct {
 	LastTime time.Time `json:"lastTime,omitempty"`
 }
@@ -80,13 +78,13 @@ type LogFile struct {
 	mu              sync.RWMutex // protects the logfile access
 	f               *os.File     // store for closing
 	closed          bool
-	rotateMu        sync.Mutex // blocks the next rotation until the current rotation is completed
-	capacity        int64      // maximum size of each file
-	currentSize     int64      // current size of the latest file
-	maxFiles        int        // maximum number of files
-	compress        bool       // whether old versions of log files are compressed
-	lastTimestamp   time.Time  // timestamp of the last log
-	filesRefCounter refCounter // keep reference-counted of decompressed files
+	rotateMu        sync.Mutex  // blocks the next rotation until the current rotation is completed
+	capacity        int64       // maximum size of each file
+	currentSize     int64       // current size of the latest file
+	maxFiles        int         // maximum number of files
+	compress        Compression // compression of the old versions of log files
+	lastTimestamp   time.Time   // timestamp of the last log
+	filesRefCounter refCounter  // keep reference-counted of decompressed files
 	notifyRotate    *pubsub.Publisher
 	marshal         logger.MarshalFunc
 	createDecoder   makeDecoderFunc
@@ -110,7 +108,7 @@ type SizeReaderAt interface {
 type GetTailReaderFunc func(ctx context.Context, f SizeReaderAt, nLogLines int) (rdr io.Reader, nLines int, err error)
 
 // NewLogFile creates new LogFile
-func NewLogFile(logPath string, capacity int64, maxFiles int, compress bool, marshaller logger.MarshalFunc, decodeFunc makeDecoderFunc, perms os.FileMode, getTailReader GetTailReaderFunc) (*LogFile, error) {
+func NewLogFile(logPath string, capacity int64, maxFiles int, compress Compression, marshaller logger.MarshalFunc, decodeFunc makeDecoderFunc, perms os.FileMode, getTailReader GetTailReaderFunc) (*LogFile, error) {
 	log, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, perms)
 	if err != nil {
 		return nil, err
@@ -191,13 +189,13 @@ func (w *LogFile) checkCapacityAndRotate() error {
 		w.currentSize = 0
 		w.notifyRotate.Publish(struct{}{})
 
-		if w.maxFiles <= 1 || !w.compress {
+		if w.maxFiles <= 1 || !w.compress.Enabled() {
 			w.rotateMu.Unlock()
 			return nil
 		}
 
 		go func() {
-			compressFile(fname+".1", w.lastTimestamp)
+			compressFile(fname+".1", w.compress, w.lastTimestamp)
 			w.rotateMu.Unlock()
 		}()
 	}
@@ -205,15 +203,12 @@ func (w *LogFile) checkCapacityAndRotate() error {
 	return nil
 }
 
-func rotate(name string, maxFiles int, compress bool) error {
+func rotate(name string, maxFiles int, compress Compression) error {
 	if maxFiles < 2 {
 		return nil
 	}
 
-	var extension string
-	if compress {
-		extension = ".gz"
-	}
+	extension := compress.extension()
 
 	lastFile := fmt.Sprintf("%s.%d%s", name, maxFiles-1, extension)
 	err := os.Remove(lastFile)
@@ -236,7 +231,7 @@ func rotate(name string, maxFiles int, compress bool) error {
 	return nil
 }
 
-func compressFile(fileName string, lastTimestamp time.Time) {
+func compressFile(fileName string, compress Compression, lastTimestamp time.Time) {
 	file, err := os.Open(fileName)
 	if err != nil {
 		logrus.Errorf("Failed to open log file: %v", err)
@@ -250,31 +245,31 @@ func compressFile(fileName string, lastTimestamp time.Time) {
 		}
 	}()
 
-	outFile, err := os.OpenFile(fileName+".gz", os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0640)
+	outFileName := fileName + compress.extension()
+	outFile, err := os.OpenFile(outFileName, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0640)
 	if err != nil {
-		logrus.Errorf("Failed to open or create gzip log file: %v", err)
+		logrus.Errorf("Failed to open or create %s log file: %v", compress.Format, err)
 		return
 	}
 	defer func() {
 		outFile.Close()
 		if err != nil {
-			os.Remove(fileName + ".gz")
+			os.Remove(outFileName)
 		}
 	}()
 
-	compressWriter := gzip.NewWriter(outFile)
-	defer compressWriter.Close()
-
-	// Add the last log entry timestramp to the gzip header
-	extra := rotateFileMetadata{}
-	extra.LastTime = lastTimestamp
-	compressWriter.Header.Extra, err = json.Marshal(&extra)
+	compressWriter, err := compress.newWriter(outFile, rotateFileMetadata{LastTime: lastTimestamp})
 	if err != nil {
-		// Here log the error only and don't return since this is just an optimization.
-		logrus.Warningf("Failed to marshal gzip header as JSON: %v", err)
+		logrus.WithError(err).WithField("module", "container.logs").WithField("file", fileName).Error("Error compressing log file")
+		return
 	}
 
 	_, err = pools.Copy(compressWriter, file)
+	if err == nil {
+		err = compressWriter.Close()
+	} else {
+		compressWriter.Close()
+	}
 	if err != nil {
 		logrus.WithError(err).WithField("module", "container.logs").WithField("file", fileName).Error("Error compressing log file")
 		return
@@ -373,7 +368,7 @@ func (w *LogFile) ReadLogs(config logger.ReadConfig, watcher *logger.LogWatcher)
 
 	notifyRotate := w.notifyRotate.Subscribe()
 	defer w.notifyRotate.Evict(notifyRotate)
-	followLogs(currentFile, watcher, notifyRotate, w.createDecoder, config.Since, config.Until)
+	followLogs(currentFile, watcher, notifyRotate, w.createDecoder, config)
 }
 
 func (w *LogFile) openRotatedFiles(config logger.ReadConfig) (files []*os.File, err error) {
@@ -402,7 +397,7 @@ func (w *LogFile) openRotatedFiles(config logger.ReadConfig) (files []*os.File,
 				return nil, errors.Wrap(err, "error opening rotated log file")
 			}
 
-			fileName := fmt.Sprintf("%s.%d.gz", w.f.Name(), i-1)
+			fileName := fmt.Sprintf("%s.%d", w.f.Name(), i-1)
 			decompressedFileName := fileName + tmpLogfileSuffix
 			tmpFile, err := w.filesRefCounter.GetReference(decompressedFileName, func(refFileName string, exists bool) (*os.File, error) {
 				if exists {
@@ -431,23 +426,32 @@ func (w *LogFile) openRotatedFiles(config logger.ReadConfig) (files []*os.File,
 	return files, nil
 }
 
+// decompressfile decompresses the compressed version of the rotated log file
+// fileName to destFileName, whatever its compression format.
 func decompressfile(fileName, destFileName string, since time.Time) (*os.File, error) {
-	cf, err := os.Open(fileName)
+	var (
+		cf  *os.File
+		ext string
+		err error
+	)
+	for _, ext = range compressedExts {
+		cf, err = os.Open(fileName + ext)
+		if !os.IsNotExist(err) {
+			break
+		}
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "error opening file for decompression")
 	}
 	defer cf.Close()
 
-	rc, err := gzip.NewReader(cf)
+	rc, extra, err := newDecompressReader(cf, ext)
 	if err != nil {
-		return nil, errors.Wrap(err, "error making gzip reader for compressed log file")
+		return nil, err
 	}
 	defer rc.Close()
 
-	// Extract the last log entry timestramp from the gzip header
-	extra := &rotateFileMetadata{}
-	err = json.Unmarshal(rc.Header.Extra, extra)
-	if err == nil && extra.LastTime.Before(since) {
+	if extra != nil && extra.LastTime.Before(since) {
 		return nil, nil
 	}
 
@@ -495,7 +499,17 @@ func tailFiles(files []SizeReaderAt, watcher *logger.LogWatcher, createDecoder m
 
 	readers := make([]io.Reader, 0, len(files))
 
-	if config.Tail > 0 {
+	// the lines matching Grep can be anywhere in the files, the last ones of
+	// them being kept while decoding
+	var (
+		matches []*logger.Message
+		next    int
+	)
+	if config.Tail > 0 && config.Grep != nil {
+		matches = make([]*logger.Message, 0, config.Tail)
+	}
+
+	if config.Tail > 0 && config.Grep == nil {
 		for i := len(files) - 1; i >= 0 && nLines > 0; i-- {
 			tail, n, err := getTailReader(ctx, files[i], nLines)
 			if err != nil {
@@ -518,15 +532,36 @@ func tailFiles(files []SizeReaderAt, watcher *logger.LogWatcher, createDecoder m
 		if err != nil {
 			if errors.Cause(err) != io.EOF {
 				watcher.Err <- err
+				return
 			}
-			return
+			break
 		}
 		if !config.Since.IsZero() && msg.Timestamp.Before(config.Since) {
 			continue
 		}
 		if !config.Until.IsZero() && msg.Timestamp.After(config.Until) {
+			break
+		}
+		if !config.Match(msg) {
+			continue
+		}
+		if matches != nil {
+			if len(matches) < config.Tail {
+				matches = append(matches, msg)
+			} else {
+				matches[next] = msg
+			}
+			next = (next + 1) % config.Tail
+			continue
+		}
+		select {
+		case <-ctx.Done():
 			return
+		case watcher.Msg <- msg:
 		}
+	}
+
+	for _, msg := range append(matches[next:], matches[:next]...) {
 		select {
 		case <-ctx.Done():
 			return
@@ -535,7 +570,7 @@ func tailFiles(files []SizeReaderAt, watcher *logger.LogWatcher, createDecoder m
 	}
 }
 
-func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan interface{}, createDecoder makeDecoderFunc, since, until time.Time) {
+func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan interface{}, createDecoder makeDecoderFunc, config logger.ReadConfig) {
 	decodeLogLine := createDecoder(f)
 
 	name := f.Name()
@@ -649,12 +684,15 @@ func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan int
 		}
 
 		retries = 0 // reset retries since we've succeeded
-		if !since.IsZero() && msg.Timestamp.Before(since) {
+		if !config.Since.IsZero() && msg.Timestamp.Before(config.Since) {
 			continue
 		}
-		if !until.IsZero() && msg.Timestamp.After(until) {
+		if !config.Until.IsZero() && msg.Timestamp.After(config.Until) {
 			return
 		}
+		if !config.Match(msg) {
+			continue
+		}
 		// send the message, unless the consumer is gone
 		select {
 		case logWatcher.Msg <- msg:
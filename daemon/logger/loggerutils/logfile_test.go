@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/pkg/tailfile"
 	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
 )
 
 func TestTailFiles(t *testing.T) {
@@ -77,6 +79,67 @@ func TestTailFiles(t *testing.T) {
 	}
 }
 
+func TestTailFilesGrep(t *testing.T) {
+	s1 := strings.NewReader("error 1\ninfo 1\nerror 2\n")
+	s2 := strings.NewReader("info 2\nerror 3\ninfo 3\n")
+	s3 := strings.NewReader("error 4\ninfo 4\n")
+
+	createDecoder := func(r io.Reader) func() (*logger.Message, error) {
+		scanner := bufio.NewScanner(r)
+		return func() (*logger.Message, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			return &logger.Message{Line: append([]byte(nil), scanner.Bytes()...), Timestamp: time.Now()}, nil
+		}
+	}
+	tailReader := func(ctx context.Context, r SizeReaderAt, lines int) (io.Reader, int, error) {
+		return tailfile.NewTailReader(ctx, r, lines)
+	}
+
+	for _, tc := range []struct {
+		tail     int
+		expected []string
+	}{
+		{tail: -1, expected: []string{"error 1", "error 2", "error 3", "error 4"}},
+		{tail: 3, expected: []string{"error 2", "error 3", "error 4"}},
+		{tail: 10, expected: []string{"error 1", "error 2", "error 3", "error 4"}},
+	} {
+		for _, s := range []*strings.Reader{s1, s2, s3} {
+			s.Seek(0, io.SeekStart)
+		}
+		watcher := logger.NewLogWatcher()
+		config := logger.ReadConfig{Tail: tc.tail, Grep: regexp.MustCompile("^error")}
+		done := make(chan struct{})
+		go func() {
+			tailFiles([]SizeReaderAt{s1, s2, s3}, watcher, createDecoder, tailReader, config)
+			close(done)
+		}()
+
+		var lines []string
+	read:
+		for {
+			select {
+			case msg := <-watcher.Msg:
+				lines = append(lines, string(msg.Line))
+			case err := <-watcher.Err:
+				t.Fatal(err)
+			case <-done:
+				break read
+			case <-time.After(10 * time.Second):
+				t.Fatal("timeout waiting for the tail lines")
+			}
+		}
+		for len(watcher.Msg) > 0 {
+			lines = append(lines, string((<-watcher.Msg).Line))
+		}
+		assert.Check(t, is.DeepEqual(lines, tc.expected), "tail %d", tc.tail)
+	}
+}
+
 func TestFollowLogsConsumerGone(t *testing.T) {
 	lw := logger.NewLogWatcher()
 
@@ -94,9 +157,8 @@ func TestFollowLogsConsumerGone(t *testing.T) {
 	}
 
 	followLogsDone := make(chan struct{})
-	var since, until time.Time
 	go func() {
-		followLogs(f, lw, make(chan interface{}), makeDecoder, since, until)
+		followLogs(f, lw, make(chan interface{}), makeDecoder, logger.ReadConfig{})
 		close(followLogsDone)
 	}()
 
@@ -140,11 +202,10 @@ func TestFollowLogsProducerGone(t *testing.T) {
 			return &logger.Message{}, nil
 		}
 	}
-	var since, until time.Time
 
 	followLogsDone := make(chan struct{})
 	go func() {
-		followLogs(f, lw, make(chan interface{}), makeDecoder, since, until)
+		followLogs(f, lw, make(chan interface{}), makeDecoder, logger.ReadConfig{})
 		close(followLogsDone)
 	}()
 
@@ -1,13 +1,24 @@
 package logger // import "github.com/docker/docker/daemon/logger"
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/daemon/logger/templates"
 )
 
+// AttrTemplatePrefix is the prefix of the log options whose values are the
+// templates of extra attributes, the name of the attribute following the
+// prefix, rendered with the Info of the container.
+const AttrTemplatePrefix = "attr-"
+
+const composeProjectLabel = "com.docker.compose.project"
+
 // Info provides enough information for a logging driver to do its function.
 type Info struct {
 	Config              map[string]string
@@ -25,8 +36,8 @@ type Info struct {
 }
 
 // ExtraAttributes returns the user-defined extra attributes (labels,
-// environment variables) in key-value format. This can be used by log drivers
-// that support metadata to add more context to a log.
+// environment variables, rendered templates) in key-value format. This can be
+// used by log drivers that support metadata to add more context to a log.
 func (info *Info) ExtraAttributes(keyMod func(string) string) (map[string]string, error) {
 	extra := make(map[string]string)
 	labels, ok := info.Config["labels"]
@@ -76,9 +87,56 @@ func (info *Info) ExtraAttributes(keyMod func(string) string) (map[string]string
 		}
 	}
 
+	for k, v := range info.Config {
+		if !IsAttrTemplateOpt(k) {
+			continue
+		}
+		attr, err := info.renderAttrTemplate(k, v)
+		if err != nil {
+			return nil, err
+		}
+		k = strings.TrimPrefix(k, AttrTemplatePrefix)
+		if keyMod != nil {
+			k = keyMod(k)
+		}
+		extra[k] = attr
+	}
+
 	return extra, nil
 }
 
+// IsAttrTemplateOpt returns whether the log option key is the template of an
+// extra attribute.
+func IsAttrTemplateOpt(key string) bool {
+	return strings.HasPrefix(key, AttrTemplatePrefix) && len(key) > len(AttrTemplatePrefix)
+}
+
+func (info *Info) renderAttrTemplate(key, value string) (string, error) {
+	tmpl, err := templates.NewParse(key, value)
+	if err != nil {
+		return "", fmt.Errorf("logger: invalid template for log opt '%s': %v", key, err)
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, info); err != nil {
+		return "", fmt.Errorf("logger: error rendering template of log opt '%s': %v", key, err)
+	}
+	return buf.String(), nil
+}
+
+// validateAttrTemplates checks that the templates of the extra attributes of
+// the log options parse.
+func validateAttrTemplates(cfg map[string]string) error {
+	for k, v := range cfg {
+		if !IsAttrTemplateOpt(k) {
+			continue
+		}
+		if _, err := templates.NewParse(k, v); err != nil {
+			return fmt.Errorf("logger: invalid template for log opt '%s': %v", k, err)
+		}
+	}
+	return nil
+}
+
 // Hostname returns the hostname from the underlying OS.
 func (info *Info) Hostname() (string, error) {
 	hostname, err := os.Hostname()
@@ -127,3 +185,40 @@ func (info *Info) ImageFullID() string {
 func (info *Info) ImageName() string {
 	return info.ContainerImageName
 }
+
+// ImageDigest returns the digest of the image reference the container was
+// created with, or an empty string when the image was not referenced by its
+// digest.
+func (info *Info) ImageDigest() string {
+	ref, err := reference.ParseNormalizedNamed(info.ContainerImageName)
+	if err != nil {
+		return ""
+	}
+	if canonical, ok := ref.(reference.Canonical); ok {
+		return canonical.Digest().String()
+	}
+	return ""
+}
+
+// Label returns the value of the label of the container, or an empty string
+// when the container has no such label.
+func (info *Info) Label(name string) string {
+	return info.ContainerLabels[name]
+}
+
+// Env returns the value of the environment variable of the container, or an
+// empty string when it is not set.
+func (info *Info) Env(name string) string {
+	for _, e := range info.ContainerEnv {
+		if kv := strings.SplitN(e, "=", 2); len(kv) == 2 && kv[0] == name {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// ComposeProject returns the name of the compose project of the container,
+// from its com.docker.compose.project label.
+func (info *Info) ComposeProject() string {
+	return info.ContainerLabels[composeProjectLabel]
+}
@@ -0,0 +1,69 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestExtraAttributesTemplates(t *testing.T) {
+	info := Info{
+		Config: map[string]string{
+			"labels":                   "team",
+			"attr-project":             "{{.ComposeProject}}",
+			"attr-version":             `{{.Env "VERSION"}}`,
+			"attr-owner":               `{{.Label "team" | upper}}`,
+			"attr-digest":              "{{.ImageDigest}}",
+			"tag":                      "{{.Name}}",
+			AttrTemplatePrefix:         "not an attribute",
+			"attr-container":           "{{.Name}}/{{.ID}}",
+			"attr-missing-environment": `{{.Env "MISSING"}}`,
+		},
+		ContainerID:        "0123456789abcdef0123456789abcdef",
+		ContainerName:      "/shop_web_1",
+		ContainerImageName: "busybox@sha256:4b8407fa6dc8d3a4fd4fcd1b4e3e33ae0dc1e4a4c88c9cab1ffb10fe2a7fa3c4",
+		ContainerEnv:       []string{"VERSION=1.2.3", "EMPTY="},
+		ContainerLabels:    map[string]string{"team": "payments", composeProjectLabel: "shop"},
+	}
+
+	attrs, err := info.ExtraAttributes(nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(attrs, map[string]string{
+		"team":                "payments",
+		"project":             "shop",
+		"version":             "1.2.3",
+		"owner":               "PAYMENTS",
+		"digest":              "sha256:4b8407fa6dc8d3a4fd4fcd1b4e3e33ae0dc1e4a4c88c9cab1ffb10fe2a7fa3c4",
+		"container":           "shop_web_1/0123456789ab",
+		"missing-environment": "",
+	}))
+
+	attrs, err = info.ExtraAttributes(func(key string) string { return "_" + key })
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(attrs["_project"], "shop"))
+
+	info.Config = map[string]string{"attr-bad": "{{.Unknown}}"}
+	_, err = info.ExtraAttributes(nil)
+	assert.Check(t, is.ErrorContains(err, "error rendering template of log opt 'attr-bad'"))
+}
+
+func TestImageDigest(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		expected string
+	}{
+		{image: "busybox", expected: ""},
+		{image: "busybox:latest", expected: ""},
+		{image: "sha256:4b8407fa6dc8d3a4fd4fcd1b4e3e33ae0dc1e4a4c88c9cab1ffb10fe2a7fa3c4", expected: ""},
+		{image: "example.com/busybox:1@sha256:4b8407fa6dc8d3a4fd4fcd1b4e3e33ae0dc1e4a4c88c9cab1ffb10fe2a7fa3c4", expected: "sha256:4b8407fa6dc8d3a4fd4fcd1b4e3e33ae0dc1e4a4c88c9cab1ffb10fe2a7fa3c4"},
+	} {
+		info := Info{ContainerImageName: tc.image}
+		assert.Check(t, is.Equal(info.ImageDigest(), tc.expected), tc.image)
+	}
+}
+
+func TestValidateAttrTemplates(t *testing.T) {
+	assert.Check(t, validateAttrTemplates(map[string]string{"attr-project": "{{.ComposeProject}}", "tag": "{{"}))
+	assert.Check(t, is.ErrorContains(validateAttrTemplates(map[string]string{"attr-project": "{{.ComposeProject"}), "invalid template for log opt 'attr-project'"))
+}
@@ -8,6 +8,12 @@ var (
 	logWritesFailedCount metrics.Counter
 	logReadsFailedCount  metrics.Counter
 	totalPartialLogs     metrics.Counter
+	logLinesDroppedCount metrics.LabeledCounter
+
+	ringMessagesDroppedCount metrics.Counter
+
+	driverWriteErrorsCount metrics.LabeledCounter
+	pluginRestartsCount    metrics.LabeledCounter
 )
 
 func init() {
@@ -16,6 +22,10 @@ func init() {
 	logWritesFailedCount = loggerMetrics.NewCounter("log_write_operations_failed", "Number of log write operations that failed")
 	logReadsFailedCount = loggerMetrics.NewCounter("log_read_operations_failed", "Number of log reads from container stdio that failed")
 	totalPartialLogs = loggerMetrics.NewCounter("log_entries_size_greater_than_buffer", "Number of log entries which are larger than the log buffer")
+	logLinesDroppedCount = loggerMetrics.NewLabeledCounter("log_lines_dropped", "Number of log lines from container stdio dropped by the rate limit or the sampling", "reason")
+	ringMessagesDroppedCount = loggerMetrics.NewCounter("ring_messages_dropped", "Number of log messages dropped by the buffer of the non-blocking mode")
+	driverWriteErrorsCount = loggerMetrics.NewLabeledCounter("driver_write_errors", "Number of log messages which the log drivers failed to write", "driver")
+	pluginRestartsCount = loggerMetrics.NewLabeledCounter("plugin_restarts", "Number of times the logging of the logging plugins was restarted after failures", "driver")
 
 	metrics.Register(loggerMetrics)
 }
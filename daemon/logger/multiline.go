@@ -0,0 +1,144 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	multilinePatternKey = "multiline-pattern"
+	multilineTimeoutKey = "multiline-timeout"
+
+	defaultMultilineTimeout = time.Second
+
+	// maxMultilineSize is the maximum size of a joined log record, the lines
+	// which would exceed it starting a new record.
+	maxMultilineSize = 1024 * 1024
+)
+
+// Multiline joins the continuation lines of the log records, such as the
+// lines of a stack trace, to the lines starting the records, before they are
+// logged.
+type Multiline struct {
+	pattern *regexp.Regexp
+	timeout time.Duration
+}
+
+// NewMultiline returns the multiline processor of the log options, or nil if
+// they do not join the lines. multiline-pattern is the regular expression
+// matching the lines starting the records, and multiline-timeout the duration
+// after which a record is logged when no line follows it.
+func NewMultiline(cfg map[string]string) (*Multiline, error) {
+	v, ok := cfg[multilinePatternKey]
+	if !ok {
+		if _, ok := cfg[multilineTimeoutKey]; ok {
+			return nil, errors.Errorf("%s cannot be set without %s", multilineTimeoutKey, multilinePatternKey)
+		}
+		return nil, nil
+	}
+	if v == "" {
+		return nil, errors.Errorf("invalid value for %s: the pattern cannot be empty", multilinePatternKey)
+	}
+	pattern, err := regexp.Compile(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid value for %s", multilinePatternKey)
+	}
+
+	m := &Multiline{pattern: pattern, timeout: defaultMultilineTimeout}
+	if v, ok := cfg[multilineTimeoutKey]; ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return nil, errors.Errorf("invalid value for %s: %s, expected a positive duration", multilineTimeoutKey, v)
+		}
+		m.timeout = timeout
+	}
+	return m, nil
+}
+
+// newJoiner returns a joiner of the lines of a source, emit being called with
+// the joined records.
+func (m *Multiline) newJoiner(emit func(*Message)) *multilineJoiner {
+	return &multilineJoiner{m: m, emit: emit}
+}
+
+// multilineJoiner joins the lines of a source into records, the records being
+// emitted when a line starts the next one, or on timeout.
+type multilineJoiner struct {
+	m    *Multiline
+	emit func(*Message)
+
+	mu     sync.Mutex
+	record *Message  // the record being joined
+	last   time.Time // when the last line of the record was added
+	timer  *time.Timer
+}
+
+// add adds a line to the record being joined, emitting the previous record
+// when the line starts a new one. The joiner takes ownership of msg.
+func (j *multilineJoiner) add(msg *Message) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.record != nil && (j.m.pattern.Match(msg.Line) || len(j.record.Line)+1+len(msg.Line) > maxMultilineSize) {
+		j.flushLocked()
+	}
+	j.last = time.Now()
+	if j.record == nil {
+		j.record = msg
+		if j.timer == nil {
+			j.timer = time.AfterFunc(j.m.timeout, j.expire)
+		} else {
+			j.timer.Reset(j.m.timeout)
+		}
+		return
+	}
+	j.record.Line = append(j.record.Line, '\n')
+	j.record.Line = append(j.record.Line, msg.Line...)
+	PutMessage(msg)
+}
+
+// expire emits the record being joined once no line was added to it for the
+// timeout.
+func (j *multilineJoiner) expire() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.record == nil {
+		return
+	}
+	if wait := j.m.timeout - time.Since(j.last); wait > 0 {
+		j.timer.Reset(wait)
+		return
+	}
+	j.flushLocked()
+}
+
+// flush emits the record being joined, if any.
+func (j *multilineJoiner) flush() {
+	j.mu.Lock()
+	j.flushLocked()
+	j.mu.Unlock()
+}
+
+func (j *multilineJoiner) flushLocked() {
+	if j.record == nil {
+		return
+	}
+	record := j.record
+	j.record = nil
+	j.emit(record)
+}
+
+// close emits the record being joined and stops the timer.
+func (j *multilineJoiner) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.timer != nil {
+		j.timer.Stop()
+	}
+	j.flushLocked()
+}
@@ -4,14 +4,52 @@ import (
 	"errors"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	defaultRingMaxSize = 1e6 // 1MB
+
+	// sustainedDropsDuration is how long the messages must be dropped for,
+	// without a pause as long, for the drops to be sustained.
+	sustainedDropsDuration = 10 * time.Second
+	// sustainedDropsInterval is the minimum interval between the
+	// notifications of the sustained drops.
+	sustainedDropsInterval = time.Minute
+	// ringShrinkDelay is how long an adaptive buffer stays below a quarter of
+	// its size before it is halved.
+	ringShrinkDelay = time.Minute
 )
 
+// RingConfig is the configuration of a RingLogger.
+type RingConfig struct {
+	// MaxSize is the size in bytes of the buffer, the default size being
+	// used when negative.
+	MaxSize int64
+	// AdaptiveMaxSize is the size in bytes the buffer doubles its size up
+	// to when it drops messages, shrinking back to MaxSize once they are
+	// logged. The size of the buffer is fixed when it is not larger than
+	// MaxSize.
+	AdaptiveMaxSize int64
+	// OnSustainedDrops, if set, is called with the number of messages
+	// dropped when the buffer keeps dropping messages.
+	OnSustainedDrops func(dropped uint64)
+}
+
+// RingStats are the stats of the buffer of a RingLogger.
+type RingStats struct {
+	// Dropped is the number of messages dropped.
+	Dropped uint64
+	// HighWatermark is the maximum size in bytes the buffer reached.
+	HighWatermark int64
+	// Size is the current size in bytes of the buffer.
+	Size int64
+	// Buffered is the size in bytes of the messages in the buffer.
+	Buffered int64
+}
+
 // RingLogger is a ring buffer that implements the Logger interface.
 // This is used when lossy logging is OK.
 type RingLogger struct {
@@ -19,6 +57,12 @@ type RingLogger struct {
 	l         Logger
 	logInfo   Info
 	closeFlag int32
+
+	onSustainedDrops func(dropped uint64)
+	dropsMu          sync.Mutex // protects the fields below
+	dropsSince       time.Time  // when the messages started being dropped
+	lastDrop         time.Time
+	lastNotified     time.Time
 }
 
 type ringWithReader struct {
@@ -35,10 +79,19 @@ func (r *ringWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
 }
 
 func newRingLogger(driver Logger, logInfo Info, maxSize int64) *RingLogger {
+	return newRingLoggerWithConfig(driver, logInfo, RingConfig{MaxSize: maxSize})
+}
+
+func newRingLoggerWithConfig(driver Logger, logInfo Info, cfg RingConfig) *RingLogger {
+	buffer := newRing(cfg.MaxSize)
+	if cfg.AdaptiveMaxSize > cfg.MaxSize {
+		buffer.adaptiveMaxBytes = cfg.AdaptiveMaxSize
+	}
 	l := &RingLogger{
-		buffer:  newRing(maxSize),
-		l:       driver,
-		logInfo: logInfo,
+		buffer:           buffer,
+		l:                driver,
+		logInfo:          logInfo,
+		onSustainedDrops: cfg.OnSustainedDrops,
 	}
 	go l.run()
 	return l
@@ -47,22 +100,68 @@ func newRingLogger(driver Logger, logInfo Info, maxSize int64) *RingLogger {
 // NewRingLogger creates a new Logger that is implemented as a RingBuffer wrapping
 // the passed in logger.
 func NewRingLogger(driver Logger, logInfo Info, maxSize int64) Logger {
-	if maxSize < 0 {
-		maxSize = defaultRingMaxSize
+	return NewRingLoggerWithConfig(driver, logInfo, RingConfig{MaxSize: maxSize})
+}
+
+// NewRingLoggerWithConfig creates a new Logger that is implemented as a
+// RingBuffer wrapping the passed in logger, configured by cfg.
+func NewRingLoggerWithConfig(driver Logger, logInfo Info, cfg RingConfig) Logger {
+	if cfg.MaxSize < 0 {
+		cfg.MaxSize = defaultRingMaxSize
 	}
-	l := newRingLogger(driver, logInfo, maxSize)
+	l := newRingLoggerWithConfig(driver, logInfo, cfg)
 	if _, ok := driver.(LogReader); ok {
 		return &ringWithReader{l}
 	}
 	return l
 }
 
+// GetRingStats returns the stats of the buffer of l, and whether l is a
+// RingLogger.
+func GetRingStats(l Logger) (RingStats, bool) {
+	switch r := l.(type) {
+	case *RingLogger:
+		return r.buffer.Stats(), true
+	case *ringWithReader:
+		return r.buffer.Stats(), true
+	}
+	return RingStats{}, false
+}
+
 // Log queues messages into the ring buffer
 func (r *RingLogger) Log(msg *Message) error {
 	if r.closed() {
 		return errClosed
 	}
-	return r.buffer.Enqueue(msg)
+	dropped, err := r.buffer.enqueue(msg)
+	if dropped {
+		ringMessagesDroppedCount.Inc(1)
+		r.recordDrop(time.Now())
+	}
+	return err
+}
+
+// recordDrop records that a message was dropped, notifying the sustained
+// drops at most every sustainedDropsInterval.
+func (r *RingLogger) recordDrop(now time.Time) {
+	if r.onSustainedDrops == nil {
+		return
+	}
+
+	r.dropsMu.Lock()
+	if now.Sub(r.lastDrop) >= sustainedDropsDuration {
+		r.dropsSince = now
+	}
+	r.lastDrop = now
+	notify := now.Sub(r.dropsSince) >= sustainedDropsDuration && now.Sub(r.lastNotified) >= sustainedDropsInterval
+	if notify {
+		r.lastNotified = now
+	}
+	r.dropsMu.Unlock()
+
+	if notify {
+		go r.onSustainedDrops(r.buffer.Stats().Dropped)
+	}
 }
 
 // Name returns the name of the underlying logger
@@ -134,6 +233,12 @@ type messageRing struct {
 	maxBytes  int64 // max buffer size size
 	queue     []*Message
 	closed    bool
+
+	baseBytes        int64     // max buffer size an adaptive buffer shrinks back to
+	adaptiveMaxBytes int64     // max buffer size an adaptive buffer grows up to, 0 when not adaptive
+	resized          time.Time // when an adaptive buffer was last resized
+	dropped          uint64    // number of messages dropped
+	highWatermark    int64     // max buffer size reached
 }
 
 func newRing(maxBytes int64) *messageRing {
@@ -144,7 +249,7 @@ func newRing(maxBytes int64) *messageRing {
 		queueSize = 1
 	}
 
-	r := &messageRing{queue: make([]*Message, 0, queueSize), maxBytes: maxBytes}
+	r := &messageRing{queue: make([]*Message, 0, queueSize), maxBytes: maxBytes, baseBytes: maxBytes}
 	r.wait = sync.NewCond(&r.mu)
 	return r
 }
@@ -153,24 +258,50 @@ func newRing(maxBytes int64) *messageRing {
 // If the message is too big for the buffer it drops the new message.
 // If there are no messages in the queue and the message is still too big, it adds the message anyway.
 func (r *messageRing) Enqueue(m *Message) error {
+	_, err := r.enqueue(m)
+	return err
+}
+
+// enqueue adds a message to the buffer queue as Enqueue does, returning
+// whether the message was dropped. An adaptive buffer grows instead of
+// dropping the message, up to its max size.
+func (r *messageRing) enqueue(m *Message) (bool, error) {
 	mSize := int64(len(m.Line))
 
 	r.mu.Lock()
 	if r.closed {
 		r.mu.Unlock()
-		return errClosed
+		return false, errClosed
+	}
+	for mSize+r.sizeBytes > r.maxBytes && r.maxBytes < r.adaptiveMaxBytes && len(r.queue) > 0 {
+		r.maxBytes *= 2
+		if r.maxBytes == 0 || r.maxBytes > r.adaptiveMaxBytes {
+			r.maxBytes = r.adaptiveMaxBytes
+		}
+		r.resized = time.Now()
 	}
 	if mSize+r.sizeBytes > r.maxBytes && len(r.queue) > 0 {
+		r.dropped++
 		r.wait.Signal()
 		r.mu.Unlock()
-		return nil
+		return true, nil
 	}
 
 	r.queue = append(r.queue, m)
 	r.sizeBytes += mSize
+	if r.sizeBytes > r.highWatermark {
+		r.highWatermark = r.sizeBytes
+	}
 	r.wait.Signal()
 	r.mu.Unlock()
-	return nil
+	return false, nil
+}
+
+// Stats returns the stats of the buffer.
+func (r *messageRing) Stats() RingStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return RingStats{Dropped: r.dropped, HighWatermark: r.highWatermark, Size: r.maxBytes, Buffered: r.sizeBytes}
 }
 
 // Dequeue pulls a message off the queue
@@ -190,6 +321,14 @@ func (r *messageRing) Dequeue() (*Message, error) {
 	msg := r.queue[0]
 	r.queue = r.queue[1:]
 	r.sizeBytes -= int64(len(msg.Line))
+	// an adaptive buffer shrinks once it stays below a quarter of its size
+	if r.maxBytes > r.baseBytes && r.sizeBytes < r.maxBytes/4 && time.Since(r.resized) >= ringShrinkDelay {
+		r.maxBytes /= 2
+		if r.maxBytes < r.baseBytes {
+			r.maxBytes = r.baseBytes
+		}
+		r.resized = time.Now()
+	}
 	r.mu.Unlock()
 	return msg, nil
 }
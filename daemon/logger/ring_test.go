@@ -48,6 +48,74 @@ func TestRingLogger(t *testing.T) {
 	}
 }
 
+func TestRingAdaptive(t *testing.T) {
+	r := newRing(4)
+	r.adaptiveMaxBytes = 10
+	for i := 0; i < 12; i++ {
+		dropped, err := r.enqueue(&Message{Line: []byte(strconv.Itoa(i % 10))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the buffer grows to 8, then 10 bytes, dropping the messages above
+		if dropped != (i >= 10) {
+			t.Fatalf("unexpected drop of message %d: %v", i, dropped)
+		}
+	}
+	stats := r.Stats()
+	if stats.Dropped != 2 || stats.HighWatermark != 10 || stats.Size != 10 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// the buffer shrinks back once it stays below a quarter of its size
+	r.resized = time.Now().Add(-ringShrinkDelay)
+	for i := 0; i < 9; i++ {
+		if _, err := r.Dequeue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size := r.Stats().Size; size != 5 {
+		t.Fatalf("expected the buffer to be halved, got a size of %d", size)
+	}
+	r.resized = time.Now().Add(-ringShrinkDelay)
+	if _, err := r.Dequeue(); err != nil {
+		t.Fatal(err)
+	}
+	if size := r.Stats().Size; size != 4 {
+		t.Fatalf("expected the buffer to shrink back to its base size, got %d", size)
+	}
+}
+
+func TestRingLoggerSustainedDrops(t *testing.T) {
+	notified := make(chan uint64, 10)
+	ring := newRingLoggerWithConfig(nopLogger{}, Info{}, RingConfig{MaxSize: 1, OnSustainedDrops: func(dropped uint64) {
+		notified <- dropped
+	}})
+	defer ring.setClosed()
+
+	// messages dropped every 5 seconds for 75 seconds are sustained after 10
+	// seconds, and notified again a minute later
+	start := time.Now()
+	for d := time.Duration(0); d <= 75*time.Second; d += 5 * time.Second {
+		ring.recordDrop(start.Add(d))
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-notified:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout waiting for notification %d of the sustained drops", i)
+		}
+	}
+
+	// a pause longer than the sustained drops duration resets them
+	ring.recordDrop(start.Add(3 * time.Minute))
+	ring.recordDrop(start.Add(3*time.Minute + 5*time.Second))
+	select {
+	case <-notified:
+		t.Fatal("unexpected notification")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestRingCap(t *testing.T) {
 	r := newRing(5)
 	for i := 0; i < 10; i++ {
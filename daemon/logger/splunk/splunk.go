@@ -22,6 +22,7 @@ import (
 
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/loggerutils"
+	"github.com/docker/docker/daemon/logger/templates"
 	"github.com/docker/docker/pkg/pools"
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/sirupsen/logrus"
@@ -41,6 +42,8 @@ const (
 	splunkVerifyConnectionKey     = "splunk-verify-connection"
 	splunkGzipCompressionKey      = "splunk-gzip"
 	splunkGzipCompressionLevelKey = "splunk-gzip-level"
+	splunkBatchSizeKey            = "splunk-batch-size"
+	splunkBatchIntervalKey        = "splunk-batch-interval"
 	envKey                        = "env"
 	envRegexKey                   = "env-regex"
 	labelsKey                     = "labels"
@@ -85,6 +88,8 @@ type splunkLogger struct {
 	// http compression
 	gzipCompression      bool
 	gzipCompressionLevel int
+	// gzipWriter is reused by the worker for the requests, when compressing.
+	gzipWriter *gzip.Writer
 
 	// Advanced options
 	postMessagesFrequency time.Duration
@@ -224,9 +229,18 @@ func New(info logger.Info) (logger.Logger, error) {
 		Transport: transport,
 	}
 
-	source := info.Config[splunkSourceKey]
-	sourceType := info.Config[splunkSourceTypeKey]
-	index := info.Config[splunkIndexKey]
+	source, err := parseTemplate(info, splunkSourceKey)
+	if err != nil {
+		return nil, err
+	}
+	sourceType, err := parseTemplate(info, splunkSourceTypeKey)
+	if err != nil {
+		return nil, err
+	}
+	index, err := parseTemplate(info, splunkIndexKey)
+	if err != nil {
+		return nil, err
+	}
 
 	var nullMessage = &splunkMessage{
 		Host:       hostname,
@@ -255,6 +269,23 @@ func New(info logger.Info) (logger.Logger, error) {
 		bufferMaximum         = getAdvancedOptionInt(envVarBufferMaximum, defaultBufferMaximum)
 		streamChannelSize     = getAdvancedOptionInt(envVarStreamChannelSize, defaultStreamChannelSize)
 	)
+	// The options of the container take precedence over the environment of
+	// the daemon.
+	if v, ok := info.Config[splunkBatchSizeKey]; ok {
+		if postMessagesBatchSize, err = parseBatchSize(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := info.Config[splunkBatchIntervalKey]; ok {
+		if postMessagesFrequency, err = parseBatchInterval(v); err != nil {
+			return nil, err
+		}
+	}
+	// The buffer holds at least one batch, for a batch not to be dropped
+	// when it cannot be sent once.
+	if bufferMaximum < postMessagesBatchSize {
+		bufferMaximum = postMessagesBatchSize
+	}
 
 	logger := &splunkLogger{
 		client:                client,
@@ -465,16 +496,20 @@ func (l *splunkLogger) tryPostMessages(ctx context.Context, messages []*splunkMe
 	}
 	var buffer bytes.Buffer
 	var writer io.Writer
-	var gzipWriter *gzip.Writer
 	var err error
-	// If gzip compression is enabled - create gzip writer with specified compression
-	// level. If gzip compression is disabled, use standard buffer as a writer
+	// If gzip compression is enabled - use the gzip writer with specified compression
+	// level, created for the first request. If gzip compression is disabled, use
+	// standard buffer as a writer
 	if l.gzipCompression {
-		gzipWriter, err = gzip.NewWriterLevel(&buffer, l.gzipCompressionLevel)
-		if err != nil {
-			return err
+		if l.gzipWriter == nil {
+			l.gzipWriter, err = gzip.NewWriterLevel(&buffer, l.gzipCompressionLevel)
+			if err != nil {
+				return err
+			}
+		} else {
+			l.gzipWriter.Reset(&buffer)
 		}
-		writer = gzipWriter
+		writer = l.gzipWriter
 	} else {
 		writer = &buffer
 	}
@@ -489,7 +524,7 @@ func (l *splunkLogger) tryPostMessages(ctx context.Context, messages []*splunkMe
 	}
 	// If gzip compression is enabled, tell it, that we are done
 	if l.gzipCompression {
-		err = gzipWriter.Close()
+		err = l.gzipWriter.Close()
 		if err != nil {
 			return err
 		}
@@ -552,9 +587,10 @@ func ValidateLogOpt(cfg map[string]string) error {
 		switch key {
 		case splunkURLKey:
 		case splunkTokenKey:
-		case splunkSourceKey:
-		case splunkSourceTypeKey:
-		case splunkIndexKey:
+		case splunkSourceKey, splunkSourceTypeKey, splunkIndexKey:
+			if _, err := templates.NewParse(key, cfg[key]); err != nil {
+				return fmt.Errorf("%s: invalid template for %s: %v", driverName, key, err)
+			}
 		case splunkCAPathKey:
 		case splunkCANameKey:
 		case splunkInsecureSkipVerifyKey:
@@ -562,17 +598,61 @@ func ValidateLogOpt(cfg map[string]string) error {
 		case splunkVerifyConnectionKey:
 		case splunkGzipCompressionKey:
 		case splunkGzipCompressionLevelKey:
+		case splunkBatchSizeKey:
+			if _, err := parseBatchSize(cfg[key]); err != nil {
+				return err
+			}
+		case splunkBatchIntervalKey:
+			if _, err := parseBatchInterval(cfg[key]); err != nil {
+				return err
+			}
 		case envKey:
 		case envRegexKey:
 		case labelsKey:
 		case tagKey:
 		default:
-			return fmt.Errorf("unknown log opt '%s' for %s log driver", key, driverName)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt '%s' for %s log driver", key, driverName)
+			}
 		}
 	}
 	return nil
 }
 
+// parseTemplate returns the value of the option key, rendered as a template
+// of the container, such as "{{.ImageName}}" or "{{.Label "app"}}".
+func parseTemplate(info logger.Info, key string) (string, error) {
+	value := info.Config[key]
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tmpl, err := templates.NewParse(key, value)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid template for %s: %v", driverName, key, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, &info); err != nil {
+		return "", fmt.Errorf("%s: failed to render %s: %v", driverName, key, err)
+	}
+	return buf.String(), nil
+}
+
+func parseBatchSize(value string) (int, error) {
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		return 0, fmt.Errorf("%s: invalid value %s for %s, expected a positive number of messages", driverName, value, splunkBatchSizeKey)
+	}
+	return size, nil
+}
+
+func parseBatchInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("%s: invalid value %s for %s, expected a positive duration", driverName, value, splunkBatchIntervalKey)
+	}
+	return interval, nil
+}
+
 func parseURL(info logger.Info) (*url.URL, error) {
 	splunkURLStr, ok := info.Config[splunkURLKey]
 	if !ok {
@@ -30,6 +30,8 @@ func TestValidateLogOpt(t *testing.T) {
 		splunkVerifyConnectionKey:     "true",
 		splunkGzipCompressionKey:      "true",
 		splunkGzipCompressionLevelKey: "1",
+		splunkBatchSizeKey:            "100",
+		splunkBatchIntervalKey:        "1s",
 		envKey:                        "a",
 		envRegexKey:                   "^foo",
 		labelsKey:                     "b",
@@ -45,6 +47,18 @@ func TestValidateLogOpt(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expecting error on unsupported options")
 	}
+
+	for _, cfg := range []map[string]string{
+		{splunkBatchSizeKey: "0"},
+		{splunkBatchSizeKey: "many"},
+		{splunkBatchIntervalKey: "-1s"},
+		{splunkBatchIntervalKey: "often"},
+		{splunkIndexKey: "{{.Name"},
+	} {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expecting error validating %v", cfg)
+		}
+	}
 }
 
 // Driver require user to specify required options
@@ -1030,6 +1044,96 @@ func TestOneMessagePerRequest(t *testing.T) {
 	}
 }
 
+// Verify that the batching options of the container take precedence over the
+// environment of the daemon
+func TestBatchingOptions(t *testing.T) {
+	defer env.Patch(t, envVarPostMessagesBatchSize, "1000")()
+
+	hec := NewHTTPEventCollectorMock(t)
+
+	go hec.Serve()
+
+	info := logger.Info{
+		Config: map[string]string{
+			splunkURLKey:             hec.URL(),
+			splunkTokenKey:           hec.token,
+			splunkBatchSizeKey:       "3",
+			splunkBatchIntervalKey:   "10h",
+			splunkGzipCompressionKey: "true",
+		},
+		ContainerID:        "containeriid",
+		ContainerName:      "/container_name",
+		ContainerImageID:   "contaimageid",
+		ContainerImageName: "container_image_name",
+	}
+
+	loggerDriver, err := New(info)
+	assert.NilError(t, err)
+
+	splunkLoggerDriver, ok := loggerDriver.(*splunkLoggerInline)
+	assert.Assert(t, ok, "Unexpected Splunk Logging Driver type")
+	assert.Equal(t, splunkLoggerDriver.postMessagesBatchSize, 3)
+	assert.Equal(t, splunkLoggerDriver.postMessagesFrequency, 10*time.Hour)
+
+	for i := 0; i < 10; i++ {
+		err := loggerDriver.Log(&logger.Message{Line: []byte(fmt.Sprintf("%d", i)), Source: "stdout", Timestamp: time.Now()})
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, loggerDriver.Close())
+
+	assert.Equal(t, len(hec.messages), 10)
+	for i, message := range hec.messages {
+		event, err := message.EventAsMap()
+		assert.NilError(t, err)
+		assert.Equal(t, event["line"], fmt.Sprintf("%d", i))
+	}
+	assert.Assert(t, hec.gzipEnabled != nil && *hec.gzipEnabled)
+	// 1 to verify connection, 3 batches and the rest on close
+	assert.Equal(t, hec.numOfRequests, 5)
+
+	assert.NilError(t, hec.Close())
+}
+
+// Verify that the index, source and sourcetype are rendered as templates of
+// the container
+func TestTemplatedOptions(t *testing.T) {
+	hec := NewHTTPEventCollectorMock(t)
+
+	go hec.Serve()
+
+	info := logger.Info{
+		Config: map[string]string{
+			splunkURLKey:        hec.URL(),
+			splunkTokenKey:      hec.token,
+			splunkIndexKey:      `{{.Label "team"}}`,
+			splunkSourceKey:     "docker:{{.Name}}",
+			splunkSourceTypeKey: "{{.ImageName}}",
+		},
+		ContainerID:        "containeriid",
+		ContainerName:      "/container_name",
+		ContainerImageID:   "contaimageid",
+		ContainerImageName: "container_image_name",
+		ContainerLabels: map[string]string{
+			"team": "payments",
+		},
+	}
+
+	loggerDriver, err := New(info)
+	assert.NilError(t, err)
+
+	err = loggerDriver.Log(&logger.Message{Line: []byte("message"), Source: "stdout", Timestamp: time.Now()})
+	assert.NilError(t, err)
+	assert.NilError(t, loggerDriver.Close())
+
+	assert.Equal(t, len(hec.messages), 1)
+	message := hec.messages[0]
+	assert.Equal(t, message.Index, "payments")
+	assert.Equal(t, message.Source, "docker:container_name")
+	assert.Equal(t, message.SourceType, "container_image_name")
+
+	assert.NilError(t, hec.Close())
+}
+
 // Driver should not be created when HEC is unresponsive
 func TestVerify(t *testing.T) {
 	hec := NewHTTPEventCollectorMock(t)
@@ -8,6 +8,8 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -24,8 +26,20 @@ import (
 const (
 	name        = "syslog"
 	secureProto = "tcp+tls"
+
+	rfc5424microLayout = "2006-01-02T15:04:05.999999Z07:00"
+
+	// structuredDataID is the SD-ID of the structured data element of the
+	// extra attributes, 32473 being the private enterprise number reserved
+	// for documentation by RFC 5612.
+	structuredDataID = "docker@32473"
+	// containerStructuredDataID is the SD-ID of the structured data element
+	// of the metadata of the container.
+	containerStructuredDataID = "container@32473"
 )
 
+var structuredDataEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
 var facilities = map[string]syslog.Priority{
 	"kern":     syslog.LOG_KERN,
 	"user":     syslog.LOG_USER,
@@ -49,8 +63,34 @@ var facilities = map[string]syslog.Priority{
 	"local7":   syslog.LOG_LOCAL7,
 }
 
+var severities = map[string]syslog.Priority{
+	"emerg":   syslog.LOG_EMERG,
+	"alert":   syslog.LOG_ALERT,
+	"crit":    syslog.LOG_CRIT,
+	"err":     syslog.LOG_ERR,
+	"error":   syslog.LOG_ERR,
+	"warning": syslog.LOG_WARNING,
+	"warn":    syslog.LOG_WARNING,
+	"notice":  syslog.LOG_NOTICE,
+	"info":    syslog.LOG_INFO,
+	"debug":   syslog.LOG_DEBUG,
+}
+
+// levelRule sends the lines matching pattern with severity, and with
+// facility when it is set.
+type levelRule struct {
+	pattern     *regexp.Regexp
+	severity    syslog.Priority
+	facility    syslog.Priority
+	hasFacility bool
+}
+
 type syslogger struct {
 	writer *syslog.Writer
+	// writers are the writers of the facilities of the level rules other
+	// than the facility of writer, by facility.
+	writers map[syslog.Priority]*syslog.Writer
+	rules   []levelRule
 }
 
 func init() {
@@ -66,27 +106,85 @@ func init() {
 // attribute in rsyslog.conf. In order to be backward compatible to rfc3164
 // tag will be also used as an appname
 func rfc5424formatterWithAppNameAsTag(p syslog.Priority, hostname, tag, content string) string {
-	timestamp := time.Now().Format(time.RFC3339)
-	pid := os.Getpid()
-	msg := fmt.Sprintf("<%d>%d %s %s %s %d %s - %s",
-		p, 1, timestamp, hostname, tag, pid, tag, content)
-	return msg
+	return formatRFC5424(p, time.RFC3339, hostname, tag, "-", content)
 }
 
 // The timestamp field in rfc5424 is derived from rfc3339. Whereas rfc3339 makes allowances
 // for multiple syntaxes, there are further restrictions in rfc5424, i.e., the maximum
 // resolution is limited to "TIME-SECFRAC" which is 6 (microsecond resolution)
 func rfc5424microformatterWithAppNameAsTag(p syslog.Priority, hostname, tag, content string) string {
-	timestamp := time.Now().Format("2006-01-02T15:04:05.999999Z07:00")
+	return formatRFC5424(p, rfc5424microLayout, hostname, tag, "-", content)
+}
+
+func formatRFC5424(p syslog.Priority, layout, hostname, tag, structuredData, content string) string {
+	timestamp := time.Now().Format(layout)
 	pid := os.Getpid()
-	msg := fmt.Sprintf("<%d>%d %s %s %s %d %s - %s",
-		p, 1, timestamp, hostname, tag, pid, tag, content)
+	msg := fmt.Sprintf("<%d>%d %s %s %s %d %s %s %s",
+		p, 1, timestamp, hostname, tag, pid, tag, structuredData, content)
 	return msg
 }
 
+// rfc5424formatterWithStructuredData returns a formatter of the rfc5424 or
+// rfc5424micro syslog format adding the structured data to the messages.
+func rfc5424formatterWithStructuredData(logFormat, structuredData string) syslog.Formatter {
+	layout := time.RFC3339
+	if logFormat == "rfc5424micro" {
+		layout = rfc5424microLayout
+	}
+	return func(p syslog.Priority, hostname, tag, content string) string {
+		return formatRFC5424(p, layout, hostname, tag, structuredData, content)
+	}
+}
+
+// formatStructuredData formats the parameters of the RFC 5424 structured data
+// element id, or returns an empty string when there are no parameters. The
+// names of the parameters are restricted to 32 printable ASCII characters,
+// other than '=', ' ', ']' and '"', and the values escape '"', '\\' and ']'.
+func formatStructuredData(id string, params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sd strings.Builder
+	sd.WriteString("[" + id)
+	for _, k := range keys {
+		name := strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+				return '_'
+			}
+			return r
+		}, k)
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		fmt.Fprintf(&sd, " %s=\"%s\"", name, structuredDataEscaper.Replace(params[k]))
+	}
+	sd.WriteString("]")
+	return sd.String()
+}
+
+// containerStructuredData returns the structured data element of the
+// metadata of the container.
+func containerStructuredData(info logger.Info) string {
+	return formatStructuredData(containerStructuredDataID, map[string]string{
+		"id":       info.FullID(),
+		"name":     info.Name(),
+		"image":    info.ImageName(),
+		"image_id": info.ImageFullID(),
+	})
+}
+
 // New creates a syslog logger using the configuration passed in on
 // the context. Supported context configuration variables are
-// syslog-address, syslog-facility, syslog-format.
+// syslog-address, syslog-facility, syslog-format, syslog-structured-data and
+// syslog-level-rules. The extra attributes, and the metadata of the container
+// with syslog-structured-data, are sent as structured data with the rfc5424
+// and rfc5424micro formats.
 func New(info logger.Info) (logger.Logger, error) {
 	tag, err := loggerutils.ParseLogTag(info, loggerutils.DefaultTemplate)
 	if err != nil {
@@ -108,33 +206,88 @@ func New(info logger.Info) (logger.Logger, error) {
 		return nil, err
 	}
 
-	var log *syslog.Writer
-	if proto == secureProto {
-		tlsConfig, tlsErr := parseTLSConfig(info.Config)
-		if tlsErr != nil {
-			return nil, tlsErr
-		}
-		log, err = syslog.DialWithTLSConfig(proto, address, facility, tag, tlsConfig)
-	} else {
-		log, err = syslog.Dial(proto, address, facility, tag)
+	rules, err := parseLevelRules(info.Config["syslog-level-rules"])
+	if err != nil {
+		return nil, err
 	}
 
+	extraAttrs, err := info.ExtraAttributes(nil)
 	if err != nil {
 		return nil, err
 	}
+	sd := formatStructuredData(structuredDataID, extraAttrs)
+	if withMetadata, _ := strconv.ParseBool(info.Config["syslog-structured-data"]); withMetadata {
+		sd = containerStructuredData(info) + sd
+	}
+	// the structured data is only sent with the syslog formats supporting it
+	if sd != "" {
+		switch logFormat := info.Config["syslog-format"]; logFormat {
+		case "rfc5424", "rfc5424micro":
+			syslogFormatter = rfc5424formatterWithStructuredData(logFormat, sd)
+		}
+	}
 
-	log.SetFormatter(syslogFormatter)
-	log.SetFramer(syslogFramer)
+	var tlsConfig *tls.Config
+	if proto == secureProto {
+		if tlsConfig, err = parseTLSConfig(info.Config); err != nil {
+			return nil, err
+		}
+	}
+	dial := func(facility syslog.Priority) (*syslog.Writer, error) {
+		var w *syslog.Writer
+		var err error
+		if proto == secureProto {
+			w, err = syslog.DialWithTLSConfig(proto, address, facility, tag, tlsConfig)
+		} else {
+			w, err = syslog.Dial(proto, address, facility, tag)
+		}
+		if err != nil {
+			return nil, err
+		}
+		w.SetFormatter(syslogFormatter)
+		w.SetFramer(syslogFramer)
+		return w, nil
+	}
 
-	return &syslogger{
-		writer: log,
-	}, nil
+	log, err := dial(facility)
+	if err != nil {
+		return nil, err
+	}
+	s := &syslogger{
+		writer:  log,
+		writers: make(map[syslog.Priority]*syslog.Writer),
+		rules:   rules,
+	}
+	// the facility of the messages is the one of their writer
+	for _, r := range rules {
+		if !r.hasFacility || r.facility == facility || s.writers[r.facility] != nil {
+			continue
+		}
+		w, err := dial(r.facility)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.writers[r.facility] = w
+	}
+	return s, nil
 }
 
 func (s *syslogger) Log(msg *logger.Message) error {
 	line := string(msg.Line)
 	source := msg.Source
 	logger.PutMessage(msg)
+	for _, r := range s.rules {
+		if !r.pattern.MatchString(line) {
+			continue
+		}
+		w := s.writer
+		if fw, ok := s.writers[r.facility]; ok && r.hasFacility {
+			w = fw
+		}
+		_, err := w.WriteWithPriority(r.severity, []byte(line))
+		return err
+	}
 	if source == "stderr" {
 		return s.writer.Err(line)
 	}
@@ -142,6 +295,9 @@ func (s *syslogger) Log(msg *logger.Message) error {
 }
 
 func (s *syslogger) Close() error {
+	for _, w := range s.writers {
+		w.Close()
+	}
 	return s.writer.Close()
 }
 
@@ -182,7 +338,7 @@ func parseAddress(address string) (string, string, error) {
 }
 
 // ValidateLogOpt looks for syslog specific log options
-// syslog-address, syslog-facility.
+// syslog-address, syslog-facility, syslog-structured-data, syslog-level-rules.
 func ValidateLogOpt(cfg map[string]string) error {
 	for key := range cfg {
 		switch key {
@@ -197,8 +353,12 @@ func ValidateLogOpt(cfg map[string]string) error {
 		case "syslog-tls-skip-verify":
 		case "tag":
 		case "syslog-format":
+		case "syslog-structured-data":
+		case "syslog-level-rules":
 		default:
-			return fmt.Errorf("unknown log opt '%s' for syslog log driver", key)
+			if !logger.IsAttrTemplateOpt(key) {
+				return fmt.Errorf("unknown log opt '%s' for syslog log driver", key)
+			}
 		}
 	}
 	if _, _, err := parseAddress(cfg["syslog-address"]); err != nil {
@@ -210,6 +370,18 @@ func ValidateLogOpt(cfg map[string]string) error {
 	if _, _, err := parseLogFormat(cfg["syslog-format"], ""); err != nil {
 		return err
 	}
+	if v, ok := cfg["syslog-structured-data"]; ok {
+		withMetadata, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for syslog-structured-data: %s", v)
+		}
+		if format := cfg["syslog-format"]; withMetadata && format != "rfc5424" && format != "rfc5424micro" {
+			return errors.New("syslog-structured-data requires the rfc5424 or rfc5424micro syslog format")
+		}
+	}
+	if _, err := parseLevelRules(cfg["syslog-level-rules"]); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -230,6 +402,51 @@ func parseFacility(facility string) (syslog.Priority, error) {
 	return syslog.Priority(0), errors.New("invalid syslog facility")
 }
 
+// parseLevelRules parses the rules of the severities of the lines, separated by
+// semicolons. The rules are of the form "<severity>:<pattern>", or
+// "<facility>.<severity>:<pattern>" to also set the facility, the first rule
+// whose regular expression matches a line setting its priority.
+func parseLevelRules(value string) ([]levelRule, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var rules []levelRule
+	for _, rule := range strings.Split(value, ";") {
+		selector, expr := rule, ""
+		if i := strings.Index(rule, ":"); i >= 0 {
+			selector, expr = rule[:i], rule[i+1:]
+		}
+		if expr == "" {
+			return nil, fmt.Errorf("invalid syslog level rule %q: expected <severity>:<pattern>", rule)
+		}
+
+		var r levelRule
+		severity := selector
+		if i := strings.LastIndex(selector, "."); i >= 0 {
+			if i == 0 {
+				return nil, fmt.Errorf("invalid syslog level rule %q: missing facility", rule)
+			}
+			facility, err := parseFacility(selector[:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid syslog level rule %q: %v", rule, err)
+			}
+			r.facility, r.hasFacility = facility, true
+			severity = selector[i+1:]
+		}
+		var ok bool
+		if r.severity, ok = severities[severity]; !ok {
+			return nil, fmt.Errorf("invalid syslog level rule %q: unknown severity %q", rule, severity)
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog level rule %q: %v", rule, err)
+		}
+		r.pattern = pattern
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
 func parseTLSConfig(cfg map[string]string) (*tls.Config, error) {
 	_, skipVerify := cfg["syslog-tls-skip-verify"]
 
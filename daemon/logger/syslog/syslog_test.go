@@ -1,10 +1,15 @@
 package syslog // import "github.com/docker/docker/daemon/logger/syslog"
 
 import (
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	syslog "github.com/RackSec/srslog"
+
+	"github.com/docker/docker/daemon/logger"
 )
 
 func functionMatches(expectedFun interface{}, actualFun interface{}) bool {
@@ -60,3 +65,126 @@ func TestValidateLogOptEmpty(t *testing.T) {
 		t.Fatal("Failed to parse empty config", err)
 	}
 }
+
+func TestFormatStructuredData(t *testing.T) {
+	if sd := formatStructuredData(structuredDataID, nil); sd != "" {
+		t.Fatalf("expected no structured data without attributes, got %q", sd)
+	}
+	sd := formatStructuredData(structuredDataID, map[string]string{
+		"project":     "shop",
+		"a key=value": `a "quoted" value\with]`,
+	})
+	expected := `[docker@32473 a_key_value="a \"quoted\" value\\with\]" project="shop"]`
+	if sd != expected {
+		t.Fatalf("expected structured data %q, got %q", expected, sd)
+	}
+
+	msg := rfc5424formatterWithStructuredData("rfc5424micro", sd)(syslog.LOG_INFO, "host", "tag", "content")
+	if !strings.HasSuffix(msg, " tag "+expected+" content") {
+		t.Fatalf("expected the structured data in the message, got %q", msg)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	for _, tc := range []struct {
+		cfg   map[string]string
+		valid bool
+	}{
+		{cfg: map[string]string{"syslog-format": "rfc5424", "syslog-structured-data": "true"}, valid: true},
+		{cfg: map[string]string{"syslog-structured-data": "false"}, valid: true},
+		{cfg: map[string]string{"syslog-structured-data": "true"}},
+		{cfg: map[string]string{"syslog-format": "rfc5424", "syslog-structured-data": "maybe"}},
+		{cfg: map[string]string{"syslog-level-rules": "warning:^WARN;local3.crit:^FATAL"}, valid: true},
+		{cfg: map[string]string{"syslog-level-rules": "loud:^WARN"}},
+		{cfg: map[string]string{"syslog-level-rules": "err:("}},
+	} {
+		err := ValidateLogOpt(tc.cfg)
+		if tc.valid && err != nil {
+			t.Fatalf("unexpected error validating %v: %v", tc.cfg, err)
+		}
+		if !tc.valid && err == nil {
+			t.Fatalf("expected an error validating %v", tc.cfg)
+		}
+	}
+}
+
+func TestParseLevelRules(t *testing.T) {
+	rules, err := parseLevelRules("warn:^WARN;local3.crit:^(FATAL|PANIC):;19.debug:^DEBUG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+	if r := rules[0]; r.severity != syslog.LOG_WARNING || r.hasFacility || r.pattern.String() != "^WARN" {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	if r := rules[1]; r.severity != syslog.LOG_CRIT || !r.hasFacility || r.facility != syslog.LOG_LOCAL3 || r.pattern.String() != "^(FATAL|PANIC):" {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	if r := rules[2]; r.severity != syslog.LOG_DEBUG || r.facility != syslog.LOG_LOCAL3 {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+
+	for _, value := range []string{"err", "err:", ".err:x", "nofacility.err:x", "err;warn:x"} {
+		if _, err := parseLevelRules(value); err == nil {
+			t.Fatalf("expected an error parsing %q", value)
+		}
+	}
+}
+
+func TestLevelRulesAndStructuredData(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	l, err := New(logger.Info{
+		Config: map[string]string{
+			"syslog-address":         "udp://" + conn.LocalAddr().String(),
+			"syslog-format":          "rfc5424",
+			"syslog-structured-data": "true",
+			"syslog-level-rules":     "warning:^WARN;local3.crit:^FATAL",
+		},
+		ContainerID:        "0123456789abcdef0123456789abcdef",
+		ContainerName:      "/web",
+		ContainerImageName: "nginx",
+		ContainerImageID:   "sha256:abcdef",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for _, msg := range []*logger.Message{
+		{Line: []byte("WARN disk almost full"), Source: "stdout"},
+		{Line: []byte("FATAL out of disk"), Source: "stdout"},
+		{Line: []byte("started"), Source: "stdout"},
+		{Line: []byte("failed"), Source: "stderr"},
+	} {
+		if err := l.Log(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sd := `[container@32473 id="0123456789abcdef0123456789abcdef" image="nginx" image_id="sha256:abcdef" name="web"]`
+	buf := make([]byte, 1024)
+	// daemon is 3 and local3 is 19, the priority being facility*8+severity
+	for _, expected := range []struct{ priority, content string }{
+		{"<28>", "WARN disk almost full"},
+		{"<154>", "FATAL out of disk"},
+		{"<30>", "started"},
+		{"<27>", "failed"},
+	} {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := string(buf[:n])
+		if !strings.HasPrefix(msg, expected.priority) || !strings.HasSuffix(msg, " "+sd+" "+expected.content+"\n") {
+			t.Fatalf("expected a message with the priority %s, the structured data and %q, got %q", expected.priority, expected.content, msg)
+		}
+	}
+}
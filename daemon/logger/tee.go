@@ -0,0 +1,187 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/backend"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	teeKey    = "tee"
+	teePrefix = "tee-"
+)
+
+// TeeDrivers returns the log drivers the logs are also sent to, set by the
+// tee option as a comma-separated list.
+func TeeDrivers(cfg map[string]string) []string {
+	v := cfg[teeKey]
+	if v == "" {
+		return nil
+	}
+	var drivers []string
+	for _, name := range strings.Split(v, ",") {
+		drivers = append(drivers, strings.TrimSpace(name))
+	}
+	return drivers
+}
+
+// TeeConfig returns the options of the log driver name the logs are also sent
+// to, which are the options prefixed by "tee-<name>-", without the prefix.
+// For example, "tee-fluentd-fluentd-address" is the "fluentd-address" of the
+// fluentd driver.
+func TeeConfig(cfg map[string]string, name string) map[string]string {
+	prefix := teePrefix + name + "-"
+	teeCfg := make(map[string]string)
+	for k, v := range cfg {
+		if strings.HasPrefix(k, prefix) {
+			teeCfg[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return teeCfg
+}
+
+// isTeeOpt returns whether the option key is an option of the tee drivers of
+// cfg.
+func isTeeOpt(cfg map[string]string, key string) bool {
+	if key == teeKey {
+		return true
+	}
+	for _, name := range TeeDrivers(cfg) {
+		if strings.HasPrefix(key, teePrefix+name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTeeOpts validates the tee drivers of the primary driver name, and
+// their options.
+func validateTeeOpts(name string, cfg map[string]string) error {
+	drivers := TeeDrivers(cfg)
+	seen := map[string]bool{name: true}
+	for _, driver := range drivers {
+		switch {
+		case driver == "" || driver == "none":
+			return errors.Errorf("logger: invalid log driver '%s' for option %s", driver, teeKey)
+		case seen[driver]:
+			return errors.Errorf("logger: log driver '%s' cannot be used more than once", driver)
+		case !factory.driverRegistered(driver):
+			return errors.Errorf("logger: no log driver named '%s' is registered", driver)
+		}
+		seen[driver] = true
+
+		teeCfg := TeeConfig(cfg, driver)
+		if err := validateAttrTemplates(teeCfg); err != nil {
+			return err
+		}
+		if validator := factory.getLogOptValidator(driver); validator != nil {
+			if err := validator(teeCfg); err != nil {
+				return errors.Wrapf(err, "error validating the options of the log driver '%s' of option %s", driver, teeKey)
+			}
+		}
+	}
+
+	// the options of the drivers which are not tee'd would be ignored
+	var unused []string
+	for k := range cfg {
+		if strings.HasPrefix(k, teePrefix) && !isTeeOpt(cfg, k) {
+			unused = append(unused, k)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return errors.Errorf("logger: options %s are not options of a log driver of option %s", strings.Join(unused, ", "), teeKey)
+	}
+	return nil
+}
+
+// teeLogger sends the messages to several loggers.
+type teeLogger struct {
+	loggers []Logger
+}
+
+// teeWithReader is a teeLogger reading the logs from the first of its
+// loggers which can read them.
+type teeWithReader struct {
+	*teeLogger
+	reader LogReader
+}
+
+func (l *teeWithReader) ReadLogs(config ReadConfig) *LogWatcher {
+	return l.reader.ReadLogs(config)
+}
+
+// NewTeeLogger returns a logger sending the messages to all the loggers, the
+// first one being the primary logger, whose name is the name of the logger.
+// The messages are sent to all the loggers even if some of them fail, the
+// error of the first one failing being returned. The logs can be read when
+// one of the loggers can read them.
+func NewTeeLogger(loggers ...Logger) Logger {
+	l := &teeLogger{loggers: loggers}
+	for _, lg := range loggers {
+		if r, ok := lg.(LogReader); ok {
+			return &teeWithReader{teeLogger: l, reader: r}
+		}
+	}
+	return l
+}
+
+func (l *teeLogger) Log(msg *Message) error {
+	var err error
+	last := len(l.loggers) - 1
+	for i, lg := range l.loggers {
+		m := msg
+		// the loggers reset the message once it is logged, so all but the
+		// last one log a copy of it
+		if i < last {
+			m = NewMessage()
+			copyMessage(m, msg)
+		}
+		if e := lg.Log(m); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (l *teeLogger) Name() string {
+	return l.loggers[0].Name()
+}
+
+// BufSize returns the smallest of the sizes of buffer of the loggers.
+func (l *teeLogger) BufSize() int {
+	size := defaultBufSize
+	for _, lg := range l.loggers {
+		if s, ok := lg.(SizedLogger); ok && s.BufSize() < size {
+			size = s.BufSize()
+		}
+	}
+	return size
+}
+
+func (l *teeLogger) Close() error {
+	err := l.loggers[0].Close()
+	for _, lg := range l.loggers[1:] {
+		if err := lg.Close(); err != nil {
+			logrus.WithError(err).WithField("driver", lg.Name()).Warn("error closing tee'd logger")
+		}
+	}
+	return err
+}
+
+// copyMessage copies the message, with a copy of its line and attributes, to
+// dst.
+func copyMessage(dst, src *Message) {
+	dst.Source = src.Source
+	dst.Timestamp = src.Timestamp
+	dst.PLogMetaData = src.PLogMetaData
+	dst.Err = src.Err
+	if src.Attrs != nil {
+		dst.Attrs = make([]backend.LogAttr, len(src.Attrs))
+		copy(dst.Attrs, src.Attrs)
+	}
+	dst.Line = append(dst.Line[:0], src.Line...)
+}
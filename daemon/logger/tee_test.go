@@ -0,0 +1,132 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// resettingLogger records the lines logged, resetting the messages as the
+// log drivers do.
+type resettingLogger struct {
+	name   string
+	lines  []string
+	err    error
+	closed bool
+}
+
+func (l *resettingLogger) Log(msg *Message) error {
+	l.lines = append(l.lines, string(msg.Line))
+	PutMessage(msg)
+	return l.err
+}
+
+func (l *resettingLogger) Name() string { return l.name }
+
+func (l *resettingLogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+type readingLogger struct {
+	resettingLogger
+}
+
+func (l *readingLogger) ReadLogs(ReadConfig) *LogWatcher {
+	w := NewLogWatcher()
+	for _, line := range l.lines {
+		w.Msg <- &Message{Line: []byte(line)}
+	}
+	return w
+}
+
+func TestTeeLogger(t *testing.T) {
+	primary := &resettingLogger{name: "primary"}
+	local := &readingLogger{resettingLogger{name: "local"}}
+	remote := &resettingLogger{name: "remote", err: errors.New("unreachable")}
+
+	l := NewTeeLogger(primary, local, remote)
+	assert.Check(t, is.Equal(l.Name(), "primary"))
+	assert.Check(t, is.Equal(l.(SizedLogger).BufSize(), defaultBufSize))
+
+	for i := 0; i < 3; i++ {
+		msg := NewMessage()
+		msg.Line = append(msg.Line, fmt.Sprintf("line %d", i)...)
+		msg.Timestamp = time.Now()
+		assert.Check(t, is.ErrorContains(l.Log(msg), "unreachable"))
+	}
+	expected := []string{"line 0", "line 1", "line 2"}
+	assert.Check(t, is.DeepEqual(primary.lines, expected))
+	assert.Check(t, is.DeepEqual(local.lines, expected))
+	assert.Check(t, is.DeepEqual(remote.lines, expected))
+
+	// the logs are read from the logger which can read them
+	w := l.(LogReader).ReadLogs(ReadConfig{})
+	msg := <-w.Msg
+	assert.Check(t, is.Equal(string(msg.Line), "line 0"))
+
+	assert.NilError(t, l.Close())
+	assert.Check(t, primary.closed && local.closed && remote.closed)
+
+	_, ok := NewTeeLogger(primary, remote).(LogReader)
+	assert.Check(t, !ok, "expected the logs not to be readable without a log reader")
+}
+
+func TestValidateTeeOpts(t *testing.T) {
+	validator := func(cfg map[string]string) error {
+		for k := range cfg {
+			if k != "address" {
+				return fmt.Errorf("unknown log opt '%s'", k)
+			}
+		}
+		return nil
+	}
+	creator := func(Info) (Logger, error) { return &resettingLogger{}, nil }
+	for _, name := range []string{"tee-test-local", "tee-test-remote"} {
+		assert.NilError(t, RegisterLogDriver(name, creator))
+		assert.NilError(t, RegisterLogOptValidator(name, validator))
+	}
+
+	for _, tc := range []struct {
+		cfg   map[string]string
+		valid bool
+	}{
+		{cfg: map[string]string{}, valid: true},
+		{cfg: map[string]string{"tee": "tee-test-remote", "tee-tee-test-remote-address": "x"}, valid: true},
+		{cfg: map[string]string{"tee": "tee-test-remote", "tee-tee-test-remote-port": "1"}},
+		{cfg: map[string]string{"tee": "tee-test-local"}},
+		{cfg: map[string]string{"tee": "tee-test-remote,tee-test-remote"}},
+		{cfg: map[string]string{"tee": "none"}},
+		{cfg: map[string]string{"tee": "tee-test-missing"}},
+		{cfg: map[string]string{"tee-tee-test-remote-address": "x"}},
+	} {
+		err := ValidateLogOpts("tee-test-local", tc.cfg)
+		if tc.valid {
+			assert.Check(t, err, "%v", tc.cfg)
+		} else {
+			assert.Check(t, err != nil, "expected an error validating %v", tc.cfg)
+		}
+	}
+}
+
+func TestTeeConfig(t *testing.T) {
+	cfg := map[string]string{
+		"tee":                        "fluentd,json-file",
+		"tee-fluentd-fluentd-async":  "true",
+		"tee-json-file-max-size":     "10m",
+		"tee-json-file-labels":       "team",
+		"fluentd-address":            "localhost:24224",
+		"tee-gelf-gelf-address":      "udp://localhost",
+		"tee-fluentd-attr-container": "{{.Name}}",
+	}
+	assert.Check(t, is.DeepEqual(TeeDrivers(cfg), []string{"fluentd", "json-file"}))
+	assert.Check(t, is.DeepEqual(TeeConfig(cfg, "fluentd"), map[string]string{"fluentd-async": "true", "attr-container": "{{.Name}}"}))
+	assert.Check(t, is.DeepEqual(TeeConfig(cfg, "json-file"), map[string]string{"max-size": "10m", "labels": "team"}))
+	assert.Check(t, isTeeOpt(cfg, "tee"))
+	assert.Check(t, !isTeeOpt(cfg, "tee-gelf-gelf-address"))
+	assert.Check(t, !isTeeOpt(cfg, "fluentd-address"))
+}
@@ -2,7 +2,9 @@ package daemon // import "github.com/docker/docker/daemon"
 
 import (
 	"context"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -11,6 +13,7 @@ import (
 	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils/cache"
 	"github.com/docker/docker/errdefs"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -72,20 +75,26 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 
 	var since time.Time
 	if config.Since != "" {
-		s, n, err := timetypes.ParseTimestamps(config.Since, 0)
+		since, err = parseLogsTimestamp(config.Since)
 		if err != nil {
-			return nil, false, err
+			return nil, false, errdefs.InvalidParameter(errors.Wrap(err, `invalid value for "since"`))
 		}
-		since = time.Unix(s, n)
 	}
 
 	var until time.Time
 	if config.Until != "" && config.Until != "0" {
-		s, n, err := timetypes.ParseTimestamps(config.Until, 0)
+		until, err = parseLogsTimestamp(config.Until)
 		if err != nil {
-			return nil, false, err
+			return nil, false, errdefs.InvalidParameter(errors.Wrap(err, `invalid value for "until"`))
+		}
+	}
+
+	var grep *regexp.Regexp
+	if config.Grep != "" {
+		grep, err = regexp.Compile(config.Grep)
+		if err != nil {
+			return nil, false, errdefs.InvalidParameter(errors.Wrap(err, `invalid value for "grep"`))
 		}
-		until = time.Unix(s, n)
 	}
 
 	readConfig := logger.ReadConfig{
@@ -93,6 +102,7 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 		Until:  until,
 		Tail:   tailLines,
 		Follow: follow,
+		Grep:   grep,
 	}
 
 	logs := logReader.ReadLogs(readConfig)
@@ -159,6 +169,19 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 	return messageChan, container.Config.Tty, nil
 }
 
+// parseLogsTimestamp parses a since or until timestamp of the logs, either a
+// UNIX timestamp with up to nanosecond precision, or an RFC 3339 timestamp.
+func parseLogsTimestamp(value string) (time.Time, error) {
+	if strings.Contains(value, "T") {
+		return time.Parse(time.RFC3339Nano, value)
+	}
+	s, n, err := timetypes.ParseTimestamps(value, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(s, n), nil
+}
+
 func (daemon *Daemon) getLogger(container *container.Container) (l logger.Logger, created bool, err error) {
 	container.Lock()
 	if container.State.Running {
@@ -167,7 +190,12 @@ func (daemon *Daemon) getLogger(container *container.Container) (l logger.Logger
 	container.Unlock()
 	if l == nil {
 		created = true
-		l, err = container.StartLogger()
+		// the logs cached locally are read without starting the log
+		// driver, which may write remotely
+		l, err = container.OpenLogCache()
+		if err == nil && l == nil {
+			l, err = container.StartLogger()
+		}
 	}
 	return
 }
@@ -188,6 +216,9 @@ func (daemon *Daemon) mergeAndVerifyLogConfig(cfg *containertypes.LogConfig) err
 				cfg.Config[k] = v
 			}
 		}
+	} else {
+		// the options of the local cache apply to all the log drivers
+		cache.MergeDefaultLogConfig(cfg.Config, daemon.defaultLogConfig.Config)
 	}
 
 	return logger.ValidateLogOpts(cfg.Type, cfg.Config)
@@ -0,0 +1,49 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/daemon/logger"
+)
+
+// ContainerLogHealth returns the state of the logging pipeline of the
+// container, for the logs missing from its log driver to be diagnosed.
+func (daemon *Daemon) ContainerLogHealth(name string) (*types.ContainerLogHealth, error) {
+	ctr, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctr.Lock()
+	cfg := ctr.HostConfig.LogConfig
+	copier, logDriver := ctr.LogCopier, ctr.LogDriver
+	ctr.Unlock()
+
+	mode := containertypes.LogMode(cfg.Config["mode"])
+	if mode == containertypes.LogModeUnset {
+		mode = containertypes.LogModeBlocking
+	}
+	health := &types.ContainerLogHealth{
+		Driver:  cfg.Type,
+		Mode:    string(mode),
+		Running: logDriver != nil,
+	}
+
+	if copier != nil && copier.Limiter() != nil {
+		health.RateLimitedLines, health.SampledOutLines = copier.Limiter().Dropped()
+	}
+	if ringStats, ok := logger.GetRingStats(logDriver); ok {
+		health.BufferedBytes = ringStats.Buffered
+		health.BufferSize = ringStats.Size
+		health.DroppedMessages = ringStats.Dropped
+	}
+	if stats, ok := logger.GetHealthStats(logDriver); ok {
+		health.WrittenMessages = stats.Written
+		health.WriteErrors = stats.Errors
+		health.LastWrite = stats.LastWrite
+		health.LastError = stats.LastError
+		health.LastErrorTime = stats.LastErrorTime
+		health.PluginRestarts = stats.PluginRestarts
+	}
+	return health, nil
+}
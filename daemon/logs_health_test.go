@@ -0,0 +1,55 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"errors"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/logger"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type failingLogger struct{}
+
+func (failingLogger) Log(msg *logger.Message) error {
+	logger.PutMessage(msg)
+	return errors.New("endpoint unreachable")
+}
+
+func (failingLogger) Name() string { return "remote" }
+
+func (failingLogger) Close() error { return nil }
+
+func TestContainerLogHealth(t *testing.T) {
+	d := &Daemon{containers: container.NewMemoryStore()}
+	c := &container.Container{
+		ID:    "container_id",
+		State: &container.State{},
+		HostConfig: &containertypes.HostConfig{
+			LogConfig: containertypes.LogConfig{Type: "remote"},
+		},
+	}
+	d.containers.Add(c.ID, c)
+
+	health, err := d.ContainerLogHealth(c.ID)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(health.Driver, "remote"))
+	assert.Check(t, is.Equal(health.Mode, "blocking"))
+	assert.Check(t, !health.Running)
+
+	l := logger.WithHealth(failingLogger{}, failingLogger{})
+	msg := logger.NewMessage()
+	msg.Line = append(msg.Line, "message"...)
+	assert.Check(t, l.Log(msg) != nil)
+	c.LogDriver = l
+
+	health, err = d.ContainerLogHealth(c.ID)
+	assert.NilError(t, err)
+	assert.Check(t, health.Running)
+	assert.Check(t, is.Equal(health.WrittenMessages, uint64(0)))
+	assert.Check(t, is.Equal(health.WriteErrors, uint64(1)))
+	assert.Check(t, is.Equal(health.LastError, "endpoint unreachable"))
+	assert.Check(t, !health.LastErrorTime.IsZero())
+}
@@ -2,6 +2,7 @@ package daemon // import "github.com/docker/docker/daemon"
 
 import (
 	"testing"
+	"time"
 
 	containertypes "github.com/docker/docker/api/types/container"
 )
@@ -13,3 +14,23 @@ func TestMergeAndVerifyLogConfigNilConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParseLogsTimestamp(t *testing.T) {
+	for value, expected := range map[string]time.Time{
+		"1136073600":                          time.Unix(1136073600, 0),
+		"1136073600.000000001":                time.Unix(1136073600, 1),
+		"2006-01-02T15:04:05.000000001Z":      time.Date(2006, 1, 2, 15, 4, 5, 1, time.UTC),
+		"2006-01-02T15:04:05.123456789+01:00": time.Date(2006, 1, 2, 14, 4, 5, 123456789, time.UTC),
+	} {
+		ts, err := parseLogsTimestamp(value)
+		if err != nil {
+			t.Fatalf("%s: %v", value, err)
+		}
+		if !ts.Equal(expected) {
+			t.Fatalf("%s: expected %v, got %v", value, expected, ts)
+		}
+	}
+	if _, err := parseLogsTimestamp("2006-01-02T15:04"); err == nil {
+		t.Fatal("expected an error parsing an incomplete timestamp")
+	}
+}
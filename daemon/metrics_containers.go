@@ -0,0 +1,175 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// containerMetricsSyncInterval is the interval at which the containers
+// collected by the per-container metrics are synced with the running
+// containers.
+const containerMetricsSyncInterval = 10 * time.Second
+
+type containerMetricsSub struct {
+	container *container.Container
+	ch        chan interface{}
+}
+
+// containerMetrics exports the resource usage of the running containers as
+// prometheus metrics, from the latest stats published by the stats collector.
+type containerMetrics struct {
+	mu    sync.Mutex
+	subs  map[string]containerMetricsSub
+	stats map[string]types.StatsJSON
+
+	cpuUsage    *prometheus.Desc
+	memoryUsage *prometheus.Desc
+	memoryLimit *prometheus.Desc
+	blkioRead   *prometheus.Desc
+	blkioWrite  *prometheus.Desc
+	networkRx   *prometheus.Desc
+	networkTx   *prometheus.Desc
+	pidsCurrent *prometheus.Desc
+}
+
+func newContainerMetrics(ns *metrics.Namespace) *containerMetrics {
+	return &containerMetrics{
+		subs:        make(map[string]containerMetricsSub),
+		stats:       make(map[string]types.StatsJSON),
+		cpuUsage:    ns.NewDesc("cpu_usage_seconds", "The total CPU time consumed by the container", metrics.Total, "id", "name"),
+		memoryUsage: ns.NewDesc("memory_usage", "The memory usage of the container", metrics.Bytes, "id", "name"),
+		memoryLimit: ns.NewDesc("memory_limit", "The memory limit of the container", metrics.Bytes, "id", "name"),
+		blkioRead:   ns.NewDesc("blkio_read_bytes", "The number of bytes read from block devices by the container", metrics.Total, "id", "name"),
+		blkioWrite:  ns.NewDesc("blkio_write_bytes", "The number of bytes written to block devices by the container", metrics.Total, "id", "name"),
+		networkRx:   ns.NewDesc("network_receive_bytes", "The number of bytes received by the container on a network interface", metrics.Total, "id", "name", "interface"),
+		networkTx:   ns.NewDesc("network_transmit_bytes", "The number of bytes transmitted by the container on a network interface", metrics.Total, "id", "name", "interface"),
+		pidsCurrent: ns.NewDesc("processes", "The number of processes running in the container", metrics.Unit(""), "id", "name"),
+	}
+}
+
+// sync subscribes to the stats of the running containers, and unsubscribes
+// from the stats of the containers which are no longer running.
+func (m *containerMetrics) sync(containers []*container.Container, subscribe func(*container.Container) chan interface{}, unsubscribe func(*container.Container, chan interface{})) {
+	running := make(map[string]*container.Container, len(containers))
+	for _, c := range containers {
+		if c.IsRunning() {
+			running[c.ID] = c
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, sub := range m.subs {
+		if _, ok := running[id]; !ok {
+			delete(m.subs, id)
+			delete(m.stats, id)
+			unsubscribe(sub.container, sub.ch)
+		}
+	}
+	for id, c := range running {
+		if _, ok := m.subs[id]; ok {
+			continue
+		}
+		sub := containerMetricsSub{container: c, ch: subscribe(c)}
+		m.subs[id] = sub
+		go m.consume(id, sub.ch)
+	}
+}
+
+// consume records the stats published on ch until it is closed, either
+// because the container was unsubscribed, or because it was removed.
+func (m *containerMetrics) consume(id string, ch chan interface{}) {
+	for v := range ch {
+		stats, ok := v.(types.StatsJSON)
+		if !ok || stats.Read.IsZero() {
+			// the container is not running
+			continue
+		}
+		m.update(id, ch, stats)
+	}
+
+	m.mu.Lock()
+	if sub, ok := m.subs[id]; ok && sub.ch == ch {
+		delete(m.subs, id)
+		delete(m.stats, id)
+	}
+	m.mu.Unlock()
+}
+
+func (m *containerMetrics) update(id string, ch chan interface{}, stats types.StatsJSON) {
+	m.mu.Lock()
+	if sub, ok := m.subs[id]; ok && sub.ch == ch {
+		m.stats[id] = stats
+	}
+	m.mu.Unlock()
+}
+
+func (m *containerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuUsage
+	ch <- m.memoryUsage
+	ch <- m.memoryLimit
+	ch <- m.blkioRead
+	ch <- m.blkioWrite
+	ch <- m.networkRx
+	ch <- m.networkTx
+	ch <- m.pidsCurrent
+}
+
+func (m *containerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, s := range m.stats {
+		name := strings.TrimPrefix(s.Name, "/")
+
+		cpuUsage := float64(s.CPUStats.CPUUsage.TotalUsage) / 1e9
+		if runtime.GOOS == "windows" {
+			// the CPU usage is given in units of 100 nanoseconds on Windows
+			cpuUsage *= 100
+		}
+		blkioRead := s.StorageStats.ReadSizeBytes
+		blkioWrite := s.StorageStats.WriteSizeBytes
+		for _, e := range s.BlkioStats.IoServiceBytesRecursive {
+			switch strings.ToLower(e.Op) {
+			case "read":
+				blkioRead += e.Value
+			case "write":
+				blkioWrite += e.Value
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(m.cpuUsage, prometheus.CounterValue, cpuUsage, id, name)
+		ch <- prometheus.MustNewConstMetric(m.memoryUsage, prometheus.GaugeValue, float64(s.MemoryStats.Usage), id, name)
+		ch <- prometheus.MustNewConstMetric(m.memoryLimit, prometheus.GaugeValue, float64(s.MemoryStats.Limit), id, name)
+		ch <- prometheus.MustNewConstMetric(m.blkioRead, prometheus.CounterValue, float64(blkioRead), id, name)
+		ch <- prometheus.MustNewConstMetric(m.blkioWrite, prometheus.CounterValue, float64(blkioWrite), id, name)
+		ch <- prometheus.MustNewConstMetric(m.pidsCurrent, prometheus.GaugeValue, float64(s.PidsStats.Current), id, name)
+		for iface, n := range s.Networks {
+			ch <- prometheus.MustNewConstMetric(m.networkRx, prometheus.CounterValue, float64(n.RxBytes), id, name, iface)
+			ch <- prometheus.MustNewConstMetric(m.networkTx, prometheus.CounterValue, float64(n.TxBytes), id, name, iface)
+		}
+	}
+}
+
+// collectContainerMetrics runs a loop keeping the per-container metrics in
+// sync with the running containers.
+func (daemon *Daemon) collectContainerMetrics() {
+	if daemon.containerMetrics == nil {
+		return
+	}
+	for {
+		daemon.containerMetrics.sync(daemon.List(), daemon.subscribeToContainerStats, daemon.unsubscribeToContainerStats)
+		time.Sleep(containerMetricsSyncInterval)
+
+		if daemon.IsShuttingDown() {
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestContainerMetricsSync(t *testing.T) {
+	m := newContainerMetrics(metrics.NewNamespace("test", "container", nil))
+
+	running := &container.Container{ID: "running", State: container.NewState()}
+	running.SetRunning(0, true)
+	stopped := &container.Container{ID: "stopped", State: container.NewState()}
+
+	subscribed := make(map[string]chan interface{})
+	subscribe := func(c *container.Container) chan interface{} {
+		ch := make(chan interface{})
+		subscribed[c.ID] = ch
+		return ch
+	}
+	unsubscribe := func(c *container.Container, ch chan interface{}) {
+		close(ch)
+		delete(subscribed, c.ID)
+	}
+
+	m.sync([]*container.Container{running, stopped}, subscribe, unsubscribe)
+	assert.Check(t, is.Len(subscribed, 1))
+	ch, ok := subscribed["running"]
+	assert.Assert(t, ok)
+
+	ch <- types.StatsJSON{Name: "/running", ID: "running"}
+	ch <- types.StatsJSON{Name: "/running", ID: "running", Stats: types.Stats{Read: time.Now()}}
+	// the stats are recorded after the next value is received
+	ch <- types.StatsJSON{Name: "/running", ID: "running", Stats: types.Stats{Read: time.Now()}}
+	m.mu.Lock()
+	assert.Check(t, is.Len(m.stats, 1))
+	m.mu.Unlock()
+
+	running.SetStopped(&container.ExitStatus{})
+	m.sync([]*container.Container{running, stopped}, subscribe, unsubscribe)
+	assert.Check(t, is.Len(subscribed, 0))
+	m.mu.Lock()
+	assert.Check(t, is.Len(m.stats, 0))
+	m.mu.Unlock()
+}
+
+func TestContainerMetricsCollect(t *testing.T) {
+	m := newContainerMetrics(metrics.NewNamespace("test", "container", nil))
+	ch := make(chan interface{})
+	m.subs["abc"] = containerMetricsSub{ch: ch}
+
+	var stats types.StatsJSON
+	stats.Name = "/web"
+	stats.CPUStats.CPUUsage.TotalUsage = 2500000000
+	stats.MemoryStats.Usage = 1024
+	stats.MemoryStats.Limit = 4096
+	stats.PidsStats.Current = 3
+	stats.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Major: 8, Op: "Read", Value: 100},
+		{Major: 8, Op: "Write", Value: 200},
+		{Major: 8, Op: "Total", Value: 300},
+	}
+	stats.Networks = map[string]types.NetworkStats{"eth0": {RxBytes: 10, TxBytes: 20}}
+	m.update("abc", ch, stats)
+
+	registry := prometheus.NewRegistry()
+	assert.NilError(t, registry.Register(m))
+	families, err := registry.Gather()
+	assert.NilError(t, err)
+
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, metric := range f.Metric {
+			labels := make(map[string]string)
+			for _, l := range metric.Label {
+				labels[l.GetName()] = l.GetValue()
+			}
+			assert.Check(t, is.Equal(labels["id"], "abc"))
+			assert.Check(t, is.Equal(labels["name"], "web"))
+			values[f.GetName()] = metricValue(metric)
+		}
+	}
+	assert.Check(t, is.DeepEqual(values, map[string]float64{
+		"test_container_cpu_usage_seconds_total":      2.5,
+		"test_container_memory_usage_bytes":           1024,
+		"test_container_memory_limit_bytes":           4096,
+		"test_container_processes":                    3,
+		"test_container_blkio_read_bytes_total":       100,
+		"test_container_blkio_write_bytes_total":      200,
+		"test_container_network_receive_bytes_total":  10,
+		"test_container_network_transmit_bytes_total": 20,
+	}))
+}
+
+func metricValue(m *dto.Metric) float64 {
+	if m.Counter != nil {
+		return m.Counter.GetValue()
+	}
+	return m.Gauge.GetValue()
+}
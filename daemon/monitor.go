@@ -94,7 +94,7 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerd.EventType, ei libc
 						// But containerStart will use daemon.netController segment.
 						// So to avoid panic at startup process, here must wait util daemon restore done.
 						daemon.waitForStartupDone()
-						if err = daemon.containerStart(c, "", "", false); err != nil {
+						if err = daemon.containerStart(context.Background(), c, "", "", false); err != nil {
 							logrus.Debugf("failed to restart container: %+v", err)
 						}
 					}
@@ -133,6 +133,7 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerd.EventType, ei libc
 				"exitCode": strconv.Itoa(ec),
 			}
 			daemon.LogContainerEventWithAttributes(c, "exec_die", attributes)
+			daemon.auditExec(c, execConfig)
 		} else {
 			logrus.WithFields(logrus.Fields{
 				"container": c.ID,
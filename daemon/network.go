@@ -17,18 +17,18 @@ import (
 	clustertypes "github.com/docker/docker/daemon/cluster/provider"
 	internalnetwork "github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/libnetwork"
+	lncluster "github.com/docker/docker/libnetwork/cluster"
+	"github.com/docker/docker/libnetwork/driverapi"
+	"github.com/docker/docker/libnetwork/ipamapi"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/networkdb"
+	"github.com/docker/docker/libnetwork/options"
+	networktypes "github.com/docker/docker/libnetwork/types"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/plugingetter"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/go-connections/nat"
-	"github.com/docker/libnetwork"
-	lncluster "github.com/docker/libnetwork/cluster"
-	"github.com/docker/libnetwork/driverapi"
-	"github.com/docker/libnetwork/ipamapi"
-	"github.com/docker/libnetwork/netlabel"
-	"github.com/docker/libnetwork/networkdb"
-	"github.com/docker/libnetwork/options"
-	networktypes "github.com/docker/libnetwork/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -290,6 +290,57 @@ func (daemon *Daemon) CreateNetwork(create types.NetworkCreateRequest) (*types.N
 	return resp, err
 }
 
+const (
+	minNetworkMTU     = 68
+	minNetworkMTUIPv6 = 1280
+	maxNetworkMTU     = 65535
+)
+
+// validateNetworkMTU validates the MTU set in the driver options of a network.
+// IPv6 networks require an MTU of at least 1280.
+func validateNetworkMTU(options map[string]string, enableIPv6 bool) error {
+	value, ok := options[netlabel.DriverMTU]
+	if !ok {
+		return nil
+	}
+	mtu, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.Errorf("invalid MTU %q: must be an integer", value)
+	}
+	min := minNetworkMTU
+	if enableIPv6 {
+		min = minNetworkMTUIPv6
+	}
+	if mtu < min || mtu > maxNetworkMTU {
+		return errors.Errorf("invalid MTU %d: must be between %d and %d", mtu, min, maxNetworkMTU)
+	}
+	return nil
+}
+
+// validateNetworkDNS validates and normalizes the DNS configuration of a
+// network.
+func validateNetworkDNS(dns *network.DNSConfig) (*network.DNSConfig, error) {
+	if dns == nil {
+		return nil, nil
+	}
+	validated := &network.DNSConfig{Options: dns.Options}
+	for _, ns := range dns.Nameservers {
+		ip, err := opts.ValidateIPAddress(ns)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid nameserver")
+		}
+		validated.Nameservers = append(validated.Nameservers, ip)
+	}
+	for _, domain := range dns.Search {
+		d, err := opts.ValidateDNSSearch(domain)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid DNS search domain")
+		}
+		validated.Search = append(validated.Search, d)
+	}
+	return validated, nil
+}
+
 func (daemon *Daemon) createNetwork(create types.NetworkCreateRequest, id string, agent bool) (*types.NetworkCreateResponse, error) {
 	if runconfig.IsPreDefinedNetwork(create.Name) {
 		return nil, PredefinedNetworkError(create.Name)
@@ -313,6 +364,14 @@ func (daemon *Daemon) createNetwork(create types.NetworkCreateRequest, id string
 		warning = fmt.Sprintf("Network with name %s (id : %s) already exists", nw.Name(), nw.ID())
 	}
 
+	if err := validateNetworkMTU(create.Options, create.EnableIPv6); err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+	dns, err := validateNetworkDNS(create.DNS)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+
 	c := daemon.netController
 	driver := create.Driver
 	if driver == "" {
@@ -332,6 +391,10 @@ func (daemon *Daemon) createNetwork(create types.NetworkCreateRequest, id string
 		nwOptions = append(nwOptions, libnetwork.NetworkOptionConfigOnly())
 	}
 
+	if dns != nil {
+		nwOptions = append(nwOptions, libnetwork.NetworkOptionDNS(dns.Nameservers, dns.Search, dns.Options))
+	}
+
 	if create.IPAM != nil {
 		ipam := create.IPAM
 		v4Conf, v6Conf, err := getIpamConfig(ipam.Config)
@@ -463,6 +526,44 @@ func (daemon *Daemon) DisconnectContainerFromNetwork(containerName string, netwo
 	return daemon.DisconnectFromNetwork(container, networkName, force)
 }
 
+// SetContainerGwPriority changes the gateway priority of the endpoint of the
+// container on the network. The default gateway of a running container is
+// updated accordingly.
+func (daemon *Daemon) SetContainerGwPriority(containerName, networkName string, priority int) error {
+	container, err := daemon.GetContainer(containerName)
+	if err != nil {
+		return err
+	}
+	container.Lock()
+	defer container.Unlock()
+
+	n, err := daemon.FindNetwork(networkName)
+	if err == nil {
+		networkName = n.Name()
+	}
+	epConfig, ok := container.NetworkSettings.Networks[networkName]
+	if !ok || epConfig.EndpointSettings == nil {
+		return errdefs.NotFound(fmt.Errorf("container %s is not connected to the network %s", container.ID, networkName))
+	}
+
+	if container.Running && n != nil && epConfig.EndpointID != "" {
+		sb := daemon.getNetworkSandbox(container)
+		if sb == nil {
+			return fmt.Errorf("network sandbox does not exist for container %s", container.ID)
+		}
+		ep, err := n.EndpointByID(epConfig.EndpointID)
+		if err != nil {
+			return err
+		}
+		if err := sb.SetEndpointPriority(ep, priority); err != nil {
+			return err
+		}
+	}
+	epConfig.GwPriority = priority
+
+	return container.CheckpointTo(daemon.containersReplica)
+}
+
 // GetNetworkDriverList returns the list of plugins drivers
 // registered for network.
 func (daemon *Daemon) GetNetworkDriverList() []string {
@@ -604,6 +705,9 @@ func buildNetworkResource(nw libnetwork.Network) types.NetworkResource {
 	buildIpamResources(&r, info)
 	r.Labels = info.Labels()
 	r.ConfigOnly = info.ConfigOnly()
+	if servers, search, options := info.DNSConfig(); len(servers) > 0 || len(search) > 0 || len(options) > 0 {
+		r.DNS = &network.DNSConfig{Nameservers: servers, Search: search, Options: options}
+	}
 
 	if cn := info.ConfigFrom(); cn != "" {
 		r.ConfigFrom = network.ConfigReference{Network: cn}
@@ -635,7 +739,19 @@ func buildDetailedNetworkResources(r *types.NetworkResource, nw libnetwork.Netwo
 			key = sb.ContainerID()
 		}
 
-		r.Containers[key] = buildEndpointResource(tmpID, e.Name(), ei)
+		er := buildEndpointResource(tmpID, e.Name(), ei)
+		if info, err := e.DriverInfo(); err == nil {
+			er.IngressRate, _ = info[netlabel.IngressRate].(uint64)
+			er.EgressRate, _ = info[netlabel.EgressRate].(uint64)
+		}
+		if verbose && sb != nil {
+			if stats, err := e.Statistics(); err == nil {
+				er.Statistics = buildEndpointStatistics(stats)
+			} else {
+				logrus.WithError(err).Debugf("failed to retrieve the statistics of endpoint %s", tmpID)
+			}
+		}
+		r.Containers[key] = er
 	}
 	if !verbose {
 		return
@@ -661,6 +777,17 @@ func buildDetailedNetworkResources(r *types.NetworkResource, nw libnetwork.Netwo
 	}
 }
 
+func buildEndpointStatistics(stats *networktypes.InterfaceStatistics) *types.EndpointStatistics {
+	return &types.EndpointStatistics{
+		RxBytes:   stats.RxBytes,
+		RxPackets: stats.RxPackets,
+		RxDropped: stats.RxDropped,
+		TxBytes:   stats.TxBytes,
+		TxPackets: stats.TxPackets,
+		TxDropped: stats.TxDropped,
+	}
+}
+
 func buildPeerInfoResources(peers []networkdb.PeerInfo) []network.PeerInfo {
 	peerInfo := make([]network.PeerInfo, 0, len(peers))
 	for _, peer := range peers {
@@ -785,6 +912,26 @@ func (daemon *Daemon) clearAttachableNetworks() {
 	}
 }
 
+// dnsTXTRecords returns the text records of the container served by the
+// embedded DNS server, in the "key=value" form, from the container labels
+// with the netlabel.DNSTXTPrefix prefix.
+func dnsTXTRecords(labels map[string]string) []string {
+	var records []string
+	for k, v := range labels {
+		if !strings.HasPrefix(k, netlabel.DNSTXTPrefix) {
+			continue
+		}
+		record := strings.TrimPrefix(k, netlabel.DNSTXTPrefix) + "=" + v
+		if len(record) > 255 {
+			logrus.Warnf("Ignoring label %s: the text record exceeds 255 characters", k)
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Strings(records)
+	return records
+}
+
 // buildCreateEndpointOptions builds endpoint options from a given network.
 func buildCreateEndpointOptions(c *container.Container, n libnetwork.Network, epConfig *network.EndpointSettings, sb libnetwork.Sandbox, daemonDNS []string) ([]libnetwork.EndpointOption, error) {
 	var (
@@ -801,6 +948,10 @@ func buildCreateEndpointOptions(c *container.Container, n libnetwork.Network, ep
 		createOptions = append(createOptions, libnetwork.CreateOptionAnonymous())
 	}
 
+	if txt := dnsTXTRecords(c.Config.Labels); len(txt) > 0 {
+		createOptions = append(createOptions, libnetwork.CreateOptionTXTRecords(txt))
+	}
+
 	if epConfig != nil {
 		ipam := epConfig.IPAMConfig
 
@@ -1089,6 +1240,9 @@ func buildJoinOptions(networkSettings *internalnetwork.Settings, n interface {
 		for k, v := range epConfig.DriverOpts {
 			joinOptions = append(joinOptions, libnetwork.EndpointOptionGeneric(options.Generic{k: v}))
 		}
+		if epConfig.EndpointSettings != nil && epConfig.GwPriority != 0 {
+			joinOptions = append(joinOptions, libnetwork.JoinOptionPriority(nil, epConfig.GwPriority))
+		}
 	}
 
 	return joinOptions, nil
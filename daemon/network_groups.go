@@ -0,0 +1,327 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/names"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/libnetwork"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/runconfig"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// networkGroupsFile is the file, in the root of the daemon, in which the
+	// network groups are persisted.
+	networkGroupsFile = "network-groups.json"
+	// networkGroupsDir is the directory, in the root of the daemon, of the
+	// hosts and resolv.conf files of the active network groups.
+	networkGroupsDir = "network-groups"
+)
+
+// networkGroup is a network namespace shared by the containers started with
+// the "group:<name>" network mode. The namespace, and its endpoints on the
+// networks of the group, are created when the first container of the group
+// is started, and released when the last one is stopped.
+type networkGroup struct {
+	config  types.NetworkGroup
+	sandbox libnetwork.Sandbox
+	members map[string]struct{}
+}
+
+func (g *networkGroup) inspect() types.NetworkGroup {
+	info := g.config
+	info.Networks = append([]string{}, g.config.Networks...)
+	info.Containers = []string{}
+	for id := range g.members {
+		info.Containers = append(info.Containers, id)
+	}
+	sort.Strings(info.Containers)
+	if g.sandbox != nil {
+		info.SandboxKey = g.sandbox.Key()
+	}
+	return info
+}
+
+// networkGroupStore holds the network groups of the daemon, by name.
+type networkGroupStore struct {
+	sync.Mutex
+	root   string
+	groups map[string]*networkGroup
+}
+
+func newNetworkGroupStore(root string) (*networkGroupStore, error) {
+	s := &networkGroupStore{
+		root:   root,
+		groups: make(map[string]*networkGroup),
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, networkGroupsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var configs []types.NetworkGroup
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, errors.Wrap(err, "failed to load the network groups")
+	}
+	for _, c := range configs {
+		s.groups[c.Name] = &networkGroup{config: c, members: make(map[string]struct{})}
+	}
+	// The namespaces of the network groups do not outlive the daemon.
+	if err := os.RemoveAll(filepath.Join(root, networkGroupsDir)); err != nil {
+		logrus.WithError(err).Warn("failed to remove the files of the network groups")
+	}
+	return s, nil
+}
+
+// get returns the group with the given name or ID. The store must be locked.
+func (s *networkGroupStore) get(nameOrID string) (*networkGroup, error) {
+	if g, ok := s.groups[nameOrID]; ok {
+		return g, nil
+	}
+	for _, g := range s.groups {
+		if g.config.ID == nameOrID {
+			return g, nil
+		}
+	}
+	return nil, errdefs.NotFound(fmt.Errorf("network group %s not found", nameOrID))
+}
+
+// save persists the configuration of the groups. The store must be locked.
+func (s *networkGroupStore) save() error {
+	configs := make([]types.NetworkGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		configs = append(configs, g.config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	b, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(filepath.Join(s.root, networkGroupsFile), b, 0600)
+}
+
+// CreateNetworkGroup creates a network group, attached to the given networks
+// or else to the default network.
+func (daemon *Daemon) CreateNetworkGroup(req types.NetworkGroupCreateRequest) (*types.NetworkCreateResponse, error) {
+	if !names.RestrictedNamePattern.MatchString(req.Name) {
+		return nil, errdefs.InvalidParameter(fmt.Errorf("invalid network group name %q, only %s are allowed", req.Name, names.RestrictedNameChars))
+	}
+	networks := req.Networks
+	if len(networks) == 0 {
+		networks = []string{runconfig.DefaultDaemonNetworkMode().NetworkName()}
+	}
+	for _, name := range networks {
+		n, err := daemon.FindNetwork(name)
+		if err != nil {
+			return nil, err
+		}
+		if n.Info().Dynamic() || n.Type() == "host" || n.Type() == "null" {
+			return nil, errdefs.InvalidParameter(fmt.Errorf("network group cannot be attached to the %s network", name))
+		}
+	}
+
+	s := daemon.networkGroups
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.groups[req.Name]; ok {
+		return nil, errdefs.Conflict(fmt.Errorf("network group %s already exists", req.Name))
+	}
+	g := &networkGroup{
+		config: types.NetworkGroup{
+			Name:     req.Name,
+			ID:       stringid.GenerateRandomID(),
+			Created:  time.Now().UTC(),
+			Networks: networks,
+		},
+		members: make(map[string]struct{}),
+	}
+	s.groups[g.config.Name] = g
+	if err := s.save(); err != nil {
+		delete(s.groups, g.config.Name)
+		return nil, err
+	}
+	return &types.NetworkCreateResponse{ID: g.config.ID}, nil
+}
+
+// NetworkGroups returns the network groups of the daemon.
+func (daemon *Daemon) NetworkGroups() []types.NetworkGroup {
+	s := daemon.networkGroups
+	s.Lock()
+	defer s.Unlock()
+	list := make([]types.NetworkGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		list = append(list, g.inspect())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// NetworkGroup returns the network group with the given name or ID.
+func (daemon *Daemon) NetworkGroup(nameOrID string) (types.NetworkGroup, error) {
+	s := daemon.networkGroups
+	s.Lock()
+	defer s.Unlock()
+	g, err := s.get(nameOrID)
+	if err != nil {
+		return types.NetworkGroup{}, err
+	}
+	return g.inspect(), nil
+}
+
+// DeleteNetworkGroup removes the network group with the given name or ID,
+// which must not have running containers.
+func (daemon *Daemon) DeleteNetworkGroup(nameOrID string) error {
+	s := daemon.networkGroups
+	s.Lock()
+	defer s.Unlock()
+	g, err := s.get(nameOrID)
+	if err != nil {
+		return err
+	}
+	if len(g.members) > 0 {
+		var members []string
+		for id := range g.members {
+			members = append(members, stringid.TruncateID(id))
+		}
+		sort.Strings(members)
+		return errdefs.Conflict(fmt.Errorf("network group %s has running containers: %s", g.config.Name, strings.Join(members, ", ")))
+	}
+	delete(s.groups, g.config.Name)
+	if err := s.save(); err != nil {
+		s.groups[g.config.Name] = g
+		return err
+	}
+	return nil
+}
+
+// joinNetworkGroup attaches the container to the network namespace of its
+// network group, which is created if the container is the first one of the
+// group to be started.
+func (daemon *Daemon) joinNetworkGroup(c *container.Container) error {
+	s := daemon.networkGroups
+	s.Lock()
+	defer s.Unlock()
+	g, err := s.get(c.HostConfig.NetworkMode.ConnectedGroup())
+	if err != nil {
+		return err
+	}
+	if g.sandbox == nil {
+		if g.sandbox, err = daemon.activateNetworkGroup(g); err != nil {
+			return errors.Wrapf(err, "failed to set up the network namespace of network group %s", g.config.Name)
+		}
+	}
+	g.members[c.ID] = struct{}{}
+
+	dir := filepath.Join(s.root, networkGroupsDir, g.config.ID)
+	c.HostsPath = filepath.Join(dir, "hosts")
+	c.ResolvConfPath = filepath.Join(dir, "resolv.conf")
+	c.NetworkSettings.SandboxID = g.sandbox.ID()
+	c.NetworkSettings.SandboxKey = g.sandbox.Key()
+	return nil
+}
+
+func (daemon *Daemon) activateNetworkGroup(g *networkGroup) (_ libnetwork.Sandbox, retErr error) {
+	dir := filepath.Join(daemon.networkGroups.root, networkGroupsDir, g.config.ID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	opts := []libnetwork.SandboxOption{
+		libnetwork.OptionHostname(g.config.Name),
+		libnetwork.OptionHostsPath(filepath.Join(dir, "hosts")),
+		libnetwork.OptionResolvConfPath(filepath.Join(dir, "resolv.conf")),
+	}
+	for _, dns := range daemon.configStore.DNS {
+		opts = append(opts, libnetwork.OptionDNS(dns))
+	}
+	for _, search := range daemon.configStore.DNSSearch {
+		opts = append(opts, libnetwork.OptionDNSSearch(search))
+	}
+	for _, opt := range daemon.configStore.DNSOptions {
+		opts = append(opts, libnetwork.OptionDNSOptions(opt))
+	}
+
+	sb, err := daemon.netController.NewSandbox("netgroup-"+g.config.ID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			if err := sb.Delete(); err != nil {
+				logrus.WithError(err).Warnf("failed to clean up the network namespace of network group %s", g.config.Name)
+			}
+		}
+	}()
+
+	for _, name := range g.config.Networks {
+		n, err := daemon.FindNetwork(name)
+		if err != nil {
+			return nil, err
+		}
+		ep, err := n.CreateEndpoint(g.config.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := ep.Join(sb); err != nil {
+			if err := ep.Delete(false); err != nil {
+				logrus.WithError(err).Warnf("failed to clean up the endpoint of network group %s on network %s", g.config.Name, name)
+			}
+			return nil, err
+		}
+	}
+	logrus.Debugf("network group %s activated with namespace %s", g.config.Name, sb.Key())
+	return sb, nil
+}
+
+// leaveNetworkGroup detaches the stopped container from its network group,
+// whose network namespace is released if it was the last running container
+// of the group.
+func (daemon *Daemon) leaveNetworkGroup(c *container.Container) {
+	s := daemon.networkGroups
+	s.Lock()
+	defer s.Unlock()
+
+	c.NetworkSettings.SandboxID = ""
+	c.NetworkSettings.SandboxKey = ""
+	g, err := s.get(c.HostConfig.NetworkMode.ConnectedGroup())
+	if err != nil {
+		return
+	}
+	if _, ok := g.members[c.ID]; !ok {
+		return
+	}
+	delete(g.members, c.ID)
+	if len(g.members) > 0 || g.sandbox == nil {
+		return
+	}
+
+	if err := g.sandbox.Delete(); err != nil {
+		logrus.WithError(err).Errorf("failed to release the network namespace of network group %s", g.config.Name)
+	}
+	g.sandbox = nil
+	if err := os.RemoveAll(filepath.Join(s.root, networkGroupsDir, g.config.ID)); err != nil {
+		logrus.WithError(err).Warnf("failed to remove the files of network group %s", g.config.Name)
+	}
+	logrus.Debugf("network group %s deactivated", g.config.Name)
+}
@@ -0,0 +1,46 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestNetworkGroupStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "network-groups")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	s, err := newNetworkGroupStore(root)
+	assert.NilError(t, err)
+	s.groups["web"] = &networkGroup{
+		config:  types.NetworkGroup{Name: "web", ID: "abcdef", Networks: []string{"front"}},
+		members: make(map[string]struct{}),
+	}
+	assert.NilError(t, s.save())
+
+	s, err = newNetworkGroupStore(root)
+	assert.NilError(t, err)
+	d := &Daemon{networkGroups: s}
+
+	g, err := d.NetworkGroup("abcdef")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(g.Name, "web"))
+	assert.Check(t, is.DeepEqual(g.Networks, []string{"front"}))
+	assert.Check(t, is.Len(g.Containers, 0))
+
+	s.groups["web"].members["container_id"] = struct{}{}
+	err = d.DeleteNetworkGroup("web")
+	assert.Check(t, errdefs.IsConflict(err))
+
+	delete(s.groups["web"].members, "container_id")
+	assert.NilError(t, d.DeleteNetworkGroup("web"))
+	_, err = d.NetworkGroup("web")
+	assert.Check(t, errdefs.IsNotFound(err))
+	assert.Check(t, is.Len(d.NetworkGroups(), 0))
+}
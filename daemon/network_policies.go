@@ -0,0 +1,181 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/names"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/libnetwork"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/pkg/errors"
+)
+
+// networkPoliciesFile is the file, in the root of the daemon, in which the
+// network policies are persisted.
+const networkPoliciesFile = "network-policies.json"
+
+// networkPolicyStore holds the network policies of the daemon, by name. The
+// policies refer to their networks by name, so that they apply again to a
+// network which is removed and created again.
+type networkPolicyStore struct {
+	sync.Mutex
+	path     string
+	policies map[string]types.NetworkPolicy
+}
+
+func newNetworkPolicyStore(path string) (*networkPolicyStore, error) {
+	s := &networkPolicyStore{
+		path:     path,
+		policies: make(map[string]types.NetworkPolicy),
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var policies []types.NetworkPolicy
+	if err := json.Unmarshal(b, &policies); err != nil {
+		return nil, errors.Wrap(err, "failed to load the network policies")
+	}
+	for _, p := range policies {
+		s.policies[p.Name] = p
+	}
+	return s, nil
+}
+
+// list returns the policies sorted by name. The store must be locked.
+func (s *networkPolicyStore) list() []types.NetworkPolicy {
+	policies := make([]types.NetworkPolicy, 0, len(s.policies))
+	for _, p := range s.policies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies
+}
+
+// save persists the policies. The store must be locked.
+func (s *networkPolicyStore) save() error {
+	b, err := json.Marshal(s.list())
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(s.path, b, 0600)
+}
+
+func toLibnetworkPolicy(p types.NetworkPolicy) libnetwork.NetworkPolicy {
+	return libnetwork.NetworkPolicy{
+		Name:        p.Name,
+		Source:      p.Source,
+		Destination: p.Destination,
+		Action:      p.Action,
+		Protocol:    p.Protocol,
+		Ports:       p.Ports,
+	}
+}
+
+// applyNetworkPolicies programs the rules of the network policies in the
+// firewall. The store must not be locked.
+func (daemon *Daemon) applyNetworkPolicies() error {
+	s := daemon.networkPolicies
+	s.Lock()
+	defer s.Unlock()
+	return daemon.setNetworkPolicies(s.list())
+}
+
+func (daemon *Daemon) setNetworkPolicies(policies []types.NetworkPolicy) error {
+	if daemon.netController == nil {
+		return nil
+	}
+	list := make([]libnetwork.NetworkPolicy, 0, len(policies))
+	for _, p := range policies {
+		list = append(list, toLibnetworkPolicy(p))
+	}
+	return daemon.netController.SetNetworkPolicies(list)
+}
+
+// CreateNetworkPolicy creates a policy allowing or denying the traffic between
+// two bridge networks.
+func (daemon *Daemon) CreateNetworkPolicy(p types.NetworkPolicy) (types.NetworkPolicy, error) {
+	if p.Name != "" && !names.RestrictedNamePattern.MatchString(p.Name) {
+		return types.NetworkPolicy{}, errdefs.InvalidParameter(fmt.Errorf("invalid network policy name %q, only %s are allowed", p.Name, names.RestrictedNameChars))
+	}
+	lp := toLibnetworkPolicy(p)
+	if err := lp.Validate(); err != nil {
+		return types.NetworkPolicy{}, errdefs.InvalidParameter(err)
+	}
+	for _, name := range []*string{&p.Source, &p.Destination} {
+		n, err := daemon.FindNetwork(*name)
+		if err != nil {
+			return types.NetworkPolicy{}, err
+		}
+		if n.Type() != "bridge" {
+			return types.NetworkPolicy{}, errdefs.InvalidParameter(fmt.Errorf("network policies only apply to bridge networks, %s is a %s network", *name, n.Type()))
+		}
+		*name = n.Name()
+	}
+	if p.Source == p.Destination {
+		return types.NetworkPolicy{}, errdefs.InvalidParameter(fmt.Errorf("network policy %s cannot apply to the traffic within network %s", p.Name, p.Source))
+	}
+
+	s := daemon.networkPolicies
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.policies[p.Name]; ok {
+		return types.NetworkPolicy{}, errdefs.Conflict(fmt.Errorf("network policy %s already exists", p.Name))
+	}
+	s.policies[p.Name] = p
+	if err := s.save(); err != nil {
+		delete(s.policies, p.Name)
+		return types.NetworkPolicy{}, err
+	}
+	if err := daemon.setNetworkPolicies(s.list()); err != nil {
+		return types.NetworkPolicy{}, err
+	}
+	return p, nil
+}
+
+// NetworkPolicies returns the network policies of the daemon.
+func (daemon *Daemon) NetworkPolicies() []types.NetworkPolicy {
+	s := daemon.networkPolicies
+	s.Lock()
+	defer s.Unlock()
+	return s.list()
+}
+
+// NetworkPolicy returns the network policy with the given name.
+func (daemon *Daemon) NetworkPolicy(name string) (types.NetworkPolicy, error) {
+	s := daemon.networkPolicies
+	s.Lock()
+	defer s.Unlock()
+	p, ok := s.policies[name]
+	if !ok {
+		return types.NetworkPolicy{}, errdefs.NotFound(fmt.Errorf("network policy %s not found", name))
+	}
+	return p, nil
+}
+
+// DeleteNetworkPolicy removes the network policy with the given name, and its
+// rules from the firewall.
+func (daemon *Daemon) DeleteNetworkPolicy(name string) error {
+	s := daemon.networkPolicies
+	s.Lock()
+	defer s.Unlock()
+	p, ok := s.policies[name]
+	if !ok {
+		return errdefs.NotFound(fmt.Errorf("network policy %s not found", name))
+	}
+	delete(s.policies, name)
+	if err := s.save(); err != nil {
+		s.policies[name] = p
+		return err
+	}
+	return daemon.setNetworkPolicies(s.list())
+}
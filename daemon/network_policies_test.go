@@ -0,0 +1,58 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestCreateNetworkPolicyValidation(t *testing.T) {
+	d := &Daemon{networkPolicies: &networkPolicyStore{policies: make(map[string]types.NetworkPolicy)}}
+
+	for _, p := range []types.NetworkPolicy{
+		{Name: "bad/name", Source: "a", Destination: "b", Action: "allow"},
+		{Name: "p", Source: "a", Action: "allow"},
+		{Name: "p", Source: "a", Destination: "a", Action: "allow"},
+		{Name: "p", Source: "a", Destination: "b", Action: "reject"},
+		{Name: "p", Source: "a", Destination: "b", Action: "allow", Protocol: "icmp"},
+		{Name: "p", Source: "a", Destination: "b", Action: "allow", Ports: []string{"80"}},
+		{Name: "p", Source: "a", Destination: "b", Action: "allow", Protocol: "tcp", Ports: []string{"0"}},
+		{Name: "p", Source: "a", Destination: "b", Action: "allow", Protocol: "tcp", Ports: []string{"90-80"}},
+	} {
+		_, err := d.CreateNetworkPolicy(p)
+		assert.Check(t, errdefs.IsInvalidParameter(err), "%+v: %v", p, err)
+	}
+}
+
+func TestNetworkPolicyStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "network-policies")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, networkPoliciesFile)
+
+	s, err := newNetworkPolicyStore(path)
+	assert.NilError(t, err)
+	policy := types.NetworkPolicy{Name: "db", Source: "frontend", Destination: "backend", Action: "allow", Protocol: "tcp", Ports: []string{"5432"}}
+	s.policies[policy.Name] = policy
+	assert.NilError(t, s.save())
+
+	s, err = newNetworkPolicyStore(path)
+	assert.NilError(t, err)
+	d := &Daemon{networkPolicies: s}
+
+	p, err := d.NetworkPolicy("db")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(p, policy))
+
+	assert.NilError(t, d.DeleteNetworkPolicy("db"))
+	_, err = d.NetworkPolicy("db")
+	assert.Check(t, errdefs.IsNotFound(err))
+	assert.Check(t, errdefs.IsNotFound(d.DeleteNetworkPolicy("db")))
+	assert.Check(t, is.Len(d.NetworkPolicies(), 0))
+}
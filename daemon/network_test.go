@@ -0,0 +1,20 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestDNSTXTRecords(t *testing.T) {
+	labels := map[string]string{
+		"com.docker.network.dns.txt.version": "1.2.3",
+		"com.docker.network.dns.txt.env":     "production",
+		"com.docker.network.dns.txt.long":    strings.Repeat("a", 255),
+		"com.example.label":                  "ignored",
+	}
+	assert.Check(t, is.DeepEqual(dnsTXTRecords(labels), []string{"env=production", "version=1.2.3"}))
+	assert.Check(t, is.Len(dnsTXTRecords(nil), 0))
+}
@@ -246,7 +246,7 @@ func setNamespaces(daemon *Daemon, s *specs.Spec, c *container.Container) error
 				nsUser.Path = fmt.Sprintf("/proc/%d/ns/user", nc.State.GetPID())
 				setNamespace(s, nsUser)
 			}
-		} else if c.HostConfig.NetworkMode.IsHost() {
+		} else if c.HostConfig.NetworkMode.IsHost() || c.HostConfig.NetworkMode.IsGroup() {
 			ns.Path = c.NetworkSettings.SandboxKey
 		}
 		setNamespace(s, ns)
@@ -711,6 +711,7 @@ func (daemon *Daemon) createSpec(c *container.Container) (retSpec *specs.Spec, e
 		return nil, fmt.Errorf("linux runtime spec resources: %v", err)
 	}
 	s.Linux.Sysctl = c.HostConfig.Sysctls
+	s.Annotations = c.HostConfig.Annotations
 
 	p := s.Linux.CgroupsPath
 	if useSystemd {
@@ -808,6 +809,9 @@ func (daemon *Daemon) createSpec(c *container.Container) (retSpec *specs.Spec, e
 			}
 		}
 	}
+	if err := setHooks(daemon, &s, c.Config.Labels); err != nil {
+		return nil, err
+	}
 
 	if apparmor.IsEnabled() {
 		var appArmorProfile string
@@ -829,6 +833,12 @@ func (daemon *Daemon) createSpec(c *container.Container) (retSpec *specs.Spec, e
 			if err := ensureDefaultAppArmorProfile(); err != nil {
 				return nil, err
 			}
+		} else if daemon.apparmorTemplate != nil && appArmorProfile == containerAppArmorProfile(c) {
+			// the profile is rendered again on each start, the mounts and
+			// the capabilities of the container being able to change
+			if err := daemon.installContainerAppArmorProfile(c, &s); err != nil {
+				return nil, err
+			}
 		}
 
 		s.Process.ApparmorProfile = appArmorProfile
@@ -42,6 +42,7 @@ func (daemon *Daemon) createSpec(c *container.Container) (*specs.Spec, error) {
 
 	// In base spec
 	s.Hostname = c.FullHostname()
+	s.Annotations = c.HostConfig.Annotations
 
 	if err := daemon.setupSecretDir(c); err != nil {
 		return nil, err
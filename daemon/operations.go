@@ -0,0 +1,13 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import "github.com/docker/docker/api/types"
+
+// Operations returns the running background operations of the daemon.
+func (daemon *Daemon) Operations() []types.Operation {
+	return daemon.operations.List()
+}
+
+// CancelOperation cancels a running background operation.
+func (daemon *Daemon) CancelOperation(id string) error {
+	return daemon.operations.Cancel(id)
+}
@@ -0,0 +1,272 @@
+// Package operations keeps track of the background operations of the daemon,
+// the operations which continue after their clients disconnected, for the
+// clients to attach to their progress again.
+package operations // import "github.com/docker/docker/daemon/operations"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/pkg/errors"
+)
+
+// Manager keeps track of the running background operations, indexed by their
+// IDs and keys.
+type Manager struct {
+	mu    sync.Mutex
+	ops   map[string]*Operation
+	byKey map[string]*Operation
+}
+
+// NewManager returns a new Manager.
+func NewManager() *Manager {
+	return &Manager{
+		ops:   make(map[string]*Operation),
+		byKey: make(map[string]*Operation),
+	}
+}
+
+// Start starts an operation running fn in the background, the progress
+// messages written by fn being kept for the clients attached to the
+// operation. The operation is identified by a key, which is the type and
+// the target of the operation by default: when an operation with the same key
+// is running, it is returned instead and the second return value is false.
+// The context of fn is canceled when the operation is canceled.
+func (m *Manager) Start(typ, target, key string, fn func(ctx context.Context, progress io.Writer) error) (*Operation, bool) {
+	if key == "" {
+		key = typ + " " + target
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if op, ok := m.byKey[key]; ok {
+		return op, false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &Operation{
+		id:      stringid.GenerateRandomID(),
+		typ:     typ,
+		target:  target,
+		created: time.Now().UTC(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		changed: make(chan struct{}),
+		byID:    make(map[string]*message),
+	}
+	m.ops[op.id] = op
+	m.byKey[key] = op
+	go func() {
+		err := fn(ctx, op)
+		cancel()
+
+		m.mu.Lock()
+		delete(m.ops, op.id)
+		delete(m.byKey, key)
+		m.mu.Unlock()
+
+		op.mu.Lock()
+		op.err = err
+		close(op.done)
+		op.mu.Unlock()
+	}()
+	return op, true
+}
+
+// List returns the running operations, the oldest first.
+func (m *Manager) List() []types.Operation {
+	m.mu.Lock()
+	ops := make([]*Operation, 0, len(m.ops))
+	for _, op := range m.ops {
+		ops = append(ops, op)
+	}
+	m.mu.Unlock()
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].created.Before(ops[j].created)
+	})
+
+	list := make([]types.Operation, 0, len(ops))
+	for _, op := range ops {
+		list = append(list, op.summary())
+	}
+	return list
+}
+
+// Cancel cancels a running operation, waiting for it to return.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	op, ok := m.ops[id]
+	m.mu.Unlock()
+	if !ok {
+		return errdefs.NotFound(errors.Errorf("no such operation: %s", id))
+	}
+	op.cancel()
+	<-op.done
+	return nil
+}
+
+// CancelAll cancels the running operations, waiting for them to return.
+func (m *Manager) CancelAll() {
+	m.mu.Lock()
+	ops := make([]*Operation, 0, len(m.ops))
+	for _, op := range m.ops {
+		ops = append(ops, op)
+	}
+	m.mu.Unlock()
+	for _, op := range ops {
+		op.cancel()
+	}
+	for _, op := range ops {
+		<-op.done
+	}
+}
+
+// message is a progress message of an operation, with the sequence number of
+// its last update.
+type message struct {
+	seq  uint64
+	data []byte
+}
+
+// Operation is a background operation. Its progress is written to it as
+// JSON messages, the messages with IDs replacing the previous messages with
+// the same IDs, for the clients attaching to the operation to receive the
+// current progress of the operation rather than all its updates.
+type Operation struct {
+	id      string
+	typ     string
+	target  string
+	created time.Time
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu       sync.Mutex
+	err      error
+	seq      uint64
+	messages []*message
+	byID     map[string]*message
+	status   string
+	attached int
+	// changed is closed, and replaced, when messages are written
+	changed chan struct{}
+}
+
+// ID returns the ID of the operation.
+func (op *Operation) ID() string {
+	return op.id
+}
+
+func (op *Operation) summary() types.Operation {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return types.Operation{
+		ID:       op.id,
+		Type:     op.typ,
+		Target:   op.target,
+		Created:  op.created,
+		Status:   op.status,
+		Attached: op.attached,
+	}
+}
+
+// Write writes progress messages to the operation, one JSON message per line.
+func (op *Operation) Write(p []byte) (int, error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var jm jsonmessage.JSONMessage
+		if err := json.Unmarshal(line, &jm); err != nil {
+			// the output which is not JSON is kept as is
+			jm = jsonmessage.JSONMessage{}
+		}
+		op.seq++
+		data := append([]byte(nil), line...)
+		if jm.ID == "" {
+			op.messages = append(op.messages, &message{seq: op.seq, data: data})
+			if jm.Status != "" {
+				op.status = jm.Status
+			}
+			continue
+		}
+		if m, ok := op.byID[jm.ID]; ok {
+			m.seq, m.data = op.seq, data
+			continue
+		}
+		m := &message{seq: op.seq, data: data}
+		op.byID[jm.ID] = m
+		op.messages = append(op.messages, m)
+	}
+	close(op.changed)
+	op.changed = make(chan struct{})
+	return len(p), nil
+}
+
+// updates returns the messages updated after seq, in the order of their
+// updates, and the channel closed when messages are written next.
+func (op *Operation) updates(seq uint64) ([]*message, uint64, <-chan struct{}) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	var updated []*message
+	for _, m := range op.messages {
+		if m.seq > seq {
+			updated = append(updated, &message{seq: m.seq, data: m.data})
+		}
+	}
+	sort.Slice(updated, func(i, j int) bool {
+		return updated[i].seq < updated[j].seq
+	})
+	return updated, op.seq, op.changed
+}
+
+// Attach writes the progress of the operation to w until the operation
+// returns, returning its error, or until ctx is done, in which case the
+// operation continues in the background and Attach returns nil. The current
+// progress of the operation is written first.
+func (op *Operation) Attach(ctx context.Context, w io.Writer) error {
+	op.mu.Lock()
+	op.attached++
+	op.mu.Unlock()
+	defer func() {
+		op.mu.Lock()
+		op.attached--
+		op.mu.Unlock()
+	}()
+
+	var seq uint64
+	for {
+		updated, last, changed := op.updates(seq)
+		for _, m := range updated {
+			if _, err := w.Write(m.data); err != nil {
+				// the client disconnected
+				return nil
+			}
+		}
+		seq = last
+
+		select {
+		case <-changed:
+		case <-op.done:
+			// the messages written before the operation returned
+			updated, _, _ := op.updates(seq)
+			for _, m := range updated {
+				if _, err := w.Write(m.data); err != nil {
+					return nil
+				}
+			}
+			return op.err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
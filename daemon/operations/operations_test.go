@@ -0,0 +1,89 @@
+package operations // import "github.com/docker/docker/daemon/operations"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestOperationAttach(t *testing.T) {
+	m := NewManager()
+	proceed := make(chan struct{})
+	op, started := m.Start("pull", "busybox:latest", "", func(ctx context.Context, progress io.Writer) error {
+		io.WriteString(progress, `{"status":"Pulling from library/busybox","id":"latest"}`+"\r\n")
+		io.WriteString(progress, `{"status":"Downloading","id":"layer1","progressDetail":{"current":1,"total":10}}`+"\r\n")
+		io.WriteString(progress, `{"status":"Downloading","id":"layer1","progressDetail":{"current":5,"total":10}}`+"\r\n")
+		<-proceed
+		io.WriteString(progress, `{"status":"Pull complete","id":"layer1"}`+"\r\n")
+		io.WriteString(progress, `{"status":"Status: Downloaded newer image for busybox:latest"}`+"\r\n")
+		return errors.New("pull failed")
+	})
+	assert.Assert(t, started)
+
+	// a client attaching again receives the current progress, and the
+	// operation continues when it detaches
+	ctx, cancel := context.WithCancel(context.Background())
+	var first bytes.Buffer
+	attached := make(chan error)
+	go func() {
+		attached <- op.Attach(ctx, &first)
+	}()
+	poll(t, func() bool { return m.List()[0].Attached == 1 })
+	cancel()
+	assert.NilError(t, <-attached)
+
+	same, started := m.Start("pull", "busybox:latest", "", nil)
+	assert.Check(t, !started)
+	assert.Check(t, is.Equal(same.ID(), op.ID()))
+
+	ops := m.List()
+	assert.Assert(t, is.Len(ops, 1))
+	assert.Check(t, is.Equal(ops[0].ID, op.ID()))
+	assert.Check(t, is.Equal(ops[0].Type, "pull"))
+	assert.Check(t, is.Equal(ops[0].Target, "busybox:latest"))
+
+	var second bytes.Buffer
+	go func() {
+		attached <- op.Attach(context.Background(), &second)
+	}()
+	poll(t, func() bool { return m.List()[0].Attached == 1 })
+	close(proceed)
+	assert.Check(t, is.Error(<-attached, "pull failed"))
+	assert.Check(t, is.Equal(second.String(), strings.Join([]string{
+		`{"status":"Pulling from library/busybox","id":"latest"}`,
+		`{"status":"Downloading","id":"layer1","progressDetail":{"current":5,"total":10}}`,
+		`{"status":"Pull complete","id":"layer1"}`,
+		`{"status":"Status: Downloaded newer image for busybox:latest"}`,
+		``}, "\r\n")))
+	assert.Check(t, is.Len(m.List(), 0))
+}
+
+func TestOperationCancel(t *testing.T) {
+	m := NewManager()
+	op, _ := m.Start("pull", "busybox:latest", "", func(ctx context.Context, progress io.Writer) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.NilError(t, m.Cancel(op.ID()))
+	assert.Check(t, is.Error(op.Attach(context.Background(), &bytes.Buffer{}), context.Canceled.Error()))
+	assert.Check(t, is.Len(m.List(), 0))
+	assert.Check(t, errdefs.IsNotFound(m.Cancel(op.ID())))
+}
+
+func poll(t *testing.T, f func() bool) {
+	for i := 0; i < 500; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timeout")
+}
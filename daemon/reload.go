@@ -3,9 +3,15 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/discovery"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,9 +28,14 @@ import (
 // - Insecure registries
 // - Registry mirrors
 // - Daemon live restore
+// - Default logging driver and options
+//
+// The report of the reload, listing the options that were reloaded and the
+// ones requiring a restart, is returned in the system info.
 func (daemon *Daemon) Reload(conf *config.Config) (err error) {
 	daemon.configStore.Lock()
 	attributes := map[string]string{}
+	restartRequired := daemon.restartRequiredOptions(conf)
 
 	defer func() {
 		jsonString, _ := json.Marshal(daemon.configStore)
@@ -33,12 +44,18 @@ func (daemon *Daemon) Reload(conf *config.Config) (err error) {
 		// LogDaemonEventWithAttributes() -> SystemInfo() -> GetAllRuntimes()
 		// holds that lock too.
 		daemon.configStore.Unlock()
+		daemon.recordReload(conf, restartRequired, err)
 		if err == nil {
 			logrus.Infof("Reloaded configuration: %s", jsonString)
 			daemon.LogDaemonEventWithAttributes("reload", attributes)
 		}
 	}()
 
+	// the log configuration is validated before the other options are
+	// reloaded, for an invalid configuration not to be partially applied
+	if err := daemon.reloadLogConfig(conf, attributes); err != nil {
+		return err
+	}
 	if err := daemon.reloadPlatform(conf, attributes); err != nil {
 		return err
 	}
@@ -333,3 +350,162 @@ func (daemon *Daemon) reloadFeatures(conf *config.Config, attributes map[string]
 	// prepare reload event attributes with updatable configurations
 	attributes["features"] = fmt.Sprintf("%v", daemon.configStore.Features)
 }
+
+// reloadLogConfig updates the default log configuration of the containers
+// with the log-driver and log-opts options, and updates the passed
+// attributes. The options of the previous driver are not kept when only the
+// driver changes.
+func (daemon *Daemon) reloadLogConfig(conf *config.Config, attributes map[string]string) error {
+	if conf.IsValueSet("log-driver") || conf.IsValueSet("log-opts") {
+		logConfig := daemon.defaultLogConfig
+		if conf.IsValueSet("log-driver") && conf.LogConfig.Type != logConfig.Type {
+			logConfig.Type = conf.LogConfig.Type
+			logConfig.Config = nil
+		}
+		if conf.IsValueSet("log-opts") {
+			logConfig.Config = conf.LogConfig.Config
+		}
+		if err := logger.ValidateLogOpts(logConfig.Type, logConfig.Config); err != nil {
+			return errors.Wrap(err, "failed to set log opts")
+		}
+		daemon.defaultLogConfig = logConfig
+		daemon.configStore.LogConfig = config.LogConfig{Type: logConfig.Type, Config: logConfig.Config}
+	}
+
+	// prepare reload event attributes with updatable configurations
+	attributes["log-driver"] = daemon.defaultLogConfig.Type
+	logOpts, err := json.Marshal(daemon.defaultLogConfig.Config)
+	if err != nil {
+		return err
+	}
+	attributes["log-opts"] = string(logOpts)
+	return nil
+}
+
+// reloadableOptions are the options of the configuration file which are
+// reloaded, by the daemon or by the API server.
+var reloadableOptions = map[string]bool{
+	"allow-nondistributable-artifacts": true,
+	"api-rate-burst":                   true,
+	"api-rate-limit":                   true,
+	"authorization-plugins":            true,
+	"cluster-advertise":                true,
+	"cluster-store":                    true,
+	"cluster-store-opts":               true,
+	"debug":                            true,
+	"default-ipc-mode":                 true,
+	"default-runtime":                  true,
+	"default-shm-size":                 true,
+	"features":                         true,
+	"insecure-registries":              true,
+	"labels":                           true,
+	"live-restore":                     true,
+	"log-driver":                       true,
+	"log-opts":                         true,
+	"max-concurrent-downloads":         true,
+	"max-concurrent-uploads":           true,
+	"metrics-addr":                     true,
+	"network-diagnostic-port":          true,
+	"registry-mirrors":                 true,
+	"runtimes":                         true,
+	"shutdown-timeout":                 true,
+	"tls-identities":                   true,
+}
+
+// restartRequiredOptions returns the options of the configuration file which
+// are not reloaded and whose values differ from the running configuration.
+func (daemon *Daemon) restartRequiredOptions(conf *config.Config) []string {
+	running := make(map[string]interface{})
+	if b, err := json.Marshal(daemon.configStore); err == nil {
+		json.Unmarshal(b, &running)
+	}
+	var options []string
+	for key, value := range conf.ValuesSet {
+		if reloadableOptions[key] {
+			continue
+		}
+		if current, ok := running[key]; ok {
+			if !reflect.DeepEqual(normalizeJSON(value), current) {
+				options = append(options, key)
+			}
+		} else if !isZeroJSON(normalizeJSON(value)) {
+			// the options omitted from the running configuration are empty
+			options = append(options, key)
+		}
+	}
+	sort.Strings(options)
+	return options
+}
+
+// normalizeJSON returns a value as it is decoded from its JSON encoding.
+func normalizeJSON(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
+// isZeroJSON returns whether a decoded JSON value is empty.
+func isZeroJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// recordReload records the report of a reload of the configuration.
+func (daemon *Daemon) recordReload(conf *config.Config, restartRequired []string, err error) {
+	report := &types.ConfigReload{
+		Time:            time.Now().Format(time.RFC3339Nano),
+		RestartRequired: restartRequired,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	} else {
+		for key := range conf.ValuesSet {
+			if reloadableOptions[key] {
+				report.Reloaded = append(report.Reloaded, key)
+			}
+		}
+		sort.Strings(report.Reloaded)
+	}
+	daemon.configReloadMu.Lock()
+	daemon.configReload = report
+	daemon.configReloadMu.Unlock()
+}
+
+// RecordReloadError records the error of a reload of the configuration which
+// failed before the daemon was reloaded, such as when the configuration file
+// is invalid, for it to be reported in the system info.
+func (daemon *Daemon) RecordReloadError(err error) {
+	daemon.configReloadMu.Lock()
+	daemon.configReload = &types.ConfigReload{
+		Time:  time.Now().Format(time.RFC3339Nano),
+		Error: err.Error(),
+	}
+	daemon.configReloadMu.Unlock()
+}
+
+// configReloadReport returns the report of the last reload of the
+// configuration, if any.
+func (daemon *Daemon) configReloadReport() *types.ConfigReload {
+	daemon.configReloadMu.Lock()
+	defer daemon.configReloadMu.Unlock()
+	return daemon.configReload
+}
@@ -1,18 +1,20 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"errors"
 	"os"
 	"reflect"
 	"sort"
 	"testing"
 	"time"
 
+	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/images"
+	"github.com/docker/docker/libnetwork"
 	"github.com/docker/docker/pkg/discovery"
 	_ "github.com/docker/docker/pkg/discovery/memory"
 	"github.com/docker/docker/registry"
-	"github.com/docker/libnetwork"
 	"gotest.tools/assert"
 	is "gotest.tools/assert/cmp"
 )
@@ -571,3 +573,82 @@ func TestDaemonReloadNetworkDiagnosticPort(t *testing.T) {
 	}
 
 }
+
+func TestDaemonReloadLogConfig(t *testing.T) {
+	daemon := &Daemon{
+		configStore:  &config.Config{},
+		imageService: images.NewImageService(images.ImageServiceConfig{}),
+		defaultLogConfig: containertypes.LogConfig{
+			Type:   "json-file",
+			Config: map[string]string{"max-size": "10m"},
+		},
+	}
+
+	reload := func(valuesSet map[string]interface{}, logConfig config.LogConfig) error {
+		return daemon.Reload(&config.Config{
+			CommonConfig: config.CommonConfig{
+				LogConfig: logConfig,
+				ValuesSet: valuesSet,
+			},
+		})
+	}
+
+	// the options of the driver are reloaded
+	err := reload(map[string]interface{}{"log-opts": map[string]interface{}{"max-size": "20m"}}, config.LogConfig{Config: map[string]string{"max-size": "20m"}})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(daemon.defaultLogConfig.Type, "json-file"))
+	assert.Check(t, is.DeepEqual(daemon.defaultLogConfig.Config, map[string]string{"max-size": "20m"}))
+
+	// the options of the previous driver are not kept
+	err = reload(map[string]interface{}{"log-driver": "local"}, config.LogConfig{Type: "local"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(daemon.defaultLogConfig.Type, "local"))
+	assert.Check(t, is.Len(daemon.defaultLogConfig.Config, 0))
+	assert.Check(t, is.Equal(daemon.configStore.LogConfig.Type, "local"))
+
+	// the invalid configurations are not applied
+	err = reload(map[string]interface{}{"log-driver": "nope", "labels": []interface{}{"foo=bar"}}, config.LogConfig{Type: "nope"})
+	assert.Check(t, is.ErrorContains(err, "no log driver named 'nope' is registered"))
+	assert.Check(t, is.Equal(daemon.defaultLogConfig.Type, "local"))
+	assert.Check(t, is.Len(daemon.configStore.Labels, 0))
+}
+
+func TestDaemonReloadReport(t *testing.T) {
+	daemon := &Daemon{
+		configStore: &config.Config{
+			CommonConfig: config.CommonConfig{
+				Root:     "/var/lib/docker",
+				ExecRoot: "/var/run/docker",
+			},
+		},
+		imageService: images.NewImageService(images.ImageServiceConfig{}),
+	}
+	assert.Check(t, is.Nil(daemon.configReloadReport()))
+
+	newConfig := &config.Config{
+		CommonConfig: config.CommonConfig{
+			Root:     "/srv/docker",
+			ExecRoot: "/var/run/docker",
+			Labels:   []string{"foo=bar"},
+			Debug:    true,
+			ValuesSet: map[string]interface{}{
+				"data-root":    "/srv/docker",
+				"exec-root":    "/var/run/docker",
+				"experimental": false,
+				"labels":       []interface{}{"foo=bar"},
+				"debug":        true,
+			},
+		},
+	}
+	assert.NilError(t, daemon.Reload(newConfig))
+	report := daemon.configReloadReport()
+	assert.Assert(t, report != nil)
+	assert.Check(t, is.Equal(report.Error, ""))
+	assert.Check(t, is.DeepEqual(report.Reloaded, []string{"debug", "labels"}))
+	assert.Check(t, is.DeepEqual(report.RestartRequired, []string{"data-root"}))
+
+	daemon.RecordReloadError(errors.New("invalid configuration"))
+	report = daemon.configReloadReport()
+	assert.Check(t, is.Equal(report.Error, "invalid configuration"))
+	assert.Check(t, is.Len(report.Reloaded, 0))
+}
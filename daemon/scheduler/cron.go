@@ -0,0 +1,199 @@
+// Package scheduler implements the parsing of the cron-like expressions used
+// to schedule the start and stop of containers.
+package scheduler // import "github.com/docker/docker/daemon/scheduler"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch is how far in the future Next looks for a matching time, so that
+// expressions which can never match (e.g. "0 0 30 2 *") terminate.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// day of week 7 is an alias for sunday
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Schedule is a parsed cron expression, made of the five standard fields:
+// minute, hour, day of month, month, and day of week.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are set if the day of month and day of week fields
+	// are "*". If both fields are restricted, a day matches if it matches
+	// either of them.
+	domAny, dowAny bool
+}
+
+// Parse parses a cron expression. Each field is either "*", a value, a range
+// ("1-5"), a step ("*/15", "0-30/10"), or a comma-separated list of those.
+// Months and days of week can be given by their three-letter names. The
+// "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", and
+// "@hourly" shorthands are also accepted.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := shorthands[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return &s, nil
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		b, err := f.parsePart(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %v", f.name, expr, err)
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+func (f field) parsePart(part string) (uint64, error) {
+	rng, step := part, 1
+	if i := strings.Index(part, "/"); i >= 0 {
+		var err error
+		rng = part[:i]
+		if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", part[i+1:])
+		}
+	}
+
+	start, end := f.min, f.max
+	switch {
+	case rng == "*":
+	case strings.Contains(rng, "-"):
+		bounds := strings.SplitN(rng, "-", 2)
+		var err error
+		if start, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if end, err = f.value(bounds[1]); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q", rng)
+		}
+	default:
+		v, err := f.value(rng)
+		if err != nil {
+			return 0, err
+		}
+		start = v
+		if step == 1 {
+			end = v
+		}
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time matching the schedule strictly after t, in the
+// location of t. It returns the zero time if no such time exists.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
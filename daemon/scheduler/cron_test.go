@@ -0,0 +1,62 @@
+package scheduler // import "github.com/docker/docker/daemon/scheduler"
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		spec        string
+		expectedErr string
+	}{
+		{spec: "", expectedErr: "expected 5 fields, got 0"},
+		{spec: "* * * *", expectedErr: "expected 5 fields, got 4"},
+		{spec: "60 * * * *", expectedErr: `invalid minute "60": value 60 out of range [0-59]`},
+		{spec: "* 24 * * *", expectedErr: `invalid hour "24"`},
+		{spec: "* * 0 * *", expectedErr: `invalid day of month "0"`},
+		{spec: "* * * foo * ", expectedErr: `invalid month "foo": invalid value "foo"`},
+		{spec: "* * * * 8", expectedErr: `invalid day of week "8"`},
+		{spec: "*/0 * * * *", expectedErr: `invalid step "0"`},
+		{spec: "5-1 * * * *", expectedErr: `invalid range "5-1"`},
+	}
+	for _, tc := range testCases {
+		_, err := Parse(tc.spec)
+		assert.Check(t, is.ErrorContains(err, tc.expectedErr), tc.spec)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2019, time.January, 16, 10, 30, 15, 0, time.UTC)
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2019, time.January, 16, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2019, time.January, 16, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 22 * * *", expected: time.Date(2019, time.January, 16, 22, 0, 0, 0, time.UTC)},
+		{spec: "0 6 * * *", expected: time.Date(2019, time.January, 17, 6, 0, 0, 0, time.UTC)},
+		{spec: "30 10 * * *", expected: time.Date(2019, time.January, 17, 10, 30, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * mon-fri", expected: time.Date(2019, time.January, 16, 11, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * sat,sun", expected: time.Date(2019, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expected: time.Date(2019, time.January, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 * *", expected: time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 feb *", expected: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// either the day of month or the day of week must match
+		{spec: "0 0 1 * fri", expected: time.Date(2019, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2019, time.January, 16, 11, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", expected: time.Date(2019, time.January, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "@yearly", expected: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", expected: time.Time{}},
+	}
+	for _, tc := range testCases {
+		s, err := Parse(tc.spec)
+		assert.NilError(t, err, tc.spec)
+		assert.Check(t, is.Equal(s.Next(now), tc.expected), tc.spec)
+	}
+}
@@ -0,0 +1,89 @@
+package scheduler // import "github.com/docker/docker/daemon/scheduler"
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/pkg/errors"
+)
+
+// Record describes the last scheduled action run on a container.
+type Record struct {
+	// Action is either "start" or "stop".
+	Action string
+	Time   time.Time
+	Error  string `json:",omitempty"`
+}
+
+// History stores the last scheduled action run on each container. The
+// records are persisted in a file, so that they survive daemon restarts.
+type History struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]Record
+}
+
+// NewHistory returns a history persisted at the given path, loading the
+// records already stored there.
+func NewHistory(path string) (*History, error) {
+	h := &History{path: path, records: make(map[string]Record)}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, errors.Wrap(err, "error reading schedule history")
+	}
+	if err := json.Unmarshal(b, &h.records); err != nil {
+		return nil, errors.Wrap(err, "error decoding schedule history")
+	}
+	return h, nil
+}
+
+// Get returns the last action run on the container.
+func (h *History) Get(containerID string) (Record, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.records[containerID]
+	return r, ok
+}
+
+// Set records the last action run on the container.
+func (h *History) Set(containerID string, r Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records[containerID] = r
+	return h.save()
+}
+
+// Prune removes the records of the containers for which keep returns false.
+func (h *History) Prune(keep func(containerID string) bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var pruned bool
+	for id := range h.records {
+		if !keep(id) {
+			delete(h.records, id)
+			pruned = true
+		}
+	}
+	if !pruned {
+		return nil
+	}
+	return h.save()
+}
+
+func (h *History) save() error {
+	b, err := json.Marshal(h.records)
+	if err != nil {
+		return err
+	}
+	if err := ioutils.AtomicWriteFile(h.path, b, 0600); err != nil {
+		return errors.Wrap(err, "error writing schedule history")
+	}
+	return nil
+}
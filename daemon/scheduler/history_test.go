@@ -0,0 +1,41 @@
+package scheduler // import "github.com/docker/docker/daemon/scheduler"
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/fs"
+)
+
+func TestHistory(t *testing.T) {
+	dir := fs.NewDir(t, "schedule-history")
+	defer dir.Remove()
+	path := filepath.Join(dir.Path(), "schedules.json")
+
+	h, err := NewHistory(path)
+	assert.NilError(t, err)
+	_, ok := h.Get("c1")
+	assert.Check(t, !ok)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NilError(t, h.Set("c1", Record{Action: "start", Time: now}))
+	assert.NilError(t, h.Set("c2", Record{Action: "stop", Time: now, Error: "failed"}))
+
+	// the records are persisted
+	h, err = NewHistory(path)
+	assert.NilError(t, err)
+	r, ok := h.Get("c2")
+	assert.Check(t, ok)
+	assert.Check(t, is.DeepEqual(r, Record{Action: "stop", Time: now, Error: "failed"}))
+
+	assert.NilError(t, h.Prune(func(id string) bool { return id == "c2" }))
+	h, err = NewHistory(path)
+	assert.NilError(t, err)
+	_, ok = h.Get("c1")
+	assert.Check(t, !ok)
+	_, ok = h.Get("c2")
+	assert.Check(t, ok)
+}
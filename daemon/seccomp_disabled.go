@@ -17,3 +17,7 @@ func setSeccomp(daemon *Daemon, rs *specs.Spec, c *container.Container) error {
 	}
 	return nil
 }
+
+func resolveSeccompProfile(path string, b []byte) ([]byte, error) {
+	return b, nil
+}
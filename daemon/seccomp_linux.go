@@ -4,6 +4,7 @@ package daemon // import "github.com/docker/docker/daemon"
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/profiles/seccomp"
@@ -53,3 +54,9 @@ func setSeccomp(daemon *Daemon, rs *specs.Spec, c *container.Container) error {
 	rs.Linux.Seccomp = profile
 	return nil
 }
+
+// resolveSeccompProfile validates the seccomp profile of the daemon, read
+// from path, and merges its includes.
+func resolveSeccompProfile(path string, b []byte) ([]byte, error) {
+	return seccomp.ResolveProfile(b, filepath.Dir(path))
+}
@@ -3,3 +3,7 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 var supportsSeccomp = false
+
+func resolveSeccompProfile(path string, b []byte) ([]byte, error) {
+	return b, nil
+}
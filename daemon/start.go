@@ -10,12 +10,14 @@ import (
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/tracing"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 )
 
 // ContainerStart starts a container.
-func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.HostConfig, checkpoint string, checkpointDir string) error {
+func (daemon *Daemon) ContainerStart(ctx context.Context, name string, hostConfig *containertypes.HostConfig, checkpoint string, checkpointDir string) error {
 	if checkpoint != "" && !daemon.HasExperimental() {
 		return errdefs.InvalidParameter(errors.New("checkpoint is only supported in experimental mode"))
 	}
@@ -92,15 +94,20 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.Hos
 			return errdefs.InvalidParameter(err)
 		}
 	}
-	return daemon.containerStart(container, checkpoint, checkpointDir, true)
+	return daemon.containerStart(ctx, container, checkpoint, checkpointDir, true)
 }
 
 // containerStart prepares the container to run by setting up everything the
 // container needs, such as storage and networking, as well as links
 // between containers. The container is left waiting for a signal to
-// begin running.
-func (daemon *Daemon) containerStart(container *container.Container, checkpoint string, checkpointDir string, resetRestartManager bool) (err error) {
+// begin running. The steps of the start are traced as the children of the
+// span of ctx.
+func (daemon *Daemon) containerStart(ctx context.Context, container *container.Container, checkpoint string, checkpointDir string, resetRestartManager bool) (err error) {
 	start := time.Now()
+	ctx, span := trace.StartSpan(ctx, "daemon.containerStart")
+	span.AddAttributes(trace.StringAttribute("container.id", container.ID))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	container.Lock()
 	defer container.Unlock()
 
@@ -147,11 +154,16 @@ func (daemon *Daemon) containerStart(container *container.Container, checkpoint
 		return err
 	}
 
-	if err := daemon.initializeNetworking(container); err != nil {
+	_, step := trace.StartSpan(ctx, "daemon.initializeNetworking")
+	err = daemon.initializeNetworking(container)
+	tracing.EndSpan(step, err)
+	if err != nil {
 		return err
 	}
 
+	_, step = trace.StartSpan(ctx, "daemon.createSpec")
 	spec, err := daemon.createSpec(container)
+	tracing.EndSpan(step, err)
 	if err != nil {
 		return errdefs.System(err)
 	}
@@ -176,15 +188,19 @@ func (daemon *Daemon) containerStart(container *container.Container, checkpoint
 		return err
 	}
 
+	_, step = trace.StartSpan(ctx, "containerd.Create", trace.WithSpanKind(trace.SpanKindClient))
 	err = daemon.containerd.Create(context.Background(), container.ID, spec, createOptions)
+	tracing.EndSpan(step, err)
 	if err != nil {
 		return translateContainerdStartErr(container.Path, container.SetExitCode, err)
 	}
 
 	// TODO(mlaventure): we need to specify checkpoint options here
+	_, step = trace.StartSpan(ctx, "containerd.Start", trace.WithSpanKind(trace.SpanKindClient))
 	pid, err := daemon.containerd.Start(context.Background(), container.ID, checkpointDir,
 		container.StreamConfig.Stdin() != nil || container.Config.Tty,
 		container.InitializeStdio)
+	tracing.EndSpan(step, err)
 	if err != nil {
 		if err := daemon.containerd.Delete(context.Background(), container.ID); err != nil {
 			logrus.WithError(err).WithField("container", container.ID).
@@ -232,6 +248,8 @@ func (daemon *Daemon) Cleanup(container *container.Container) {
 		logrus.Warnf("%s cleanup: failed to unmount secrets: %s", container.ID, err)
 	}
 
+	daemon.removeContainerAppArmorProfile(container)
+
 	if err := mount.RecursiveUnmount(container.Root); err != nil {
 		logrus.WithError(err).WithField("container", container.ID).Warn("Error while cleaning up container resource mounts.")
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/api/types/versions/v1p20"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/pkg/ioutils"
 )
 
@@ -144,6 +145,22 @@ func (daemon *Daemon) GetContainerStats(container *container.Container) (*types.
 		return nil, err
 	}
 
+	container.Lock()
+	copier, logDriver := container.LogCopier, container.LogDriver
+	container.Unlock()
+	if copier != nil && copier.Limiter() != nil {
+		rateLimited, sampledOut := copier.Limiter().Dropped()
+		stats.LogStats = &types.LogStats{RateLimitedLines: rateLimited, SampledOutLines: sampledOut}
+	}
+	if ringStats, ok := logger.GetRingStats(logDriver); ok {
+		if stats.LogStats == nil {
+			stats.LogStats = &types.LogStats{}
+		}
+		stats.LogStats.DroppedMessages = ringStats.Dropped
+		stats.LogStats.BufferHighWatermark = ringStats.HighWatermark
+		stats.LogStats.BufferSize = ringStats.Size
+	}
+
 	// We already have the network stats on Windows directly from HCS.
 	if !container.Config.NetworkDisabled && runtime.GOOS != "windows" {
 		if stats.Networks, err = daemon.getNetworkStats(container); err != nil {
@@ -0,0 +1,39 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"golang.org/x/sys/unix"
+)
+
+// getTmpfsStats returns the usage of the tmpfs mounts of the container, as
+// seen from the mount namespace of its process.
+func getTmpfsStats(c *container.Container) map[string]types.TmpfsStats {
+	c.Lock()
+	mounts, err := c.TmpfsMounts()
+	pid := c.Pid
+	c.Unlock()
+	if err != nil || len(mounts) == 0 || pid == 0 {
+		return nil
+	}
+
+	root := filepath.Join("/proc", strconv.Itoa(pid), "root")
+	stats := make(map[string]types.TmpfsStats, len(mounts))
+	for _, m := range mounts {
+		var st unix.Statfs_t
+		// the destination is only reported while a tmpfs is mounted on it,
+		// and not the filesystem it may have been replaced by
+		if err := unix.Statfs(filepath.Join(root, m.Destination), &st); err != nil || st.Type != unix.TMPFS_MAGIC {
+			continue
+		}
+		stats[m.Destination] = types.TmpfsStats{
+			Usage:  (st.Blocks - st.Bfree) * uint64(st.Bsize),
+			Limit:  st.Blocks * uint64(st.Bsize),
+			Inodes: st.Files - st.Ffree,
+		}
+	}
+	return stats
+}
@@ -38,10 +38,11 @@ func (daemon *Daemon) getNetworkStats(c *container.Container) (map[string]types.
 		return nil, err
 	}
 
+	endpoints := sb.InterfaceEndpoints()
 	stats := make(map[string]types.NetworkStats)
 	// Convert libnetwork nw stats into api stats
 	for ifName, ifStats := range lnstats {
-		stats[ifName] = types.NetworkStats{
+		s := types.NetworkStats{
 			RxBytes:   ifStats.RxBytes,
 			RxPackets: ifStats.RxPackets,
 			RxErrors:  ifStats.RxErrors,
@@ -51,6 +52,13 @@ func (daemon *Daemon) getNetworkStats(c *container.Container) (map[string]types.
 			TxErrors:  ifStats.TxErrors,
 			TxDropped: ifStats.TxDropped,
 		}
+		if ep, ok := endpoints[ifName]; ok {
+			s.EndpointID = ep.ID()
+			if n, err := daemon.netController.NetworkByName(ep.Network()); err == nil {
+				s.NetworkID = n.ID()
+			}
+		}
+		stats[ifName] = s
 	}
 
 	return stats, nil
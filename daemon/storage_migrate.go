@@ -0,0 +1,144 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	containerderrors "github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/snapshots"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// errStorageMigrateRunning is returned when a storage migration is requested
+// while another one is running.
+var errStorageMigrateRunning = errdefs.Conflict(errors.New("a storage migration is already running"))
+
+// MigrateStorage migrates the images, and the writable layers of the
+// containers which are not running, from the layer stores of the daemon to a
+// containerd snapshotter, in the namespace of the containers of the daemon.
+// The daemon keeps running, the running containers being skipped so they can
+// be migrated once stopped, by migrating again: the images and containers
+// which are already migrated are kept.
+func (daemon *Daemon) MigrateStorage(ctx context.Context, options types.StorageMigrateOptions) (*types.StorageMigrateReport, error) {
+	if daemon.containerdCli == nil {
+		return nil, errdefs.NotImplemented(errors.New("the storage cannot be migrated without containerd"))
+	}
+	if !atomic.CompareAndSwapInt32(&daemon.storageMigrateRunning, 0, 1) {
+		return nil, errStorageMigrateRunning
+	}
+	defer atomic.StoreInt32(&daemon.storageMigrateRunning, 0)
+
+	snapshotter := options.Snapshotter
+	if snapshotter == "" {
+		snapshotter = containerd.DefaultSnapshotter
+	}
+	ctx = namespaces.WithNamespace(ctx, ContainersNamespace)
+	// the content written for the containers is only referenced by the
+	// lease until it is applied
+	ctx, done, err := daemon.containerdCli.WithLease(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating a lease for the storage migration")
+	}
+	defer done(context.Background())
+
+	report := &types.StorageMigrateReport{Snapshotter: snapshotter}
+	if report.Images, err = daemon.imageService.MigrateImages(ctx, daemon.containerdCli, snapshotter); err != nil {
+		return nil, err
+	}
+	for _, c := range daemon.List() {
+		if err := daemon.migrateContainer(ctx, c, snapshotter); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if report.Skipped == nil {
+				report.Skipped = make(map[string]string)
+			}
+			report.Skipped[c.ID] = err.Error()
+			continue
+		}
+		report.Containers = append(report.Containers, c.ID)
+	}
+	return report, nil
+}
+
+// migrateContainer applies the writable layer of the container to an active
+// snapshot named by the ID of the container, on the snapshot of its image.
+// The container is locked while it is migrated, for it not to be started.
+func (daemon *Daemon) migrateContainer(ctx context.Context, c *container.Container, snapshotter string) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.Running {
+		return errors.New("the container is running")
+	}
+	if c.RWLayer == nil {
+		return errors.New("the container has no writable layer")
+	}
+
+	sn := daemon.containerdCli.SnapshotService(snapshotter)
+	if _, err := sn.Stat(ctx, c.ID); err == nil {
+		return nil
+	}
+	img, err := daemon.imageService.GetImage(c.ImageID.String())
+	if err != nil {
+		return err
+	}
+
+	desc, err := writeContainerDiff(ctx, daemon.containerdCli.ContentStore(), c)
+	if err != nil {
+		return errors.Wrap(err, "error copying the writable layer of the container")
+	}
+	mounts, err := sn.Prepare(ctx, c.ID, img.RootFS.ChainID().String(), snapshots.WithLabels(map[string]string{
+		"containerd.io/gc.root": time.Now().UTC().Format(time.RFC3339),
+	}))
+	if err != nil {
+		return errors.Wrap(err, "error preparing the snapshot of the container")
+	}
+	if _, err := daemon.containerdCli.DiffService().Apply(ctx, desc, mounts); err != nil {
+		if err := sn.Remove(ctx, c.ID); err != nil {
+			logrus.WithError(err).WithField("container", c.ID).Warn("error removing the snapshot of the container")
+		}
+		return errors.Wrap(err, "error applying the writable layer of the container")
+	}
+	logrus.WithField("container", c.ID).WithField("snapshotter", snapshotter).Debug("migrated container")
+	return nil
+}
+
+// writeContainerDiff writes the changes of the writable layer of the
+// container to the content store.
+func writeContainerDiff(ctx context.Context, cs content.Store, c *container.Container) (ocispec.Descriptor, error) {
+	ts, err := c.RWLayer.TarStream()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer ts.Close()
+
+	cw, err := content.OpenWriter(ctx, cs, content.WithRef("migrate-"+c.ID))
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer cw.Close()
+	// a previous attempt may have been interrupted
+	if err := cw.Truncate(0); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	size, err := io.Copy(cw, ts)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{MediaType: schema2.MediaTypeUncompressedLayer, Digest: cw.Digest(), Size: size}
+	if err := cw.Commit(ctx, size, desc.Digest); err != nil && !containerderrors.IsAlreadyExists(err) {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
@@ -0,0 +1,515 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/user"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// clockTicks is the number of clock ticks per second used by the kernel in
+// /proc (USER_HZ), which is 100 on all architectures supported by Linux.
+const clockTicks = 100
+
+// procInfo holds the information about a process read from /proc.
+type procInfo struct {
+	pid        int
+	ppid       int
+	comm       string
+	cmdline    string
+	state      string
+	ttyNr      int
+	cpuTicks   uint64
+	startTicks uint64
+	vsize      uint64
+	rss        int64
+	numThreads int
+	nice       int
+	uid        int
+	userName   string
+}
+
+// procContext holds the values shared by all the processes listed by
+// a single call to "docker top".
+type procContext struct {
+	bootTime time.Time
+	now      time.Time
+}
+
+// psColumn is a column of the procfs-based "docker top".
+type psColumn struct {
+	title string
+	value func(ctx procContext, p *procInfo) string
+}
+
+var (
+	psUIDColumn   = psColumn{"UID", func(_ procContext, p *procInfo) string { return strconv.Itoa(p.uid) }}
+	psUserColumn  = psColumn{"USER", func(_ procContext, p *procInfo) string { return p.userName }}
+	psPIDColumn   = psColumn{"PID", func(_ procContext, p *procInfo) string { return strconv.Itoa(p.pid) }}
+	psPPIDColumn  = psColumn{"PPID", func(_ procContext, p *procInfo) string { return strconv.Itoa(p.ppid) }}
+	psCColumn     = psColumn{"C", func(ctx procContext, p *procInfo) string { return strconv.Itoa(int(p.cpuPercent(ctx))) }}
+	psPCPUColumn  = psColumn{"%CPU", func(ctx procContext, p *procInfo) string { return strconv.FormatFloat(p.cpuPercent(ctx), 'f', 1, 64) }}
+	psSTimeColumn = psColumn{"STIME", func(ctx procContext, p *procInfo) string { return formatSTime(ctx, p.startTime(ctx)) }}
+	psTTYColumn   = psColumn{"TTY", func(_ procContext, p *procInfo) string { return formatTTY(p.ttyNr) }}
+	psTimeColumn  = psColumn{"TIME", func(_ procContext, p *procInfo) string { return formatCPUTime(p.cpuTicks / clockTicks) }}
+	psCmdColumn   = psColumn{"CMD", func(_ procContext, p *procInfo) string { return p.cmdline }}
+	psArgsColumn  = psColumn{"COMMAND", func(_ procContext, p *procInfo) string { return p.cmdline }}
+	psCommColumn  = psColumn{"COMMAND", func(_ procContext, p *procInfo) string { return p.comm }}
+	psStatColumn  = psColumn{"STAT", func(_ procContext, p *procInfo) string { return p.state }}
+	psRSSColumn   = psColumn{"RSS", func(_ procContext, p *procInfo) string {
+		return strconv.FormatInt(p.rss*int64(os.Getpagesize())/1024, 10)
+	}}
+	psVSZColumn  = psColumn{"VSZ", func(_ procContext, p *procInfo) string { return strconv.FormatUint(p.vsize/1024, 10) }}
+	psNLWPColumn = psColumn{"NLWP", func(_ procContext, p *procInfo) string { return strconv.Itoa(p.numThreads) }}
+	psNiceColumn = psColumn{"NI", func(_ procContext, p *procInfo) string { return strconv.Itoa(p.nice) }}
+
+	// psColumns are the columns that can be selected with "-o", by their
+	// ps(1) format specifier.
+	psColumns = map[string]psColumn{
+		"uid":        psUIDColumn,
+		"euid":       psUIDColumn,
+		"user":       psUserColumn,
+		"euser":      psUserColumn,
+		"pid":        psPIDColumn,
+		"ppid":       psPPIDColumn,
+		"c":          psCColumn,
+		"pcpu":       psPCPUColumn,
+		"%cpu":       psPCPUColumn,
+		"stime":      psSTimeColumn,
+		"start_time": psSTimeColumn,
+		"tty":        psTTYColumn,
+		"tt":         psTTYColumn,
+		"time":       psTimeColumn,
+		"cputime":    psTimeColumn,
+		"cmd":        psCmdColumn,
+		"args":       psArgsColumn,
+		"command":    psArgsColumn,
+		"comm":       psCommColumn,
+		"ucomm":      psCommColumn,
+		"stat":       psStatColumn,
+		"s":          psStatColumn,
+		"rss":        psRSSColumn,
+		"rssize":     psRSSColumn,
+		"vsz":        psVSZColumn,
+		"vsize":      psVSZColumn,
+		"nlwp":       psNLWPColumn,
+		"thcount":    psNLWPColumn,
+		"ni":         psNiceColumn,
+		"nice":       psNiceColumn,
+	}
+
+	// psDefaultColumns are the columns of "ps -e".
+	psDefaultColumns = []psColumn{psPIDColumn, psTTYColumn, psTimeColumn, psCmdColumn}
+
+	// psFullColumns are the columns of "ps -ef".
+	psFullColumns = []psColumn{
+		{"UID", psUserColumn.value}, psPIDColumn, psPPIDColumn, psCColumn, psSTimeColumn, psTTYColumn, psTimeColumn, psCmdColumn,
+	}
+)
+
+// parsePSColumns returns the columns selected by the ps arguments, and
+// false if the arguments cannot be handled without running ps(1). The
+// supported arguments are "-e", "-A", "-f", and "-o" with any of the
+// format specifiers in psColumns.
+func parsePSColumns(psArgs string) ([]psColumn, bool) {
+	var (
+		full    bool
+		columns []psColumn
+	)
+	fields := fieldsASCII(psArgs)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if len(f) < 2 || f[0] != '-' {
+			return nil, false
+		}
+	options:
+		for j := 1; j < len(f); j++ {
+			switch f[j] {
+			case 'e', 'A':
+				// all the processes of the container are always listed
+			case 'f':
+				full = true
+			case 'o':
+				spec := f[j+1:]
+				if spec == "" {
+					i++
+					if i == len(fields) {
+						return nil, false
+					}
+					spec = fields[i]
+				}
+				cols, ok := parsePSColumnSpec(spec)
+				if !ok {
+					return nil, false
+				}
+				columns = append(columns, cols...)
+				break options
+			default:
+				return nil, false
+			}
+		}
+	}
+	switch {
+	case len(columns) > 0:
+		return columns, true
+	case full:
+		return psFullColumns, true
+	default:
+		return psDefaultColumns, true
+	}
+}
+
+// parsePSColumnSpec parses a ps(1) format specification such as
+// "pid,comm=NAME". As with ps(1), a header following "=" extends to
+// the end of the specification.
+func parsePSColumnSpec(spec string) ([]psColumn, bool) {
+	var columns []psColumn
+	for spec != "" {
+		var name string
+		if i := strings.IndexAny(spec, ",="); i == -1 {
+			name, spec = spec, ""
+		} else if spec[i] == ',' {
+			name, spec = spec[:i], spec[i+1:]
+			if name == "" {
+				continue
+			}
+		} else {
+			c, ok := psColumns[strings.ToLower(spec[:i])]
+			if !ok {
+				return nil, false
+			}
+			return append(columns, psColumn{title: spec[i+1:], value: c.value}), true
+		}
+		c, ok := psColumns[strings.ToLower(name)]
+		if !ok {
+			return nil, false
+		}
+		columns = append(columns, c)
+	}
+	return columns, len(columns) > 0
+}
+
+// topProcfs lists the processes of the container by reading /proc. It
+// returns false if the ps arguments require running ps(1).
+func (daemon *Daemon) topProcfs(c *container.Container, psArgs string) (*containertypes.ContainerTopOKBody, bool, error) {
+	columns, ok := parsePSColumns(psArgs)
+	if !ok {
+		return nil, false, nil
+	}
+
+	pids, err := cgroupPids(c.GetPID())
+	if err != nil {
+		logrus.WithError(err).WithField("container", c.ID).Debug("failed to read container cgroup, falling back to containerd")
+		procs, err := daemon.containerd.ListPids(context.Background(), c.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		pids = make([]int, len(procs))
+		for i, p := range procs {
+			pids[i] = int(p)
+		}
+	}
+	sort.Ints(pids)
+
+	bootTime, err := readBootTime()
+	if err != nil {
+		return nil, true, err
+	}
+	ctx := procContext{bootTime: bootTime, now: time.Now()}
+	userNames := containerUserNames(c)
+
+	procList := &containertypes.ContainerTopOKBody{Processes: [][]string{}}
+	for _, col := range columns {
+		procList.Titles = append(procList.Titles, col.title)
+	}
+	for _, pid := range pids {
+		p, err := readProcInfo(pid, daemon.idMapping)
+		if err != nil {
+			// the process exited after the cgroup was read
+			if os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
+			return nil, true, err
+		}
+		if name, ok := userNames[p.uid]; ok {
+			p.userName = name
+		} else {
+			p.userName = strconv.Itoa(p.uid)
+		}
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			row[i] = col.value(ctx, p)
+		}
+		procList.Processes = append(procList.Processes, row)
+	}
+	return procList, true, nil
+}
+
+// cgroupPids returns the pids of the processes in the cgroup of the
+// given process, including its sub-cgroups.
+func cgroupPids(pid int) ([]int, error) {
+	dir, _, err := cgroupDir(pid, "pids", "memory", "cpu")
+	if err != nil {
+		return nil, err
+	}
+	return cgroups.GetAllPids(dir)
+}
+
+// cgroupDir returns the path of the cgroup of the given process on the
+// unified hierarchy (cgroup v2), or in the hierarchy of the first subsystem
+// found (cgroup v1). unified reports whether the cgroup v2 path is returned.
+func cgroupDir(pid int, subsystems ...string) (dir string, unified bool, err error) {
+	cgs, err := cgroups.ParseCgroupFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", false, err
+	}
+
+	if p, ok := cgs[""]; ok && len(cgs) == 1 {
+		// cgroup v2 unified hierarchy
+		return filepath.Join("/sys/fs/cgroup", p), true, nil
+	}
+	for _, subsystem := range subsystems {
+		p, ok := cgs[subsystem]
+		if !ok {
+			continue
+		}
+		mnt, root, err := cgroups.FindCgroupMountpointAndRoot(subsystem)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			continue
+		}
+		return filepath.Join(mnt, rel), false, nil
+	}
+	return "", false, errors.Errorf("no cgroup found for process %d", pid)
+}
+
+// readBootTime returns the boot time of the host from /proc/stat.
+func readBootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) == 2 && fields[0] == "btime" {
+			secs, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, errors.Wrap(err, "invalid btime in /proc/stat")
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, errors.New("btime not found in /proc/stat")
+}
+
+// readProcInfo reads the information of a process from /proc. The uid of
+// the process is translated to the user namespace of the container.
+func readProcInfo(pid int, idMapping *idtools.IdentityMapping) (*procInfo, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	stat, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p, err := parseProcStat(stat)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid stat for process %d", pid)
+	}
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p.cmdline = string(bytes.TrimRight(bytes.Replace(cmdline, []byte{0}, []byte{' '}, -1), " "))
+	if p.cmdline == "" {
+		p.cmdline = "[" + p.comm + "]"
+	}
+
+	uid, gid, err := readProcIDs(filepath.Join(dir, "status"))
+	if err != nil {
+		return nil, err
+	}
+	p.uid = uid
+	if idMapping != nil && !idMapping.Empty() {
+		if cuid, _, err := idMapping.ToContainer(idtools.Identity{UID: uid, GID: gid}); err == nil {
+			p.uid = cuid
+		}
+	}
+	return p, nil
+}
+
+// parseProcStat parses the content of /proc/<pid>/stat, as documented
+// in proc(5).
+func parseProcStat(stat []byte) (*procInfo, error) {
+	s := string(stat)
+	start, end := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+	if start == -1 || end < start {
+		return nil, errors.New("missing command name")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(s[:start]))
+	if err != nil {
+		return nil, err
+	}
+	// fields following the command name, starting with the state (3)
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 22 {
+		return nil, errors.Errorf("expected at least 22 fields after command name, got %d", len(fields))
+	}
+
+	p := &procInfo{
+		pid:   pid,
+		comm:  s[start+1 : end],
+		state: fields[0],
+	}
+	ints := make([]int64, len(fields))
+	for _, i := range []int{1, 4, 11, 12, 16, 17, 19, 20, 21} {
+		if ints[i], err = strconv.ParseInt(fields[i], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	p.ppid = int(ints[1])
+	p.ttyNr = int(ints[4])
+	p.cpuTicks = uint64(ints[11] + ints[12])
+	p.nice = int(ints[16])
+	p.numThreads = int(ints[17])
+	p.startTicks = uint64(ints[19])
+	p.vsize = uint64(ints[20])
+	p.rss = ints[21]
+	return p, nil
+}
+
+// readProcIDs returns the effective uid and gid from /proc/<pid>/status.
+func readProcIDs(path string) (uid, gid int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	uid, gid = -1, -1
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[0] {
+		case "Uid:":
+			uid, err = strconv.Atoi(fields[2])
+		case "Gid:":
+			gid, err = strconv.Atoi(fields[2])
+		}
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "invalid %s", path)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, 0, err
+	}
+	if uid == -1 || gid == -1 {
+		return 0, 0, errors.Errorf("uid or gid not found in %s", path)
+	}
+	return uid, gid, nil
+}
+
+// containerUserNames returns the user names by uid from the /etc/passwd
+// file of the container.
+func containerUserNames(c *container.Container) map[int]string {
+	names := make(map[int]string)
+	passwdPath, err := user.GetPasswdPath()
+	if err != nil {
+		return names
+	}
+	passwd, err := readUserFile(c, passwdPath)
+	if err != nil {
+		return names
+	}
+	defer passwd.Close()
+
+	users, err := user.ParsePasswd(passwd)
+	if err != nil {
+		return names
+	}
+	for _, u := range users {
+		if _, ok := names[u.Uid]; !ok {
+			names[u.Uid] = u.Name
+		}
+	}
+	return names
+}
+
+func (p *procInfo) startTime(ctx procContext) time.Time {
+	return ctx.bootTime.Add(time.Duration(p.startTicks) * time.Second / clockTicks)
+}
+
+// cpuPercent returns the CPU utilization of the process over its lifetime.
+func (p *procInfo) cpuPercent(ctx procContext) float64 {
+	elapsed := ctx.now.Sub(p.startTime(ctx)).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.cpuTicks) / clockTicks * 100 / elapsed
+}
+
+// formatSTime formats the start time of a process as ps(1) does.
+func formatSTime(ctx procContext, t time.Time) string {
+	switch {
+	case ctx.now.Sub(t) < 24*time.Hour:
+		return t.Format("15:04")
+	case ctx.now.Year() == t.Year():
+		return t.Format("Jan02")
+	default:
+		return t.Format("2006")
+	}
+}
+
+// formatCPUTime formats a cumulative CPU time as ps(1) does.
+func formatCPUTime(secs uint64) string {
+	days, secs := secs/86400, secs%86400
+	s := fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	if days > 0 {
+		s = fmt.Sprintf("%d-%s", days, s)
+	}
+	return s
+}
+
+// formatTTY returns the name of the controlling terminal of a process
+// from the tty_nr field of /proc/<pid>/stat.
+func formatTTY(ttyNr int) string {
+	major := (ttyNr >> 8) & 0xfff
+	minor := (ttyNr & 0xff) | ((ttyNr >> 12) & 0xfff00)
+	switch {
+	case ttyNr == 0:
+		return "?"
+	case major >= 136 && major <= 143:
+		return fmt.Sprintf("pts/%d", (major-136)*256+minor)
+	case major == 4 && minor < 64:
+		return fmt.Sprintf("tty%d", minor)
+	case major == 4:
+		return fmt.Sprintf("ttyS%d", minor-64)
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,81 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestParsePSColumns(t *testing.T) {
+	tests := []struct {
+		psArgs string
+		titles []string
+		ok     bool
+	}{
+		{psArgs: "-ef", titles: []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"}, ok: true},
+		{psArgs: "-e -f", titles: []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"}, ok: true},
+		{psArgs: "-A", titles: []string{"PID", "TTY", "TIME", "CMD"}, ok: true},
+		{psArgs: "-o pid,comm", titles: []string{"PID", "COMMAND"}, ok: true},
+		{psArgs: "-eo pid,user,rss", titles: []string{"PID", "USER", "RSS"}, ok: true},
+		{psArgs: "-opid -o vsz", titles: []string{"PID", "VSZ"}, ok: true},
+		{psArgs: "-o pid,comm=NAME,WITH,COMMAS", titles: []string{"PID", "NAME,WITH,COMMAS"}, ok: true},
+		{psArgs: "-o pid=", titles: []string{""}, ok: true},
+		{psArgs: "aux"},
+		{psArgs: "-ef --forest"},
+		{psArgs: "-o pid,unknown"},
+		{psArgs: "-o"},
+	}
+	for _, tc := range tests {
+		columns, ok := parsePSColumns(tc.psArgs)
+		assert.Check(t, is.Equal(ok, tc.ok), tc.psArgs)
+		var titles []string
+		for _, c := range columns {
+			titles = append(titles, c.title)
+		}
+		assert.Check(t, is.DeepEqual(titles, tc.titles), tc.psArgs)
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	stat := "1234 (my (weird) cmd) S 1 1234 1234 34816 1234 4194560 1000 0 0 0 150 50 0 0 20 0 3 0 5000 10485760 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0\n"
+	p, err := parseProcStat([]byte(stat))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(p.pid, 1234))
+	assert.Check(t, is.Equal(p.comm, "my (weird) cmd"))
+	assert.Check(t, is.Equal(p.state, "S"))
+	assert.Check(t, is.Equal(p.ppid, 1))
+	assert.Check(t, is.Equal(formatTTY(p.ttyNr), "pts/0"))
+	assert.Check(t, is.Equal(p.cpuTicks, uint64(200)))
+	assert.Check(t, is.Equal(p.numThreads, 3))
+	assert.Check(t, is.Equal(p.startTicks, uint64(5000)))
+	assert.Check(t, is.Equal(p.vsize, uint64(10485760)))
+	assert.Check(t, is.Equal(p.rss, int64(256)))
+
+	_, err = parseProcStat([]byte("1234 (cmd) S 1"))
+	assert.Check(t, is.ErrorContains(err, "expected at least 22 fields"))
+}
+
+func TestFormatTTY(t *testing.T) {
+	assert.Check(t, is.Equal(formatTTY(0), "?"))
+	assert.Check(t, is.Equal(formatTTY(136<<8|3), "pts/3"))
+	assert.Check(t, is.Equal(formatTTY(137<<8|1), "pts/257"))
+	assert.Check(t, is.Equal(formatTTY(4<<8|1), "tty1"))
+	assert.Check(t, is.Equal(formatTTY(4<<8|65), "ttyS1"))
+}
+
+func TestFormatCPUTime(t *testing.T) {
+	assert.Check(t, is.Equal(formatCPUTime(0), "00:00:00"))
+	assert.Check(t, is.Equal(formatCPUTime(3723), "01:02:03"))
+	assert.Check(t, is.Equal(formatCPUTime(90061), "1-01:01:01"))
+}
+
+func TestReadProcInfo(t *testing.T) {
+	p, err := readProcInfo(os.Getpid(), nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(p.pid, os.Getpid()))
+	assert.Check(t, is.Equal(p.ppid, os.Getppid()))
+	assert.Check(t, is.Equal(p.uid, os.Geteuid()))
+	assert.Check(t, p.cmdline != "")
+}
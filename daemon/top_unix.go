@@ -131,10 +131,11 @@ func psPidsArg(pids []uint32) string {
 }
 
 // ContainerTop lists the processes running inside of the given
-// container by calling ps with the given args, or with the flags
-// "-ef" if no args are given.  An error is returned if the container
-// is not found, or is not running, or if there are any problems
-// running ps, or parsing the output.
+// container with the given ps args, or with the flags "-ef" if no
+// args are given. The processes are read from /proc when the args
+// are supported, and listed by calling ps otherwise. An error is
+// returned if the container is not found, or is not running, or if
+// there are any problems running ps, or parsing the output.
 func (daemon *Daemon) ContainerTop(name string, psArgs string) (*container.ContainerTopOKBody, error) {
 	if psArgs == "" {
 		psArgs = "-ef"
@@ -157,6 +158,19 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*container.Conta
 		return nil, errContainerIsRestarting(container.ID)
 	}
 
+	procList, ok, err := daemon.topProcfs(container, psArgs)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		daemon.LogContainerEvent(container, "top")
+		return procList, nil
+	}
+
+	if _, err := exec.LookPath("ps"); err != nil {
+		return nil, errdefs.InvalidParameter(errors.Errorf("ps_args %q require ps to be installed on the host", psArgs))
+	}
+
 	procs, err := daemon.containerd.ListPids(context.Background(), container.ID)
 	if err != nil {
 		return nil, err
@@ -180,7 +194,7 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*container.Conta
 			return nil, errdefs.System(errors.Wrap(err, "ps"))
 		}
 	}
-	procList, err := parsePSOutput(output, procs)
+	procList, err = parsePSOutput(output, procs)
 	if err != nil {
 		return nil, err
 	}
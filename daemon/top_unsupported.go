@@ -0,0 +1,14 @@
+// +build !linux,!windows
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+// topProcfs is not supported on this platform, processes are always
+// listed by calling ps.
+func (daemon *Daemon) topProcfs(c *container.Container, psArgs string) (*containertypes.ContainerTopOKBody, bool, error) {
+	return nil, false, nil
+}
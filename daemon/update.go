@@ -83,6 +83,10 @@ func (daemon *Daemon) update(name string, hostConfig *container.HostConfig) erro
 			// TODO: it would be nice if containerd responded with better errors here so we can classify this better.
 			return errCannotUpdate(container.ID, errdefs.System(err))
 		}
+		if err := updateBlkioThrottle(container.GetPID(), hostConfig.Resources); err != nil {
+			restoreConfig = true
+			return errCannotUpdate(container.ID, errdefs.System(err))
+		}
 	}
 
 	daemon.LogContainerEvent(container, "update")
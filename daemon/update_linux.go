@@ -1,11 +1,18 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/docker/docker/api/types/blkiodev"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/libcontainerd"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
 )
 
 func toContainerdResources(resources container.Resources) *libcontainerd.Resources {
@@ -52,3 +59,98 @@ func toContainerdResources(resources container.Resources) *libcontainerd.Resourc
 
 	return &r
 }
+
+// blkioThrottle holds the throttle devices of a resource, along with the
+// corresponding cgroup v1 file and cgroup v2 io.max key.
+type blkioThrottle struct {
+	file    string
+	key     string
+	devices []specs.LinuxThrottleDevice
+}
+
+// updateBlkioThrottle applies the block IO throttling limits set in resources
+// to the cgroup of the process pid. The runtime only updates the blkio weight
+// of a running container, so the limits are written to the cgroup directly.
+func updateBlkioThrottle(pid int, resources container.Resources) error {
+	var throttles []blkioThrottle
+	for _, t := range []struct {
+		file, key string
+		devices   []*blkiodev.ThrottleDevice
+	}{
+		{"blkio.throttle.read_bps_device", "rbps", resources.BlkioDeviceReadBps},
+		{"blkio.throttle.write_bps_device", "wbps", resources.BlkioDeviceWriteBps},
+		{"blkio.throttle.read_iops_device", "riops", resources.BlkioDeviceReadIOps},
+		{"blkio.throttle.write_iops_device", "wiops", resources.BlkioDeviceWriteIOps},
+	} {
+		if len(t.devices) == 0 {
+			continue
+		}
+		devices, err := getBlkioThrottleDevices(t.devices)
+		if err != nil {
+			return err
+		}
+		throttles = append(throttles, blkioThrottle{file: t.file, key: t.key, devices: devices})
+	}
+	if len(throttles) == 0 {
+		return nil
+	}
+
+	dir, unified, err := cgroupDir(pid, "blkio")
+	if err != nil {
+		return err
+	}
+
+	if unified {
+		for _, line := range ioMaxLines(throttles) {
+			if err := writeCgroupFile(dir, "io.max", line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, t := range throttles {
+		for _, d := range t.devices {
+			// A rate of 0 removes the limit set on the device
+			if err := writeCgroupFile(dir, t.file, fmt.Sprintf("%d:%d %d", d.Major, d.Minor, d.Rate)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ioMaxLines returns the lines to write to the cgroup v2 io.max file to set
+// the given limits, one line per device. A rate of 0 removes the limit.
+func ioMaxLines(throttles []blkioThrottle) []string {
+	var (
+		order  []string
+		limits = make(map[string][]string)
+	)
+	for _, t := range throttles {
+		for _, d := range t.devices {
+			dev := fmt.Sprintf("%d:%d", d.Major, d.Minor)
+			if _, ok := limits[dev]; !ok {
+				order = append(order, dev)
+			}
+			value := "max"
+			if d.Rate != 0 {
+				value = strconv.FormatUint(d.Rate, 10)
+			}
+			limits[dev] = append(limits[dev], t.key+"="+value)
+		}
+	}
+
+	lines := make([]string, 0, len(order))
+	for _, dev := range order {
+		lines = append(lines, dev+" "+strings.Join(limits[dev], " "))
+	}
+	return lines
+}
+
+func writeCgroupFile(dir, file, data string) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(data), 0); err != nil {
+		return errors.Wrapf(err, "failed to write %q to %s", data, file)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestIOMaxLines(t *testing.T) {
+	throttleDevice := func(major, minor int64, rate uint64) specs.LinuxThrottleDevice {
+		d := specs.LinuxThrottleDevice{Rate: rate}
+		d.Major = major
+		d.Minor = minor
+		return d
+	}
+	throttles := []blkioThrottle{
+		{key: "rbps", devices: []specs.LinuxThrottleDevice{throttleDevice(8, 0, 1048576), throttleDevice(8, 16, 0)}},
+		{key: "wiops", devices: []specs.LinuxThrottleDevice{throttleDevice(8, 0, 100)}},
+	}
+	assert.Check(t, is.DeepEqual(ioMaxLines(throttles), []string{
+		"8:0 rbps=1048576 wiops=100",
+		"8:16 rbps=max",
+	}))
+	assert.Check(t, is.Len(ioMaxLines(nil), 0))
+}
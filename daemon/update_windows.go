@@ -9,3 +9,8 @@ func toContainerdResources(resources container.Resources) *libcontainerd.Resourc
 	// We don't support update, so do nothing
 	return nil
 }
+
+func updateBlkioThrottle(pid int, resources container.Resources) error {
+	// We don't support update, so do nothing
+	return nil
+}
@@ -141,6 +141,9 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 		if needsSlavePropagation {
 			bind.Propagation = mount.PropagationRSlave
 		}
+		if err := daemon.validateBindPropagation(bind); err != nil {
+			return err
+		}
 
 		// #10618
 		_, tmpfsExists := hostConfig.Tmpfs[bind.Destination]
@@ -180,6 +183,9 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 		if needsSlavePropagation {
 			mp.Propagation = mount.PropagationRSlave
 		}
+		if err := daemon.validateBindPropagation(mp); err != nil {
+			return err
+		}
 
 		if binds[mp.Destination] {
 			return duplicateMountPointError(cfg.Target)
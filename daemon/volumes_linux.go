@@ -1,11 +1,25 @@
 package daemon
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/container"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/idtools"
+	pkgmount "github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/volume"
+	volumemounts "github.com/docker/docker/volume/mounts"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
 )
 
 // validateBindDaemonRoot ensures that if a given mountpoint's source is within
@@ -25,6 +39,11 @@ func (daemon *Daemon) validateBindDaemonRoot(m mount.Mount) (bool, error) {
 	if m.BindOptions == nil {
 		return true, nil
 	}
+	if m.BindOptions.ReadOnlyRecursive {
+		// the recursively read-only mounts are private clones of the mounts
+		// of their source
+		return false, nil
+	}
 
 	switch m.BindOptions.Propagation {
 	case mount.PropagationRSlave, mount.PropagationRShared, "":
@@ -34,3 +53,229 @@ func (daemon *Daemon) validateBindDaemonRoot(m mount.Mount) (bool, error) {
 
 	return false, errdefs.InvalidParameter(errors.Errorf(`invalid mount config: must use either propagation mode "rslave" or "rshared" when mount source is within the daemon root, daemon root: %q, bind mount source: %q, propagation: %q`, daemon.root, m.Source, m.BindOptions.Propagation))
 }
+
+// validateBindPropagation checks that the propagation mode set for the bind
+// mount m is supported by the mount of its source, which must be shared for
+// the mounts to be propagated to the host, or either shared or slave for the
+// mounts of the host to be propagated to the container.
+func (daemon *Daemon) validateBindPropagation(m *volumemounts.MountPoint) error {
+	if m.Type != mount.TypeBind || m.Spec.BindOptions == nil || m.Spec.BindOptions.Propagation == "" {
+		return nil
+	}
+	// the sources which do not exist are created when the container starts
+	if _, err := os.Stat(m.Source); err != nil {
+		return nil
+	}
+
+	var err error
+	switch m.Spec.BindOptions.Propagation {
+	case mount.PropagationShared, mount.PropagationRShared:
+		err = ensureShared(m.Source)
+	case mount.PropagationSlave, mount.PropagationRSlave:
+		// the mounts from the daemon root fall back to a private propagation
+		if strings.HasPrefix(m.Source, daemon.root) || strings.HasPrefix(daemon.root, m.Source) {
+			return nil
+		}
+		err = ensureSharedOrSlave(m.Source)
+	}
+	if err != nil {
+		return errdefs.InvalidParameter(errors.Wrapf(err, "invalid propagation mode %s for bind mount %s", m.Spec.BindOptions.Propagation, m.Destination))
+	}
+	return nil
+}
+
+// readOnlyRecursiveSource returns the source to bind mount in the container c
+// for the mount point m, set up at path. The recursively read-only bind
+// mounts are mounted from a read-only clone of the mount of path, and of the
+// mounts below it, in the root of c.
+func (daemon *Daemon) readOnlyRecursiveSource(c *container.Container, m *volumemounts.MountPoint, path string) (string, error) {
+	if m.Spec.BindOptions == nil || !m.Spec.BindOptions.ReadOnlyRecursive {
+		return path, nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(c.Root, "rro", digest.FromString(m.Destination).Hex())
+	if err := fileutils.CreateIfNotExists(target, fi.IsDir()); err != nil {
+		return "", err
+	}
+	if err := pkgmount.RecursiveUnmount(target); err != nil {
+		return "", err
+	}
+	if err := pkgmount.CloneTree(path, target, &pkgmount.MountAttr{AttrSet: pkgmount.MountAttrRdonly}); err != nil {
+		if pkgmount.IsCloneTreeNotSupported(err) {
+			return "", errdefs.NotImplemented(errors.Wrap(err, "recursively read-only mounts require Linux 5.12"))
+		}
+		return "", err
+	}
+	return target, nil
+}
+
+// volumeSubpathSource returns the source to bind mount in the container c for
+// the mount point m of a subpath of a volume mounted at path. The symlinks of
+// the subpath are resolved within the volume, then it is opened one component
+// at a time without following symlinks, and bind mounted from its file
+// descriptor in the root of c, for a container using the volume not to make
+// it escape the volume by replacing one of its components with a symlink.
+func (daemon *Daemon) volumeSubpathSource(c *container.Container, m *volumemounts.MountPoint, path string) (string, error) {
+	if m.Volume == nil || m.Spec.VolumeOptions == nil || m.Spec.VolumeOptions.Subpath == "" {
+		return path, nil
+	}
+	subpath := m.Spec.VolumeOptions.Subpath
+
+	resolved, err := symlink.FollowSymlinkInScope(filepath.Join(path, subpath), path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(path, resolved)
+	if err != nil {
+		return "", err
+	}
+	f, err := openNoFollow(path, rel)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errdefs.InvalidParameter(errors.Errorf("subpath %s does not exist in volume %s", subpath, m.Name))
+		}
+		return "", errors.Wrapf(err, "error opening subpath %s of volume %s", subpath, m.Name)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(c.Root, "subpath", digest.FromString(m.Destination).Hex())
+	if err := fileutils.CreateIfNotExists(target, fi.IsDir()); err != nil {
+		return "", err
+	}
+	if err := pkgmount.RecursiveUnmount(target); err != nil {
+		return "", err
+	}
+	if err := pkgmount.Mount(fmt.Sprintf("/proc/self/fd/%d", f.Fd()), target, "none", "rbind"); err != nil {
+		return "", errors.Wrapf(err, "error mounting subpath %s of volume %s", subpath, m.Name)
+	}
+	return target, nil
+}
+
+// openNoFollow opens the path rel below root one component at a time, none
+// of them being allowed to be a symlink.
+func openNoFollow(root, rel string) (*os.File, error) {
+	fd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: root, Err: err}
+	}
+	p := root
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if name == "" || name == "." {
+			continue
+		}
+		p = filepath.Join(p, name)
+		next, err := unix.Openat(fd, name, unix.O_PATH|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		unix.Close(fd)
+		if err != nil {
+			return nil, &os.PathError{Op: "openat", Path: p, Err: err}
+		}
+		fd = next
+	}
+
+	f := os.NewFile(uintptr(fd), p)
+	// the last component is opened, and not followed, when it is a symlink
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		f.Close()
+		return nil, &os.PathError{Op: "openat", Path: p, Err: unix.ELOOP}
+	}
+	return f, nil
+}
+
+// idMappedSource returns the source to bind mount in the container c for the
+// mount point m, set up at path. With the ids of the containers remapped, the
+// bind mounts and the local volumes whose content is not owned by remapped
+// ids are mounted with their ids shifted, through an idmapped mount of path
+// in the root of c, for the container to see their owners without chowning
+// them. The files of bind mounts owned by the root of the host stay owned by
+// an unmapped id.
+func (daemon *Daemon) idMappedSource(c *container.Container, m *volumemounts.MountPoint, path string) (string, error) {
+	if daemon.idMapping.Empty() {
+		return path, nil
+	}
+	isBind := m.Type == mount.TypeBind && m.Volume == nil
+	if !isBind && (m.Volume == nil || m.Volume.DriverName() != volume.DefaultDriverName) {
+		return path, nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	if _, _, err := daemon.idMapping.ToContainer(idtools.Identity{UID: int(st.Uid), GID: int(st.Gid)}); err == nil {
+		// the content is already owned by remapped ids
+		return path, nil
+	}
+
+	userns, err := daemon.mountUserNamespace(isBind)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(c.Root, "idmapped", digest.FromString(m.Destination).Hex())
+	if err := fileutils.CreateIfNotExists(target, fi.IsDir()); err != nil {
+		return "", err
+	}
+	// the mount of a previous setup of the mounts of a container which is
+	// not running, as to copy files from it, is replaced
+	if err := pkgmount.Unmount(target); err != nil {
+		return "", err
+	}
+	if err := idtools.MountIDMapped(path, target, userns); err != nil {
+		if idtools.IsIDMappedMountNotSupported(err) {
+			logrus.WithError(err).WithField("container", c.ID).Debugf("mounting %s without shifting its ids", path)
+			return path, nil
+		}
+		return "", err
+	}
+	return target, nil
+}
+
+// mountUserNamespace returns the user namespace of the idmapped mounts of
+// the bind mounts, or of the volumes.
+func (daemon *Daemon) mountUserNamespace(isBind bool) (*os.File, error) {
+	daemon.usernsLock.Lock()
+	defer daemon.usernsLock.Unlock()
+
+	userns := &daemon.volumeUserns
+	mapping := daemon.idMapping
+	if isBind {
+		userns = &daemon.bindUserns
+		mapping = idtools.NewIDMappingsFromMaps(withoutRoot(mapping.UIDs()), withoutRoot(mapping.GIDs()))
+	}
+	if *userns == nil {
+		f, err := mapping.UserNamespace()
+		if err != nil {
+			return nil, err
+		}
+		*userns = f
+	}
+	return *userns, nil
+}
+
+// withoutRoot returns idMap without the mapping of the root of the container.
+func withoutRoot(idMap []idtools.IDMap) []idtools.IDMap {
+	var m []idtools.IDMap
+	for _, id := range idMap {
+		if id.ContainerID == 0 {
+			if id.Size == 1 {
+				continue
+			}
+			id.ContainerID, id.HostID, id.Size = 1, id.HostID+1, id.Size-1
+		}
+		m = append(m, id)
+	}
+	return m
+}
@@ -1,10 +1,19 @@
 package daemon
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	pkgmount "github.com/docker/docker/pkg/mount"
+	volumemounts "github.com/docker/docker/volume/mounts"
+	"github.com/docker/docker/volume/testutils"
 )
 
 func TestBindDaemonRoot(t *testing.T) {
@@ -54,3 +63,86 @@ func TestBindDaemonRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestWithoutRoot(t *testing.T) {
+	for _, test := range []struct {
+		idMap    []idtools.IDMap
+		expected []idtools.IDMap
+	}{
+		{
+			idMap:    []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			expected: []idtools.IDMap{{ContainerID: 1, HostID: 100001, Size: 65535}},
+		},
+		{
+			idMap:    []idtools.IDMap{{ContainerID: 0, HostID: 1000, Size: 1}, {ContainerID: 1, HostID: 100000, Size: 65535}},
+			expected: []idtools.IDMap{{ContainerID: 1, HostID: 100000, Size: 65535}},
+		},
+	} {
+		if m := withoutRoot(test.idMap); !reflect.DeepEqual(m, test.expected) {
+			t.Errorf("expected %v for %v, got %v", test.expected, test.idMap, m)
+		}
+	}
+}
+
+func TestVolumeSubpathSource(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("root required")
+	}
+	tmp, err := ioutil.TempDir("", "docker-volume-subpath-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	volumePath := filepath.Join(tmp, "volume")
+	for _, dir := range []string{filepath.Join(volumePath, "app1"), filepath.Join(tmp, "outside"), filepath.Join(tmp, "container")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(volumePath, "app1", "data"), []byte("app1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, "outside", "data"), []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// resolved within the volume, to /outside below the volume
+	if err := os.Symlink("../outside", filepath.Join(volumePath, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Daemon{}
+	c := &container.Container{Root: filepath.Join(tmp, "container")}
+	subpathSource := func(subpath string) (string, error) {
+		m := &volumemounts.MountPoint{
+			Destination: "/data",
+			Name:        "test",
+			Volume:      testutils.NewFakeVolume("test", "fake"),
+			Spec:        mount.Mount{Type: mount.TypeVolume, VolumeOptions: &mount.VolumeOptions{Subpath: subpath}},
+		}
+		return d.volumeSubpathSource(c, m, volumePath)
+	}
+
+	source, err := subpathSource("app1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkgmount.RecursiveUnmount(source)
+	if data, err := ioutil.ReadFile(filepath.Join(source, "data")); err != nil || string(data) != "app1" {
+		t.Fatalf("expected the subpath to be mounted, got %q, %v", data, err)
+	}
+
+	if _, err := subpathSource("escape"); !errdefs.IsInvalidParameter(err) {
+		t.Fatalf("expected a symlink escaping the volume to resolve within it, got %v", err)
+	}
+	if _, err := subpathSource("missing"); !errdefs.IsInvalidParameter(err) {
+		t.Fatalf("expected an invalid parameter error for a missing subpath, got %v", err)
+	}
+	// as if the subpath was replaced with a symlink once resolved
+	for _, rel := range []string{"escape", filepath.Join("escape", "data")} {
+		if f, err := openNoFollow(volumePath, rel); err == nil {
+			f.Close()
+			t.Fatalf("expected %s not to be opened through a symlink", rel)
+		}
+	}
+}
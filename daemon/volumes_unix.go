@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	mounttypes "github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/mount"
@@ -51,6 +52,15 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 		if err != nil {
 			return nil, err
 		}
+		if path, err = daemon.volumeSubpathSource(c, m, path); err != nil {
+			return nil, err
+		}
+		if path, err = daemon.idMappedSource(c, m, path); err != nil {
+			return nil, err
+		}
+		if path, err = daemon.readOnlyRecursiveSource(c, m, path); err != nil {
+			return nil, err
+		}
 		if !c.TrySetNetworkMount(m.Destination, path) {
 			mnt := container.Mount{
 				Source:      path,
@@ -103,7 +113,7 @@ func sortMounts(m []container.Mount) []container.Mount {
 // shared mode is set to 'z' if it is null. This is called in the case
 // of processing a named volume and not a typical bind.
 func setBindModeIfNull(bind *volumemounts.MountPoint) {
-	if bind.Mode == "" {
+	if bind.Mode == "" && bind.Spec.Relabel != mounttypes.RelabelNone {
 		bind.Mode = "z"
 	}
 }
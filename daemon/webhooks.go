@@ -0,0 +1,176 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/config"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// webhookQueueSize is the number of events waiting to be posted to a
+	// webhook above which the new events are dropped.
+	webhookQueueSize = 256
+	// defaultWebhookRetryInterval is the time before the first retry of the
+	// failed posts of the events.
+	defaultWebhookRetryInterval = time.Second
+	// webhookTimeout is the timeout of the posts of the events.
+	webhookTimeout = 10 * time.Second
+	// webhookStopTimeout is the time the webhooks have to post the queued
+	// events when the daemon shuts down.
+	webhookStopTimeout = 5 * time.Second
+)
+
+// webhook posts the events matching its filter to its URL.
+type webhook struct {
+	conf          config.WebhookConfig
+	retryInterval time.Duration
+	client        *http.Client
+
+	events   *daemonevents.Events
+	listener chan interface{}
+	queue    chan events.Message
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// startWebhook starts posting the events of e matching the filter of the
+// webhook to its URL.
+func startWebhook(e *daemonevents.Events, conf config.WebhookConfig) *webhook {
+	w := &webhook{
+		conf:          conf,
+		retryInterval: defaultWebhookRetryInterval,
+		client:        &http.Client{Timeout: webhookTimeout},
+		events:        e,
+		queue:         make(chan events.Message, webhookQueueSize),
+		done:          make(chan struct{}),
+	}
+	if conf.RetryInterval != "" {
+		// the interval has been validated with the configuration
+		w.retryInterval, _ = time.ParseDuration(conf.RetryInterval)
+	}
+	_, w.listener = e.SubscribeTopic(time.Time{}, time.Time{}, daemonevents.NewFilter(conf.Filter))
+
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(context.Background())
+	go w.forward()
+	go w.run(ctx)
+	return w
+}
+
+// forward queues the events of the listener of the webhook, the listener
+// having little time to receive each event.
+func (w *webhook) forward() {
+	defer close(w.queue)
+	for ev := range w.listener {
+		select {
+		case w.queue <- ev.(events.Message):
+		default:
+			logrus.WithField("url", w.conf.URL).Warn("Too many events waiting to be posted to the webhook, dropping event")
+		}
+	}
+}
+
+func (w *webhook) run(ctx context.Context) {
+	defer close(w.done)
+	for ev := range w.queue {
+		if err := w.post(ctx, ev); err != nil {
+			logrus.WithError(err).WithField("url", w.conf.URL).WithField("event", ev.Action).Error("Error posting event to webhook")
+		}
+	}
+}
+
+// post posts an event, retrying the failed posts as configured.
+func (w *webhook) post(ctx context.Context, ev events.Message) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	interval := w.retryInterval
+	for retries := 0; ; retries++ {
+		retry, err := w.send(ctx, body)
+		if err == nil || !retry || retries == w.conf.MaxRetries {
+			return err
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return err
+		}
+		interval *= 2
+	}
+}
+
+// send sends a post of an event, returning whether it may be retried when
+// it fails.
+func (w *webhook) send(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.conf.Secret != "" {
+		req.Header.Set("X-Docker-Signature", signWebhookBody(w.conf.Secret, body))
+	}
+	resp, err := w.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	// the requests the webhook rejects are not retried, except when it is
+	// overloaded
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook responded with status %s", resp.Status)
+}
+
+// signWebhookBody returns the signature of the body of a post, the hex HMAC
+// SHA-256 of the body prefixed with "sha256=".
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// stop stops the webhook, the queued events being posted until the stop
+// timeout expires.
+func (w *webhook) stop() {
+	w.events.Evict(w.listener)
+	select {
+	case <-w.done:
+	case <-time.After(webhookStopTimeout):
+		w.cancel()
+		<-w.done
+	}
+	w.cancel()
+}
+
+// startWebhooks starts the webhooks of the daemon.
+func (daemon *Daemon) startWebhooks(webhooks []config.WebhookConfig) {
+	for _, conf := range webhooks {
+		daemon.webhooks = append(daemon.webhooks, startWebhook(daemon.EventsService, conf))
+	}
+}
+
+// stopWebhooks stops the webhooks of the daemon.
+func (daemon *Daemon) stopWebhooks() {
+	for _, w := range daemon.webhooks {
+		w.stop()
+	}
+	daemon.webhooks = nil
+}
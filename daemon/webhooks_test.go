@@ -0,0 +1,89 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/daemon/config"
+	daemonevents "github.com/docker/docker/daemon/events"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestWebhook(t *testing.T) {
+	posts := make(chan events.Message, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var ev events.Message
+		if err := json.Unmarshal(body, &ev); err != nil {
+			t.Error(err)
+		}
+		assert.Check(t, is.Equal(r.Header.Get("X-Docker-Signature"), signWebhookBody("s3cr3t", body)))
+		posts <- ev
+	}))
+	defer ts.Close()
+
+	e := daemonevents.New()
+	w := startWebhook(e, config.WebhookConfig{
+		URL:    ts.URL,
+		Filter: filters.NewArgs(filters.Arg("event", "oom"), filters.Arg("event", "health_status")),
+		Secret: "s3cr3t",
+	})
+	defer w.stop()
+
+	actor := events.Actor{ID: "c1"}
+	e.Log("start", events.ContainerEventType, actor)
+	e.Log("oom", events.ContainerEventType, actor)
+	e.Log("die", events.ContainerEventType, actor)
+	e.Log("health_status: unhealthy", events.ContainerEventType, actor)
+
+	for _, action := range []string{"oom", "health_status: unhealthy"} {
+		select {
+		case ev := <-posts:
+			assert.Check(t, is.Equal(ev.Action, action))
+			assert.Check(t, is.Equal(ev.Actor.ID, "c1"))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the %s event was not posted", action)
+		}
+	}
+	select {
+	case ev := <-posts:
+		t.Fatalf("unexpected event posted: %s", ev.Action)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		statuses []int
+		expected int32
+	}{
+		{doc: "succeeds after retries", statuses: []int{503, 500, 200}, expected: 3},
+		{doc: "too many retries", statuses: []int{503, 503, 503, 200}, expected: 3},
+		{doc: "overloaded", statuses: []int{429, 200}, expected: 2},
+		{doc: "rejected", statuses: []int{400, 200}, expected: 1},
+	}
+	for _, tc := range testCases {
+		var attempts int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(tc.statuses[n-1])
+		}))
+
+		e := daemonevents.New()
+		w := startWebhook(e, config.WebhookConfig{URL: ts.URL, MaxRetries: 2, RetryInterval: "1ms"})
+		e.Log("oom", events.ContainerEventType, events.Actor{ID: "c1"})
+		// the queued events are posted when the webhook stops
+		w.stop()
+		ts.Close()
+		assert.Check(t, is.Equal(atomic.LoadInt32(&attempts), tc.expected), tc.doc)
+	}
+}
@@ -0,0 +1,82 @@
+package metadata // import "github.com/docker/docker/distribution/metadata"
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// ImageSourceService maps image IDs to the repositories the images were
+// pulled from.
+type ImageSourceService struct {
+	store Store
+}
+
+// NewImageSourceService creates a new image ID to source repositories mapping
+// service.
+func NewImageSourceService(store Store) *ImageSourceService {
+	return &ImageSourceService{
+		store: store,
+	}
+}
+
+// namespace returns the namespace used by this service.
+func (serv *ImageSourceService) namespace() string {
+	return "sources-by-imageid"
+}
+
+func (serv *ImageSourceService) key(imageID digest.Digest) string {
+	return string(imageID.Algorithm()) + "/" + imageID.Hex()
+}
+
+// Get returns the repositories the image was pulled from.
+func (serv *ImageSourceService) Get(imageID digest.Digest) ([]string, error) {
+	if serv.store == nil {
+		return nil, errors.New("no image source storage")
+	}
+	jsonBytes, err := serv.store.Get(serv.namespace(), serv.key(imageID))
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	if err := json.Unmarshal(jsonBytes, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// Add records that the image was pulled from the repository.
+func (serv *ImageSourceService) Add(imageID digest.Digest, repository string) error {
+	if serv.store == nil {
+		return nil
+	}
+	sources, err := serv.Get(imageID)
+	if err != nil {
+		sources = nil
+	}
+	for _, s := range sources {
+		if s == repository {
+			return nil
+		}
+	}
+
+	jsonBytes, err := json.Marshal(append(sources, repository))
+	if err != nil {
+		return err
+	}
+	return serv.store.Set(serv.namespace(), serv.key(imageID), jsonBytes)
+}
+
+// Delete forgets the repositories the image was pulled from.
+func (serv *ImageSourceService) Delete(imageID digest.Digest) error {
+	if serv.store == nil {
+		return nil
+	}
+	if err := serv.store.Delete(serv.namespace(), serv.key(imageID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package metadata // import "github.com/docker/docker/distribution/metadata"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestImageSourceService(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "image-source-service-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	metadataStore, err := NewFSMetadataStore(tmpDir)
+	assert.NilError(t, err)
+	sourceService := NewImageSourceService(metadataStore)
+
+	imageID := digest.Digest("sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4")
+	_, err = sourceService.Get(imageID)
+	assert.Check(t, err != nil, "expected error looking up nonexistent entry")
+
+	assert.NilError(t, sourceService.Add(imageID, "registry.example.com/web"))
+	assert.NilError(t, sourceService.Add(imageID, "docker.io/library/web"))
+	assert.NilError(t, sourceService.Add(imageID, "registry.example.com/web"))
+	sources, err := sourceService.Get(imageID)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(sources, []string{"registry.example.com/web", "docker.io/library/web"}))
+
+	assert.NilError(t, sourceService.Delete(imageID))
+	_, err = sourceService.Get(imageID)
+	assert.Check(t, err != nil, "expected error looking up deleted entry")
+	assert.NilError(t, sourceService.Delete(imageID))
+}
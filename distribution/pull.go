@@ -8,12 +8,14 @@ import (
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/tracing"
 	refstore "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 )
 
 // Puller is an interface that abstracts pulling for different API versions.
@@ -115,7 +117,7 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 			continue
 		}
 
-		if err := puller.Pull(ctx, ref, imagePullConfig.Platform); err != nil {
+		if err := pullFromEndpoint(ctx, puller, ref, endpoint, imagePullConfig.Platform); err != nil {
 			// Was this pull cancelled? If so, don't try to fall
 			// back.
 			fallback := false
@@ -161,6 +163,18 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 	return TranslatePullError(lastErr, ref)
 }
 
+// pullFromEndpoint pulls the image referenced by ref with the puller of the
+// endpoint, tracing the attempt.
+func pullFromEndpoint(ctx context.Context, puller Puller, ref reference.Named, endpoint registry.APIEndpoint, platform *specs.Platform) (err error) {
+	ctx, span := trace.StartSpan(ctx, "distribution.pullEndpoint", trace.WithSpanKind(trace.SpanKindClient))
+	span.AddAttributes(
+		trace.StringAttribute("registry.endpoint", endpoint.URL.String()),
+		trace.StringAttribute("registry.version", endpoint.Version.String()),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+	return puller.Pull(ctx, ref, platform)
+}
+
 // writeStatus writes a status message to out. If layersDownloaded is true, the
 // status message indicates that a newer image was downloaded. Otherwise, it
 // indicates that the image is up to date. requestedTag is the tag the message
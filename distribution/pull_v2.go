@@ -29,12 +29,14 @@ import (
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/pkg/tracing"
 	refstore "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 )
 
 var (
@@ -412,6 +414,10 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named, platform
 
 	progress.Message(p.config.ProgressOutput, "", "Digest: "+manifestDigest.String())
 
+	if err := metadata.NewImageSourceService(p.config.MetadataStore).Add(id, p.repoInfo.Name.Name()); err != nil {
+		return false, err
+	}
+
 	if p.config.ReferenceStore != nil {
 		oldTagID, err := p.config.ReferenceStore.Get(ref)
 		if err == nil {
@@ -527,7 +533,9 @@ func (p *v2Puller) pullSchema1(ctx context.Context, ref reference.Reference, unv
 		return "", "", fmt.Errorf("cannot download image with operating system %q when requesting %q", configOS, requestedOS)
 	}
 
-	resultRootFS, release, err := p.config.DownloadManager.Download(ctx, *rootFS, configOS, descriptors, p.config.ProgressOutput)
+	downloadCtx, span := startDownloadSpan(ctx, descriptors)
+	resultRootFS, release, err := p.config.DownloadManager.Download(downloadCtx, *rootFS, configOS, descriptors, p.config.ProgressOutput)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return "", "", err
 	}
@@ -655,7 +663,9 @@ func (p *v2Puller) pullSchema2(ctx context.Context, ref reference.Named, mfst *s
 				rootFS image.RootFS
 			)
 			downloadRootFS := *image.NewRootFS()
-			rootFS, release, err = p.config.DownloadManager.Download(ctx, downloadRootFS, layerStoreOS, descriptors, p.config.ProgressOutput)
+			downloadCtx, span := startDownloadSpan(ctx, descriptors)
+			rootFS, release, err = p.config.DownloadManager.Download(downloadCtx, downloadRootFS, layerStoreOS, descriptors, p.config.ProgressOutput)
+			tracing.EndSpan(span, err)
 			if err != nil {
 				// Intentionally do not cancel the config download here
 				// as the error from config download (if there is one)
@@ -974,3 +984,11 @@ func toOCIPlatform(p manifestlist.PlatformSpec) specs.Platform {
 		OSVersion:    p.OSVersion,
 	}
 }
+
+// startDownloadSpan starts the span of the download of the layers of an
+// image.
+func startDownloadSpan(ctx context.Context, descriptors []xfer.DownloadDescriptor) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, "distribution.downloadLayers")
+	span.AddAttributes(trace.Int64Attribute("layers", int64(len(descriptors))))
+	return ctx, span
+}
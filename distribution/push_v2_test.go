@@ -487,6 +487,9 @@ func (s *mockReferenceStore) Delete(ref reference.Named) (bool, error) {
 func (s *mockReferenceStore) Get(ref reference.Named) (digest.Digest, error) {
 	return "", nil
 }
+func (s *mockReferenceStore) Apply(changes []refstore.Change) error {
+	return nil
+}
 
 func TestWhenEmptyAuthConfig(t *testing.T) {
 	for _, authInfo := range []struct {
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/docker/distribution"
 	"github.com/docker/docker/daemon/graphdriver"
@@ -37,6 +39,10 @@ type layerStore struct {
 	mounts map[string]*mountedLayer
 	mountL sync.Mutex
 	os     string
+
+	// driverRoot is the home of the graph driver, whose filesystem is
+	// reported in the metrics.
+	driverRoot string
 }
 
 // StoreOptions are the options used to create a new Store instance
@@ -67,7 +73,14 @@ func NewStoreFromOptions(options StoreOptions) (Store, error) {
 
 	root := fmt.Sprintf(options.MetadataStorePathTemplate, driver)
 
-	return newStoreFromGraphDriver(root, driver, options.OS)
+	s, err := newStoreFromGraphDriver(root, driver, options.OS)
+	if err != nil {
+		return nil, err
+	}
+	ls := s.(*layerStore)
+	ls.driverRoot = filepath.Join(options.Root, driver.String())
+	storeCtr.add(ls)
+	return ls, nil
 }
 
 // newStoreFromGraphDriver creates a new Store instance using the provided
@@ -273,6 +286,8 @@ func (ls *layerStore) Register(ts io.Reader, parent ChainID) (Layer, error) {
 }
 
 func (ls *layerStore) registerWithDescriptor(ts io.Reader, parent ChainID, descriptor distribution.Descriptor) (Layer, error) {
+	defer layerActions.WithValues("register").UpdateSince(time.Now())
+
 	// err is used to hold the error which will always trigger
 	// cleanup of creates sources but may not be an error returned
 	// to the caller (already exists).
@@ -461,6 +476,7 @@ func (ls *layerStore) releaseLayer(l *roLayer) ([]Metadata, error) {
 }
 
 func (ls *layerStore) Release(l Layer) ([]Metadata, error) {
+	defer layerActions.WithValues("release").UpdateSince(time.Now())
 	ls.layerL.Lock()
 	defer ls.layerL.Unlock()
 	layer, ok := ls.layerMap[l.ChainID()]
@@ -477,6 +493,8 @@ func (ls *layerStore) Release(l Layer) ([]Metadata, error) {
 }
 
 func (ls *layerStore) CreateRWLayer(name string, parent ChainID, opts *CreateRWLayerOpts) (RWLayer, error) {
+	defer layerActions.WithValues("create").UpdateSince(time.Now())
+
 	var (
 		storageOpt map[string]string
 		initFunc   MountInit
@@ -570,6 +588,7 @@ func (ls *layerStore) GetMountID(id string) (string, error) {
 }
 
 func (ls *layerStore) ReleaseRWLayer(l RWLayer) ([]Metadata, error) {
+	defer layerActions.WithValues("release").UpdateSince(time.Now())
 	ls.mountL.Lock()
 	defer ls.mountL.Unlock()
 	m, ok := ls.mounts[l.Name()]
@@ -720,6 +739,7 @@ func (ls *layerStore) assembleTarTo(graphID string, metadata io.ReadCloser, size
 }
 
 func (ls *layerStore) Cleanup() error {
+	storeCtr.del(ls)
 	return ls.driver.Cleanup()
 }
 
@@ -0,0 +1,138 @@
+package layer // import "github.com/docker/docker/layer"
+
+import (
+	"sync"
+
+	"github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	layerActions metrics.LabeledTimer
+
+	storeCtr *storeCollector
+)
+
+func init() {
+	ns := metrics.NewNamespace("engine", "daemon", nil)
+	layerActions = ns.NewLabeledTimer("layer_actions", "The number of seconds it takes to process each layer action", "action")
+	for _, a := range []string{
+		"register",
+		"create",
+		"mount",
+		"unmount",
+		"release",
+	} {
+		layerActions.WithValues(a).Update(0)
+	}
+
+	storeCtr = &storeCollector{
+		stores: make(map[*layerStore]struct{}),
+		layers: ns.NewDesc("layers", "The number of layers in the layer store, by type", "", "driver", "type"),
+		size:   ns.NewDesc("layers_size", "The size of the layers in the layer store, counting the shared layers once (deduplicated) or once per image using them (total)", metrics.Bytes, "driver", "kind"),
+		mounts: ns.NewDesc("layer_mounts", "The number of mounts of the container layers", "", "driver"),
+		inodes: ns.NewDesc("storage_inodes", "The number of inodes of the filesystem of the storage driver, by state", "", "driver", "state"),
+	}
+	ns.Add(storeCtr)
+	metrics.Register(ns)
+}
+
+// storeCollector collects the metrics of the layer stores, which are
+// computed when they are collected.
+type storeCollector struct {
+	mu     sync.Mutex
+	stores map[*layerStore]struct{}
+
+	layers *prometheus.Desc
+	size   *prometheus.Desc
+	mounts *prometheus.Desc
+	inodes *prometheus.Desc
+}
+
+func (c *storeCollector) add(ls *layerStore) {
+	c.mu.Lock()
+	c.stores[ls] = struct{}{}
+	c.mu.Unlock()
+}
+
+func (c *storeCollector) del(ls *layerStore) {
+	c.mu.Lock()
+	delete(c.stores, ls)
+	c.mu.Unlock()
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.layers
+	ch <- c.size
+	ch <- c.mounts
+	ch <- c.inodes
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	stores := make([]*layerStore, 0, len(c.stores))
+	for ls := range c.stores {
+		stores = append(stores, ls)
+	}
+	c.mu.Unlock()
+
+	for _, ls := range stores {
+		driver := ls.driver.String()
+		s := ls.stats()
+		ch <- prometheus.MustNewConstMetric(c.layers, prometheus.GaugeValue, float64(s.layers), driver, "image")
+		ch <- prometheus.MustNewConstMetric(c.layers, prometheus.GaugeValue, float64(s.rwLayers), driver, "container")
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.size), driver, "deduplicated")
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.totalSize), driver, "total")
+		ch <- prometheus.MustNewConstMetric(c.mounts, prometheus.GaugeValue, float64(s.mounts), driver)
+
+		if ls.driverRoot == "" {
+			continue
+		}
+		used, free, err := inodes(ls.driverRoot)
+		if err != nil {
+			logrus.WithError(err).WithField("storage-driver", driver).Debug("Could not get the inodes of the storage filesystem")
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.inodes, prometheus.GaugeValue, float64(used), driver, "used")
+		ch <- prometheus.MustNewConstMetric(c.inodes, prometheus.GaugeValue, float64(free), driver, "free")
+	}
+}
+
+type storeStats struct {
+	layers    int
+	rwLayers  int
+	size      int64
+	totalSize int64
+	mounts    int
+}
+
+// stats returns the statistics of the layers of the store. The total size
+// is the sum of the sizes of the layers of each image, that is what the
+// layers would use if they were not shared.
+func (ls *layerStore) stats() storeStats {
+	var s storeStats
+
+	ls.layerL.Lock()
+	s.layers = len(ls.layerMap)
+	for _, rl := range ls.layerMap {
+		s.size += rl.size
+		if len(rl.references) > 0 {
+			var chainSize int64
+			for l := rl; l != nil; l = l.parent {
+				chainSize += l.size
+			}
+			s.totalSize += chainSize * int64(len(rl.references))
+		}
+	}
+	ls.layerL.Unlock()
+
+	ls.mountL.Lock()
+	s.rwLayers = len(ls.mounts)
+	for _, ml := range ls.mounts {
+		s.mounts += ml.activeMounts()
+	}
+	ls.mountL.Unlock()
+
+	return s
+}
@@ -0,0 +1,12 @@
+package layer // import "github.com/docker/docker/layer"
+
+import "golang.org/x/sys/unix"
+
+// inodes returns the number of used and free inodes of the filesystem at path.
+func inodes(path string) (used, free uint64, err error) {
+	var buf unix.Statfs_t
+	if err := unix.Statfs(path, &buf); err != nil {
+		return 0, 0, err
+	}
+	return buf.Files - buf.Ffree, buf.Ffree, nil
+}
@@ -0,0 +1,56 @@
+package layer // import "github.com/docker/docker/layer"
+
+import (
+	"testing"
+)
+
+func TestStoreStats(t *testing.T) {
+	ls, _, cleanup := newTestStore(t)
+	defer cleanup()
+
+	base, err := createLayer(ls, "", initWithFiles(newTestFile("base.txt", []byte("base layer"), 0644)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := createLayer(ls, base.ChainID(), initWithFiles(newTestFile("child.txt", []byte("child layer data"), 0644)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseSize, _ := base.DiffSize()
+	childSize, _ := child.DiffSize()
+
+	s := ls.(*layerStore).stats()
+	if s.layers != 2 {
+		t.Fatalf("expected 2 layers, got %d", s.layers)
+	}
+	if expected := baseSize + childSize; s.size != expected {
+		t.Fatalf("expected a deduplicated size of %d, got %d", expected, s.size)
+	}
+	// both layers are referenced, so the base layer is counted twice
+	if expected := 2*baseSize + childSize; s.totalSize != expected {
+		t.Fatalf("expected a total size of %d, got %d", expected, s.totalSize)
+	}
+
+	m, err := ls.CreateRWLayer("stats-mount", child.ChainID(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Mount(""); err != nil {
+		t.Fatal(err)
+	}
+	s = ls.(*layerStore).stats()
+	if s.rwLayers != 1 || s.mounts != 1 {
+		t.Fatalf("expected 1 container layer mounted once, got %d layers and %d mounts", s.rwLayers, s.mounts)
+	}
+
+	if err := m.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ls.ReleaseRWLayer(m); err != nil {
+		t.Fatal(err)
+	}
+	s = ls.(*layerStore).stats()
+	if s.rwLayers != 0 || s.mounts != 0 {
+		t.Fatalf("expected no container layers, got %d layers and %d mounts", s.rwLayers, s.mounts)
+	}
+}
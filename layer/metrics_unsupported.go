@@ -0,0 +1,10 @@
+// +build !linux
+
+package layer // import "github.com/docker/docker/layer"
+
+import "errors"
+
+// inodes is not supported on this platform.
+func inodes(path string) (used, free uint64, err error) {
+	return 0, 0, errors.New("inode statistics are not supported on this platform")
+}
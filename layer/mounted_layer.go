@@ -2,6 +2,8 @@ package layer // import "github.com/docker/docker/layer"
 
 import (
 	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/containerfs"
@@ -16,6 +18,9 @@ type mountedLayer struct {
 	layerStore *layerStore
 
 	references map[RWLayer]*referencedRWLayer
+
+	// mounts is the number of mounts of the layer which are not unmounted
+	mounts int32
 }
 
 func (ml *mountedLayer) cacheParent() string {
@@ -58,6 +63,10 @@ func (ml *mountedLayer) Metadata() (map[string]string, error) {
 	return ml.layerStore.driver.GetMetadata(ml.mountID)
 }
 
+func (ml *mountedLayer) activeMounts() int {
+	return int(atomic.LoadInt32(&ml.mounts))
+}
+
 func (ml *mountedLayer) getReference() RWLayer {
 	ref := &referencedRWLayer{
 		mountedLayer: ml,
@@ -90,11 +99,16 @@ type referencedRWLayer struct {
 }
 
 func (rl *referencedRWLayer) Mount(mountLabel string) (containerfs.ContainerFS, error) {
+	defer layerActions.WithValues("mount").UpdateSince(time.Now())
+	// The callers unmount the layer even if mounting it fails.
+	atomic.AddInt32(&rl.mountedLayer.mounts, 1)
 	return rl.layerStore.driver.Get(rl.mountedLayer.mountID, mountLabel)
 }
 
 // Unmount decrements the activity count and unmounts the underlying layer
 // Callers should only call `Unmount` once per call to `Mount`, even on error.
 func (rl *referencedRWLayer) Unmount() error {
+	defer layerActions.WithValues("unmount").UpdateSince(time.Now())
+	atomic.AddInt32(&rl.mountedLayer.mounts, -1)
 	return rl.layerStore.driver.Put(rl.mountedLayer.mountID)
 }
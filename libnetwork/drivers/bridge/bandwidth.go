@@ -0,0 +1,132 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	ifbPrefix = "ifb"
+	ifbLen    = 12
+
+	// shapingLatency is the maximum time, in microseconds, a packet can be
+	// delayed by the token bucket filter before being dropped.
+	shapingLatency = 25000
+	// minShapingBurst is the minimum size, in bytes, of the bucket of the
+	// token bucket filter, to allow a few maximum sized frames through.
+	minShapingBurst = 32 * 1024
+)
+
+// parseRate parses a rate limit in bits per second, with an optional
+// decimal unit suffix, for example "500k" or "100m".
+func parseRate(s string) (uint64, error) {
+	rate, err := units.FromHumanSize(s)
+	if err != nil || rate <= 0 {
+		return 0, types.BadRequestErrorf("invalid rate limit %q: must be a positive number of bits per second, for example 100m", s)
+	}
+	return uint64(rate), nil
+}
+
+// ifbName returns the name of the intermediate functional block device
+// shaping the egress traffic of the endpoint.
+func ifbName(eid string) string {
+	if len(eid) > ifbLen {
+		eid = eid[:ifbLen]
+	}
+	return ifbPrefix + eid
+}
+
+// setupBandwidthLimits limits the rate of the traffic of the endpoint with
+// token bucket filters. The ingress traffic of the container is shaped on
+// the egress of the host side interface. The egress traffic of the container,
+// received on the host side interface, is redirected to an intermediate
+// functional block device on which it is shaped.
+func (d *driver) setupBandwidthLimits(ep *bridgeEndpoint, host netlink.Link) error {
+	if ep.config == nil {
+		return nil
+	}
+	if ep.config.IngressRate != 0 {
+		if err := d.nlh.QdiscAdd(newTbf(host.Attrs().Index, ep.config.IngressRate)); err != nil {
+			return fmt.Errorf("failed to limit the ingress rate on interface %s: %v", host.Attrs().Name, err)
+		}
+	}
+	if ep.config.EgressRate == 0 {
+		return nil
+	}
+
+	ifb := &netlink.Ifb{LinkAttrs: netlink.LinkAttrs{Name: ifbName(ep.id), TxQLen: 1000, MTU: host.Attrs().MTU}}
+	if err := d.nlh.LinkAdd(ifb); err != nil {
+		return fmt.Errorf("failed to create the %s interface to limit the egress rate: %v", ifb.Name, err)
+	}
+	link, err := d.nlh.LinkByName(ifb.Name)
+	if err == nil {
+		err = d.nlh.LinkSetUp(link)
+	}
+	if err == nil {
+		err = d.nlh.QdiscAdd(newTbf(link.Attrs().Index, ep.config.EgressRate))
+	}
+	if err == nil {
+		err = d.nlh.QdiscAdd(&netlink.Ingress{
+			QdiscAttrs: netlink.QdiscAttrs{
+				LinkIndex: host.Attrs().Index,
+				Handle:    netlink.MakeHandle(0xffff, 0),
+				Parent:    netlink.HANDLE_INGRESS,
+			},
+		})
+	}
+	if err == nil {
+		// match all the packets and redirect them to the ifb device
+		err = d.nlh.FilterAdd(&netlink.U32{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: host.Attrs().Index,
+				Parent:    netlink.MakeHandle(0xffff, 0),
+				Priority:  1,
+				Protocol:  syscall.ETH_P_ALL,
+			},
+			Actions: []netlink.Action{netlink.NewMirredAction(link.Attrs().Index)},
+		})
+	}
+	if err != nil {
+		d.removeBandwidthLimits(ep)
+		return fmt.Errorf("failed to limit the egress rate on interface %s: %v", host.Attrs().Name, err)
+	}
+	return nil
+}
+
+// removeBandwidthLimits removes the intermediate functional block device of
+// the endpoint. The filters of the host side interface are removed with it.
+func (d *driver) removeBandwidthLimits(ep *bridgeEndpoint) {
+	if ep.config == nil || ep.config.EgressRate == 0 {
+		return
+	}
+	if link, err := d.nlh.LinkByName(ifbName(ep.id)); err == nil {
+		if err := d.nlh.LinkDel(link); err != nil {
+			logrus.WithError(err).Warnf("Failed to delete interface (%s)'s link on endpoint (%s) delete", link.Attrs().Name, ep.id)
+		}
+	}
+}
+
+// newTbf returns a token bucket filter limiting the rate of the traffic sent
+// on the interface to rate bits per second.
+func newTbf(linkIndex int, rate uint64) *netlink.Tbf {
+	rateInBytes := rate / 8
+	burst := uint32(rateInBytes / 100) // 10ms at rate
+	if burst < minShapingBurst {
+		burst = minShapingBurst
+	}
+	return &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: linkIndex,
+			Handle:    netlink.MakeHandle(1, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   rateInBytes,
+		Limit:  uint32(rateInBytes*shapingLatency/netlink.TIME_UNITS_PER_SEC) + burst,
+		Buffer: uint32(netlink.Xmittime(rateInBytes, burst)),
+	}
+}
@@ -0,0 +1,42 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+const (
+	// BridgeName label for bridge driver
+	BridgeName = "com.docker.network.bridge.name"
+
+	// EnableIPMasquerade label for bridge driver
+	EnableIPMasquerade = "com.docker.network.bridge.enable_ip_masquerade"
+
+	// EnableICC label
+	EnableICC = "com.docker.network.bridge.enable_icc"
+
+	// DefaultBindingIP label
+	DefaultBindingIP = "com.docker.network.bridge.host_binding_ipv4"
+
+	// DefaultBridge label
+	DefaultBridge = "com.docker.network.bridge.default_bridge"
+
+	// EnableUserlandProxy label, to disable the userland proxy of the
+	// network when it is enabled on the driver
+	EnableUserlandProxy = "com.docker.network.bridge.enable_userland_proxy"
+
+	// DynamicPortRange label for the range of the dynamically allocated host ports
+	DynamicPortRange = "com.docker.network.bridge.dynamic_port_range"
+
+	// MulticastSnooping label, to disable the IGMP and MLD snooping of the
+	// bridge of a network with multicast enabled
+	MulticastSnooping = "com.docker.network.bridge.multicast_snooping"
+
+	// MulticastQuerier label, to disable the multicast querier of the bridge
+	// of a network with multicast enabled
+	MulticastQuerier = "com.docker.network.bridge.multicast_querier"
+
+	// Routed label, to expose the containers of the network on the network
+	// of the host with their own addresses instead of masquerading them
+	Routed = "com.docker.network.bridge.routed"
+
+	// RoutedInterface label for the interface of the host on which the
+	// addresses of the containers of a routed network are proxied, the
+	// interface of the default route by default
+	RoutedInterface = "com.docker.network.bridge.routed_interface"
+)
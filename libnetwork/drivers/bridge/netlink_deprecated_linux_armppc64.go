@@ -0,0 +1,7 @@
+// +build arm ppc64 ppc64le
+
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+func ifrDataByte(b byte) uint8 {
+	return uint8(b)
+}
@@ -0,0 +1,257 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/libnetwork/portmapper"
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/ishidawataru/sctp"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	defaultBindingIP = net.IPv4(0, 0, 0, 0)
+)
+
+func (n *bridgeNetwork) allocatePorts(ep *bridgeEndpoint, reqDefBindIP net.IP, ulPxyEnabled bool) ([]types.PortBinding, error) {
+	if ep.extConnConfig == nil || ep.extConnConfig.PortBindings == nil {
+		return nil, nil
+	}
+
+	defHostIP := defaultBindingIP
+	if reqDefBindIP != nil {
+		defHostIP = reqDefBindIP
+	}
+
+	// The IPv6 bindings of a restored endpoint are mapped again from its
+	// IPv4 bindings.
+	bindings := make([]types.PortBinding, 0, len(ep.extConnConfig.PortBindings))
+	for _, b := range ep.extConnConfig.PortBindings {
+		if !isIPv6Binding(b) {
+			bindings = append(bindings, b)
+		}
+	}
+
+	bs, err := n.allocatePortsInternal(n.portMapper, bindings, ep.addr.IP, defHostIP, ulPxyEnabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if ep.addrv6 == nil || n.portMapperV6 == nil {
+		return bs, nil
+	}
+
+	// Publish the ports bound to the IPv4 wildcard address on the IPv6
+	// wildcard address too, as the proxy of such bindings listens on both.
+	var bindingsV6 []types.PortBinding
+	for _, b := range bs {
+		if b.HostIP.Equal(net.IPv4zero) {
+			b6 := b.GetCopy()
+			b6.HostIP = net.IPv6zero
+			b6.HostPortEnd = b6.HostPort
+			bindingsV6 = append(bindingsV6, b6)
+		}
+	}
+	bs6, err := n.allocatePortsInternal(n.portMapperV6, bindingsV6, ep.addrv6.IP, net.IPv6zero, false)
+	if err != nil {
+		if cuErr := n.releasePortsInternal(bs); cuErr != nil {
+			logrus.Warnf("Upon IPv6 allocation failure, failed to clear previously allocated port bindings: %v", cuErr)
+		}
+		return nil, err
+	}
+	return append(bs, bs6...), nil
+}
+
+// isIPv6Binding returns whether b is an operational binding to the IPv6
+// address of a container.
+func isIPv6Binding(b types.PortBinding) bool {
+	return b.IP != nil && b.IP.To4() == nil
+}
+
+func (n *bridgeNetwork) allocatePortsInternal(pm *portmapper.PortMapper, bindings []types.PortBinding, containerIP, defHostIP net.IP, ulPxyEnabled bool) ([]types.PortBinding, error) {
+	bs := make([]types.PortBinding, 0, len(bindings))
+	// The dynamically allocated bindings, by pairing key. A container port
+	// published for several protocols gets the same dynamic host port for all
+	// of them, if available.
+	paired := make(map[string]types.PortBinding)
+	for _, c := range bindings {
+		b := c.GetCopy()
+		key, dynamic := pairingKey(c)
+		if p, ok := paired[key]; dynamic && ok && p.Proto != c.Proto {
+			hostPort := p.HostPort
+			pb := c.GetCopy()
+			pb.HostPort, pb.HostPortEnd = hostPort, hostPort
+			if err := n.allocatePort(pm, &pb, containerIP, defHostIP, ulPxyEnabled); err == nil {
+				bs = append(bs, pb)
+				continue
+			}
+			logrus.Debugf("Host port %d of %s is not available for %s, allocating another one", hostPort, key, c.Proto)
+		}
+		if err := n.allocatePort(pm, &b, containerIP, defHostIP, ulPxyEnabled); err != nil {
+			// On allocation failure, release previously allocated ports. On cleanup error, just log a warning message
+			if cuErr := n.releasePortsInternal(bs); cuErr != nil {
+				logrus.Warnf("Upon allocation failure for %v, failed to clear previously allocated port bindings: %v", b, cuErr)
+			}
+			return nil, err
+		}
+		bs = append(bs, b)
+		if _, ok := paired[key]; dynamic && !ok {
+			paired[key] = b
+		}
+	}
+	return bs, nil
+}
+
+// pairingKey returns the key under which the host port allocated to b is
+// paired with the bindings of the same container port for the other protocols
+// among TCP, UDP and SCTP, and whether the host port of b is dynamically
+// allocated.
+func pairingKey(b types.PortBinding) (string, bool) {
+	if b.Proto != types.TCP && b.Proto != types.UDP && b.Proto != types.SCTP {
+		return "", false
+	}
+	if b.HostPort != 0 && (b.HostPortEnd == 0 || b.HostPortEnd == b.HostPort) {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d-%d->%d", b.HostIP, b.HostPort, b.HostPortEnd, b.Port), true
+}
+
+func (n *bridgeNetwork) allocatePort(pm *portmapper.PortMapper, bnd *types.PortBinding, containerIP, defHostIP net.IP, ulPxyEnabled bool) error {
+	var (
+		host net.Addr
+		err  error
+	)
+
+	// Store the container interface address in the operational binding
+	bnd.IP = containerIP
+
+	// Adjust the host address in the operational binding
+	if len(bnd.HostIP) == 0 {
+		bnd.HostIP = defHostIP
+	}
+
+	// Adjust HostPortEnd if this is not a range.
+	if bnd.HostPortEnd == 0 {
+		bnd.HostPortEnd = bnd.HostPort
+	}
+
+	// Construct the container side transport address
+	container, err := bnd.ContainerAddr()
+	if err != nil {
+		return err
+	}
+
+	// Allocate the dynamic host ports in the range configured on the network,
+	// or else on the driver, if any.
+	hostPortStart, hostPortEnd := int(bnd.HostPort), int(bnd.HostPortEnd)
+	if bnd.HostPort == 0 {
+		hostPortStart, hostPortEnd = n.dynamicPortRange()
+	}
+
+	// Try up to maxAllocatePortAttempts times to get a port that's not already allocated.
+	for i := 0; i < maxAllocatePortAttempts; i++ {
+		if host, err = pm.MapRange(container, bnd.HostIP, hostPortStart, hostPortEnd, ulPxyEnabled); err == nil {
+			break
+		}
+		// There is no point in immediately retrying to map an explicitly chosen port.
+		if bnd.HostPort != 0 {
+			logrus.Warnf("Failed to allocate and map port %d-%d: %s", bnd.HostPort, bnd.HostPortEnd, err)
+			break
+		}
+		logrus.Warnf("Failed to allocate and map port: %s, retry: %d", err, i+1)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Save the host port (regardless it was or not specified in the binding)
+	switch netAddr := host.(type) {
+	case *net.TCPAddr:
+		bnd.HostPort = uint16(host.(*net.TCPAddr).Port)
+		return nil
+	case *net.UDPAddr:
+		bnd.HostPort = uint16(host.(*net.UDPAddr).Port)
+		return nil
+	case *sctp.SCTPAddr:
+		bnd.HostPort = uint16(host.(*sctp.SCTPAddr).Port)
+		return nil
+	default:
+		// For completeness
+		return ErrUnsupportedAddressType(fmt.Sprintf("%T", netAddr))
+	}
+}
+
+// dynamicPortRange returns the range of the dynamically allocated host ports
+// of the network, or 0, 0 for the default range of the port allocator.
+func (n *bridgeNetwork) dynamicPortRange() (int, int) {
+	portRange := n.config.DynamicPortRange
+	if portRange == "" && n.driver != nil {
+		portRange = n.driver.config.DynamicPortRange
+	}
+	if portRange == "" {
+		return 0, 0
+	}
+	start, end, err := parsePortRange(portRange)
+	if err != nil {
+		logrus.Warnf("Ignoring invalid dynamic port range %q of network %s: %v", portRange, n.id, err)
+		return 0, 0
+	}
+	return int(start), int(end)
+}
+
+// parsePortRange parses a range of ports in the form "start-end".
+func parsePortRange(s string) (uint16, uint16, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.New("must be in the form start-end")
+	}
+	start, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 16)
+	if err != nil || start == 0 {
+		return 0, 0, fmt.Errorf("invalid start port %q", parts[0])
+	}
+	end, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 16)
+	if err != nil || end == 0 {
+		return 0, 0, fmt.Errorf("invalid end port %q", parts[1])
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("start port %d is greater than end port %d", start, end)
+	}
+	return uint16(start), uint16(end), nil
+}
+
+func (n *bridgeNetwork) releasePorts(ep *bridgeEndpoint) error {
+	return n.releasePortsInternal(ep.portMapping)
+}
+
+func (n *bridgeNetwork) releasePortsInternal(bindings []types.PortBinding) error {
+	var errorBuf bytes.Buffer
+
+	// Attempt to release all port bindings, do not stop on failure
+	for _, m := range bindings {
+		if err := n.releasePort(m); err != nil {
+			errorBuf.WriteString(fmt.Sprintf("\ncould not release %v because of %v", m, err))
+		}
+	}
+
+	if errorBuf.Len() != 0 {
+		return errors.New(errorBuf.String())
+	}
+	return nil
+}
+
+func (n *bridgeNetwork) releasePort(bnd types.PortBinding) error {
+	// Construct the host side transport address
+	host, err := bnd.HostAddr()
+	if err != nil {
+		return err
+	}
+	if isIPv6Binding(bnd) && n.portMapperV6 != nil {
+		return n.portMapperV6.Unmap(host)
+	}
+	return n.portMapper.Unmap(host)
+}
@@ -0,0 +1,38 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+import (
+	"net"
+	"testing"
+
+	"github.com/docker/docker/libnetwork/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestPairingKey(t *testing.T) {
+	hostIP := net.ParseIP("0.0.0.0")
+	binding := func(proto types.Protocol, hostPort, hostPortEnd uint16) types.PortBinding {
+		return types.PortBinding{Proto: proto, HostIP: hostIP, HostPort: hostPort, HostPortEnd: hostPortEnd, Port: 9999}
+	}
+
+	// The dynamic host ports of the same container port are paired for
+	// TCP, UDP and SCTP.
+	tcp, ok := pairingKey(binding(types.TCP, 0, 0))
+	assert.Check(t, ok)
+	for _, proto := range []types.Protocol{types.UDP, types.SCTP} {
+		key, ok := pairingKey(binding(proto, 0, 0))
+		assert.Check(t, ok, proto)
+		assert.Check(t, is.Equal(key, tcp), proto)
+	}
+	key, ok := pairingKey(binding(types.SCTP, 8000, 8010))
+	assert.Check(t, ok)
+	assert.Check(t, key != tcp)
+
+	// The static host ports are not paired.
+	_, ok = pairingKey(binding(types.SCTP, 9999, 0))
+	assert.Check(t, !ok)
+	_, ok = pairingKey(binding(types.SCTP, 9999, 9999))
+	assert.Check(t, !ok)
+	_, ok = pairingKey(binding(types.ICMP, 0, 0))
+	assert.Check(t, !ok)
+}
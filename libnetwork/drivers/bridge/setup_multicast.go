@@ -0,0 +1,34 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// setupMulticast configures the IGMP and MLD snooping of the bridge, so that
+// the multicast traffic is only forwarded to the interfaces of the members of
+// the multicast groups, and the bridge as the multicast querier of the
+// network, so that the memberships of the containers do not expire in the
+// absence of a multicast router.
+func setupMulticast(config *networkConfiguration, i *bridgeInterface) error {
+	snooping := !config.DisableMulticastSnooping
+	querier := snooping && !config.DisableMulticastQuerier
+	for _, s := range []struct {
+		name  string
+		value bool
+	}{
+		{"multicast_snooping", snooping},
+		{"multicast_querier", querier},
+	} {
+		value := []byte{'0', '\n'}
+		if s.value {
+			value[0] = '1'
+		}
+		path := filepath.Join("/sys/class/net", config.BridgeName, "bridge", s.name)
+		if err := ioutil.WriteFile(path, value, 0644); err != nil {
+			return fmt.Errorf("failed to set %s on bridge %s: %v", s.name, config.BridgeName, err)
+		}
+	}
+	return nil
+}
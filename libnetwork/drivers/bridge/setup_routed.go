@@ -0,0 +1,235 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// The containers of a routed network are reachable on the network of the
+// host with their own addresses, instead of being masqueraded behind the
+// addresses of the host. The host answers the ARP requests and the neighbor
+// solicitations for the addresses of the containers on its routed interface,
+// routes the traffic to them through the bridge with host routes, and
+// announces them on the routed interface when their endpoints are created,
+// so that the neighbors of the host update their caches.
+
+// setupRouted resolves the routed interface of the network and enables the
+// proxying of the neighbor discovery on it.
+func setupRouted(config *networkConfiguration, i *bridgeInterface) error {
+	if config.RoutedInterface == "" {
+		name, err := defaultRouteInterface(i.nlh)
+		if err != nil {
+			return err
+		}
+		config.RoutedInterface = name
+	}
+	if _, err := i.nlh.LinkByName(config.RoutedInterface); err != nil {
+		return fmt.Errorf("could not find the routed interface %s of bridge %s: %v", config.RoutedInterface, config.BridgeName, err)
+	}
+
+	// The ARP requests for the addresses of the containers are answered
+	// without the random delay of the proxied entries.
+	settings := []string{filepath.Join("/proc/sys/net/ipv4/neigh", config.RoutedInterface, "proxy_delay")}
+	values := [][]byte{{'0', '\n'}}
+	if config.EnableIPv6 {
+		settings = append(settings, filepath.Join("/proc/sys/net/ipv6/conf", config.RoutedInterface, "proxy_ndp"))
+		values = append(values, []byte{'1', '\n'})
+	}
+	for idx, path := range settings {
+		if err := ioutil.WriteFile(path, values[idx], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// defaultRouteInterface returns the name of the interface of the IPv4 default
+// route of the host.
+func defaultRouteInterface(nlh *netlink.Handle) (string, error) {
+	routes, err := nlh.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the routes of the host: %v", err)
+	}
+	for _, r := range routes {
+		if r.Dst != nil || r.LinkIndex == 0 {
+			continue
+		}
+		link, err := nlh.LinkByIndex(r.LinkIndex)
+		if err != nil {
+			return "", fmt.Errorf("could not find the interface of the default route: %v", err)
+		}
+		return link.Attrs().Name, nil
+	}
+	return "", fmt.Errorf("no default route to select the routed interface, the %s option is required", RoutedInterface)
+}
+
+// routedAddresses returns the host prefixes of the addresses of the endpoint.
+func (ep *bridgeEndpoint) routedAddresses() []*net.IPNet {
+	var addrs []*net.IPNet
+	if ep.addr != nil {
+		addrs = append(addrs, &net.IPNet{IP: ep.addr.IP, Mask: net.CIDRMask(32, 32)})
+	}
+	if ep.addrv6 != nil {
+		addrs = append(addrs, &net.IPNet{IP: ep.addrv6.IP, Mask: net.CIDRMask(128, 128)})
+	}
+	return addrs
+}
+
+// addRoutedEndpoint routes the addresses of the endpoint through the bridge,
+// proxies them on the routed interface of the network and announces them.
+func (d *driver) addRoutedEndpoint(config *networkConfiguration, ep *bridgeEndpoint) error {
+	uplink, err := d.nlh.LinkByName(config.RoutedInterface)
+	if err != nil {
+		return fmt.Errorf("could not find the routed interface %s: %v", config.RoutedInterface, err)
+	}
+	bridge, err := d.nlh.LinkByName(config.BridgeName)
+	if err != nil {
+		return fmt.Errorf("could not find bridge %s: %v", config.BridgeName, err)
+	}
+
+	for _, addr := range ep.routedAddresses() {
+		route := &netlink.Route{LinkIndex: bridge.Attrs().Index, Dst: addr, Scope: netlink.SCOPE_LINK}
+		if err := d.nlh.RouteReplace(route); err != nil {
+			return fmt.Errorf("failed to add the route to %s through bridge %s: %v", addr, config.BridgeName, err)
+		}
+		if err := d.nlh.NeighSet(proxyNeigh(uplink, addr.IP)); err != nil {
+			return fmt.Errorf("failed to proxy %s on interface %s: %v", addr.IP, config.RoutedInterface, err)
+		}
+	}
+
+	// The announcements are a best effort, the neighbors of the host learn
+	// the addresses from the proxied replies anyway.
+	for _, addr := range ep.routedAddresses() {
+		announce := sendGratuitousARP
+		if addr.IP.To4() == nil {
+			announce = sendUnsolicitedNA
+		}
+		if err := announce(uplink, addr.IP); err != nil {
+			logrus.Warnf("Failed to announce %s on interface %s: %v", addr.IP, config.RoutedInterface, err)
+		}
+	}
+	return nil
+}
+
+// removeRoutedEndpoint removes the routes and the proxied entries of the
+// addresses of the endpoint. It is a best effort.
+func (d *driver) removeRoutedEndpoint(config *networkConfiguration, ep *bridgeEndpoint) {
+	uplink, err := d.nlh.LinkByName(config.RoutedInterface)
+	if err != nil {
+		logrus.Debugf("Could not find the routed interface %s: %v", config.RoutedInterface, err)
+	}
+	bridge, err := d.nlh.LinkByName(config.BridgeName)
+	if err != nil {
+		logrus.Debugf("Could not find bridge %s: %v", config.BridgeName, err)
+	}
+
+	for _, addr := range ep.routedAddresses() {
+		if uplink != nil {
+			if err := d.nlh.NeighDel(proxyNeigh(uplink, addr.IP)); err != nil {
+				logrus.Debugf("Failed to remove the proxied entry of %s on interface %s: %v", addr.IP, config.RoutedInterface, err)
+			}
+		}
+		if bridge != nil {
+			route := &netlink.Route{LinkIndex: bridge.Attrs().Index, Dst: addr, Scope: netlink.SCOPE_LINK}
+			if err := d.nlh.RouteDel(route); err != nil {
+				logrus.Debugf("Failed to remove the route to %s through bridge %s: %v", addr, config.BridgeName, err)
+			}
+		}
+	}
+}
+
+func proxyNeigh(link netlink.Link, ip net.IP) *netlink.Neigh {
+	family := netlink.FAMILY_V6
+	if ip.To4() != nil {
+		family = netlink.FAMILY_V4
+	}
+	return &netlink.Neigh{
+		LinkIndex: link.Attrs().Index,
+		Family:    family,
+		Flags:     netlink.NTF_PROXY,
+		IP:        ip,
+	}
+}
+
+// sendGratuitousARP broadcasts an ARP request for ip from the hardware
+// address of the link.
+func sendGratuitousARP(link netlink.Link, ip net.IP) error {
+	mac := link.Attrs().HardwareAddr
+	if len(mac) != 6 {
+		return nil
+	}
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(nl.Swap16(syscall.ETH_P_ARP)))
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	to := &syscall.SockaddrLinklayer{
+		Protocol: nl.Swap16(syscall.ETH_P_ARP),
+		Ifindex:  link.Attrs().Index,
+		Halen:    6,
+		Addr:     [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	return syscall.Sendto(fd, gratuitousARP(mac, ip), 0, to)
+}
+
+// gratuitousARP returns the ARP request for ip from the hardware address mac.
+func gratuitousARP(mac net.HardwareAddr, ip net.IP) []byte {
+	// Ethernet, IPv4, hardware and protocol address lengths, request
+	pkt := make([]byte, 28)
+	binary.BigEndian.PutUint16(pkt[0:], 1)
+	binary.BigEndian.PutUint16(pkt[2:], syscall.ETH_P_IP)
+	pkt[4], pkt[5] = 6, 4
+	binary.BigEndian.PutUint16(pkt[6:], 1)
+	copy(pkt[8:14], mac)
+	copy(pkt[14:18], ip.To4())
+	copy(pkt[24:28], ip.To4())
+	return pkt
+}
+
+// sendUnsolicitedNA sends a neighbor advertisement for ip, with the hardware
+// address of the link, to the all-nodes multicast group. Its checksum is
+// computed by the kernel.
+func sendUnsolicitedNA(link netlink.Link, ip net.IP) error {
+	mac := link.Attrs().HardwareAddr
+	if len(mac) != 6 {
+		return nil
+	}
+	fd, err := syscall.Socket(syscall.AF_INET6, syscall.SOCK_RAW, syscall.IPPROTO_ICMPV6)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_HOPS, 255); err != nil {
+		return err
+	}
+	if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_IF, link.Attrs().Index); err != nil {
+		return err
+	}
+
+	to := &syscall.SockaddrInet6{ZoneId: uint32(link.Attrs().Index)}
+	copy(to.Addr[:], net.IPv6linklocalallnodes)
+	return syscall.Sendto(fd, unsolicitedNA(mac, ip), 0, to)
+}
+
+// unsolicitedNA returns the ICMPv6 neighbor advertisement for ip with the
+// hardware address mac, without its checksum.
+func unsolicitedNA(mac net.HardwareAddr, ip net.IP) []byte {
+	// Neighbor advertisement with the override flag, the target address and
+	// the target link-layer address option
+	pkt := make([]byte, 32)
+	pkt[0] = 136
+	pkt[4] = 0x20
+	copy(pkt[8:24], ip.To16())
+	pkt[24], pkt[25] = 2, 1
+	copy(pkt[26:32], mac)
+	return pkt
+}
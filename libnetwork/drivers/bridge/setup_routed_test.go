@@ -0,0 +1,87 @@
+package bridge // import "github.com/docker/docker/libnetwork/drivers/bridge"
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestRoutedConfig(t *testing.T) {
+	c := &networkConfiguration{}
+	assert.NilError(t, c.fromLabels(map[string]string{Routed: "true", RoutedInterface: "eth1"}))
+	assert.Check(t, c.Routed)
+	assert.Check(t, is.Equal(c.RoutedInterface, "eth1"))
+	assert.NilError(t, c.Validate())
+
+	c.Internal = true
+	assert.Check(t, is.Error(c.Validate(), "an internal network cannot be routed"))
+
+	assert.Check(t, is.ErrorContains((&networkConfiguration{}).fromLabels(map[string]string{Routed: "yes please"}), Routed))
+
+	// The routed mode is persisted.
+	b, err := json.Marshal(&networkConfiguration{BridgeName: "br-routed", Routed: true, RoutedInterface: "eth1"})
+	assert.NilError(t, err)
+	restored := &networkConfiguration{}
+	assert.NilError(t, json.Unmarshal(b, restored))
+	assert.Check(t, restored.Routed)
+	assert.Check(t, is.Equal(restored.RoutedInterface, "eth1"))
+}
+
+func TestRoutedRule(t *testing.T) {
+	rule := routedRule("br-routed")
+	assert.Check(t, is.Equal(string(rule.table), "filter"))
+	assert.Check(t, is.Equal(rule.chain, "FORWARD"))
+	assert.Check(t, is.DeepEqual(rule.args, []string{"-o", "br-routed", "!", "-i", "br-routed", "-j", "ACCEPT"}))
+}
+
+func TestRoutedAddresses(t *testing.T) {
+	ep := &bridgeEndpoint{
+		addr:   &net.IPNet{IP: net.ParseIP("192.0.2.10").To4(), Mask: net.CIDRMask(24, 32)},
+		addrv6: &net.IPNet{IP: net.ParseIP("2001:db8::10"), Mask: net.CIDRMask(64, 128)},
+	}
+	var addrs []string
+	for _, a := range ep.routedAddresses() {
+		addrs = append(addrs, a.String())
+	}
+	assert.Check(t, is.DeepEqual(addrs, []string{"192.0.2.10/32", "2001:db8::10/128"}))
+	assert.Check(t, is.Len((&bridgeEndpoint{}).routedAddresses(), 0))
+}
+
+func TestProxyNeigh(t *testing.T) {
+	link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 7}}
+	n := proxyNeigh(link, net.ParseIP("192.0.2.10"))
+	assert.Check(t, is.Equal(n.LinkIndex, 7))
+	assert.Check(t, is.Equal(n.Family, netlink.FAMILY_V4))
+	assert.Check(t, is.Equal(n.Flags, netlink.NTF_PROXY))
+	n = proxyNeigh(link, net.ParseIP("2001:db8::10"))
+	assert.Check(t, is.Equal(n.Family, netlink.FAMILY_V6))
+}
+
+func TestGratuitousARP(t *testing.T) {
+	mac, err := net.ParseMAC("02:42:ac:11:00:02")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(gratuitousARP(mac, net.ParseIP("192.0.2.10")), []byte{
+		0x00, 0x01, // Ethernet
+		0x08, 0x00, // IPv4
+		6, 4, // address lengths
+		0x00, 0x01, // request
+		0x02, 0x42, 0xac, 0x11, 0x00, 0x02, // sender hardware address
+		192, 0, 2, 10, // sender protocol address
+		0, 0, 0, 0, 0, 0, // target hardware address
+		192, 0, 2, 10, // target protocol address
+	}))
+}
+
+func TestUnsolicitedNA(t *testing.T) {
+	mac, err := net.ParseMAC("02:42:ac:11:00:02")
+	assert.NilError(t, err)
+	pkt := unsolicitedNA(mac, net.ParseIP("2001:db8::10"))
+	assert.Check(t, is.DeepEqual(pkt[:8], []byte{136, 0, 0, 0, 0x20, 0, 0, 0}))
+	assert.Check(t, is.DeepEqual(net.IP(pkt[8:24]), net.ParseIP("2001:db8::10")))
+	// target link-layer address option
+	assert.Check(t, is.DeepEqual(pkt[24:], []byte{2, 1, 0x02, 0x42, 0xac, 0x11, 0x00, 0x02}))
+}
@@ -0,0 +1,257 @@
+package overlay // import "github.com/docker/docker/libnetwork/drivers/overlay"
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// The VXLAN port, the ESP cipher and the rekey interval of the IPsec
+// encryption of a secure network can be set with driver options. The traffic
+// of the secure networks with the same parameters is encrypted, between two
+// nodes, with the same security associations, and the VXLAN packets of these
+// networks are marked for their security policies. The keys of the security
+// associations with non default parameters are derived from the encryption
+// keys distributed to the nodes of the cluster, so that no key is shared by
+// the security associations with different parameters.
+//
+// The keys of the networks with a rekey interval are derived from the primary
+// encryption key and from the number of intervals elapsed since the epoch, so
+// that all the nodes rotate them at the same time. As on the rotation of the
+// cluster keys, the keys of the previous and of the next intervals are
+// accepted on reception, to tolerate the drift of the clocks of the nodes.
+const (
+	defaultCipher    = "aes128-gcm64"
+	minRekeyInterval = time.Minute
+	encrKeyLabel     = "docker overlay ipsec key"
+)
+
+type espCipher struct {
+	algo   string
+	keyLen int
+	icvLen int
+}
+
+// pktExpansion returns the expansion of the packets encrypted with the cipher:
+// SPI(4) + SeqN(4) + IV(8) + PadLength(1) + NextHeader(1) + ICV
+func (c espCipher) pktExpansion() int {
+	return 18 + c.icvLen/8
+}
+
+var espCiphers = map[string]espCipher{
+	"aes128-gcm64":      {algo: "rfc4106(gcm(aes))", keyLen: 16, icvLen: 64},
+	"aes128-gcm128":     {algo: "rfc4106(gcm(aes))", keyLen: 16, icvLen: 128},
+	"aes256-gcm128":     {algo: "rfc4106(gcm(aes))", keyLen: 32, icvLen: 128},
+	"chacha20-poly1305": {algo: "rfc7539esp(chacha20,poly1305)", keyLen: 32, icvLen: 128},
+}
+
+// encrConfig are the parameters of the IPsec encryption of a secure network.
+type encrConfig struct {
+	port   uint16
+	cipher string
+	rekey  time.Duration
+}
+
+var defaultEncrConfig = encrConfig{port: vxlanPort, cipher: defaultCipher}
+
+func (c encrConfig) String() string {
+	return fmt.Sprintf("%d/%s/%s", c.port, c.cipher, c.rekey)
+}
+
+// mark returns the firewall mark of the VXLAN packets encrypted with these
+// parameters. The mark of the default parameters is kept in the low bytes of
+// the others, to find the security policies of the driver.
+func (c encrConfig) mark() uint32 {
+	if c == defaultEncrConfig {
+		return r
+	}
+	h := fnv.New32a()
+	h.Write([]byte(c.String()))
+	return (h.Sum32()%255+1)<<24 | r
+}
+
+// epoch returns the number of rekey intervals elapsed at t.
+func (c encrConfig) epoch(t time.Time) int64 {
+	return t.Unix() / int64(c.rekey/time.Second)
+}
+
+// deriveKey returns the key of the security associations with these
+// parameters, derived from the encryption key k for the given rekey interval.
+func (c encrConfig) deriveKey(k *key, epoch int64) *key {
+	if k == nil || c == defaultEncrConfig {
+		return k
+	}
+	id := fmt.Sprintf("%s/%d", c, epoch)
+	mac := hmac.New(sha256.New, k.value)
+	mac.Write([]byte(encrKeyLabel))
+	mac.Write([]byte(id))
+
+	tag := make([]byte, 4)
+	binary.BigEndian.PutUint32(tag, k.tag)
+	h := fnv.New32a()
+	h.Write(tag)
+	h.Write([]byte(id))
+
+	return &key{
+		value: mac.Sum(nil)[:espCiphers[c.cipher].keyLen],
+		tag:   h.Sum32(),
+	}
+}
+
+// keys returns the keys of the security associations with these parameters,
+// the primary key first, derived from the encryption keys of the driver.
+func (c encrConfig) keys(keys []*key, epoch int64) []*key {
+	if len(keys) == 0 {
+		return nil
+	}
+	if c.rekey != 0 {
+		return []*key{c.deriveKey(keys[0], epoch), c.deriveKey(keys[0], epoch-1), c.deriveKey(keys[0], epoch+1)}
+	}
+	derived := make([]*key, 0, len(keys))
+	for _, k := range keys {
+		derived = append(derived, c.deriveKey(k, 0))
+	}
+	return derived
+}
+
+// encrProfile is the IPsec state of the secure networks with the same
+// encryption parameters.
+type encrProfile struct {
+	config encrConfig
+	mark   netlink.XfrmMark
+	// keys of the security associations, the primary key first
+	keys   []*key
+	secMap *encrMap
+	epoch  int64
+	timer  *time.Timer
+}
+
+func (p *encrProfile) cipher() espCipher {
+	return espCiphers[p.config.cipher]
+}
+
+// encrProfile returns the IPsec state of the networks with the encryption
+// parameters c, which is created if it does not exist.
+func (d *driver) encrProfile(c encrConfig) *encrProfile {
+	d.Lock()
+	defer d.Unlock()
+	if p, ok := d.encrProfiles[c]; ok {
+		return p
+	}
+	p := &encrProfile{
+		config: c,
+		mark:   netlink.XfrmMark{Value: c.mark(), Mask: 0xffffffff},
+		secMap: &encrMap{nodes: map[string][]*spi{}},
+	}
+	if c.rekey != 0 {
+		p.epoch = c.epoch(time.Now())
+		d.scheduleRekey(p)
+	}
+	p.keys = c.keys(d.keys, p.epoch)
+	d.encrProfiles[c] = p
+	return p
+}
+
+// resetEncrProfiles discards the IPsec state of the networks. The driver must
+// be locked.
+func (d *driver) resetEncrProfiles() {
+	for _, p := range d.encrProfiles {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+	}
+	d.encrProfiles = map[encrConfig]*encrProfile{}
+}
+
+func (d *driver) scheduleRekey(p *encrProfile) {
+	next := time.Unix((p.epoch+1)*int64(p.config.rekey/time.Second), 0)
+	p.timer = time.AfterFunc(time.Until(next), func() { d.rekey(p) })
+}
+
+// rekey rotates the keys of the security associations of the profile at the
+// end of its rekey interval: the key of the next interval becomes the primary
+// key, the key of the previous interval is pruned, and the key of the interval
+// after next is added.
+func (d *driver) rekey(p *encrProfile) {
+	d.Lock()
+	defer d.Unlock()
+	if d.encrProfiles[p.config] != p {
+		return
+	}
+	if len(d.keys) != 0 {
+		c := p.config
+		logrus.Debugf("Rekeying the networks encrypted with %s", c)
+		newKey := c.deriveKey(d.keys[0], p.epoch+2)
+		primary := c.deriveKey(d.keys[0], p.epoch+1)
+		pruneKey := c.deriveKey(d.keys[0], p.epoch-1)
+		if err := d.updateProfileKeys(p, newKey, primary, pruneKey); err != nil {
+			logrus.Warnf("Failed to rekey the networks encrypted with %s: %v", c, err)
+		}
+	}
+	p.epoch++
+	d.scheduleRekey(p)
+}
+
+// updateProfileKeys adds newKey, makes primary the primary key and prunes
+// pruneKey from the keys of the profile, and updates the security associations
+// with the nodes accordingly. The driver must be locked.
+func (d *driver) updateProfileKeys(p *encrProfile, newKey, primary, pruneKey *key) error {
+	lIP := net.ParseIP(d.bindAddress)
+	aIP := net.ParseIP(d.advertiseAddress)
+	keys, _, err := updateKeyRing(p.keys, newKey, primary, pruneKey, func(keys []*key, newIdx, priIdx, delIdx int) {
+		p.secMapWalk(func(rIPs string, spis []*spi) ([]*spi, bool) {
+			rIP := net.ParseIP(rIPs)
+			return updateNodeKey(p, lIP, aIP, rIP, spis, keys, newIdx, priIdx, delIdx), false
+		})
+	})
+	if err != nil {
+		return err
+	}
+	p.keys = keys
+	return nil
+}
+
+// resetProfileKeys programs the security associations of the profile with the
+// keys derived from the current encryption keys of the driver, after the
+// primary key of the driver was rotated. The driver must be locked.
+func (d *driver) resetProfileKeys(p *encrProfile) {
+	lIP := net.ParseIP(d.bindAddress)
+	aIP := net.ParseIP(d.advertiseAddress)
+	p.secMap.Lock()
+	nodes := make([]net.IP, 0, len(p.secMap.nodes))
+	for rIPs := range p.secMap.nodes {
+		nodes = append(nodes, net.ParseIP(rIPs))
+	}
+	p.secMap.Unlock()
+
+	for _, rIP := range nodes {
+		if err := removeEncryption(lIP, rIP, p); err != nil {
+			logrus.Warnf("Failed to remove network encryption between %s and %s: %v", lIP, rIP, err)
+		}
+	}
+	p.keys = p.config.keys(d.keys, p.epoch)
+	for _, rIP := range nodes {
+		programNodeEncryption(lIP, aIP, rIP, p)
+	}
+}
+
+func (p *encrProfile) secMapWalk(f func(string, []*spi) ([]*spi, bool)) {
+	p.secMap.Lock()
+	for node, indices := range p.secMap.nodes {
+		idxs, stop := f(node, indices)
+		if idxs != nil {
+			p.secMap.nodes[node] = idxs
+		}
+		if stop {
+			break
+		}
+	}
+	p.secMap.Unlock()
+}
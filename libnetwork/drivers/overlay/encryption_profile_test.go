@@ -0,0 +1,140 @@
+package overlay // import "github.com/docker/docker/libnetwork/drivers/overlay"
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/google/go-cmp/cmp"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+var cmpKeys = cmp.AllowUnexported(key{})
+
+func TestParseVxlanOptions(t *testing.T) {
+	n := &network{secure: true}
+	assert.NilError(t, n.parseVxlanOptions(map[string]string{
+		netlabel.OverlayVxlanPort:               "4790",
+		netlabel.OverlayVxlanTOS:                "0x10",
+		netlabel.OverlayVxlanTTL:                "64",
+		netlabel.OverlayEncryptionCipher:        "chacha20-poly1305",
+		netlabel.OverlayEncryptionRekeyInterval: "1h30m0.5s",
+	}))
+	assert.Check(t, is.Equal(n.port(), uint16(4790)))
+	assert.Check(t, is.Equal(n.vxlanTOS, 0x10))
+	assert.Check(t, is.Equal(n.vxlanTTL, 64))
+	assert.Check(t, is.Equal(n.encrConfig(), encrConfig{port: 4790, cipher: "chacha20-poly1305", rekey: 90 * time.Minute}))
+
+	n = &network{}
+	assert.NilError(t, n.parseVxlanOptions(map[string]string{netlabel.OverlayVxlanTOS: "inherit"}))
+	assert.Check(t, is.Equal(n.vxlanTOS, 1))
+	assert.Check(t, is.Equal(n.port(), uint16(vxlanPort)))
+	assert.Check(t, is.Equal(n.encrConfig(), defaultEncrConfig))
+
+	for _, tc := range []struct {
+		network *network
+		opts    map[string]string
+		err     string
+	}{
+		{&network{}, map[string]string{netlabel.OverlayVxlanPort: "0"}, `invalid VXLAN port "0"`},
+		{&network{}, map[string]string{netlabel.OverlayVxlanPort: "65536"}, `invalid VXLAN port "65536"`},
+		{&network{}, map[string]string{netlabel.OverlayVxlanTOS: "256"}, `invalid VXLAN TOS "256"`},
+		{&network{}, map[string]string{netlabel.OverlayVxlanTTL: "0"}, `invalid VXLAN TTL "0"`},
+		{&network{secure: true}, map[string]string{netlabel.OverlayEncryptionCipher: "des"}, `invalid encryption cipher "des": must be one of aes128-gcm128, aes128-gcm64, aes256-gcm128, chacha20-poly1305`},
+		{&network{secure: true}, map[string]string{netlabel.OverlayEncryptionRekeyInterval: "30s"}, `invalid encryption rekey interval "30s"`},
+		{&network{}, map[string]string{netlabel.OverlayEncryptionCipher: "aes256-gcm128"}, "the encryption cipher and rekey interval only apply to networks encrypted with IPsec"},
+		{&network{secure: true, wireguard: true}, map[string]string{netlabel.OverlayEncryptionRekeyInterval: "1h"}, "the encryption cipher and rekey interval only apply to networks encrypted with IPsec"},
+	} {
+		assert.Check(t, is.ErrorContains(tc.network.parseVxlanOptions(tc.opts), tc.err), tc.opts)
+	}
+}
+
+func TestEncrConfigMark(t *testing.T) {
+	assert.Check(t, is.Equal(defaultEncrConfig.mark(), uint32(r)))
+
+	configs := []encrConfig{
+		{port: 4790, cipher: defaultCipher},
+		{port: vxlanPort, cipher: "aes256-gcm128"},
+		{port: vxlanPort, cipher: defaultCipher, rekey: time.Hour},
+	}
+	seen := map[uint32]encrConfig{}
+	for _, c := range configs {
+		m := c.mark()
+		// The mark of the default parameters is kept in the low bytes.
+		assert.Check(t, is.Equal(m&0xffffff, uint32(r)), c)
+		assert.Check(t, m>>24 != 0, c)
+		assert.Check(t, is.Equal(m, c.mark()), c)
+		if other, ok := seen[m]; ok {
+			t.Errorf("%s and %s have the same mark %#x", c, other, m)
+		}
+		seen[m] = c
+	}
+}
+
+func TestEncrConfigKeys(t *testing.T) {
+	keys := []*key{{value: []byte("0123456789abcdef"), tag: 1}, {value: []byte("fedcba9876543210"), tag: 2}}
+
+	// The default parameters use the keys of the cluster.
+	assert.Check(t, is.DeepEqual(defaultEncrConfig.keys(keys, 0), keys, cmpKeys))
+
+	c := encrConfig{port: vxlanPort, cipher: "aes256-gcm128"}
+	derived := c.keys(keys, 0)
+	assert.Assert(t, is.Len(derived, 2))
+	for i, k := range derived {
+		assert.Check(t, is.Len(k.value, 32))
+		assert.Check(t, !bytes.Equal(k.value, keys[i].value))
+		assert.Check(t, k.tag != keys[i].tag)
+		assert.Check(t, is.DeepEqual(k, c.deriveKey(keys[i], 0), cmpKeys))
+	}
+	// The keys are not shared by the profiles with different parameters.
+	other := encrConfig{port: 4790, cipher: "aes256-gcm128"}.keys(keys, 0)
+	assert.Check(t, !bytes.Equal(derived[0].value, other[0].value))
+
+	// With a rekey interval, the keys of the current, previous and next
+	// intervals are derived from the primary key.
+	c = encrConfig{port: vxlanPort, cipher: defaultCipher, rekey: time.Hour}
+	derived = c.keys(keys, 100)
+	assert.Check(t, is.DeepEqual(derived, []*key{c.deriveKey(keys[0], 100), c.deriveKey(keys[0], 99), c.deriveKey(keys[0], 101)}, cmpKeys))
+	assert.Check(t, is.Len(derived[0].value, 16))
+	assert.Check(t, !bytes.Equal(derived[0].value, derived[1].value))
+	assert.Check(t, derived[0].tag != derived[1].tag)
+
+	assert.Check(t, is.Len(c.keys(nil, 0), 0))
+}
+
+func TestEncrConfigEpoch(t *testing.T) {
+	c := encrConfig{port: vxlanPort, cipher: defaultCipher, rekey: time.Hour}
+	start := time.Unix(3600*1000, 0)
+	assert.Check(t, is.Equal(c.epoch(start), int64(1000)))
+	assert.Check(t, is.Equal(c.epoch(start.Add(59*time.Minute)), int64(1000)))
+	assert.Check(t, is.Equal(c.epoch(start.Add(time.Hour)), int64(1001)))
+}
+
+func TestCipherPktExpansion(t *testing.T) {
+	assert.Check(t, is.Equal(espCiphers["aes128-gcm64"].pktExpansion(), 26))
+	assert.Check(t, is.Equal(espCiphers["aes128-gcm128"].pktExpansion(), 34))
+
+	n := &network{mtu: 1500, secure: true}
+	assert.Check(t, is.Equal(n.maxMTU(), 1424))
+	n.cipher = "chacha20-poly1305"
+	assert.Check(t, is.Equal(n.maxMTU(), 1416))
+}
+
+func TestUpdateKeyRing(t *testing.T) {
+	k1, k2, k3 := &key{tag: 1}, &key{tag: 2}, &key{tag: 3}
+	var indices []int
+	keys, rotated, err := updateKeyRing([]*key{k1, k2}, k3, k2, k1, func(_ []*key, newIdx, priIdx, delIdx int) {
+		indices = []int{newIdx, priIdx, delIdx}
+	})
+	assert.NilError(t, err)
+	assert.Check(t, rotated)
+	assert.Check(t, is.DeepEqual(indices, []int{2, 1, 0}))
+	assert.Check(t, is.DeepEqual(keys, []*key{k2, k3}, cmpKeys))
+
+	_, _, err = updateKeyRing([]*key{k1, k2}, nil, k3, nil, nil)
+	assert.Check(t, is.ErrorContains(err, "cannot find proper key indices"))
+	_, _, err = updateKeyRing([]*key{k1, k2}, nil, k2, k2, nil)
+	assert.Check(t, is.ErrorContains(err, "attempting to both make a key (index 1) primary and delete it"))
+}
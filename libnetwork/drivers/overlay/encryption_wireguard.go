@@ -0,0 +1,317 @@
+package overlay // import "github.com/docker/docker/libnetwork/drivers/overlay"
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/ns"
+	"github.com/docker/docker/libnetwork/types"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/crypto/curve25519"
+)
+
+// The secure overlay networks created with the "encrypted=wireguard" option
+// encrypt the VXLAN traffic between the nodes with WireGuard instead of
+// IPsec. The VXLAN packets of these networks are marked, and routed through
+// a WireGuard interface shared by all these networks, to the WireGuard peer
+// of the remote node.
+//
+// The key pair of a node is derived from the primary encryption key
+// distributed to the nodes of the cluster, and from the advertise address of
+// the node, so that every node can compute the public keys of its peers. A
+// pre-shared key, also derived from the primary key, is mixed in the
+// handshakes. When the primary key is rotated, the interface and its peers
+// are reconfigured with the keys derived from the new primary key.
+const (
+	wgLinkName     = "docker_wg0"
+	wgPort         = 51820
+	wgOverhead     = 80 // IPv6(40) + UDP(8) + WireGuard header(16) + Tag(16)
+	wgVxlanMark    = 0xD0C4E4
+	wgRouteTable   = 0xD0C4
+	wgPeerKeyLabel = "docker overlay wireguard node key"
+	wgPSKLabel     = "docker overlay wireguard preshared key"
+	wgEncryption   = "wireguard"
+)
+
+type wgMap struct {
+	// nodes maps the address of the peer nodes to their public key
+	nodes map[string]string
+	sync.Mutex
+}
+
+// wgKeys returns the WireGuard private and public keys of the node with the
+// given address, derived from the encryption key k.
+func wgKeys(k *key, nodeIP net.IP) (private, public [32]byte) {
+	mac := hmac.New(sha256.New, k.value)
+	mac.Write([]byte(wgPeerKeyLabel))
+	mac.Write([]byte(nodeIP.String()))
+	copy(private[:], mac.Sum(nil))
+	// clamp the private key as specified by Curve25519
+	private[0] &= 248
+	private[31] &= 127
+	private[31] |= 64
+	curve25519.ScalarBaseMult(&public, &private)
+	return private, public
+}
+
+// wgPresharedKey returns the WireGuard pre-shared key derived from the
+// encryption key k.
+func wgPresharedKey(k *key) []byte {
+	mac := hmac.New(sha256.New, k.value)
+	mac.Write([]byte(wgPSKLabel))
+	return mac.Sum(nil)
+}
+
+func wgEncode(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// wgSet runs "wg set" on the WireGuard interface, with the given arguments.
+// The secret referenced as "/dev/stdin" in the arguments is passed on the
+// standard input of the command, so that it does not show in its arguments.
+func wgSet(secret string, args ...string) error {
+	cmd := exec.Command("wg", append([]string{"set", wgLinkName}, args...)...)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("wg set %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// setupWireGuardLink creates the WireGuard interface if it does not exist,
+// and configures its private key from the primary encryption key.
+func (d *driver) setupWireGuardLink() error {
+	if _, err := exec.LookPath("wg"); err != nil {
+		return fmt.Errorf("wireguard tools are missing on host: %v", err)
+	}
+
+	nlh := ns.NlHandle()
+	link, err := nlh.LinkByName(wgLinkName)
+	if err != nil {
+		mtu := d.underlayMTU()
+		if mtu == 0 {
+			mtu = 1500
+		}
+		link = &netlink.GenericLink{
+			LinkAttrs: netlink.LinkAttrs{Name: wgLinkName, MTU: mtu - wgOverhead},
+			LinkType:  "wireguard",
+		}
+		if err := nlh.LinkAdd(link); err != nil {
+			return fmt.Errorf("failed to create the wireguard interface, the wireguard kernel module may be missing on host: %v", err)
+		}
+		// The decrypted VXLAN packets are received on the WireGuard interface
+		// with the address of the remote node, which is routed through the
+		// underlay interface.
+		if err := ioutil.WriteFile("/proc/sys/net/ipv4/conf/"+wgLinkName+"/rp_filter", []byte("2"), 0644); err != nil {
+			logrus.Warnf("Failed to set loose reverse path filtering on %s: %v", wgLinkName, err)
+		}
+		if err := nlh.LinkSetUp(link); err != nil {
+			return fmt.Errorf("failed to set up the wireguard interface: %v", err)
+		}
+		if err := wgSet(wgEncode(d.wgPrivateKey()), "listen-port", strconv.Itoa(wgPort), "private-key", "/dev/stdin"); err != nil {
+			return err
+		}
+	}
+
+	return programWireGuardRule(true)
+}
+
+func (d *driver) wgPrivateKey() []byte {
+	private, _ := wgKeys(d.keys[0], net.ParseIP(d.advertiseAddress))
+	return private[:]
+}
+
+// programWireGuardRule routes the marked VXLAN packets of the secure
+// networks using WireGuard through the routing table of the peers.
+func programWireGuardRule(add bool) error {
+	nlh := ns.NlHandle()
+	rule := netlink.NewRule()
+	rule.Mark = wgVxlanMark
+	rule.Table = wgRouteTable
+
+	rules, err := nlh.RuleList(netlink.FAMILY_V4)
+	if err != nil {
+		return fmt.Errorf("failed to list routing rules: %v", err)
+	}
+	exists := false
+	for _, rl := range rules {
+		if rl.Mark == rule.Mark && rl.Table == rule.Table {
+			exists = true
+			break
+		}
+	}
+	if add == exists {
+		return nil
+	}
+	if add {
+		if err := nlh.RuleAdd(rule); err != nil {
+			return fmt.Errorf("failed to add the wireguard routing rule: %v", err)
+		}
+		return nil
+	}
+	return nlh.RuleDel(rule)
+}
+
+func (d *driver) checkWireGuard(nodes map[string]net.IP, rIP net.IP, vni uint32, port uint16, add bool) error {
+	if add {
+		programMangle(vni, port, wgVxlanMark, true)
+		programWireGuardInput(vni, port, true)
+		for _, rIP := range nodes {
+			if err := d.addWireGuardPeer(rIP); err != nil {
+				logrus.Warnf("Failed to program wireguard peer %s: %v", rIP, err)
+			}
+		}
+		return nil
+	}
+	if len(nodes) == 0 {
+		if err := d.removeWireGuardPeer(rIP); err != nil {
+			logrus.Warnf("Failed to remove wireguard peer %s: %v", rIP, err)
+		}
+	}
+	return nil
+}
+
+func (d *driver) addWireGuardPeer(rIP net.IP) error {
+	logrus.Debugf("Programming wireguard peer %s", rIP)
+	if err := d.setupWireGuardLink(); err != nil {
+		return err
+	}
+	_, public := wgKeys(d.keys[0], rIP)
+	if err := programWireGuardPeer(rIP, public[:], d.keys[0]); err != nil {
+		return err
+	}
+	d.wgMap.Lock()
+	d.wgMap.nodes[rIP.String()] = wgEncode(public[:])
+	d.wgMap.Unlock()
+	return nil
+}
+
+func (d *driver) removeWireGuardPeer(rIP net.IP) error {
+	d.wgMap.Lock()
+	public, ok := d.wgMap.nodes[rIP.String()]
+	delete(d.wgMap.nodes, rIP.String())
+	d.wgMap.Unlock()
+	if !ok {
+		return nil
+	}
+	logrus.Debugf("Removing wireguard peer %s", rIP)
+	if err := wgSet("", "peer", public, "remove"); err != nil {
+		return err
+	}
+	return programWireGuardRoute(rIP, false)
+}
+
+func programWireGuardPeer(rIP net.IP, public []byte, k *key) error {
+	endpoint := net.JoinHostPort(rIP.String(), strconv.Itoa(wgPort))
+	if err := wgSet(wgEncode(wgPresharedKey(k)), "peer", wgEncode(public), "preshared-key", "/dev/stdin", "endpoint", endpoint, "allowed-ips", hostNet(rIP).String()); err != nil {
+		return err
+	}
+	return programWireGuardRoute(rIP, true)
+}
+
+func programWireGuardRoute(rIP net.IP, add bool) error {
+	nlh := ns.NlHandle()
+	link, err := nlh.LinkByName(wgLinkName)
+	if err != nil {
+		if !add {
+			return nil
+		}
+		return err
+	}
+	route := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       hostNet(rIP),
+		Scope:     netlink.SCOPE_LINK,
+		Table:     wgRouteTable,
+	}
+	if add {
+		return nlh.RouteReplace(route)
+	}
+	if err := nlh.RouteDel(route); err != nil {
+		logrus.Debugf("Failed to remove wireguard route to %s: %v", rIP, err)
+	}
+	return nil
+}
+
+func hostNet(ip net.IP) *net.IPNet {
+	ip = types.GetMinimalIP(ip)
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))}
+}
+
+// rekeyWireGuard reconfigures the WireGuard interface and its peers with the
+// keys derived from the primary key, after it was rotated.
+func (d *driver) rekeyWireGuard() {
+	if _, err := ns.NlHandle().LinkByName(wgLinkName); err != nil {
+		return
+	}
+	logrus.Debugf("Updating wireguard keys")
+
+	if err := wgSet(wgEncode(d.wgPrivateKey()), "private-key", "/dev/stdin"); err != nil {
+		logrus.Warnf("Failed to update the wireguard private key: %v", err)
+	}
+
+	d.wgMap.Lock()
+	defer d.wgMap.Unlock()
+	for rIPs, old := range d.wgMap.nodes {
+		rIP := net.ParseIP(rIPs)
+		if err := wgSet("", "peer", old, "remove"); err != nil {
+			logrus.Warnf("Failed to remove wireguard peer %s: %v", rIP, err)
+		}
+		_, public := wgKeys(d.keys[0], rIP)
+		if err := programWireGuardPeer(rIP, public[:], d.keys[0]); err != nil {
+			logrus.Warnf("Failed to program wireguard peer %s: %v", rIP, err)
+			continue
+		}
+		d.wgMap.nodes[rIPs] = wgEncode(public[:])
+	}
+}
+
+func programWireGuardInput(vni uint32, port uint16, add bool) {
+	var (
+		p          = strconv.FormatUint(uint64(port), 10)
+		vniMatch   = fmt.Sprintf("0>>22&0x3C@12&0xFFFFFF00=%d", int(vni)<<8)
+		plainVxlan = []string{"-p", "udp", "--dport", p, "-m", "u32", "--u32", vniMatch, "-j"}
+		block      = append(plainVxlan, "DROP")
+		accept     = append([]string{"-i", wgLinkName}, append(plainVxlan, "ACCEPT")...)
+		chain      = "INPUT"
+		action     = iptables.Append
+		msg        = "add"
+	)
+
+	if !add {
+		action = iptables.Delete
+		msg = "remove"
+	}
+
+	if err := iptables.ProgramRule(iptables.Filter, chain, action, accept); err != nil {
+		logrus.Errorf("could not %s input rule: %v. Please do it manually.", msg, err)
+	}
+
+	if err := iptables.ProgramRule(iptables.Filter, chain, action, block); err != nil {
+		logrus.Errorf("could not %s input rule: %v. Please do it manually.", msg, err)
+	}
+}
+
+// clearWireGuardState removes the WireGuard interface, and its peers, left
+// over by a previous run of the daemon.
+func clearWireGuardState() {
+	nlh := ns.NlHandle()
+	if link, err := nlh.LinkByName(wgLinkName); err == nil {
+		if err := nlh.LinkDel(link); err != nil {
+			logrus.Warnf("Failed to delete stale wireguard interface: %v", err)
+		}
+	}
+	if err := programWireGuardRule(false); err != nil {
+		logrus.Warnf("Failed to remove stale wireguard routing rule: %v", err)
+	}
+}
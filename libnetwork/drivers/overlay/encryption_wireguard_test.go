@@ -0,0 +1,97 @@
+package overlay // import "github.com/docker/docker/libnetwork/drivers/overlay"
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestWireGuardKeys(t *testing.T) {
+	k := &key{value: []byte("0123456789abcdef"), tag: 1}
+	node1 := net.ParseIP("192.0.2.1")
+	node2 := net.ParseIP("192.0.2.2")
+
+	private1, public1 := wgKeys(k, node1)
+	private2, public2 := wgKeys(k, node2)
+
+	// The keys of a node are the same on every node.
+	p, q := wgKeys(k, net.ParseIP("192.0.2.1"))
+	assert.Check(t, is.Equal(p, private1))
+	assert.Check(t, is.Equal(q, public1))
+	assert.Check(t, private1 != private2)
+	assert.Check(t, public1 != public2)
+
+	// The private keys are clamped, and the public keys are derived from
+	// them.
+	for _, private := range [][32]byte{private1, private2} {
+		assert.Check(t, is.Equal(private[0]&7, byte(0)))
+		assert.Check(t, is.Equal(private[31]&0xc0, byte(0x40)))
+	}
+	var expected [32]byte
+	curve25519.ScalarBaseMult(&expected, &private1)
+	assert.Check(t, is.Equal(public1, expected))
+
+	// Both nodes compute the same shared secret.
+	var s1, s2 [32]byte
+	curve25519.ScalarMult(&s1, &private1, &public2)
+	curve25519.ScalarMult(&s2, &private2, &public1)
+	assert.Check(t, is.Equal(s1, s2))
+
+	// The keys change with the encryption key.
+	_, rotated := wgKeys(&key{value: []byte("fedcba9876543210"), tag: 2}, node1)
+	assert.Check(t, rotated != public1)
+}
+
+func TestWireGuardPresharedKey(t *testing.T) {
+	k := &key{value: []byte("0123456789abcdef"), tag: 1}
+	psk := wgPresharedKey(k)
+	assert.Check(t, is.Len(psk, 32))
+	assert.Check(t, bytes.Equal(psk, wgPresharedKey(&key{value: []byte("0123456789abcdef"), tag: 1})))
+	assert.Check(t, !bytes.Equal(psk, wgPresharedKey(&key{value: []byte("fedcba9876543210"), tag: 2})))
+
+	// The pre-shared key is not the private key of any node.
+	private, _ := wgKeys(k, net.ParseIP("192.0.2.1"))
+	assert.Check(t, !bytes.Equal(psk, private[:]))
+
+	// wg expects the keys in base64.
+	b, err := base64.StdEncoding.DecodeString(wgEncode(psk))
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(b, psk))
+}
+
+func TestWireGuardHostNet(t *testing.T) {
+	assert.Check(t, is.Equal(hostNet(net.ParseIP("192.0.2.1")).String(), "192.0.2.1/32"))
+	assert.Check(t, is.Equal(hostNet(net.ParseIP("2001:db8::1")).String(), "2001:db8::1/128"))
+}
+
+func TestWireGuardMTU(t *testing.T) {
+	for _, tc := range []struct {
+		n   *network
+		mtu int
+	}{
+		{&network{mtu: 1500}, 1500 - vxlanEncap},
+		{&network{mtu: 1500, secure: true, wireguard: true}, 1500 - vxlanEncap - wgOverhead},
+		{&network{mtu: 9000, secure: true, wireguard: true}, 9000 - vxlanEncap - wgOverhead},
+	} {
+		assert.Check(t, is.Equal(tc.n.maxMTU(), tc.mtu))
+	}
+}
+
+func TestWireGuardNetworkValue(t *testing.T) {
+	n := &network{secure: true, wireguard: true}
+	restored := &network{}
+	assert.NilError(t, restored.SetValue(n.Value()))
+	assert.Check(t, restored.secure)
+	assert.Check(t, restored.wireguard)
+
+	// The networks stored before the wireguard mode use IPsec.
+	restored = &network{}
+	assert.NilError(t, restored.SetValue([]byte(`{"secure":true,"subnets":[]}`)))
+	assert.Check(t, restored.secure)
+	assert.Check(t, !restored.wireguard)
+}
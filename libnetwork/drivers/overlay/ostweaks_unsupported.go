@@ -0,0 +1,5 @@
+// +build !linux
+
+package overlay // import "github.com/docker/docker/libnetwork/drivers/overlay"
+
+func applyOStweaks() {}
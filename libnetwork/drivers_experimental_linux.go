@@ -0,0 +1,9 @@
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import "github.com/docker/docker/libnetwork/drivers/ipvlan"
+
+func additionalDrivers() []initializer {
+	return []initializer{
+		{ipvlan.Init, "ipvlan"},
+	}
+}
@@ -0,0 +1,26 @@
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import (
+	"github.com/docker/docker/libnetwork/drivers/bridge"
+	"github.com/docker/docker/libnetwork/drivers/host"
+	"github.com/docker/docker/libnetwork/drivers/macvlan"
+	"github.com/docker/docker/libnetwork/drivers/null"
+	"github.com/docker/docker/libnetwork/drivers/overlay"
+	"github.com/docker/docker/libnetwork/drivers/remote"
+)
+
+func getInitializers(experimental bool) []initializer {
+	in := []initializer{
+		{bridge.Init, "bridge"},
+		{host.Init, "host"},
+		{macvlan.Init, "macvlan"},
+		{null.Init, "null"},
+		{remote.Init, "remote"},
+		{overlay.Init, "overlay"},
+	}
+
+	if experimental {
+		in = append(in, additionalDrivers()...)
+	}
+	return in
+}
@@ -0,0 +1,110 @@
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import (
+	"github.com/docker/docker/libnetwork/iptables"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/options"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	userChain          = "DOCKER-USER"
+	networkPolicyChain = "DOCKER-NETWORK-POLICY"
+)
+
+func (c *controller) arrangeUserFilterRule() {
+	c.arrangeFilterRules()
+	iptables.OnReloaded(c.arrangeFilterRules)
+}
+
+// arrangeFilterRules programs the rules of the network policies, and keeps
+// the jumps to the DOCKER-USER and DOCKER-NETWORK-POLICY chains, in this
+// order, on top of the FORWARD chain.
+func (c *controller) arrangeFilterRules() {
+	c.arrangeNetworkPolicyRules()
+	c.Lock()
+	arrangeUserFilterRule()
+	c.Unlock()
+}
+
+// This chain allow users to configure firewall policies in a way that persists
+// docker operations/restarts. Docker will not delete or modify any pre-existing
+// rules from the DOCKER-USER filter chain.
+func arrangeUserFilterRule() {
+	_, err := iptables.NewChain(userChain, iptables.Filter, false)
+	if err != nil {
+		logrus.Warnf("Failed to create %s chain: %v", userChain, err)
+		return
+	}
+
+	if err = iptables.AddReturnRule(userChain); err != nil {
+		logrus.Warnf("Failed to add the RETURN rule for %s: %v", userChain, err)
+		return
+	}
+
+	err = iptables.EnsureJumpRule("FORWARD", userChain)
+	if err != nil {
+		logrus.Warnf("Failed to ensure the jump rule for %s: %v", userChain, err)
+	}
+}
+
+// arrangeNetworkPolicyRules replaces the rules of the DOCKER-NETWORK-POLICY
+// chain with the ones of the network policies. The chain is evaluated before
+// the chains isolating the networks from each other.
+func (c *controller) arrangeNetworkPolicyRules() {
+	versions := []iptables.IPVersion{iptables.IPv4}
+	// the IPv6 rules are only programmed with ip6tables enabled, as the
+	// bridge driver does
+	if c.ip6tablesEnabled() {
+		versions = append(versions, iptables.IPv6)
+	}
+	for _, version := range versions {
+		iptable := iptables.GetIptable(version)
+		rules := c.networkPolicyRules(version == iptables.IPv6)
+		if len(rules) == 0 && !iptable.ExistChain(networkPolicyChain, iptables.Filter) {
+			continue
+		}
+
+		if _, err := iptable.NewChain(networkPolicyChain, iptables.Filter, false); err != nil {
+			logrus.Warnf("Failed to create %s chain: %v", networkPolicyChain, err)
+			continue
+		}
+		if err := iptable.RawCombinedOutput("-F", networkPolicyChain); err != nil {
+			logrus.Warnf("Failed to flush %s chain: %v", networkPolicyChain, err)
+			continue
+		}
+		for _, rule := range rules {
+			if err := iptable.ProgramRule(iptables.Filter, networkPolicyChain, iptables.Append, rule); err != nil {
+				logrus.Warnf("Failed to program network policy rule %v: %v", rule, err)
+			}
+		}
+		if err := iptable.AddReturnRule(networkPolicyChain); err != nil {
+			logrus.Warnf("Failed to add the RETURN rule for %s: %v", networkPolicyChain, err)
+			continue
+		}
+		if err := iptable.EnsureJumpRule("FORWARD", networkPolicyChain); err != nil {
+			logrus.Warnf("Failed to ensure the jump rule for %s: %v", networkPolicyChain, err)
+		}
+	}
+}
+
+// ip6tablesEnabled returns whether the bridge driver is configured to program
+// the IPv6 rules with ip6tables, which is disabled unless enabled explicitly.
+func (c *controller) ip6tablesEnabled() bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.cfg == nil {
+		return false
+	}
+	cfgBridge, ok := c.cfg.Daemon.DriverCfg["bridge"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	cfgGeneric, ok := cfgBridge[netlabel.GenericData].(options.Generic)
+	if !ok {
+		return false
+	}
+	enabled, _ := cfgGeneric["EnableIP6Tables"].(bool)
+	return enabled
+}
@@ -0,0 +1,21 @@
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import (
+	"testing"
+
+	"github.com/docker/docker/libnetwork/config"
+	"github.com/docker/docker/libnetwork/netlabel"
+	"github.com/docker/docker/libnetwork/options"
+	"gotest.tools/assert"
+)
+
+func TestIP6TablesEnabled(t *testing.T) {
+	bridgeConfig := func(generic options.Generic) *config.Config {
+		return config.ParseConfigOptions(config.OptionDriverConfig("bridge", options.Generic{netlabel.GenericData: generic}))
+	}
+
+	assert.Check(t, !(&controller{}).ip6tablesEnabled())
+	assert.Check(t, !(&controller{cfg: bridgeConfig(options.Generic{"EnableIPTables": true})}).ip6tablesEnabled())
+	assert.Check(t, !(&controller{cfg: bridgeConfig(options.Generic{"EnableIP6Tables": false})}).ip6tablesEnabled())
+	assert.Check(t, (&controller{cfg: bridgeConfig(options.Generic{"EnableIP6Tables": true})}).ip6tablesEnabled())
+}
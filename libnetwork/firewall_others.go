@@ -0,0 +1,9 @@
+// +build !linux
+
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+func (c *controller) arrangeUserFilterRule() {
+}
+
+func (c *controller) arrangeFilterRules() {
+}
@@ -0,0 +1,14 @@
+package ipamapi // import "github.com/docker/docker/libnetwork/ipamapi"
+
+const (
+	// Prefix constant marks the reserved label space for libnetwork
+	Prefix = "com.docker.network"
+
+	// AllocSerialPrefix constant marks the reserved label space for libnetwork ipam
+	// allocation ordering.(serial/first available)
+	AllocSerialPrefix = Prefix + ".ipam.serial"
+
+	// NetworkDriverLabel is the pool request option carrying the driver of
+	// the network, for the built-in ipam to pick a pool reserved to it
+	NetworkDriverLabel = Prefix + ".ipam.network_driver"
+)
@@ -0,0 +1,235 @@
+// Package ipamutils provides utility functions for ipam management
+package ipamutils // import "github.com/docker/docker/libnetwork/ipamutils"
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+var (
+	// PredefinedLocalScopeDefaultNetworks contains a list of 31 IPv4 private networks with host size 16 and 12
+	// (172.17-31.x.x/16, 192.168.x.x/20) which do not overlap with the networks in `PredefinedGlobalScopeDefaultNetworks`
+	PredefinedLocalScopeDefaultNetworks []*net.IPNet
+	// PredefinedGlobalScopeDefaultNetworks contains a list of 64K IPv4 private networks with host size 8
+	// (10.x.x.x/24) which do not overlap with the networks in `PredefinedLocalScopeDefaultNetworks`
+	PredefinedGlobalScopeDefaultNetworks []*net.IPNet
+	mutex                                sync.Mutex
+	localScopeDefaultNetworks            = []*NetworkToSplit{{Base: "172.17.0.0/16", Size: 16}, {Base: "172.18.0.0/16", Size: 16}, {Base: "172.19.0.0/16", Size: 16},
+		{Base: "172.20.0.0/14", Size: 16}, {Base: "172.24.0.0/14", Size: 16}, {Base: "172.28.0.0/14", Size: 16},
+		{Base: "192.168.0.0/16", Size: 20}}
+	globalScopeDefaultNetworks = []*NetworkToSplit{{Base: "10.0.0.0/8", Size: 24}}
+
+	// localScopeDriverNetworks holds the local scope default networks
+	// reserved to the networks of a driver, by driver
+	localScopeDriverNetworks = make(map[string][]*net.IPNet)
+	// localScopePools and globalScopePools are the pools the default
+	// networks are split from
+	localScopePools  = localScopeDefaultNetworks
+	globalScopePools = globalScopeDefaultNetworks
+)
+
+// OverlayDriver is the driver of the networks whose default networks are the
+// global scope ones
+const OverlayDriver = "overlay"
+
+// NetworkToSplit represent a network that has to be split in chunks with mask length Size.
+// Each subnet in the set is derived from the Base pool. Base is to be passed
+// in CIDR format.
+// Example: a Base "10.10.0.0/16 with Size 24 will define the set of 256
+// 10.10.[0-255].0/24 address pools
+type NetworkToSplit struct {
+	Base string `json:"base"`
+	Size int    `json:"size"`
+	// Driver reserves the pool to the networks of a driver, for example
+	// "bridge". The networks of a driver are allocated from its reserved
+	// pools first, then from the pools without driver. The pools of the
+	// "overlay" driver are the global scope default pools.
+	Driver string `json:"driver,omitempty"`
+}
+
+func init() {
+	var err error
+	if PredefinedGlobalScopeDefaultNetworks, err = splitNetworks(globalScopeDefaultNetworks); err != nil {
+		//we are going to panic in case of error as we should never get into this state
+		panic("InitAddressPools failed to initialize the global scope default address pool")
+	}
+
+	if PredefinedLocalScopeDefaultNetworks, err = splitNetworks(localScopeDefaultNetworks); err != nil {
+		//we are going to panic in case of error as we should never get into this state
+		panic("InitAddressPools failed to initialize the local scope default address pool")
+	}
+}
+
+// configDefaultNetworks configures local as well global default pool based on input
+func configDefaultNetworks(defaultAddressPool []*NetworkToSplit, result *[]*net.IPNet) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	defaultNetworks, err := splitNetworks(defaultAddressPool)
+	if err != nil {
+		return err
+	}
+	*result = defaultNetworks
+	return nil
+}
+
+// GetGlobalScopeDefaultNetworks returns PredefinedGlobalScopeDefaultNetworks
+func GetGlobalScopeDefaultNetworks() []*net.IPNet {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return PredefinedGlobalScopeDefaultNetworks
+}
+
+// GetLocalScopeDefaultNetworks returns PredefinedLocalScopeDefaultNetworks
+func GetLocalScopeDefaultNetworks() []*net.IPNet {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return PredefinedLocalScopeDefaultNetworks
+}
+
+// GetLocalScopeDriverNetworks returns the local scope default networks
+// reserved to the networks of the given driver
+func GetLocalScopeDriverNetworks(driver string) []*net.IPNet {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return localScopeDriverNetworks[driver]
+}
+
+// GetLocalScopeDefaultPools returns the pools the local scope default
+// networks are split from
+func GetLocalScopeDefaultPools() []*NetworkToSplit {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return append([]*NetworkToSplit(nil), localScopePools...)
+}
+
+// GetGlobalScopeDefaultPools returns the pools the global scope default
+// networks are split from
+func GetGlobalScopeDefaultPools() []*NetworkToSplit {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return append([]*NetworkToSplit(nil), globalScopePools...)
+}
+
+// ConfigGlobalScopeDefaultNetworks configures global default pool.
+// Ideally this will be called from SwarmKit as part of swarm init
+func ConfigGlobalScopeDefaultNetworks(defaultAddressPool []*NetworkToSplit) error {
+	if defaultAddressPool == nil {
+		mutex.Lock()
+		defaultAddressPool = globalScopeDefaultNetworks
+		mutex.Unlock()
+	}
+	if err := configDefaultNetworks(defaultAddressPool, &PredefinedGlobalScopeDefaultNetworks); err != nil {
+		return err
+	}
+	mutex.Lock()
+	globalScopePools = defaultAddressPool
+	mutex.Unlock()
+	return nil
+}
+
+// ConfigLocalScopeDefaultNetworks configures local default pool.
+// Ideally this will be called during libnetwork init. The pools of the
+// "overlay" driver replace the global default pool, used when swarm is not
+// configured with a default address pool of its own.
+func ConfigLocalScopeDefaultNetworks(defaultAddressPool []*NetworkToSplit) error {
+	if defaultAddressPool == nil {
+		return nil
+	}
+
+	var (
+		shared, overlay, local []*NetworkToSplit
+		drivers                = make(map[string][]*NetworkToSplit)
+	)
+	for _, p := range defaultAddressPool {
+		switch p.Driver {
+		case "":
+			shared = append(shared, p)
+		case OverlayDriver:
+			overlay = append(overlay, p)
+			continue
+		default:
+			drivers[p.Driver] = append(drivers[p.Driver], p)
+		}
+		local = append(local, p)
+	}
+
+	if len(overlay) > 0 {
+		if _, err := splitNetworks(overlay); err != nil {
+			return err
+		}
+		mutex.Lock()
+		globalScopeDefaultNetworks = overlay
+		mutex.Unlock()
+		if err := ConfigGlobalScopeDefaultNetworks(nil); err != nil {
+			return err
+		}
+	}
+	// the built-in pools are kept if only overlay pools are configured
+	if len(local) == 0 {
+		return nil
+	}
+
+	driverNetworks := make(map[string][]*net.IPNet, len(drivers))
+	for d, pools := range drivers {
+		nws, err := splitNetworks(pools)
+		if err != nil {
+			return err
+		}
+		driverNetworks[d] = nws
+	}
+	if err := configDefaultNetworks(shared, &PredefinedLocalScopeDefaultNetworks); err != nil {
+		return err
+	}
+	mutex.Lock()
+	localScopeDriverNetworks = driverNetworks
+	localScopePools = local
+	mutex.Unlock()
+	return nil
+}
+
+// splitNetworks takes a slice of networks, split them accordingly and returns them
+func splitNetworks(list []*NetworkToSplit) ([]*net.IPNet, error) {
+	localPools := make([]*net.IPNet, 0, len(list))
+
+	for _, p := range list {
+		_, b, err := net.ParseCIDR(p.Base)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base pool %q: %v", p.Base, err)
+		}
+		ones, _ := b.Mask.Size()
+		if p.Size <= 0 || p.Size < ones {
+			return nil, fmt.Errorf("invalid pools size: %d", p.Size)
+		}
+		localPools = append(localPools, splitNetwork(p.Size, b)...)
+	}
+	return localPools, nil
+}
+
+func splitNetwork(size int, base *net.IPNet) []*net.IPNet {
+	one, bits := base.Mask.Size()
+	mask := net.CIDRMask(size, bits)
+	n := 1 << uint(size-one)
+	s := uint(bits - size)
+	list := make([]*net.IPNet, 0, n)
+
+	for i := 0; i < n; i++ {
+		ip := copyIP(base.IP)
+		addIntToIP(ip, uint(i<<s))
+		list = append(list, &net.IPNet{IP: ip, Mask: mask})
+	}
+	return list
+}
+
+func copyIP(from net.IP) net.IP {
+	ip := make([]byte, len(from))
+	copy(ip, from)
+	return ip
+}
+
+func addIntToIP(array net.IP, ordinal uint) {
+	for i := len(array) - 1; i >= 0; i-- {
+		array[i] |= (byte)(ordinal & 0xff)
+		ordinal >>= 8
+	}
+}
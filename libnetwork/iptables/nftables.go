@@ -0,0 +1,416 @@
+package iptables // import "github.com/docker/docker/libnetwork/iptables"
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FirewallBackend is the backend programming the firewall rules.
+type FirewallBackend string
+
+const (
+	// IptablesBackend programs the rules with the iptables binaries.
+	IptablesBackend FirewallBackend = "iptables"
+	// NftablesBackend programs the rules with the nft binary, in tables
+	// dedicated to docker.
+	NftablesBackend FirewallBackend = "nftables"
+)
+
+var (
+	backend = IptablesBackend
+	nftPath string
+	// ErrNftablesNotFound is returned when the nft binary is not found.
+	ErrNftablesNotFound = errors.New("nft not found")
+
+	nftTablesMu sync.Mutex
+	nftTables   = make(map[string]bool)
+)
+
+// nftBaseChains holds the definition of the nftables base chains standing
+// for the built-in chains of each iptables table. The priorities are the
+// ones of the iptables tables, so that the rules are evaluated in the same
+// order relatively to the other tables.
+var nftBaseChains = map[Table]map[string]string{
+	Filter: {
+		"INPUT":   "type filter hook input priority 0",
+		"FORWARD": "type filter hook forward priority 0",
+		"OUTPUT":  "type filter hook output priority 0",
+	},
+	Nat: {
+		"PREROUTING":  "type nat hook prerouting priority -100",
+		"INPUT":       "type nat hook input priority 100",
+		"OUTPUT":      "type nat hook output priority -100",
+		"POSTROUTING": "type nat hook postrouting priority 100",
+	},
+	Mangle: {
+		"PREROUTING":  "type filter hook prerouting priority -150",
+		"INPUT":       "type filter hook input priority -150",
+		"FORWARD":     "type filter hook forward priority -150",
+		"OUTPUT":      "type route hook output priority -150",
+		"POSTROUTING": "type filter hook postrouting priority -150",
+	},
+}
+
+// ValidateFirewallBackend checks that b is a backend programming the firewall
+// rules, the empty backend being the iptables backend.
+func ValidateFirewallBackend(b FirewallBackend) error {
+	switch b {
+	case "", IptablesBackend, NftablesBackend:
+		return nil
+	default:
+		return fmt.Errorf("invalid firewall backend %q: must be %q or %q", b, IptablesBackend, NftablesBackend)
+	}
+}
+
+// SetFirewallBackend selects the backend programming the firewall rules. It
+// must be called before any rule is programmed.
+func SetFirewallBackend(b FirewallBackend) error {
+	if err := ValidateFirewallBackend(b); err != nil {
+		return err
+	}
+	if b == "" {
+		b = IptablesBackend
+	}
+	backend = b
+	return nil
+}
+
+// GetFirewallBackend returns the backend programming the firewall rules.
+func GetFirewallBackend() FirewallBackend {
+	return backend
+}
+
+func detectNftables() {
+	if path, err := exec.LookPath("nft"); err == nil {
+		nftPath = path
+	}
+}
+
+// nftTable returns the family and the name of the nftables table holding the
+// rules of an iptables table.
+func (iptable IPTable) nftTable(table Table) (string, string) {
+	family := "ip"
+	if iptable.isIPv6() {
+		family = "ip6"
+	}
+	return family, "docker_" + string(table)
+}
+
+// nftRaw translates the iptables command specified by args to nftables, and
+// runs it.
+func (iptable IPTable) nftRaw(args ...string) ([]byte, error) {
+	var (
+		table   = Filter
+		command string
+		chain   string
+		rule    []string
+	)
+parse:
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; a {
+		case "-t":
+			if i+1 == len(args) {
+				return nil, errors.New("missing table name")
+			}
+			i++
+			table = Table(args[i])
+		case "-n", "--wait":
+		case "-A", "-I", "-D", "-C", "-N", "-X", "-F", "-L", "-nL", "-S", "-P":
+			command = a
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") && args[i+1] != "!" {
+				i++
+				chain = args[i]
+			}
+			rule = args[i+1:]
+			break parse
+		default:
+			return nil, fmt.Errorf("iptables option %s is not supported by the nftables backend", a)
+		}
+	}
+	if _, ok := nftBaseChains[table]; !ok {
+		return nil, fmt.Errorf("iptables table %s is not supported by the nftables backend", table)
+	}
+
+	family, name := iptable.nftTable(table)
+	if err := iptable.nftEnsureTable(table); err != nil {
+		return nil, err
+	}
+
+	switch command {
+	case "-N":
+		return nftRun("add", "chain", family, name, chain)
+	case "-X":
+		return nftRun("delete", "chain", family, name, chain)
+	case "-F":
+		if chain == "" {
+			return nftRun("flush", "table", family, name)
+		}
+		return nftRun("flush", "chain", family, name, chain)
+	case "-L", "-nL", "-S":
+		if chain == "" {
+			return nftRun("list", "table", family, name)
+		}
+		return nftRun("list", "chain", family, name, chain)
+	case "-P":
+		spec, ok := nftBaseChains[table][chain]
+		if !ok || len(rule) != 1 {
+			return nil, fmt.Errorf("cannot set the policy of chain %s/%s", table, chain)
+		}
+		return nftRun(nftChainArgs(family, name, chain, spec, strings.ToLower(rule[0]))...)
+	case "-A", "-I", "-C", "-D":
+	default:
+		return nil, errors.New("missing iptables command")
+	}
+
+	if command == "-I" && len(rule) > 0 && rule[0] == "1" {
+		// inserting at the first position is the default
+		rule = rule[1:]
+	}
+	exprs, err := iptable.nftRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	comment := nftRuleComment(rule)
+
+	switch command {
+	case "-A", "-I":
+		verb := "add"
+		if command == "-I" {
+			verb = "insert"
+		}
+		cmd := append([]string{verb, "rule", family, name, chain}, exprs...)
+		return nftRun(append(cmd, "comment", comment)...)
+	default:
+		handle, err := nftRuleHandle(family, name, chain, comment)
+		if err != nil {
+			return nil, err
+		}
+		if command == "-C" {
+			return nil, nil
+		}
+		return nftRun("delete", "rule", family, name, chain, "handle", handle)
+	}
+}
+
+// nftEnsureTable creates the nftables table of an iptables table, along with
+// its base chains, unless it was already created.
+func (iptable IPTable) nftEnsureTable(table Table) error {
+	family, name := iptable.nftTable(table)
+	key := family + " " + name
+
+	nftTablesMu.Lock()
+	defer nftTablesMu.Unlock()
+	if nftTables[key] {
+		return nil
+	}
+	if _, err := nftRun("add", "table", family, name); err != nil {
+		return err
+	}
+	for chain, spec := range nftBaseChains[table] {
+		if _, err := nftRun(nftChainArgs(family, name, chain, spec, "")...); err != nil {
+			return err
+		}
+	}
+	nftTables[key] = true
+	return nil
+}
+
+func nftChainArgs(family, table, chain, spec, policy string) []string {
+	args := append([]string{"add", "chain", family, table, chain, "{"}, strings.Fields(spec)...)
+	args = append(args, ";")
+	if policy != "" {
+		args = append(args, "policy", policy, ";")
+	}
+	return append(args, "}")
+}
+
+// nftRule translates the matches and the target of an iptables rule to
+// nftables expressions. It returns an error if the rule has no equivalent.
+func (iptable IPTable) nftRule(rule []string) ([]string, error) {
+	var (
+		exprs      []string
+		proto      string
+		negate     bool
+		target     string
+		targetOpts = make(map[string]string)
+		addr       = "ip"
+	)
+	if iptable.isIPv6() {
+		addr = "ip6"
+	}
+	match := func(keys []string, value string) {
+		exprs = append(exprs, keys...)
+		if negate {
+			exprs = append(exprs, "!=")
+		}
+		exprs = append(exprs, value)
+		negate = false
+	}
+
+	for i := 0; i < len(rule); i++ {
+		opt := rule[i]
+		switch opt {
+		case "!":
+			negate = true
+			continue
+		case "--checksum-fill":
+			continue
+		}
+		if i+1 == len(rule) {
+			return nil, fmt.Errorf("missing value for iptables option %s", opt)
+		}
+		i++
+		value := rule[i]
+
+		switch opt {
+		case "-m", "--comment":
+			// the options of the modules are translated on their own
+		case "-s", "--source", "--src":
+			if value != "0/0" && value != "::/0" {
+				match([]string{addr, "saddr"}, value)
+			}
+		case "-d", "--destination", "--dst":
+			if value != "0/0" && value != "::/0" {
+				match([]string{addr, "daddr"}, value)
+			}
+		case "-i", "--in-interface":
+			match([]string{"iifname"}, nftInterface(value))
+		case "-o", "--out-interface":
+			match([]string{"oifname"}, nftInterface(value))
+		case "-p", "--protocol":
+			proto = value
+			match([]string{"meta", "l4proto"}, value)
+		case "--dport", "--sport":
+			if proto == "" {
+				return nil, fmt.Errorf("iptables option %s requires a protocol", opt)
+			}
+			match([]string{proto, strings.TrimPrefix(opt, "--")}, strings.Replace(value, ":", "-", 1))
+		case "--dst-type":
+			match([]string{"fib", "daddr", "type"}, strings.ToLower(value))
+		case "--src-type":
+			match([]string{"fib", "saddr", "type"}, strings.ToLower(value))
+		case "--ctstate", "--state":
+			match([]string{"ct", "state"}, strings.ToLower(value))
+		case "--mark":
+			match([]string{"meta", "mark"}, value)
+		case "-j", "--jump":
+			target = value
+		case "--to-destination", "--to-source", "--set-mark", "--set-xmark":
+			targetOpts[opt] = value
+		default:
+			return nil, fmt.Errorf("iptables option %s is not supported by the nftables backend", opt)
+		}
+	}
+	if target == "" {
+		return nil, errors.New("missing iptables target")
+	}
+
+	verdict, err := nftTarget(target, targetOpts)
+	if err != nil {
+		return nil, err
+	}
+	return append(exprs, verdict...), nil
+}
+
+// nftTarget translates an iptables target along with its options. It returns
+// an error if the target has no equivalent in nftables.
+func nftTarget(target string, opts map[string]string) ([]string, error) {
+	option := func(names ...string) (string, error) {
+		for _, name := range names {
+			if v, ok := opts[name]; ok {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("missing option %s for iptables target %s", names[0], target)
+	}
+
+	switch target {
+	case "ACCEPT", "DROP", "RETURN", "MASQUERADE", "REJECT":
+		return []string{strings.ToLower(target)}, nil
+	case "DNAT":
+		to, err := option("--to-destination")
+		return []string{"dnat", "to", to}, err
+	case "SNAT":
+		to, err := option("--to-source")
+		return []string{"snat", "to", to}, err
+	case "MARK":
+		mark, err := option("--set-mark", "--set-xmark")
+		return []string{"meta", "mark", "set", strings.TrimSuffix(mark, "/0xffffffff")}, err
+	case "CHECKSUM":
+		// nftables has no statement filling the checksums.
+		return nil, fmt.Errorf("iptables target %s is not supported by the nftables backend", target)
+	}
+	// Any other target is a user-defined chain
+	return []string{"jump", target}, nil
+}
+
+// nftInterface returns the nftables string matching an interface name. The
+// iptables "+" wildcard is translated to "*".
+func nftInterface(name string) string {
+	if strings.HasSuffix(name, "+") {
+		name = strings.TrimSuffix(name, "+") + "*"
+	}
+	return `"` + name + `"`
+}
+
+// nftRuleComment returns the comment identifying the nftables rule
+// translated from an iptables rule, as nftables rules can only be checked
+// and deleted through their handle.
+func nftRuleComment(rule []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(rule, " ")))
+	return `"docker:` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// nftRuleHandle returns the handle of the rule identified by comment in the
+// specified chain.
+func nftRuleHandle(family, table, chain, comment string) (string, error) {
+	out, err := nftRun("-a", "list", "chain", family, table, chain)
+	if err != nil {
+		return "", err
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		if !strings.Contains(line, "comment "+comment) {
+			continue
+		}
+		if i := strings.LastIndex(line, "# handle "); i >= 0 {
+			return strings.TrimSpace(line[i+len("# handle "):]), nil
+		}
+	}
+	return "", fmt.Errorf("rule %s not found in chain %s %s %s", comment, family, table, chain)
+}
+
+func nftRun(args ...string) ([]byte, error) {
+	if err := initCheck(); err != nil {
+		return nil, err
+	}
+	// Stop parsing options after the leading ones, as the negative chain
+	// priorities would be mistaken for options.
+	var opts []string
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		opts = append(opts, args[0])
+		args = args[1:]
+	}
+	args = append(append(opts, "--"), args...)
+
+	logrus.Debugf("%s, %v", nftPath, args)
+
+	startTime := time.Now()
+	output, err := exec.Command(nftPath, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("nft failed: nft %v: %s (%s)", strings.Join(args, " "), output, err)
+	}
+
+	return filterOutput(startTime, output, args...), nil
+}
@@ -0,0 +1,141 @@
+package iptables // import "github.com/docker/docker/libnetwork/iptables"
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// TestNftRule checks the translations of the rules programmed by the bridge
+// driver, the port mappings, the links, the embedded DNS server and the
+// network policies.
+func TestNftRule(t *testing.T) {
+	for _, tc := range []struct {
+		version IPVersion
+		rule    string
+		nft     string
+	}{
+		// bridge driver
+		{IPv4, "-s 172.17.0.0/16 ! -o docker0 -j MASQUERADE", `ip saddr 172.17.0.0/16 oifname != "docker0" masquerade`},
+		{IPv4, "-m addrtype --src-type LOCAL -o docker0 -j MASQUERADE", `fib saddr type local oifname "docker0" masquerade`},
+		{IPv4, "-i docker0 -j RETURN", `iifname "docker0" return`},
+		{IPv4, "-i docker0 ! -o docker0 -j ACCEPT", `iifname "docker0" oifname != "docker0" accept`},
+		{IPv4, "-i docker0 -o docker0 -j ACCEPT", `iifname "docker0" oifname "docker0" accept`},
+		{IPv4, "-i docker0 -o docker0 -j DROP", `iifname "docker0" oifname "docker0" drop`},
+		{IPv4, "-i docker0 -o docker0 -d 224.0.0.0/4 -j ACCEPT", `iifname "docker0" oifname "docker0" ip daddr 224.0.0.0/4 accept`},
+		{IPv6, "-i docker0 -o docker0 -d ff00::/8 -j ACCEPT", `iifname "docker0" oifname "docker0" ip6 daddr ff00::/8 accept`},
+		{IPv4, "-o docker0 ! -i docker0 -j ACCEPT", `oifname "docker0" iifname != "docker0" accept`},
+		{IPv4, "-i docker0 ! -o docker0 -j DOCKER-ISOLATION-STAGE-2", `iifname "docker0" oifname != "docker0" jump DOCKER-ISOLATION-STAGE-2`},
+		{IPv4, "-o docker0 -j DROP", `oifname "docker0" drop`},
+		{IPv4, "-i docker0 ! -d 172.17.0.0/16 -j DROP", `iifname "docker0" ip daddr != 172.17.0.0/16 drop`},
+		{IPv4, "-o docker0 ! -s 172.17.0.0/16 -j DROP", `oifname "docker0" ip saddr != 172.17.0.0/16 drop`},
+		{IPv4, "-j DOCKER-ISOLATION", `jump DOCKER-ISOLATION`},
+
+		// chains
+		{IPv4, "-m addrtype --dst-type LOCAL -j DOCKER", `fib daddr type local jump DOCKER`},
+		{IPv4, "-m addrtype --dst-type LOCAL ! --dst 127.0.0.0/8 -j DOCKER", `fib daddr type local ip daddr != 127.0.0.0/8 jump DOCKER`},
+		{IPv6, "-m addrtype --dst-type LOCAL ! --dst ::1/128 -j DOCKER", `fib daddr type local ip6 daddr != ::1/128 jump DOCKER`},
+		{IPv4, "-o docker0 -j DOCKER", `oifname "docker0" jump DOCKER`},
+		{IPv4, "-o docker0 -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT", `oifname "docker0" ct state related,established accept`},
+		{IPv4, "-j RETURN", `return`},
+		{IPv4, "-j DOCKER-USER", `jump DOCKER-USER`},
+
+		// port mappings
+		{IPv4, "-p tcp -d 0/0 --dport 8080 -j DNAT --to-destination 172.17.0.2:80 ! -i docker0", `meta l4proto tcp tcp dport 8080 iifname != "docker0" dnat to 172.17.0.2:80`},
+		{IPv4, "-p udp -d 192.0.2.1 --dport 53 -j DNAT --to-destination 172.17.0.2:53", `meta l4proto udp ip daddr 192.0.2.1 udp dport 53 dnat to 172.17.0.2:53`},
+		{IPv6, "-p tcp -d ::/0 --dport 8080 -j DNAT --to-destination [fd00::2]:80 ! -i docker0", `meta l4proto tcp tcp dport 8080 iifname != "docker0" dnat to [fd00::2]:80`},
+		{IPv4, "-p sctp -d 0/0 --dport 9999 -j DNAT --to-destination 172.17.0.2:9999", `meta l4proto sctp sctp dport 9999 dnat to 172.17.0.2:9999`},
+		{IPv4, "! -i docker0 -o docker0 -p tcp -d 172.17.0.2 --dport 80 -j ACCEPT", `iifname != "docker0" oifname "docker0" meta l4proto tcp ip daddr 172.17.0.2 tcp dport 80 accept`},
+		{IPv4, "-p tcp -s 172.17.0.2 -d 172.17.0.2 --dport 80 -j MASQUERADE", `meta l4proto tcp ip saddr 172.17.0.2 ip daddr 172.17.0.2 tcp dport 80 masquerade`},
+
+		// links
+		{IPv4, "-i docker0 -o docker0 -p tcp -s 172.17.0.2 -d 172.17.0.3 --dport 5432 -j ACCEPT", `iifname "docker0" oifname "docker0" meta l4proto tcp ip saddr 172.17.0.2 ip daddr 172.17.0.3 tcp dport 5432 accept`},
+		{IPv4, "-i docker0 -o docker0 -p tcp -s 172.17.0.3 -d 172.17.0.2 --sport 5432 -j ACCEPT", `iifname "docker0" oifname "docker0" meta l4proto tcp ip saddr 172.17.0.3 ip daddr 172.17.0.2 tcp sport 5432 accept`},
+
+		// embedded DNS server
+		{IPv4, "-d 127.0.0.11 -p udp --dport 53 -j DNAT --to-destination 127.0.0.11:41552", `ip daddr 127.0.0.11 meta l4proto udp udp dport 53 dnat to 127.0.0.11:41552`},
+		{IPv4, "-s 127.0.0.11 -p udp --sport 41552 -j SNAT --to-source :53", `ip saddr 127.0.0.11 meta l4proto udp udp sport 41552 snat to :53`},
+
+		// network policies
+		{IPv4, "-s 10.0.1.0/24 -d 10.0.2.0/24 -p tcp --dport 8000:8080 -j ACCEPT", `ip saddr 10.0.1.0/24 ip daddr 10.0.2.0/24 meta l4proto tcp tcp dport 8000-8080 accept`},
+		{IPv4, "-s 10.0.1.0/24 -d 10.0.2.0/24 -j DROP", `ip saddr 10.0.1.0/24 ip daddr 10.0.2.0/24 drop`},
+		{IPv4, "-s 10.0.2.0/24 -d 10.0.1.0/24 -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT", `ip saddr 10.0.2.0/24 ip daddr 10.0.1.0/24 ct state related,established accept`},
+
+		// marks and wildcards
+		{IPv4, "-i veth+ -j MARK --set-mark 0x100", `iifname "veth*" meta mark set 0x100`},
+		{IPv4, "-m mark --mark 0x100 -j MARK --set-xmark 0x200/0xffffffff", `meta mark 0x100 meta mark set 0x200`},
+	} {
+		exprs, err := GetIptable(tc.version).nftRule(strings.Fields(tc.rule))
+		if assert.Check(t, err, tc.rule) {
+			assert.Check(t, is.Equal(strings.Join(exprs, " "), tc.nft), tc.rule)
+		}
+	}
+}
+
+func TestNftRuleErrors(t *testing.T) {
+	for _, tc := range []struct {
+		rule string
+		err  string
+	}{
+		// The checksums are not filled by the nftables rules.
+		{"-p sctp --sport 9999 -j CHECKSUM --checksum-fill", "iptables target CHECKSUM is not supported by the nftables backend"},
+		{"-p tcp --dport 80 -j TCPMSS --set-mss 1400", "iptables option --set-mss is not supported by the nftables backend"},
+		{"-m physdev --physdev-in eth0 -j ACCEPT", "iptables option --physdev-in is not supported by the nftables backend"},
+		{"--dport 80 -j ACCEPT", "iptables option --dport requires a protocol"},
+		{"-p tcp -j DNAT", "missing option --to-destination for iptables target DNAT"},
+		{"-p tcp -j SNAT", "missing option --to-source for iptables target SNAT"},
+		{"-j MARK", "missing option --set-mark for iptables target MARK"},
+		{"-i docker0", "missing iptables target"},
+		{"-i", "missing value for iptables option -i"},
+	} {
+		_, err := GetIptable(IPv4).nftRule(strings.Fields(tc.rule))
+		assert.Check(t, is.Error(err, tc.err), tc.rule)
+	}
+}
+
+func TestNftChainArgs(t *testing.T) {
+	assert.Check(t, is.DeepEqual(
+		nftChainArgs("ip", "docker_nat", "PREROUTING", nftBaseChains[Nat]["PREROUTING"], ""),
+		strings.Fields("add chain ip docker_nat PREROUTING { type nat hook prerouting priority -100 ; }"),
+	))
+	assert.Check(t, is.DeepEqual(
+		nftChainArgs("ip6", "docker_filter", "FORWARD", nftBaseChains[Filter]["FORWARD"], "drop"),
+		strings.Fields("add chain ip6 docker_filter FORWARD { type filter hook forward priority 0 ; policy drop ; }"),
+	))
+}
+
+func TestNftTable(t *testing.T) {
+	family, name := GetIptable(IPv4).nftTable(Nat)
+	assert.Check(t, is.Equal(family, "ip"))
+	assert.Check(t, is.Equal(name, "docker_nat"))
+	family, name = GetIptable(IPv6).nftTable(Filter)
+	assert.Check(t, is.Equal(family, "ip6"))
+	assert.Check(t, is.Equal(name, "docker_filter"))
+}
+
+func TestNftRuleComment(t *testing.T) {
+	a := nftRuleComment(strings.Fields("-i docker0 -j ACCEPT"))
+	assert.Check(t, strings.HasPrefix(a, `"docker:`), a)
+	assert.Check(t, is.Len(a, len(`"docker:`)+16+1))
+	assert.Check(t, is.Equal(a, nftRuleComment(strings.Fields("-i docker0 -j ACCEPT"))))
+	assert.Check(t, a != nftRuleComment(strings.Fields("-i docker0 -j DROP")))
+}
+
+func TestFirewallBackend(t *testing.T) {
+	defer SetFirewallBackend(GetFirewallBackend())
+
+	assert.NilError(t, SetFirewallBackend(""))
+	assert.Check(t, is.Equal(GetFirewallBackend(), IptablesBackend))
+
+	// the validation of a backend does not select it
+	assert.NilError(t, ValidateFirewallBackend(NftablesBackend))
+	assert.Check(t, is.Equal(GetFirewallBackend(), IptablesBackend))
+	assert.Check(t, is.Error(ValidateFirewallBackend("ebtables"), `invalid firewall backend "ebtables": must be "iptables" or "nftables"`))
+
+	assert.NilError(t, SetFirewallBackend(NftablesBackend))
+	assert.Check(t, is.Equal(GetFirewallBackend(), NftablesBackend))
+	assert.Check(t, SetFirewallBackend("ebtables") != nil)
+	assert.Check(t, is.Equal(GetFirewallBackend(), NftablesBackend))
+}
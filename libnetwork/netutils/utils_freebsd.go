@@ -0,0 +1,33 @@
+package netutils // import "github.com/docker/docker/libnetwork/netutils"
+
+import (
+	"net"
+
+	"github.com/docker/docker/libnetwork/types"
+)
+
+// ElectInterfaceAddresses looks for an interface on the OS with the specified name
+// and returns returns all its IPv4 and IPv6 addresses in CIDR notation.
+// If a failure in retrieving the addresses or no IPv4 address is found, an error is returned.
+// If the interface does not exist, it chooses from a predefined
+// list the first IPv4 address which does not conflict with other
+// interfaces on the system.
+func ElectInterfaceAddresses(name string) ([]*net.IPNet, []*net.IPNet, error) {
+	return nil, nil, types.NotImplementedErrorf("not supported on freebsd")
+}
+
+// FindAvailableNetwork returns a network from the passed list which does not
+// overlap with existing interfaces in the system
+func FindAvailableNetwork(list []*net.IPNet) (*net.IPNet, error) {
+	return nil, types.NotImplementedErrorf("not supported on freebsd")
+}
+
+// DefaultRouteMTU returns the MTU of the interface of the IPv4 default route.
+func DefaultRouteMTU() (int, error) {
+	return 0, types.NotImplementedErrorf("not supported on freebsd")
+}
+
+// AddressMTU returns the MTU of the interface holding the specified address.
+func AddressMTU(ip net.IP) (int, error) {
+	return 0, types.NotImplementedErrorf("not supported on freebsd")
+}
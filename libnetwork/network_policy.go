@@ -0,0 +1,156 @@
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/libnetwork/types"
+)
+
+const (
+	// PolicyAllow is the action of the network policies allowing traffic
+	PolicyAllow = "allow"
+	// PolicyDeny is the action of the network policies denying traffic
+	PolicyDeny = "deny"
+)
+
+// NetworkPolicy is a named rule allowing or denying the traffic from the
+// containers of a bridge network to the containers of another bridge network,
+// which are otherwise isolated from each other. The deny policies take
+// precedence over the allow policies.
+type NetworkPolicy struct {
+	Name        string
+	Source      string
+	Destination string
+	Action      string
+	// Protocol restricts the policy to the traffic of a protocol, the policy
+	// applies to all the traffic if empty.
+	Protocol string
+	// Ports are the destination ports or port ranges ("8000-8100") the
+	// policy applies to, all the ports of the protocol if empty.
+	Ports []string
+}
+
+// Validate checks the configuration of the policy.
+func (p *NetworkPolicy) Validate() error {
+	if p.Name == "" {
+		return types.BadRequestErrorf("network policy name is required")
+	}
+	if p.Source == "" || p.Destination == "" {
+		return types.BadRequestErrorf("network policy %s must have a source and a destination network", p.Name)
+	}
+	if p.Source == p.Destination {
+		return types.BadRequestErrorf("network policy %s cannot apply to the traffic within network %s", p.Name, p.Source)
+	}
+	switch p.Action {
+	case PolicyAllow, PolicyDeny:
+	default:
+		return types.BadRequestErrorf("invalid action %q for network policy %s: must be %q or %q", p.Action, p.Name, PolicyAllow, PolicyDeny)
+	}
+	switch p.Protocol {
+	case "", "tcp", "udp", "sctp":
+	default:
+		return types.BadRequestErrorf("invalid protocol %q for network policy %s", p.Protocol, p.Name)
+	}
+	if len(p.Ports) > 0 && p.Protocol == "" {
+		return types.BadRequestErrorf("network policy %s must have a protocol to apply to ports", p.Name)
+	}
+	for _, port := range p.Ports {
+		if !validPolicyPort(port) {
+			return types.BadRequestErrorf("invalid port %q for network policy %s: must be a port or a range of ports", port, p.Name)
+		}
+	}
+	return nil
+}
+
+func validPolicyPort(s string) bool {
+	parts := strings.SplitN(s, "-", 2)
+	var ports []int
+	for _, part := range parts {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 65535 {
+			return false
+		}
+		ports = append(ports, port)
+	}
+	return len(ports) == 1 || ports[0] <= ports[1]
+}
+
+func (c *controller) SetNetworkPolicies(policies []NetworkPolicy) error {
+	for i := range policies {
+		if err := policies[i].Validate(); err != nil {
+			return err
+		}
+	}
+	c.Lock()
+	c.networkPolicies = append([]NetworkPolicy(nil), policies...)
+	c.Unlock()
+	c.arrangeFilterRules()
+	return nil
+}
+
+// networkPolicyRules returns the iptables rules of the network policies, for
+// the subnets of the given IP version of their networks. The policies of the
+// networks which do not exist, or are not bridge networks, are skipped.
+func (c *controller) networkPolicyRules(ipv6 bool) [][]string {
+	c.Lock()
+	policies := c.networkPolicies
+	c.Unlock()
+
+	var deny, allow [][]string
+	for _, p := range policies {
+		src := c.policyNetworkSubnets(p.Source, ipv6)
+		dst := c.policyNetworkSubnets(p.Destination, ipv6)
+		ports := p.Ports
+		if len(ports) == 0 {
+			ports = []string{""}
+		}
+		for _, s := range src {
+			for _, d := range dst {
+				for _, port := range ports {
+					rule := []string{"-s", s, "-d", d}
+					if p.Protocol != "" {
+						rule = append(rule, "-p", p.Protocol)
+					}
+					if port != "" {
+						rule = append(rule, "--dport", strings.Replace(port, "-", ":", 1))
+					}
+					if p.Action == PolicyDeny {
+						deny = append(deny, append(rule, "-j", "DROP"))
+					} else {
+						allow = append(allow, append(rule, "-j", "ACCEPT"))
+					}
+				}
+				if p.Action == PolicyAllow {
+					// the replies of the destination are isolated as well
+					allow = append(allow, []string{"-s", d, "-d", s, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"})
+				}
+			}
+		}
+	}
+	return append(deny, allow...)
+}
+
+func (c *controller) policyNetworkSubnets(nameOrID string, ipv6 bool) []string {
+	n, err := c.NetworkByName(nameOrID)
+	if err != nil {
+		if n, err = c.NetworkByID(nameOrID); err != nil {
+			return nil
+		}
+	}
+	if n.Type() != "bridge" {
+		return nil
+	}
+	v4, v6 := n.Info().IpamInfo()
+	infos := v4
+	if ipv6 {
+		infos = v6
+	}
+	var subnets []string
+	for _, info := range infos {
+		if info.Pool != nil {
+			subnets = append(subnets, info.Pool.String())
+		}
+	}
+	return subnets
+}
@@ -0,0 +1,14 @@
+// +build !windows
+
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import "github.com/docker/docker/libnetwork/ipamapi"
+
+// Stub implementations for DNS related functions
+
+func (n *network) startResolver() {
+}
+
+func defaultIpamForNetworkType(networkType string) string {
+	return ipamapi.DefaultIPAM
+}
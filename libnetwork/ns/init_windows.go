@@ -0,0 +1,3 @@
+package ns // import "github.com/docker/docker/libnetwork/ns"
+
+// File is present so that go build ./... is closer to working on Windows from repo root.
@@ -0,0 +1,4 @@
+package osl // import "github.com/docker/docker/libnetwork/osl"
+
+// IfaceOption is a function option type to set interface options
+type IfaceOption func()
@@ -0,0 +1,7 @@
+// +build !linux
+
+package kernel // import "github.com/docker/docker/libnetwork/osl/kernel"
+
+// ApplyOSTweaks applies the configuration values passed as arguments
+func ApplyOSTweaks(osConfig map[string]*OSValue) {
+}
@@ -0,0 +1,4 @@
+package osl // import "github.com/docker/docker/libnetwork/osl"
+
+// NeighOption is a function option type to set neighbor options
+type NeighOption func()
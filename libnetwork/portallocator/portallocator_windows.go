@@ -0,0 +1 @@
+package portallocator // import "github.com/docker/docker/libnetwork/portallocator"
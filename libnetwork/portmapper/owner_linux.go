@@ -0,0 +1,128 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The states of the sockets bound to a local port, in /proc/net/{tcp,udp}.
+const (
+	tcpListen = "0A"
+	udpClose  = "07"
+)
+
+// portOwner returns a description of the host process with a socket bound to
+// the given port, or an empty string if it cannot be found.
+func portOwner(proto string, port int) string {
+	inodes := make(map[string]struct{})
+	switch proto {
+	case "tcp":
+		socketInodes("/proc/net/tcp", port, tcpListen, inodes)
+		socketInodes("/proc/net/tcp6", port, tcpListen, inodes)
+	case "udp":
+		socketInodes("/proc/net/udp", port, udpClose, inodes)
+		socketInodes("/proc/net/udp6", port, udpClose, inodes)
+	case "sctp":
+		sctpInodes("/proc/net/sctp/eps", port, inodes)
+	default:
+		return ""
+	}
+	if len(inodes) == 0 {
+		return ""
+	}
+
+	procs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return ""
+	}
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		fdDir := filepath.Join("/proc", p.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			if _, ok := inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]; ok {
+				return fmt.Sprintf("process %s (pid %d)", processName(p.Name()), pid)
+			}
+		}
+	}
+	return ""
+}
+
+// socketInodes adds to inodes the inodes of the sockets of the table, in the
+// format of /proc/net/tcp, bound to the port in the given state.
+func socketInodes(table string, port int, state string, inodes map[string]struct{}) {
+	f, err := os.Open(table)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Scan() // skip the header
+	for s.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+		i := strings.LastIndex(fields[1], ":")
+		if i < 0 {
+			continue
+		}
+		if p, err := strconv.ParseUint(fields[1][i+1:], 16, 16); err != nil || int(p) != port {
+			continue
+		}
+		if fields[9] != "0" {
+			inodes[fields[9]] = struct{}{}
+		}
+	}
+}
+
+// sctpInodes adds to inodes the inodes of the SCTP endpoints of the table, in
+// the format of /proc/net/sctp/eps, bound to the port.
+func sctpInodes(table string, port int, inodes map[string]struct{}) {
+	f, err := os.Open(table)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Scan() // skip the header
+	for s.Scan() {
+		// ENDPT SOCK STY SST HBKT LPORT UID INODE LADDRS
+		fields := strings.Fields(s.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		if p, err := strconv.Atoi(fields[5]); err != nil || p != port {
+			continue
+		}
+		if fields[7] != "0" {
+			inodes[fields[7]] = struct{}{}
+		}
+	}
+}
+
+func processName(pid string) string {
+	comm, err := ioutil.ReadFile(filepath.Join("/proc", pid, "comm"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(comm))
+}
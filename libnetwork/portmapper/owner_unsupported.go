@@ -0,0 +1,7 @@
+// +build !linux
+
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+func portOwner(proto string, port int) string {
+	return ""
+}
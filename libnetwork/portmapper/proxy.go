@@ -0,0 +1,158 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/ishidawataru/sctp"
+)
+
+var userlandProxyCommandName = "docker-proxy"
+
+type userlandProxy interface {
+	Start() error
+	Stop() error
+}
+
+// proxyCommand wraps an exec.Cmd to run the userland TCP and UDP
+// proxies as separate processes.
+type proxyCommand struct {
+	cmd *exec.Cmd
+}
+
+func (p *proxyCommand) Start() error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("proxy unable to open os.Pipe %s", err)
+	}
+	defer r.Close()
+	p.cmd.ExtraFiles = []*os.File{w}
+	if err := p.cmd.Start(); err != nil {
+		return err
+	}
+	w.Close()
+
+	errchan := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 2)
+		r.Read(buf)
+
+		if string(buf) != "0\n" {
+			errStr, err := ioutil.ReadAll(r)
+			if err != nil {
+				errchan <- fmt.Errorf("Error reading exit status from userland proxy: %v", err)
+				return
+			}
+
+			errchan <- fmt.Errorf("Error starting userland proxy: %s", errStr)
+			return
+		}
+		errchan <- nil
+	}()
+
+	select {
+	case err := <-errchan:
+		return err
+	case <-time.After(16 * time.Second):
+		return fmt.Errorf("Timed out proxy starting the userland proxy")
+	}
+}
+
+func (p *proxyCommand) Stop() error {
+	if p.cmd.Process != nil {
+		if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		return p.cmd.Wait()
+	}
+	return nil
+}
+
+// dummyProxy just listen on some port, it is needed to prevent accidental
+// port allocations on bound port, because without userland proxy we using
+// iptables rules and not net.Listen
+type dummyProxy struct {
+	listener io.Closer
+	addr     net.Addr
+}
+
+func newDummyProxy(proto string, hostIP net.IP, hostPort int) (userlandProxy, error) {
+	switch proto {
+	case "tcp":
+		addr := &net.TCPAddr{IP: hostIP, Port: hostPort}
+		return &dummyProxy{addr: addr}, nil
+	case "udp":
+		addr := &net.UDPAddr{IP: hostIP, Port: hostPort}
+		return &dummyProxy{addr: addr}, nil
+	case "sctp":
+		addr := &sctp.SCTPAddr{IP: []net.IP{hostIP}, Port: hostPort}
+		return &dummyProxy{addr: addr}, nil
+	default:
+		return nil, fmt.Errorf("Unknown addr type: %s", proto)
+	}
+}
+
+func (p *dummyProxy) Start() error {
+	switch addr := p.addr.(type) {
+	case *net.TCPAddr:
+		l, err := net.ListenTCP("tcp", addr)
+		if err != nil {
+			return err
+		}
+		p.listener = l
+	case *net.UDPAddr:
+		l, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return err
+		}
+		p.listener = l
+	case *sctp.SCTPAddr:
+		var err error
+		for _, a := range sctpListenAddrs(addr.IP[0], addr.Port) {
+			var l *sctp.SCTPListener
+			if l, err = sctp.ListenSCTP("sctp", a); err == nil {
+				p.listener = l
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unknown addr type: %T", p.addr)
+	}
+	return nil
+}
+
+func (p *dummyProxy) Stop() error {
+	if p.listener != nil {
+		return p.listener.Close()
+	}
+	return nil
+}
+
+// sctpListenAddrs returns the addresses on which the SCTP port of hostIP can
+// be listened on, in order of preference. Unlike the TCP and UDP listeners of
+// the net package, the SCTP listeners only listen on the IPv4 wildcard address
+// if asked to, the IPv6 wildcard address is preferred to accept the
+// associations of both IP versions.
+func sctpListenAddrs(hostIP net.IP, hostPort int) []*sctp.SCTPAddr {
+	addrs := []*sctp.SCTPAddr{{IP: []net.IP{hostIP}, Port: hostPort}}
+	if hostIP.Equal(net.IPv4zero) {
+		addrs = append([]*sctp.SCTPAddr{{IP: []net.IP{net.IPv6zero}, Port: hostPort}}, addrs...)
+	}
+	return addrs
+}
+
+// noopProxy is used for the mappings whose host address is already listened
+// on by the proxy of another mapping.
+type noopProxy struct{}
+
+func (noopProxy) Start() error { return nil }
+
+func (noopProxy) Stop() error { return nil }
@@ -0,0 +1,230 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ishidawataru/sctp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// udpConnTrackTimeout is the time after which the association between
+	// a UDP client and its backend connection is removed, if idle.
+	udpConnTrackTimeout = 90 * time.Second
+	// udpBufSize is the size of the buffer of the UDP datagrams.
+	udpBufSize = 65507
+)
+
+// inDaemonProxy proxies the traffic of a TCP, UDP or SCTP port mapping from the
+// daemon process, instead of from a docker-proxy process per mapping. The
+// TCP streams are copied with io.Copy, which splices the data between the
+// sockets in the kernel on Linux.
+type inDaemonProxy struct {
+	frontend net.Addr
+	backend  net.Addr
+
+	mu       sync.Mutex
+	listener io.Closer
+	conns    map[io.Closer]struct{}
+	wg       sync.WaitGroup
+}
+
+func newInDaemonProxyCommand(proto string, hostIP net.IP, hostPort int, containerIP net.IP, containerPort int, proxyPath string) (userlandProxy, error) {
+	switch proto {
+	case "tcp":
+		return &inDaemonProxy{
+			frontend: &net.TCPAddr{IP: hostIP, Port: hostPort},
+			backend:  &net.TCPAddr{IP: containerIP, Port: containerPort},
+			conns:    make(map[io.Closer]struct{}),
+		}, nil
+	case "udp":
+		return &inDaemonProxy{
+			frontend: &net.UDPAddr{IP: hostIP, Port: hostPort},
+			backend:  &net.UDPAddr{IP: containerIP, Port: containerPort},
+			conns:    make(map[io.Closer]struct{}),
+		}, nil
+	case "sctp":
+		return &inDaemonProxy{
+			frontend: &sctp.SCTPAddr{IP: []net.IP{hostIP}, Port: hostPort},
+			backend:  &sctp.SCTPAddr{IP: []net.IP{containerIP}, Port: containerPort},
+			conns:    make(map[io.Closer]struct{}),
+		}, nil
+	default:
+		return newProxy(proto, hostIP, hostPort, containerIP, containerPort, proxyPath)
+	}
+}
+
+func (p *inDaemonProxy) Start() error {
+	switch addr := p.frontend.(type) {
+	case *net.TCPAddr:
+		l, err := net.ListenTCP("tcp", addr)
+		if err != nil {
+			return err
+		}
+		p.listener = l
+		p.wg.Add(1)
+		go p.serveTCP(l)
+	case *net.UDPAddr:
+		l, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return err
+		}
+		p.listener = l
+		p.wg.Add(1)
+		go p.serveUDP(l)
+	case *sctp.SCTPAddr:
+		return p.startSCTP(addr)
+	}
+	return nil
+}
+
+func (p *inDaemonProxy) Stop() error {
+	if p.listener == nil {
+		return nil
+	}
+	// the connections are closed once the listener is closed
+	err := p.listener.Close()
+	p.wg.Wait()
+	return err
+}
+
+// track records c to be closed when the proxy is stopped, or forgets it if
+// it was closed. It returns false if the proxy is stopped.
+func (p *inDaemonProxy) track(c io.Closer, add bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !add {
+		delete(p.conns, c)
+		return true
+	}
+	if p.conns == nil {
+		return false
+	}
+	p.conns[c] = struct{}{}
+	return true
+}
+
+func (p *inDaemonProxy) serveTCP(l *net.TCPListener) {
+	defer p.wg.Done()
+	defer p.closeConns()
+	for {
+		client, err := l.AcceptTCP()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		p.wg.Add(1)
+		go p.proxyTCP(client)
+	}
+}
+
+func (p *inDaemonProxy) proxyTCP(client *net.TCPConn) {
+	defer p.wg.Done()
+	defer client.Close()
+
+	backend, err := net.DialTCP("tcp", nil, p.backend.(*net.TCPAddr))
+	if err != nil {
+		logrus.Debugf("Can't forward traffic to backend tcp/%v: %s", p.backend, err)
+		return
+	}
+	defer backend.Close()
+	if !p.track(client, true) || !p.track(backend, true) {
+		return
+	}
+	defer p.track(client, false)
+	defer p.track(backend, false)
+
+	var wg sync.WaitGroup
+	copyHalf := func(dst, src *net.TCPConn) {
+		defer wg.Done()
+		io.Copy(dst, src)
+		dst.CloseWrite()
+		src.CloseRead()
+	}
+	wg.Add(2)
+	go copyHalf(backend, client)
+	go copyHalf(client, backend)
+	wg.Wait()
+}
+
+func (p *inDaemonProxy) serveUDP(l *net.UDPConn) {
+	defer p.wg.Done()
+	defer p.closeConns()
+
+	var (
+		mu    sync.Mutex
+		table = make(map[string]*net.UDPConn)
+		buf   = make([]byte, udpBufSize)
+	)
+	for {
+		n, from, err := l.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+
+		key := from.String()
+		mu.Lock()
+		backend, ok := table[key]
+		if !ok {
+			backend, err = net.DialUDP("udp", nil, p.backend.(*net.UDPAddr))
+			if err != nil || !p.track(backend, true) {
+				mu.Unlock()
+				if err != nil {
+					logrus.Debugf("Can't proxy a datagram to udp/%v: %s", p.backend, err)
+				}
+				continue
+			}
+			table[key] = backend
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				p.replyUDP(l, backend, from)
+				mu.Lock()
+				delete(table, key)
+				mu.Unlock()
+				p.track(backend, false)
+				backend.Close()
+			}()
+		}
+		mu.Unlock()
+
+		if _, err := backend.Write(buf[:n]); err != nil {
+			logrus.Debugf("Can't proxy a datagram to udp/%v: %s", p.backend, err)
+		}
+	}
+}
+
+// replyUDP forwards the replies of the backend to the client, until the
+// association is idle for udpConnTrackTimeout.
+func (p *inDaemonProxy) replyUDP(l *net.UDPConn, backend *net.UDPConn, client *net.UDPAddr) {
+	buf := make([]byte, udpBufSize)
+	for {
+		backend.SetReadDeadline(time.Now().Add(udpConnTrackTimeout))
+		n, err := backend.Read(buf)
+		if err != nil {
+			return
+		}
+		if _, err := l.WriteToUDP(buf[:n], client); err != nil {
+			return
+		}
+	}
+}
+
+// closeConns closes the tracked connections once the listener is closed, and
+// prevents the tracking of new ones.
+func (p *inDaemonProxy) closeConns() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+}
@@ -0,0 +1,192 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+
+	"github.com/ishidawataru/sctp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// sctpBufSize is the size of the buffer of the SCTP messages, larger
+// messages are forwarded in several parts.
+const sctpBufSize = 65536
+
+var errSCTPListenerClosed = errors.New("sctp listener closed")
+
+// sctpListener is a listening SCTP socket whose accept is interrupted when it
+// is closed. The accept of the listeners of the sctp package blocks in the
+// system call, and keeps the socket bound to the port, until an association
+// is received.
+type sctpListener struct {
+	fd   int
+	wake [2]int
+	once sync.Once
+}
+
+func listenSCTP(addr *sctp.SCTPAddr) (*sctpListener, error) {
+	var err error
+	for _, a := range sctpListenAddrs(addr.IP[0], addr.Port) {
+		var l *sctpListener
+		if l, err = listenSCTPAddr(a); err == nil {
+			return l, nil
+		}
+	}
+	return nil, err
+}
+
+func listenSCTPAddr(addr *sctp.SCTPAddr) (_ *sctpListener, err error) {
+	family := syscall.AF_INET6
+	if addr.IP[0].To4() != nil {
+		family = syscall.AF_INET
+	}
+	fd, err := syscall.Socket(family, syscall.SOCK_STREAM|syscall.SOCK_NONBLOCK|syscall.SOCK_CLOEXEC, syscall.IPPROTO_SCTP)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			syscall.Close(fd)
+		}
+	}()
+	if err := sctp.SCTPBind(fd, addr, sctp.SCTP_BINDX_ADD_ADDR); err != nil {
+		return nil, err
+	}
+	if err := syscall.Listen(fd, syscall.SOMAXCONN); err != nil {
+		return nil, err
+	}
+	l := &sctpListener{fd: fd}
+	if err := unix.Pipe2(l.wake[:], unix.O_NONBLOCK|unix.O_CLOEXEC); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// accept waits for an association, until the listener is closed.
+func (l *sctpListener) accept() (*sctp.SCTPConn, error) {
+	fds := []unix.PollFd{
+		{Fd: int32(l.fd), Events: unix.POLLIN},
+		{Fd: int32(l.wake[0]), Events: unix.POLLIN},
+	}
+	for {
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return nil, err
+		}
+		if fds[1].Revents != 0 {
+			return nil, errSCTPListenerClosed
+		}
+		// The accepted socket is blocking, as expected by the sctp package.
+		fd, _, err := syscall.Accept4(l.fd, syscall.SOCK_CLOEXEC)
+		switch err {
+		case nil:
+			return sctp.NewSCTPConn(fd, nil), nil
+		case syscall.EAGAIN, syscall.EINTR, syscall.ECONNABORTED:
+			continue
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Close interrupts the accept of the listener. The socket is released by
+// the goroutine serving the listener.
+func (l *sctpListener) Close() error {
+	l.once.Do(func() {
+		syscall.Write(l.wake[1], []byte{0})
+	})
+	return nil
+}
+
+func (l *sctpListener) release() {
+	syscall.Close(l.fd)
+	syscall.Close(l.wake[0])
+	syscall.Close(l.wake[1])
+}
+
+func (p *inDaemonProxy) startSCTP(addr *sctp.SCTPAddr) error {
+	l, err := listenSCTP(addr)
+	if err != nil {
+		return err
+	}
+	p.listener = l
+	p.wg.Add(1)
+	go p.serveSCTP(l)
+	return nil
+}
+
+func (p *inDaemonProxy) serveSCTP(l *sctpListener) {
+	defer p.wg.Done()
+	defer p.closeConns()
+	defer l.release()
+	for {
+		client, err := l.accept()
+		if err != nil {
+			if err != errSCTPListenerClosed {
+				logrus.Debugf("Stopped proxying sctp/%v: %s", p.frontend, err)
+			}
+			return
+		}
+		p.wg.Add(1)
+		go p.proxySCTP(client)
+	}
+}
+
+func (p *inDaemonProxy) proxySCTP(client *sctp.SCTPConn) {
+	defer p.wg.Done()
+	defer client.Close()
+
+	backend, err := sctp.DialSCTP("sctp", nil, p.backend.(*sctp.SCTPAddr))
+	if err != nil {
+		logrus.Debugf("Can't forward traffic to backend sctp/%v: %s", p.backend, err)
+		return
+	}
+	defer backend.Close()
+	if !p.track(client, true) || !p.track(backend, true) {
+		return
+	}
+	defer p.track(client, false)
+	defer p.track(backend, false)
+
+	// The stream and the payload protocol identifier of the messages are
+	// received along with them, to be forwarded as is.
+	for _, c := range []*sctp.SCTPConn{client, backend} {
+		if err := c.SubscribeEvents(sctp.SCTP_EVENT_DATA_IO); err != nil {
+			logrus.Debugf("Can't proxy sctp/%v: %s", p.frontend, err)
+			return
+		}
+	}
+
+	var wg sync.WaitGroup
+	copyMessages := func(dst, src *sctp.SCTPConn) {
+		defer wg.Done()
+		// closing the association interrupts the copy in the other direction
+		defer dst.Close()
+		defer src.Close()
+		buf := make([]byte, sctpBufSize)
+		for {
+			n, info, err := src.SCTPRead(buf)
+			if err != nil {
+				return
+			}
+			if n == 0 {
+				continue
+			}
+			var sndInfo *sctp.SndRcvInfo
+			if info != nil {
+				sndInfo = &sctp.SndRcvInfo{Stream: info.Stream, PPID: info.PPID}
+			}
+			if _, err := dst.SCTPWrite(buf[:n], sndInfo); err != nil {
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go copyMessages(backend, client)
+	go copyMessages(client, backend)
+	wg.Wait()
+}
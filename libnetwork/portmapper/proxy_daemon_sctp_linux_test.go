@@ -0,0 +1,92 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ishidawataru/sctp"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestSCTPListenAddrs(t *testing.T) {
+	// The IPv6 wildcard address accepts the associations of both IP
+	// versions, the IPv4 wildcard address is the fallback for the hosts
+	// without IPv6.
+	addrs := sctpListenAddrs(net.IPv4zero, 9999)
+	assert.Assert(t, is.Len(addrs, 2))
+	assert.Check(t, is.Equal(addrs[0].String(), "[::]:9999"))
+	assert.Check(t, is.Equal(addrs[1].String(), "0.0.0.0:9999"))
+
+	for _, ip := range []net.IP{net.ParseIP("192.0.2.1"), net.IPv6zero, net.ParseIP("2001:db8::1")} {
+		addrs := sctpListenAddrs(ip, 9999)
+		assert.Assert(t, is.Len(addrs, 1))
+		assert.Check(t, addrs[0].IP[0].Equal(ip))
+		assert.Check(t, is.Equal(addrs[0].Port, 9999))
+	}
+}
+
+func TestSCTPInodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sctp-inodes")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	table := filepath.Join(dir, "eps")
+	assert.NilError(t, ioutil.WriteFile(table, []byte(
+		` ENDPT     SOCK   STY SST HBKT LPORT   UID INODE LADDRS
+ffff8a0b3c4d5e00 ffff8a0b3c4d6000 2   10  29   9999      0 30370 0.0.0.0
+ffff8a0b3c4d7e00 ffff8a0b3c4d8000 2   10  30   9999      0 30371 ::
+ffff8a0b3c4d9e00 ffff8a0b3c4da000 2   10  31   9998      0 30372 0.0.0.0
+ffff8a0b3c4dbe00 ffff8a0b3c4dc000 2   10  29   9999      0 0 0.0.0.0
+truncated line
+`), 0644))
+
+	inodes := make(map[string]struct{})
+	sctpInodes(table, 9999, inodes)
+	assert.Check(t, is.DeepEqual(inodes, map[string]struct{}{"30370": {}, "30371": {}}))
+
+	// A missing table, on the hosts without SCTP, is ignored.
+	inodes = make(map[string]struct{})
+	sctpInodes(filepath.Join(dir, "missing"), 9999, inodes)
+	assert.Check(t, is.Len(inodes, 0))
+}
+
+func TestInDaemonProxySCTP(t *testing.T) {
+	p, err := newInDaemonProxyCommand("sctp", net.IPv4zero, 9999, net.ParseIP("172.17.0.2"), 9998, "")
+	assert.NilError(t, err)
+	proxy := p.(*inDaemonProxy)
+	assert.Check(t, is.Equal(proxy.frontend.String(), "0.0.0.0:9999"))
+	assert.Check(t, is.Equal(proxy.backend.String(), "172.17.0.2:9998"))
+	assert.Check(t, is.Equal(proxy.backend.Network(), "sctp"))
+}
+
+// TestSCTPListenerClose checks that closing the listener interrupts its
+// accept and releases its port.
+func TestSCTPListenerClose(t *testing.T) {
+	addr := &sctp.SCTPAddr{IP: []net.IP{net.ParseIP("127.0.0.1")}, Port: 0}
+	l, err := listenSCTPAddr(addr)
+	if err == syscall.EPROTONOSUPPORT || err == syscall.EAFNOSUPPORT {
+		t.Skip("SCTP is not supported by the kernel")
+	}
+	assert.NilError(t, err)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := l.accept()
+		errs <- err
+	}()
+	assert.NilError(t, l.Close())
+	assert.NilError(t, l.Close())
+	select {
+	case err := <-errs:
+		assert.Check(t, is.Equal(err, errSCTPListenerClosed))
+	case <-time.After(10 * time.Second):
+		t.Fatal("accept was not interrupted by closing the listener")
+	}
+	l.release()
+}
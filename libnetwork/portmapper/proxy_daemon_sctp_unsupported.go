@@ -0,0 +1,13 @@
+// +build !linux
+
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"errors"
+
+	"github.com/ishidawataru/sctp"
+)
+
+func (p *inDaemonProxy) startSCTP(addr *sctp.SCTPAddr) error {
+	return errors.New("the in-daemon proxy only supports SCTP on Linux")
+}
@@ -0,0 +1,494 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"unsafe"
+
+	"github.com/docker/docker/libnetwork/ns"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// With the eBPF backend, the TCP connections to the published ports are
+// steered by a BPF_PROG_TYPE_SK_LOOKUP program, attached to the network
+// namespace of the daemon, to a single listening socket of the daemon, which
+// proxies them to the containers. The program looks up the protocol, the
+// local port and the local address of the connection, or the unspecified
+// address, in the ports map, and steers the connection to the listening socket of its
+// address family in the sockets map. As the connections are delivered to the socket by the
+// kernel socket lookup, neither NAT rules nor a listening socket per published
+// port are needed, and the cost of the lookup does not depend on the number
+// of published ports.
+
+const (
+	bpfMapCreate     = 0
+	bpfMapUpdateElem = 2
+	bpfMapDeleteElem = 3
+	bpfProgLoad      = 5
+	bpfLinkCreate    = 28
+
+	bpfMapTypeHash      = 1
+	bpfMapTypeSockmap   = 15
+	bpfProgTypeSkLookup = 30
+	bpfSkLookup         = 36
+
+	bpfFuncMapLookupElem = 1
+	bpfFuncSkRelease     = 86
+	bpfFuncSkAssign      = 124
+
+	// steeredPortsMax is the number of entries of the ports map
+	steeredPortsMax = 65536
+	// steeredKeySize is the size of the keys of the ports map: the protocol,
+	// the local port and the local IPv6, or IPv4-mapped, address
+	steeredKeySize = 24
+
+	// indexes of the listening sockets in the sockets map
+	steeredSocketIPv4 = 0
+	steeredSocketIPv6 = 1
+)
+
+// bpfInsn is a struct bpf_insn.
+type bpfInsn struct {
+	code uint8
+	regs uint8
+	off  int16
+	imm  int32
+}
+
+func insn(code, dst, src uint8, off int16, imm int32) bpfInsn {
+	return bpfInsn{code: code, regs: src<<4 | dst, off: off, imm: imm}
+}
+
+// loadMapFd returns the two instructions loading the map fd in dst.
+func loadMapFd(dst uint8, fd int) []bpfInsn {
+	const bpfPseudoMapFd = 1
+	return []bpfInsn{insn(0x18, dst, bpfPseudoMapFd, 0, int32(fd)), {}}
+}
+
+// Offsets of the fields of the struct bpf_sk_lookup context of the program.
+const (
+	skLookupFamily    = 8
+	skLookupProtocol  = 12
+	skLookupLocalIP4  = 40
+	skLookupLocalIP6  = 44
+	skLookupLocalPort = 60
+)
+
+// steeringProgram returns the sk_lookup program, for the ports and sockets
+// maps.
+func steeringProgram(ports, sockets int) []bpfInsn {
+	const (
+		ldxw   = 0x61
+		stxw   = 0x63
+		stw    = 0x62
+		stdw   = 0x7a
+		movx   = 0xbf
+		movk   = 0xb7
+		addk   = 0x07
+		jeqk   = 0x15
+		jnek   = 0x55
+		ja     = 0x05
+		call   = 0x85
+		exit   = 0x95
+		skPass = 1
+	)
+	lookup := func() []bpfInsn {
+		return append(loadMapFd(1, ports),
+			insn(movx, 2, 10, 0, 0),
+			insn(addk, 2, 0, 0, -steeredKeySize),
+			insn(call, 0, 0, 0, bpfFuncMapLookupElem),
+		)
+	}
+
+	// r6 = ctx, the key is on the stack at r10-24 and the index of the
+	// listening socket of the family at r10-28
+	prog := []bpfInsn{
+		insn(movx, 6, 1, 0, 0),
+		insn(ldxw, 2, 6, skLookupProtocol, 0),
+		insn(stxw, 10, 2, -24, 0),
+		insn(ldxw, 2, 6, skLookupLocalPort, 0),
+		insn(stxw, 10, 2, -20, 0),
+		insn(ldxw, 2, 6, skLookupFamily, 0),
+		insn(jeqk, 2, 0, 6, unix.AF_INET6),
+		// ::ffff:<local_ip4>
+		insn(stdw, 10, 0, -16, 0),
+		insn(stw, 10, 0, -8, int32(nl.NativeEndian().Uint32([]byte{0, 0, 0xff, 0xff}))),
+		insn(ldxw, 2, 6, skLookupLocalIP4, 0),
+		insn(stxw, 10, 2, -4, 0),
+		insn(stw, 10, 0, -28, steeredSocketIPv4),
+		insn(ja, 0, 0, 9, 0),
+		// <local_ip6>
+		insn(ldxw, 2, 6, skLookupLocalIP6, 0),
+		insn(stxw, 10, 2, -16, 0),
+		insn(ldxw, 2, 6, skLookupLocalIP6+4, 0),
+		insn(stxw, 10, 2, -12, 0),
+		insn(ldxw, 2, 6, skLookupLocalIP6+8, 0),
+		insn(stxw, 10, 2, -8, 0),
+		insn(ldxw, 2, 6, skLookupLocalIP6+12, 0),
+		insn(stxw, 10, 2, -4, 0),
+		insn(stw, 10, 0, -28, steeredSocketIPv6),
+	}
+	prog = append(prog, lookup()...)
+	// fall back to the unspecified address
+	prog = append(prog,
+		insn(jnek, 0, 0, 8, 0),
+		insn(stdw, 10, 0, -16, 0),
+		insn(stdw, 10, 0, -8, 0),
+	)
+	prog = append(prog, lookup()...)
+	prog = append(prog, insn(jeqk, 0, 0, 13, 0))
+	prog = append(prog, loadMapFd(1, sockets)...)
+	prog = append(prog,
+		insn(movx, 2, 10, 0, 0),
+		insn(addk, 2, 0, 0, -28),
+		insn(call, 0, 0, 0, bpfFuncMapLookupElem),
+		insn(jeqk, 0, 0, 7, 0),
+		insn(movx, 7, 0, 0, 0),
+		insn(movx, 1, 6, 0, 0),
+		insn(movx, 2, 7, 0, 0),
+		insn(movk, 3, 0, 0, 0),
+		insn(call, 0, 0, 0, bpfFuncSkAssign),
+		insn(movx, 1, 7, 0, 0),
+		insn(call, 0, 0, 0, bpfFuncSkRelease),
+		insn(movk, 0, 0, 0, skPass),
+		insn(exit, 0, 0, 0, 0),
+	)
+	return prog
+}
+
+func bpf(cmd int, attr unsafe.Pointer, size uintptr) (int, error) {
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, uintptr(cmd), uintptr(attr), size)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+func bpfMapCreateFd(mapType, keySize, valueSize, maxEntries uint32) (int, error) {
+	attr := struct {
+		mapType    uint32
+		keySize    uint32
+		valueSize  uint32
+		maxEntries uint32
+		mapFlags   uint32
+	}{mapType, keySize, valueSize, maxEntries, 0}
+	return bpf(bpfMapCreate, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+}
+
+func bpfMapUpdate(fd int, key, value unsafe.Pointer) error {
+	attr := struct {
+		mapFd uint32
+		_     uint32
+		key   uint64
+		value uint64
+		flags uint64
+	}{mapFd: uint32(fd), key: uint64(uintptr(key)), value: uint64(uintptr(value))}
+	_, err := bpf(bpfMapUpdateElem, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	return err
+}
+
+func bpfMapDelete(fd int, key unsafe.Pointer) error {
+	attr := struct {
+		mapFd uint32
+		_     uint32
+		key   uint64
+	}{mapFd: uint32(fd), key: uint64(uintptr(key))}
+	_, err := bpf(bpfMapDeleteElem, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	return err
+}
+
+func bpfProgLoadFd(progType, attachType uint32, prog []bpfInsn) (int, error) {
+	license := []byte("GPL\x00")
+	logBuf := make([]byte, 65536)
+	attr := struct {
+		progType           uint32
+		insnCnt            uint32
+		insns              uint64
+		license            uint64
+		logLevel           uint32
+		logSize            uint32
+		logBuf             uint64
+		kernVersion        uint32
+		progFlags          uint32
+		progName           [16]byte
+		progIfindex        uint32
+		expectedAttachType uint32
+	}{
+		progType:           progType,
+		insnCnt:            uint32(len(prog)),
+		insns:              uint64(uintptr(unsafe.Pointer(&prog[0]))),
+		license:            uint64(uintptr(unsafe.Pointer(&license[0]))),
+		logLevel:           1,
+		logSize:            uint32(len(logBuf)),
+		logBuf:             uint64(uintptr(unsafe.Pointer(&logBuf[0]))),
+		expectedAttachType: attachType,
+	}
+	copy(attr.progName[:], "docker_ports")
+	fd, err := bpf(bpfProgLoad, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		if n := clen(logBuf); n > 0 {
+			return -1, fmt.Errorf("%v: %s", err, logBuf[:n])
+		}
+		return -1, err
+	}
+	return fd, nil
+}
+
+func clen(b []byte) int {
+	for i, c := range b {
+		if c == 0 {
+			return i
+		}
+	}
+	return len(b)
+}
+
+func bpfLinkCreateFd(progFd, targetFd int, attachType uint32) (int, error) {
+	attr := struct {
+		progFd     uint32
+		targetFd   uint32
+		attachType uint32
+		flags      uint32
+	}{uint32(progFd), uint32(targetFd), attachType, 0}
+	return bpf(bpfLinkCreate, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+}
+
+// steeredKey returns the key of the ports map of a published port.
+func steeredKey(proto uint8, ip net.IP, port int) [steeredKeySize]byte {
+	var key [steeredKeySize]byte
+	nl.NativeEndian().PutUint32(key[0:], uint32(proto))
+	nl.NativeEndian().PutUint32(key[4:], uint32(port))
+	if !ip.IsUnspecified() {
+		copy(key[8:], ip.To16())
+	}
+	return key
+}
+
+// steering is the state of the sk_lookup program attached to the network
+// namespace of the daemon, shared by the port mappers of all the networks.
+type steering struct {
+	mu        sync.Mutex
+	ports     int
+	sockets   int
+	prog      int
+	link      int
+	listeners []*net.TCPListener
+	proxies   map[[steeredKeySize]byte]*inDaemonProxy
+}
+
+var tcpSteering = &steering{}
+
+// CheckEBPF returns an error if the kernel does not support the eBPF port
+// mapping backend.
+func CheckEBPF() error {
+	ports, err := bpfMapCreateFd(bpfMapTypeHash, steeredKeySize, 4, 1)
+	if err != nil {
+		return fmt.Errorf("failed to create a BPF map: %v", err)
+	}
+	defer unix.Close(ports)
+	sockets, err := bpfMapCreateFd(bpfMapTypeSockmap, 4, 8, 2)
+	if err != nil {
+		return fmt.Errorf("failed to create a BPF socket map: %v", err)
+	}
+	defer unix.Close(sockets)
+	prog, err := bpfProgLoadFd(bpfProgTypeSkLookup, bpfSkLookup, steeringProgram(ports, sockets))
+	if err != nil {
+		return fmt.Errorf("failed to load the BPF socket lookup program: %v", err)
+	}
+	unix.Close(prog)
+	return nil
+}
+
+// start loads and attaches the program, and starts the listening sockets to
+// which the connections are steered. The steering must be locked.
+func (s *steering) start() (err error) {
+	for _, fd := range []*int{&s.ports, &s.sockets, &s.prog, &s.link} {
+		*fd = -1
+	}
+	defer func() {
+		if err != nil {
+			s.stop()
+		}
+	}()
+
+	if s.ports, err = bpfMapCreateFd(bpfMapTypeHash, steeredKeySize, 4, steeredPortsMax); err != nil {
+		return fmt.Errorf("failed to create the BPF map of the published ports: %v", err)
+	}
+	if s.sockets, err = bpfMapCreateFd(bpfMapTypeSockmap, 4, 8, 2); err != nil {
+		return fmt.Errorf("failed to create the BPF socket map of the published ports: %v", err)
+	}
+	if s.prog, err = bpfProgLoadFd(bpfProgTypeSkLookup, bpfSkLookup, steeringProgram(s.ports, s.sockets)); err != nil {
+		return fmt.Errorf("failed to load the BPF socket lookup program: %v", err)
+	}
+
+	// The listening sockets are bound to an ephemeral port of the loopback
+	// addresses, as the connections are steered to them whatever their
+	// destination. The IPv6 socket is IPv6 only, as the kernel does not
+	// steer the IPv4 connections to the IPv6 sockets, and is not required
+	// on the hosts without IPv6.
+	for _, l := range []struct {
+		network string
+		addr    *net.TCPAddr
+		idx     uint32
+	}{
+		{"tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, steeredSocketIPv4},
+		{"tcp6", &net.TCPAddr{IP: net.IPv6loopback}, steeredSocketIPv6},
+	} {
+		if err := s.listen(l.network, l.addr, l.idx); err != nil {
+			if l.idx == steeredSocketIPv6 {
+				logrus.Debugf("Published ports are not steered to IPv6: %v", err)
+				continue
+			}
+			return err
+		}
+	}
+
+	if s.link, err = bpfLinkCreateFd(s.prog, ns.ParseHandlerInt(), bpfSkLookup); err != nil {
+		return fmt.Errorf("failed to attach the BPF socket lookup program: %v", err)
+	}
+
+	s.proxies = make(map[[steeredKeySize]byte]*inDaemonProxy)
+	for _, l := range s.listeners {
+		go s.serve(l)
+	}
+	return nil
+}
+
+func (s *steering) listen(network string, addr *net.TCPAddr, idx uint32) (err error) {
+	l, err := net.ListenTCP(network, addr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			l.Close()
+		}
+	}()
+	rc, err := l.SyscallConn()
+	if err != nil {
+		return err
+	}
+	if cerr := rc.Control(func(fd uintptr) {
+		value := uint64(fd)
+		err = bpfMapUpdate(s.sockets, unsafe.Pointer(&idx), unsafe.Pointer(&value))
+	}); cerr != nil {
+		return cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add the %s listening socket of the published ports to the BPF socket map: %v", network, err)
+	}
+	s.listeners = append(s.listeners, l)
+	return nil
+}
+
+// stop detaches the program and closes the listening sockets, once no port
+// is steered. The steering must be locked.
+func (s *steering) stop() {
+	for _, fd := range []*int{&s.link, &s.prog, &s.sockets, &s.ports} {
+		if *fd >= 0 {
+			unix.Close(*fd)
+		}
+		*fd = -1
+	}
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	s.listeners = nil
+	s.proxies = nil
+}
+
+func (s *steering) serve(l *net.TCPListener) {
+	for {
+		client, err := l.AcceptTCP()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		addr := client.LocalAddr().(*net.TCPAddr)
+		s.mu.Lock()
+		p, ok := s.proxies[steeredKey(unix.IPPROTO_TCP, addr.IP, addr.Port)]
+		if !ok {
+			p, ok = s.proxies[steeredKey(unix.IPPROTO_TCP, net.IPv6zero, addr.Port)]
+		}
+		if ok {
+			p.wg.Add(1)
+			go p.proxyTCP(client)
+		}
+		s.mu.Unlock()
+		if !ok {
+			client.Close()
+		}
+	}
+}
+
+func (s *steering) add(p *inDaemonProxy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listeners == nil {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	addr := p.frontend.(*net.TCPAddr)
+	key := steeredKey(unix.IPPROTO_TCP, addr.IP, addr.Port)
+	if _, ok := s.proxies[key]; ok {
+		return fmt.Errorf("port %s/tcp is already steered", addr)
+	}
+	var idx uint32
+	if err := bpfMapUpdate(s.ports, unsafe.Pointer(&key), unsafe.Pointer(&idx)); err != nil {
+		if len(s.proxies) == 0 {
+			s.stop()
+		}
+		return fmt.Errorf("failed to steer port %s/tcp: %v", addr, err)
+	}
+	s.proxies[key] = p
+	return nil
+}
+
+func (s *steering) remove(p *inDaemonProxy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	addr := p.frontend.(*net.TCPAddr)
+	key := steeredKey(unix.IPPROTO_TCP, addr.IP, addr.Port)
+	if s.proxies[key] != p {
+		return
+	}
+	if err := bpfMapDelete(s.ports, unsafe.Pointer(&key)); err != nil {
+		logrus.Warnf("Failed to remove the steering of port %s/tcp: %v", addr, err)
+	}
+	delete(s.proxies, key)
+	if len(s.proxies) == 0 {
+		s.stop()
+	}
+}
+
+// steeredProxy proxies the TCP connections to a published port, which are
+// steered to the listening socket of the eBPF backend.
+type steeredProxy struct {
+	*inDaemonProxy
+}
+
+func newSteeredProxy(hostIP net.IP, hostPort int, containerIP net.IP, containerPort int) userlandProxy {
+	return steeredProxy{&inDaemonProxy{
+		frontend: &net.TCPAddr{IP: hostIP, Port: hostPort},
+		backend:  &net.TCPAddr{IP: containerIP, Port: containerPort},
+		conns:    make(map[io.Closer]struct{}),
+	}}
+}
+
+func (p steeredProxy) Start() error {
+	return tcpSteering.add(p.inDaemonProxy)
+}
+
+func (p steeredProxy) Stop() error {
+	tcpSteering.remove(p.inDaemonProxy)
+	p.closeConns()
+	p.wg.Wait()
+	return nil
+}
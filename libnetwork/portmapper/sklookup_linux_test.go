@@ -0,0 +1,273 @@
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// Addresses of the memory regions of the interpreted program.
+const (
+	vmCtx    = 1 << 32
+	vmStack  = 2 << 32
+	vmValue  = 3 << 32
+	vmSocket = 4 << 32
+
+	vmStackSize = 512
+)
+
+// skLookupVM interprets the instructions of the steering program, with the
+// ports map holding the keys of ports and the sockets map holding the
+// sockets of sockets.
+type skLookupVM struct {
+	t       *testing.T
+	ctx     []byte
+	stack   [vmStackSize]byte
+	ports   map[[steeredKeySize]byte]bool
+	sockets map[uint32]bool
+
+	lookups  [][]byte
+	assigned []uint64
+}
+
+const (
+	vmPortsFd   = 3
+	vmSocketsFd = 4
+)
+
+func (vm *skLookupVM) mem(addr uint64, size int) []byte {
+	switch {
+	case addr >= vmCtx && addr+uint64(size) <= vmCtx+uint64(len(vm.ctx)):
+		return vm.ctx[addr-vmCtx:][:size]
+	case addr >= vmStack && addr+uint64(size) <= vmStack+vmStackSize:
+		return vm.stack[addr-vmStack:][:size]
+	}
+	vm.t.Fatalf("invalid memory access at %#x", addr)
+	return nil
+}
+
+func (vm *skLookupVM) call(fn int32, r *[11]uint64) uint64 {
+	switch fn {
+	case bpfFuncMapLookupElem:
+		switch r[1] {
+		case vmPortsFd:
+			key := vm.mem(r[2], steeredKeySize)
+			vm.lookups = append(vm.lookups, append([]byte{}, key...))
+			var k [steeredKeySize]byte
+			copy(k[:], key)
+			if vm.ports[k] {
+				return vmValue
+			}
+		case vmSocketsFd:
+			idx := nl.NativeEndian().Uint32(vm.mem(r[2], 4))
+			if vm.sockets[idx] {
+				return vmSocket + uint64(idx)
+			}
+		default:
+			vm.t.Fatalf("lookup in unknown map %d", r[1])
+		}
+		return 0
+	case bpfFuncSkAssign:
+		assert.Equal(vm.t, r[1], uint64(vmCtx))
+		vm.assigned = append(vm.assigned, r[2])
+		return 0
+	case bpfFuncSkRelease:
+		return 0
+	}
+	vm.t.Fatalf("call to unknown helper %d", fn)
+	return 0
+}
+
+// run runs the program and returns its result.
+func (vm *skLookupVM) run(prog []bpfInsn) uint64 {
+	var r [11]uint64
+	r[1] = vmCtx
+	r[10] = vmStack + vmStackSize
+	for pc, steps := 0, 0; ; pc, steps = pc+1, steps+1 {
+		if pc < 0 || pc >= len(prog) || steps > len(prog) {
+			vm.t.Fatalf("invalid jump to %d", pc)
+		}
+		in := prog[pc]
+		dst, src := in.regs&0xf, in.regs>>4
+		addr := func(reg uint8) uint64 { return r[reg] + uint64(int64(in.off)) }
+		switch in.code {
+		case 0x18:
+			assert.Equal(vm.t, src, uint8(1), "only map fds are loaded")
+			r[dst] = uint64(uint32(in.imm))
+			pc++
+		case 0x61:
+			r[dst] = uint64(nl.NativeEndian().Uint32(vm.mem(addr(src), 4)))
+		case 0x63:
+			nl.NativeEndian().PutUint32(vm.mem(addr(dst), 4), uint32(r[src]))
+		case 0x62:
+			nl.NativeEndian().PutUint32(vm.mem(addr(dst), 4), uint32(in.imm))
+		case 0x7a:
+			nl.NativeEndian().PutUint64(vm.mem(addr(dst), 8), uint64(int64(in.imm)))
+		case 0xbf:
+			r[dst] = r[src]
+		case 0xb7:
+			r[dst] = uint64(int64(in.imm))
+		case 0x07:
+			r[dst] += uint64(int64(in.imm))
+		case 0x05:
+			pc += int(in.off)
+		case 0x15:
+			if r[dst] == uint64(int64(in.imm)) {
+				pc += int(in.off)
+			}
+		case 0x55:
+			if r[dst] != uint64(int64(in.imm)) {
+				pc += int(in.off)
+			}
+		case 0x85:
+			r[0] = vm.call(in.imm, &r)
+		case 0x95:
+			return r[0]
+		default:
+			vm.t.Fatalf("unknown opcode %#x at %d", in.code, pc)
+		}
+	}
+}
+
+// skLookupCtx returns the struct bpf_sk_lookup context of a connection.
+func skLookupCtx(proto uint32, ip net.IP, port uint32) []byte {
+	ctx := make([]byte, 72)
+	if ip4 := ip.To4(); ip4 != nil {
+		nl.NativeEndian().PutUint32(ctx[skLookupFamily:], unix.AF_INET)
+		copy(ctx[skLookupLocalIP4:], ip4)
+	} else {
+		nl.NativeEndian().PutUint32(ctx[skLookupFamily:], unix.AF_INET6)
+		copy(ctx[skLookupLocalIP6:], ip.To16())
+	}
+	nl.NativeEndian().PutUint32(ctx[skLookupProtocol:], proto)
+	nl.NativeEndian().PutUint32(ctx[skLookupLocalPort:], port)
+	return ctx
+}
+
+func TestSteeredKey(t *testing.T) {
+	key := steeredKey(unix.IPPROTO_TCP, net.ParseIP("192.0.2.1"), 8080)
+	assert.Check(t, is.Equal(nl.NativeEndian().Uint32(key[0:]), uint32(unix.IPPROTO_TCP)))
+	assert.Check(t, is.Equal(nl.NativeEndian().Uint32(key[4:]), uint32(8080)))
+	assert.Check(t, is.DeepEqual(key[8:], []byte(net.ParseIP("192.0.2.1").To16())))
+
+	key = steeredKey(unix.IPPROTO_TCP, net.ParseIP("2001:db8::1"), 80)
+	assert.Check(t, is.DeepEqual(key[8:], []byte(net.ParseIP("2001:db8::1"))))
+
+	// The unspecified addresses of both families have the same key.
+	var zero [16]byte
+	for _, ip := range []net.IP{net.IPv4zero, net.IPv6zero} {
+		key := steeredKey(unix.IPPROTO_TCP, ip, 80)
+		assert.Check(t, is.DeepEqual(key[8:], zero[:]), ip)
+	}
+}
+
+func TestSteeringProgram(t *testing.T) {
+	v4 := net.ParseIP("192.0.2.1")
+	v6 := net.ParseIP("2001:db8::1")
+	for _, tc := range []struct {
+		name     string
+		ctx      []byte
+		ports    [][steeredKeySize]byte
+		sockets  []uint32
+		lookups  [][steeredKeySize]byte
+		assigned []uint64
+	}{
+		{
+			name:     "ipv4 address",
+			ctx:      skLookupCtx(unix.IPPROTO_TCP, v4, 8080),
+			ports:    [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v4, 8080)},
+			sockets:  []uint32{steeredSocketIPv4, steeredSocketIPv6},
+			lookups:  [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v4, 8080)},
+			assigned: []uint64{vmSocket + steeredSocketIPv4},
+		},
+		{
+			name:    "ipv4 unspecified address",
+			ctx:     skLookupCtx(unix.IPPROTO_TCP, v4, 8080),
+			ports:   [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, net.IPv4zero, 8080)},
+			sockets: []uint32{steeredSocketIPv4, steeredSocketIPv6},
+			lookups: [][steeredKeySize]byte{
+				steeredKey(unix.IPPROTO_TCP, v4, 8080),
+				steeredKey(unix.IPPROTO_TCP, net.IPv4zero, 8080),
+			},
+			assigned: []uint64{vmSocket + steeredSocketIPv4},
+		},
+		{
+			name:    "ipv6 address",
+			ctx:     skLookupCtx(unix.IPPROTO_TCP, v6, 443),
+			ports:   [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v6, 443)},
+			sockets: []uint32{steeredSocketIPv4, steeredSocketIPv6},
+			lookups: [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v6, 443)},
+			// The IPv4 connections are steered to the IPv4 socket and
+			// the IPv6 connections to the IPv6 socket.
+			assigned: []uint64{vmSocket + steeredSocketIPv6},
+		},
+		{
+			name:    "ipv6 unspecified address",
+			ctx:     skLookupCtx(unix.IPPROTO_TCP, v6, 443),
+			ports:   [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, net.IPv6zero, 443)},
+			sockets: []uint32{steeredSocketIPv4, steeredSocketIPv6},
+			lookups: [][steeredKeySize]byte{
+				steeredKey(unix.IPPROTO_TCP, v6, 443),
+				steeredKey(unix.IPPROTO_TCP, net.IPv6zero, 443),
+			},
+			assigned: []uint64{vmSocket + steeredSocketIPv6},
+		},
+		{
+			name:    "unpublished port",
+			ctx:     skLookupCtx(unix.IPPROTO_TCP, v4, 8081),
+			ports:   [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v4, 8080)},
+			sockets: []uint32{steeredSocketIPv4, steeredSocketIPv6},
+			lookups: [][steeredKeySize]byte{
+				steeredKey(unix.IPPROTO_TCP, v4, 8081),
+				steeredKey(unix.IPPROTO_TCP, net.IPv4zero, 8081),
+			},
+		},
+		{
+			name:    "other protocol",
+			ctx:     skLookupCtx(unix.IPPROTO_UDP, v4, 8080),
+			ports:   [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v4, 8080)},
+			sockets: []uint32{steeredSocketIPv4, steeredSocketIPv6},
+			lookups: [][steeredKeySize]byte{
+				steeredKey(unix.IPPROTO_UDP, v4, 8080),
+				steeredKey(unix.IPPROTO_UDP, net.IPv4zero, 8080),
+			},
+		},
+		{
+			// Without IPv6 listening socket, the IPv6 connections are
+			// not steered.
+			name:    "missing socket",
+			ctx:     skLookupCtx(unix.IPPROTO_TCP, v6, 443),
+			ports:   [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v6, 443)},
+			sockets: []uint32{steeredSocketIPv4},
+			lookups: [][steeredKeySize]byte{steeredKey(unix.IPPROTO_TCP, v6, 443)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vm := &skLookupVM{
+				t:       t,
+				ctx:     tc.ctx,
+				ports:   make(map[[steeredKeySize]byte]bool),
+				sockets: make(map[uint32]bool),
+			}
+			for _, k := range tc.ports {
+				vm.ports[k] = true
+			}
+			for _, idx := range tc.sockets {
+				vm.sockets[idx] = true
+			}
+			// The connections are never dropped.
+			assert.Check(t, is.Equal(vm.run(steeringProgram(vmPortsFd, vmSocketsFd)), uint64(1)))
+			var expected [][]byte
+			for _, k := range tc.lookups {
+				expected = append(expected, append([]byte{}, k[:]...))
+			}
+			assert.Check(t, is.DeepEqual(vm.lookups, expected), fmt.Sprintf("%x", vm.lookups))
+			assert.Check(t, is.DeepEqual(vm.assigned, tc.assigned))
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// +build !linux
+
+package portmapper // import "github.com/docker/docker/libnetwork/portmapper"
+
+import (
+	"errors"
+	"net"
+)
+
+// CheckEBPF returns an error if the kernel does not support the eBPF port
+// mapping backend.
+func CheckEBPF() error {
+	return errors.New("the eBPF port mapping backend is only supported on Linux")
+}
+
+func newSteeredProxy(hostIP net.IP, hostPort int, containerIP net.IP, containerPort int) userlandProxy {
+	return noopProxy{}
+}
@@ -0,0 +1,207 @@
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+const (
+	dotPort     = "853"
+	dohPath     = "/dns-query"
+	dohMimeType = "application/dns-message"
+)
+
+// secureDNSServer is an external DNS server queried over an encrypted
+// transport, either DNS over TLS (RFC 7858) or DNS over HTTPS (RFC 8484).
+type secureDNSServer struct {
+	url string
+
+	// DNS over TLS
+	addr      string
+	dotClient *dns.Client
+
+	// DNS over HTTPS
+	dohClient *http.Client
+}
+
+// parseSecureDNSServers parses the DNS over TLS ("tls://host[:port]") and
+// DNS over HTTPS ("https://host[:port][/path]") servers. The certificates of
+// the servers are validated against the system roots, or against the CA
+// bundle in caFile if set.
+func parseSecureDNSServers(servers []string, caFile string) ([]*secureDNSServer, error) {
+	if len(servers) == 0 {
+		return nil, nil
+	}
+	tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+		CAFile:             caFile,
+		ExclusiveRootPools: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the CA of the DNS upstreams: %v", err)
+	}
+
+	var list []*secureDNSServer
+	for _, s := range servers {
+		server, err := newSecureDNSServer(s, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, server)
+	}
+	return list, nil
+}
+
+func newSecureDNSServer(server string, tlsConfig *tls.Config) (*secureDNSServer, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS upstream %q: %v", server, err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid DNS upstream %q: missing host", server)
+	}
+
+	switch u.Scheme {
+	case "tls":
+		if u.Path != "" && u.Path != "/" {
+			return nil, fmt.Errorf("invalid DNS upstream %q: DNS over TLS does not support a path", server)
+		}
+		port := u.Port()
+		if port == "" {
+			port = dotPort
+		}
+		cfg := tlsConfig.Clone()
+		cfg.ServerName = u.Hostname()
+		return &secureDNSServer{
+			url:  server,
+			addr: net.JoinHostPort(u.Hostname(), port),
+			dotClient: &dns.Client{
+				Net:       "tcp-tls",
+				TLSConfig: cfg,
+				Timeout:   extIOTimeout,
+			},
+		}, nil
+	case "https":
+		if u.Path == "" || u.Path == "/" {
+			u.Path = dohPath
+		}
+		transport := &http.Transport{
+			TLSClientConfig: tlsConfig.Clone(),
+		}
+		// A custom TLS configuration disables HTTP/2, which the DNS over
+		// HTTPS servers are expected to support.
+		if err := http2.ConfigureTransport(transport); err != nil {
+			return nil, fmt.Errorf("failed to configure HTTP/2 for DNS upstream %q: %v", server, err)
+		}
+		return &secureDNSServer{
+			url: u.String(),
+			dohClient: &http.Client{
+				Timeout:   extIOTimeout,
+				Transport: transport,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid DNS upstream %q: unsupported scheme %q, must be \"tls\" or \"https\"", server, u.Scheme)
+	}
+}
+
+// exchange sends the query to the server and returns its response.
+func (s *secureDNSServer) exchange(query *dns.Msg) (*dns.Msg, error) {
+	if s.dotClient != nil {
+		resp, _, err := s.dotClient.Exchange(query, s.addr)
+		return resp, err
+	}
+
+	// The ID of the query is set to 0 for the response to be cacheable
+	// by HTTP caches, as recommended by RFC 8484.
+	q := query.Copy()
+	q.Id = 0
+	b, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dohMimeType)
+	req.Header.Set("Accept", dohMimeType)
+
+	httpResp, err := s.dohClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned HTTP status %q", httpResp.Status)
+	}
+	if ct := httpResp.Header.Get("Content-Type"); ct != dohMimeType {
+		return nil, fmt.Errorf("unexpected Content-Type %q", ct)
+	}
+	b, err = ioutil.ReadAll(io.LimitReader(httpResp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, err
+	}
+	resp := new(dns.Msg)
+	if err := resp.Unpack(b); err != nil {
+		return nil, err
+	}
+	resp.Id = query.Id
+	return resp, nil
+}
+
+// forwardSecureQuery forwards the query to the secure external servers, in
+// order, until one of them responds. The queries are sent from the network
+// namespace of the daemon.
+func (r *resolver) forwardSecureQuery(query *dns.Msg) *dns.Msg {
+	name := query.Question[0].Name
+	queryType := dns.TypeToString[query.Question[0].Qtype]
+
+	for _, s := range r.secureDNSList {
+		// limits the number of outstanding concurrent queries.
+		if !r.forwardQueryStart() {
+			old := r.tStamp
+			r.tStamp = time.Now()
+			if r.tStamp.Sub(old) > logInterval {
+				logrus.Errorf("[resolver] more than %v concurrent queries", maxConcurrent)
+			}
+			continue
+		}
+		logrus.Debugf("[resolver] query %s (%s), forwarding to %s", name, queryType, s.url)
+		resp, err := s.exchange(query)
+		r.forwardQueryEnd()
+		if err != nil {
+			logrus.Debugf("[resolver] query to DNS server %s failed, %s", s.url, err)
+			continue
+		}
+		if resp.Rcode == dns.RcodeServerFailure {
+			// for Server Failure response, continue to the next external DNS server
+			logrus.Debugf("[resolver] external DNS %s responded with ServFail for %q", s.url, name)
+			continue
+		}
+		for _, rr := range resp.Answer {
+			h := rr.Header()
+			switch h.Rrtype {
+			case dns.TypeA:
+				r.backend.HandleQueryResp(h.Name, rr.(*dns.A).A)
+			case dns.TypeAAAA:
+				r.backend.HandleQueryResp(h.Name, rr.(*dns.AAAA).AAAA)
+			}
+		}
+		resp.Compress = true
+		return resp
+	}
+	return nil
+}
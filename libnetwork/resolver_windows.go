@@ -0,0 +1,7 @@
+// +build windows
+
+package libnetwork // import "github.com/docker/docker/libnetwork"
+
+func (r *resolver) setupIPTable() error {
+	return nil
+}
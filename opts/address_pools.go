@@ -7,7 +7,7 @@ import (
 	"strconv"
 	"strings"
 
-	types "github.com/docker/libnetwork/ipamutils"
+	types "github.com/docker/docker/libnetwork/ipamutils"
 )
 
 // PoolsOpt is a Value type for parsing the default address pools definitions
@@ -48,6 +48,8 @@ func (p *PoolsOpt) Set(value string) error {
 				return fmt.Errorf("invalid size value: %q (must be integer): %v", value, err)
 			}
 			poolsDef.Size = size
+		case "driver":
+			poolsDef.Driver = value
 		default:
 			return fmt.Errorf("unexpected key '%s' in '%s'", key, field)
 		}
@@ -68,6 +70,9 @@ func (p *PoolsOpt) String() string {
 	var pools []string
 	for _, pool := range p.values {
 		repr := fmt.Sprintf("%s %d", pool.Base, pool.Size)
+		if pool.Driver != "" {
+			repr += " " + pool.Driver
+		}
 		pools = append(pools, repr)
 	}
 	return strings.Join(pools, ", ")
@@ -17,4 +17,11 @@ func TestAddressPoolOpt(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if err := poolopt.Set("base=10.10.0.0/16,size=24,driver=overlay"); err != nil {
+		t.Fatal(err)
+	}
+	if pools := poolopt.Value(); len(pools) != 2 || pools[1].Driver != "overlay" {
+		t.Fatalf("unexpected pools %v", poolopt.String())
+	}
+
 }
@@ -3,6 +3,7 @@ package opts // import "github.com/docker/docker/opts"
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"path"
 	"regexp"
 	"strings"
@@ -243,6 +244,25 @@ func ValidateDNSSearch(val string) (string, error) {
 	return validateDomain(val)
 }
 
+// ValidateDNSUpstream validates the URL of a DNS over TLS ("tls://host[:port]")
+// or DNS over HTTPS ("https://host[:port][/path]") server.
+func ValidateDNSUpstream(val string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(val))
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid DNS upstream: %v", val, err)
+	}
+	if u.Scheme != "tls" && u.Scheme != "https" {
+		return "", fmt.Errorf("%s is not a valid DNS upstream: the scheme must be tls or https", val)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("%s is not a valid DNS upstream: missing host", val)
+	}
+	if u.Scheme == "tls" && u.Path != "" && u.Path != "/" {
+		return "", fmt.Errorf("%s is not a valid DNS upstream: DNS over TLS does not support a path", val)
+	}
+	return u.String(), nil
+}
+
 func validateDomain(val string) (string, error) {
 	if alphaRegexp.FindString(val) == "" {
 		return "", fmt.Errorf("%s is not a valid domain", val)
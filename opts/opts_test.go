@@ -173,6 +173,38 @@ func TestValidateDNSSearch(t *testing.T) {
 	}
 }
 
+func TestValidateDNSUpstream(t *testing.T) {
+	valid := []string{
+		`tls://1.1.1.1`,
+		`tls://dns.example.com:853`,
+		`tls://[2001:db8::53]:853`,
+		`https://dns.example.com/dns-query`,
+		`https://dns.example.com`,
+		`https://192.0.2.53:8443/resolve`,
+	}
+	invalid := []string{
+		``,
+		`1.1.1.1`,
+		`udp://1.1.1.1`,
+		`http://dns.example.com/dns-query`,
+		`tls://`,
+		`tls://1.1.1.1/dns-query`,
+		`https:///dns-query`,
+	}
+
+	for _, upstream := range valid {
+		if ret, err := ValidateDNSUpstream(upstream); err != nil || ret == "" {
+			t.Fatalf("ValidateDNSUpstream(`"+upstream+"`) got %s %s", ret, err)
+		}
+	}
+
+	for _, upstream := range invalid {
+		if ret, err := ValidateDNSUpstream(upstream); err == nil || ret != "" {
+			t.Fatalf("ValidateDNSUpstream(`"+upstream+"`) got %s %s", ret, err)
+		}
+	}
+}
+
 func TestValidateLabel(t *testing.T) {
 	if _, err := ValidateLabel("label"); err == nil || err.Error() != "bad attribute format: label" {
 		t.Fatalf("Expected an error [bad attribute format: label], go %v", err)
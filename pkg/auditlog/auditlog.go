@@ -0,0 +1,218 @@
+// Package auditlog records the state-changing API calls of the daemon in an
+// audit log, the records being chained by their sequence numbers and hashes
+// for the modification, removal or reordering of the records to be detected.
+package auditlog // import "github.com/docker/docker/pkg/auditlog"
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// queueSize is the number of records waiting to be written to the sink
+// above which the API calls wait for their records to be queued.
+const queueSize = 1024
+
+// Record is a record of the audit log.
+type Record struct {
+	// Seq is the sequence number of the record, incremented by one for
+	// each record of the log.
+	Seq  uint64
+	Time time.Time
+	// Actor is the client of the API call.
+	Actor Actor
+	// Request is the summary of the request of the API call.
+	Request Request
+	// Result is the result of the API call.
+	Result Result
+	// PrevHash is the hash of the previous record of the log.
+	PrevHash string `json:",omitempty"`
+	// Hash is the SHA-256 hash of the record, computed with its previous
+	// hash and without its hash.
+	Hash string
+}
+
+// Actor is the client of an API call.
+type Actor struct {
+	// User is the user that authenticated the request, with TLS or an
+	// authorization plugin, and AuthNMethod how it was authenticated.
+	User        string `json:",omitempty"`
+	AuthNMethod string `json:",omitempty"`
+	RemoteAddr  string `json:",omitempty"`
+	UserAgent   string `json:",omitempty"`
+}
+
+// Request is the summary of the request of an API call.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values `json:",omitempty"`
+}
+
+// Result is the result of an API call.
+type Result struct {
+	// Status is the HTTP status of the response.
+	Status int
+	// Error is the error message of the response, if any.
+	Error    string `json:",omitempty"`
+	Duration time.Duration
+}
+
+// hash returns the hash of a record, computed without its hash.
+func (r Record) hash() (string, error) {
+	r.Hash = ""
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify verifies that the records are consecutive records of an audit log,
+// in order, and were not modified.
+func Verify(records []Record) error {
+	for i, r := range records {
+		hash, err := r.hash()
+		if err != nil {
+			return err
+		}
+		if hash != r.Hash {
+			return fmt.Errorf("audit record %d was modified", r.Seq)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := records[i-1]
+		if r.Seq != prev.Seq+1 {
+			return fmt.Errorf("audit records %d to %d are missing", prev.Seq+1, r.Seq-1)
+		}
+		if r.PrevHash != prev.Hash {
+			return fmt.Errorf("audit record %d does not follow record %d", r.Seq, prev.Seq)
+		}
+	}
+	return nil
+}
+
+// Sink is where the records of an audit log are written.
+type Sink interface {
+	Write(r *Record) error
+	Close() error
+}
+
+// state is the state of an audit log, persisted for the log to continue
+// after the restarts of the daemon.
+type state struct {
+	Seq  uint64
+	Hash string
+}
+
+// Log is an audit log writing its records to a sink, in the order they are
+// added.
+type Log struct {
+	sink      Sink
+	statePath string
+	state     state
+
+	records chan *Record
+	done    chan struct{}
+	// mu protects closed, the records being added with a read lock held
+	// for records not to be closed while they are added
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New returns an audit log writing its records to sink, continuing the log
+// whose state is persisted at statePath, if any.
+func New(sink Sink, statePath string) (*Log, error) {
+	l := &Log{
+		sink:      sink,
+		statePath: statePath,
+		records:   make(chan *Record, queueSize),
+		done:      make(chan struct{}),
+	}
+	b, err := ioutil.ReadFile(statePath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, errors.Wrap(err, "error reading audit log state")
+	default:
+		if err := json.Unmarshal(b, &l.state); err != nil {
+			return nil, errors.Wrap(err, "error reading audit log state")
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating audit log state directory")
+	}
+	go l.write()
+	return l, nil
+}
+
+// Add adds a record to the log, waiting for it to be queued when the sink has
+// too many records waiting to be written. The sequence number and the hashes
+// of the record are set when it is written. The records added once the log is
+// closed, such as those of the hijacked requests ending after the API server
+// is shut down, are dropped.
+func (l *Log) Add(r *Record) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		logrus.WithField("method", r.Request.Method).WithField("path", r.Request.Path).Warn("Dropping audit record added after the audit log was closed")
+		return
+	}
+	l.records <- r
+}
+
+func (l *Log) write() {
+	defer close(l.done)
+	for r := range l.records {
+		r.Seq, r.PrevHash = l.state.Seq+1, l.state.Hash
+		hash, err := r.hash()
+		if err != nil {
+			logrus.WithError(err).Error("Error hashing audit record")
+			continue
+		}
+		r.Hash = hash
+		// the records which are not written do not use sequence numbers,
+		// for the missing records to be the removed ones
+		if err := l.sink.Write(r); err != nil {
+			logrus.WithError(err).WithField("method", r.Request.Method).WithField("path", r.Request.Path).Error("Error writing audit record")
+			continue
+		}
+		l.state = state{Seq: r.Seq, Hash: r.Hash}
+		if err := l.saveState(); err != nil {
+			logrus.WithError(err).Error("Error saving audit log state")
+		}
+	}
+}
+
+func (l *Log) saveState() error {
+	b, err := json.Marshal(l.state)
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(l.statePath, b, 0600)
+}
+
+// Close writes the records which are queued, and closes the sink.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.records)
+	}
+	l.mu.Unlock()
+	<-l.done
+	return l.sink.Close()
+}
@@ -0,0 +1,183 @@
+package auditlog // import "github.com/docker/docker/pkg/auditlog"
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	assert.NilError(t, scanner.Err())
+	return records
+}
+
+func addRecords(l *Log, paths ...string) {
+	for _, p := range paths {
+		l.Add(&Record{
+			Time:    time.Now().UTC(),
+			Actor:   Actor{User: "alice", AuthNMethod: "TLS"},
+			Request: Request{Method: "POST", Path: p},
+			Result:  Result{Status: http.StatusNoContent},
+		})
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	logPath, statePath := filepath.Join(dir, "audit.log"), filepath.Join(dir, "state.json")
+
+	sink, err := NewFileSink(logPath)
+	assert.NilError(t, err)
+	l, err := New(sink, statePath)
+	assert.NilError(t, err)
+	addRecords(l, "/containers/c1/start", "/containers/c1/stop")
+	assert.NilError(t, l.Close())
+
+	// the log continues after a restart
+	sink, err = NewFileSink(logPath)
+	assert.NilError(t, err)
+	l, err = New(sink, statePath)
+	assert.NilError(t, err)
+	addRecords(l, "/containers/c1/kill")
+	assert.NilError(t, l.Close())
+
+	records := readRecords(t, logPath)
+	assert.Assert(t, is.Len(records, 3))
+	for i, r := range records {
+		assert.Check(t, is.Equal(r.Seq, uint64(i+1)))
+	}
+	assert.Check(t, is.Equal(records[0].PrevHash, ""))
+	assert.Check(t, is.Equal(records[2].Request.Path, "/containers/c1/kill"))
+	assert.Check(t, Verify(records))
+}
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink, err := NewFileSink(filepath.Join(dir, "audit.log"))
+	assert.NilError(t, err)
+	l, err := New(sink, filepath.Join(dir, "state.json"))
+	assert.NilError(t, err)
+	addRecords(l, "/containers/c1/start", "/containers/c2/start", "/containers/c3/start")
+	assert.NilError(t, l.Close())
+	records := readRecords(t, filepath.Join(dir, "audit.log"))
+
+	modified := append([]Record(nil), records...)
+	modified[1].Result.Status = http.StatusForbidden
+	assert.Check(t, is.Error(Verify(modified), "audit record 2 was modified"))
+
+	removed := []Record{records[0], records[2]}
+	assert.Check(t, is.Error(Verify(removed), "audit records 2 to 2 are missing"))
+
+	// a record removed and the following records renumbered, without
+	// rehashing them
+	renumbered := append([]Record(nil), records[0], records[2])
+	renumbered[1].Seq = 2
+	assert.Check(t, is.Error(Verify(renumbered), "audit record 2 was modified"))
+}
+
+type failingSink struct {
+	fail    bool
+	records []*Record
+}
+
+func (s *failingSink) Write(r *Record) error {
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *failingSink) Close() error {
+	return nil
+}
+
+func TestLogSinkError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	statePath := filepath.Join(dir, "state.json")
+
+	sink := &failingSink{fail: true}
+	l, err := New(sink, statePath)
+	assert.NilError(t, err)
+	addRecords(l, "/containers/c1/start")
+	assert.NilError(t, l.Close())
+
+	// the records which are not written do not use sequence numbers
+	sink = &failingSink{}
+	l, err = New(sink, statePath)
+	assert.NilError(t, err)
+	addRecords(l, "/containers/c1/start")
+	assert.NilError(t, l.Close())
+	assert.Assert(t, is.Len(sink.records, 1))
+	assert.Check(t, is.Equal(sink.records[0].Seq, uint64(1)))
+}
+
+func TestLogAddAfterClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink := &failingSink{}
+	l, err := New(sink, filepath.Join(dir, "state.json"))
+	assert.NilError(t, err)
+	addRecords(l, "/containers/c1/attach")
+	assert.NilError(t, l.Close())
+
+	// the records of the requests ending after the log is closed are
+	// dropped
+	addRecords(l, "/containers/c2/attach")
+	assert.NilError(t, l.Close())
+	assert.Assert(t, is.Len(sink.records, 1))
+	assert.Check(t, is.Equal(sink.records[0].Request.Path, "/containers/c1/attach"))
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []Record
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rec Record
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, rec)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	defer sink.Close()
+	assert.NilError(t, sink.Write(&Record{Seq: 1, Request: Request{Method: "DELETE", Path: "/containers/c1"}}))
+	assert.Assert(t, is.Len(received, 1))
+	assert.Check(t, is.Equal(received[0].Request.Path, "/containers/c1"))
+
+	status = http.StatusInternalServerError
+	assert.Check(t, is.ErrorContains(sink.Write(&Record{Seq: 2}), "status 500"))
+}
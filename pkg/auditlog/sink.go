@@ -0,0 +1,104 @@
+package auditlog // import "github.com/docker/docker/pkg/auditlog"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileSink is a sink appending the records to a file, one JSON-encoded
+// record per line.
+type FileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileSink returns a sink appending the records to the file at path.
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating audit log directory")
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening audit log")
+	}
+	return &FileSink{f: f}, nil
+}
+
+// Write appends a record to the file.
+func (s *FileSink) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "error writing audit log")
+	}
+	return s.f.Sync()
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// webhookTimeout is the timeout of the requests posting the records to a
+// webhook.
+const webhookTimeout = 10 * time.Second
+
+// WebhookSink is a sink posting the records to a webhook, one JSON-encoded
+// record per request.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink posting the records to the webhook at url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{}}
+}
+
+// Write posts a record to the webhook, the record being written when the
+// webhook responds with a 2xx status.
+func (s *WebhookSink) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "error posting audit record")
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error posting audit record: webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close closes the idle connections to the webhook.
+func (s *WebhookSink) Close() error {
+	if t, ok := s.client.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// +build !windows
+
+package auditlog // import "github.com/docker/docker/pkg/auditlog"
+
+import (
+	"encoding/json"
+	"log/syslog"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// SyslogSink is a sink sending the records to syslog, one JSON-encoded
+// record per message.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink returns a sink sending the records to the syslog server at
+// address, a URL such as udp://host:514 or unix:///dev/log, or to the local
+// syslog server if it is empty.
+func NewSyslogSink(address string) (Sink, error) {
+	var network, raddr string
+	if address != "" {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid syslog address")
+		}
+		network = u.Scheme
+		switch network {
+		case "unix", "unixgram":
+			raddr = u.Path
+		case "tcp", "udp":
+			raddr = u.Host
+		default:
+			return nil, errors.Errorf("invalid syslog address %q: unsupported protocol %s", address, network)
+		}
+	}
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_AUTHPRIV, "dockerd-audit")
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to syslog")
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+// Write sends a record to syslog.
+func (s *SyslogSink) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(b))
+}
+
+// Close closes the connection to syslog.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,8 @@
+package auditlog // import "github.com/docker/docker/pkg/auditlog"
+
+import "errors"
+
+// NewSyslogSink returns an error, syslog not being supported on Windows.
+func NewSyslogSink(address string) (Sink, error) {
+	return nil, errors.New("the syslog audit log sink is not supported on Windows")
+}
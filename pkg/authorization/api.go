@@ -15,6 +15,38 @@ const (
 
 	// AuthZApiImplements is the name of the interface all AuthZ plugins implement
 	AuthZApiImplements = "authz"
+
+	// AuthZApiCapabilities is the url for the capabilities of the plugins.
+	// It is optional, the plugins not implementing it implementing the v1
+	// protocol.
+	AuthZApiCapabilities = "AuthZPlugin.Capabilities"
+
+	// AuthZApiRequestBody is the url for the authorization of the chunks of
+	// the request bodies, and of the data sent by the clients on the
+	// hijacked connections, by the plugins implementing the v2 protocol
+	AuthZApiRequestBody = "AuthZPlugin.AuthZReqBody"
+
+	// AuthZApiResponseBody is the url for the authorization of the chunks of
+	// the response bodies, and of the data sent by the daemon on the
+	// hijacked connections, by the plugins implementing the v2 protocol
+	AuthZApiResponseBody = "AuthZPlugin.AuthZResBody"
+)
+
+// The versions of the protocol of the plugins. The plugins implementing the
+// v2 protocol authorize the responses when the daemon starts sending them,
+// without the responses being buffered, can inspect the bodies as they are
+// streamed, and return structured decisions.
+const (
+	ProtocolV1 = 1
+	ProtocolV2 = 2
+)
+
+// The modes of the decisions of the plugins implementing the v2 protocol.
+const (
+	// DecisionModeEnforce denies the requests the plugins do not allow.
+	DecisionModeEnforce = "enforce"
+	// DecisionModeAudit only logs the requests the plugins do not allow.
+	DecisionModeAudit = "audit"
 )
 
 // PeerCertificate is a wrapper around x509.Certificate which provides a sane
@@ -73,6 +105,10 @@ type Request struct {
 
 	// ResponseHeaders stores the response headers sent to the docker daemon
 	ResponseHeaders map[string]string `json:"ResponseHeaders,omitempty"`
+
+	// RequestID identifies the request, for the plugins implementing the v2
+	// protocol to associate the chunks of its bodies with it
+	RequestID string `json:"RequestId,omitempty"`
 }
 
 // Response represents authZ plugin response
@@ -85,4 +121,66 @@ type Response struct {
 
 	// Err stores a message in case there's an error
 	Err string `json:"Err,omitempty"`
+
+	// User is the user the plugin authenticated the request as, replacing
+	// the user extracted by the daemon for the daemon and the next plugins.
+	// It is only used in the responses to the requests.
+	User string `json:"User,omitempty"`
+
+	// Decision is the decision of the plugins implementing the v2 protocol,
+	// replacing Allow and Msg
+	Decision *Decision `json:"Decision,omitempty"`
+}
+
+// Capabilities represents the capabilities of an authZ plugin
+type Capabilities struct {
+	// Version is the version of the protocol the plugin implements
+	Version int `json:"Version"`
+}
+
+// Decision represents the decision of an authZ plugin implementing the v2
+// protocol
+type Decision struct {
+	// Allow indicates whether the request is allowed
+	Allow bool `json:"Allow"`
+
+	// Mode is how the decision is applied, enforce (the default) or audit
+	Mode string `json:"Mode,omitempty"`
+
+	// Reason stores the reason of the decision, returned to the clients of
+	// the denied requests
+	Reason string `json:"Reason,omitempty"`
+
+	// Rule identifies the rule of the policy of the plugin the decision was
+	// made by, which is logged with the audited requests
+	Rule string `json:"Rule,omitempty"`
+
+	// InspectRequestBody requests the chunks of the request body, and of the
+	// data sent by the client on a hijacked connection, to be authorized by
+	// the plugin. It is only used in the responses to the requests.
+	InspectRequestBody bool `json:"InspectRequestBody,omitempty"`
+
+	// InspectResponseBody requests the chunks of the response body, and of
+	// the data sent by the daemon on a hijacked connection, to be authorized
+	// by the plugin. It is only used in the responses to the requests.
+	InspectResponseBody bool `json:"InspectResponseBody,omitempty"`
+}
+
+// BodyChunk holds a chunk of the body of a request or response, sent to the
+// authZ plugins implementing the v2 protocol
+type BodyChunk struct {
+	// RequestID identifies the request of the body
+	RequestID string `json:"RequestId"`
+
+	// Offset is the offset of the chunk in the body
+	Offset int64 `json:"Offset"`
+
+	// Data stores the raw content of the chunk
+	Data []byte `json:"Data,omitempty"`
+
+	// EOF indicates the chunk is the last one of the body, and may be empty
+	EOF bool `json:"EOF,omitempty"`
+
+	// Hijacked indicates the chunk is data sent on a hijacked connection
+	Hijacked bool `json:"Hijacked,omitempty"`
 }
@@ -8,8 +8,10 @@ import (
 	"mime"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,6 +33,10 @@ const maxBodySize = 1048576 // 1MB
 // If multiple authZ plugins are specified, the block/allow decision is based on ANDing all plugin results
 // For response manipulation, the response from each plugin is piped between plugins. Plugin execution order
 // is determined according to daemon parameters
+//
+// The plugins implementing the v2 protocol authorize the response when the
+// daemon starts sending it, and can inspect the request and response bodies
+// as they are streamed, see WrapResponseWriter.
 func NewCtx(authZPlugins []Plugin, user, userAuthNMethod, requestMethod, requestURI string) *Ctx {
 	return &Ctx{
 		plugins:         authZPlugins,
@@ -50,6 +56,16 @@ type Ctx struct {
 	plugins         []Plugin
 	// authReq stores the cached request object for the current transaction
 	authReq *Request
+	// requestBodyPlugins and responseBodyPlugins store the plugins
+	// implementing the v2 protocol that inspect the request and response
+	// bodies of the current transaction
+	requestBodyPlugins  []StreamingPlugin
+	responseBodyPlugins []StreamingPlugin
+
+	mu sync.Mutex
+	// denied stores the error of the plugins implementing the v2 protocol
+	// that denied the streamed response or bodies
+	denied error
 }
 
 // AuthZRequest authorized the request to the docker daemon using authZ plugins
@@ -76,6 +92,12 @@ func (ctx *Ctx) AuthZRequest(w http.ResponseWriter, r *http.Request) error {
 		RequestBody:     body,
 		RequestHeaders:  headers(r.Header),
 	}
+	for _, plugin := range ctx.plugins {
+		if pluginVersion(plugin) >= ProtocolV2 {
+			ctx.authReq.RequestID = stringid.GenerateRandomID()
+			break
+		}
+	}
 
 	if r.TLS != nil {
 		for _, c := range r.TLS.PeerCertificates {
@@ -92,15 +114,36 @@ func (ctx *Ctx) AuthZRequest(w http.ResponseWriter, r *http.Request) error {
 			return fmt.Errorf("plugin %s failed with error: %s", plugin.Name(), err)
 		}
 
-		if !authRes.Allow {
-			return newAuthorizationError(plugin.Name(), authRes.Msg)
+		if err := ctx.decide(plugin, authRes); err != nil {
+			return err
+		}
+
+		if d := authRes.Decision; d != nil && pluginVersion(plugin) >= ProtocolV2 {
+			if d.InspectRequestBody {
+				ctx.requestBodyPlugins = append(ctx.requestBodyPlugins, plugin.(StreamingPlugin))
+			}
+			if d.InspectResponseBody {
+				ctx.responseBodyPlugins = append(ctx.responseBodyPlugins, plugin.(StreamingPlugin))
+			}
+		}
+
+		if authRes.User != "" {
+			// the user was authenticated by the plugin
+			ctx.user, ctx.userAuthNMethod = authRes.User, plugin.Name()
+			ctx.authReq.User, ctx.authReq.UserAuthNMethod = ctx.user, ctx.userAuthNMethod
 		}
 	}
 
+	if len(ctx.requestBodyPlugins) > 0 && r.Body != nil {
+		r.Body = &requestBody{ReadCloser: r.Body, inspector: ctx.newBodyInspector(ctx.requestBodyPlugins, false, false)}
+	}
+
 	return nil
 }
 
-// AuthZResponse authorized and manipulates the response from docker daemon using authZ plugins
+// AuthZResponse authorized and manipulates the response from docker daemon using authZ plugins.
+// The plugins implementing the v2 protocol are skipped, as they authorize the response when the
+// daemon starts sending it.
 func (ctx *Ctx) AuthZResponse(rm ResponseModifier, r *http.Request) error {
 	ctx.authReq.ResponseStatusCode = rm.StatusCode()
 	ctx.authReq.ResponseHeaders = headers(rm.Header())
@@ -110,6 +153,9 @@ func (ctx *Ctx) AuthZResponse(rm ResponseModifier, r *http.Request) error {
 	}
 
 	for _, plugin := range ctx.plugins {
+		if pluginVersion(plugin) >= ProtocolV2 {
+			continue
+		}
 		logrus.Debugf("AuthZ response using plugin %s", plugin.Name())
 
 		authRes, err := plugin.AuthZResponse(ctx.authReq)
@@ -127,6 +173,76 @@ func (ctx *Ctx) AuthZResponse(rm ResponseModifier, r *http.Request) error {
 	return nil
 }
 
+// authZResponseHeaders authorizes the response from the docker daemon, when it starts being sent,
+// using the authZ plugins implementing the v2 protocol
+func (ctx *Ctx) authZResponseHeaders(statusCode int, header http.Header) error {
+	ctx.authReq.ResponseStatusCode = statusCode
+	ctx.authReq.ResponseHeaders = headers(header)
+
+	for _, plugin := range ctx.plugins {
+		if pluginVersion(plugin) < ProtocolV2 {
+			continue
+		}
+		logrus.Debugf("AuthZ response using plugin %s", plugin.Name())
+
+		authRes, err := plugin.AuthZResponse(ctx.authReq)
+		if err != nil {
+			return fmt.Errorf("plugin %s failed with error: %s", plugin.Name(), err)
+		}
+
+		if err := ctx.decide(plugin, authRes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decide returns the authorization error of the request when the response of
+// a plugin denies it. The requests denied by the decisions of the plugins
+// implementing the v2 protocol in audit mode are only logged.
+func (ctx *Ctx) decide(plugin Plugin, authRes *Response) error {
+	d := authRes.Decision
+	if d == nil || pluginVersion(plugin) < ProtocolV2 {
+		if !authRes.Allow {
+			return newAuthorizationError(plugin.Name(), authRes.Msg)
+		}
+		return nil
+	}
+	if d.Allow {
+		return nil
+	}
+	if d.Mode == DecisionModeAudit {
+		logrus.WithFields(logrus.Fields{
+			"plugin": plugin.Name(),
+			"rule":   d.Rule,
+			"user":   ctx.user,
+			"method": ctx.requestMethod,
+			"uri":    ctx.requestURI,
+		}).Warnf("Request would be denied by authorization plugin: %s", d.Reason)
+		return nil
+	}
+	return newAuthorizationError(plugin.Name(), d.Reason)
+}
+
+// deny records the error denying the streamed response or bodies, returning
+// the first one
+func (ctx *Ctx) deny(err error) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.denied == nil {
+		ctx.denied = err
+	}
+	return ctx.denied
+}
+
+// deniedErr returns the error denying the streamed response or bodies
+func (ctx *Ctx) deniedErr() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.denied
+}
+
 // drainBody dump the body (if its length is less than 1MB) without modifying the request state
 func drainBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
 	bufReader := bufio.NewReaderSize(body, maxBodySize)
@@ -120,6 +120,57 @@ func TestAuthZResponsePlugin(t *testing.T) {
 	}
 }
 
+func TestAuthZPluginVersion(t *testing.T) {
+	server := authZPluginTestServer{t: t}
+	server.start()
+	defer server.stop()
+
+	// the plugins not implementing the capabilities api implement the v1 protocol
+	if v := createTestPlugin(t).Version(); v != ProtocolV1 {
+		t.Fatalf("Expected the v1 protocol, got %d", v)
+	}
+
+	server.capabilities = &Capabilities{Version: ProtocolV2}
+	if v := createTestPlugin(t).Version(); v != ProtocolV2 {
+		t.Fatalf("Expected the v2 protocol, got %d", v)
+	}
+
+	server.capabilities = &Capabilities{Version: 3}
+	if v := createTestPlugin(t).Version(); v != ProtocolV1 {
+		t.Fatalf("Expected the v1 protocol for an unsupported version, got %d", v)
+	}
+}
+
+func TestAuthZRequestBodyPlugin(t *testing.T) {
+	server := authZPluginTestServer{t: t}
+	server.start()
+	defer server.stop()
+
+	authZPlugin := createTestPlugin(t)
+
+	chunk := BodyChunk{
+		RequestID: "id",
+		Offset:    10,
+		Data:      []byte("sample body"),
+		EOF:       true,
+	}
+	server.replayResponse = Response{
+		Decision: &Decision{Allow: false, Mode: DecisionModeAudit, Reason: "reason", Rule: "rule"},
+	}
+
+	actualResponse, err := authZPlugin.AuthZRequestBody(&chunk)
+	if err != nil {
+		t.Fatalf("Failed to authorize request body %v", err)
+	}
+
+	if !reflect.DeepEqual(server.replayResponse, *actualResponse) {
+		t.Fatal("Response must be equal")
+	}
+	if !reflect.DeepEqual(chunk, server.recordedChunk) {
+		t.Fatal("Chunks must be equal")
+	}
+}
+
 func TestResponseModifier(t *testing.T) {
 	r := httptest.NewRecorder()
 	m := NewResponseModifier(r)
@@ -284,7 +335,12 @@ type authZPluginTestServer struct {
 	recordedRequest Request
 	// response stores the response sent from the plugin to the daemon
 	replayResponse Response
-	server         *httptest.Server
+	// capabilities stores the capabilities of the plugin, the plugin not
+	// implementing the capabilities api when it is nil
+	capabilities *Capabilities
+	// recordedChunk stores the body chunk sent from the daemon to the plugin
+	recordedChunk BodyChunk
+	server        *httptest.Server
 }
 
 // start starts the test server that implements the plugin
@@ -298,6 +354,9 @@ func (t *authZPluginTestServer) start() {
 	r.HandleFunc("/Plugin.Activate", t.activate)
 	r.HandleFunc("/"+AuthZApiRequest, t.auth)
 	r.HandleFunc("/"+AuthZApiResponse, t.auth)
+	r.HandleFunc("/"+AuthZApiCapabilities, t.caps)
+	r.HandleFunc("/"+AuthZApiRequestBody, t.authBody)
+	r.HandleFunc("/"+AuthZApiResponseBody, t.authBody)
 	t.server = &httptest.Server{
 		Listener: l,
 		Config: &http.Server{
@@ -333,6 +392,31 @@ func (t *authZPluginTestServer) auth(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// authBody is a used to record/replay the body chunk api messages
+func (t *authZPluginTestServer) authBody(w http.ResponseWriter, r *http.Request) {
+	t.recordedChunk = BodyChunk{}
+	if err := json.NewDecoder(r.Body).Decode(&t.recordedChunk); err != nil {
+		t.t.Fatal(err)
+	}
+	b, err := json.Marshal(t.replayResponse)
+	if err != nil {
+		t.t.Fatal(err)
+	}
+	w.Write(b)
+}
+
+func (t *authZPluginTestServer) caps(w http.ResponseWriter, r *http.Request) {
+	if t.capabilities == nil {
+		http.NotFound(w, r)
+		return
+	}
+	b, err := json.Marshal(t.capabilities)
+	if err != nil {
+		t.t.Fatal(err)
+	}
+	w.Write(b)
+}
+
 func (t *authZPluginTestServer) activate(w http.ResponseWriter, r *http.Request) {
 	b, err := json.Marshal(plugins.Manifest{Implements: []string{AuthZApiImplements}})
 	if err != nil {
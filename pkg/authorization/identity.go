@@ -0,0 +1,179 @@
+package authorization // import "github.com/docker/docker/pkg/authorization"
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Policy is the coarse policy restricting the API requests of an identity.
+type Policy string
+
+const (
+	// PolicyReadOnly only allows the requests which do not change the state
+	// of the daemon: the GET and HEAD requests, except the attachments to the
+	// stdin of the containers.
+	PolicyReadOnly Policy = "read-only"
+	// PolicyNoExec allows the requests other than the creation and the start
+	// of the exec instances.
+	PolicyNoExec Policy = "no-exec"
+	// PolicyFull allows all the requests.
+	PolicyFull Policy = "full"
+)
+
+// ValidatePolicy validates a policy, the empty policy being valid.
+func ValidatePolicy(p Policy) error {
+	switch p {
+	case "", PolicyReadOnly, PolicyNoExec, PolicyFull:
+		return nil
+	}
+	return fmt.Errorf("invalid policy %q: must be %s, %s or %s", p, PolicyReadOnly, PolicyNoExec, PolicyFull)
+}
+
+// TLSIdentity is a named identity the certificates of the TLS clients are
+// mapped to, the API requests of the identity being restricted by its
+// policy.
+type TLSIdentity struct {
+	Name string
+	// Subjects are the common names of the subjects of the certificates of
+	// the identity.
+	Subjects []string `json:",omitempty"`
+	// SANs are the subject alternative names of the certificates of the
+	// identity: DNS names, email addresses, IP addresses or URIs.
+	SANs   []string `json:",omitempty"`
+	Policy Policy
+}
+
+// matches returns whether a certificate is a certificate of the identity.
+func (i TLSIdentity) matches(cert *x509.Certificate) bool {
+	for _, s := range i.Subjects {
+		if s == cert.Subject.CommonName {
+			return true
+		}
+	}
+	for _, san := range i.SANs {
+		for _, name := range cert.DNSNames {
+			if san == name {
+				return true
+			}
+		}
+		for _, email := range cert.EmailAddresses {
+			if san == email {
+				return true
+			}
+		}
+		for _, ip := range cert.IPAddresses {
+			if san == ip.String() {
+				return true
+			}
+		}
+		for _, uri := range cert.URIs {
+			if san == uri.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	versionPrefix = regexp.MustCompile(`^/v[0-9.]+/`)
+	execPath      = regexp.MustCompile(`^/(containers/[^/]+/exec|exec/[^/]+/(start|resize))$`)
+	attachWSPath  = regexp.MustCompile(`^/containers/[^/]+/attach/ws$`)
+)
+
+// allows returns whether the policy allows a request.
+func (p Policy) allows(r *http.Request) bool {
+	urlPath := versionPrefix.ReplaceAllString(path.Clean(r.URL.Path), "/")
+	switch p {
+	case PolicyReadOnly:
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return false
+		}
+		// the websocket attachments are GET requests
+		return !attachWSPath.MatchString(urlPath) || !boolValue(r.URL.Query().Get("stdin"))
+	case PolicyNoExec:
+		return r.Method != http.MethodPost || !execPath.MatchString(urlPath)
+	}
+	return true
+}
+
+// boolValue parses a boolean query parameter as the API server does.
+func boolValue(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return !(s == "" || s == "0" || s == "no" || s == "false" || s == "none")
+}
+
+// TLSIdentityMiddleware maps the certificates of the TLS clients to named
+// identities, the requests of the identities being restricted by their
+// policies before they are authorized by the authorization plugins. The
+// identities are the users the requests are authenticated as.
+type TLSIdentityMiddleware struct {
+	mu            sync.Mutex
+	identities    []TLSIdentity
+	defaultPolicy Policy
+}
+
+// NewTLSIdentityMiddleware creates a new TLSIdentityMiddleware mapping the
+// certificates to the first of the identities they match. The requests with
+// the certificates that match no identity are restricted by the default
+// policy, or allowed if it is empty.
+func NewTLSIdentityMiddleware(identities []TLSIdentity, defaultPolicy Policy) *TLSIdentityMiddleware {
+	m := &TLSIdentityMiddleware{}
+	m.SetIdentities(identities, defaultPolicy)
+	return m
+}
+
+// SetIdentities sets the identities the certificates are mapped to, and the
+// default policy.
+func (m *TLSIdentityMiddleware) SetIdentities(identities []TLSIdentity, defaultPolicy Policy) {
+	m.mu.Lock()
+	m.identities, m.defaultPolicy = identities, defaultPolicy
+	m.mu.Unlock()
+}
+
+// identity returns the identity a certificate is mapped to, or false if it
+// matches no identity, and the policy restricting its requests.
+func (m *TLSIdentityMiddleware) identity(cert *x509.Certificate) (string, Policy, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, i := range m.identities {
+		if i.matches(cert) {
+			return i.Name, i.Policy, true
+		}
+	}
+	return "", m.defaultPolicy, false
+}
+
+// WrapHandler returns a new handler function wrapping the previous one in the request chain.
+func (m *TLSIdentityMiddleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			return handler(ctx, w, r, vars)
+		}
+		cert := r.TLS.PeerCertificates[0]
+		name, policy, ok := m.identity(cert)
+		if ok {
+			ctx = WithUser(ctx, name, "TLS")
+		} else {
+			name = cert.Subject.CommonName
+		}
+		if policy != "" && !policy.allows(r) {
+			return policyError{fmt.Errorf("%s %s is not allowed for identity %s by its %s policy", r.Method, r.URL.Path, name, policy)}
+		}
+		return handler(ctx, w, r, vars)
+	}
+}
+
+// policyError is the error of the requests denied by the policies of the
+// identities.
+type policyError struct {
+	error
+}
+
+func (policyError) Forbidden() {}
@@ -0,0 +1,104 @@
+package authorization // import "github.com/docker/docker/pkg/authorization"
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestTLSIdentityMiddleware(t *testing.T) {
+	m := NewTLSIdentityMiddleware([]TLSIdentity{
+		{Name: "monitoring", Subjects: []string{"prometheus"}, Policy: PolicyReadOnly},
+		{Name: "ci", SANs: []string{"ci.example.com", "10.0.0.5"}, Policy: PolicyNoExec},
+		{Name: "admin", Subjects: []string{"alice"}, Policy: PolicyFull},
+	}, "")
+	var user string
+	handler := m.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		user, _ = UserFromContext(ctx)
+		return nil
+	})
+	request := func(method, target string, cert *x509.Certificate) error {
+		user = ""
+		r := httptest.NewRequest(method, target, nil)
+		if cert != nil {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		}
+		return handler(context.Background(), httptest.NewRecorder(), r, nil)
+	}
+
+	monitoring := &x509.Certificate{Subject: pkix.Name{CommonName: "prometheus"}}
+	ci := &x509.Certificate{Subject: pkix.Name{CommonName: "runner"}, IPAddresses: []net.IP{net.ParseIP("10.0.0.5")}}
+	admin := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+	other := &x509.Certificate{Subject: pkix.Name{CommonName: "bob"}}
+
+	testCases := []struct {
+		method, target string
+		cert           *x509.Certificate
+		user           string
+		denied         bool
+	}{
+		{method: "GET", target: "/v1.40/containers/json", cert: monitoring, user: "monitoring"},
+		{method: "HEAD", target: "/_ping", cert: monitoring, user: "monitoring"},
+		{method: "POST", target: "/v1.40/containers/c1/stop", cert: monitoring, denied: true},
+		{method: "GET", target: "/containers/c1/attach/ws?stream=1&stdout=1", cert: monitoring, user: "monitoring"},
+		{method: "GET", target: "/containers/c1/attach/ws?stream=1&stdin=1", cert: monitoring, denied: true},
+		{method: "POST", target: "/v1.40/containers/c1/stop", cert: ci, user: "ci"},
+		{method: "POST", target: "/v1.40/containers/c1/exec", cert: ci, denied: true},
+		{method: "POST", target: "/exec/e1/start", cert: ci, denied: true},
+		{method: "GET", target: "/exec/e1/json", cert: ci, user: "ci"},
+		{method: "POST", target: "/v1.40/containers/c1/exec", cert: admin, user: "admin"},
+		{method: "POST", target: "/v1.40/containers/c1/exec", cert: other},
+		{method: "POST", target: "/v1.40/containers/c1/exec"},
+	}
+	for _, tc := range testCases {
+		err := request(tc.method, tc.target, tc.cert)
+		if tc.denied {
+			assert.Check(t, errdefs.IsForbidden(err), "%s %s", tc.method, tc.target)
+			continue
+		}
+		assert.Check(t, err, "%s %s", tc.method, tc.target)
+		assert.Check(t, is.Equal(user, tc.user), "%s %s", tc.method, tc.target)
+	}
+
+	// the certificates matching no identity are restricted by the default
+	// policy
+	m.SetIdentities(nil, PolicyReadOnly)
+	err := request("POST", "/v1.40/containers/c1/stop", other)
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.Error(err, "POST /v1.40/containers/c1/stop is not allowed for identity bob by its read-only policy"))
+	assert.Check(t, request("POST", "/v1.40/containers/c1/stop", nil))
+}
+
+func TestMiddlewareTLSIdentity(t *testing.T) {
+	identities := NewTLSIdentityMiddleware([]TLSIdentity{{Name: "admin", Subjects: []string{"alice"}, Policy: PolicyFull}}, "")
+	m := NewMiddleware(nil, nil)
+
+	var user string
+	handler := identities.WrapHandler(m.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		user, _ = UserFromContext(ctx)
+		return nil
+	}))
+
+	r := httptest.NewRequest("GET", "/info", nil)
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "alice"}}},
+	}
+	assert.NilError(t, handler(context.Background(), httptest.NewRecorder(), r, nil))
+	assert.Check(t, is.Equal(user, "admin"))
+}
+
+func TestValidatePolicy(t *testing.T) {
+	for _, p := range []Policy{"", PolicyReadOnly, PolicyNoExec, PolicyFull} {
+		assert.Check(t, ValidatePolicy(p))
+	}
+	assert.Check(t, is.ErrorContains(ValidatePolicy("admin"), `invalid policy "admin"`))
+}
@@ -54,11 +54,6 @@ func (m *Middleware) RemovePlugin(name string) {
 // WrapHandler returns a new handler function wrapping the previous one in the request chain.
 func (m *Middleware) WrapHandler(handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error) func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-		plugins := m.getAuthzPlugins()
-		if len(plugins) == 0 {
-			return handler(ctx, w, r, vars)
-		}
-
 		user := ""
 		userAuthNMethod := ""
 
@@ -66,10 +61,22 @@ func (m *Middleware) WrapHandler(handler func(ctx context.Context, w http.Respon
 		// FIXME: Non trivial authorization mechanisms (such as advanced certificate validations, kerberos support
 		// and ldap) will be extracted using AuthN feature, which is tracked under:
 		// https://github.com/docker/docker/pull/20883
-		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		if u, method := UserFromContext(ctx); u != "" {
+			// the certificate was mapped to an identity by the
+			// TLSIdentityMiddleware
+			user, userAuthNMethod = u, method
+		} else if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
 			user = r.TLS.PeerCertificates[0].Subject.CommonName
 			userAuthNMethod = "TLS"
 		}
+		if user != "" {
+			ctx = WithUser(ctx, user, userAuthNMethod)
+		}
+
+		plugins := m.getAuthzPlugins()
+		if len(plugins) == 0 {
+			return handler(ctx, w, r, vars)
+		}
 
 		authCtx := NewCtx(plugins, user, userAuthNMethod, r.Method, r.RequestURI)
 
@@ -77,8 +84,21 @@ func (m *Middleware) WrapHandler(handler func(ctx context.Context, w http.Respon
 			logrus.Errorf("AuthZRequest for %s %s returned error: %s", r.Method, r.RequestURI, err)
 			return err
 		}
+		if authCtx.user != user {
+			ctx = WithUser(ctx, authCtx.user, authCtx.userAuthNMethod)
+		}
 
-		rw := NewResponseModifier(w)
+		// The responses are only buffered for the plugins implementing the v1 protocol
+		var rm ResponseModifier
+		var rw http.ResponseWriter = w
+		for _, plugin := range plugins {
+			if pluginVersion(plugin) < ProtocolV2 {
+				rm = NewResponseModifier(w)
+				rw = rm
+				break
+			}
+		}
+		rw = authCtx.WrapResponseWriter(rw, r)
 
 		var errD error
 
@@ -86,6 +106,11 @@ func (m *Middleware) WrapHandler(handler func(ctx context.Context, w http.Respon
 			logrus.Errorf("Handler for %s %s returned error: %s", r.Method, r.RequestURI, errD)
 		}
 
+		if err := authCtx.End(rw, errD); err != nil {
+			logrus.Errorf("AuthZResponse for %s %s returned error: %s", r.Method, r.RequestURI, err)
+			return err
+		}
+
 		// There's a chance that the authCtx.plugins was updated. One of the reasons
 		// this can happen is when an authzplugin is disabled.
 		plugins = m.getAuthzPlugins()
@@ -96,7 +121,11 @@ func (m *Middleware) WrapHandler(handler func(ctx context.Context, w http.Respon
 
 		authCtx.plugins = plugins
 
-		if err := authCtx.AuthZResponse(rw, r); errD == nil && err != nil {
+		if rm == nil {
+			return errD
+		}
+
+		if err := authCtx.AuthZResponse(rm, r); errD == nil && err != nil {
 			logrus.Errorf("AuthZResponse for %s %s returned error: %s", r.Method, r.RequestURI, err)
 			return err
 		}
@@ -1,6 +1,10 @@
 package authorization // import "github.com/docker/docker/pkg/authorization"
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -20,6 +24,24 @@ func TestMiddleware(t *testing.T) {
 	assert.Equal(t, pluginNames[1], authPlugins[1].Name())
 }
 
+func TestMiddlewareUserFromContext(t *testing.T) {
+	m := NewMiddleware(nil, nil)
+
+	var user, authNMethod string
+	handler := m.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		user, authNMethod = UserFromContext(ctx)
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/info", nil)
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "alice"}}},
+	}
+	assert.NilError(t, handler(context.Background(), httptest.NewRecorder(), r, nil))
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, "TLS", authNMethod)
+}
+
 func TestNewResponseModifier(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	modifier := NewResponseModifier(recorder)
@@ -63,4 +63,18 @@ func TestMiddlewareWrapHandler(t *testing.T) {
 
 	})
 
+	t.Run("Plugin User Test Case :", func(t *testing.T) {
+		server.replayResponse = Response{
+			Allow: true,
+			User:  "bob",
+		}
+		var user, authNMethod string
+		userHandler := middleWare.WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			user, authNMethod = UserFromContext(ctx)
+			return nil
+		})
+		assert.NilError(t, userHandler(ctx, resp, req, map[string]string{}))
+		assert.Check(t, is.Equal(user, "bob"))
+		assert.Check(t, is.Equal(authNMethod, authZPlugin.name))
+	})
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/docker/docker/pkg/plugingetter"
 	"github.com/docker/docker/pkg/plugins"
+	"github.com/sirupsen/logrus"
 )
 
 // Plugin allows third party plugins to authorize requests and responses
@@ -20,6 +21,31 @@ type Plugin interface {
 	AuthZResponse(*Request) (*Response, error)
 }
 
+// StreamingPlugin is a Plugin that can implement the v2 protocol, inspecting
+// the bodies of the requests and responses as they are streamed
+type StreamingPlugin interface {
+	Plugin
+
+	// Version returns the version of the protocol the plugin implements
+	Version() int
+
+	// AuthZRequestBody authorizes a chunk of the body of the request from
+	// the client to the daemon
+	AuthZRequestBody(*BodyChunk) (*Response, error)
+
+	// AuthZResponseBody authorizes a chunk of the body of the response from
+	// the daemon to the client
+	AuthZResponseBody(*BodyChunk) (*Response, error)
+}
+
+// pluginVersion returns the version of the protocol a plugin implements
+func pluginVersion(plugin Plugin) int {
+	if p, ok := plugin.(StreamingPlugin); ok {
+		return p.Version()
+	}
+	return ProtocolV1
+}
+
 // newPlugins constructs and initializes the authorization plugins based on plugin names
 func newPlugins(names []string) []Plugin {
 	plugins := []Plugin{}
@@ -51,6 +77,7 @@ type authorizationPlugin struct {
 	initErr error
 	plugin  *plugins.Client
 	name    string
+	version int
 	once    sync.Once
 }
 
@@ -93,6 +120,39 @@ func (a *authorizationPlugin) AuthZResponse(authReq *Request) (*Response, error)
 	return authRes, nil
 }
 
+func (a *authorizationPlugin) Version() int {
+	if err := a.initPlugin(); err != nil {
+		return ProtocolV1
+	}
+	return a.version
+}
+
+func (a *authorizationPlugin) AuthZRequestBody(chunk *BodyChunk) (*Response, error) {
+	if err := a.initPlugin(); err != nil {
+		return nil, err
+	}
+
+	authRes := &Response{}
+	if err := a.plugin.Call(AuthZApiRequestBody, chunk, authRes); err != nil {
+		return nil, err
+	}
+
+	return authRes, nil
+}
+
+func (a *authorizationPlugin) AuthZResponseBody(chunk *BodyChunk) (*Response, error) {
+	if err := a.initPlugin(); err != nil {
+		return nil, err
+	}
+
+	authRes := &Response{}
+	if err := a.plugin.Call(AuthZApiResponseBody, chunk, authRes); err != nil {
+		return nil, err
+	}
+
+	return authRes, nil
+}
+
 // initPlugin initializes the authorization plugin if needed
 func (a *authorizationPlugin) initPlugin() error {
 	// Lazy loading of plugins
@@ -113,6 +173,26 @@ func (a *authorizationPlugin) initPlugin() error {
 			}
 			a.plugin = plugin.Client()
 		}
+		a.version = a.getVersion()
 	})
 	return a.initErr
 }
+
+// getVersion returns the version of the protocol the plugin implements, from
+// its capabilities
+func (a *authorizationPlugin) getVersion() int {
+	var caps Capabilities
+	if err := a.plugin.Call(AuthZApiCapabilities, nil, &caps); err != nil {
+		// `Capabilities` is not a required endpoint.
+		// On error assume the plugin implements the v1 protocol
+		if !plugins.IsNotFound(err) {
+			logrus.WithError(err).WithField("plugin", a.name).Debug("Authorization plugin returned an error while trying to query its capabilities, using the v1 protocol")
+		}
+		return ProtocolV1
+	}
+	if caps.Version < ProtocolV1 || caps.Version > ProtocolV2 {
+		logrus.WithField("plugin", a.name).WithField("version", caps.Version).Warn("Authorization plugin returned an unsupported protocol version, using the v1 protocol")
+		return ProtocolV1
+	}
+	return caps.Version
+}
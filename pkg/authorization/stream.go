@@ -0,0 +1,322 @@
+package authorization // import "github.com/docker/docker/pkg/authorization"
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxChunkSize is the maximum size of the chunks of the bodies sent to the
+// plugins implementing the v2 protocol
+const maxChunkSize = 64 * 1024
+
+// bodyInspector sends the chunks of a streamed body to the plugins
+// inspecting it
+type bodyInspector struct {
+	ctx      *Ctx
+	plugins  []StreamingPlugin
+	response bool
+	hijacked bool
+
+	mu     sync.Mutex
+	offset int64
+	eof    bool
+}
+
+func (ctx *Ctx) newBodyInspector(plugins []StreamingPlugin, response, hijacked bool) *bodyInspector {
+	return &bodyInspector{ctx: ctx, plugins: plugins, response: response, hijacked: hijacked}
+}
+
+// inspect authorizes the data of the body with the plugins, in chunks of up
+// to maxChunkSize, and its end when eof is set. The data must not be used
+// when an error is returned, the first error denying the body being returned
+// for all its next chunks.
+func (b *bodyInspector) inspect(data []byte, eof bool) error {
+	if len(b.plugins) == 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.ctx.deniedErr(); err != nil {
+		return err
+	}
+	if b.eof {
+		return nil
+	}
+	for {
+		n := len(data)
+		if n > maxChunkSize {
+			n = maxChunkSize
+		}
+		chunk := &BodyChunk{
+			RequestID: b.ctx.authReq.RequestID,
+			Offset:    b.offset,
+			Data:      data[:n],
+			EOF:       eof && n == len(data),
+			Hijacked:  b.hijacked,
+		}
+		if err := b.inspectChunk(chunk); err != nil {
+			return b.ctx.deny(err)
+		}
+		b.offset += int64(n)
+		b.eof = chunk.EOF
+		data = data[n:]
+		if len(data) == 0 {
+			return nil
+		}
+	}
+}
+
+func (b *bodyInspector) inspectChunk(chunk *BodyChunk) error {
+	for _, plugin := range b.plugins {
+		var (
+			authRes *Response
+			err     error
+		)
+		if b.response {
+			authRes, err = plugin.AuthZResponseBody(chunk)
+		} else {
+			authRes, err = plugin.AuthZRequestBody(chunk)
+		}
+		if err != nil {
+			return fmt.Errorf("plugin %s failed with error: %s", plugin.Name(), err)
+		}
+		if err := b.ctx.decide(plugin, authRes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestBody authorizes the request body as it is read
+type requestBody struct {
+	io.ReadCloser
+	inspector *bodyInspector
+}
+
+func (r *requestBody) Read(p []byte) (int, error) {
+	if len(p) > maxChunkSize {
+		p = p[:maxChunkSize]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 || err == io.EOF {
+		if aerr := r.inspector.inspect(p[:n], err == io.EOF); aerr != nil {
+			return 0, aerr
+		}
+	}
+	return n, err
+}
+
+// WrapResponseWriter returns a writer of the response authorizing it with the
+// plugins implementing the v2 protocol when the daemon starts sending it, and
+// sending the response body and the data of the hijacked connection to the
+// plugins inspecting them. The error denying the response is returned by its
+// writes and by End, w being returned when no plugin implements the v2
+// protocol.
+func (ctx *Ctx) WrapResponseWriter(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	for _, plugin := range ctx.plugins {
+		if pluginVersion(plugin) >= ProtocolV2 {
+			return &responseInspector{
+				ResponseWriter: w,
+				ctx:            ctx,
+				upgrade:        r.Header.Get("Upgrade") != "",
+				body:           ctx.newBodyInspector(ctx.responseBodyPlugins, true, false),
+			}
+		}
+	}
+	return w
+}
+
+// End authorizes the end of the response written with the writer returned by
+// WrapResponseWriter, once the handler of the request returned handlerErr. It
+// returns the error denying the response when the response was not sent to
+// the client yet, the denials of the responses already sent being logged.
+func (ctx *Ctx) End(w http.ResponseWriter, handlerErr error) error {
+	ri, ok := w.(*responseInspector)
+	if !ok {
+		return nil
+	}
+	if !ri.wroteHeader && handlerErr == nil {
+		// the daemon sends an empty response with an OK status
+		ri.authorize(http.StatusOK)
+	}
+	if err := ctx.deniedErr(); err != nil {
+		if !ri.sent {
+			return err
+		}
+		logrus.Errorf("AuthZ denied the response to %s %s after it was sent: %s", ctx.requestMethod, ctx.requestURI, err)
+		return nil
+	}
+	if ri.sent && !ri.hijacked {
+		if err := ri.body.inspect(nil, true); err != nil {
+			logrus.Errorf("AuthZ denied the response to %s %s after it was sent: %s", ctx.requestMethod, ctx.requestURI, err)
+		}
+	}
+	return nil
+}
+
+// responseInspector is used as an adapter to http.ResponseWriter in order to
+// authorize the response when the daemon starts sending it
+type responseInspector struct {
+	http.ResponseWriter
+	ctx *Ctx
+	// upgrade indicates the request asked for the connection to be upgraded
+	upgrade bool
+	body    *bodyInspector
+	// wroteHeader indicates the response was authorized, and sent indicates
+	// it was allowed
+	wroteHeader bool
+	sent        bool
+	hijacked    bool
+}
+
+// authorize authorizes the response with the status code when it starts
+// being sent
+func (ri *responseInspector) authorize(statusCode int) error {
+	if ri.wroteHeader {
+		return ri.ctx.deniedErr()
+	}
+	ri.wroteHeader = true
+	if err := ri.ctx.authZResponseHeaders(statusCode, ri.Header()); err != nil {
+		return ri.ctx.deny(err)
+	}
+	ri.sent = true
+	return nil
+}
+
+func (ri *responseInspector) WriteHeader(s int) {
+	if ri.wroteHeader {
+		if ri.sent {
+			ri.ResponseWriter.WriteHeader(s)
+		}
+		return
+	}
+	if ri.authorize(s) == nil {
+		ri.ResponseWriter.WriteHeader(s)
+	}
+}
+
+func (ri *responseInspector) Write(b []byte) (int, error) {
+	if !ri.wroteHeader {
+		ri.WriteHeader(http.StatusOK)
+	}
+	if !ri.sent {
+		return 0, ri.ctx.deniedErr()
+	}
+	if err := ri.body.inspect(b, false); err != nil {
+		return 0, err
+	}
+	return ri.ResponseWriter.Write(b)
+}
+
+// Flush uses the internal flush API of the wrapped http.ResponseWriter
+func (ri *responseInspector) Flush() {
+	if !ri.wroteHeader {
+		ri.WriteHeader(http.StatusOK)
+	}
+	if !ri.sent {
+		return
+	}
+	if flusher, ok := ri.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify uses the internal close notify API of the wrapped http.ResponseWriter
+func (ri *responseInspector) CloseNotify() <-chan bool {
+	if closer, ok := ri.ResponseWriter.(http.CloseNotifier); ok {
+		return closer.CloseNotify()
+	}
+	logrus.Error("Internal response writer doesn't support the CloseNotifier interface")
+	return nil
+}
+
+// Hijack returns the internal connection of the wrapped http.ResponseWriter,
+// once the response is authorized, the data sent on the connection being sent
+// to the plugins inspecting the bodies
+func (ri *responseInspector) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := ri.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Internal response writer doesn't support the Hijacker interface")
+	}
+	statusCode := http.StatusOK
+	if ri.upgrade {
+		statusCode = http.StatusSwitchingProtocols
+	}
+	if !ri.wroteHeader {
+		if err := ri.authorize(statusCode); err != nil {
+			return nil, nil, err
+		}
+	} else if !ri.sent {
+		return nil, nil, ri.ctx.deniedErr()
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	ri.hijacked = true
+	if len(ri.ctx.requestBodyPlugins) == 0 && len(ri.ctx.responseBodyPlugins) == 0 {
+		return conn, rw, nil
+	}
+	c := &inspectedConn{
+		Conn: conn,
+		r:    rw.Reader,
+		in:   ri.ctx.newBodyInspector(ri.ctx.requestBodyPlugins, false, true),
+		out:  ri.ctx.newBodyInspector(ri.ctx.responseBodyPlugins, true, true),
+	}
+	return c, bufio.NewReadWriter(bufio.NewReader(c), bufio.NewWriter(c)), nil
+}
+
+// inspectedConn is a hijacked connection whose data is sent to the plugins
+// inspecting the bodies
+type inspectedConn struct {
+	net.Conn
+	// r holds the data buffered by the server before the connection was hijacked
+	r       *bufio.Reader
+	in, out *bodyInspector
+}
+
+func (c *inspectedConn) Read(p []byte) (int, error) {
+	if len(p) > maxChunkSize {
+		p = p[:maxChunkSize]
+	}
+	n, err := c.r.Read(p)
+	if n > 0 || err == io.EOF {
+		if aerr := c.in.inspect(p[:n], err == io.EOF); aerr != nil {
+			c.Conn.Close()
+			return 0, aerr
+		}
+	}
+	return n, err
+}
+
+func (c *inspectedConn) Write(p []byte) (int, error) {
+	if err := c.out.inspect(p, false); err != nil {
+		c.Conn.Close()
+		return 0, err
+	}
+	return c.Conn.Write(p)
+}
+
+// CloseWrite closes the writing side of the connection, or the connection
+// when it does not support it
+func (c *inspectedConn) CloseWrite() error {
+	c.out.inspect(nil, true)
+	if cw, ok := c.Conn.(interface {
+		CloseWrite() error
+	}); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+func (c *inspectedConn) Close() error {
+	c.out.inspect(nil, true)
+	return c.Conn.Close()
+}
@@ -0,0 +1,252 @@
+package authorization // import "github.com/docker/docker/pkg/authorization"
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// streamingTestPlugin is a plugin implementing the v2 protocol recording the
+// requests and body chunks it authorizes
+type streamingTestPlugin struct {
+	mu sync.Mutex
+	// request and response are the decisions on the requests and responses
+	request, response Decision
+	// deny returns whether a body chunk is denied
+	deny     func(chunk *BodyChunk, response bool) bool
+	requests []Request
+	// requestChunks and responseChunks store the body chunks sent to the plugin
+	requestChunks, responseChunks []BodyChunk
+}
+
+func (p *streamingTestPlugin) Name() string {
+	return "streaming"
+}
+
+func (p *streamingTestPlugin) Version() int {
+	return ProtocolV2
+}
+
+func (p *streamingTestPlugin) AuthZRequest(authReq *Request) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, *authReq)
+	d := p.request
+	return &Response{Decision: &d}, nil
+}
+
+func (p *streamingTestPlugin) AuthZResponse(authReq *Request) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, *authReq)
+	d := p.response
+	return &Response{Decision: &d}, nil
+}
+
+func (p *streamingTestPlugin) AuthZRequestBody(chunk *BodyChunk) (*Response, error) {
+	return p.authZBody(chunk, false), nil
+}
+
+func (p *streamingTestPlugin) AuthZResponseBody(chunk *BodyChunk) (*Response, error) {
+	return p.authZBody(chunk, true), nil
+}
+
+func (p *streamingTestPlugin) authZBody(chunk *BodyChunk, response bool) *Response {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c := *chunk
+	c.Data = append([]byte(nil), chunk.Data...)
+	if response {
+		p.responseChunks = append(p.responseChunks, c)
+	} else {
+		p.requestChunks = append(p.requestChunks, c)
+	}
+	allow := p.deny == nil || !p.deny(chunk, response)
+	return &Response{Decision: &Decision{Allow: allow, Reason: "body denied"}}
+}
+
+func newStreamingTestMiddleware(p Plugin) *Middleware {
+	m := NewMiddleware(nil, nil)
+	setAuthzPlugins(m, []Plugin{p})
+	return m
+}
+
+func TestStreamingPluginDecision(t *testing.T) {
+	p := &streamingTestPlugin{
+		request:  Decision{Allow: false, Reason: "not allowed"},
+		response: Decision{Allow: true},
+	}
+	handler := newStreamingTestMiddleware(p).WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	err := handler(context.Background(), httptest.NewRecorder(), httptest.NewRequest("POST", "/containers/create", nil), nil)
+	assert.Check(t, is.ErrorContains(err, "authorization denied by plugin streaming: not allowed"))
+	assert.Check(t, errdefs.IsForbidden(err))
+
+	// the requests denied in audit mode are allowed
+	p.request.Mode = DecisionModeAudit
+	resp := httptest.NewRecorder()
+	assert.NilError(t, handler(context.Background(), resp, httptest.NewRequest("POST", "/containers/create", nil), nil))
+	assert.Check(t, is.Equal(resp.Code, http.StatusNoContent))
+
+	assert.Assert(t, is.Len(p.requests, 3))
+	assert.Check(t, p.requests[1].RequestID != "")
+	// the plugins authorize the responses when they start being sent
+	assert.Check(t, is.Equal(p.requests[2].RequestID, p.requests[1].RequestID))
+	assert.Check(t, is.Equal(p.requests[2].ResponseStatusCode, http.StatusNoContent))
+}
+
+func TestStreamingPluginRequestBody(t *testing.T) {
+	p := &streamingTestPlugin{
+		request:  Decision{Allow: true, InspectRequestBody: true},
+		response: Decision{Allow: true},
+	}
+	var read string
+	handler := newStreamingTestMiddleware(p).WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		b, err := ioutil.ReadAll(r.Body)
+		read = string(b)
+		return err
+	})
+
+	body := strings.Repeat("a", maxChunkSize) + "b"
+	assert.NilError(t, handler(context.Background(), httptest.NewRecorder(), httptest.NewRequest("POST", "/build", strings.NewReader(body)), nil))
+	assert.Check(t, is.Equal(read, body))
+
+	var inspected string
+	for i, c := range p.requestChunks {
+		assert.Check(t, is.Equal(c.Offset, int64(len(inspected))))
+		assert.Check(t, is.Equal(c.EOF, i == len(p.requestChunks)-1))
+		assert.Check(t, is.Equal(c.RequestID, p.requests[0].RequestID))
+		assert.Check(t, len(c.Data) <= maxChunkSize)
+		inspected += string(c.Data)
+	}
+	assert.Check(t, is.Equal(inspected, body))
+
+	// the requests whose bodies are denied are rejected
+	p.deny = func(chunk *BodyChunk, response bool) bool {
+		return strings.Contains(string(chunk.Data), "b")
+	}
+	err := handler(context.Background(), httptest.NewRecorder(), httptest.NewRequest("POST", "/build", strings.NewReader(body)), nil)
+	assert.Check(t, is.ErrorContains(err, "authorization denied by plugin streaming: body denied"))
+	assert.Check(t, errdefs.IsForbidden(err))
+	// the denied chunks are not read
+	assert.Check(t, !strings.Contains(read, "b"))
+}
+
+func TestStreamingPluginResponse(t *testing.T) {
+	p := &streamingTestPlugin{
+		request:  Decision{Allow: true, InspectResponseBody: true},
+		response: Decision{Allow: true},
+	}
+	var writeErr error
+	handler := newStreamingTestMiddleware(p).WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		w.Header().Set("Content-Type", "application/json")
+		_, writeErr = w.Write([]byte(`{"status":"pulling"}`))
+		// the responses are not buffered
+		w.(http.Flusher).Flush()
+		if writeErr == nil {
+			_, writeErr = w.Write([]byte(`{"status":"done"}`))
+		}
+		return nil
+	})
+
+	resp := httptest.NewRecorder()
+	assert.NilError(t, handler(context.Background(), resp, httptest.NewRequest("POST", "/images/create", nil), nil))
+	assert.NilError(t, writeErr)
+	assert.Check(t, resp.Flushed)
+	assert.Check(t, is.Equal(resp.Body.String(), `{"status":"pulling"}{"status":"done"}`))
+	assert.Check(t, is.Equal(p.requests[1].ResponseStatusCode, http.StatusOK))
+	assert.Check(t, is.Equal(p.requests[1].ResponseHeaders["Content-Type"], "application/json"))
+	assert.Check(t, is.DeepEqual(p.responseChunks, []BodyChunk{
+		{RequestID: p.requests[0].RequestID, Data: []byte(`{"status":"pulling"}`)},
+		{RequestID: p.requests[0].RequestID, Offset: 20, Data: []byte(`{"status":"done"}`)},
+		{RequestID: p.requests[0].RequestID, Offset: 37, EOF: true},
+	}))
+
+	// the responses denied when they start being sent are not sent
+	p.response = Decision{Allow: false, Reason: "not allowed"}
+	resp = httptest.NewRecorder()
+	err := handler(context.Background(), resp, httptest.NewRequest("POST", "/images/create", nil), nil)
+	assert.Check(t, is.ErrorContains(err, "authorization denied by plugin streaming: not allowed"))
+	assert.Check(t, writeErr != nil)
+	assert.Check(t, is.Equal(resp.Body.Len(), 0))
+	assert.Check(t, !resp.Flushed)
+}
+
+func TestStreamingPluginHijack(t *testing.T) {
+	p := &streamingTestPlugin{
+		request:  Decision{Allow: true, InspectRequestBody: true, InspectResponseBody: true},
+		response: Decision{Allow: true},
+		deny: func(chunk *BodyChunk, response bool) bool {
+			return !response && strings.Contains(string(chunk.Data), "exit")
+		},
+	}
+	handler := newStreamingTestMiddleware(p).WrapHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n"))
+		// echo the lines sent by the client
+		br := bufio.NewReader(conn)
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				return nil
+			}
+			conn.Write([]byte(line))
+		}
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(context.Background(), w, r, nil)
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	assert.NilError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("POST /containers/c/attach HTTP/1.1\r\nHost: docker\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n"))
+	assert.NilError(t, err)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(resp.StatusCode, http.StatusSwitchingProtocols))
+
+	_, err = conn.Write([]byte("ls\n"))
+	assert.NilError(t, err)
+	line, err := br.ReadString('\n')
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(line, "ls\n"))
+
+	// the connection is closed when the data sent by the client is denied
+	_, err = conn.Write([]byte("exit\n"))
+	assert.NilError(t, err)
+	_, err = br.ReadString('\n')
+	assert.Check(t, is.Equal(err, io.EOF))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	assert.Check(t, is.Equal(p.requests[1].ResponseStatusCode, http.StatusSwitchingProtocols))
+	assert.Assert(t, is.Len(p.requestChunks, 2))
+	assert.Check(t, p.requestChunks[0].Hijacked)
+	assert.Check(t, is.Equal(string(p.requestChunks[0].Data), "ls\n"))
+	assert.Check(t, is.Equal(string(p.requestChunks[1].Data), "exit\n"))
+	assert.Check(t, is.Equal(p.requestChunks[1].Offset, int64(3)))
+	assert.Assert(t, len(p.responseChunks) >= 2)
+	assert.Check(t, p.responseChunks[1].Hijacked)
+	assert.Check(t, is.Equal(string(p.responseChunks[1].Data), "ls\n"))
+}
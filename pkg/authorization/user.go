@@ -0,0 +1,63 @@
+package authorization // import "github.com/docker/docker/pkg/authorization"
+
+import (
+	"context"
+	"sync"
+)
+
+// userKey is used as key type for the authenticated user in a context.
+type userKey struct{}
+
+// userRecordKey is used as key type for the UserRecord of a context.
+type userRecordKey struct{}
+
+type userInfo struct {
+	user        string
+	authNMethod string
+}
+
+// WithUser returns a copy of ctx carrying the user that authenticated the
+// request, and the authentication method that was used. The user is also
+// recorded by the UserRecord of ctx, if any.
+func WithUser(ctx context.Context, user, authNMethod string) context.Context {
+	if rec, ok := ctx.Value(userRecordKey{}).(*UserRecord); ok {
+		rec.mu.Lock()
+		rec.info = userInfo{user: user, authNMethod: authNMethod}
+		rec.mu.Unlock()
+	}
+	return context.WithValue(ctx, userKey{}, userInfo{user: user, authNMethod: authNMethod})
+}
+
+// UserFromContext returns the user that authenticated the request, and the
+// authentication method that was used. It returns empty strings if the
+// request was not authenticated.
+func UserFromContext(ctx context.Context) (user, authNMethod string) {
+	if info, ok := ctx.Value(userKey{}).(userInfo); ok {
+		return info.user, info.authNMethod
+	}
+	return "", ""
+}
+
+// UserRecord records the last user set for a request in the contexts derived
+// from the context it was created for, for the middlewares wrapping the
+// middlewares authenticating the requests to know their users.
+type UserRecord struct {
+	mu   sync.Mutex
+	info userInfo
+}
+
+// WithUserRecord returns a copy of ctx carrying a new UserRecord, recording
+// the users set with WithUser in the contexts derived from it.
+func WithUserRecord(ctx context.Context) (context.Context, *UserRecord) {
+	rec := &UserRecord{}
+	rec.info.user, rec.info.authNMethod = UserFromContext(ctx)
+	return context.WithValue(ctx, userRecordKey{}, rec), rec
+}
+
+// User returns the last user recorded, and the authentication method that
+// was used.
+func (r *UserRecord) User() (user, authNMethod string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.info.user, r.info.authNMethod
+}
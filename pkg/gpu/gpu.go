@@ -0,0 +1,95 @@
+// Package gpu discovers the GPUs of the host, for them to be advertised to
+// swarm as generic resources and exposed to the containers of the tasks they
+// are assigned to.
+package gpu // import "github.com/docker/docker/pkg/gpu"
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kind is the kind of the generic resources advertised for the GPUs.
+const Kind = "gpu"
+
+// ModelLabel is the engine label set to the model of the GPUs of a node,
+// when they all are of the same model.
+const ModelLabel = "com.docker.gpu.model"
+
+// Vendors of the GPUs.
+const (
+	VendorNVIDIA = "nvidia"
+	VendorAMD    = "amd"
+	VendorIntel  = "intel"
+)
+
+// Device is a GPU of the host.
+type Device struct {
+	// ID is the identifier of the GPU, its UUID for the NVIDIA GPUs and its
+	// PCI address for the others.
+	ID     string
+	Vendor string
+	Model  string
+	// Paths are the paths of the device nodes to expose to the containers
+	// using the GPU.
+	Paths []string
+}
+
+// Discover returns the GPUs of the host.
+func Discover() ([]Device, error) {
+	return discover("/")
+}
+
+// Lookup returns the devices of the GPUs with the IDs, the GPUs which are not
+// found being ignored.
+func Lookup(devices []Device, ids []string) []Device {
+	var found []Device
+	for _, id := range ids {
+		for _, d := range devices {
+			if d.ID == id {
+				found = append(found, d)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Model returns the model of the GPUs, if they all are of the same model.
+func Model(devices []Device) string {
+	var model string
+	for i, d := range devices {
+		if i > 0 && d.Model != model {
+			return ""
+		}
+		model = d.Model
+	}
+	return model
+}
+
+// Paths returns the sorted, deduplicated paths of the device nodes of the
+// GPUs.
+func Paths(devices []Device) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, d := range devices {
+		for _, p := range d.Paths {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// IDs returns the IDs of the GPUs of the vendor, comma-separated.
+func IDs(devices []Device, vendor string) string {
+	var ids []string
+	for _, d := range devices {
+		if d.Vendor == vendor {
+			ids = append(ids, d.ID)
+		}
+	}
+	return strings.Join(ids, ",")
+}
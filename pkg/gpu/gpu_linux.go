@@ -0,0 +1,135 @@
+package gpu // import "github.com/docker/docker/pkg/gpu"
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nvidiaControlDevices are the device nodes of the NVIDIA driver which the
+// containers using NVIDIA GPUs need besides the nodes of the GPUs.
+var nvidiaControlDevices = []string{"/dev/nvidiactl", "/dev/nvidia-uvm", "/dev/nvidia-uvm-tools"}
+
+// pciVendors are the vendors of the GPUs by their PCI vendor IDs.
+var pciVendors = map[string]string{
+	"0x10de": VendorNVIDIA,
+	"0x1002": VendorAMD,
+	"0x8086": VendorIntel,
+}
+
+// discover probes the GPUs of the host, the NVIDIA GPUs with the procfs
+// interface of their driver, and the others with their DRM render nodes.
+// root is the root of the host filesystem.
+func discover(root string) ([]Device, error) {
+	devices, err := discoverNVIDIA(root)
+	if err != nil {
+		return nil, err
+	}
+	drm, err := discoverDRM(root, len(devices) > 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(devices, drm...), nil
+}
+
+func discoverNVIDIA(root string) ([]Device, error) {
+	infos, err := filepath.Glob(filepath.Join(root, "proc/driver/nvidia/gpus/*/information"))
+	if err != nil {
+		return nil, err
+	}
+	var control []string
+	for _, p := range nvidiaControlDevices {
+		if _, err := os.Stat(filepath.Join(root, p)); err == nil {
+			control = append(control, p)
+		}
+	}
+	var devices []Device
+	for _, info := range infos {
+		fields, err := readNVIDIAInformation(info)
+		if err != nil {
+			return nil, err
+		}
+		id, minor := fields["GPU UUID"], fields["Device Minor"]
+		if id == "" || minor == "" {
+			return nil, errors.Errorf("error reading GPU information %s: missing UUID or minor number", info)
+		}
+		devices = append(devices, Device{
+			ID:     id,
+			Vendor: VendorNVIDIA,
+			Model:  fields["Model"],
+			Paths:  append([]string{"/dev/nvidia" + minor}, control...),
+		})
+	}
+	return devices, nil
+}
+
+// readNVIDIAInformation reads the "key: value" lines of the information file
+// of an NVIDIA GPU.
+func readNVIDIAInformation(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading GPU information")
+	}
+	fields := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		kv := strings.SplitN(s.Text(), ":", 2)
+		if len(kv) == 2 {
+			fields[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return fields, s.Err()
+}
+
+// discoverDRM probes the GPUs with DRM render nodes, skipping the NVIDIA GPUs
+// when they were found with the procfs interface of their driver.
+func discoverDRM(root string, skipNVIDIA bool) ([]Device, error) {
+	nodes, err := filepath.Glob(filepath.Join(root, "sys/class/drm/renderD*"))
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for _, node := range nodes {
+		pciDevice, err := filepath.EvalSymlinks(filepath.Join(node, "device"))
+		if err != nil {
+			continue
+		}
+		vendorID := readSysfs(pciDevice, "vendor")
+		vendor, ok := pciVendors[vendorID]
+		if !ok {
+			vendor = vendorID
+		}
+		if vendor == VendorNVIDIA && skipNVIDIA {
+			continue
+		}
+		model := readSysfs(pciDevice, "product_name")
+		if model == "" {
+			model = strings.TrimPrefix(vendorID, "0x") + ":" + strings.TrimPrefix(readSysfs(pciDevice, "device"), "0x")
+		}
+		paths := []string{"/dev/dri/" + filepath.Base(node)}
+		cards, _ := filepath.Glob(filepath.Join(pciDevice, "drm/card*"))
+		for _, card := range cards {
+			paths = append(paths, "/dev/dri/"+filepath.Base(card))
+		}
+		devices = append(devices, Device{
+			ID:     filepath.Base(pciDevice),
+			Vendor: vendor,
+			Model:  model,
+			Paths:  paths,
+		})
+	}
+	return devices, nil
+}
+
+func readSysfs(dir, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,83 @@
+package gpu // import "github.com/docker/docker/pkg/gpu"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	path = filepath.Join(root, path)
+	assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+// addDRMDevice adds a PCI device with a render node and a card to the sysfs
+// of root.
+func addDRMDevice(t *testing.T, root, address, render, card, vendor, device string) {
+	t.Helper()
+	pci := filepath.Join("sys/devices/pci0000:00", address)
+	writeFile(t, root, filepath.Join(pci, "vendor"), vendor+"\n")
+	writeFile(t, root, filepath.Join(pci, "device"), device+"\n")
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, pci, "drm", card), 0755))
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "sys/class/drm", render), 0755))
+	assert.NilError(t, os.Symlink(filepath.Join(root, pci), filepath.Join(root, "sys/class/drm", render, "device")))
+}
+
+func TestDiscover(t *testing.T) {
+	root, err := ioutil.TempDir("", "gpu")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	devices, err := discover(root)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(devices, 0))
+
+	writeFile(t, root, "proc/driver/nvidia/gpus/0000:00:1e.0/information", `Model: 		 Tesla V100-SXM2-16GB
+IRQ:   		 42
+GPU UUID: 	 GPU-5c3b3e8b-0a0e-1f2a-9d2c-7a6f0e9c1d11
+Bus Location: 	 0000:00:1e.0
+Device Minor: 	 0
+`)
+	writeFile(t, root, "dev/nvidiactl", "")
+	writeFile(t, root, "dev/nvidia-uvm", "")
+	addDRMDevice(t, root, "0000:00:1e.0", "renderD128", "card0", "0x10de", "0x1db1")
+	addDRMDevice(t, root, "0000:03:00.0", "renderD129", "card1", "0x1002", "0x73bf")
+	writeFile(t, root, "sys/devices/pci0000:00/0000:03:00.0/product_name", "Radeon RX 6800\n")
+	addDRMDevice(t, root, "0000:00:02.0", "renderD130", "card2", "0x8086", "0x9bc4")
+
+	devices, err = discover(root)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(devices, []Device{
+		{
+			ID:     "GPU-5c3b3e8b-0a0e-1f2a-9d2c-7a6f0e9c1d11",
+			Vendor: VendorNVIDIA,
+			Model:  "Tesla V100-SXM2-16GB",
+			Paths:  []string{"/dev/nvidia0", "/dev/nvidiactl", "/dev/nvidia-uvm"},
+		},
+		{
+			ID:     "0000:03:00.0",
+			Vendor: VendorAMD,
+			Model:  "Radeon RX 6800",
+			Paths:  []string{"/dev/dri/renderD129", "/dev/dri/card1"},
+		},
+		{
+			ID:     "0000:00:02.0",
+			Vendor: VendorIntel,
+			Model:  "8086:9bc4",
+			Paths:  []string{"/dev/dri/renderD130", "/dev/dri/card2"},
+		},
+	}))
+
+	assert.Check(t, is.Equal(Model(devices), ""))
+	assert.Check(t, is.Equal(Model(devices[:1]), "Tesla V100-SXM2-16GB"))
+	assigned := Lookup(devices, []string{"0000:00:02.0", "GPU-5c3b3e8b-0a0e-1f2a-9d2c-7a6f0e9c1d11", "unknown"})
+	assert.Check(t, is.Len(assigned, 2))
+	assert.Check(t, is.DeepEqual(Paths(assigned), []string{"/dev/dri/card2", "/dev/dri/renderD130", "/dev/nvidia-uvm", "/dev/nvidia0", "/dev/nvidiactl"}))
+	assert.Check(t, is.Equal(IDs(assigned, VendorNVIDIA), "GPU-5c3b3e8b-0a0e-1f2a-9d2c-7a6f0e9c1d11"))
+}
@@ -0,0 +1,94 @@
+package idtools // import "github.com/docker/docker/pkg/idtools"
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/reexec"
+	"golang.org/x/sys/unix"
+)
+
+const userNamespaceCommand = "docker-userns"
+
+func init() {
+	reexec.Register(userNamespaceCommand, holdUserNamespace)
+}
+
+// holdUserNamespace keeps the user namespace it runs in alive until its
+// standard input is closed.
+func holdUserNamespace() {
+	io.Copy(ioutil.Discard, os.Stdin)
+	os.Exit(0)
+}
+
+// UserNamespace creates a user namespace with the mappings of i, and returns
+// a file referring to it which keeps it alive until it is closed.
+func (i *IdentityMapping) UserNamespace() (*os.File, error) {
+	cmd := reexec.Command(userNamespaceCommand)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER,
+		UidMappings: sysProcIDMap(i.uids),
+		GidMappings: sysProcIDMap(i.gids),
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error creating user namespace: %v", err)
+	}
+	defer cmd.Wait()
+	defer stdin.Close()
+
+	return os.Open(filepath.Join("/proc", fmt.Sprint(cmd.Process.Pid), "ns", "user"))
+}
+
+func sysProcIDMap(idMap []IDMap) []syscall.SysProcIDMap {
+	m := make([]syscall.SysProcIDMap, 0, len(idMap))
+	for _, id := range idMap {
+		m = append(m, syscall.SysProcIDMap{ContainerID: id.ContainerID, HostID: id.HostID, Size: id.Size})
+	}
+	return m
+}
+
+// MountIDMapped mounts source, and the mounts below it, on target with the
+// ids shifted by the mappings of the user namespace userns: the files owned
+// by an id of the namespace appear as owned by the id it maps to on the host.
+// An error satisfying IsIDMappedMountNotSupported is returned when either
+// the kernel or the filesystems of source do not support idmapped mounts.
+func MountIDMapped(source, target string, userns *os.File) error {
+	return mount.CloneTree(source, target, &mount.MountAttr{AttrSet: mount.MountAttrIDMap, UsernsFd: uint64(userns.Fd())})
+}
+
+// IsIDMappedMountNotSupported returns whether err was returned by
+// MountIDMapped because idmapped mounts are not supported.
+func IsIDMappedMountNotSupported(err error) bool {
+	return mount.IsCloneTreeNotSupported(err)
+}
+
+// SupportsIDMappedMounts checks whether idmapped mounts of the filesystem of
+// path are supported.
+func SupportsIDMappedMounts(path string) bool {
+	userns, err := NewIDMappingsFromMaps([]IDMap{{ContainerID: 0, HostID: 0, Size: 1}}, []IDMap{{ContainerID: 0, HostID: 0, Size: 1}}).UserNamespace()
+	if err != nil {
+		return false
+	}
+	defer userns.Close()
+
+	target, err := ioutil.TempDir(path, "idmapped-check")
+	if err != nil {
+		return false
+	}
+	defer os.Remove(target)
+
+	if err := MountIDMapped(target, target, userns); err != nil {
+		return false
+	}
+	unix.Unmount(target, unix.MNT_DETACH)
+	return true
+}
@@ -0,0 +1,59 @@
+package idtools // import "github.com/docker/docker/pkg/idtools"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+	"golang.org/x/sys/unix"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/skip"
+)
+
+func TestMain(m *testing.M) {
+	if reexec.Init() {
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestMountIDMapped(t *testing.T) {
+	RequiresRoot(t)
+	dir, err := ioutil.TempDir("", "idmapped")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	skip.If(t, !SupportsIDMappedMounts(dir), "idmapped mounts are not supported")
+
+	source := filepath.Join(dir, "source")
+	assert.NilError(t, os.Mkdir(source, 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(source, "file"), nil, 0644))
+	assert.NilError(t, os.Chown(filepath.Join(source, "file"), 1000, 1001))
+
+	m := NewIDMappingsFromMaps([]IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}, []IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}})
+	userns, err := m.UserNamespace()
+	assert.NilError(t, err)
+	defer userns.Close()
+
+	target := filepath.Join(dir, "target")
+	assert.NilError(t, os.Mkdir(target, 0755))
+	assert.NilError(t, MountIDMapped(source, target, userns))
+	defer unix.Unmount(target, unix.MNT_DETACH)
+
+	for name, ids := range map[string][2]uint32{".": {100000, 200000}, "file": {101000, 201001}} {
+		fi, err := os.Stat(filepath.Join(target, name))
+		assert.NilError(t, err)
+		st := fi.Sys().(*syscall.Stat_t)
+		assert.Check(t, is.DeepEqual([2]uint32{st.Uid, st.Gid}, ids), name)
+	}
+
+	// the ids set through the mount are stored unshifted
+	assert.NilError(t, os.Chown(filepath.Join(target, "file"), 100010, 200010))
+	fi, err := os.Stat(filepath.Join(source, "file"))
+	assert.NilError(t, err)
+	st := fi.Sys().(*syscall.Stat_t)
+	assert.Check(t, is.DeepEqual([2]uint32{st.Uid, st.Gid}, [2]uint32{10, 10}))
+}
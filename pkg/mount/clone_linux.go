@@ -0,0 +1,79 @@
+package mount // import "github.com/docker/docker/pkg/mount"
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The system calls of the new mount API, which have the same numbers on all
+// the architectures using the generic table.
+const (
+	sysOpenTree     = 428
+	sysMoveMount    = 429
+	sysMountSetattr = 442
+
+	openTreeClone       = 0x1
+	moveMountFEmptyPath = 0x4
+	atRecursive         = 0x8000
+)
+
+const (
+	// MountAttrRdonly makes the mounts read-only.
+	MountAttrRdonly = 0x1
+	// MountAttrIDMap shifts the ids of the files of the mounts by the
+	// mappings of the user namespace of MountAttr.UsernsFd.
+	MountAttrIDMap = 0x100000
+)
+
+// MountAttr is the mount_attr structure of mount_setattr(2).
+type MountAttr struct {
+	AttrSet     uint64
+	AttrClr     uint64
+	Propagation uint64
+	UsernsFd    uint64
+}
+
+// CloneTree mounts a clone of the mount of source, and of the mounts below
+// it, on target, with the attributes of attr set on all of them. The errors
+// are *os.PathError with the failing system call as Op.
+func CloneTree(source, target string, attr *MountAttr) error {
+	path, err := unix.BytePtrFromString(source)
+	if err != nil {
+		return err
+	}
+	cwd := unix.AT_FDCWD
+	fd, _, errno := unix.Syscall(sysOpenTree, uintptr(cwd), uintptr(unsafe.Pointer(path)), openTreeClone|unix.O_CLOEXEC|atRecursive)
+	if errno != 0 {
+		return &os.PathError{Op: "open_tree", Path: source, Err: errno}
+	}
+	defer unix.Close(int(fd))
+
+	empty, _ := unix.BytePtrFromString("")
+	if _, _, errno := unix.Syscall6(sysMountSetattr, fd, uintptr(unsafe.Pointer(empty)), unix.AT_EMPTY_PATH|atRecursive, uintptr(unsafe.Pointer(attr)), unsafe.Sizeof(*attr), 0); errno != 0 {
+		return &os.PathError{Op: "mount_setattr", Path: source, Err: errno}
+	}
+
+	dest, err := unix.BytePtrFromString(target)
+	if err != nil {
+		return err
+	}
+	if _, _, errno := unix.Syscall6(sysMoveMount, fd, uintptr(unsafe.Pointer(empty)), uintptr(cwd), uintptr(unsafe.Pointer(dest)), moveMountFEmptyPath, 0); errno != 0 {
+		return &os.PathError{Op: "move_mount", Path: target, Err: errno}
+	}
+	return nil
+}
+
+// IsCloneTreeNotSupported returns whether err was returned by CloneTree
+// because either the kernel or the filesystems of the source do not support
+// the attributes.
+func IsCloneTreeNotSupported(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		switch pe.Err {
+		case unix.ENOSYS, unix.EINVAL, unix.EPERM, unix.EOPNOTSUPP:
+			return pe.Op == "open_tree" || pe.Op == "mount_setattr"
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package mount // import "github.com/docker/docker/pkg/mount"
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCloneTreeReadOnly(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("root required")
+	}
+
+	tmp, err := ioutil.TempDir("", "mount-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var (
+		sourceDir = path.Join(tmp, "source")
+		subDir    = path.Join(sourceDir, "sub")
+		targetDir = path.Join(tmp, "target")
+	)
+	if err := os.MkdirAll(subDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(targetDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := Mount("tmpfs", subDir, "tmpfs", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer Unmount(subDir)
+
+	if err := CloneTree(sourceDir, targetDir, &MountAttr{AttrSet: MountAttrRdonly}); err != nil {
+		if IsCloneTreeNotSupported(err) {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	defer RecursiveUnmount(targetDir)
+
+	for _, p := range []string{path.Join(targetDir, "file"), path.Join(targetDir, "sub", "file")} {
+		if err := createFile(p); err == nil {
+			t.Fatalf("expected %s not to be writable", p)
+		} else if pe, ok := err.(*os.PathError); !ok || pe.Err != unix.EROFS {
+			t.Fatalf("expected a read-only filesystem creating %s, got %v", p, err)
+		}
+	}
+	if err := createFile(path.Join(subDir, "file")); err != nil {
+		t.Fatalf("expected the source to stay writable: %v", err)
+	}
+}
@@ -53,6 +53,12 @@ var validFlags = map[string]bool{
 	"nr_inodes": true,
 	"nr_blocks": true,
 	"mpol":      true,
+	"huge":      true,
+}
+
+// validValuelessFlags are the tmpfs options without a value.
+var validValuelessFlags = map[string]bool{
+	"noswap": true,
 }
 
 var propagationFlags = map[string]bool{
@@ -98,7 +104,8 @@ func MergeTmpfsOptions(options []string) ([]string, error) {
 			continue
 		}
 		opt := strings.SplitN(option, "=", 2)
-		if len(opt) != 2 || !validFlags[opt[0]] {
+		valid := len(opt) == 2 && validFlags[opt[0]] || len(opt) == 1 && validValuelessFlags[opt[0]]
+		if !valid {
 			return nil, fmt.Errorf("Invalid tmpfs option %q", opt)
 		}
 		if !dataCollisions[opt[0]] {
@@ -147,8 +147,8 @@ func TestGetMounts(t *testing.T) {
 }
 
 func TestMergeTmpfsOptions(t *testing.T) {
-	options := []string{"noatime", "ro", "size=10k", "defaults", "atime", "defaults", "rw", "rprivate", "size=1024k", "slave"}
-	expected := []string{"atime", "rw", "size=1024k", "slave"}
+	options := []string{"noatime", "ro", "size=10k", "defaults", "atime", "defaults", "rw", "rprivate", "size=1024k", "slave", "noswap", "huge=advise"}
+	expected := []string{"atime", "rw", "size=1024k", "slave", "noswap", "huge=advise"}
 	merged, err := MergeTmpfsOptions(options)
 	if err != nil {
 		t.Fatal(err)
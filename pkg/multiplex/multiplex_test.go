@@ -0,0 +1,147 @@
+package multiplex // import "github.com/docker/docker/pkg/multiplex"
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func newSessions() (*Session, *Session) {
+	c1, c2 := net.Pipe()
+	return Client(c1), Server(c2)
+}
+
+func TestStreams(t *testing.T) {
+	client, server := newSessions()
+	defer client.Close()
+	defer server.Close()
+
+	// echo the streams, in parallel
+	go func() {
+		for {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.(*Stream).CloseWrite()
+			}()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			st, err := client.Open()
+			assert.Check(t, err)
+			defer st.Close()
+			// more data than the windows of the streams
+			data := bytes.Repeat([]byte{byte(i)}, 3*initialWindow+1)
+			go func() {
+				st.Write(data)
+				st.CloseWrite()
+			}()
+			b, err := ioutil.ReadAll(st)
+			assert.Check(t, err)
+			assert.Check(t, bytes.Equal(b, data), "stream %d", st.ID())
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestStreamClose(t *testing.T) {
+	client, server := newSessions()
+	defer client.Close()
+	defer server.Close()
+
+	st, err := client.Open()
+	assert.NilError(t, err)
+	_, err = st.Write([]byte("hello"))
+	assert.NilError(t, err)
+	conn, err := server.Accept()
+	assert.NilError(t, err)
+
+	// the data sent before the reset is read before the error
+	b := make([]byte, 5)
+	_, err = io.ReadFull(conn, b)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "hello"))
+	assert.NilError(t, st.Close())
+	_, err = conn.Read(b)
+	assert.Check(t, is.Equal(err, ErrStreamReset))
+	_, err = st.Write(b)
+	assert.Check(t, is.Equal(err, io.ErrClosedPipe))
+
+	client.Close()
+	_, err = client.Open()
+	assert.Check(t, is.Equal(err, ErrSessionClosed))
+	<-server.Done()
+	_, err = server.Accept()
+	assert.Check(t, is.Equal(err, ErrSessionClosed))
+}
+
+func TestStreamDeadline(t *testing.T) {
+	client, server := newSessions()
+	defer client.Close()
+	defer server.Close()
+
+	st, err := client.Open()
+	assert.NilError(t, err)
+	st.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err = st.Read(make([]byte, 1))
+	netErr, ok := err.(net.Error)
+	assert.Assert(t, ok, "expected a net.Error, got %v", err)
+	assert.Check(t, netErr.Timeout())
+
+	// the reads waiting for data are interrupted by the new deadlines
+	st.SetReadDeadline(time.Time{})
+	errC := make(chan error)
+	go func() {
+		_, err := st.Read(make([]byte, 1))
+		errC <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	st.SetReadDeadline(time.Now())
+	select {
+	case err := <-errC:
+		assert.Check(t, err.(net.Error).Timeout())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the read was not interrupted")
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	client, server := newSessions()
+	defer client.Close()
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})}
+	go srv.Serve(server)
+	defer srv.Close()
+
+	c := &http.Client{Transport: &http.Transport{
+		Dial: func(network, addr string) (net.Conn, error) {
+			return client.Open()
+		},
+	}}
+	for _, p := range []string{"/a", "/b", "/c"} {
+		resp, err := c.Get("http://multiplex" + p)
+		assert.NilError(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(b), p))
+	}
+}
@@ -0,0 +1,279 @@
+// Package multiplex multiplexes streams over a connection, such as the
+// connection to the daemon of `docker system dial-stdio` over SSH, for the
+// clients to run parallel requests without a connection each.
+//
+// The frames of the streams start with a 12 bytes header: the version of the
+// protocol (0), the type of the frame, its flags (2 bytes), the ID of its
+// stream (4 bytes) and the length of its payload (4 bytes), the integers
+// being big-endian. The data frames carry the data of the streams, and the
+// window update frames the number of bytes the receivers of the streams can
+// receive in addition to their windows, their length being the number of
+// bytes rather than the length of their payload, which is empty.
+package multiplex // import "github.com/docker/docker/pkg/multiplex"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	protoVersion = 0
+	headerSize   = 12
+
+	typeData         = 0
+	typeWindowUpdate = 1
+
+	// flagSYN opens a stream, flagFIN closes the stream of the sender, and
+	// flagRST aborts a stream.
+	flagSYN = 1 << 0
+	flagFIN = 1 << 1
+	flagRST = 1 << 2
+
+	// initialWindow is the number of bytes the senders of the streams can
+	// send before the receivers update their windows.
+	initialWindow = 256 * 1024
+	// maxFrame is the maximum length of the payload of a data frame.
+	maxFrame = 32 * 1024
+	// acceptBacklog is the number of streams opened by the peer which are
+	// not accepted yet above which the new streams are reset.
+	acceptBacklog = 128
+)
+
+var (
+	// ErrSessionClosed is returned by the operations of a closed session,
+	// and of its streams.
+	ErrSessionClosed = errors.New("multiplex: session closed")
+	// ErrStreamReset is returned by the operations of a stream reset by the
+	// peer, or closed before the peer closed it.
+	ErrStreamReset = errors.New("multiplex: stream reset")
+)
+
+// Session is a multiplexed session over a connection, the streams of the
+// session being opened by either side of the connection. It implements
+// net.Listener, accepting the streams opened by the peer.
+type Session struct {
+	conn io.ReadWriteCloser
+
+	mu      sync.Mutex
+	nextID  uint32
+	streams map[uint32]*Stream
+
+	accept chan *Stream
+
+	writeMu sync.Mutex
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	err       error
+}
+
+// Client returns the session of the client side of conn.
+func Client(conn io.ReadWriteCloser) *Session {
+	return newSession(conn, 1)
+}
+
+// Server returns the session of the server side of conn.
+func Server(conn io.ReadWriteCloser) *Session {
+	return newSession(conn, 2)
+}
+
+// newSession returns a session whose streams have odd IDs for the client,
+// and even IDs for the server.
+func newSession(conn io.ReadWriteCloser, firstID uint32) *Session {
+	s := &Session{
+		conn:    conn,
+		nextID:  firstID,
+		streams: make(map[uint32]*Stream),
+		accept:  make(chan *Stream, acceptBacklog),
+		closed:  make(chan struct{}),
+	}
+	go s.readLoop()
+	return s
+}
+
+// Open opens a new stream.
+func (s *Session) Open() (*Stream, error) {
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+		s.mu.Unlock()
+		return nil, ErrSessionClosed
+	default:
+	}
+	id := s.nextID
+	s.nextID += 2
+	st := newStream(s, id)
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	if err := s.writeFrame(typeWindowUpdate, flagSYN, id, 0, nil); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Accept waits for the peer to open a stream, and returns it.
+func (s *Session) Accept() (net.Conn, error) {
+	select {
+	case st := <-s.accept:
+		return st, nil
+	case <-s.closed:
+		return nil, ErrSessionClosed
+	}
+}
+
+// Addr returns the local address of the connection of the session.
+func (s *Session) Addr() net.Addr {
+	if c, ok := s.conn.(net.Conn); ok {
+		return c.LocalAddr()
+	}
+	return addr{}
+}
+
+func (s *Session) remoteAddr() net.Addr {
+	if c, ok := s.conn.(net.Conn); ok {
+		return c.RemoteAddr()
+	}
+	return addr{}
+}
+
+// Close closes the session and its connection, the streams of the session
+// being closed.
+func (s *Session) Close() error {
+	s.close(ErrSessionClosed)
+	return nil
+}
+
+// Done returns a channel which is closed when the session is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.closed
+}
+
+func (s *Session) close(err error) {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		close(s.closed)
+		s.mu.Unlock()
+		s.conn.Close()
+	})
+}
+
+func (s *Session) writeFrame(typ byte, flags uint16, id, length uint32, payload []byte) error {
+	frame := make([]byte, headerSize+len(payload))
+	frame[0] = protoVersion
+	frame[1] = typ
+	binary.BigEndian.PutUint16(frame[2:], flags)
+	binary.BigEndian.PutUint32(frame[4:], id)
+	binary.BigEndian.PutUint32(frame[8:], length)
+	copy(frame[headerSize:], payload)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	select {
+	case <-s.closed:
+		return ErrSessionClosed
+	default:
+	}
+	if _, err := s.conn.Write(frame); err != nil {
+		s.close(err)
+		return err
+	}
+	return nil
+}
+
+func (s *Session) readLoop() {
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(s.conn, header); err != nil {
+			s.close(err)
+			return
+		}
+		if header[0] != protoVersion {
+			s.close(fmt.Errorf("multiplex: unsupported protocol version %d", header[0]))
+			return
+		}
+		typ := header[1]
+		flags := binary.BigEndian.Uint16(header[2:])
+		id := binary.BigEndian.Uint32(header[4:])
+		length := binary.BigEndian.Uint32(header[8:])
+
+		var payload []byte
+		switch typ {
+		case typeData:
+			if length > maxFrame {
+				s.close(fmt.Errorf("multiplex: frame of %d bytes exceeds the maximum frame size", length))
+				return
+			}
+			payload = make([]byte, length)
+			if _, err := io.ReadFull(s.conn, payload); err != nil {
+				s.close(err)
+				return
+			}
+		case typeWindowUpdate:
+		default:
+			s.close(fmt.Errorf("multiplex: invalid frame type %d", typ))
+			return
+		}
+		if err := s.handleFrame(typ, flags, id, length, payload); err != nil {
+			s.close(err)
+			return
+		}
+	}
+}
+
+func (s *Session) handleFrame(typ byte, flags uint16, id, length uint32, payload []byte) error {
+	s.mu.Lock()
+	st, ok := s.streams[id]
+	if !ok && flags&flagSYN != 0 {
+		st = newStream(s, id)
+		s.streams[id] = st
+		select {
+		case s.accept <- st:
+		default:
+			delete(s.streams, id)
+			s.mu.Unlock()
+			// too many streams are not accepted yet
+			return s.writeFrame(typeWindowUpdate, flagRST, id, 0, nil)
+		}
+	}
+	s.mu.Unlock()
+	if st == nil {
+		// the frames of the streams which were closed are discarded
+		return nil
+	}
+
+	if flags&flagRST != 0 {
+		st.resetRemote()
+		return nil
+	}
+	switch typ {
+	case typeData:
+		if err := st.receive(payload); err != nil {
+			return err
+		}
+	case typeWindowUpdate:
+		st.updateWindow(length)
+	}
+	if flags&flagFIN != 0 {
+		st.remoteClose()
+	}
+	return nil
+}
+
+// remove removes a stream from the session, once it is closed by both sides
+// or reset.
+func (s *Session) remove(id uint32) {
+	s.mu.Lock()
+	delete(s.streams, id)
+	s.mu.Unlock()
+}
+
+type addr struct{}
+
+func (addr) Network() string { return "multiplex" }
+func (addr) String() string  { return "multiplex" }
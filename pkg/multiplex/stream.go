@@ -0,0 +1,315 @@
+package multiplex // import "github.com/docker/docker/pkg/multiplex"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Stream is a stream of a session. It implements net.Conn, and CloseWrite to
+// close the stream for writing only.
+type Stream struct {
+	session *Session
+	id      uint32
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+	// consumed is the number of bytes read since the last update of the
+	// window of the peer
+	consumed   uint32
+	sendWindow uint32
+
+	localClosed  bool // a FIN was sent
+	remoteClosed bool // a FIN was received
+	reset        bool
+	closed       bool
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	// readable and writable are notified when the stream may be read from,
+	// or written to, or when its deadlines change
+	readable chan struct{}
+	writable chan struct{}
+}
+
+func newStream(s *Session, id uint32) *Stream {
+	return &Stream{
+		session:    s,
+		id:         id,
+		sendWindow: initialWindow,
+		readable:   make(chan struct{}, 1),
+		writable:   make(chan struct{}, 1),
+	}
+}
+
+// ID returns the ID of the stream in its session.
+func (st *Stream) ID() uint32 {
+	return st.id
+}
+
+func notify(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// wait waits for c to be notified, the session to be closed, or the deadline
+// to expire if it is not zero.
+func (st *Stream) wait(c chan struct{}, deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-c:
+	case <-st.session.closed:
+	case <-timeout:
+		return timeoutError{}
+	}
+	return nil
+}
+
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// Read reads data from the stream, returning io.EOF once the peer closed it
+// and its data was read.
+func (st *Stream) Read(p []byte) (int, error) {
+	for {
+		st.mu.Lock()
+		switch {
+		case st.closed:
+			st.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		case expired(st.readDeadline):
+			st.mu.Unlock()
+			return 0, timeoutError{}
+		case st.buf.Len() > 0:
+			n, _ := st.buf.Read(p)
+			st.consumed += uint32(n)
+			var update uint32
+			if st.consumed >= initialWindow/2 && !st.remoteClosed && !st.reset {
+				update, st.consumed = st.consumed, 0
+			}
+			st.mu.Unlock()
+			if update > 0 {
+				st.session.writeFrame(typeWindowUpdate, 0, st.id, update, nil)
+			}
+			return n, nil
+		case st.reset:
+			st.mu.Unlock()
+			return 0, ErrStreamReset
+		case st.remoteClosed:
+			st.mu.Unlock()
+			return 0, io.EOF
+		}
+		deadline := st.readDeadline
+		st.mu.Unlock()
+
+		select {
+		case <-st.session.closed:
+			return 0, ErrSessionClosed
+		default:
+		}
+		if err := st.wait(st.readable, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write writes data to the stream, waiting for the peer to update the window
+// of the stream when it is full.
+func (st *Stream) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		st.mu.Lock()
+		switch {
+		case st.closed, st.localClosed:
+			st.mu.Unlock()
+			return written, io.ErrClosedPipe
+		case st.reset:
+			st.mu.Unlock()
+			return written, ErrStreamReset
+		case expired(st.writeDeadline):
+			st.mu.Unlock()
+			return written, timeoutError{}
+		case st.sendWindow == 0:
+			deadline := st.writeDeadline
+			st.mu.Unlock()
+			select {
+			case <-st.session.closed:
+				return written, ErrSessionClosed
+			default:
+			}
+			if err := st.wait(st.writable, deadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+		n := uint32(len(p))
+		if n > st.sendWindow {
+			n = st.sendWindow
+		}
+		if n > maxFrame {
+			n = maxFrame
+		}
+		st.sendWindow -= n
+		st.mu.Unlock()
+
+		if err := st.session.writeFrame(typeData, 0, st.id, n, p[:n]); err != nil {
+			return written, err
+		}
+		written += int(n)
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// CloseWrite closes the stream for writing, the peer reading io.EOF once it
+// read the data of the stream.
+func (st *Stream) CloseWrite() error {
+	st.mu.Lock()
+	if st.closed || st.localClosed || st.reset {
+		st.mu.Unlock()
+		return nil
+	}
+	st.localClosed = true
+	done := st.remoteClosed
+	st.mu.Unlock()
+
+	if done {
+		st.session.remove(st.id)
+	}
+	return st.session.writeFrame(typeWindowUpdate, flagFIN, st.id, 0, nil)
+}
+
+// Close closes the stream. The stream is reset when the peer did not close
+// it yet, as the data the peer would send could not be read.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return nil
+	}
+	st.closed = true
+	var flags uint16
+	switch {
+	case st.reset:
+	case !st.remoteClosed:
+		flags = flagRST
+	case !st.localClosed:
+		flags = flagFIN
+	}
+	st.mu.Unlock()
+	notify(st.readable)
+	notify(st.writable)
+
+	st.session.remove(st.id)
+	if flags == 0 {
+		return nil
+	}
+	err := st.session.writeFrame(typeWindowUpdate, flags, st.id, 0, nil)
+	if err == ErrSessionClosed {
+		return nil
+	}
+	return err
+}
+
+func (st *Stream) receive(data []byte) error {
+	st.mu.Lock()
+	if st.closed || st.reset {
+		st.mu.Unlock()
+		return nil
+	}
+	if uint32(st.buf.Len())+st.consumed+uint32(len(data)) > initialWindow {
+		st.mu.Unlock()
+		return errors.New("multiplex: the peer exceeded the window of a stream")
+	}
+	st.buf.Write(data)
+	st.mu.Unlock()
+	notify(st.readable)
+	return nil
+}
+
+func (st *Stream) updateWindow(n uint32) {
+	if n == 0 {
+		return
+	}
+	st.mu.Lock()
+	st.sendWindow += n
+	st.mu.Unlock()
+	notify(st.writable)
+}
+
+func (st *Stream) remoteClose() {
+	st.mu.Lock()
+	st.remoteClosed = true
+	done := st.localClosed
+	st.mu.Unlock()
+	notify(st.readable)
+	if done {
+		st.session.remove(st.id)
+	}
+}
+
+func (st *Stream) resetRemote() {
+	st.mu.Lock()
+	st.reset = true
+	st.mu.Unlock()
+	notify(st.readable)
+	notify(st.writable)
+	st.session.remove(st.id)
+}
+
+// LocalAddr returns the local address of the connection of the session.
+func (st *Stream) LocalAddr() net.Addr {
+	return st.session.Addr()
+}
+
+// RemoteAddr returns the remote address of the connection of the session.
+func (st *Stream) RemoteAddr() net.Addr {
+	return st.session.remoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the stream.
+func (st *Stream) SetDeadline(t time.Time) error {
+	st.SetReadDeadline(t)
+	return st.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline of the reads of the stream, the reads
+// waiting for data returning a timeout error once it expires.
+func (st *Stream) SetReadDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.mu.Unlock()
+	notify(st.readable)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of the writes of the stream, the writes
+// waiting for the window of the stream returning a timeout error once it
+// expires.
+func (st *Stream) SetWriteDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.writeDeadline = t
+	st.mu.Unlock()
+	notify(st.writable)
+	return nil
+}
+
+// timeoutError is the error of the operations of the streams whose deadlines
+// expired. It implements net.Error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "multiplex: i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,316 @@
+package tracing // import "github.com/docker/docker/pkg/tracing"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// maxBatchSize is the maximum number of spans sent in a request.
+	maxBatchSize = 512
+	// maxQueueSize is the maximum number of spans waiting to be sent, the
+	// spans being dropped once it is reached.
+	maxQueueSize = 4 * maxBatchSize
+	// exportInterval is the time between two exports of the spans waiting
+	// to be sent.
+	exportInterval = 5 * time.Second
+	// exportTimeout is the timeout of the requests to the collector.
+	exportTimeout = 10 * time.Second
+)
+
+// EndpointURL returns the URL the spans are sent to for the endpoint of an
+// OTLP/HTTP collector, such as http://localhost:4318. The spans are sent to
+// the /v1/traces path of the endpoint when it has no path.
+func EndpointURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.Errorf("invalid OTLP endpoint %q: %v", endpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.Errorf("invalid OTLP endpoint %q: must be an http or https URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return u.String(), nil
+}
+
+// Exporter exports the spans to an OpenTelemetry collector with the OTLP/HTTP
+// protocol, in its JSON encoding. The spans are sent in batches, every few
+// seconds or once a batch is full.
+type Exporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+
+	mu      sync.Mutex
+	spans   []*trace.SpanData
+	dropped int
+
+	fullc chan struct{}
+	stopc chan struct{}
+	done  chan struct{}
+}
+
+// NewExporter returns an exporter sending the spans to the OTLP/HTTP
+// endpoint, the spans being reported as the spans of serviceName.
+func NewExporter(endpoint, serviceName string) (*Exporter, error) {
+	u, err := EndpointURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	e := &Exporter{
+		url:         u,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: exportTimeout},
+		fullc:       make(chan struct{}, 1),
+		stopc:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// ExportSpan queues the span to be sent to the collector. It implements
+// trace.Exporter.
+func (e *Exporter) ExportSpan(s *trace.SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.spans) >= maxQueueSize {
+		e.dropped++
+		return
+	}
+	e.spans = append(e.spans, s)
+	if len(e.spans)%maxBatchSize == 0 {
+		select {
+		case e.fullc <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close sends the spans waiting to be sent, and stops the exporter.
+func (e *Exporter) Close() {
+	close(e.stopc)
+	<-e.done
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.fullc:
+		case <-e.stopc:
+			e.flush()
+			return
+		}
+		e.flush()
+	}
+}
+
+// flush sends the spans waiting to be sent.
+func (e *Exporter) flush() {
+	e.mu.Lock()
+	spans, dropped := e.spans, e.dropped
+	e.spans, e.dropped = nil, 0
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		logrus.WithField("spans", dropped).Warn("dropped trace spans: too many spans waiting to be sent to the OTLP endpoint")
+	}
+	for len(spans) > 0 {
+		n := len(spans)
+		if n > maxBatchSize {
+			n = maxBatchSize
+		}
+		if err := e.send(spans[:n]); err != nil {
+			logrus.WithError(err).WithField("spans", n).Warn("failed to send trace spans")
+		}
+		spans = spans[n:]
+	}
+}
+
+func (e *Exporter) send(spans []*trace.SpanData) error {
+	b, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error sending trace spans")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("error sending trace spans: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// The types below are the JSON encoding of the
+// ExportTraceServiceRequest messages of the OTLP protocol.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+// The kinds and status codes of the spans.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+
+	otlpStatusCodeError = 2
+)
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+	// IntValue is a string, as the int64 values of the JSON encoding
+	// of protobuf.
+	IntValue *string `json:"intValue,omitempty"`
+}
+
+func (e *Exporter) request(spans []*trace.SpanData) otlpRequest {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "github.com/docker/docker"}}
+	for _, s := range spans {
+		scope.Spans = append(scope.Spans, otlpSpanFromData(s))
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: otlpAttributes(map[string]interface{}{"service.name": e.serviceName}),
+			},
+			ScopeSpans: []otlpScopeSpans{scope},
+		}},
+	}
+}
+
+func otlpSpanFromData(s *trace.SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.TraceID.String(),
+		SpanID:            s.SpanID.String(),
+		Name:              s.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+		Attributes:        otlpAttributes(s.Attributes),
+	}
+	if s.ParentSpanID != (trace.SpanID{}) {
+		span.ParentSpanID = s.ParentSpanID.String()
+	}
+	switch s.SpanKind {
+	case trace.SpanKindServer:
+		span.Kind = otlpSpanKindServer
+	case trace.SpanKindClient:
+		span.Kind = otlpSpanKindClient
+	}
+	for _, a := range s.Annotations {
+		span.Events = append(span.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(a.Time.UnixNano(), 10),
+			Name:         a.Message,
+			Attributes:   otlpAttributes(a.Attributes),
+		})
+	}
+	if s.Code != trace.StatusCodeOK {
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.Message}
+	}
+	return span
+}
+
+// otlpAttributes converts the attributes of the spans, whose values are
+// strings, bools or int64s, sorted by key.
+func otlpAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var kvs []otlpKeyValue
+	for _, k := range keys {
+		kv := otlpKeyValue{Key: k}
+		switch v := attrs[k].(type) {
+		case string:
+			kv.Value.StringValue = &v
+		case bool:
+			kv.Value.BoolValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			kv.Value.IntValue = &s
+		default:
+			s := fmt.Sprint(v)
+			kv.Value.StringValue = &s
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs
+}
@@ -0,0 +1,98 @@
+package tracing // import "github.com/docker/docker/pkg/tracing"
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+func TestEndpointURL(t *testing.T) {
+	for endpoint, expected := range map[string]string{
+		"http://localhost:4318":             "http://localhost:4318/v1/traces",
+		"https://collector/":                "https://collector/v1/traces",
+		"http://collector:4318/custom/path": "http://collector:4318/custom/path",
+	} {
+		u, err := EndpointURL(endpoint)
+		if err != nil {
+			t.Fatalf("%s: %v", endpoint, err)
+		}
+		if u != expected {
+			t.Fatalf("%s: expected %s, got %s", endpoint, expected, u)
+		}
+	}
+	for _, endpoint := range []string{"", "localhost:4318", "grpc://collector:4317", "http://"} {
+		if _, err := EndpointURL(endpoint); err == nil {
+			t.Errorf("expected %q to be rejected", endpoint)
+		}
+	}
+}
+
+func TestExporter(t *testing.T) {
+	requests := make(chan otlpRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests <- req
+	}))
+	defer srv.Close()
+
+	e, err := NewExporter(srv.URL, "dockerd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(0, 1000)
+	e.ExportSpan(&trace.SpanData{
+		SpanContext: trace.SpanContext{
+			TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		ParentSpanID: trace.SpanID{8, 7, 6, 5, 4, 3, 2, 1},
+		SpanKind:     trace.SpanKindServer,
+		Name:         "POST /containers/create",
+		StartTime:    start,
+		EndTime:      start.Add(time.Second),
+		Attributes:   map[string]interface{}{"http.status_code": int64(500), "http.method": "POST"},
+		Status:       trace.Status{Code: trace.StatusCodeUnknown, Message: "failed"},
+	})
+	// the spans waiting to be sent are sent when the exporter is closed
+	e.Close()
+
+	var req otlpRequest
+	select {
+	case req = <-requests:
+	default:
+		t.Fatal("expected the spans to be sent")
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if attrs := req.ResourceSpans[0].Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" || *attrs[0].Value.StringValue != "dockerd" {
+		t.Fatalf("unexpected resource attributes: %+v", attrs)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.TraceID != "0102030405060708090a0b0c0d0e0f10" || s.SpanID != "0102030405060708" || s.ParentSpanID != "0807060504030201" {
+		t.Fatalf("unexpected IDs: %+v", s)
+	}
+	if s.Kind != otlpSpanKindServer || s.StartTimeUnixNano != "1000" || s.EndTimeUnixNano != "1000001000" {
+		t.Fatalf("unexpected span: %+v", s)
+	}
+	if s.Status.Code != otlpStatusCodeError || s.Status.Message != "failed" {
+		t.Fatalf("unexpected status: %+v", s.Status)
+	}
+	if len(s.Attributes) != 2 || s.Attributes[0].Key != "http.method" || *s.Attributes[1].Value.IntValue != "500" {
+		t.Fatalf("unexpected attributes: %+v", s.Attributes)
+	}
+}
@@ -0,0 +1,76 @@
+package tracing // import "github.com/docker/docker/pkg/tracing"
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+	"go.opencensus.io/trace/propagation"
+)
+
+// traceparentHeader is the header of the W3C Trace Context propagating the
+// trace context of the requests.
+const traceparentHeader = "traceparent"
+
+// HTTPFormat propagates the span contexts in the traceparent header of the
+// W3C Trace Context, which the OpenTelemetry SDKs use by default.
+type HTTPFormat struct{}
+
+var _ propagation.HTTPFormat = (*HTTPFormat)(nil)
+
+// SpanContextFromRequest extracts the span context of the traceparent header
+// of the request.
+func (f *HTTPFormat) SpanContextFromRequest(req *http.Request) (trace.SpanContext, bool) {
+	return ParseTraceparent(req.Header.Get(traceparentHeader))
+}
+
+// SpanContextToRequest sets the traceparent header of the request to the
+// span context.
+func (f *HTTPFormat) SpanContextToRequest(sc trace.SpanContext, req *http.Request) {
+	req.Header.Set(traceparentHeader, FormatTraceparent(sc))
+}
+
+// ParseTraceparent parses the value of a traceparent header, of the form
+// version-traceid-spanid-flags. The values of the versions after 00 are
+// parsed as version 00, ignoring the fields they add.
+func ParseTraceparent(h string) (sc trace.SpanContext, ok bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 {
+		return trace.SpanContext{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return trace.SpanContext{}, false
+	}
+	if _, err := hex.DecodeString(version); err != nil {
+		return trace.SpanContext{}, false
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return trace.SpanContext{}, false
+	}
+	// the IDs are lowercase, and not all zeros
+	if strings.ToLower(traceID) != traceID || strings.ToLower(spanID) != spanID {
+		return trace.SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(traceID)); err != nil || sc.TraceID == (trace.TraceID{}) {
+		return trace.SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(spanID)); err != nil || sc.SpanID == (trace.SpanID{}) {
+		return trace.SpanContext{}, false
+	}
+	var opts [1]byte
+	if _, err := hex.Decode(opts[:], []byte(flags)); err != nil {
+		return trace.SpanContext{}, false
+	}
+	// only the sampled flag is defined
+	sc.TraceOptions = trace.TraceOptions(opts[0] & 1)
+	return sc, true
+}
+
+// FormatTraceparent returns the value of the traceparent header of the span
+// context.
+func FormatTraceparent(sc trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, uint32(sc.TraceOptions)&1)
+}
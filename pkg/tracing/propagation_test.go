@@ -0,0 +1,66 @@
+package tracing // import "github.com/docker/docker/pkg/tracing"
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok {
+		t.Fatal("expected the traceparent to be parsed")
+	}
+	if got := sc.TraceID.String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected trace ID: %s", got)
+	}
+	if got := sc.SpanID.String(); got != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected span ID: %s", got)
+	}
+	if !sc.IsSampled() {
+		t.Fatal("expected the span context to be sampled")
+	}
+
+	// the fields of the later versions are ignored
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); !ok {
+		t.Fatal("expected the traceparent of a later version to be parsed")
+	}
+
+	for _, h := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+	} {
+		if _, ok := ParseTraceparent(h); ok {
+			t.Errorf("expected %q to be rejected", h)
+		}
+	}
+}
+
+func TestHTTPFormat(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+	req, err := http.NewRequest("GET", "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &HTTPFormat{}
+	f.SpanContextToRequest(sc, req)
+	if got := req.Header.Get("traceparent"); got != "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01" {
+		t.Fatalf("unexpected traceparent: %s", got)
+	}
+	got, ok := f.SpanContextFromRequest(req)
+	if !ok || got != sc {
+		t.Fatalf("expected %v, got %v", sc, got)
+	}
+}
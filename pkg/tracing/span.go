@@ -0,0 +1,16 @@
+// Package tracing traces the operations of the daemon with OpenCensus, and
+// exports the spans to the OpenTelemetry collectors with the OTLP protocol.
+package tracing // import "github.com/docker/docker/pkg/tracing"
+
+import (
+	"go.opencensus.io/trace"
+)
+
+// EndSpan ends the span of an operation, marking it as failed if the
+// operation returned an error.
+func EndSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	span.End()
+}
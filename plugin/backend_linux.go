@@ -13,6 +13,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
@@ -28,6 +30,7 @@ import (
 	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/plugins"
 	"github.com/docker/docker/pkg/pools"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/system"
@@ -365,6 +368,19 @@ func (pm *Manager) Pull(ctx context.Context, ref reference.Named, name string, m
 
 // List displays the list of plugins and associated metadata.
 func (pm *Manager) List(pluginFilters filters.Args) ([]types.Plugin, error) {
+	plugins, err := pm.filter(pluginFilters)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types.Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		out = append(out, p.PluginObj)
+	}
+	return out, nil
+}
+
+// filter returns the plugins matching the filters of the plugin list.
+func (pm *Manager) filter(pluginFilters filters.Args) ([]*v2.Plugin, error) {
 	if err := pluginFilters.Validate(acceptedPluginFilterTags); err != nil {
 		return nil, err
 	}
@@ -382,7 +398,7 @@ func (pm *Manager) List(pluginFilters filters.Args) ([]types.Plugin, error) {
 	}
 
 	plugins := pm.config.Store.GetAll()
-	out := make([]types.Plugin, 0, len(plugins))
+	out := make([]*v2.Plugin, 0, len(plugins))
 
 next:
 	for _, p := range plugins {
@@ -399,11 +415,122 @@ next:
 				}
 			}
 		}
-		out = append(out, p.PluginObj)
+		out = append(out, p)
 	}
 	return out, nil
 }
 
+// pluginCapabilitiesTimeout is the timeout of the requests of the
+// capabilities of the plugins.
+const pluginCapabilitiesTimeout = 5 * time.Second
+
+// Capabilities returns the capabilities of the volume and network plugins
+// matching the filters of the plugin list. The capabilities of the enabled
+// plugins are requested from them, concurrently, which tells whether they
+// are healthy.
+func (pm *Manager) Capabilities(pluginFilters filters.Args) ([]types.PluginCapabilities, error) {
+	ps, err := pm.filter(pluginFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out     = make([]types.PluginCapabilities, 0, len(ps))
+		clients = make([]*plugins.Client, 0, len(ps))
+		wg      sync.WaitGroup
+	)
+	for _, p := range ps {
+		var isVolume, isNetwork bool
+		var ifaces []string
+		for _, t := range p.GetTypes() {
+			switch t.Capability {
+			case "volumedriver":
+				isVolume = true
+			case "networkdriver":
+				isNetwork = true
+			}
+			ifaces = append(ifaces, t.String())
+		}
+		if !isVolume && !isNetwork {
+			continue
+		}
+		c := types.PluginCapabilities{
+			ID:      p.GetID(),
+			Name:    p.Name(),
+			Types:   ifaces,
+			Enabled: p.IsEnabled(),
+			Options: p.PluginObj.Config.Options,
+		}
+		if isVolume {
+			c.Volume = &types.PluginVolumeCapabilities{}
+		}
+		if isNetwork {
+			c.Network = &types.PluginNetworkCapabilities{}
+		}
+		out = append(out, c)
+		clients = append(clients, p.Client())
+	}
+
+	for i := range out {
+		if !out[i].Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func(c *types.PluginCapabilities, client *plugins.Client) {
+			defer wg.Done()
+			if err := requestCapabilities(client, c); err != nil {
+				c.Error = err.Error()
+				return
+			}
+			c.Healthy = true
+		}(&out[i], clients[i])
+	}
+	wg.Wait()
+	return out, nil
+}
+
+// requestCapabilities requests the capabilities of the volume and network
+// interfaces of c from the plugin. Requesting the capabilities of a volume
+// driver is optional, its volumes being local by default.
+func requestCapabilities(client *plugins.Client, c *types.PluginCapabilities) error {
+	if client == nil {
+		return errors.New("the plugin does not use the HTTP protocol")
+	}
+	if c.Volume != nil {
+		var ret struct {
+			Capabilities struct{ Scope string }
+			Err          string
+		}
+		err := client.CallWithOptions("VolumeDriver.Capabilities", nil, &ret, plugins.WithRequestTimeout(pluginCapabilitiesTimeout))
+		if err != nil && !plugins.IsNotFound(err) {
+			return err
+		}
+		if ret.Err != "" {
+			return errors.New(ret.Err)
+		}
+		c.Volume.Scope = strings.ToLower(ret.Capabilities.Scope)
+		if c.Volume.Scope == "" {
+			c.Volume.Scope = "local"
+		}
+	}
+	if c.Network != nil {
+		var ret struct {
+			Scope             string
+			ConnectivityScope string
+			Err               string
+		}
+		if err := client.CallWithOptions("NetworkDriver.GetCapabilities", nil, &ret, plugins.WithRequestTimeout(pluginCapabilitiesTimeout)); err != nil {
+			return err
+		}
+		if ret.Err != "" {
+			return errors.New(ret.Err)
+		}
+		c.Network.Scope = ret.Scope
+		c.Network.ConnectivityScope = ret.ConnectivityScope
+	}
+	return nil
+}
+
 // Push pushes a plugin to the store.
 func (pm *Manager) Push(ctx context.Context, name string, metaHeader http.Header, authConfig *types.AuthConfig, outStream io.Writer) error {
 	p, err := pm.config.Store.GetV2Plugin(name)
@@ -518,6 +645,10 @@ func (r *pluginReference) Delete(ref reference.Named) (bool, error) {
 	// Read only, ignore
 	return false, nil
 }
+func (r *pluginReference) Apply(changes []refstore.Change) error {
+	// Read only, ignore
+	return nil
+}
 
 type pluginConfigStore struct {
 	pm     *Manager
@@ -778,8 +909,33 @@ func (pm *Manager) CreateFromContext(ctx context.Context, tarCtx io.ReadCloser,
 	return nil
 }
 
+// validPluginOptionTypes are the types of the values of the driver options
+// declared by the plugins.
+var validPluginOptionTypes = map[string]bool{
+	"string":  true,
+	"boolean": true,
+	"integer": true,
+	"size":    true,
+}
+
 func (pm *Manager) validateConfig(config types.PluginConfig) error {
-	return nil // TODO:
+	names := make(map[string]bool, len(config.Options))
+	for _, o := range config.Options {
+		if o.Name == "" {
+			return errdefs.InvalidParameter(errors.New("plugin options must have a name"))
+		}
+		if names[o.Name] {
+			return errdefs.InvalidParameter(errors.Errorf("plugin option %s is declared more than once", o.Name))
+		}
+		names[o.Name] = true
+		if !validPluginOptionTypes[o.Type] {
+			return errdefs.InvalidParameter(errors.Errorf("invalid type %q of plugin option %s", o.Type, o.Name))
+		}
+		if len(o.Values) > 0 && o.Type != "string" {
+			return errdefs.InvalidParameter(errors.Errorf("plugin option %s of type %s must not restrict its values", o.Name, o.Type))
+		}
+	}
+	return nil
 }
 
 func splitConfigRootFSFromTar(in io.ReadCloser, config *[]byte) io.ReadCloser {
@@ -2,9 +2,15 @@ package plugin // import "github.com/docker/docker/plugin"
 
 import (
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/plugins"
 )
 
 func TestAtomicRemoveAllNormal(t *testing.T) {
@@ -79,3 +85,54 @@ func TestAtomicRemoveAllNotExist(t *testing.T) {
 		t.Fatalf("dir should be gone: %v", err)
 	}
 }
+
+func TestRequestCapabilities(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/VolumeDriver.Capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Capabilities":{"Scope":"Global"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, err := plugins.NewClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &types.PluginCapabilities{Volume: &types.PluginVolumeCapabilities{}}
+	if err := requestCapabilities(client, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Volume.Scope != "global" {
+		t.Fatalf("expected a global scope, got %q", c.Volume.Scope)
+	}
+
+	// the network drivers must implement their capabilities
+	c = &types.PluginCapabilities{Network: &types.PluginNetworkCapabilities{}}
+	if err := requestCapabilities(client, c); err == nil {
+		t.Fatal("expected an error requesting the capabilities of a network driver not implementing them")
+	}
+
+	if err := requestCapabilities(nil, c); err == nil {
+		t.Fatal("expected an error without a client")
+	}
+}
+
+func TestValidateConfigOptions(t *testing.T) {
+	pm := &Manager{}
+	for _, tc := range []struct {
+		options []types.PluginOption
+		valid   bool
+	}{
+		{options: nil, valid: true},
+		{options: []types.PluginOption{{Name: "size", Type: "size"}, {Name: "mode", Type: "string", Values: []string{"a", "b"}}}, valid: true},
+		{options: []types.PluginOption{{Type: "string"}}},
+		{options: []types.PluginOption{{Name: "size", Type: "size"}, {Name: "size", Type: "string"}}},
+		{options: []types.PluginOption{{Name: "size", Type: "float"}}},
+		{options: []types.PluginOption{{Name: "count", Type: "integer", Values: []string{"1"}}}},
+	} {
+		err := pm.validateConfig(types.PluginConfig{Options: tc.options})
+		if (err == nil) != tc.valid {
+			t.Errorf("expected valid=%v for %+v, got %v", tc.valid, tc.options, err)
+		}
+	}
+}
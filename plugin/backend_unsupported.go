@@ -30,6 +30,11 @@ func (pm *Manager) Inspect(refOrID string) (tp *types.Plugin, err error) {
 	return nil, errNotSupported
 }
 
+// Capabilities returns the capabilities of the volume and network plugins.
+func (pm *Manager) Capabilities(pluginFilters filters.Args) ([]types.PluginCapabilities, error) {
+	return nil, errNotSupported
+}
+
 // Privileges pulls a plugin config and computes the privileges required to install it.
 func (pm *Manager) Privileges(ctx context.Context, ref reference.Named, metaHeader http.Header, authConfig *types.AuthConfig) (types.PluginPrivileges, error) {
 	return nil, errNotSupported
@@ -0,0 +1,120 @@
+// +build linux
+
+package apparmor // import "github.com/docker/docker/profiles/apparmor"
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"text/template"
+
+	"github.com/docker/docker/pkg/aaparser"
+)
+
+// removeFile is the file of the kernel the names of the profiles to unload
+// are written to.
+var removeFile = "/sys/kernel/security/apparmor/.remove"
+
+// Mount is a mount of a container.
+type Mount struct {
+	Source      string
+	Destination string
+	Type        string
+	ReadOnly    bool
+}
+
+// ContainerProfile holds the information about a container the profiles
+// rendered from templates are parameterized by.
+type ContainerProfile struct {
+	// Name is profile name.
+	Name string
+	// Imports defines the apparmor functions to import, before defining the profile.
+	Imports []string
+	// InnerImports defines the apparmor functions to import in the profile.
+	InnerImports []string
+	// Version is the {major, minor, patch} version of apparmor_parser as a single number.
+	Version int
+
+	// Mounts are the mounts of the container.
+	Mounts []Mount
+	// NetworkMode is the network mode of the container.
+	NetworkMode string
+	// Capabilities are the capabilities of the container, "CAP_CHOWN" for
+	// instance.
+	Capabilities []string
+}
+
+// templateFuncs are the functions of the templates of container profiles.
+var templateFuncs = template.FuncMap{
+	// hasCapability returns whether the container has a capability
+	"hasCapability": func(p *ContainerProfile, capability string) bool {
+		for _, c := range p.Capabilities {
+			if c == capability {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// ParseTemplate parses a template of container profiles. The templates are
+// executed with a ContainerProfile, and the "hasCapability" function.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// render renders the profile of a container from a template.
+func (p *ContainerProfile) render(tmpl *template.Template, out io.Writer) error {
+	if macroExists("tunables/global") {
+		p.Imports = append(p.Imports, "#include <tunables/global>")
+	} else {
+		p.Imports = append(p.Imports, "@{PROC}=/proc/")
+	}
+
+	if macroExists("abstractions/base") {
+		p.InnerImports = append(p.InnerImports, "#include <abstractions/base>")
+	}
+
+	return tmpl.Execute(out, p)
+}
+
+// InstallContainer renders the profile of a container from a template in a
+// temp directory determined by os.TempDir(), then loads the profile into the
+// kernel using 'apparmor_parser', replacing the profile of the same name.
+func InstallContainer(tmpl *template.Template, p ContainerProfile) error {
+	ver, err := aaparser.GetVersion()
+	if err != nil {
+		return err
+	}
+	p.Version = ver
+
+	// Install to a temporary directory.
+	f, err := ioutil.TempFile("", p.Name)
+	if err != nil {
+		return err
+	}
+	profilePath := f.Name()
+
+	defer f.Close()
+	defer os.Remove(profilePath)
+
+	if err := p.render(tmpl, f); err != nil {
+		return err
+	}
+
+	return aaparser.LoadProfile(profilePath)
+}
+
+// Remove unloads a profile from the kernel. It does nothing if the profile is
+// not loaded.
+func Remove(name string) error {
+	f, err := os.OpenFile(removeFile, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
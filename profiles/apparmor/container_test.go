@@ -0,0 +1,64 @@
+// +build linux
+
+package apparmor // import "github.com/docker/docker/profiles/apparmor"
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const testTemplate = `profile {{.Name}} {
+{{range .Imports}}  {{.}}
+{{end}}{{range .Mounts}}  {{.Destination}}/** {{if .ReadOnly}}r{{else}}rw{{end}},
+{{end}}{{if eq .NetworkMode "none"}}  deny network,
+{{end}}{{if not (hasCapability . "CAP_SYS_ADMIN")}}  deny mount,
+{{end}}}
+`
+
+func TestRenderContainerProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apparmor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { profileDirectory = d }(profileDirectory)
+	profileDirectory = dir
+
+	tmpl, err := ParseTemplate("test", testTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := ContainerProfile{
+		Name: "docker-test",
+		Mounts: []Mount{
+			{Source: "/srv/data", Destination: "/data", Type: "bind"},
+			{Source: "/srv/config", Destination: "/config", Type: "bind", ReadOnly: true},
+		},
+		NetworkMode:  "none",
+		Capabilities: []string{"CAP_CHOWN"},
+	}
+	var out bytes.Buffer
+	if err := p.render(tmpl, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `profile docker-test {
+  @{PROC}=/proc/
+  /data/** rw,
+  /config/** r,
+  deny network,
+  deny mount,
+}
+`
+	if out.String() != expected {
+		t.Fatalf("expected profile:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestParseTemplateError(t *testing.T) {
+	if _, err := ParseTemplate("test", "profile {{.Name}"); err == nil {
+		t.Fatal("expected an error parsing an invalid template")
+	}
+}
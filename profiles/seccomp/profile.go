@@ -0,0 +1,266 @@
+// +build linux
+
+package seccomp // import "github.com/docker/docker/profiles/seccomp"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// DefaultInclude is the name the default profile is included with.
+const DefaultInclude = "default"
+
+// maxSyscallArgs is the number of arguments of the syscalls.
+const maxSyscallArgs = 6
+
+var validActions = map[types.Action]bool{
+	types.ActKill:  true,
+	types.ActTrap:  true,
+	types.ActErrno: true,
+	types.ActTrace: true,
+	types.ActAllow: true,
+}
+
+var validOperators = map[types.Operator]bool{
+	types.OpNotEqual:     true,
+	types.OpLessThan:     true,
+	types.OpLessEqual:    true,
+	types.OpEqualTo:      true,
+	types.OpGreaterEqual: true,
+	types.OpGreaterThan:  true,
+	types.OpMaskedEqual:  true,
+}
+
+var validArches = map[types.Arch]bool{
+	types.ArchX86:         true,
+	types.ArchX86_64:      true,
+	types.ArchX32:         true,
+	types.ArchARM:         true,
+	types.ArchAARCH64:     true,
+	types.ArchMIPS:        true,
+	types.ArchMIPS64:      true,
+	types.ArchMIPS64N32:   true,
+	types.ArchMIPSEL:      true,
+	types.ArchMIPSEL64:    true,
+	types.ArchMIPSEL64N32: true,
+	types.ArchPPC:         true,
+	types.ArchPPC64:       true,
+	types.ArchPPC64LE:     true,
+	types.ArchS390:        true,
+	types.ArchS390X:       true,
+}
+
+// ResolveProfile decodes and validates the seccomp profile file of the
+// daemon, and returns it with its includes merged. The included profile
+// files are relative to dir, the directory of the profile.
+func ResolveProfile(body []byte, dir string) ([]byte, error) {
+	config, err := decodeProfile(body, "")
+	if err != nil {
+		return nil, err
+	}
+	merged, err := resolveIncludes(config, dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+func decodeProfile(body []byte, source string) (*types.Seccomp, error) {
+	var config types.Seccomp
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, fmt.Errorf("Decoding seccomp profile failed: %v", err)
+	}
+	if err := validateProfile(&config); err != nil {
+		if source != "" {
+			return nil, fmt.Errorf("invalid seccomp profile %s: %v", source, err)
+		}
+		return nil, fmt.Errorf("invalid seccomp profile: %v", err)
+	}
+	return &config, nil
+}
+
+// resolveIncludes returns the profile merged with its includes. The profile
+// files can only be included when dir is set, the other profiles only being
+// able to include the default profile. stack are the profile files being
+// included, for the include cycles to be detected.
+func resolveIncludes(config *types.Seccomp, dir string, stack []string) (*types.Seccomp, error) {
+	merged := &types.Seccomp{}
+	for _, include := range config.Include {
+		var included *types.Seccomp
+		switch {
+		case include == DefaultInclude:
+			included = DefaultProfile()
+			if included == nil {
+				return nil, fmt.Errorf("include %q: the default seccomp profile is not supported", include)
+			}
+		case dir == "":
+			return nil, fmt.Errorf("include %q: only the %q profile can be included in the seccomp profiles of containers", include, DefaultInclude)
+		default:
+			path := include
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			for _, p := range stack {
+				if p == path {
+					return nil, fmt.Errorf("include %q: include cycle through %s", include, strings.Join(append(stack, path), ", "))
+				}
+			}
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("include %q: %v", include, err)
+			}
+			decoded, err := decodeProfile(body, path)
+			if err != nil {
+				return nil, err
+			}
+			if included, err = resolveIncludes(decoded, filepath.Dir(path), append(stack, path)); err != nil {
+				return nil, err
+			}
+		}
+		merged = mergeProfiles(merged, included)
+	}
+	profile := *config
+	profile.Include = nil
+	return mergeProfiles(merged, &profile), nil
+}
+
+// mergeProfiles returns the profile over merged on the profile base, the
+// default action and the architectures of over replacing the ones of base
+// when they are set, and its syscall rules replacing the rules of base for
+// the same syscalls.
+func mergeProfiles(base, over *types.Seccomp) *types.Seccomp {
+	overridden := make(map[string]bool)
+	for _, call := range over.Syscalls {
+		for _, name := range syscallNames(call) {
+			overridden[name] = true
+		}
+	}
+
+	merged := *base
+	if over.DefaultAction != "" {
+		merged.DefaultAction = over.DefaultAction
+	}
+	if len(over.Architectures) != 0 || len(over.ArchMap) != 0 {
+		merged.Architectures = over.Architectures
+		merged.ArchMap = over.ArchMap
+	} else {
+		merged.ArchMap = nil
+		for _, a := range base.ArchMap {
+			a.Syscalls = filterSyscalls(a.Syscalls, overridden)
+			merged.ArchMap = append(merged.ArchMap, a)
+		}
+	}
+	merged.Syscalls = append(filterSyscalls(base.Syscalls, overridden), over.Syscalls...)
+	return &merged
+}
+
+// filterSyscalls returns the syscall rules without the overridden syscalls.
+func filterSyscalls(calls []*types.Syscall, overridden map[string]bool) []*types.Syscall {
+	var filtered []*types.Syscall
+	for _, call := range calls {
+		var names []string
+		for _, name := range syscallNames(call) {
+			if !overridden[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		c := *call
+		c.Name, c.Names = "", names
+		filtered = append(filtered, &c)
+	}
+	return filtered
+}
+
+func syscallNames(call *types.Syscall) []string {
+	if call.Name != "" {
+		return []string{call.Name}
+	}
+	return call.Names
+}
+
+// validateProfile validates a seccomp profile, the errors naming the rules
+// which are not valid.
+func validateProfile(config *types.Seccomp) error {
+	if config.DefaultAction != "" && !validActions[config.DefaultAction] {
+		return fmt.Errorf("defaultAction: unknown action %q", config.DefaultAction)
+	}
+	if len(config.Architectures) != 0 && len(config.ArchMap) != 0 {
+		return fmt.Errorf("'architectures' and 'archMap' were specified in the seccomp profile, use either 'architectures' or 'archMap'")
+	}
+	for _, a := range config.Architectures {
+		if !validArches[a] {
+			return fmt.Errorf("architectures: unknown architecture %q", a)
+		}
+	}
+	for i, a := range config.ArchMap {
+		if !validArches[a.Arch] {
+			return fmt.Errorf("archMap[%d]: unknown architecture %q", i, a.Arch)
+		}
+		for _, sa := range a.SubArches {
+			if !validArches[sa] {
+				return fmt.Errorf("archMap[%d] (%s): unknown sub-architecture %q", i, a.Arch, sa)
+			}
+		}
+		for j, call := range a.Syscalls {
+			if err := validateSyscall(call); err != nil {
+				return fmt.Errorf("archMap[%d] (%s): syscalls[%d]%s: %v", i, a.Arch, j, describeSyscall(call), err)
+			}
+		}
+	}
+	for i, call := range config.Syscalls {
+		if err := validateSyscall(call); err != nil {
+			return fmt.Errorf("syscalls[%d]%s: %v", i, describeSyscall(call), err)
+		}
+	}
+	return nil
+}
+
+func validateSyscall(call *types.Syscall) error {
+	if call == nil {
+		return fmt.Errorf("empty rule")
+	}
+	if call.Name != "" && len(call.Names) != 0 {
+		return fmt.Errorf("'name' and 'names' were specified in the seccomp profile, use either 'name' or 'names'")
+	}
+	if call.Name == "" && len(call.Names) == 0 {
+		return fmt.Errorf("no syscall name")
+	}
+	if !validActions[call.Action] {
+		return fmt.Errorf("unknown action %q", call.Action)
+	}
+	for i, arg := range call.Args {
+		if arg == nil {
+			return fmt.Errorf("args[%d]: empty argument", i)
+		}
+		if arg.Index >= maxSyscallArgs {
+			return fmt.Errorf("args[%d]: argument index %d out of range", i, arg.Index)
+		}
+		if !validOperators[arg.Op] {
+			return fmt.Errorf("args[%d]: unknown operator %q", i, arg.Op)
+		}
+	}
+	return nil
+}
+
+// describeSyscall describes a syscall rule by its syscalls, or its comment
+// when it has no syscall.
+func describeSyscall(call *types.Syscall) string {
+	if call == nil {
+		return ""
+	}
+	if names := syscallNames(call); len(names) != 0 {
+		return " (" + strings.Join(names, ", ") + ")"
+	}
+	if call.Comment != "" {
+		return fmt.Sprintf(" (%q)", call.Comment)
+	}
+	return ""
+}
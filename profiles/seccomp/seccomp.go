@@ -3,9 +3,7 @@
 package seccomp // import "github.com/docker/docker/profiles/seccomp"
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
 
 	"github.com/docker/docker/api/types"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -19,13 +17,17 @@ func GetDefaultProfile(rs *specs.Spec) (*specs.LinuxSeccomp, error) {
 	return setupSeccomp(DefaultProfile(), rs)
 }
 
-// LoadProfile takes a json string and decodes the seccomp profile.
+// LoadProfile takes a json string and decodes the seccomp profile. The
+// profile can only include the default profile.
 func LoadProfile(body string, rs *specs.Spec) (*specs.LinuxSeccomp, error) {
-	var config types.Seccomp
-	if err := json.Unmarshal([]byte(body), &config); err != nil {
-		return nil, fmt.Errorf("Decoding seccomp profile failed: %v", err)
+	config, err := decodeProfile([]byte(body), "")
+	if err != nil {
+		return nil, err
 	}
-	return setupSeccomp(&config, rs)
+	if config, err = resolveIncludes(config, "", nil); err != nil {
+		return nil, err
+	}
+	return setupSeccomp(config, rs)
 }
 
 var nativeToSeccomp = map[string]types.Arch{
@@ -78,6 +80,7 @@ func setupSeccomp(config *types.Seccomp, rs *specs.Spec) (*specs.LinuxSeccomp, e
 		}
 	}
 
+	syscalls := append([]*types.Syscall{}, config.Syscalls...)
 	if len(config.ArchMap) != 0 {
 		for _, a := range config.ArchMap {
 			seccompArch, ok := nativeToSeccomp[arch]
@@ -87,6 +90,7 @@ func setupSeccomp(config *types.Seccomp, rs *specs.Spec) (*specs.LinuxSeccomp, e
 					for _, sa := range a.SubArches {
 						newConfig.Architectures = append(newConfig.Architectures, specs.Arch(sa))
 					}
+					syscalls = append(syscalls, a.Syscalls...)
 					break
 				}
 			}
@@ -97,7 +101,7 @@ func setupSeccomp(config *types.Seccomp, rs *specs.Spec) (*specs.LinuxSeccomp, e
 
 Loop:
 	// Loop through all syscall blocks and convert them to libcontainer format after filtering them
-	for _, call := range config.Syscalls {
+	for _, call := range syscalls {
 		if len(call.Excludes.Arches) > 0 {
 			if inSlice(call.Excludes.Arches, arch) {
 				continue Loop
@@ -3,9 +3,14 @@
 package seccomp // import "github.com/docker/docker/profiles/seccomp"
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/oci"
 )
 
@@ -30,3 +35,159 @@ func TestLoadDefaultProfile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestResolveProfileIncludes(t *testing.T) {
+	f, err := ioutil.ReadFile("fixtures/include.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ResolveProfile(f, "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config types.Seccomp
+	if err := json.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Include) != 0 {
+		t.Fatalf("expected the includes to be merged, got %v", config.Include)
+	}
+	if config.DefaultAction != types.ActErrno {
+		t.Fatalf("expected the default action of the included profile, got %s", config.DefaultAction)
+	}
+	if len(config.ArchMap) != 1 || len(config.ArchMap[0].Syscalls) != 1 {
+		t.Fatalf("expected the architecture syscalls of the profile, got %+v", config.ArchMap)
+	}
+
+	// the rule of the profile for open replaces the rule of the included profile
+	var rules []string
+	for _, call := range config.Syscalls {
+		rules = append(rules, strings.Join(syscallNames(call), ",")+":"+string(call.Action))
+	}
+	expected := "clone:SCMP_ACT_ALLOW close:SCMP_ACT_ALLOW open,openat:SCMP_ACT_TRACE"
+	if strings.Join(rules, " ") != expected {
+		t.Fatalf("expected syscalls %q, got %q", expected, strings.Join(rules, " "))
+	}
+}
+
+func TestResolveProfileIncludeCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seccomp-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, include := range map[string]string{"a.json": "b.json", "b.json": "a.json"} {
+		profile := `{"include": ["` + include + `"], "defaultAction": "SCMP_ACT_ERRNO"}`
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(profile), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = ResolveProfile([]byte(`{"include": ["a.json"]}`), dir)
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected an include cycle error, got %v", err)
+	}
+}
+
+func TestLoadProfileIncludeFile(t *testing.T) {
+	rs := oci.DefaultSpec()
+	_, err := LoadProfile(`{"include": ["fixtures/example.json"]}`, &rs)
+	if err == nil || !strings.Contains(err.Error(), `include "fixtures/example.json"`) {
+		t.Fatalf("expected the include of a profile file to fail, got %v", err)
+	}
+}
+
+func TestLoadProfileInvalidRule(t *testing.T) {
+	for _, tc := range []struct {
+		profile  string
+		expected string
+	}{
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_DENY"}`,
+			expected: `defaultAction: unknown action "SCMP_ACT_DENY"`,
+		},
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_ERRNO", "architectures": ["SCMP_ARCH_VAX"]}`,
+			expected: `architectures: unknown architecture "SCMP_ARCH_VAX"`,
+		},
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "SCMP_ACT_ALLOW"}, {"names": ["open", "openat"], "action": "SCMP_ACT_DENY"}]}`,
+			expected: `syscalls[1] (open, openat): unknown action "SCMP_ACT_DENY"`,
+		},
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"action": "SCMP_ACT_ALLOW", "comment": "nothing"}]}`,
+			expected: `syscalls[0] ("nothing"): no syscall name`,
+		},
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "clone", "action": "SCMP_ACT_ALLOW", "args": [{"index": 6, "op": "SCMP_CMP_EQ"}]}]}`,
+			expected: `syscalls[0] (clone): args[0]: argument index 6 out of range`,
+		},
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "clone", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "op": "SCMP_CMP_IN"}]}]}`,
+			expected: `syscalls[0] (clone): args[0]: unknown operator "SCMP_CMP_IN"`,
+		},
+		{
+			profile:  `{"defaultAction": "SCMP_ACT_ERRNO", "archMap": [{"architecture": "SCMP_ARCH_X86_64", "syscalls": [{"name": "arch_prctl", "action": "SCMP_ACT_DENY"}]}]}`,
+			expected: `archMap[0] (SCMP_ARCH_X86_64): syscalls[0] (arch_prctl): unknown action "SCMP_ACT_DENY"`,
+		},
+	} {
+		rs := oci.DefaultSpec()
+		_, err := LoadProfile(tc.profile, &rs)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Fatalf("expected error %q for profile %s, got %v", tc.expected, tc.profile, err)
+		}
+	}
+}
+
+func TestLoadProfileArchitectureSyscalls(t *testing.T) {
+	f, err := ioutil.ReadFile("fixtures/include.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ResolveProfile(f, "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := oci.DefaultSpec()
+	profile, err := LoadProfile(string(b), &rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	native := false
+	for _, a := range profile.Architectures {
+		if a == "SCMP_ARCH_X86_64" {
+			native = true
+		}
+	}
+	found := false
+	for _, call := range profile.Syscalls {
+		if call.Names[0] == "arch_prctl" {
+			found = true
+		}
+	}
+	if found != native {
+		t.Fatalf("expected the architecture syscalls to be applied on the architecture only (native %v, applied %v)", native, found)
+	}
+}
+
+func TestLoadProfileIncludeDefault(t *testing.T) {
+	if DefaultProfile() == nil {
+		t.Skip("the default profile is not supported")
+	}
+	rs := oci.DefaultSpec()
+	profile, err := LoadProfile(`{"include": ["default"], "syscalls": [{"name": "ptrace", "action": "SCMP_ACT_ALLOW"}]}`, &rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.DefaultAction != "SCMP_ACT_ERRNO" {
+		t.Fatalf("expected the default action of the default profile, got %s", profile.DefaultAction)
+	}
+	defaultProfile, err := GetDefaultProfile(&rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Syscalls) != len(defaultProfile.Syscalls)+1 {
+		t.Fatalf("expected the syscalls of the default profile and ptrace, got %d syscalls", len(profile.Syscalls))
+	}
+}
@@ -34,6 +34,31 @@ type Store interface {
 	AddDigest(ref reference.Canonical, id digest.Digest, force bool) error
 	Delete(ref reference.Named) (bool, error)
 	Get(ref reference.Named) (digest.Digest, error)
+	Apply(changes []Change) error
+}
+
+// A Change is a change of the references of a store: the reference is added
+// as a tag of the image ID when ID is set, or deleted otherwise.
+type Change struct {
+	Ref reference.Named
+	ID  digest.Digest
+}
+
+// ChangeError is the error of a change which could not be applied to a
+// store, none of the changes being applied.
+type ChangeError struct {
+	// Index is the index of the change in the changes applied.
+	Index int
+	Err   error
+}
+
+func (e *ChangeError) Error() string {
+	return e.Err.Error()
+}
+
+// Cause returns the error of the change.
+func (e *ChangeError) Cause() error {
+	return e.Err
 }
 
 type store struct {
@@ -130,6 +155,18 @@ func (store *store) addReference(ref reference.Named, id digest.Digest, force bo
 		return err
 	}
 
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.add(ref, id, force); err != nil {
+		return err
+	}
+	return store.save()
+}
+
+// add adds a reference to the store, without saving it. The store must be
+// locked.
+func (store *store) add(ref reference.Named, id digest.Digest, force bool) error {
 	refName := reference.FamiliarName(ref)
 	refStr := reference.FamiliarString(ref)
 
@@ -137,9 +174,6 @@ func (store *store) addReference(ref reference.Named, id digest.Digest, force bo
 		return errors.WithStack(invalidTagError("refusing to create an ambiguous tag using digest algorithm as name"))
 	}
 
-	store.mu.Lock()
-	defer store.mu.Unlock()
-
 	repository, exists := store.Repositories[refName]
 	if !exists || repository == nil {
 		repository = make(map[string]digest.Digest)
@@ -175,8 +209,7 @@ func (store *store) addReference(ref reference.Named, id digest.Digest, force bo
 		store.referencesByIDCache[id] = make(map[string]reference.Named)
 	}
 	store.referencesByIDCache[id][refStr] = ref
-
-	return store.save()
+	return nil
 }
 
 // Delete deletes a reference from the store. It returns true if a deletion
@@ -189,32 +222,101 @@ func (store *store) Delete(ref reference.Named) (bool, error) {
 
 	ref = reference.TagNameOnly(ref)
 
-	refName := reference.FamiliarName(ref)
-	refStr := reference.FamiliarString(ref)
-
 	store.mu.Lock()
 	defer store.mu.Unlock()
 
+	if err := store.delete(ref); err != nil {
+		return false, err
+	}
+	return true, store.save()
+}
+
+// delete deletes a reference from the store, without saving it. The store
+// must be locked.
+func (store *store) delete(ref reference.Named) error {
+	refName := reference.FamiliarName(ref)
+	refStr := reference.FamiliarString(ref)
+
 	repository, exists := store.Repositories[refName]
 	if !exists {
-		return false, ErrDoesNotExist
+		return ErrDoesNotExist
 	}
 
-	if id, exists := repository[refStr]; exists {
-		delete(repository, refStr)
-		if len(repository) == 0 {
-			delete(store.Repositories, refName)
+	id, exists := repository[refStr]
+	if !exists {
+		return ErrDoesNotExist
+	}
+	delete(repository, refStr)
+	if len(repository) == 0 {
+		delete(store.Repositories, refName)
+	}
+	if store.referencesByIDCache[id] != nil {
+		delete(store.referencesByIDCache[id], refStr)
+		if len(store.referencesByIDCache[id]) == 0 {
+			delete(store.referencesByIDCache, id)
 		}
-		if store.referencesByIDCache[id] != nil {
-			delete(store.referencesByIDCache[id], refStr)
-			if len(store.referencesByIDCache[id]) == 0 {
-				delete(store.referencesByIDCache, id)
-			}
+	}
+	return nil
+}
+
+// Apply applies changes to the store, in order, as AddTag with force set and
+// Delete. Either all the changes are applied, or none of them is and the
+// error is a *ChangeError for the first change which could not be applied.
+func (store *store) Apply(changes []Change) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	repositories, referencesByID := store.copyState()
+	restore := func() {
+		store.Repositories, store.referencesByIDCache = repositories, referencesByID
+	}
+	for i, c := range changes {
+		if err := store.apply(c); err != nil {
+			restore()
+			return &ChangeError{Index: i, Err: err}
 		}
-		return true, store.save()
 	}
+	if err := store.save(); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+func (store *store) apply(c Change) error {
+	ref, err := favorDigest(c.Ref)
+	if err != nil {
+		return err
+	}
+	if c.ID == "" {
+		return store.delete(reference.TagNameOnly(ref))
+	}
+	if _, isCanonical := ref.(reference.Canonical); isCanonical {
+		return errors.WithStack(invalidTagError("refusing to create a tag with a digest reference"))
+	}
+	return store.add(reference.TagNameOnly(ref), c.ID, true)
+}
 
-	return false, ErrDoesNotExist
+// copyState returns copies of the references of the store, for the changes
+// of the store to be reverted. The store must be locked.
+func (store *store) copyState() (map[string]repository, map[digest.Digest]map[string]reference.Named) {
+	repositories := make(map[string]repository, len(store.Repositories))
+	for name, r := range store.Repositories {
+		c := make(repository, len(r))
+		for refStr, id := range r {
+			c[refStr] = id
+		}
+		repositories[name] = c
+	}
+	referencesByID := make(map[digest.Digest]map[string]reference.Named, len(store.referencesByIDCache))
+	for id, refs := range store.referencesByIDCache {
+		c := make(map[string]reference.Named, len(refs))
+		for refStr, ref := range refs {
+			c[refStr] = ref
+		}
+		referencesByID[id] = c
+	}
+	return repositories, referencesByID
 }
 
 // Get retrieves an item from the store by reference
@@ -348,3 +348,59 @@ func TestInvalidTags(t *testing.T) {
 	err = store.AddTag(ref, id, true)
 	assert.Check(t, is.ErrorContains(err, ""))
 }
+
+func TestApply(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tag-store-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	jsonPath := filepath.Join(tmpDir, "repositories.json")
+	store, err := NewReferenceStore(jsonPath)
+	assert.NilError(t, err)
+	id1 := digest.Digest("sha256:470022b8af682154f57a2163d030eb369549549cba00edc69e1b99b46bb924d6")
+	id2 := digest.Digest("sha256:ae300ebc4a4f00693702cfb0a5e0b7bc527b353828dc86ad09fb95c8a681b793")
+
+	parse := func(s string) reference.Named {
+		ref, err := reference.ParseNormalizedNamed(s)
+		assert.NilError(t, err)
+		return ref
+	}
+	assert.NilError(t, store.AddTag(parse("old.example.com/app:1"), id1, false))
+	assert.NilError(t, store.AddTag(parse("old.example.com/app:2"), id2, false))
+
+	// the changes are applied in order
+	err = store.Apply([]Change{
+		{Ref: parse("new.example.com/app:1"), ID: id1},
+		{Ref: parse("old.example.com/app:1")},
+		{Ref: parse("new.example.com/app"), ID: id2},
+		{Ref: parse("new.example.com/app:latest")},
+		{Ref: parse("new.example.com/app:2"), ID: id2},
+	})
+	assert.NilError(t, err)
+	refs := store.References(id1)
+	assert.Assert(t, is.Len(refs, 1))
+	assert.Check(t, is.Equal(refs[0].String(), "new.example.com/app:1"))
+	assert.Check(t, is.Len(store.References(id2), 2))
+
+	// none of the changes is applied when one of them fails
+	err = store.Apply([]Change{
+		{Ref: parse("new.example.com/app:2")},
+		{Ref: parse("new.example.com/app:1"), ID: id2},
+		{Ref: parse("old.example.com/app:1")},
+	})
+	changeErr, ok := err.(*ChangeError)
+	assert.Assert(t, ok, "unexpected error %v", err)
+	assert.Check(t, is.Equal(changeErr.Index, 2))
+	assert.Check(t, is.Equal(changeErr.Err, error(ErrDoesNotExist)))
+	id, err := store.Get(parse("new.example.com/app:1"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(id, id1))
+	assert.Check(t, is.Len(store.References(id2), 2))
+
+	// the changes applied are saved
+	reloaded, err := NewReferenceStore(jsonPath)
+	assert.NilError(t, err)
+	refs = reloaded.References(id1)
+	assert.Assert(t, is.Len(refs, 1))
+	assert.Check(t, is.Equal(refs[0].String(), "new.example.com/app:1"))
+}
@@ -24,6 +24,7 @@ func TestNetworkModeTest(t *testing.T) {
 		DefaultDaemonNetworkMode(): {true, true, false, false, false, false},
 		"host":                     {false, false, true, false, false, false},
 		"container:name":           {false, false, false, true, false, false},
+		"group:name":               {false, false, false, false, false, false},
 		"none":                     {true, false, false, false, true, false},
 		"default":                  {true, false, false, false, false, true},
 	}
@@ -34,6 +35,7 @@ func TestNetworkModeTest(t *testing.T) {
 		DefaultDaemonNetworkMode(): "bridge",
 		"host":                     "host",
 		"container:name":           "container",
+		"group:name":               "group",
 		"none":                     "none",
 		"default":                  "default",
 	}
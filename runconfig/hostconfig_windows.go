@@ -30,6 +30,10 @@ func validateNetMode(c *container.Config, hc *container.HostConfig) error {
 		return err
 	}
 
+	if hc.NetworkMode.IsGroup() {
+		return fmt.Errorf("Network groups are not supported on Windows")
+	}
+
 	if hc.NetworkMode.IsContainer() && hc.Isolation.IsHyperV() {
 		return fmt.Errorf("Using the network stack of another container is not supported while using Hyper-V Containers")
 	}
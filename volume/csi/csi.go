@@ -0,0 +1,497 @@
+// Package csi provides a volume driver using the node service of a plugin
+// implementing the Container Storage Interface (CSI). The volumes are
+// provisioned out of band, the driver staging and publishing them on the
+// host when they are mounted.
+package csi // import "github.com/docker/docker/volume/csi"
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	csipb "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/docker/docker/daemon/names"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/volume"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+const (
+	configFileName  = "volume.json"
+	stagingPathName = "staging"
+	targetPathName  = "mount"
+
+	// callTimeout is the timeout of the calls to the plugin, which may
+	// attach and format devices.
+	callTimeout = 2 * time.Minute
+)
+
+// The options of the volumes, the volume context being passed with the
+// "context." prefix. The secrets are not passed as options, which are
+// persisted and returned by the API, but read from the file referenced by the
+// secrets-file option, a JSON object of the secrets, when the volume is
+// mounted.
+const (
+	optVolumeID    = "volume-id"
+	optFsType      = "fs-type"
+	optMountFlags  = "mount-flags"
+	optAccessMode  = "access-mode"
+	optReadonly    = "readonly"
+	optSecretsFile = "secrets-file"
+
+	contextPrefix = "context."
+	secretPrefix  = "secret."
+)
+
+// The access modes of the volumes.
+var accessModes = map[string]csipb.VolumeCapability_AccessMode_Mode{
+	"single-node-writer":       csipb.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+	"single-node-reader-only":  csipb.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+	"multi-node-reader-only":   csipb.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+	"multi-node-single-writer": csipb.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+	"multi-node-multi-writer":  csipb.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+}
+
+var volumeNameRegex = names.RestrictedNamePattern
+
+// ParseEndpoint returns the path of the unix socket of a CSI plugin endpoint,
+// which is either a path or a unix:// URL.
+func ParseEndpoint(endpoint string) (string, error) {
+	path := strings.TrimPrefix(endpoint, "unix://")
+	if strings.Contains(path, "://") || !filepath.IsAbs(path) {
+		return "", errors.Errorf("invalid CSI plugin endpoint %q: only the absolute paths of unix sockets are supported", endpoint)
+	}
+	return path, nil
+}
+
+// Driver is a volume driver whose volumes are published by a CSI plugin.
+type Driver struct {
+	name     string
+	root     string
+	conn     *grpc.ClientConn
+	identity csipb.IdentityClient
+	node     csipb.NodeClient
+
+	m       sync.Mutex
+	volumes map[string]*csiVolume
+}
+
+// New returns the volume driver named name, using the CSI plugin listening
+// on endpoint. The volumes are stored in root. The plugin is only connected
+// when it is used, so it may be started after the driver.
+func New(name, endpoint, root string) (*Driver, error) {
+	socket, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(socket, grpc.WithInsecure(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("unix", addr, timeout)
+	}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error connecting to CSI plugin %s", name)
+	}
+
+	d := &Driver{
+		name:     name,
+		root:     root,
+		conn:     conn,
+		identity: csipb.NewIdentityClient(conn),
+		node:     csipb.NewNodeClient(conn),
+		volumes:  make(map[string]*csiVolume),
+	}
+
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		v := &csiVolume{driver: d, name: dir.Name()}
+		b, err := ioutil.ReadFile(filepath.Join(d.volumeDir(v.name), configFileName))
+		if err == nil {
+			err = json.Unmarshal(b, &v.config)
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("driver", name).WithField("volume", v.name).Error("Error restoring the CSI volume")
+			continue
+		}
+		d.volumes[v.name] = v
+	}
+	return d, nil
+}
+
+// Name returns the name of the driver.
+func (d *Driver) Name() string {
+	return d.name
+}
+
+// Scope returns the scope of the driver. The volumes are published on this
+// host only.
+func (d *Driver) Scope() string {
+	return volume.LocalScope
+}
+
+// Close closes the connection to the plugin.
+func (d *Driver) Close() error {
+	return d.conn.Close()
+}
+
+func (d *Driver) volumeDir(name string) string {
+	return filepath.Join(d.root, name)
+}
+
+// Create records a volume of the plugin. The volume is not published until
+// it is mounted.
+func (d *Driver) Create(name string, opts map[string]string) (volume.Volume, error) {
+	if !volumeNameRegex.MatchString(name) {
+		return nil, errdefs.InvalidParameter(errors.Errorf("%q includes invalid characters for a CSI volume name, only %q are allowed", name, names.RestrictedNameChars))
+	}
+	config, err := parseOpts(name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	if v, exists := d.volumes[name]; exists {
+		return v, nil
+	}
+
+	dir := d.volumeDir(name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(errdefs.System(err), "error while creating volume path '%s'", dir)
+	}
+	b, err := json.Marshal(config)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, configFileName), b, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, errdefs.System(errors.Wrap(err, "error while persisting volume options"))
+	}
+
+	v := &csiVolume{driver: d, name: name, config: config}
+	d.volumes[name] = v
+	return v, nil
+}
+
+// Remove removes the record of a volume which is not mounted. The volume of
+// the plugin is left as is.
+func (d *Driver) Remove(v volume.Volume) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	cv, exists := d.volumes[v.Name()]
+	if !exists {
+		return errdefs.NotFound(errors.Errorf("volume %s not found", v.Name()))
+	}
+	cv.m.Lock()
+	defer cv.m.Unlock()
+	if cv.active > 0 {
+		return errdefs.Conflict(errors.Errorf("volume %s is mounted", v.Name()))
+	}
+	if err := os.RemoveAll(d.volumeDir(v.Name())); err != nil {
+		return errdefs.System(errors.Wrapf(err, "error removing volume %s", v.Name()))
+	}
+	delete(d.volumes, v.Name())
+	return nil
+}
+
+// List lists the volumes of the driver.
+func (d *Driver) List() ([]volume.Volume, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	ls := make([]volume.Volume, 0, len(d.volumes))
+	for _, v := range d.volumes {
+		ls = append(ls, v)
+	}
+	return ls, nil
+}
+
+// Get returns the volume named name.
+func (d *Driver) Get(name string) (volume.Volume, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	v, exists := d.volumes[name]
+	if !exists {
+		return nil, errdefs.NotFound(errors.Errorf("volume %s not found", name))
+	}
+	return v, nil
+}
+
+// volumeConfig is the configuration of a volume, persisted in its directory.
+type volumeConfig struct {
+	VolumeID      string
+	FsType        string            `json:",omitempty"`
+	MountFlags    []string          `json:",omitempty"`
+	AccessMode    string            `json:",omitempty"`
+	Readonly      bool              `json:",omitempty"`
+	VolumeContext map[string]string `json:",omitempty"`
+	SecretsFile   string            `json:",omitempty"`
+	CreatedAt     time.Time
+}
+
+func parseOpts(name string, opts map[string]string) (volumeConfig, error) {
+	config := volumeConfig{
+		VolumeID:   name,
+		AccessMode: "single-node-writer",
+		CreatedAt:  time.Now(),
+	}
+	for key, value := range opts {
+		switch {
+		case key == optVolumeID:
+			config.VolumeID = value
+		case key == optFsType:
+			config.FsType = value
+		case key == optMountFlags:
+			config.MountFlags = strings.Split(value, ",")
+		case key == optAccessMode:
+			if _, ok := accessModes[value]; !ok {
+				return config, errdefs.InvalidParameter(errors.Errorf("invalid access mode %q", value))
+			}
+			config.AccessMode = value
+		case key == optReadonly:
+			readonly, err := strconv.ParseBool(value)
+			if err != nil {
+				return config, errdefs.InvalidParameter(errors.Wrapf(err, "invalid value for %s", optReadonly))
+			}
+			config.Readonly = readonly
+		case strings.HasPrefix(key, contextPrefix):
+			if config.VolumeContext == nil {
+				config.VolumeContext = make(map[string]string)
+			}
+			config.VolumeContext[strings.TrimPrefix(key, contextPrefix)] = value
+		case key == optSecretsFile:
+			if !filepath.IsAbs(value) {
+				return config, errdefs.InvalidParameter(errors.Errorf("invalid value for %s: %q is not an absolute path", optSecretsFile, value))
+			}
+			config.SecretsFile = value
+		case strings.HasPrefix(key, secretPrefix):
+			return config, errdefs.InvalidParameter(errors.Errorf("invalid option: %q, the secrets are read from the file of the %s option", key, optSecretsFile))
+		default:
+			return config, errdefs.InvalidParameter(errors.Errorf("invalid option: %q", key))
+		}
+	}
+	if config.VolumeID == "" {
+		return config, errdefs.InvalidParameter(errors.Errorf("invalid value for %s: the volume id is empty", optVolumeID))
+	}
+	return config, nil
+}
+
+type csiVolume struct {
+	driver *Driver
+	name   string
+	config volumeConfig
+
+	m sync.Mutex
+	// active is the number of the mounts of the volume
+	active int
+	// staged is whether the volume has been staged for the active mounts
+	staged bool
+}
+
+func (v *csiVolume) Name() string {
+	return v.name
+}
+
+func (v *csiVolume) DriverName() string {
+	return v.driver.name
+}
+
+func (v *csiVolume) Path() string {
+	return filepath.Join(v.driver.volumeDir(v.name), targetPathName)
+}
+
+func (v *csiVolume) stagingPath() string {
+	return filepath.Join(v.driver.volumeDir(v.name), stagingPathName)
+}
+
+func (v *csiVolume) CreatedAt() (time.Time, error) {
+	return v.config.CreatedAt, nil
+}
+
+func (v *csiVolume) Status() map[string]interface{} {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return map[string]interface{}{
+		"VolumeID": v.config.VolumeID,
+		"Mounted":  v.active > 0,
+	}
+}
+
+func (v *csiVolume) capability() *csipb.VolumeCapability {
+	return &csipb.VolumeCapability{
+		AccessType: &csipb.VolumeCapability_Mount{
+			Mount: &csipb.VolumeCapability_MountVolume{
+				FsType:     v.config.FsType,
+				MountFlags: v.config.MountFlags,
+			},
+		},
+		AccessMode: &csipb.VolumeCapability_AccessMode{Mode: accessModes[v.config.AccessMode]},
+	}
+}
+
+// secrets returns the secrets of the volume, read from its secrets file.
+func (v *csiVolume) secrets() (map[string]string, error) {
+	if v.config.SecretsFile == "" {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(v.config.SecretsFile)
+	if err != nil {
+		return nil, errdefs.System(errors.Wrapf(err, "error reading the secrets of volume %s", v.name))
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, errdefs.InvalidParameter(errors.Wrapf(err, "invalid secrets file %s of volume %s", v.config.SecretsFile, v.name))
+	}
+	return secrets, nil
+}
+
+// Mount stages the volume, if the plugin supports it, and publishes it on
+// the path of the volume, when it is first mounted.
+func (v *csiVolume) Mount(id string) (string, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.active > 0 {
+		v.active++
+		return v.Path(), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	secrets, err := v.secrets()
+	if err != nil {
+		return "", err
+	}
+	staged, err := v.driver.supportsStaging(ctx)
+	if err != nil {
+		return "", err
+	}
+	if staged {
+		if err := os.MkdirAll(v.stagingPath(), 0700); err != nil {
+			return "", errdefs.System(err)
+		}
+		req := &csipb.NodeStageVolumeRequest{
+			VolumeId:          v.config.VolumeID,
+			StagingTargetPath: v.stagingPath(),
+			VolumeCapability:  v.capability(),
+			Secrets:           secrets,
+			VolumeContext:     v.config.VolumeContext,
+		}
+		if _, err := v.driver.node.NodeStageVolume(ctx, req); err != nil {
+			return "", errdefs.System(errors.Wrapf(err, "error staging volume %s", v.name))
+		}
+	}
+
+	req := &csipb.NodePublishVolumeRequest{
+		VolumeId:         v.config.VolumeID,
+		TargetPath:       v.Path(),
+		VolumeCapability: v.capability(),
+		Readonly:         v.config.Readonly,
+		Secrets:          secrets,
+		VolumeContext:    v.config.VolumeContext,
+	}
+	if staged {
+		req.StagingTargetPath = v.stagingPath()
+	}
+	if _, err := v.driver.node.NodePublishVolume(ctx, req); err != nil {
+		err = errdefs.System(errors.Wrapf(err, "error publishing volume %s", v.name))
+		if staged {
+			if unstageErr := v.unstage(ctx); unstageErr != nil {
+				logrus.WithError(unstageErr).WithField("volume", v.name).Error("Error unstaging the volume after a failure to publish it")
+			}
+		}
+		return "", err
+	}
+
+	v.active = 1
+	v.staged = staged
+	return v.Path(), nil
+}
+
+// Unmount unpublishes and unstages the volume when its last mount is
+// unmounted.
+func (v *csiVolume) Unmount(id string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.active == 0 {
+		return nil
+	}
+	if v.active > 1 {
+		v.active--
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	req := &csipb.NodeUnpublishVolumeRequest{
+		VolumeId:   v.config.VolumeID,
+		TargetPath: v.Path(),
+	}
+	if _, err := v.driver.node.NodeUnpublishVolume(ctx, req); err != nil {
+		return errdefs.System(errors.Wrapf(err, "error unpublishing volume %s", v.name))
+	}
+	v.active = 0
+	if v.staged {
+		v.staged = false
+		if err := v.unstage(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *csiVolume) unstage(ctx context.Context) error {
+	req := &csipb.NodeUnstageVolumeRequest{
+		VolumeId:          v.config.VolumeID,
+		StagingTargetPath: v.stagingPath(),
+	}
+	if _, err := v.driver.node.NodeUnstageVolume(ctx, req); err != nil {
+		return errdefs.System(errors.Wrapf(err, "error unstaging volume %s", v.name))
+	}
+	return nil
+}
+
+// supportsStaging returns whether the plugin requires the volumes to be
+// staged before they are published.
+func (d *Driver) supportsStaging(ctx context.Context) (bool, error) {
+	resp, err := d.node.NodeGetCapabilities(ctx, &csipb.NodeGetCapabilitiesRequest{})
+	if err != nil {
+		return false, errdefs.Unavailable(errors.Wrapf(err, "error getting the capabilities of CSI plugin %s", d.name))
+	}
+	for _, c := range resp.GetCapabilities() {
+		if c.GetRpc().GetType() == csipb.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PluginInfo returns the name and the version of the plugin.
+func (d *Driver) PluginInfo(ctx context.Context) (name, version string, err error) {
+	resp, err := d.identity.GetPluginInfo(ctx, &csipb.GetPluginInfoRequest{})
+	if err != nil {
+		return "", "", errdefs.Unavailable(errors.Wrapf(err, "error getting the information of CSI plugin %s", d.name))
+	}
+	return resp.GetName(), resp.GetVendorVersion(), nil
+}
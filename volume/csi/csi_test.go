@@ -0,0 +1,252 @@
+package csi // import "github.com/docker/docker/volume/csi"
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	csipb "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/docker/docker/errdefs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// fakePlugin is a CSI plugin recording the calls to its node service.
+type fakePlugin struct {
+	staging bool
+
+	mu    sync.Mutex
+	calls []string
+	stage *csipb.NodeStageVolumeRequest
+	pub   *csipb.NodePublishVolumeRequest
+}
+
+func (p *fakePlugin) record(call string) {
+	p.mu.Lock()
+	p.calls = append(p.calls, call)
+	p.mu.Unlock()
+}
+
+func (p *fakePlugin) getCalls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.calls...)
+}
+
+func (p *fakePlugin) GetPluginInfo(context.Context, *csipb.GetPluginInfoRequest) (*csipb.GetPluginInfoResponse, error) {
+	return &csipb.GetPluginInfoResponse{Name: "fake.csi.docker.com", VendorVersion: "1.0.0"}, nil
+}
+
+func (p *fakePlugin) GetPluginCapabilities(context.Context, *csipb.GetPluginCapabilitiesRequest) (*csipb.GetPluginCapabilitiesResponse, error) {
+	return &csipb.GetPluginCapabilitiesResponse{}, nil
+}
+
+func (p *fakePlugin) Probe(context.Context, *csipb.ProbeRequest) (*csipb.ProbeResponse, error) {
+	return &csipb.ProbeResponse{}, nil
+}
+
+func (p *fakePlugin) NodeGetCapabilities(context.Context, *csipb.NodeGetCapabilitiesRequest) (*csipb.NodeGetCapabilitiesResponse, error) {
+	resp := &csipb.NodeGetCapabilitiesResponse{}
+	if p.staging {
+		resp.Capabilities = append(resp.Capabilities, &csipb.NodeServiceCapability{
+			Type: &csipb.NodeServiceCapability_Rpc{
+				Rpc: &csipb.NodeServiceCapability_RPC{Type: csipb.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME},
+			},
+		})
+	}
+	return resp, nil
+}
+
+func (p *fakePlugin) NodeStageVolume(_ context.Context, req *csipb.NodeStageVolumeRequest) (*csipb.NodeStageVolumeResponse, error) {
+	p.record("stage")
+	p.mu.Lock()
+	p.stage = req
+	p.mu.Unlock()
+	return &csipb.NodeStageVolumeResponse{}, nil
+}
+
+func (p *fakePlugin) NodeUnstageVolume(context.Context, *csipb.NodeUnstageVolumeRequest) (*csipb.NodeUnstageVolumeResponse, error) {
+	p.record("unstage")
+	return &csipb.NodeUnstageVolumeResponse{}, nil
+}
+
+func (p *fakePlugin) NodePublishVolume(_ context.Context, req *csipb.NodePublishVolumeRequest) (*csipb.NodePublishVolumeResponse, error) {
+	p.record("publish")
+	p.mu.Lock()
+	p.pub = req
+	p.mu.Unlock()
+	return &csipb.NodePublishVolumeResponse{}, nil
+}
+
+func (p *fakePlugin) NodeUnpublishVolume(context.Context, *csipb.NodeUnpublishVolumeRequest) (*csipb.NodeUnpublishVolumeResponse, error) {
+	p.record("unpublish")
+	return &csipb.NodeUnpublishVolumeResponse{}, nil
+}
+
+func (p *fakePlugin) NodeGetVolumeStats(context.Context, *csipb.NodeGetVolumeStatsRequest) (*csipb.NodeGetVolumeStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "NodeGetVolumeStats is not implemented")
+}
+
+func (p *fakePlugin) NodeGetInfo(context.Context, *csipb.NodeGetInfoRequest) (*csipb.NodeGetInfoResponse, error) {
+	return &csipb.NodeGetInfoResponse{NodeId: "node"}, nil
+}
+
+func newFakePlugin(t *testing.T, staging bool) (*fakePlugin, string, func()) {
+	dir, err := ioutil.TempDir("", "csi-plugin")
+	assert.NilError(t, err)
+	socket := filepath.Join(dir, "csi.sock")
+	l, err := net.Listen("unix", socket)
+	assert.NilError(t, err)
+
+	p := &fakePlugin{staging: staging}
+	s := grpc.NewServer()
+	csipb.RegisterIdentityServer(s, p)
+	csipb.RegisterNodeServer(s, p)
+	go s.Serve(l)
+	return p, "unix://" + socket, func() {
+		s.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestDriver(t *testing.T, endpoint string) (*Driver, string, func()) {
+	root, err := ioutil.TempDir("", "csi-driver")
+	assert.NilError(t, err)
+	d, err := New("fake", endpoint, root)
+	assert.NilError(t, err)
+	return d, root, func() {
+		d.Close()
+		os.RemoveAll(root)
+	}
+}
+
+func TestMountStaged(t *testing.T) {
+	p, endpoint, stop := newFakePlugin(t, true)
+	defer stop()
+	d, root, cleanup := newTestDriver(t, endpoint)
+	defer cleanup()
+
+	name, version, err := d.PluginInfo(context.Background())
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(name, "fake.csi.docker.com"))
+	assert.Check(t, is.Equal(version, "1.0.0"))
+
+	secrets := filepath.Join(root, "secrets.json")
+	assert.NilError(t, ioutil.WriteFile(secrets, []byte(`{"token":"s3cr3t"}`), 0600))
+	v, err := d.Create("data", map[string]string{
+		"volume-id":      "vol-0123",
+		"fs-type":        "ext4",
+		"mount-flags":    "noatime,nodev",
+		"context.server": "10.0.0.1",
+		"secrets-file":   secrets,
+	})
+	assert.NilError(t, err)
+
+	// The secrets are not persisted.
+	b, err := ioutil.ReadFile(filepath.Join(root, "data", configFileName))
+	assert.NilError(t, err)
+	assert.Check(t, !strings.Contains(string(b), "s3cr3t"), string(b))
+
+	path, err := v.Mount("c1")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(path, v.Path()))
+	_, err = v.Mount("c2")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(p.getCalls(), []string{"stage", "publish"}))
+
+	assert.Check(t, is.Equal(p.stage.VolumeId, "vol-0123"))
+	assert.Check(t, is.Equal(p.stage.VolumeCapability.GetMount().FsType, "ext4"))
+	assert.Check(t, is.DeepEqual(p.stage.VolumeCapability.GetMount().MountFlags, []string{"noatime", "nodev"}))
+	assert.Check(t, is.Equal(p.stage.VolumeCapability.AccessMode.Mode, csipb.VolumeCapability_AccessMode_SINGLE_NODE_WRITER))
+	assert.Check(t, is.DeepEqual(p.stage.Secrets, map[string]string{"token": "s3cr3t"}))
+	assert.Check(t, is.DeepEqual(p.pub.Secrets, map[string]string{"token": "s3cr3t"}))
+	assert.Check(t, is.DeepEqual(p.pub.VolumeContext, map[string]string{"server": "10.0.0.1"}))
+	assert.Check(t, is.Equal(p.pub.StagingTargetPath, p.stage.StagingTargetPath))
+	assert.Check(t, is.Equal(p.pub.TargetPath, path))
+
+	err = d.Remove(v)
+	assert.Check(t, errdefs.IsConflict(err), err)
+
+	assert.NilError(t, v.Unmount("c1"))
+	assert.Check(t, is.DeepEqual(p.getCalls(), []string{"stage", "publish"}))
+	assert.NilError(t, v.Unmount("c2"))
+	assert.Check(t, is.DeepEqual(p.getCalls(), []string{"stage", "publish", "unpublish", "unstage"}))
+
+	assert.NilError(t, d.Remove(v))
+	_, err = d.Get("data")
+	assert.Check(t, errdefs.IsNotFound(err), err)
+}
+
+func TestMountWithoutStaging(t *testing.T) {
+	p, endpoint, stop := newFakePlugin(t, false)
+	defer stop()
+	d, _, cleanup := newTestDriver(t, endpoint)
+	defer cleanup()
+
+	v, err := d.Create("data", map[string]string{"readonly": "true", "access-mode": "multi-node-reader-only"})
+	assert.NilError(t, err)
+	_, err = v.Mount("c1")
+	assert.NilError(t, err)
+	assert.NilError(t, v.Unmount("c1"))
+	assert.Check(t, is.DeepEqual(p.getCalls(), []string{"publish", "unpublish"}))
+	assert.Check(t, is.Equal(p.pub.VolumeId, "data"))
+	assert.Check(t, p.pub.Readonly)
+	assert.Check(t, is.Equal(p.pub.StagingTargetPath, ""))
+	assert.Check(t, is.Equal(p.pub.VolumeCapability.AccessMode.Mode, csipb.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY))
+
+	// The volume is not published without its secrets.
+	v, err = d.Create("missing-secrets", map[string]string{"secrets-file": "/nonexistent/secrets.json"})
+	assert.NilError(t, err)
+	_, err = v.Mount("c1")
+	assert.Check(t, is.ErrorContains(err, "error reading the secrets of volume missing-secrets"))
+	assert.Check(t, is.DeepEqual(p.getCalls(), []string{"publish", "unpublish"}))
+}
+
+func TestCreateInvalidOptions(t *testing.T) {
+	d, _, cleanup := newTestDriver(t, "/run/csi/none.sock")
+	defer cleanup()
+
+	for _, opts := range []map[string]string{
+		{"unknown": "value"},
+		{"access-mode": "everyone"},
+		{"readonly": "maybe"},
+		{"volume-id": ""},
+		{"secret.token": "s3cr3t"},
+		{"secrets-file": "secrets.json"},
+	} {
+		_, err := d.Create("data", opts)
+		assert.Check(t, errdefs.IsInvalidParameter(err), "%v: %v", opts, err)
+	}
+	_, err := d.Create("../data", nil)
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+
+	_, err = New("fake", "tcp://127.0.0.1:1234", "/tmp")
+	assert.Check(t, is.ErrorContains(err, "invalid CSI plugin endpoint"))
+}
+
+func TestRestore(t *testing.T) {
+	d, root, cleanup := newTestDriver(t, "/run/csi/none.sock")
+	defer cleanup()
+
+	_, err := d.Create("data", map[string]string{"volume-id": "vol-0123"})
+	assert.NilError(t, err)
+
+	d2, err := New("fake", "/run/csi/none.sock", root)
+	assert.NilError(t, err)
+	defer d2.Close()
+	v, err := d2.Get("data")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(v.Status()["VolumeID"], "vol-0123"))
+
+	// the plugin is not running
+	_, err = v.Mount("c1")
+	assert.Check(t, errdefs.IsUnavailable(err), err)
+}
@@ -0,0 +1,118 @@
+// Package keymanager provides the key managers protecting the keys of the
+// encrypted volumes: the key file of the daemon, or a key management plugin.
+package keymanager // import "github.com/docker/docker/volume/keymanager"
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/plugingetter"
+	"github.com/docker/docker/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+// KeyManager wraps the keys of the volumes, for them to be stored along the
+// volumes, and unwraps them when the volumes are used.
+type KeyManager interface {
+	// WrapKey wraps the key of the volume id.
+	WrapKey(id string, key []byte) ([]byte, error)
+	// UnwrapKey returns the key of the volume id from its wrapped key.
+	UnwrapKey(id string, wrapped []byte) ([]byte, error)
+}
+
+var getter plugingetter.PluginGetter
+
+// RegisterPluginGetter sets the plugingetter the key management plugins are
+// looked up with.
+func RegisterPluginGetter(pg plugingetter.PluginGetter) {
+	getter = pg
+}
+
+// Get returns the key management plugin with the given name.
+func Get(name string) (KeyManager, error) {
+	var (
+		p   plugingetter.CompatPlugin
+		err error
+	)
+	if getter != nil {
+		p, err = getter.Get(name, pluginImplements, plugingetter.Lookup)
+	} else {
+		p, err = plugins.Get(name, pluginImplements)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error looking up key management plugin %s", name)
+	}
+	return &pluginKeyManager{name: p.Name(), client: p.Client()}, nil
+}
+
+const masterKeySize = 32
+
+// fileKeyManager wraps the keys of the volumes with a master key stored in
+// a file readable only by the daemon.
+type fileKeyManager struct {
+	aead cipher.AEAD
+}
+
+// NewFile returns a key manager wrapping the keys with the master key in
+// the file at path, which is generated if it does not exist. The keys are
+// only protected as long as the file is kept apart from the volumes, as
+// when the volumes are backed up or moved.
+func NewFile(path string) (KeyManager, error) {
+	key, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		key = make([]byte, masterKeySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		err = ioutils.AtomicWriteFile(path, key, 0600)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading the master key of the volumes")
+	}
+	if len(key) != masterKeySize {
+		return nil, errdefs.System(errors.Errorf("invalid master key of the volumes in %s", path))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &fileKeyManager{aead: aead}, nil
+}
+
+// WrapKey encrypts the key with the master key, authenticating the id of
+// the volume with it.
+func (m *fileKeyManager) WrapKey(id string, key []byte) ([]byte, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return m.aead.Seal(nonce, nonce, key, []byte(id)), nil
+}
+
+// UnwrapKey decrypts the key with the master key.
+func (m *fileKeyManager) UnwrapKey(id string, wrapped []byte) ([]byte, error) {
+	n := m.aead.NonceSize()
+	if len(wrapped) < n {
+		return nil, errdefs.System(errors.Errorf("invalid wrapped key of volume %s", id))
+	}
+	key, err := m.aead.Open(nil, wrapped[:n], wrapped[n:], []byte(id))
+	if err != nil {
+		return nil, errdefs.System(errors.Wrapf(err, "error unwrapping the key of volume %s", id))
+	}
+	return key, nil
+}
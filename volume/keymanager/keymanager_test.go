@@ -0,0 +1,36 @@
+package keymanager // import "github.com/docker/docker/volume/keymanager"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestFileKeyManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keymanager-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "encryption.key")
+
+	km, err := NewFile(path)
+	assert.NilError(t, err)
+	fi, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fi.Mode().Perm(), os.FileMode(0600)))
+
+	wrapped, err := km.WrapKey("test", []byte("key"))
+	assert.NilError(t, err)
+
+	km, err = NewFile(path)
+	assert.NilError(t, err)
+	key, err := km.UnwrapKey("test", wrapped)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(key), "key"))
+
+	_, err = km.UnwrapKey("other", wrapped)
+	assert.Check(t, err != nil, "expected the wrapped key to be bound to its volume")
+}
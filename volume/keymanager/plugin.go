@@ -0,0 +1,77 @@
+package keymanager // import "github.com/docker/docker/volume/keymanager"
+
+import (
+	"github.com/docker/docker/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+const (
+	pluginImplements = "KeyManager"
+	wrapKeyPath      = "KeyManager.WrapKey"
+	unwrapKeyPath    = "KeyManager.UnwrapKey"
+)
+
+// WrapKeyRequest is the request of a key management plugin to wrap the key
+// of a volume.
+type WrapKeyRequest struct {
+	// ID is the id of the volume
+	ID string
+	// Key is the key of the volume
+	Key []byte
+}
+
+// WrapKeyResponse is the response of a key management plugin to a
+// WrapKeyRequest.
+type WrapKeyResponse struct {
+	// WrappedKey is the wrapped key, stored along the volume
+	WrappedKey []byte
+	// Err is the error of the plugin
+	Err string
+}
+
+// UnwrapKeyRequest is the request of a key management plugin to unwrap the
+// key of a volume.
+type UnwrapKeyRequest struct {
+	// ID is the id of the volume
+	ID string
+	// WrappedKey is the key returned by the plugin when it was wrapped
+	WrappedKey []byte
+}
+
+// UnwrapKeyResponse is the response of a key management plugin to an
+// UnwrapKeyRequest.
+type UnwrapKeyResponse struct {
+	// Key is the key of the volume
+	Key []byte
+	// Err is the error of the plugin
+	Err string
+}
+
+// pluginKeyManager is a key manager delegating to a key management plugin,
+// as an adapter to a KMS.
+type pluginKeyManager struct {
+	name   string
+	client *plugins.Client
+}
+
+func (p *pluginKeyManager) WrapKey(id string, key []byte) ([]byte, error) {
+	var resp WrapKeyResponse
+	if err := p.client.Call(wrapKeyPath, &WrapKeyRequest{ID: id, Key: key}, &resp); err != nil {
+		return nil, errors.Wrapf(err, "error wrapping the key of volume %s with key management plugin %s", id, p.name)
+	}
+	if resp.Err != "" {
+		return nil, errors.Errorf("error wrapping the key of volume %s with key management plugin %s: %s", id, p.name, resp.Err)
+	}
+	return resp.WrappedKey, nil
+}
+
+func (p *pluginKeyManager) UnwrapKey(id string, wrapped []byte) ([]byte, error) {
+	var resp UnwrapKeyResponse
+	if err := p.client.Call(unwrapKeyPath, &UnwrapKeyRequest{ID: id, WrappedKey: wrapped}, &resp); err != nil {
+		return nil, errors.Wrapf(err, "error unwrapping the key of volume %s with key management plugin %s", id, p.name)
+	}
+	if resp.Err != "" {
+		return nil, errors.Errorf("error unwrapping the key of volume %s with key management plugin %s: %s", id, p.name, resp.Err)
+	}
+	return resp.Key, nil
+}
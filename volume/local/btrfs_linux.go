@@ -0,0 +1,85 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// btrfs ioctls, from linux/btrfs.h
+const (
+	btrfsIocSnapDestroy  = 0x5000940f
+	btrfsIocSnapCreateV2 = 0x50009417
+
+	// btrfsFirstFreeObjectID is the inode number of the root directory of
+	// the subvolumes.
+	btrfsFirstFreeObjectID = 256
+)
+
+type btrfsVolArgs struct {
+	fd   int64
+	name [4088]byte
+}
+
+type btrfsVolArgsV2 struct {
+	fd      int64
+	transid uint64
+	flags   uint64
+	unused  [4]uint64
+	name    [4040]byte
+}
+
+func isBtrfsSubvolume(path string) bool {
+	var buf unix.Statfs_t
+	if err := unix.Statfs(path, &buf); err != nil || buf.Type != unix.BTRFS_SUPER_MAGIC {
+		return false
+	}
+	fi, err := os.Lstat(path)
+	if err != nil || !fi.IsDir() {
+		return false
+	}
+	return fi.Sys().(*syscall.Stat_t).Ino == btrfsFirstFreeObjectID
+}
+
+// btrfsSnapshot creates a snapshot of the subvolume src named name in the
+// directory dir.
+func btrfsSnapshot(src, dir, name string) error {
+	srcDir, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcDir.Close()
+	dstDir, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer dstDir.Close()
+
+	var args btrfsVolArgsV2
+	args.fd = int64(srcDir.Fd())
+	copy(args.name[:len(args.name)-1], name)
+	return btrfsIoctl(dstDir, btrfsIocSnapCreateV2, unsafe.Pointer(&args))
+}
+
+// btrfsDestroy destroys the subvolume at path.
+func btrfsDestroy(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	var args btrfsVolArgs
+	copy(args.name[:len(args.name)-1], filepath.Base(path))
+	return btrfsIoctl(dir, btrfsIocSnapDestroy, unsafe.Pointer(&args))
+}
+
+func btrfsIoctl(dir *os.File, req uintptr, args unsafe.Pointer) error {
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, dir.Fd(), req, uintptr(args)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const unmountNamespaceCommand = "docker-unmount-namespace"
+
+func init() {
+	reexec.Register(unmountNamespaceCommand, unmountNamespace)
+}
+
+// detachMounts lazily unmounts the mounts at or below path, with the mounts
+// of their filesystems leaked to other mount namespaces, as to containers
+// which did not release them. The processes holding these filesystems busy
+// are killed first if kill is set.
+//
+// The filesystems are identified by their device, and the ones which are
+// also mounted outside of path in the mount namespace of the daemon, as the
+// filesystem a volume is bind mounted from, are only unmounted from path.
+func detachMounts(path string, kill bool) error {
+	mounts, err := mount.GetMounts(nil)
+	if err != nil {
+		return err
+	}
+	var targets []string
+	devices := make(map[uint64]bool)
+	for _, m := range mounts {
+		if m.Mountpoint == path || strings.HasPrefix(m.Mountpoint, path+"/") {
+			targets = append(targets, m.Mountpoint)
+			if _, seen := devices[mountDevice(m)]; !seen {
+				devices[mountDevice(m)] = true
+			}
+		} else {
+			devices[mountDevice(m)] = false
+		}
+	}
+	for dev, exclusive := range devices {
+		if !exclusive {
+			delete(devices, dev)
+		}
+	}
+
+	if len(devices) != 0 {
+		pids, err := processes()
+		if err != nil {
+			return err
+		}
+		if kill {
+			for _, pid := range pids {
+				if holdsDevice(pid, devices) {
+					logrus.WithField("pid", pid).Infof("killing a process holding volume path '%s'", path)
+					if err := unix.Kill(pid, unix.SIGKILL); err != nil && err != unix.ESRCH {
+						return errors.Wrapf(err, "error killing process %d", pid)
+					}
+				}
+			}
+		}
+		if err := detachLeakedMounts(pids, devices); err != nil {
+			return err
+		}
+	}
+
+	// the mounts below others are unmounted first
+	sort.Sort(sort.Reverse(sort.StringSlice(targets)))
+	for _, target := range targets {
+		if err := mount.Unmount(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mountDevice(m *mount.Info) uint64 {
+	return unix.Mkdev(uint32(m.Major), uint32(m.Minor))
+}
+
+// processes returns the ids of the processes, except the daemon.
+func processes() ([]int, error) {
+	dirs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, d := range dirs {
+		if pid, err := strconv.Atoi(d.Name()); err == nil && pid != os.Getpid() {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// holdsDevice returns whether the process has its root, working directory,
+// executable or an open file on one of devices.
+func holdsDevice(pid int, devices map[uint64]bool) bool {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	links := []string{"root", "cwd", "exe"}
+	if fds, err := ioutil.ReadDir(filepath.Join(dir, "fd")); err == nil {
+		for _, fd := range fds {
+			links = append(links, filepath.Join("fd", fd.Name()))
+		}
+	}
+	for _, l := range links {
+		var st unix.Stat_t
+		if err := unix.Stat(filepath.Join(dir, l), &st); err == nil && devices[uint64(st.Dev)] {
+			return true
+		}
+	}
+	return false
+}
+
+// detachLeakedMounts lazily unmounts the mounts of devices which are in
+// other mount namespaces than the one of the daemon.
+func detachLeakedMounts(pids []int, devices map[uint64]bool) error {
+	self, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return err
+	}
+	namespaces := map[string]bool{self: true}
+	for _, pid := range pids {
+		ns, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid))
+		if err != nil || namespaces[ns] {
+			continue
+		}
+		namespaces[ns] = true
+
+		mounts, err := mount.PidMountInfo(pid)
+		if err != nil {
+			continue
+		}
+		var targets []string
+		for _, m := range mounts {
+			if devices[mountDevice(m)] {
+				targets = append(targets, m.Mountpoint)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(targets)))
+		if err := unmountInNamespace(pid, targets); err != nil {
+			return errors.Wrapf(err, "error unmounting the mounts leaked to the mount namespace of process %d", pid)
+		}
+	}
+	return nil
+}
+
+// unmountInNamespace lazily unmounts targets in the mount namespace of the
+// process pid, from a child process joining the namespace.
+func unmountInNamespace(pid int, targets []string) error {
+	cmd := reexec.Command(append([]string{unmountNamespaceCommand, fmt.Sprintf("/proc/%d/ns/mnt", pid)}, targets...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// unmountNamespace is the entrypoint of the child processes unmounting the
+// targets given as arguments in the mount namespace given as first argument.
+func unmountNamespace() {
+	runtime.LockOSThread()
+	if err := func() error {
+		ns, err := os.Open(os.Args[1])
+		if err != nil {
+			return err
+		}
+		defer ns.Close()
+		// the filesystem attributes of the thread must not be shared with
+		// the other threads for it to change mount namespace
+		if err := unix.Unshare(unix.CLONE_FS); err != nil {
+			return err
+		}
+		if err := unix.Setns(int(ns.Fd()), unix.CLONE_NEWNS); err != nil {
+			return err
+		}
+		for _, target := range os.Args[2:] {
+			if err := unix.Unmount(target, unix.MNT_DETACH); err != nil && err != unix.EINVAL && err != unix.ENOENT {
+				return &os.PathError{Op: "unmount", Path: target, Err: err}
+			}
+		}
+		return nil
+	}(); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,101 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/pkg/reexec"
+	"gotest.tools/skip"
+)
+
+func TestMain(m *testing.M) {
+	if reexec.Init() {
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestRemoveDetached(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "requires mounts")
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vol, err := r.Create("test", map[string]string{"device": "tmpfs", "type": "tmpfs", "o": "size=1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := vol.Mount("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(vol); err == nil {
+		t.Fatal("expected an error removing a mounted volume")
+	}
+
+	// a process working in the volume, and a mount namespace the mount of
+	// the volume leaked to
+	holder := exec.Command("sleep", "60")
+	holder.Dir = dir
+	if err := holder.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Process.Kill()
+
+	ready := filepath.Join(rootDir, "ready")
+	leaker := exec.Command("sh", "-c", "mount --make-rprivate / && touch "+ready+" && exec sleep 60")
+	leaker.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNS}
+	if err := leaker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		leaker.Process.Kill()
+		leaker.Wait()
+	}()
+	for i := 0; ; i++ {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if i == 100 {
+			t.Fatal("timeout waiting for the mount namespace to be set up")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := r.RemoveDetached(vol, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := holder.Wait(); err == nil || !strings.Contains(err.Error(), "killed") {
+		t.Fatalf("expected the process holding the volume to be killed, got %v", err)
+	}
+	mounts, err := mount.PidMountInfo(leaker.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mounts {
+		if m.Mountpoint == dir {
+			t.Fatalf("expected the leaked mount of the volume to be unmounted: %+v", m)
+		}
+	}
+	if _, err := os.Stat(filepath.Dir(dir)); !os.IsNotExist(err) {
+		t.Fatalf("expected the volume directory to be removed, got %v", err)
+	}
+	if _, err := r.Get("test"); err == nil {
+		t.Fatal("expected the volume to be removed")
+	}
+}
@@ -0,0 +1,272 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/volume/keymanager"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// The data of the encrypted volumes is encrypted by their filesystem
+// (fscrypt), with a v2 encryption policy set on the data directory of the
+// volumes, or on a data directory in the filesystem of the loopback-backed
+// volumes, which requires Linux 5.4. The key of a volume is added to the filesystem
+// when the volume is mounted.
+const (
+	fscryptKeySize = 64
+
+	fsIocAddEncryptionKey    = 0xc0506617
+	fsIocRemoveEncryptionKey = 0xc0406618
+
+	fscryptKeySpecTypeIdentifier = 2
+	fscryptPolicyV2              = 2
+	fscryptModeAES256XTS         = 1
+	fscryptModeAES256CTS         = 4
+	fscryptPolicyFlagsPad32      = 0x3
+)
+
+type fscryptKeySpecifier struct {
+	keyType    uint32
+	reserved   uint32
+	identifier [32]byte
+}
+
+// fscryptAddKeyArg is the fscrypt_add_key_arg structure, with the key.
+type fscryptAddKeyArg struct {
+	keySpec  fscryptKeySpecifier
+	rawSize  uint32
+	keyID    uint32
+	reserved [8]uint32
+	raw      [fscryptKeySize]byte
+}
+
+type fscryptRemoveKeyArg struct {
+	keySpec            fscryptKeySpecifier
+	removalStatusFlags uint32
+	reserved           [5]uint32
+}
+
+type fscryptPolicy struct {
+	version                 uint8
+	contentsEncryptionMode  uint8
+	filenamesEncryptionMode uint8
+	flags                   uint8
+	reserved                [4]uint8
+	masterKeyIdentifier     [16]byte
+}
+
+// addEncryptionKey adds key to the filesystem of path, returning its
+// identifier.
+func addEncryptionKey(path string, key []byte) ([16]byte, error) {
+	var id [16]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return id, err
+	}
+	defer f.Close()
+
+	arg := fscryptAddKeyArg{
+		keySpec: fscryptKeySpecifier{keyType: fscryptKeySpecTypeIdentifier},
+		rawSize: uint32(len(key)),
+	}
+	copy(arg.raw[:], key)
+	defer func() { arg.raw = [fscryptKeySize]byte{} }()
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIocAddEncryptionKey, uintptr(unsafe.Pointer(&arg))); errno != 0 {
+		if errno == unix.EOPNOTSUPP || errno == unix.ENOTTY {
+			return id, errdefs.NotImplemented(errors.New("the filesystem of the volume does not support encryption, a loopback-backed volume can be encrypted with the size option"))
+		}
+		return id, errors.Wrap(errno, "error adding the key of the volume to its filesystem")
+	}
+	copy(id[:], arg.keySpec.identifier[:])
+	return id, nil
+}
+
+// removeEncryptionKey removes the key with the given identifier from the
+// filesystem of path, the files it encrypts becoming inaccessible.
+func removeEncryptionKey(path string, id [16]byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	arg := fscryptRemoveKeyArg{keySpec: fscryptKeySpecifier{keyType: fscryptKeySpecTypeIdentifier}}
+	copy(arg.keySpec.identifier[:], id[:])
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIocRemoveEncryptionKey, uintptr(unsafe.Pointer(&arg))); errno != 0 {
+		return errors.Wrap(errno, "error removing the key of the volume from its filesystem")
+	}
+	return nil
+}
+
+// setEncryptionPolicy encrypts the directory at path, which must be empty,
+// with the key with the given identifier.
+func setEncryptionPolicy(path string, id [16]byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	policy := fscryptPolicy{
+		version:                 fscryptPolicyV2,
+		contentsEncryptionMode:  fscryptModeAES256XTS,
+		filenamesEncryptionMode: fscryptModeAES256CTS,
+		flags:                   fscryptPolicyFlagsPad32,
+		masterKeyIdentifier:     id,
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), unix.FS_IOC_SET_ENCRYPTION_POLICY, uintptr(unsafe.Pointer(&policy))); errno != 0 {
+		if errno == unix.EOPNOTSUPP || errno == unix.ENOTTY {
+			return errdefs.NotImplemented(errors.New("the filesystem of the volume does not support encryption"))
+		}
+		return errors.Wrap(errno, "error setting the encryption policy of the volume")
+	}
+	return nil
+}
+
+// SetEncryptionKeyFile sets the key file with which the keys of the encrypted
+// volumes are wrapped, unless they use a key management plugin. The key file
+// must not be on the filesystem of the volumes, which would then hold the
+// wrapped keys of the volumes along with the key unwrapping them.
+func (r *Root) SetEncryptionKeyFile(path string) error {
+	if !filepath.IsAbs(path) {
+		return errors.Errorf("the key file of the encrypted volumes must be an absolute path: %s", path)
+	}
+	var keyStat, rootStat unix.Stat_t
+	err := unix.Stat(path, &keyStat)
+	if os.IsNotExist(err) {
+		err = unix.Stat(filepath.Dir(path), &keyStat)
+	}
+	if err != nil {
+		return errors.Wrap(err, "error checking the key file of the encrypted volumes")
+	}
+	if err := unix.Stat(r.path, &rootStat); err != nil {
+		return errors.Wrap(err, "error checking the key file of the encrypted volumes")
+	}
+	if keyStat.Dev == rootStat.Dev {
+		return errors.Errorf("the key file of the encrypted volumes must not be on the filesystem of the volumes: %s", path)
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.encryptionKeyFile = path
+	for _, v := range r.volumes {
+		if v.encryption != nil {
+			v.encryption.masterKey = path
+		}
+	}
+	return nil
+}
+
+// keyManager returns the key manager of the encrypted volumes with the
+// given key management plugin, or with the key file of the driver.
+func (r *Root) keyManager(plugin string) (keymanager.KeyManager, error) {
+	if plugin != "" {
+		return keymanager.Get(plugin)
+	}
+	if r.encryptionKeyFile == "" {
+		return nil, errdefs.InvalidParameter(errors.New("an encrypted volume needs the kms option when the daemon has no key file for the encrypted volumes, set with its volume-encryption-key-file option"))
+	}
+	return keymanager.NewFile(r.encryptionKeyFile)
+}
+
+// setupEncryption encrypts the new volume v with a new key, protected by the
+// key manager of the plugin.
+func (r *Root) setupEncryption(v *localVolume, plugin string) error {
+	km, err := r.keyManager(plugin)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, fscryptKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+	wrapped, err := km.WrapKey(v.name, key)
+	if err != nil {
+		return err
+	}
+
+	dir := v.path
+	if v.opts != nil {
+		// the data is in a directory of the filesystem of the image, its root
+		// cannot be encrypted
+		if err := mountOpts(v.opts, v.path); err != nil {
+			return errdefs.System(err)
+		}
+		defer mount.Unmount(v.path)
+		dir = filepath.Join(v.path, VolumeDataPathName)
+		if err := idtools.MkdirAndChown(dir, 0755, r.rootIdentity); err != nil {
+			return errdefs.System(err)
+		}
+	}
+	id, err := addEncryptionKey(v.path, key)
+	if err != nil {
+		if errdefs.IsNotImplemented(err) {
+			return err
+		}
+		return errdefs.System(err)
+	}
+	if err := setEncryptionPolicy(dir, id); err != nil {
+		removeEncryptionKey(v.path, id)
+		return err
+	}
+
+	c := &encryptionConfig{KeyManager: plugin, WrappedKey: wrapped, Identifier: hex.EncodeToString(id[:])}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(v.path), encryptionConfigFileName), b, 0600); err != nil {
+		return errdefs.System(errors.Wrap(err, "error while persisting the encryption of the volume"))
+	}
+	c.masterKey = r.encryptionKeyFile
+	v.encryption = c
+	return nil
+}
+
+// unlock adds the key of the encrypted volume to its filesystem, which must
+// be mounted. The volume must be locked.
+func (v *localVolume) unlock() error {
+	var (
+		km  keymanager.KeyManager
+		err error
+	)
+	switch {
+	case v.encryption.KeyManager != "":
+		km, err = keymanager.Get(v.encryption.KeyManager)
+	case v.encryption.masterKey == "":
+		return errors.New("the daemon has no key file for the encrypted volumes, set with its volume-encryption-key-file option")
+	default:
+		km, err = keymanager.NewFile(v.encryption.masterKey)
+	}
+	if err != nil {
+		return err
+	}
+	key, err := km.UnwrapKey(v.name, v.encryption.WrappedKey)
+	if err != nil {
+		return err
+	}
+	_, err = addEncryptionKey(v.path, key)
+	return err
+}
+
+// lock removes the key of the encrypted volume from its filesystem.
+func (v *localVolume) lock() error {
+	b, err := hex.DecodeString(v.encryption.Identifier)
+	if err != nil || len(b) != 16 {
+		return errors.Errorf("invalid identifier of the key of the volume: %q", v.encryption.Identifier)
+	}
+	var id [16]byte
+	copy(id[:], b)
+	return removeEncryptionKey(v.path, id)
+}
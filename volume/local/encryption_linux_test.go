@@ -0,0 +1,80 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/mount"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/skip"
+)
+
+func TestCreateEncrypted(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "requires mounts")
+	_, err := exec.LookPath("mkfs." + loopImageFsType)
+	skip.If(t, err != nil, "requires mkfs.%s", loopImageFsType)
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+
+	_, err = r.Create("test", map[string]string{"encrypted": "true", "type": "tmpfs", "device": "tmpfs"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected the encryption not to be combined with mount options, got %v", err)
+	_, err = r.Create("test", map[string]string{"kms": "plugin"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected the kms option to require the encrypted option, got %v", err)
+	_, err = r.Create("test", map[string]string{"encrypted": "true", "size": "16m"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected the encryption to require a key file or the kms option, got %v", err)
+
+	// the key file must not be on the filesystem of the volumes
+	assert.Check(t, is.ErrorContains(r.SetEncryptionKeyFile(filepath.Join(rootDir, "encryption.key")), "must not be on the filesystem of the volumes"))
+	keyDir, err := ioutil.TempDir("", "local-volume-key-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(keyDir)
+	assert.NilError(t, mount.Mount("tmpfs", keyDir, "tmpfs", ""))
+	defer mount.Unmount(keyDir)
+	keyFile := filepath.Join(keyDir, "encryption.key")
+	assert.NilError(t, r.SetEncryptionKeyFile(keyFile))
+
+	vol, err := r.Create("test", map[string]string{"encrypted": "true", "size": "16m"})
+	if errdefs.IsNotImplemented(err) {
+		t.Skip(err)
+	}
+	assert.NilError(t, err)
+	v := vol.(*localVolume)
+	assert.Check(t, is.Equal(v.Status()["Encrypted"], true))
+	_, err = v.Snapshot("snap")
+	assert.Check(t, errdefs.IsNotImplemented(err), "expected snapshots of encrypted volumes not to be supported, got %v", err)
+
+	marker := bytes.Repeat([]byte("unencrypted data of the volume "), 1024)
+	dir, err := v.Mount("1234")
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "data"), marker, 0644))
+	assert.NilError(t, v.Unmount("1234"))
+
+	image, err := ioutil.ReadFile(v.opts.MountDevice)
+	assert.NilError(t, err)
+	assert.Check(t, !bytes.Contains(image, marker[:64]), "expected the data of the volume to be encrypted in its image")
+
+	r, err = New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	v = r.volumes["test"]
+	assert.Assert(t, v.encryption != nil)
+	_, err = v.Mount("1234")
+	assert.Check(t, is.ErrorContains(err, "no key file for the encrypted volumes"))
+	assert.NilError(t, r.SetEncryptionKeyFile(keyFile))
+	dir, err = v.Mount("1234")
+	assert.NilError(t, err)
+	defer v.Unmount("1234")
+	data, err := ioutil.ReadFile(filepath.Join(dir, "data"))
+	assert.NilError(t, err)
+	assert.Check(t, bytes.Equal(data, marker), "unexpected data of the volume after it was unlocked")
+}
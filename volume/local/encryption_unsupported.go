@@ -0,0 +1,26 @@
+// +build !linux
+
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+// SetEncryptionKeyFile sets the key file of the encrypted volumes, which are
+// not supported on this platform.
+func (r *Root) SetEncryptionKeyFile(path string) error {
+	return errdefs.NotImplemented(errors.New("encrypted volumes are not supported on this platform"))
+}
+
+func (r *Root) setupEncryption(v *localVolume, plugin string) error {
+	return errdefs.NotImplemented(errors.New("encrypted volumes are not supported on this platform"))
+}
+
+func (v *localVolume) unlock() error {
+	return errdefs.NotImplemented(errors.New("encrypted volumes are not supported on this platform"))
+}
+
+func (v *localVolume) lock() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+// +build linux freebsd
+
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"time"
+
+	"github.com/docker/docker/pkg/mount"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// The mounts of the volumes mounted from a network filesystem are health
+// checked while they are in use, by querying the filesystem, so that a
+// server which is unreachable, or a mount which disappeared, is reported by
+// the status of the volume.
+var (
+	healthCheckInterval = 30 * time.Second
+	healthCheckTimeout  = 10 * time.Second
+)
+
+// startHealthCheck starts the health checks of the mount of the volume. The
+// volume must be locked.
+func (v *localVolume) startHealthCheck() {
+	v.stopHealthCheck()
+	stop := make(chan struct{})
+	v.healthStop = stop
+	v.health = mountHealth{healthy: true, checkedAt: time.Now()}
+	go v.healthCheck(stop)
+}
+
+// stopHealthCheck stops the health checks of the mount of the volume, if
+// any. The volume must be locked.
+func (v *localVolume) stopHealthCheck() {
+	if v.healthStop != nil {
+		close(v.healthStop)
+		v.healthStop = nil
+	}
+}
+
+func (v *localVolume) healthCheck(stop chan struct{}) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	// The file system is queried in its own goroutine, which blocks as long
+	// as the server does not respond. It is not queried again until it
+	// responds.
+	var pending chan error
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if pending == nil {
+			pending = make(chan error, 1)
+			go func(result chan error) {
+				result <- checkMount(v.path)
+			}(pending)
+		}
+		var err error
+		select {
+		case err = <-pending:
+			pending = nil
+		case <-time.After(healthCheckTimeout):
+			err = errors.Errorf("no response from the filesystem after %s", healthCheckTimeout)
+		case <-stop:
+			return
+		}
+
+		v.m.Lock()
+		select {
+		case <-stop:
+			v.m.Unlock()
+			return
+		default:
+		}
+		if err != nil && v.health.healthy {
+			logrus.WithError(err).WithField("volume", v.name).Warn("Volume mount is unhealthy")
+		} else if err == nil && !v.health.healthy {
+			logrus.WithField("volume", v.name).Info("Volume mount is healthy again")
+		}
+		v.health = mountHealth{healthy: err == nil, checkedAt: time.Now(), err: err}
+		v.m.Unlock()
+	}
+}
+
+func checkMount(path string) error {
+	mounted, err := mount.Mounted(path)
+	if err != nil {
+		return err
+	}
+	if !mounted {
+		return errors.New("volume is not mounted")
+	}
+	var buf unix.Statfs_t
+	return unix.Statfs(path, &buf)
+}
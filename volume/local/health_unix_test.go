@@ -0,0 +1,88 @@
+// +build linux freebsd
+
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/mount"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/poll"
+	"gotest.tools/skip"
+)
+
+func TestCreateValidatesNetworkOpts(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+
+	for _, opts := range []map[string]string{
+		{"type": "nfs", "device": ":/export", "o": "rw"},
+		{"type": "nfs", "device": "/export", "o": "addr=192.0.2.1,rw"},
+		{"type": "cifs", "device": "server/share", "o": "addr=192.0.2.1"},
+		{"type": "cifs", "device": "//server", "o": "addr=192.0.2.1"},
+	} {
+		_, err := r.Create("test", opts)
+		assert.Check(t, errdefs.IsInvalidParameter(err), "expected options %v to be invalid, got %v", opts, err)
+	}
+	_, err = os.Stat(r.DataPath("test"))
+	assert.Check(t, os.IsNotExist(err), "expected the volume not to be created, got %v", err)
+}
+
+func TestMountHealthCheck(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "requires mounts")
+	defer func(interval, timeout time.Duration) {
+		healthCheckInterval, healthCheckTimeout = interval, timeout
+	}(healthCheckInterval, healthCheckTimeout)
+	healthCheckInterval = 10 * time.Millisecond
+	healthCheckTimeout = time.Second
+
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	vol, err := r.Create("test", map[string]string{"device": "tmpfs", "type": "tmpfs", "o": "size=1m"})
+	assert.NilError(t, err)
+	v := vol.(*localVolume)
+
+	status := v.Status()
+	assert.Check(t, is.Equal(status["Mounted"], false))
+	assert.Check(t, is.Nil(status["Health"]))
+
+	// tmpfs is not health checked, the checks are started as for a network
+	// volume.
+	_, err = v.Mount("1234")
+	assert.NilError(t, err)
+	v.m.Lock()
+	v.startHealthCheck()
+	v.m.Unlock()
+
+	status = v.Status()
+	assert.Check(t, is.Equal(status["Mounted"], true))
+	assert.Check(t, is.Equal(status["Health"], "healthy"))
+
+	assert.NilError(t, mount.Unmount(v.path))
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if st := v.Status(); st["Health"] != "unhealthy" {
+			return poll.Continue("volume health is %v", st["Health"])
+		}
+		return poll.Success()
+	}, poll.WithDelay(10*time.Millisecond), poll.WithTimeout(5*time.Second))
+	assert.Check(t, is.Equal(v.Status()["HealthError"], "volume is not mounted"))
+
+	assert.NilError(t, v.Unmount("1234"))
+	status = v.Status()
+	assert.Check(t, is.Equal(status["Mounted"], false))
+	assert.Check(t, is.Nil(status["Health"]))
+}
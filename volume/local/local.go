@@ -10,15 +10,19 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/docker/daemon/names"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/volume"
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // VolumeDataPathName is the name of the directory where the volume data is stored.
@@ -27,6 +31,10 @@ import (
 const (
 	VolumeDataPathName = "_data"
 	volumesPathName    = "volumes"
+
+	// encryptionConfigFileName is the name of the file of the encryption
+	// configuration of the encrypted volumes, in their directory
+	encryptionConfigFileName = "encryption.json"
 )
 
 var (
@@ -60,6 +68,8 @@ func New(scope string, rootIdentity idtools.Identity) (*Root, error) {
 		rootIdentity: rootIdentity,
 	}
 
+	setupDriverQuota(r)
+
 	dirs, err := ioutil.ReadDir(rootDirectory)
 	if err != nil {
 		return nil, err
@@ -92,6 +102,14 @@ func New(scope string, rootIdentity idtools.Identity) (*Root, error) {
 			// unmount anything that may still be mounted (for example, from an unclean shutdown)
 			mount.Unmount(v.path)
 		}
+		if b, err := ioutil.ReadFile(filepath.Join(rootDirectory, name, encryptionConfigFileName)); err == nil {
+			c := &encryptionConfig{}
+			if err := json.Unmarshal(b, c); err != nil {
+				return nil, errors.Wrapf(err, "error while unmarshaling the encryption of volume: %s", name)
+			}
+			v.encryption = c
+		}
+		r.loadSize(v, filepath.Join(rootDirectory, name))
 	}
 
 	return r, nil
@@ -106,6 +124,10 @@ type Root struct {
 	path         string
 	volumes      map[string]*localVolume
 	rootIdentity idtools.Identity
+	// encryptionKeyFile is the key file with which the keys of the encrypted
+	// volumes are wrapped, unless they use a key management plugin
+	encryptionKeyFile string
+	driverQuota
 }
 
 // List lists all the volumes
@@ -137,6 +159,29 @@ func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error
 		return nil, err
 	}
 
+	encrypted, keyManager, opts, err := parseEncryptionOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	size, opts, err := parseSizeOpt(opts)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted && len(opts) != 0 {
+		return nil, validationError("the encrypted option of a volume cannot be combined with mount options")
+	}
+
+	// The options are validated without holding the lock of the driver, the
+	// test mount of the network volumes blocking until the server responds.
+	r.m.Lock()
+	_, exists := r.volumes[name]
+	r.m.Unlock()
+	if !exists && len(opts) != 0 {
+		if err := validateMountOpts(opts); err != nil {
+			return nil, err
+		}
+	}
+
 	r.m.Lock()
 	defer r.m.Unlock()
 
@@ -146,33 +191,56 @@ func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error
 	}
 
 	path := r.DataPath(name)
-	if err := idtools.MkdirAllAndChown(path, 0755, r.rootIdentity); err != nil {
+	dir := filepath.Dir(path)
+	if err := idtools.MkdirAllAndChown(dir, 0755, r.rootIdentity); err != nil {
 		return nil, errors.Wrapf(errdefs.System(err), "error while creating volume path '%s'", path)
 	}
 
-	var err error
 	defer func() {
 		if err != nil {
-			os.RemoveAll(filepath.Dir(path))
+			os.RemoveAll(dir)
 		}
 	}()
 
+	// The project quota of the volume is set on its directory before its
+	// data directory is created, which inherits the project of its parent.
+	if size != 0 && r.quotaSupported() {
+		if err = r.setupQuota(dir, size); err != nil {
+			return nil, errdefs.System(errors.Wrap(err, "error while setting the size of the volume"))
+		}
+	}
+	if err = idtools.MkdirAndChown(path, 0755, r.rootIdentity); err != nil {
+		return nil, errors.Wrapf(errdefs.System(err), "error while creating volume path '%s'", path)
+	}
+
 	v = &localVolume{
 		driverName: r.Name(),
 		name:       name,
 		path:       path,
+		size:       size,
 	}
 
 	if len(opts) != 0 {
 		if err = setOpts(v, opts); err != nil {
 			return nil, err
 		}
+	} else if size != 0 && !r.quotaSupported() {
+		if v.opts, err = createLoopImage(dir, size, r.rootIdentity.UID, r.rootIdentity.GID, encrypted); err != nil {
+			return nil, err
+		}
+	}
+	if encrypted {
+		if err = r.setupEncryption(v, keyManager); err != nil {
+			return nil, err
+		}
+	}
+	if v.opts != nil {
 		var b []byte
 		b, err = json.Marshal(v.opts)
 		if err != nil {
 			return nil, err
 		}
-		if err = ioutil.WriteFile(filepath.Join(filepath.Dir(path), "opts.json"), b, 600); err != nil {
+		if err = ioutil.WriteFile(filepath.Join(dir, "opts.json"), b, 600); err != nil {
 			return nil, errdefs.System(errors.Wrap(err, "error while persisting volume options"))
 		}
 	}
@@ -201,6 +269,44 @@ func (r *Root) Remove(v volume.Volume) error {
 	if err := lv.unmount(); err != nil {
 		return err
 	}
+	return r.remove(lv)
+}
+
+// RemoveDetached removes the specified volume like Remove, regardless of
+// its references, after having lazily unmounted the mounts of the volume,
+// in the mount namespace of the daemon and in the ones they leaked to.
+// The processes holding the filesystem the volume is mounted from are
+// killed if kill is set.
+func (r *Root) RemoveDetached(v volume.Volume, kill bool) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	lv, ok := v.(*localVolume)
+	if !ok {
+		return errdefs.System(errors.Errorf("unknown volume type %T", v))
+	}
+
+	if err := detachMounts(filepath.Dir(lv.path), kill); err != nil {
+		return errdefs.System(errors.Wrapf(err, "error while detaching the mounts of volume path '%s'", lv.path))
+	}
+	lv.m.Lock()
+	lv.active = activeMount{}
+	lv.stopHealthCheck()
+	lv.m.Unlock()
+	return r.remove(lv)
+}
+
+func (r *Root) remove(lv *localVolume) error {
+	if err := lv.removeSnapshots(); err != nil {
+		return err
+	}
+
+	if lv.encryption != nil && lv.opts == nil {
+		// the files of the volume can be removed without its key
+		if err := lv.lock(); err != nil {
+			logrus.WithError(err).WithField("volume", lv.name).Debug("error removing the key of the volume from its filesystem")
+		}
+	}
 
 	realPath, err := filepath.EvalSymlinks(lv.path)
 	if err != nil {
@@ -278,8 +384,38 @@ type localVolume struct {
 	driverName string
 	// opts is the parsed list of options used to create the volume
 	opts *optsConfig
+	// size is the size of the volume set with the size option
+	size uint64
+	// encryption is the encryption of the volumes created with the encrypted
+	// option
+	encryption *encryptionConfig
 	// active refcounts the active mounts
 	active activeMount
+	// health is the result of the last health check of the mount of the
+	// network volumes
+	health mountHealth
+	// healthStop stops the health checks of the mount
+	healthStop chan struct{}
+}
+
+// encryptionConfig is the encryption of an encrypted volume.
+type encryptionConfig struct {
+	// KeyManager is the name of the key management plugin protecting the key
+	// of the volume, which is protected by the key file of the driver if empty
+	KeyManager string `json:",omitempty"`
+	// WrappedKey is the key of the volume, wrapped by its key manager
+	WrappedKey []byte
+	// Identifier is the identifier of the key in the filesystem
+	Identifier string
+	// masterKey is the path of the key file of the driver, set with
+	// SetEncryptionKeyFile
+	masterKey string
+}
+
+type mountHealth struct {
+	healthy   bool
+	checkedAt time.Time
+	err       error
 }
 
 // Name returns the name of the given Volume.
@@ -294,11 +430,21 @@ func (v *localVolume) DriverName() string {
 
 // Path returns the data location.
 func (v *localVolume) Path() string {
-	return v.path
+	return v.dataPath()
 }
 
 // CachedPath returns the data location
 func (v *localVolume) CachedPath() string {
+	return v.dataPath()
+}
+
+// dataPath returns the location of the data of the volume, which is a
+// directory in the filesystem of the encrypted loopback-backed volumes, as the
+// root of a filesystem cannot be encrypted.
+func (v *localVolume) dataPath() string {
+	if v.encryption != nil && v.opts != nil {
+		return filepath.Join(v.path, VolumeDataPathName)
+	}
 	return v.path
 }
 
@@ -316,7 +462,17 @@ func (v *localVolume) Mount(id string) (string, error) {
 		}
 		v.active.count++
 	}
-	return v.path, nil
+	if v.encryption != nil {
+		if err := v.unlock(); err != nil {
+			if v.opts != nil {
+				if v.active.count--; v.active.count == 0 {
+					v.unmount()
+				}
+			}
+			return "", errdefs.System(errors.Wrap(err, "error unlocking the encrypted volume"))
+		}
+	}
+	return v.dataPath(), nil
 }
 
 // Unmount dereferences the id, and if it is the last reference will unmount any resources
@@ -348,10 +504,59 @@ func (v *localVolume) unmount() error {
 			}
 		}
 		v.active.mounted = false
+		v.stopHealthCheck()
 	}
 	return nil
 }
 
+// parseSizeOpt returns the size of the volume set by the size option, and the
+// other options.
+func parseSizeOpt(opts map[string]string) (uint64, map[string]string, error) {
+	sizeOpt, ok := opts["size"]
+	if !ok {
+		return 0, opts, nil
+	}
+	size, err := units.RAMInBytes(sizeOpt)
+	if err != nil || size <= 0 {
+		return 0, nil, validationError(fmt.Sprintf("invalid size option %q for a volume, the size must be a positive number of bytes such as 10G", sizeOpt))
+	}
+	others := make(map[string]string, len(opts)-1)
+	for k, v := range opts {
+		if k != "size" {
+			others[k] = v
+		}
+	}
+	if len(others) != 0 {
+		return 0, nil, validationError("the size option of a volume cannot be combined with mount options")
+	}
+	return uint64(size), others, nil
+}
+
+// parseEncryptionOpts returns whether the volume is encrypted by the
+// encrypted option, the key management plugin set by the kms option, and the
+// other options.
+func parseEncryptionOpts(opts map[string]string) (bool, string, map[string]string, error) {
+	encryptedOpt, ok := opts["encrypted"]
+	kms, hasKMS := opts["kms"]
+	if !ok && !hasKMS {
+		return false, "", opts, nil
+	}
+	encrypted, err := strconv.ParseBool(encryptedOpt)
+	if ok && err != nil {
+		return false, "", nil, validationError(fmt.Sprintf("invalid encrypted option %q for a volume", encryptedOpt))
+	}
+	if hasKMS && (!encrypted || kms == "") {
+		return false, "", nil, validationError("the kms option of a volume requires the encrypted option, and the name of a key management plugin")
+	}
+	others := make(map[string]string, len(opts))
+	for k, v := range opts {
+		if k != "encrypted" && k != "kms" {
+			others[k] = v
+		}
+	}
+	return encrypted, kms, others, nil
+}
+
 func validateOpts(opts map[string]string) error {
 	for opt := range opts {
 		if !validOpts[opt] {
@@ -361,8 +566,45 @@ func validateOpts(opts map[string]string) error {
 	return nil
 }
 
+// Status returns the size and the disk usage of the volumes with a size, the
+// status of the mount of the volumes with mount options, and the result of the
+// last health check of the mounts of the network volumes.
 func (v *localVolume) Status() map[string]interface{} {
-	return nil
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.opts == nil && v.size == 0 && v.encryption == nil {
+		return nil
+	}
+	status := map[string]interface{}{}
+	if v.encryption != nil {
+		status["Encrypted"] = true
+		if v.encryption.KeyManager != "" {
+			status["KeyManager"] = v.encryption.KeyManager
+		}
+	}
+	if v.size != 0 {
+		status["Size"] = v.size
+		if v.opts == nil || v.active.mounted {
+			if usage, err := diskUsage(v.path); err == nil {
+				status["Usage"] = usage
+			}
+		}
+	}
+	if v.opts == nil {
+		return status
+	}
+	status["Mounted"] = v.active.mounted
+	if v.active.mounted && v.healthStop != nil {
+		status["Health"] = "healthy"
+		if !v.health.healthy {
+			status["Health"] = "unhealthy"
+		}
+		status["HealthCheckedAt"] = v.health.checkedAt.Format(time.RFC3339Nano)
+		if v.health.err != nil {
+			status["HealthError"] = v.health.err.Error()
+		}
+	}
+	return status
 }
 
 // getAddress finds out address/hostname from options
@@ -7,6 +7,7 @@ package local // import "github.com/docker/docker/volume/local"
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
@@ -22,6 +23,16 @@ import (
 var (
 	oldVfsDir = filepath.Join("vfs", "dir")
 
+	// networkMountTypes are the filesystem types of the volumes mounted from
+	// a remote server, whose options are validated with a test mount and
+	// whose mounts are health checked.
+	networkMountTypes = map[string]bool{
+		"nfs":  true,
+		"nfs4": true,
+		"cifs": true,
+		"smb3": true,
+	}
+
 	validOpts = map[string]bool{
 		"type":   true, // specify the filesystem type for mount, e.g. nfs
 		"o":      true, // generic mount options
@@ -33,6 +44,13 @@ type optsConfig struct {
 	MountType   string
 	MountOpts   string
 	MountDevice string
+	// Size is the size of the image of the loopback-backed volumes, whose
+	// device is the image file
+	Size uint64 `json:",omitempty"`
+}
+
+func isNetworkMount(o *optsConfig) bool {
+	return o != nil && networkMountTypes[o.MountType]
 }
 
 func (o *optsConfig) String() string {
@@ -72,12 +90,25 @@ func setOpts(v *localVolume, opts map[string]string) error {
 }
 
 func (v *localVolume) mount() error {
-	if v.opts.MountDevice == "" {
+	if err := mountOpts(v.opts, v.path); err != nil {
+		return err
+	}
+	if isNetworkMount(v.opts) {
+		v.startHealthCheck()
+	}
+	return nil
+}
+
+func mountOpts(o *optsConfig, target string) error {
+	if o.Size != 0 {
+		return mountLoop(o.MountDevice, o.MountType, target)
+	}
+	if o.MountDevice == "" {
 		return fmt.Errorf("missing device in volume options")
 	}
-	mountOpts := v.opts.MountOpts
-	if v.opts.MountType == "nfs" {
-		if addrValue := getAddress(v.opts.MountOpts); addrValue != "" && net.ParseIP(addrValue).To4() == nil {
+	mountOpts := o.MountOpts
+	if o.MountType == "nfs" {
+		if addrValue := getAddress(o.MountOpts); addrValue != "" && net.ParseIP(addrValue).To4() == nil {
 			ipAddr, err := net.ResolveIPAddr("ip", addrValue)
 			if err != nil {
 				return errors.Wrapf(err, "error resolving passed in nfs address")
@@ -85,8 +116,49 @@ func (v *localVolume) mount() error {
 			mountOpts = strings.Replace(mountOpts, "addr="+addrValue, "addr="+ipAddr.String(), 1)
 		}
 	}
-	err := mount.Mount(v.opts.MountDevice, v.path, v.opts.MountType, mountOpts)
-	return errors.Wrapf(err, "error while mounting volume with options: %s", v.opts)
+	err := mount.Mount(o.MountDevice, target, o.MountType, mountOpts)
+	return errors.Wrapf(err, "error while mounting volume with options: %s", o)
+}
+
+// validateMountOpts validates the options of the volumes mounted from a
+// network filesystem, and mounts them once on a temporary directory to report
+// the errors in the options, or the unavailability of the server, when the
+// volume is created rather than when a container is started.
+func validateMountOpts(opts map[string]string) error {
+	o := &optsConfig{
+		MountType:   opts["type"],
+		MountOpts:   opts["o"],
+		MountDevice: opts["device"],
+	}
+	if !isNetworkMount(o) {
+		return nil
+	}
+	switch o.MountType {
+	case "nfs", "nfs4":
+		if !strings.Contains(o.MountDevice, ":/") {
+			return validationError(fmt.Sprintf("invalid device %q for a %s volume, the device must be a remote path such as :/path/to/dir", o.MountDevice, o.MountType))
+		}
+		if getAddress(o.MountOpts) == "" {
+			return validationError(fmt.Sprintf("missing addr option for a %s volume, the address of the server must be passed with the o=addr=<address> option", o.MountType))
+		}
+	case "cifs", "smb3":
+		if !strings.HasPrefix(o.MountDevice, "//") || len(strings.SplitN(strings.TrimPrefix(o.MountDevice, "//"), "/", 2)) != 2 {
+			return validationError(fmt.Sprintf("invalid device %q for a %s volume, the device must be a share such as //server/share", o.MountDevice, o.MountType))
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "docker-volume-probe")
+	if err != nil {
+		return errors.Wrap(err, "error while creating the directory to validate the volume options")
+	}
+	defer os.Remove(dir)
+	if err := mountOpts(o, dir); err != nil {
+		return validationError(fmt.Sprintf("invalid volume options, the test mount failed: %v", err))
+	}
+	if err := mount.Unmount(dir); err != nil {
+		return errors.Wrapf(err, "error while unmounting the test mount of the volume")
+	}
+	return nil
 }
 
 func (v *localVolume) CreatedAt() (time.Time, error) {
@@ -97,3 +169,14 @@ func (v *localVolume) CreatedAt() (time.Time, error) {
 	sec, nsec := fileInfo.Sys().(*syscall.Stat_t).Ctim.Unix()
 	return time.Unix(sec, nsec), nil
 }
+
+// diskUsage returns the space used in the filesystem of path, which is the
+// space used by the project of path on the filesystems supporting project
+// quotas.
+func diskUsage(path string) (uint64, error) {
+	var buf syscall.Statfs_t
+	if err := syscall.Statfs(path, &buf); err != nil {
+		return 0, err
+	}
+	return (buf.Blocks - buf.Bfree) * uint64(buf.Bsize), nil
+}
@@ -36,6 +36,16 @@ func (v *localVolume) mount() error {
 	return nil
 }
 
+func validateMountOpts(opts map[string]string) error {
+	return nil
+}
+
+func (v *localVolume) stopHealthCheck() {}
+
+func diskUsage(path string) (uint64, error) {
+	return 0, fmt.Errorf("disk usage is not supported on this platform")
+}
+
 func (v *localVolume) CreatedAt() (time.Time, error) {
 	fileInfo, err := os.Stat(v.path)
 	if err != nil {
@@ -0,0 +1,100 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/daemon/graphdriver/quota"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/loopback"
+	"github.com/docker/docker/pkg/mount"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// The size of the volumes created with the size option is enforced with a
+// project quota on the directory of the volume, when the filesystem of the
+// volumes supports project quotas, or else by storing the data of the
+// volume in an ext4 filesystem in a sparse image file, mounted on a loop
+// device while the volume is in use.
+const (
+	loopImageName   = "disk.img"
+	loopImageFsType = "ext4"
+)
+
+type driverQuota struct {
+	quotaCtl *quota.Control
+}
+
+func setupDriverQuota(r *Root) {
+	if quotaCtl, err := quota.NewControl(r.path); err == nil {
+		r.quotaCtl = quotaCtl
+	} else if err != quota.ErrQuotaNotSupported {
+		logrus.Warnf("Unable to setup quota: %v\n", err)
+	}
+}
+
+func (r *Root) quotaSupported() bool {
+	return r.quotaCtl != nil
+}
+
+func (r *Root) setupQuota(dir string, size uint64) error {
+	return r.quotaCtl.SetQuota(dir, quota.Quota{Size: size})
+}
+
+// loadSize sets the size of a volume found on the initialization of the
+// driver.
+func (r *Root) loadSize(v *localVolume, dir string) {
+	if v.opts != nil {
+		v.size = v.opts.Size
+		return
+	}
+	if r.quotaCtl != nil {
+		var q quota.Quota
+		if err := r.quotaCtl.GetQuota(dir, &q); err == nil {
+			v.size = q.Size
+		}
+	}
+}
+
+// createLoopImage creates the sparse image file of the volume in dir with the
+// given size, and formats it, with the support for encryption if encrypted is
+// set.
+func createLoopImage(dir string, size uint64, rootUID, rootGID int, encrypted bool) (*optsConfig, error) {
+	if _, err := exec.LookPath("mkfs." + loopImageFsType); err != nil {
+		return nil, errdefs.NotImplemented(errors.Errorf("the filesystem of the volumes does not support project quotas, and mkfs.%s is not available to create a loopback-backed volume", loopImageFsType))
+	}
+	path := filepath.Join(dir, loopImageName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, errdefs.System(errors.Wrap(err, "error while creating the image of the volume"))
+	}
+	err = f.Truncate(int64(size))
+	f.Close()
+	if err != nil {
+		return nil, errdefs.System(errors.Wrap(err, "error while creating the image of the volume"))
+	}
+	args := []string{"-q", "-F", "-E", fmt.Sprintf("root_owner=%d:%d,nodiscard", rootUID, rootGID)}
+	if encrypted {
+		args = append(args, "-O", "encrypt")
+	}
+	out, err := exec.Command("mkfs."+loopImageFsType, append(args, path)...).CombinedOutput()
+	if err != nil {
+		return nil, errdefs.System(errors.Wrapf(err, "error while formatting the image of the volume: %s", strings.TrimSpace(string(out))))
+	}
+	return &optsConfig{MountType: loopImageFsType, MountDevice: path, Size: size}, nil
+}
+
+// mountLoop mounts the filesystem of the image file on target. The loop
+// device is released when the filesystem is unmounted.
+func mountLoop(image, fsType, target string) error {
+	loop, err := loopback.AttachLoopDevice(image)
+	if err != nil {
+		return errors.Wrapf(err, "error while attaching a loop device to %s", image)
+	}
+	defer loop.Close()
+	return errors.Wrapf(mount.Mount(loop.Name(), target, fsType, ""), "error while mounting the image of the volume %s", image)
+}
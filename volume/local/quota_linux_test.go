@@ -0,0 +1,53 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/skip"
+)
+
+func TestCreateWithSize(t *testing.T) {
+	skip.If(t, os.Getuid() != 0, "requires mounts")
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	if !r.quotaSupported() {
+		_, err := exec.LookPath("mkfs." + loopImageFsType)
+		skip.If(t, err != nil, "requires project quotas or mkfs.%s", loopImageFsType)
+	}
+
+	_, err = r.Create("test", map[string]string{"size": "16m", "type": "tmpfs"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected the size not to be combined with mount options, got %v", err)
+	_, err = r.Create("test", map[string]string{"size": "-1"})
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected an invalid size, got %v", err)
+
+	vol, err := r.Create("test", map[string]string{"size": "16m"})
+	assert.NilError(t, err)
+	v := vol.(*localVolume)
+	assert.Check(t, is.Equal(v.Status()["Size"], uint64(16*1024*1024)))
+
+	dir, err := v.Mount("1234")
+	assert.NilError(t, err)
+	defer v.Unmount("1234")
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "small"), make([]byte, 1024*1024), 0644))
+	err = ioutil.WriteFile(filepath.Join(dir, "big"), make([]byte, 32*1024*1024), 0644)
+	assert.Check(t, err != nil, "expected the size of the volume to be enforced")
+	usage, ok := v.Status()["Usage"].(uint64)
+	assert.Check(t, ok && usage >= 1024*1024, "unexpected usage of the volume: %v", v.Status()["Usage"])
+
+	r, err = New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(r.volumes["test"].size, uint64(16*1024*1024)))
+}
@@ -0,0 +1,34 @@
+// +build !linux
+
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"github.com/docker/docker/daemon/graphdriver/quota"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+type driverQuota struct {
+}
+
+func setupDriverQuota(r *Root) {
+}
+
+func (r *Root) quotaSupported() bool {
+	return false
+}
+
+func (r *Root) setupQuota(dir string, size uint64) error {
+	return quota.ErrQuotaNotSupported
+}
+
+func (r *Root) loadSize(v *localVolume, dir string) {
+}
+
+func createLoopImage(dir string, size uint64, rootUID, rootGID int, encrypted bool) (*optsConfig, error) {
+	return nil, errdefs.NotImplemented(errors.New("the size option is not supported on this platform"))
+}
+
+func mountLoop(image, fsType, target string) error {
+	return errdefs.NotImplemented(errors.New("loopback-backed volumes are not supported on this platform"))
+}
@@ -0,0 +1,319 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/daemon/graphdriver/copy"
+	"github.com/docker/docker/daemon/names"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/volume"
+	"github.com/pkg/errors"
+)
+
+// The snapshots of a volume are kept in the snapshots directory of the
+// volume, one directory per snapshot holding its metadata and, unless the
+// snapshot is a logical volume, its data.
+//
+// The data of the volume is snapshotted with a btrfs snapshot when it is a
+// btrfs subvolume, and copied otherwise, the copy cloning the extents of the
+// files on the filesystems supporting reflinks. The volumes mounted from an
+// LVM logical volume are snapshotted with an LVM snapshot of the device.
+const (
+	snapshotsPathName  = "snapshots"
+	snapshotConfigName = "snapshot.json"
+
+	snapshotMethodBtrfs = "btrfs"
+	snapshotMethodCopy  = "copy"
+	snapshotMethodLVM   = "lvm"
+)
+
+type snapshotConfig struct {
+	Name      string
+	CreatedAt time.Time
+	Method    string
+	// Device is the logical volume of the LVM snapshots, as vg/lv
+	Device string `json:",omitempty"`
+}
+
+func (c snapshotConfig) toVolumeSnapshot() volume.Snapshot {
+	return volume.Snapshot{Name: c.Name, CreatedAt: c.CreatedAt, Method: c.Method}
+}
+
+func (v *localVolume) snapshotPath(name string) string {
+	return filepath.Join(filepath.Dir(v.path), snapshotsPathName, name)
+}
+
+func (v *localVolume) snapshotConfig(name string) (snapshotConfig, error) {
+	var c snapshotConfig
+	b, err := ioutil.ReadFile(filepath.Join(v.snapshotPath(name), snapshotConfigName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, errdefs.NotFound(errors.Errorf("no such snapshot: %s", name))
+		}
+		return c, errdefs.System(err)
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, errors.Wrapf(err, "error while reading snapshot %s", name)
+	}
+	return c, nil
+}
+
+// Snapshot takes a snapshot of the data of the volume.
+func (v *localVolume) Snapshot(name string) (volume.Snapshot, error) {
+	if !volumeNameRegex.MatchString(name) {
+		return volume.Snapshot{}, validationError(fmt.Sprintf("%q includes invalid characters for a snapshot name, only %q are allowed", name, names.RestrictedNameChars))
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	c := snapshotConfig{Name: name, CreatedAt: time.Now().UTC()}
+	var lv *logicalVolume
+	switch {
+	case v.encryption != nil:
+		return volume.Snapshot{}, errdefs.NotImplemented(errors.New("snapshots are not supported for encrypted volumes"))
+	case v.opts != nil && v.opts.Size != 0:
+		return volume.Snapshot{}, errdefs.NotImplemented(errors.New("snapshots are not supported for loopback-backed volumes"))
+	case v.opts != nil:
+		var err error
+		if lv, err = lookupLogicalVolume(v.opts.MountDevice); err != nil {
+			return volume.Snapshot{}, err
+		}
+		if lv == nil {
+			return volume.Snapshot{}, errdefs.NotImplemented(errors.Errorf("snapshots are not supported for volumes mounted from %s", v.opts.MountDevice))
+		}
+		c.Method = snapshotMethodLVM
+		c.Device = lv.vg + "/" + snapshotLVName(lv.lv, name)
+	case isBtrfsSubvolume(v.path):
+		c.Method = snapshotMethodBtrfs
+	default:
+		c.Method = snapshotMethodCopy
+	}
+
+	path := v.snapshotPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return volume.Snapshot{}, errdefs.System(err)
+	}
+	if err := os.Mkdir(path, 0700); err != nil {
+		if os.IsExist(err) {
+			return volume.Snapshot{}, errdefs.Conflict(errors.Errorf("snapshot %s of volume %s already exists", name, v.name))
+		}
+		return volume.Snapshot{}, errdefs.System(err)
+	}
+
+	err := v.takeSnapshot(c, lv, path)
+	if err == nil {
+		var b []byte
+		if b, err = json.Marshal(c); err == nil {
+			err = ioutil.WriteFile(filepath.Join(path, snapshotConfigName), b, 0600)
+		}
+	}
+	if err != nil {
+		if c.Method == snapshotMethodLVM {
+			runLVM("lvremove", "--force", c.Device)
+		}
+		removeData(filepath.Join(path, VolumeDataPathName))
+		os.RemoveAll(path)
+		return volume.Snapshot{}, errdefs.System(errors.Wrapf(err, "error while taking snapshot %s of volume %s", name, v.name))
+	}
+	return c.toVolumeSnapshot(), nil
+}
+
+func (v *localVolume) takeSnapshot(c snapshotConfig, lv *logicalVolume, path string) error {
+	switch c.Method {
+	case snapshotMethodLVM:
+		args := []string{"--snapshot", "--name", snapshotLVName(lv.lv, c.Name)}
+		if !lv.thin {
+			args = append(args, "--extents", "100%ORIGIN")
+		}
+		return runLVM("lvcreate", append(args, lv.vg+"/"+lv.lv)...)
+	case snapshotMethodBtrfs:
+		return btrfsSnapshot(v.path, path, VolumeDataPathName)
+	default:
+		return copy.DirCopy(v.path, filepath.Join(path, VolumeDataPathName), copy.Content, true)
+	}
+}
+
+// Snapshots lists the snapshots of the volume, oldest first.
+func (v *localVolume) Snapshots() ([]volume.Snapshot, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	dirs, err := ioutil.ReadDir(filepath.Join(filepath.Dir(v.path), snapshotsPathName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errdefs.System(err)
+	}
+	var ls []volume.Snapshot
+	for _, d := range dirs {
+		c, err := v.snapshotConfig(d.Name())
+		if err != nil {
+			// incomplete snapshot
+			continue
+		}
+		ls = append(ls, c.toVolumeSnapshot())
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].CreatedAt.Before(ls[j].CreatedAt) })
+	return ls, nil
+}
+
+// RestoreSnapshot replaces the data of the volume with the data of the
+// snapshot. The LVM snapshots are merged into the logical volume they were
+// taken of, and so are removed.
+func (v *localVolume) RestoreSnapshot(name string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.active.count > 0 {
+		return errdefs.Conflict(errors.Errorf("volume %s has active mounts", v.name))
+	}
+	c, err := v.snapshotConfig(name)
+	if err != nil {
+		return err
+	}
+
+	if c.Method == snapshotMethodLVM {
+		if err := runLVM("lvconvert", "--merge", c.Device); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+		}
+		// The merge of the snapshots of thin and of open logical volumes
+		// starts on the next activation of the origin.
+		origin := filepath.Dir(c.Device) + "/" + strings.TrimSuffix(filepath.Base(c.Device), "-"+name)
+		runLVM("lvchange", "--activate", "n", origin)
+		if err := runLVM("lvchange", "--activate", "y", origin); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while activating logical volume %s", origin))
+		}
+		return removePath(v.snapshotPath(name))
+	}
+
+	restore := v.path + ".restore"
+	old := v.path + ".old"
+	if err := removeData(restore); err != nil {
+		return err
+	}
+	snapData := filepath.Join(v.snapshotPath(name), VolumeDataPathName)
+	if c.Method == snapshotMethodBtrfs {
+		err = btrfsSnapshot(snapData, filepath.Dir(restore), filepath.Base(restore))
+	} else {
+		err = copy.DirCopy(snapData, restore, copy.Content, true)
+	}
+	if err != nil {
+		removeData(restore)
+		return errdefs.System(errors.Wrapf(err, "error while restoring snapshot %s of volume %s", name, v.name))
+	}
+
+	if err := os.Rename(v.path, old); err != nil {
+		removeData(restore)
+		return errdefs.System(err)
+	}
+	if err := os.Rename(restore, v.path); err != nil {
+		os.Rename(old, v.path)
+		removeData(restore)
+		return errdefs.System(err)
+	}
+	return removeData(old)
+}
+
+// RemoveSnapshot removes a snapshot of the volume.
+func (v *localVolume) RemoveSnapshot(name string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if _, err := os.Stat(v.snapshotPath(name)); os.IsNotExist(err) {
+		return errdefs.NotFound(errors.Errorf("no such snapshot: %s", name))
+	}
+	return v.removeSnapshot(name)
+}
+
+// removeSnapshot removes a snapshot, which may be incomplete.
+func (v *localVolume) removeSnapshot(name string) error {
+	c, err := v.snapshotConfig(name)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+	if c.Method == snapshotMethodLVM {
+		if err := runLVM("lvremove", "--force", c.Device); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error while removing snapshot %s of volume %s", name, v.name))
+		}
+	}
+	if err := removeData(filepath.Join(v.snapshotPath(name), VolumeDataPathName)); err != nil {
+		return err
+	}
+	return removePath(v.snapshotPath(name))
+}
+
+// removeSnapshots removes all the snapshots of the volume.
+func (v *localVolume) removeSnapshots() error {
+	dirs, err := ioutil.ReadDir(filepath.Join(filepath.Dir(v.path), snapshotsPathName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errdefs.System(err)
+	}
+	for _, d := range dirs {
+		if err := v.removeSnapshot(d.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeData removes the data of a volume or of a snapshot, destroying it if
+// it is a btrfs subvolume.
+func removeData(path string) error {
+	if isBtrfsSubvolume(path) {
+		if err := btrfsDestroy(path); err != nil {
+			return errdefs.System(errors.Wrapf(err, "error removing subvolume '%s'", path))
+		}
+		return nil
+	}
+	return removePath(path)
+}
+
+// logicalVolume is an LVM logical volume.
+type logicalVolume struct {
+	vg   string
+	lv   string
+	thin bool
+}
+
+func snapshotLVName(lv, snapshot string) string {
+	return lv + "-" + snapshot
+}
+
+// lookupLogicalVolume returns the logical volume of the device, or nil if the
+// device is not a logical volume or LVM is not installed.
+func lookupLogicalVolume(device string) (*logicalVolume, error) {
+	if !strings.HasPrefix(device, "/dev/") {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("lvs"); err != nil {
+		return nil, nil
+	}
+	out, err := exec.Command("lvs", "--noheadings", "--separator", ",", "--options", "vg_name,lv_name,lv_attr", device).Output()
+	if err != nil {
+		return nil, nil
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ",")
+	if len(fields) != 3 || fields[2] == "" {
+		return nil, errdefs.System(errors.Errorf("unexpected output of lvs for %s: %q", device, out))
+	}
+	return &logicalVolume{vg: fields[0], lv: fields[1], thin: fields[2][0] == 'V'}, nil
+}
+
+func runLVM(cmd string, args ...string) error {
+	if out, err := exec.Command(cmd, append([]string{"--yes"}, args...)...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "%s failed: %s", cmd, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/volume"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestSnapshot(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, idtools.Identity{UID: os.Geteuid(), GID: os.Getegid()})
+	assert.NilError(t, err)
+	v, err := r.Create("testing", nil)
+	assert.NilError(t, err)
+	vol := v.(volume.SnapshotVolume)
+
+	data := filepath.Join(vol.Path(), "data")
+	assert.NilError(t, ioutil.WriteFile(data, []byte("v1"), 0644))
+
+	snap, err := vol.Snapshot("before")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(snap.Name, "before"))
+	assert.Check(t, is.Equal(snap.Method, snapshotMethodCopy))
+
+	_, err = vol.Snapshot("before")
+	assert.Check(t, errdefs.IsConflict(err), "expected a conflict, got %v", err)
+	_, err = vol.Snapshot("../escape")
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected an invalid parameter, got %v", err)
+
+	assert.NilError(t, ioutil.WriteFile(data, []byte("v2"), 0644))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(vol.Path(), "new"), nil, 0644))
+
+	ls, err := vol.Snapshots()
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(ls, 1))
+	assert.Check(t, is.Equal(ls[0].Name, "before"))
+
+	assert.Check(t, errdefs.IsNotFound(vol.RestoreSnapshot("unknown")))
+	assert.NilError(t, vol.RestoreSnapshot("before"))
+	b, err := ioutil.ReadFile(data)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(b), "v1"))
+	_, err = os.Stat(filepath.Join(vol.Path(), "new"))
+	assert.Check(t, os.IsNotExist(err), "expected the file created after the snapshot to be removed, got %v", err)
+
+	assert.NilError(t, vol.RemoveSnapshot("before"))
+	assert.Check(t, errdefs.IsNotFound(vol.RemoveSnapshot("before")))
+	ls, err = vol.Snapshots()
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(ls, 0))
+
+	_, err = vol.Snapshot("kept")
+	assert.NilError(t, err)
+	assert.NilError(t, r.Remove(vol))
+	_, err = os.Stat(filepath.Dir(vol.Path()))
+	assert.Check(t, os.IsNotExist(err), "expected the volume and its snapshots to be removed, got %v", err)
+}
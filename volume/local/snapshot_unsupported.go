@@ -0,0 +1,36 @@
+// +build !linux
+
+package local // import "github.com/docker/docker/volume/local"
+
+import (
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/volume"
+	"github.com/pkg/errors"
+)
+
+var errSnapshotsNotSupported = errdefs.NotImplemented(errors.New("volume snapshots are not supported on this platform"))
+
+// Snapshot takes a snapshot of the data of the volume.
+func (v *localVolume) Snapshot(name string) (volume.Snapshot, error) {
+	return volume.Snapshot{}, errSnapshotsNotSupported
+}
+
+// Snapshots lists the snapshots of the volume.
+func (v *localVolume) Snapshots() ([]volume.Snapshot, error) {
+	return nil, errSnapshotsNotSupported
+}
+
+// RestoreSnapshot replaces the data of the volume with the data of the
+// snapshot.
+func (v *localVolume) RestoreSnapshot(name string) error {
+	return errSnapshotsNotSupported
+}
+
+// RemoveSnapshot removes a snapshot of the volume.
+func (v *localVolume) RemoveSnapshot(name string) error {
+	return errSnapshotsNotSupported
+}
+
+func (v *localVolume) removeSnapshots() error {
+	return nil
+}
@@ -15,6 +15,14 @@ import (
 type linuxParser struct {
 }
 
+// linuxValidTmpfsHugePages are the huge pages policies of the tmpfs mounts.
+var linuxValidTmpfsHugePages = map[string]bool{
+	"never":       true,
+	"always":      true,
+	"within_size": true,
+	"advise":      true,
+}
+
 func linuxSplitRawSpec(raw string) ([]string, error) {
 	if strings.Count(raw, ":") > 2 {
 		return nil, errInvalidSpec(raw)
@@ -41,6 +49,16 @@ func linuxValidateAbsolute(p string) error {
 	}
 	return fmt.Errorf("invalid mount path: '%s' mount path must be absolute", p)
 }
+
+// linuxValidateSubpath validates that the subpath of a volume does not
+// escape it, before its symlinks are resolved.
+func linuxValidateSubpath(subpath string) error {
+	p := path.Clean(subpath)
+	if path.IsAbs(p) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return errInvalidSubpath(subpath)
+	}
+	return nil
+}
 func (p *linuxParser) ValidateMountConfig(mnt *mount.Mount) error {
 	// there was something looking like a bug in existing codebase:
 	// - validateMountConfig on linux was called with options skipping bind source existence when calling ParseMountRaw
@@ -60,6 +78,12 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 		return &errMountConfig{mnt, err}
 	}
 
+	switch mnt.Relabel {
+	case "", mount.RelabelShared, mount.RelabelPrivate, mount.RelabelNone:
+	default:
+		return &errMountConfig{mnt, fmt.Errorf("invalid relabel mode: %s", mnt.Relabel)}
+	}
+
 	switch mnt.Type {
 	case mount.TypeBind:
 		if len(mnt.Source) == 0 {
@@ -72,6 +96,16 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 					return &errMountConfig{mnt, fmt.Errorf("invalid propagation mode: %s", opts.Propagation)}
 				}
 			}
+			if opts.ReadOnlyRecursive {
+				if !mnt.ReadOnly {
+					return &errMountConfig{mnt, errors.New("must set ReadOnly mode when using ReadOnlyRecursive")}
+				}
+				// the mounts propagated to a recursively read-only mount
+				// would not be read-only
+				if opts.Propagation != "" && opts.Propagation != mount.PropagationPrivate && opts.Propagation != mount.PropagationRPrivate {
+					return &errMountConfig{mnt, fmt.Errorf("invalid propagation mode %s for a recursively read-only mount, it must be private", opts.Propagation)}
+				}
+			}
 		}
 		if mnt.VolumeOptions != nil {
 			return &errMountConfig{mnt, errExtraField("VolumeOptions")}
@@ -96,10 +130,21 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 		if len(mnt.Source) == 0 && mnt.ReadOnly {
 			return &errMountConfig{mnt, fmt.Errorf("must not set ReadOnly mode when using anonymous volumes")}
 		}
+		if opts := mnt.VolumeOptions; opts != nil && opts.Subpath != "" {
+			if len(mnt.Source) == 0 {
+				return &errMountConfig{mnt, errors.New("must not set Subpath when using anonymous volumes")}
+			}
+			if err := linuxValidateSubpath(opts.Subpath); err != nil {
+				return &errMountConfig{mnt, err}
+			}
+		}
 	case mount.TypeTmpfs:
 		if len(mnt.Source) != 0 {
 			return &errMountConfig{mnt, errExtraField("Source")}
 		}
+		if mnt.Relabel != "" {
+			return &errMountConfig{mnt, errExtraField("Relabel")}
+		}
 		if _, err := p.ConvertTmpfsOptions(mnt.TmpfsOptions, mnt.ReadOnly); err != nil {
 			return &errMountConfig{mnt, err}
 		}
@@ -111,8 +156,9 @@ func (p *linuxParser) validateMountConfigImpl(mnt *mount.Mount, validateBindSour
 
 // read-write modes
 var rwModes = map[string]bool{
-	"rw": true,
-	"ro": true,
+	"rw":  true,
+	"ro":  true,
+	"rro": true,
 }
 
 // label modes
@@ -198,13 +244,24 @@ func (p *linuxParser) ReadWrite(mode string) bool {
 	}
 
 	for _, o := range strings.Split(mode, ",") {
-		if o == "ro" {
+		if o == "ro" || o == "rro" {
 			return false
 		}
 	}
 	return true
 }
 
+// linuxReadOnlyRecursive returns whether the mode makes the mount
+// recursively read-only.
+func linuxReadOnlyRecursive(mode string) bool {
+	for _, o := range strings.Split(mode, ",") {
+		if o == "rro" {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *linuxParser) ParseMountRaw(raw, volumeDriver string) (*MountPoint, error) {
 	arr, err := linuxSplitRawSpec(raw)
 	if err != nil {
@@ -265,6 +322,15 @@ func (p *linuxParser) ParseMountRaw(raw, volumeDriver string) (*MountPoint, erro
 			Propagation: linuxGetPropagation(mode),
 		}
 	}
+	if linuxReadOnlyRecursive(mode) {
+		if spec.Type != mount.TypeBind {
+			return nil, errInvalidMode(mode)
+		}
+		if spec.BindOptions == nil {
+			spec.BindOptions = &mount.BindOptions{}
+		}
+		spec.BindOptions.ReadOnlyRecursive = true
+	}
 
 	mp, err := p.parseMountSpec(spec, false)
 	if mp != nil {
@@ -289,6 +355,13 @@ func (p *linuxParser) parseMountSpec(cfg mount.Mount, validateBindSourceExists b
 		Spec:        cfg,
 	}
 
+	switch cfg.Relabel {
+	case mount.RelabelShared:
+		mp.Mode = "z"
+	case mount.RelabelPrivate:
+		mp.Mode = "Z"
+	}
+
 	switch cfg.Type {
 	case mount.TypeVolume:
 		if cfg.Source == "" {
@@ -302,7 +375,8 @@ func (p *linuxParser) parseMountSpec(cfg mount.Mount, validateBindSourceExists b
 			if cfg.VolumeOptions.DriverConfig != nil {
 				mp.Driver = cfg.VolumeOptions.DriverConfig.Name
 			}
-			if cfg.VolumeOptions.NoCopy {
+			// the content of the image is not copied to a part of a volume
+			if cfg.VolumeOptions.NoCopy || cfg.VolumeOptions.Subpath != "" {
 				mp.CopyData = false
 			}
 		}
@@ -361,9 +435,30 @@ func (p *linuxParser) ConvertTmpfsOptions(opt *mount.TmpfsOptions, readOnly bool
 	}
 
 	if opt != nil && opt.Mode != 0 {
+		if opt.Mode&^07777 != 0 {
+			return "", fmt.Errorf("invalid tmpfs mode %o, only the permission bits can be set", opt.Mode)
+		}
 		rawOpts = append(rawOpts, fmt.Sprintf("mode=%o", opt.Mode))
 	}
 
+	if opt != nil && (opt.UID != 0 || opt.GID != 0) {
+		if opt.UID < 0 || opt.GID < 0 {
+			return "", fmt.Errorf("invalid tmpfs owner %d:%d", opt.UID, opt.GID)
+		}
+		rawOpts = append(rawOpts, fmt.Sprintf("uid=%d", opt.UID), fmt.Sprintf("gid=%d", opt.GID))
+	}
+
+	if opt != nil && opt.NoSwap {
+		rawOpts = append(rawOpts, "noswap")
+	}
+
+	if opt != nil && opt.HugePages != "" {
+		if !linuxValidTmpfsHugePages[opt.HugePages] {
+			return "", fmt.Errorf("invalid tmpfs huge pages policy %q, must be one of never, always, within_size or advise", opt.HugePages)
+		}
+		rawOpts = append(rawOpts, "huge="+opt.HugePages)
+	}
+
 	if opt != nil && opt.SizeBytes != 0 {
 		// calculate suffix here, making this linux specific, but that is
 		// okay, since API is that way anyways.
@@ -35,6 +35,12 @@ func TestConvertTmpfsOptions(t *testing.T) {
 			expectedSubstrings:   []string{"ro"},
 			unexpectedSubstrings: []string{},
 		},
+		{
+			opt:                  mount.TmpfsOptions{Mode: 01777, UID: 1000, NoSwap: true, HugePages: "within_size"},
+			readOnly:             false,
+			expectedSubstrings:   []string{"mode=1777", "uid=1000", "gid=0", "noswap", "huge=within_size"},
+			unexpectedSubstrings: []string{"ro"},
+		},
 	}
 	p := &linuxParser{}
 	for _, c := range cases {
@@ -55,6 +61,16 @@ func TestConvertTmpfsOptions(t *testing.T) {
 			}
 		}
 	}
+
+	for _, opt := range []mount.TmpfsOptions{
+		{Mode: os.ModeDir | 0755},
+		{UID: -1},
+		{HugePages: "sometimes"},
+	} {
+		if _, err := p.ConvertTmpfsOptions(&opt, false); err == nil {
+			t.Fatalf("expected an error converting %+v", opt)
+		}
+	}
 }
 
 type mockFiProvider struct{}
@@ -256,6 +272,8 @@ func TestParseMountRaw(t *testing.T) {
 			"/hostPath:/containerPath:rslave,ro,Z",
 			"/hostPath:/containerPath:ro,rshared,Z",
 			"/hostPath:/containerPath:ro,Z,rprivate",
+			"/hostPath:/containerPath:rro",
+			"/hostPath:/containerPath:rro,rprivate,Z",
 		},
 		invalid: map[string]string{
 			"":                                "invalid volume specification",
@@ -281,6 +299,9 @@ func TestParseMountRaw(t *testing.T) {
 			"/path:/path:rwz":                 `invalid mode`,
 			"/path:/path:ro,rshared,rslave":   `invalid mode`,
 			"/path:/path:ro,z,rshared,rslave": `invalid mode`,
+			"/path:/path:ro,rro":              `invalid mode`,
+			"name:/path:rro":                  `invalid mode`,
+			"/path:/path:rro,rslave":          `must be private`,
 			"/path:shared":                    "invalid volume specification",
 			"/path:slave":                     "invalid volume specification",
 			"/path:private":                   "invalid volume specification",
@@ -478,3 +499,64 @@ func TestParseMountSpec(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMountSpecSubpath(t *testing.T) {
+	linParser := &linuxParser{}
+	withSubpath := func(source, subpath string) mount.Mount {
+		return mount.Mount{Type: mount.TypeVolume, Source: source, Target: "/foo", VolumeOptions: &mount.VolumeOptions{Subpath: subpath}}
+	}
+
+	for _, subpath := range []string{"app1", "app1/data", "./app1", "app1/../app2"} {
+		mp, err := linParser.ParseMountSpec(withSubpath("data", subpath))
+		if err != nil {
+			t.Errorf("expected subpath %q to be valid, got %v", subpath, err)
+			continue
+		}
+		if mp.CopyData {
+			t.Errorf("expected the image content not to be copied to subpath %q", subpath)
+		}
+	}
+	for _, subpath := range []string{"/app1", ".", "..", "../app1", "app1/../../app2"} {
+		if _, err := linParser.ParseMountSpec(withSubpath("data", subpath)); err == nil || !strings.Contains(err.Error(), "invalid volume subpath") {
+			t.Errorf("expected subpath %q to be invalid, got %v", subpath, err)
+		}
+	}
+	if _, err := linParser.ParseMountSpec(withSubpath("", "app1")); err == nil || !strings.Contains(err.Error(), "anonymous volumes") {
+		t.Errorf("expected a subpath of an anonymous volume to be invalid, got %v", err)
+	}
+
+	winParser := &windowsParser{}
+	if _, err := winParser.ParseMountSpec(mount.Mount{Type: mount.TypeVolume, Source: "data", Target: `c:\foo`, VolumeOptions: &mount.VolumeOptions{Subpath: "app1"}}); err == nil || !strings.Contains(err.Error(), "not supported on Windows") {
+		t.Errorf("expected subpaths not to be supported on Windows, got %v", err)
+	}
+}
+
+func TestParseMountSpecRelabel(t *testing.T) {
+	linParser := &linuxParser{}
+	for relabel, mode := range map[mount.Relabel]string{
+		"":                   "",
+		mount.RelabelShared:  "z",
+		mount.RelabelPrivate: "Z",
+		mount.RelabelNone:    "",
+	} {
+		mp, err := linParser.ParseMountSpec(mount.Mount{Type: mount.TypeVolume, Source: "data", Target: "/foo", Relabel: relabel})
+		if err != nil {
+			t.Errorf("expected relabel mode %q to be valid, got %v", relabel, err)
+			continue
+		}
+		if mp.Mode != mode {
+			t.Errorf("expected relabel mode %q to set mode %q, got %q", relabel, mode, mp.Mode)
+		}
+	}
+	if _, err := linParser.ParseMountSpec(mount.Mount{Type: mount.TypeVolume, Source: "data", Target: "/foo", Relabel: "z"}); err == nil || !strings.Contains(err.Error(), "invalid relabel mode: z") {
+		t.Errorf("expected an invalid relabel mode error, got %v", err)
+	}
+	if _, err := linParser.ParseMountSpec(mount.Mount{Type: mount.TypeTmpfs, Target: "/foo", Relabel: mount.RelabelPrivate}); err == nil || !strings.Contains(err.Error(), "field Relabel must not be specified") {
+		t.Errorf("expected tmpfs mounts not to be relabeled, got %v", err)
+	}
+
+	winParser := &windowsParser{}
+	if _, err := winParser.ParseMountSpec(mount.Mount{Type: mount.TypeVolume, Source: "data", Target: `c:\foo`, Relabel: mount.RelabelNone}); err == nil || !strings.Contains(err.Error(), "field Relabel must not be specified") {
+		t.Errorf("expected relabeling not to be supported on Windows, got %v", err)
+	}
+}
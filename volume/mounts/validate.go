@@ -26,3 +26,7 @@ func errExtraField(name string) error {
 func errMissingField(name string) error {
 	return errors.Errorf("field %s must not be empty", name)
 }
+
+func errInvalidSubpath(subpath string) error {
+	return errors.Errorf("invalid volume subpath %q: it must be a relative path within the volume", subpath)
+}
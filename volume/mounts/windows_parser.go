@@ -224,6 +224,10 @@ func (p *windowsParser) validateMountConfigReg(mnt *mount.Mount, destRegex strin
 		return &errMountConfig{mnt, errMissingField("Target")}
 	}
 
+	if mnt.Relabel != "" {
+		return &errMountConfig{mnt, errExtraField("Relabel")}
+	}
+
 	if err := windowsValidateRegex(mnt.Target, destRegex); err != nil {
 		return &errMountConfig{mnt, err}
 	}
@@ -238,6 +242,9 @@ func (p *windowsParser) validateMountConfigReg(mnt *mount.Mount, destRegex strin
 			if len(opts.Propagation) > 0 {
 				return &errMountConfig{mnt, fmt.Errorf("invalid propagation mode: %s", opts.Propagation)}
 			}
+			if opts.ReadOnlyRecursive {
+				return &errMountConfig{mnt, errors.New("recursively read-only mounts are not supported on Windows")}
+			}
 		}
 		if mnt.VolumeOptions != nil {
 			return &errMountConfig{mnt, errExtraField("VolumeOptions")}
@@ -272,6 +279,9 @@ func (p *windowsParser) validateMountConfigReg(mnt *mount.Mount, destRegex strin
 				return &errMountConfig{mnt, err}
 			}
 		}
+		if mnt.VolumeOptions != nil && mnt.VolumeOptions.Subpath != "" {
+			return &errMountConfig{mnt, errors.New("volume subpaths are not supported on Windows")}
+		}
 	case mount.TypeNamedPipe:
 		if len(mnt.Source) == 0 {
 			return &errMountConfig{mnt, errMissingField("Source")}
@@ -1,6 +1,9 @@
 package service // import "github.com/docker/docker/volume/service"
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/volume"
 )
@@ -68,7 +71,62 @@ type fromList struct {
 
 func (fromList) isBy() {}
 
-func byLabelFilter(filter filters.Args) By {
+// labelSetExpression matches the label filters selecting the volumes with
+// (or without) one of a set of values for a label:
+// "<key> in (<value>,<value>)" or "<key> notin (<value>,<value>)".
+var labelSetExpression = regexp.MustCompile(`^\s*([^\s=()]+)\s+(in|notin)\s+\(([^()]*)\)\s*$`)
+
+type labelSet struct {
+	key    string
+	values map[string]struct{}
+	notIn  bool
+}
+
+// parseLabelSet parses a label set expression. It returns nil if the filter
+// is not an expression.
+func parseLabelSet(filter string) (*labelSet, error) {
+	if !strings.ContainsAny(filter, "()") {
+		return nil, nil
+	}
+	m := labelSetExpression.FindStringSubmatch(filter)
+	if m == nil {
+		return nil, invalidFilter{"label", filter}
+	}
+	set := &labelSet{key: m[1], values: make(map[string]struct{}), notIn: m[2] == "notin"}
+	for _, value := range strings.Split(m[3], ",") {
+		set.values[strings.TrimSpace(value)] = struct{}{}
+	}
+	return set, nil
+}
+
+// match returns whether the labels match the expression. The labels without
+// the key are not in the set.
+func (l *labelSet) match(labels map[string]string) bool {
+	value, ok := labels[l.key]
+	if ok {
+		_, ok = l.values[value]
+	}
+	return ok != l.notIn
+}
+
+func byLabelFilter(filter filters.Args) (By, error) {
+	var sets []*labelSet
+	kvs := filters.NewArgs()
+	for _, f := range filter.Get("label") {
+		set, err := parseLabelSet(f)
+		if err != nil {
+			return nil, err
+		}
+		if set != nil {
+			sets = append(sets, set)
+		} else {
+			kvs.Add("label", f)
+		}
+	}
+	for _, f := range filter.Get("label!") {
+		kvs.Add("label!", f)
+	}
+
 	return CustomFilter(func(v volume.Volume) bool {
 		dv, ok := v.(volume.DetailedVolume)
 		if !ok {
@@ -76,14 +134,19 @@ func byLabelFilter(filter filters.Args) By {
 		}
 
 		labels := dv.Labels()
-		if !filter.MatchKVList("label", labels) {
+		if !kvs.MatchKVList("label", labels) {
 			return false
 		}
-		if filter.Contains("label!") {
-			if filter.MatchKVList("label!", labels) {
+		if kvs.Contains("label!") {
+			if kvs.MatchKVList("label!", labels) {
+				return false
+			}
+		}
+		for _, set := range sets {
+			if !set.match(labels) {
 				return false
 			}
 		}
 		return true
-	})
+	}), nil
 }
@@ -24,6 +24,12 @@ type calcSize bool
 
 func (calcSize) isConvertOpt() {}
 
+// knownSize returns the size of a volume when it does not need to be
+// calculated again.
+type knownSize func(name string) (int64, bool)
+
+func (knownSize) isConvertOpt() {}
+
 type pathCacher interface {
 	CachedPath() string
 }
@@ -33,6 +39,7 @@ func (s *VolumesService) volumesToAPI(ctx context.Context, volumes []volume.Volu
 		out        = make([]*types.Volume, 0, len(volumes))
 		getSize    bool
 		cachedPath bool
+		sizeOf     knownSize
 	)
 
 	for _, o := range opts {
@@ -41,6 +48,8 @@ func (s *VolumesService) volumesToAPI(ctx context.Context, volumes []volume.Volu
 			getSize = bool(t)
 		case useCachedPath:
 			cachedPath = bool(t)
+		case knownSize:
+			sizeOf = t
 		}
 	}
 	for _, v := range volumes {
@@ -64,10 +73,16 @@ func (s *VolumesService) volumesToAPI(ctx context.Context, volumes []volume.Volu
 			if apiV.Mountpoint == "" {
 				apiV.Mountpoint = p
 			}
-			sz, err := directory.Size(ctx, p)
-			if err != nil {
-				logrus.WithError(err).WithField("volume", v.Name()).Warnf("Failed to determine size of volume")
-				sz = -1
+			sz, ok := int64(0), false
+			if sizeOf != nil {
+				sz, ok = sizeOf(v.Name())
+			}
+			if !ok {
+				var err error
+				if sz, err = directory.Size(ctx, p); err != nil {
+					logrus.WithError(err).WithField("volume", v.Name()).Warnf("Failed to determine size of volume")
+					sz = -1
+				}
 			}
 			apiV.UsageData = &types.VolumeUsageData{Size: sz, RefCount: int64(s.vs.CountReferences(v))}
 		}
@@ -95,6 +110,15 @@ func volumeToAPIType(v volume.Volume) types.Volume {
 	return tv
 }
 
+func snapshotToAPIType(name string, snap volume.Snapshot) types.VolumeSnapshot {
+	return types.VolumeSnapshot{
+		Name:      snap.Name,
+		Volume:    name,
+		CreatedAt: snap.CreatedAt,
+		Method:    snap.Method,
+	}
+}
+
 func filtersToBy(filter filters.Args, acceptedFilters map[string]bool) (By, error) {
 	if err := filter.Validate(acceptedFilters); err != nil {
 		return nil, err
@@ -108,7 +132,11 @@ func filtersToBy(filter filters.Args, acceptedFilters map[string]bool) (By, erro
 			return filter.Match("name", v.Name())
 		}))
 	}
-	bys = append(bys, byLabelFilter(filter))
+	byLabel, err := byLabelFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	bys = append(bys, byLabel)
 
 	if filter.Contains("dangling") {
 		var dangling bool
@@ -2,6 +2,7 @@ package service // import "github.com/docker/docker/volume/service"
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/docker/docker/errdefs"
 	"github.com/pkg/errors"
@@ -16,6 +17,9 @@ type volumeMetadata struct {
 	Driver  string
 	Labels  map[string]string
 	Options map[string]string
+	// LastUsed is the time the volume was created, or last released by one
+	// of its users.
+	LastUsed time.Time `json:",omitempty"`
 }
 
 func (s *VolumeStore) setMeta(name string, meta volumeMetadata) error {
@@ -2,20 +2,42 @@
 
 package service // import "github.com/docker/docker/volume/service"
 import (
+	"path/filepath"
+
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/csi"
 	"github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
 	"github.com/pkg/errors"
 )
 
-func setupDefaultDriver(store *drivers.Store, root string, rootIDs idtools.Identity) error {
+func setupDefaultDriver(store *drivers.Store, root string, rootIDs idtools.Identity, encryptionKeyFile string) error {
 	d, err := local.New(root, rootIDs)
 	if err != nil {
 		return errors.Wrap(err, "error setting up default driver")
 	}
+	if encryptionKeyFile != "" {
+		if err := d.SetEncryptionKeyFile(encryptionKeyFile); err != nil {
+			return errors.Wrap(err, "error setting up default driver")
+		}
+	}
 	if !store.Register(d, volume.DefaultDriverName) {
 		return errors.New("local volume driver could not be registered")
 	}
 	return nil
 }
+
+func setupCSIDrivers(store *drivers.Store, root string, plugins map[string]string) error {
+	for name, endpoint := range plugins {
+		d, err := csi.New(name, endpoint, filepath.Join(root, "csi", name))
+		if err != nil {
+			return errors.Wrapf(err, "error setting up CSI volume driver %s", name)
+		}
+		if !store.Register(d, name) {
+			d.Close()
+			return errors.Errorf("CSI volume driver %s could not be registered", name)
+		}
+	}
+	return nil
+}
@@ -7,4 +7,6 @@ import (
 	"github.com/docker/docker/volume/drivers"
 )
 
-func setupDefaultDriver(_ *drivers.Store, _ string, _ idtools.Identity) error { return nil }
+func setupDefaultDriver(_ *drivers.Store, _ string, _ idtools.Identity, _ string) error { return nil }
+
+func setupCSIDrivers(_ *drivers.Store, _ string, _ map[string]string) error { return nil }
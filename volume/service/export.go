@@ -0,0 +1,102 @@
+package service // import "github.com/docker/docker/volume/service"
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/volume/service/opts"
+	"github.com/pkg/errors"
+)
+
+// Export writes a tarball of the data of a volume to out, gzip compressed if
+// compress is set. The volume is referenced while it is exported, so it
+// cannot be removed.
+func (s *VolumesService) Export(ctx context.Context, name string, compress bool, out io.Writer) error {
+	ref := "export-" + stringid.GenerateNonCryptoID()
+	v, err := s.vs.Get(ctx, name, opts.WithGetReference(ref))
+	if err != nil {
+		return err
+	}
+	defer s.vs.Release(ctx, v.Name(), ref)
+
+	path, err := v.Mount(ref)
+	if err != nil {
+		return errors.Wrapf(err, "error while mounting volume %s", name)
+	}
+	defer v.Unmount(ref)
+
+	compression := archive.Uncompressed
+	if compress {
+		compression = archive.Gzip
+	}
+	data, err := archive.TarWithOptions(path, &archive.TarOptions{
+		Compression: compression,
+		UIDMaps:     s.idMapping.UIDs(),
+		GIDMaps:     s.idMapping.GIDs(),
+	})
+	if err != nil {
+		return errdefs.System(errors.Wrapf(err, "error while exporting volume %s", name))
+	}
+	defer data.Close()
+
+	if _, err := io.Copy(out, data); err != nil {
+		return errors.Wrapf(err, "error while exporting volume %s", name)
+	}
+	return nil
+}
+
+// Import creates a volume and extracts the tarball read from in, which may
+// be compressed, into it. The volume is removed if the tarball cannot be
+// extracted.
+func (s *VolumesService) Import(ctx context.Context, name, driverName string, in io.Reader, createOpts ...opts.CreateOption) (*types.Volume, error) {
+	if name != "" {
+		if _, err := s.vs.Get(ctx, name); err == nil {
+			return nil, errdefs.Conflict(errors.Errorf("volume %s already exists", name))
+		} else if !IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	vol, err := s.Create(ctx, name, driverName, createOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.importData(ctx, vol.Name, in); err != nil {
+		if rmErr := s.Remove(ctx, vol.Name); rmErr != nil {
+			return nil, errors.Wrapf(err, "error removing imported volume %s: %v", vol.Name, rmErr)
+		}
+		return nil, err
+	}
+
+	s.eventLogger.LogVolumeEvent(vol.Name, "import", map[string]string{"driver": vol.Driver})
+	return vol, nil
+}
+
+func (s *VolumesService) importData(ctx context.Context, name string, in io.Reader) error {
+	ref := "import-" + stringid.GenerateNonCryptoID()
+	v, err := s.vs.Get(ctx, name, opts.WithGetReference(ref))
+	if err != nil {
+		return err
+	}
+	defer s.vs.Release(ctx, v.Name(), ref)
+
+	path, err := v.Mount(ref)
+	if err != nil {
+		return errors.Wrapf(err, "error while mounting volume %s", name)
+	}
+	defer v.Unmount(ref)
+
+	err = chrootarchive.Untar(in, path, &archive.TarOptions{
+		UIDMaps: s.idMapping.UIDs(),
+		GIDMaps: s.idMapping.GIDs(),
+	})
+	if err != nil {
+		return errdefs.InvalidParameter(errors.Wrapf(err, "error while importing volume %s", name))
+	}
+	return nil
+}
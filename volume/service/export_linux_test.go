@@ -0,0 +1,68 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/volume"
+	volumedrivers "github.com/docker/docker/volume/drivers"
+	"github.com/docker/docker/volume/local"
+	"github.com/docker/docker/volume/service/opts"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func init() {
+	reexec.Init()
+}
+
+func TestServiceExportImport(t *testing.T) {
+	ds := volumedrivers.NewStore(nil)
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := local.New(dir, idtools.Identity{UID: os.Getuid(), GID: os.Getegid()})
+	assert.Assert(t, err)
+	assert.Assert(t, ds.Register(l, volume.DefaultDriverName))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+
+	ctx := context.Background()
+	v, err := service.Create(ctx, "test1", volume.DefaultDriverName)
+	assert.Assert(t, err)
+	assert.Assert(t, os.MkdirAll(filepath.Join(v.Mountpoint, "dir"), 0755))
+	assert.Assert(t, ioutil.WriteFile(filepath.Join(v.Mountpoint, "dir", "file"), []byte("hello"), 0644))
+
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		assert.Assert(t, service.Export(ctx, "test1", compress, &buf))
+
+		_, err = service.Import(ctx, "test1", volume.DefaultDriverName, bytes.NewReader(buf.Bytes()))
+		assert.Check(t, errdefs.IsConflict(err), "expected a conflict importing an existing volume, got %v", err)
+
+		imported, err := service.Import(ctx, "", volume.DefaultDriverName, &buf, opts.WithCreateLabels(map[string]string{"foo": "bar"}))
+		assert.Assert(t, err)
+		assert.Check(t, is.Equal(imported.Labels["foo"], "bar"))
+		data, err := ioutil.ReadFile(filepath.Join(imported.Mountpoint, "dir", "file"))
+		assert.Assert(t, err)
+		assert.Check(t, is.Equal(string(data), "hello"))
+		assert.Check(t, service.Remove(ctx, imported.Name))
+	}
+
+	_, err = service.Import(ctx, "test2", volume.DefaultDriverName, bytes.NewReader([]byte("not a tarball")))
+	assert.Check(t, errdefs.IsInvalidParameter(err), "expected an invalid parameter error, got %v", err)
+	_, err = service.Get(ctx, "test2")
+	assert.Check(t, IsNotExist(err), "expected the volume to be removed, got %v", err)
+
+	err = service.Export(ctx, "unknown", false, ioutil.Discard)
+	assert.Check(t, IsNotExist(err), "expected a not found error, got %v", err)
+}
@@ -74,6 +74,8 @@ func WithGetResolveStatus(cfg *GetConfig) {
 // RemoveConfig is used by `RemoveOption` to store config options for remove
 type RemoveConfig struct {
 	PurgeOnError bool
+	Detach       bool
+	Kill         bool
 }
 
 // RemoveOption is used to pass options to the volumes service `Remove` implementation
@@ -87,3 +89,13 @@ func WithPurgeOnError(b bool) RemoveOption {
 		o.PurgeOnError = b
 	}
 }
+
+// WithDetach is an option passed to `Remove` which lazily unmounts the volume,
+// if it is still mounted, with the drivers supporting it, killing the
+// processes holding the volume busy if kill is set.
+func WithDetach(kill bool) RemoveOption {
+	return func(o *RemoveConfig) {
+		o.Detach = true
+		o.Kill = kill
+	}
+}
@@ -3,9 +3,11 @@ package service // import "github.com/docker/docker/volume/service"
 import (
 	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/directory"
 	"github.com/docker/docker/pkg/idtools"
@@ -32,12 +34,17 @@ type VolumesService struct {
 	ds           ds
 	pruneRunning int32
 	eventLogger  volumeEventLogger
+	idMapping    *idtools.IdentityMapping
 }
 
-// NewVolumeService creates a new volume service
-func NewVolumeService(root string, pg plugingetter.PluginGetter, rootIDs idtools.Identity, logger volumeEventLogger) (*VolumesService, error) {
+// NewVolumeService creates a new volume service. The CSI plugins, whose
+// endpoints are passed by name, are registered as volume drivers.
+func NewVolumeService(root string, pg plugingetter.PluginGetter, idMapping *idtools.IdentityMapping, csiPlugins map[string]string, encryptionKeyFile string, logger volumeEventLogger) (*VolumesService, error) {
 	ds := drivers.NewStore(pg)
-	if err := setupDefaultDriver(ds, root, rootIDs); err != nil {
+	if err := setupDefaultDriver(ds, root, idMapping.RootPair(), encryptionKeyFile); err != nil {
+		return nil, err
+	}
+	if err := setupCSIDrivers(ds, root, csiPlugins); err != nil {
 		return nil, err
 	}
 
@@ -45,7 +52,7 @@ func NewVolumeService(root string, pg plugingetter.PluginGetter, rootIDs idtools
 	if err != nil {
 		return nil, err
 	}
-	return &VolumesService{vs: vs, ds: ds, eventLogger: logger}, nil
+	return &VolumesService{vs: vs, ds: ds, eventLogger: logger, idMapping: idMapping}, nil
 }
 
 // GetDriverList gets the list of registered volume drivers
@@ -147,9 +154,74 @@ func (s *VolumesService) Remove(ctx context.Context, name string, rmOpts ...opts
 	return err
 }
 
+// Snapshot takes a snapshot of the data of a volume. A name is generated for
+// the snapshot if none is provided.
+func (s *VolumesService) Snapshot(ctx context.Context, name, snapshot string) (*types.VolumeSnapshot, error) {
+	if snapshot == "" {
+		snapshot = stringid.TruncateID(stringid.GenerateNonCryptoID())
+	}
+	v, err := s.vs.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.vs.Snapshot(ctx, v, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	s.eventLogger.LogVolumeEvent(v.Name(), "snapshot", map[string]string{"driver": v.DriverName(), "snapshot": snap.Name})
+	apiSnap := snapshotToAPIType(v.Name(), snap)
+	return &apiSnap, nil
+}
+
+// Snapshots lists the snapshots of a volume
+func (s *VolumesService) Snapshots(ctx context.Context, name string) ([]types.VolumeSnapshot, error) {
+	v, err := s.vs.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	ls, err := s.vs.Snapshots(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]types.VolumeSnapshot, 0, len(ls))
+	for _, snap := range ls {
+		snapshots = append(snapshots, snapshotToAPIType(v.Name(), snap))
+	}
+	return snapshots, nil
+}
+
+// RestoreSnapshot replaces the data of a volume with the data of one of its
+// snapshots. The volume must not be in use.
+func (s *VolumesService) RestoreSnapshot(ctx context.Context, name, snapshot string) error {
+	v, err := s.vs.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := s.vs.RestoreSnapshot(ctx, v, snapshot); err != nil {
+		if IsInUse(err) {
+			err = errdefs.Conflict(err)
+		}
+		return err
+	}
+
+	s.eventLogger.LogVolumeEvent(v.Name(), "restore", map[string]string{"driver": v.DriverName(), "snapshot": snapshot})
+	return nil
+}
+
+// RemoveSnapshot removes a snapshot of a volume
+func (s *VolumesService) RemoveSnapshot(ctx context.Context, name, snapshot string) error {
+	v, err := s.vs.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	return s.vs.RemoveSnapshot(ctx, v, snapshot)
+}
+
 var acceptedPruneFilters = map[string]bool{
 	"label":  true,
 	"label!": true,
+	"until":  true,
 }
 
 var acceptedListFilters = map[string]bool{
@@ -165,8 +237,7 @@ var acceptedListFilters = map[string]bool{
 // local driver.
 func (s *VolumesService) LocalVolumesSize(ctx context.Context) ([]*types.Volume, error) {
 	ls, _, err := s.vs.Find(ctx, And(ByDriver(volume.DefaultDriverName), CustomFilter(func(v volume.Volume) bool {
-		dv, ok := v.(volume.DetailedVolume)
-		return ok && len(dv.Options()) == 0
+		return !hasMountOptions(v)
 	})))
 	if err != nil {
 		return nil, err
@@ -174,6 +245,34 @@ func (s *VolumesService) LocalVolumesSize(ctx context.Context) ([]*types.Volume,
 	return s.volumesToAPI(ctx, ls, calcSize(true)), nil
 }
 
+// LocalVolumesCachedSize is like LocalVolumesSize, except that the sizes
+// returned by cached are used instead of calculating them again.
+func (s *VolumesService) LocalVolumesCachedSize(ctx context.Context, cached func(name string) (int64, bool)) ([]*types.Volume, error) {
+	ls, _, err := s.vs.Find(ctx, And(ByDriver(volume.DefaultDriverName), CustomFilter(func(v volume.Volume) bool {
+		return !hasMountOptions(v)
+	})))
+	if err != nil {
+		return nil, err
+	}
+	return s.volumesToAPI(ctx, ls, calcSize(true), knownSize(cached)), nil
+}
+
+// hasMountOptions returns whether the (local) volume was created with mount
+// options. The size option of the local volumes only limits the size of
+// their data.
+func hasMountOptions(v volume.Volume) bool {
+	dv, ok := v.(volume.DetailedVolume)
+	if !ok {
+		return true
+	}
+	for opt := range dv.Options() {
+		if opt != "size" {
+			return true
+		}
+	}
+	return false
+}
+
 // Prune removes (local) volumes which match the past in filter arguments.
 // Note that this intentionally skips volumes with mount options as there would
 // be no space reclaimed in this case.
@@ -187,9 +286,12 @@ func (s *VolumesService) Prune(ctx context.Context, filter filters.Args) (*types
 	if err != nil {
 		return nil, err
 	}
+	until, err := getUntilFromPruneFilters(filter)
+	if err != nil {
+		return nil, err
+	}
 	ls, _, err := s.vs.Find(ctx, And(ByDriver(volume.DefaultDriverName), ByReferenced(false), by, CustomFilter(func(v volume.Volume) bool {
-		dv, ok := v.(volume.DetailedVolume)
-		return ok && len(dv.Options()) == 0
+		return !hasMountOptions(v) && s.unusedSince(v, until)
 	})))
 	if err != nil {
 		return nil, err
@@ -221,6 +323,42 @@ func (s *VolumesService) Prune(ctx context.Context, filter filters.Args) (*types
 	return rep, nil
 }
 
+// getUntilFromPruneFilters returns the time before which the volumes must have
+// been last used to be pruned, or the zero time if there is no until filter.
+func getUntilFromPruneFilters(filter filters.Args) (time.Time, error) {
+	values := filter.Get("until")
+	switch len(values) {
+	case 0:
+		return time.Time{}, nil
+	case 1:
+	default:
+		return time.Time{}, errdefs.InvalidParameter(errors.New("more than one until filter specified"))
+	}
+	ts, err := timetypes.GetTimestamp(values[0], time.Now())
+	if err != nil {
+		return time.Time{}, errdefs.InvalidParameter(err)
+	}
+	seconds, nanoseconds, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, errdefs.InvalidParameter(err)
+	}
+	return time.Unix(seconds, nanoseconds), nil
+}
+
+// unusedSince returns whether the volume was last used before until. The
+// volumes whose last use is unknown are not pruned by the until filter.
+func (s *VolumesService) unusedSince(v volume.Volume, until time.Time) bool {
+	if until.IsZero() {
+		return true
+	}
+	lastUsed, err := s.vs.LastUsed(v)
+	if err != nil {
+		logrus.WithError(err).WithField("volume", v.Name()).Debug("Could not determine the last use of the volume")
+		return false
+	}
+	return lastUsed.Before(until)
+}
+
 // List gets the list of volumes which match the past in filters
 // If filters is nil or empty all volumes are returned.
 func (s *VolumesService) List(ctx context.Context, filter filters.Args) (volumesOut []*types.Volume, warnings []string, err error) {
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
@@ -64,3 +65,87 @@ func TestLocalVolumeSize(t *testing.T) {
 		}
 	}
 }
+
+func TestLocalVolumeCachedSize(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := local.New(dir, idtools.Identity{UID: os.Getuid(), GID: os.Getegid()})
+	assert.Assert(t, err)
+	assert.Assert(t, ds.Register(l, volume.DefaultDriverName))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+
+	ctx := context.Background()
+	v1, err := service.Create(ctx, "test1", volume.DefaultDriverName)
+	assert.Assert(t, err)
+	_, err = service.Create(ctx, "test2", volume.DefaultDriverName)
+	assert.Assert(t, err)
+	err = ioutil.WriteFile(filepath.Join(v1.Mountpoint, "data"), make([]byte, 1024), 0644)
+	assert.Assert(t, err)
+
+	ls, err := service.LocalVolumesCachedSize(ctx, func(name string) (int64, bool) {
+		return 42, name == "test2"
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, is.Len(ls, 2))
+
+	for _, v := range ls {
+		switch v.Name {
+		case "test1":
+			assert.Check(t, is.Equal(v.UsageData.Size, int64(1024)))
+		case "test2":
+			assert.Check(t, is.Equal(v.UsageData.Size, int64(42)))
+		default:
+			t.Fatalf("got unexpected volume: %+v", v)
+		}
+	}
+}
+
+func TestServiceSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := local.New(dir, idtools.Identity{UID: os.Getuid(), GID: os.Getegid()})
+	assert.Assert(t, err)
+	assert.Assert(t, ds.Register(l, volume.DefaultDriverName))
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver("fake"), "fake"))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err = service.Create(ctx, "test1", volume.DefaultDriverName, opts.WithCreateReference("foo"))
+	assert.Assert(t, err)
+	_, err = service.Create(ctx, "test2", "fake")
+	assert.Assert(t, err)
+
+	snap, err := service.Snapshot(ctx, "test1", "")
+	assert.Assert(t, err)
+	assert.Check(t, snap.Name != "")
+	assert.Check(t, is.Equal(snap.Volume, "test1"))
+
+	ls, err := service.Snapshots(ctx, "test1")
+	assert.Assert(t, err)
+	assert.Check(t, is.Len(ls, 1))
+
+	err = service.RestoreSnapshot(ctx, "test1", snap.Name)
+	assert.Check(t, errdefs.IsConflict(err), "expected a conflict restoring a volume in use, got %v", err)
+	assert.Assert(t, service.Release(ctx, "test1", "foo"))
+	assert.Check(t, service.RestoreSnapshot(ctx, "test1", snap.Name))
+	assert.Check(t, service.RemoveSnapshot(ctx, "test1", snap.Name))
+
+	_, err = service.Snapshot(ctx, "test2", "")
+	assert.Check(t, errdefs.IsNotImplemented(err), "expected snapshots not to be supported by the fake driver, got %v", err)
+	_, err = service.Snapshot(ctx, "unknown", "")
+	assert.Check(t, errdefs.IsNotFound(err), "expected a not found error, got %v", err)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"io/ioutil"
 	"os"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/service/opts"
@@ -233,6 +236,85 @@ func TestServicePrune(t *testing.T) {
 	assert.Assert(t, is.Equal(pr.VolumesDeleted[0], "test"))
 }
 
+func TestServicePruneUntil(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver(volume.DefaultDriverName), volume.DefaultDriverName))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := service.Create(ctx, "unused", volume.DefaultDriverName)
+	assert.Assert(t, err)
+	v, err := service.Create(ctx, "released", volume.DefaultDriverName, opts.WithCreateReference(t.Name()))
+	assert.Assert(t, err)
+	service.vs.setLastUsed("unused", time.Now().Add(-2*time.Hour))
+	service.vs.setLastUsed("released", time.Now().Add(-2*time.Hour))
+	assert.Assert(t, service.Release(ctx, v.Name, t.Name()))
+
+	_, err = service.Prune(ctx, filters.NewArgs(filters.Arg("until", "invalid")))
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+
+	pr, err := service.Prune(ctx, filters.NewArgs(filters.Arg("until", "3h")))
+	assert.Assert(t, err)
+	assert.Check(t, is.Len(pr.VolumesDeleted, 0))
+
+	pr, err = service.Prune(ctx, filters.NewArgs(filters.Arg("until", "1h")))
+	assert.Assert(t, err)
+	assert.Check(t, is.DeepEqual(pr.VolumesDeleted, []string{"unused"}), "the release of a volume is a use of the volume")
+
+	pr, err = service.Prune(ctx, filters.NewArgs())
+	assert.Assert(t, err)
+	assert.Check(t, is.DeepEqual(pr.VolumesDeleted, []string{"released"}))
+}
+
+func TestServicePruneLabelSet(t *testing.T) {
+	t.Parallel()
+
+	ds := volumedrivers.NewStore(nil)
+	assert.Assert(t, ds.Register(testutils.NewFakeDriver(volume.DefaultDriverName), volume.DefaultDriverName))
+
+	service, cleanup := newTestService(t, ds)
+	defer cleanup()
+	ctx := context.Background()
+
+	create := func() {
+		for name, labels := range map[string]map[string]string{
+			"dev":     {"env": "dev"},
+			"test":    {"env": "test", "team": "a"},
+			"prod":    {"env": "prod", "team": "a"},
+			"nolabel": nil,
+		} {
+			_, err := service.Create(ctx, name, volume.DefaultDriverName, opts.WithCreateLabels(labels))
+			assert.Assert(t, err)
+		}
+	}
+	prune := func(args ...filters.KeyValuePair) []string {
+		pr, err := service.Prune(ctx, filters.NewArgs(args...))
+		assert.Assert(t, err)
+		sort.Strings(pr.VolumesDeleted)
+		return pr.VolumesDeleted
+	}
+
+	create()
+	assert.Check(t, is.DeepEqual(prune(filters.Arg("label", "env in (dev, test)")), []string{"dev", "test"}))
+	assert.Check(t, is.DeepEqual(prune(), []string{"nolabel", "prod"}))
+
+	create()
+	assert.Check(t, is.DeepEqual(prune(filters.Arg("label", "env notin (prod)")), []string{"dev", "nolabel", "test"}))
+	assert.Check(t, is.DeepEqual(prune(), []string{"prod"}))
+
+	create()
+	assert.Check(t, is.DeepEqual(prune(filters.Arg("label", "env notin (dev)"), filters.Arg("label", "team=a")), []string{"prod", "test"}))
+	assert.Check(t, is.DeepEqual(prune(filters.Arg("label", "env in (dev)"), filters.Arg("label!", "team")), []string{"dev"}))
+	prune()
+
+	_, err := service.Prune(ctx, filters.NewArgs(filters.Arg("label", "env in (dev")))
+	assert.Check(t, errdefs.IsInvalidParameter(err), err)
+}
+
 func newTestService(t *testing.T, ds *volumedrivers.Store) (*VolumesService, func()) {
 	t.Helper()
 
@@ -241,7 +323,7 @@ func newTestService(t *testing.T, ds *volumedrivers.Store) (*VolumesService, fun
 
 	store, err := NewStore(dir, ds)
 	assert.Assert(t, err)
-	s := &VolumesService{vs: store, eventLogger: dummyEventLogger{}}
+	s := &VolumesService{vs: store, eventLogger: dummyEventLogger{}, idMapping: &idtools.IdentityMapping{}}
 	return s, func() {
 		assert.Check(t, s.Shutdown())
 		assert.Check(t, os.RemoveAll(dir))
@@ -611,10 +611,11 @@ func (s *VolumeStore) create(ctx context.Context, name, driverName string, opts,
 	s.globalLock.Unlock()
 
 	metadata := volumeMetadata{
-		Name:    name,
-		Driver:  vd.Name(),
-		Labels:  labels,
-		Options: opts,
+		Name:     name,
+		Driver:   vd.Name(),
+		Labels:   labels,
+		Options:  opts,
+		LastUsed: time.Now().UTC(),
 	}
 
 	if err := s.setMeta(name, metadata); err != nil {
@@ -793,7 +794,11 @@ func (s *VolumeStore) Remove(ctx context.Context, v volume.Volume, rmOpts ...opt
 	logrus.Debugf("Removing volume reference: driver %s, name %s", v.DriverName(), name)
 	vol := unwrapVolume(v)
 
-	err = vd.Remove(vol)
+	if dd, ok := vd.(volume.DetachDriver); ok && cfg.Detach {
+		err = dd.RemoveDetached(vol, cfg.Kill)
+	} else {
+		err = vd.Remove(vol)
+	}
 	if err != nil {
 		err = &OpErr{Err: err, Name: name, Op: "remove"}
 	}
@@ -826,11 +831,44 @@ func (s *VolumeStore) Release(ctx context.Context, name string, ref string) erro
 	}
 
 	if s.refs[name] != nil {
-		delete(s.refs[name], ref)
+		if _, ok := s.refs[name][ref]; ok {
+			delete(s.refs[name], ref)
+			if _, exists := s.names[name]; exists {
+				s.setLastUsed(name, time.Now().UTC())
+			}
+		}
 	}
 	return nil
 }
 
+// setLastUsed records the time the volume was last used.
+// Errors are only logged, as they must not prevent the release of the volume.
+func (s *VolumeStore) setLastUsed(name string, t time.Time) {
+	meta, err := s.getMeta(name)
+	if err == nil && meta.Name != "" {
+		meta.LastUsed = t
+		err = s.setMeta(name, meta)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("volume", name).Warn("Error recording the last use of the volume")
+	}
+}
+
+// LastUsed returns the time the volume was last used, which is the time it was
+// created for the volumes which have not been used since then.
+func (s *VolumeStore) LastUsed(v volume.Volume) (time.Time, error) {
+	meta, err := s.getMeta(v.Name())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !meta.LastUsed.IsZero() {
+		return meta.LastUsed, nil
+	}
+	// the volumes created by older versions of the daemon have no last
+	// used time.
+	return v.CreatedAt()
+}
+
 // CountReferences gives a count of all references for a given volume.
 func (s *VolumeStore) CountReferences(v volume.Volume) int {
 	name := normalizeVolumeName(v.Name())
@@ -856,3 +894,88 @@ func unwrapVolume(v volume.Volume) volume.Volume {
 func (s *VolumeStore) Shutdown() error {
 	return s.db.Close()
 }
+
+// snapshotVolume returns the volume if its driver supports snapshots. It is
+// expected that callers of this function hold the lock of the volume.
+func (s *VolumeStore) snapshotVolume(ctx context.Context, v volume.Volume, op string) (volume.SnapshotVolume, error) {
+	name := v.Name()
+	v, err := s.getVolume(ctx, name, v.DriverName())
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: op}
+	}
+	sv, ok := unwrapVolume(v).(volume.SnapshotVolume)
+	if !ok {
+		return nil, &OpErr{Err: errdefs.NotImplemented(errors.Errorf("volume driver %s does not support snapshots", v.DriverName())), Name: name, Op: op}
+	}
+	return sv, nil
+}
+
+// Snapshot takes a snapshot of the data of the volume
+func (s *VolumeStore) Snapshot(ctx context.Context, v volume.Volume, snapshot string) (volume.Snapshot, error) {
+	name := v.Name()
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotVolume(ctx, v, "snapshot")
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	snap, err := sv.Snapshot(snapshot)
+	if err != nil {
+		return volume.Snapshot{}, &OpErr{Err: err, Name: name, Op: "snapshot"}
+	}
+	return snap, nil
+}
+
+// Snapshots lists the snapshots of the volume
+func (s *VolumeStore) Snapshots(ctx context.Context, v volume.Volume) ([]volume.Snapshot, error) {
+	name := v.Name()
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotVolume(ctx, v, "list snapshots")
+	if err != nil {
+		return nil, err
+	}
+	ls, err := sv.Snapshots()
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "list snapshots"}
+	}
+	return ls, nil
+}
+
+// RestoreSnapshot replaces the data of the volume with the data of the
+// snapshot. A volume is not restored if it has any refs
+func (s *VolumeStore) RestoreSnapshot(ctx context.Context, v volume.Volume, snapshot string) error {
+	name := v.Name()
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	if s.hasRef(name) {
+		return &OpErr{Err: errVolumeInUse, Name: name, Op: "restore snapshot", Refs: s.getRefs(name)}
+	}
+	sv, err := s.snapshotVolume(ctx, v, "restore snapshot")
+	if err != nil {
+		return err
+	}
+	if err := sv.RestoreSnapshot(snapshot); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "restore snapshot"}
+	}
+	return nil
+}
+
+// RemoveSnapshot removes a snapshot of the volume
+func (s *VolumeStore) RemoveSnapshot(ctx context.Context, v volume.Volume, snapshot string) error {
+	name := v.Name()
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotVolume(ctx, v, "remove snapshot")
+	if err != nil {
+		return err
+	}
+	if err := sv.RemoveSnapshot(snapshot); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "remove snapshot"}
+	}
+	return nil
+}
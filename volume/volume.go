@@ -32,6 +32,16 @@ type Driver interface {
 	Scope() string
 }
 
+// DetachDriver wraps a Driver which can remove the volumes which are still
+// mounted, as when the processes using them did not release them.
+type DetachDriver interface {
+	// RemoveDetached deletes the volume after lazily unmounting it and the
+	// mounts of it leaked to other mount namespaces. The processes holding
+	// the filesystem of the volume busy are killed if kill is set.
+	RemoveDetached(vol Volume, kill bool) error
+	Driver
+}
+
 // Capability defines a set of capabilities that a driver is able to handle.
 type Capability struct {
 	// Scope is the scope of the driver, `global` or `local`
@@ -67,3 +77,28 @@ type DetailedVolume interface {
 	Scope() string
 	Volume
 }
+
+// Snapshot describes a snapshot of the data of a volume.
+type Snapshot struct {
+	// Name is the name of the snapshot, unique for the volume
+	Name string
+	// CreatedAt is the time the snapshot was taken
+	CreatedAt time.Time
+	// Method is the mechanism used by the driver to take the snapshot
+	Method string
+}
+
+// SnapshotVolume wraps a Volume whose data can be snapshotted, and restored
+// from these snapshots, by its driver.
+type SnapshotVolume interface {
+	// Snapshot takes a snapshot of the data of the volume with the given name.
+	Snapshot(name string) (Snapshot, error)
+	// Snapshots lists the snapshots of the volume.
+	Snapshots() ([]Snapshot, error)
+	// RestoreSnapshot replaces the data of the volume with the data of the
+	// snapshot. The volume must not be in use.
+	RestoreSnapshot(name string) error
+	// RemoveSnapshot removes a snapshot of the volume.
+	RemoveSnapshot(name string) error
+	Volume
+}